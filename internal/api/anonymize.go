@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const anonymizedValue = "***"
+
+// endpoints that are not available in anonymized mode, since they
+// return or generate data that can't be anonymized.
+var anonymizeBlockedPaths = []string{
+	"/v3/config/export",
+	"/v3/auth/signurl/",
+	"/v3/paths/capture/get/",
+}
+
+// keys whose values are entirely masked, since they contain addresses
+// of clients and of the server, user names, or query parameters, that can contain credentials.
+var anonymizeMaskedKeys = map[string]struct{}{
+	"remoteAddr":      {},
+	"remoteCandidate": {},
+	"localCandidate":  {},
+	"user":            {},
+	"query":           {},
+}
+
+// keys whose values contain URLs of sources.
+var anonymizeSourceKeys = map[string]struct{}{
+	"source":         {},
+	"sourceRedirect": {},
+	"fallback":       {},
+}
+
+var anonymizeURLCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]+@`)
+
+// keys whose values are secrets, that are not matched by anonymizeIsCredentialKey.
+var anonymizeSecretKeys = map[string]struct{}{
+	"recordS3SecretAccessKey": {},
+}
+
+func anonymizeIsCredentialKey(key string) bool {
+	if _, ok := anonymizeSecretKeys[key]; ok {
+		return true
+	}
+
+	key = strings.ToLower(key)
+	return strings.HasSuffix(key, "pass") ||
+		strings.HasSuffix(key, "password") ||
+		strings.HasSuffix(key, "passphrase") ||
+		strings.HasSuffix(key, "secret")
+}
+
+func anonymizeString(key string, v string) string {
+	if v == "" {
+		return v
+	}
+
+	if _, ok := anonymizeMaskedKeys[key]; ok || anonymizeIsCredentialKey(key) {
+		return anonymizedValue
+	}
+
+	if _, ok := anonymizeSourceKeys[key]; ok {
+		if i := strings.Index(v, "://"); i >= 0 {
+			return v[:i+3] + anonymizedValue
+		}
+	}
+
+	// credentials can be embedded into URLs placed anywhere, like in commands
+	return anonymizeURLCredentials.ReplaceAllString(v, "${1}"+anonymizedValue+"@")
+}
+
+// anonymize removes credentials, source URLs and client IPs from a decoded JSON value.
+func anonymize(key string, v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		for k, el := range tv {
+			tv[k] = anonymize(k, el)
+		}
+		return tv
+
+	case []interface{}:
+		for i, el := range tv {
+			tv[i] = anonymize(key, el)
+		}
+		return tv
+
+	case string:
+		return anonymizeString(key, tv)
+
+	default:
+		return v
+	}
+}
+
+type anonymizeWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *anonymizeWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *anonymizeWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+func (w *anonymizeWriter) flush() error {
+	body := w.buf.Bytes()
+
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && len(body) != 0 {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+
+		var v interface{}
+		err := dec.Decode(&v)
+		if err != nil {
+			return err
+		}
+
+		body, err = json.Marshal(anonymize("", v))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := w.ResponseWriter.Write(body)
+	return err
+}
+
+func (a *API) middlewareAnonymize(ctx *gin.Context) {
+	if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+		a.writeError(ctx, http.StatusForbidden, fmt.Errorf("the API is in read-only mode"))
+		ctx.Abort()
+		return
+	}
+
+	for _, p := range anonymizeBlockedPaths {
+		if strings.HasPrefix(ctx.Request.URL.Path, p) {
+			a.writeError(ctx, http.StatusForbidden, fmt.Errorf("endpoint is not available in anonymized mode"))
+			ctx.Abort()
+			return
+		}
+	}
+
+	w := &anonymizeWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = w
+
+	ctx.Next()
+
+	ctx.Writer = w.ResponseWriter
+
+	err := w.flush()
+	if err != nil {
+		a.Log(logger.Error, "unable to anonymize response: %v", err)
+	}
+}
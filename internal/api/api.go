@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -19,16 +20,27 @@ import (
 
 	"github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/conf/yaml"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
+	"github.com/bluenviron/mediamtx/internal/ptz"
 	"github.com/bluenviron/mediamtx/internal/recordstore"
 	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
+	"github.com/bluenviron/mediamtx/internal/rtpcapture"
 	"github.com/bluenviron/mediamtx/internal/servers/hls"
 	"github.com/bluenviron/mediamtx/internal/servers/rtmp"
 	"github.com/bluenviron/mediamtx/internal/servers/rtsp"
 	"github.com/bluenviron/mediamtx/internal/servers/srt"
 	"github.com/bluenviron/mediamtx/internal/servers/webrtc"
+	"github.com/bluenviron/mediamtx/internal/subtitles"
+)
+
+const (
+	defaultDrainTimeout        = 30 * time.Second
+	defaultConformanceDuration = 10 * time.Second
+	maxConformanceDuration     = 60 * time.Second
+	tracksSuffix               = "/tracks"
 )
 
 func interfaceIsEmpty(i interface{}) bool {
@@ -46,6 +58,8 @@ func sortedKeys(paths map[string]*conf.Path) []string {
 	return ret
 }
 
+var errServerDisabled = errors.New("server is disabled")
+
 func paramName(ctx *gin.Context) (string, bool) {
 	name := ctx.Param("name")
 
@@ -81,6 +95,16 @@ func recordingsOfPath(
 type PathManager interface {
 	APIPathsList() (*defs.APIPathList, error)
 	APIPathsGet(string) (*defs.APIPath, error)
+	APIPathsGetTracks(string) (*defs.APIPathTrackList, error)
+	APIPathsSubtitlesAdd(string, []subtitles.Cue) error
+	APIPathsDrain(string, time.Duration) error
+	APIPathsUndrain(string) error
+	APIPathsPTZGet(string) (*defs.APIPathPTZ, error)
+	APIPathsPTZTourStart(string) error
+	APIPathsPTZTourStop(string) error
+	APIPathsPTZGotoPreset(string, string) error
+	APIPathsCaptureGet(string) (*rtpcapture.Buffer, error)
+	APIPathsConformanceGet(string, time.Duration) (*defs.APIPathConformance, error)
 }
 
 // HLSServer contains methods used by the API and Metrics server.
@@ -89,6 +113,16 @@ type HLSServer interface {
 	APIMuxersGet(string) (*defs.APIHLSMuxer, error)
 }
 
+// BandwidthAccountant contains methods used by the API and Metrics server.
+type BandwidthAccountant interface {
+	APIUsersList() *defs.APIBandwidthUserList
+}
+
+// Coordinator contains methods used by the API.
+type Coordinator interface {
+	APIClusterPathsList() (*defs.APIClusterPathList, error)
+}
+
 // RTSPServer contains methods used by the API and Metrics server.
 type RTSPServer interface {
 	APIConnsList() (*defs.APIRTSPConnsList, error)
@@ -96,6 +130,7 @@ type RTSPServer interface {
 	APISessionsList() (*defs.APIRTSPSessionList, error)
 	APISessionsGet(uuid.UUID) (*defs.APIRTSPSession, error)
 	APISessionsKick(uuid.UUID) error
+	APITransportStats() (*defs.APIRTSPTransportStats, error)
 }
 
 // RTMPServer contains methods used by the API and Metrics server.
@@ -103,13 +138,18 @@ type RTMPServer interface {
 	APIConnsList() (*defs.APIRTMPConnList, error)
 	APIConnsGet(uuid.UUID) (*defs.APIRTMPConn, error)
 	APIConnsKick(uuid.UUID) error
+	APIConnsPause(uuid.UUID) error
+	APIConnsResume(uuid.UUID) error
 }
 
 // SRTServer contains methods used by the API and Metrics server.
 type SRTServer interface {
 	APIConnsList() (*defs.APISRTConnList, error)
 	APIConnsGet(uuid.UUID) (*defs.APISRTConn, error)
+	HandshakesRejected() uint64
 	APIConnsKick(uuid.UUID) error
+	APIConnsPause(uuid.UUID) error
+	APIConnsResume(uuid.UUID) error
 }
 
 // WebRTCServer contains methods used by the API and Metrics server.
@@ -117,6 +157,8 @@ type WebRTCServer interface {
 	APISessionsList() (*defs.APIWebRTCSessionList, error)
 	APISessionsGet(uuid.UUID) (*defs.APIWebRTCSession, error)
 	APISessionsKick(uuid.UUID) error
+	APISessionsPause(uuid.UUID) error
+	APISessionsResume(uuid.UUID) error
 }
 
 type apiAuthManager interface {
@@ -130,24 +172,31 @@ type apiParent interface {
 
 // API is an API server.
 type API struct {
-	Address        string
-	Encryption     bool
-	ServerKey      string
-	ServerCert     string
-	AllowOrigin    string
-	TrustedProxies conf.IPNetworks
-	ReadTimeout    conf.StringDuration
-	Conf           *conf.Conf
-	AuthManager    apiAuthManager
-	PathManager    PathManager
-	RTSPServer     RTSPServer
-	RTSPSServer    RTSPServer
-	RTMPServer     RTMPServer
-	RTMPSServer    RTMPServer
-	HLSServer      HLSServer
-	WebRTCServer   WebRTCServer
-	SRTServer      SRTServer
-	Parent         apiParent
+	Address         string
+	Encryption      bool
+	ServerKey       string
+	ServerCert      string
+	TLSOptions      conf.TLSOptions
+	ServerHeader    string
+	ResponseHeaders conf.HTTPResponseHeaders
+	AllowOrigin     string
+	TrustedProxies  conf.IPNetworks
+	Anonymize       bool
+	ReadTimeout     conf.StringDuration
+	RequestLimiter  *httpp.RequestLimiter
+	Conf            *conf.Conf
+	AuthManager     apiAuthManager
+	PathManager     PathManager
+	Bandwidth       BandwidthAccountant
+	RTSPServer      RTSPServer
+	RTSPSServer     RTSPServer
+	RTMPServer      RTMPServer
+	RTMPSServer     RTMPServer
+	HLSServer       HLSServer
+	WebRTCServer    WebRTCServer
+	SRTServer       SRTServer
+	Coordinator     Coordinator
+	Parent          apiParent
 
 	httpServer *httpp.WrappedServer
 	mutex      sync.RWMutex
@@ -158,8 +207,17 @@ func (a *API) Initialize() error {
 	router := gin.New()
 	router.SetTrustedProxies(a.TrustedProxies.ToTrustedProxies()) //nolint:errcheck
 
-	router.NoRoute(a.middlewareOrigin, a.middlewareAuth)
-	group := router.Group("/", a.middlewareOrigin, a.middlewareAuth)
+	if a.RequestLimiter != nil {
+		router.Use(a.RequestLimiter.Handler("api"))
+	}
+
+	middlewares := []gin.HandlerFunc{a.middlewareOrigin, a.middlewareAuth}
+	if a.Anonymize {
+		middlewares = append(middlewares, a.middlewareAnonymize)
+	}
+
+	router.NoRoute(middlewares...)
+	group := router.Group("/", middlewares...)
 
 	group.GET("/v3/config/global/get", a.onConfigGlobalGet)
 	group.PATCH("/v3/config/global/patch", a.onConfigGlobalPatch)
@@ -167,76 +225,97 @@ func (a *API) Initialize() error {
 	group.GET("/v3/config/pathdefaults/get", a.onConfigPathDefaultsGet)
 	group.PATCH("/v3/config/pathdefaults/patch", a.onConfigPathDefaultsPatch)
 
+	group.GET("/v3/config/export", a.onConfigExport)
+
 	group.GET("/v3/config/paths/list", a.onConfigPathsList)
 	group.GET("/v3/config/paths/get/*name", a.onConfigPathsGet)
 	group.POST("/v3/config/paths/add/*name", a.onConfigPathsAdd)
 	group.PATCH("/v3/config/paths/patch/*name", a.onConfigPathsPatch)
 	group.POST("/v3/config/paths/replace/*name", a.onConfigPathsReplace)
 	group.DELETE("/v3/config/paths/delete/*name", a.onConfigPathsDelete)
+	group.POST("/v3/config/paths/import", a.onConfigPathsImport)
 
 	group.GET("/v3/paths/list", a.onPathsList)
 	group.GET("/v3/paths/get/*name", a.onPathsGet)
-
-	if !interfaceIsEmpty(a.HLSServer) {
-		group.GET("/v3/hlsmuxers/list", a.onHLSMuxersList)
-		group.GET("/v3/hlsmuxers/get/*name", a.onHLSMuxersGet)
-	}
-
-	if !interfaceIsEmpty(a.RTSPServer) {
-		group.GET("/v3/rtspconns/list", a.onRTSPConnsList)
-		group.GET("/v3/rtspconns/get/:id", a.onRTSPConnsGet)
-		group.GET("/v3/rtspsessions/list", a.onRTSPSessionsList)
-		group.GET("/v3/rtspsessions/get/:id", a.onRTSPSessionsGet)
-		group.POST("/v3/rtspsessions/kick/:id", a.onRTSPSessionsKick)
-	}
-
-	if !interfaceIsEmpty(a.RTSPSServer) {
-		group.GET("/v3/rtspsconns/list", a.onRTSPSConnsList)
-		group.GET("/v3/rtspsconns/get/:id", a.onRTSPSConnsGet)
-		group.GET("/v3/rtspssessions/list", a.onRTSPSSessionsList)
-		group.GET("/v3/rtspssessions/get/:id", a.onRTSPSSessionsGet)
-		group.POST("/v3/rtspssessions/kick/:id", a.onRTSPSSessionsKick)
-	}
-
-	if !interfaceIsEmpty(a.RTMPServer) {
-		group.GET("/v3/rtmpconns/list", a.onRTMPConnsList)
-		group.GET("/v3/rtmpconns/get/:id", a.onRTMPConnsGet)
-		group.POST("/v3/rtmpconns/kick/:id", a.onRTMPConnsKick)
-	}
-
-	if !interfaceIsEmpty(a.RTMPSServer) {
-		group.GET("/v3/rtmpsconns/list", a.onRTMPSConnsList)
-		group.GET("/v3/rtmpsconns/get/:id", a.onRTMPSConnsGet)
-		group.POST("/v3/rtmpsconns/kick/:id", a.onRTMPSConnsKick)
-	}
-
-	if !interfaceIsEmpty(a.WebRTCServer) {
-		group.GET("/v3/webrtcsessions/list", a.onWebRTCSessionsList)
-		group.GET("/v3/webrtcsessions/get/:id", a.onWebRTCSessionsGet)
-		group.POST("/v3/webrtcsessions/kick/:id", a.onWebRTCSessionsKick)
-	}
-
-	if !interfaceIsEmpty(a.SRTServer) {
-		group.GET("/v3/srtconns/list", a.onSRTConnsList)
-		group.GET("/v3/srtconns/get/:id", a.onSRTConnsGet)
-		group.POST("/v3/srtconns/kick/:id", a.onSRTConnsKick)
+	group.POST("/v3/paths/subtitles/add/*name", a.onPathsSubtitlesAdd)
+	group.POST("/v3/paths/drain/*name", a.onPathsDrain)
+	group.POST("/v3/paths/undrain/*name", a.onPathsUndrain)
+	group.GET("/v3/paths/ptz/get/*name", a.onPathsPTZGet)
+	group.POST("/v3/paths/ptz/tour/start/*name", a.onPathsPTZTourStart)
+	group.POST("/v3/paths/ptz/tour/stop/*name", a.onPathsPTZTourStop)
+	group.POST("/v3/paths/ptz/gotopreset/*name", a.onPathsPTZGotoPreset)
+	group.GET("/v3/paths/capture/get/*name", a.onPathsCaptureGet)
+	group.GET("/v3/paths/conformance/get/*name", a.onPathsConformanceGet)
+
+	group.GET("/v3/hlsmuxers/list", a.onHLSMuxersList)
+	group.GET("/v3/hlsmuxers/get/*name", a.onHLSMuxersGet)
+
+	group.GET("/v3/rtspconns/list", a.onRTSPConnsList)
+	group.GET("/v3/rtspconns/get/:id", a.onRTSPConnsGet)
+	group.GET("/v3/rtspsessions/list", a.onRTSPSessionsList)
+	group.GET("/v3/rtspsessions/get/:id", a.onRTSPSessionsGet)
+	group.POST("/v3/rtspsessions/kick/:id", a.onRTSPSessionsKick)
+	group.GET("/v3/rtspsessions/transportstats", a.onRTSPSessionsTransportStats)
+
+	group.GET("/v3/rtspsconns/list", a.onRTSPSConnsList)
+	group.GET("/v3/rtspsconns/get/:id", a.onRTSPSConnsGet)
+	group.GET("/v3/rtspssessions/list", a.onRTSPSSessionsList)
+	group.GET("/v3/rtspssessions/get/:id", a.onRTSPSSessionsGet)
+	group.POST("/v3/rtspssessions/kick/:id", a.onRTSPSSessionsKick)
+	group.GET("/v3/rtspssessions/transportstats", a.onRTSPSSessionsTransportStats)
+
+	group.GET("/v3/rtmpconns/list", a.onRTMPConnsList)
+	group.GET("/v3/rtmpconns/get/:id", a.onRTMPConnsGet)
+	group.POST("/v3/rtmpconns/kick/:id", a.onRTMPConnsKick)
+	group.POST("/v3/rtmpconns/pause/:id", a.onRTMPConnsPause)
+	group.POST("/v3/rtmpconns/resume/:id", a.onRTMPConnsResume)
+
+	group.GET("/v3/rtmpsconns/list", a.onRTMPSConnsList)
+	group.GET("/v3/rtmpsconns/get/:id", a.onRTMPSConnsGet)
+	group.POST("/v3/rtmpsconns/kick/:id", a.onRTMPSConnsKick)
+	group.POST("/v3/rtmpsconns/pause/:id", a.onRTMPSConnsPause)
+	group.POST("/v3/rtmpsconns/resume/:id", a.onRTMPSConnsResume)
+
+	group.GET("/v3/webrtcsessions/list", a.onWebRTCSessionsList)
+	group.GET("/v3/webrtcsessions/get/:id", a.onWebRTCSessionsGet)
+	group.POST("/v3/webrtcsessions/kick/:id", a.onWebRTCSessionsKick)
+	group.POST("/v3/webrtcsessions/pause/:id", a.onWebRTCSessionsPause)
+	group.POST("/v3/webrtcsessions/resume/:id", a.onWebRTCSessionsResume)
+
+	group.GET("/v3/srtconns/list", a.onSRTConnsList)
+	group.GET("/v3/srtconns/get/:id", a.onSRTConnsGet)
+	group.POST("/v3/srtconns/kick/:id", a.onSRTConnsKick)
+	group.POST("/v3/srtconns/pause/:id", a.onSRTConnsPause)
+	group.POST("/v3/srtconns/resume/:id", a.onSRTConnsResume)
+
+	if !interfaceIsEmpty(a.Coordinator) {
+		group.GET("/v3/cluster/paths/list", a.onClusterPathsList)
+	}
+
+	if !interfaceIsEmpty(a.Bandwidth) {
+		group.GET("/v3/bandwidth/users/list", a.onBandwidthUsersList)
 	}
 
 	group.GET("/v3/recordings/list", a.onRecordingsList)
 	group.GET("/v3/recordings/get/*name", a.onRecordingsGet)
 	group.DELETE("/v3/recordings/deletesegment", a.onRecordingDeleteSegment)
 
+	group.GET("/v3/auth/signurl/*name", a.onAuthSignURL)
+
 	network, address := restrictnetwork.Restrict("tcp", a.Address)
 
 	a.httpServer = &httpp.WrappedServer{
-		Network:     network,
-		Address:     address,
-		ReadTimeout: time.Duration(a.ReadTimeout),
-		Encryption:  a.Encryption,
-		ServerCert:  a.ServerCert,
-		ServerKey:   a.ServerKey,
-		Handler:     router,
-		Parent:      a,
+		Network:         network,
+		Address:         address,
+		ReadTimeout:     time.Duration(a.ReadTimeout),
+		Encryption:      a.Encryption,
+		ServerCert:      a.ServerCert,
+		ServerKey:       a.ServerKey,
+		TLSOptions:      a.TLSOptions,
+		ServerHeader:    a.ServerHeader,
+		ResponseHeaders: a.ResponseHeaders,
+		Handler:         router,
+		Parent:          a,
 	}
 	err := a.httpServer.Initialize()
 	if err != nil {
@@ -254,6 +333,70 @@ func (a *API) Close() {
 	a.httpServer.Close()
 }
 
+// SetRTSPServer is called by core.
+func (a *API) SetRTSPServer(s RTSPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTSPServer = s
+}
+
+// SetRTSPSServer is called by core.
+func (a *API) SetRTSPSServer(s RTSPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTSPSServer = s
+}
+
+// SetRTMPServer is called by core.
+func (a *API) SetRTMPServer(s RTMPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTMPServer = s
+}
+
+// SetRTMPSServer is called by core.
+func (a *API) SetRTMPSServer(s RTMPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTMPSServer = s
+}
+
+// SetHLSServer is called by core.
+func (a *API) SetHLSServer(s HLSServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.HLSServer = s
+}
+
+// SetWebRTCServer is called by core.
+func (a *API) SetWebRTCServer(s WebRTCServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.WebRTCServer = s
+}
+
+// SetSRTServer is called by core.
+func (a *API) SetSRTServer(s SRTServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.SRTServer = s
+}
+
+// protocolServer returns a protocol server, that can be enabled and disabled at runtime.
+// When the server is disabled, an error is written.
+func protocolServer[T any](a *API, ctx *gin.Context, s *T) (T, bool) {
+	a.mutex.RLock()
+	srv := *s
+	a.mutex.RUnlock()
+
+	if interfaceIsEmpty(srv) {
+		a.writeError(ctx, http.StatusNotFound, errServerDisabled)
+		return srv, false
+	}
+
+	return srv, true
+}
+
 // Log implements logger.Writer.
 func (a *API) Log(level logger.Level, format string, args ...interface{}) {
 	a.Parent.Log(level, "[API] "+format, args...)
@@ -269,6 +412,18 @@ func (a *API) writeError(ctx *gin.Context, status int, err error) {
 	})
 }
 
+// writeDryRun reports changes that would be applied to the configuration, without applying them.
+func (a *API) writeDryRun(ctx *gin.Context, newConf *conf.Conf) {
+	changes := configDiff(a.Conf, newConf)
+	if changes == nil {
+		changes = []*defs.APIConfigChange{}
+	}
+
+	ctx.JSON(http.StatusOK, &defs.APIConfigDryRun{
+		Changes: changes,
+	})
+}
+
 func (a *API) middlewareOrigin(ctx *gin.Context) {
 	ctx.Writer.Header().Set("Access-Control-Allow-Origin", a.AllowOrigin)
 	ctx.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
@@ -287,11 +442,12 @@ func (a *API) middlewareAuth(ctx *gin.Context) {
 	user, pass, hasCredentials := ctx.Request.BasicAuth()
 
 	err := a.AuthManager.Authenticate(&auth.Request{
-		User:   user,
-		Pass:   pass,
-		Query:  ctx.Request.URL.RawQuery,
-		IP:     net.ParseIP(ctx.ClientIP()),
-		Action: conf.AuthActionAPI,
+		User:    user,
+		Pass:    pass,
+		Query:   ctx.Request.URL.RawQuery,
+		IP:      net.ParseIP(ctx.ClientIP()),
+		LocalIP: httpp.LocalIP(ctx.Request),
+		Action:  conf.AuthActionAPI,
 	})
 	if err != nil {
 		if !hasCredentials {
@@ -308,6 +464,27 @@ func (a *API) middlewareAuth(ctx *gin.Context) {
 	}
 }
 
+// canAccessRecordings checks whether the user of the request
+// can access recordings of a path, with the same rules of the playback server.
+func (a *API) canAccessRecordings(ctx *gin.Context, c *conf.Conf, pathName string) bool {
+	if !c.AuthPlaybackRequireRead {
+		return true
+	}
+
+	user, pass, _ := ctx.Request.BasicAuth()
+
+	err := a.AuthManager.Authenticate(&auth.Request{
+		User:    user,
+		Pass:    pass,
+		Query:   ctx.Request.URL.RawQuery,
+		IP:      net.ParseIP(ctx.ClientIP()),
+		LocalIP: httpp.LocalIP(ctx.Request),
+		Action:  conf.AuthActionPlayback,
+		Path:    pathName,
+	})
+	return err == nil
+}
+
 func (a *API) onConfigGlobalGet(ctx *gin.Context) {
 	a.mutex.RLock()
 	c := a.Conf
@@ -317,8 +494,14 @@ func (a *API) onConfigGlobalGet(ctx *gin.Context) {
 }
 
 func (a *API) onConfigGlobalPatch(ctx *gin.Context) {
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	var c conf.OptionalGlobal
-	err := json.NewDecoder(ctx.Request.Body).Decode(&c)
+	err = json.NewDecoder(ctx.Request.Body).Decode(&c)
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
@@ -337,6 +520,11 @@ func (a *API) onConfigGlobalPatch(ctx *gin.Context) {
 		return
 	}
 
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
 	a.Conf = newConf
 
 	// since reloading the configuration can cause the shutdown of the API,
@@ -355,8 +543,14 @@ func (a *API) onConfigPathDefaultsGet(ctx *gin.Context) {
 }
 
 func (a *API) onConfigPathDefaultsPatch(ctx *gin.Context) {
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	var p conf.OptionalPath
-	err := json.NewDecoder(ctx.Request.Body).Decode(&p)
+	err = json.NewDecoder(ctx.Request.Body).Decode(&p)
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
@@ -375,12 +569,40 @@ func (a *API) onConfigPathDefaultsPatch(ctx *gin.Context) {
 		return
 	}
 
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
 	a.Conf = newConf
 	a.Parent.APIConfigSet(newConf)
 
 	ctx.Status(http.StatusOK)
 }
 
+func (a *API) onConfigExport(ctx *gin.Context) {
+	a.mutex.RLock()
+	c := a.Conf
+	a.mutex.RUnlock()
+
+	switch ctx.Query("format") {
+	case "", "json":
+		ctx.JSON(http.StatusOK, c)
+
+	case "yaml":
+		byts, err := yaml.Dump(c)
+		if err != nil {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		ctx.Data(http.StatusOK, "application/yaml", byts)
+
+	default:
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid format"))
+	}
+}
+
 func (a *API) onConfigPathsList(ctx *gin.Context) {
 	a.mutex.RLock()
 	c := a.Conf
@@ -426,6 +648,12 @@ func (a *API) onConfigPathsGet(ctx *gin.Context) {
 }
 
 func (a *API) onConfigPathsAdd(ctx *gin.Context) { //nolint:dupl
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	confName, ok := paramName(ctx)
 	if !ok {
 		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
@@ -433,7 +661,7 @@ func (a *API) onConfigPathsAdd(ctx *gin.Context) { //nolint:dupl
 	}
 
 	var p conf.OptionalPath
-	err := json.NewDecoder(ctx.Request.Body).Decode(&p)
+	err = json.NewDecoder(ctx.Request.Body).Decode(&p)
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
@@ -456,6 +684,11 @@ func (a *API) onConfigPathsAdd(ctx *gin.Context) { //nolint:dupl
 		return
 	}
 
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
 	a.Conf = newConf
 	a.Parent.APIConfigSet(newConf)
 
@@ -463,6 +696,12 @@ func (a *API) onConfigPathsAdd(ctx *gin.Context) { //nolint:dupl
 }
 
 func (a *API) onConfigPathsPatch(ctx *gin.Context) { //nolint:dupl
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	confName, ok := paramName(ctx)
 	if !ok {
 		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
@@ -470,7 +709,7 @@ func (a *API) onConfigPathsPatch(ctx *gin.Context) { //nolint:dupl
 	}
 
 	var p conf.OptionalPath
-	err := json.NewDecoder(ctx.Request.Body).Decode(&p)
+	err = json.NewDecoder(ctx.Request.Body).Decode(&p)
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
@@ -497,6 +736,11 @@ func (a *API) onConfigPathsPatch(ctx *gin.Context) { //nolint:dupl
 		return
 	}
 
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
 	a.Conf = newConf
 	a.Parent.APIConfigSet(newConf)
 
@@ -504,6 +748,12 @@ func (a *API) onConfigPathsPatch(ctx *gin.Context) { //nolint:dupl
 }
 
 func (a *API) onConfigPathsReplace(ctx *gin.Context) { //nolint:dupl
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	confName, ok := paramName(ctx)
 	if !ok {
 		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
@@ -511,7 +761,7 @@ func (a *API) onConfigPathsReplace(ctx *gin.Context) { //nolint:dupl
 	}
 
 	var p conf.OptionalPath
-	err := json.NewDecoder(ctx.Request.Body).Decode(&p)
+	err = json.NewDecoder(ctx.Request.Body).Decode(&p)
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
@@ -538,6 +788,11 @@ func (a *API) onConfigPathsReplace(ctx *gin.Context) { //nolint:dupl
 		return
 	}
 
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
 	a.Conf = newConf
 	a.Parent.APIConfigSet(newConf)
 
@@ -545,6 +800,12 @@ func (a *API) onConfigPathsReplace(ctx *gin.Context) { //nolint:dupl
 }
 
 func (a *API) onConfigPathsDelete(ctx *gin.Context) {
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	confName, ok := paramName(ctx)
 	if !ok {
 		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
@@ -556,7 +817,7 @@ func (a *API) onConfigPathsDelete(ctx *gin.Context) {
 
 	newConf := a.Conf.Clone()
 
-	err := newConf.RemovePath(confName)
+	err = newConf.RemovePath(confName)
 	if err != nil {
 		if errors.Is(err, conf.ErrPathNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -572,6 +833,66 @@ func (a *API) onConfigPathsDelete(ctx *gin.Context) {
 		return
 	}
 
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
+	a.Conf = newConf
+	a.Parent.APIConfigSet(newConf)
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) onConfigPathsImport(ctx *gin.Context) {
+	dryRun, err := queryDryRun(ctx)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var imp defs.APIPathConfImport
+
+	if strings.HasPrefix(ctx.ContentType(), "application/yaml") {
+		var byts []byte
+		byts, err = io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		err = yaml.Load(byts, &imp)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		err = json.NewDecoder(ctx.Request.Body).Decode(&imp)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	newConf := a.Conf.Clone()
+
+	newConf.ImportPaths(imp.Paths, imp.ReplaceAll)
+
+	// the batch is applied only if the whole resulting configuration is valid
+	err = newConf.Validate()
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if dryRun {
+		a.writeDryRun(ctx, newConf)
+		return
+	}
+
 	a.Conf = newConf
 	a.Parent.APIConfigSet(newConf)
 
@@ -585,39 +906,389 @@ func (a *API) onPathsList(ctx *gin.Context) {
 		return
 	}
 
-	data.ItemCount = len(data.Items)
-	pageCount, err := paginate(&data.Items, ctx.Query("itemsPerPage"), ctx.Query("page"))
+	data.ItemCount = len(data.Items)
+	pageCount, err := paginate(&data.Items, ctx.Query("itemsPerPage"), ctx.Query("page"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	data.PageCount = pageCount
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onPathsGet(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	// /v3/paths/get/{name}/tracks shares the route with /v3/paths/get/{name}.
+	// Paths whose name ends with /tracks can still be fetched when there's no path with the trimmed name.
+	if strings.HasSuffix(pathName, tracksSuffix) && len(pathName) > len(tracksSuffix) {
+		data, err := a.PathManager.APIPathsGetTracks(strings.TrimSuffix(pathName, tracksSuffix))
+		if err == nil {
+			ctx.JSON(http.StatusOK, data)
+			return
+		}
+
+		if !errors.Is(err, conf.ErrPathNotFound) {
+			var terr defs.PathNoOnePublishingError
+			if errors.As(err, &terr) {
+				a.writeError(ctx, http.StatusNotFound, err)
+			} else {
+				a.writeError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	data, err := a.PathManager.APIPathsGet(pathName)
+	if err != nil {
+		if errors.Is(err, conf.ErrPathNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onPathsSubtitlesAdd(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	var cues []subtitles.Cue
+
+	switch ctx.ContentType() {
+	case "text/vtt", "application/x-subrip":
+		buf, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		// timestamps are relative to the time of the request
+		cues, err = subtitles.Parse(buf, time.Now())
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+	default:
+		var in defs.APISubtitleCueList
+		err := json.NewDecoder(ctx.Request.Body).Decode(&in)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		if len(in.Cues) == 0 {
+			a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("no cues provided"))
+			return
+		}
+
+		now := time.Now()
+
+		for _, c := range in.Cues {
+			if c.Duration <= 0 {
+				a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid cue duration"))
+				return
+			}
+
+			start := now
+			if c.Start != nil {
+				start = *c.Start
+			}
+
+			cues = append(cues, subtitles.Cue{
+				Start: start,
+				End:   start.Add(time.Duration(c.Duration)),
+				Text:  c.Text,
+			})
+		}
+	}
+
+	err := a.PathManager.APIPathsSubtitlesAdd(pathName, cues)
+	if err != nil {
+		switch {
+		case errors.Is(err, conf.ErrPathNotFound):
+			a.writeError(ctx, http.StatusNotFound, err)
+		case errors.Is(err, subtitles.ErrDisabled):
+			a.writeError(ctx, http.StatusBadRequest, err)
+		default:
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) onPathsDrain(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	buf, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	timeout := defaultDrainTimeout
+
+	// body is optional
+	if len(buf) != 0 {
+		var in defs.APIPathDrainReq
+		err = json.Unmarshal(buf, &in)
+		if err != nil {
+			a.writeError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		if in.Timeout != nil {
+			if *in.Timeout < 0 {
+				a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid timeout"))
+				return
+			}
+			timeout = time.Duration(*in.Timeout)
+		}
+	}
+
+	err = a.PathManager.APIPathsDrain(pathName, timeout)
+	if err != nil {
+		if errors.Is(err, conf.ErrPathNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) onPathsUndrain(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	err := a.PathManager.APIPathsUndrain(pathName)
+	if err != nil {
+		if errors.Is(err, conf.ErrPathNotFound) {
+			a.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) writePTZError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, conf.ErrPathNotFound):
+		a.writeError(ctx, http.StatusNotFound, err)
+
+	case errors.Is(err, ptz.ErrDisabled):
+		a.writeError(ctx, http.StatusBadRequest, err)
+
+	default:
+		a.writeError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (a *API) onPathsPTZGet(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	data, err := a.PathManager.APIPathsPTZGet(pathName)
+	if err != nil {
+		a.writePTZError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onPathsPTZTourStart(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	err := a.PathManager.APIPathsPTZTourStart(pathName)
+	if err != nil {
+		a.writePTZError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) onPathsPTZTourStop(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	err := a.PathManager.APIPathsPTZTourStop(pathName)
+	if err != nil {
+		a.writePTZError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) onPathsPTZGotoPreset(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	var in defs.APIPathPTZGotoPresetReq
+	err := json.NewDecoder(ctx.Request.Body).Decode(&in)
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if in.Preset == "" {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("preset not provided"))
+		return
+	}
+
+	err = a.PathManager.APIPathsPTZGotoPreset(pathName, in.Preset)
+	if err != nil {
+		a.writePTZError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (a *API) onPathsCaptureGet(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	capture, err := a.PathManager.APIPathsCaptureGet(pathName)
+	if err != nil {
+		switch {
+		case errors.Is(err, conf.ErrPathNotFound):
+			a.writeError(ctx, http.StatusNotFound, err)
+
+		case errors.Is(err, rtpcapture.ErrDisabled):
+			a.writeError(ctx, http.StatusBadRequest, err)
+
+		default:
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	fname := strings.ReplaceAll(pathName, "/", "_") + "_" + time.Now().UTC().Format("20060102T150405Z") + ".pcap"
+
+	ctx.Header("Content-Type", "application/vnd.tcpdump.pcap")
+	ctx.Header("Content-Disposition", `attachment; filename="`+fname+`"`)
+	ctx.Status(http.StatusOK)
+
+	err = capture.WritePcap(ctx.Writer)
+	if err != nil {
+		a.Log(logger.Error, "unable to write capture: %v", err)
+	}
+}
+
+func (a *API) onPathsConformanceGet(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	duration := defaultConformanceDuration
+
+	if v := ctx.Query("duration"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxConformanceDuration {
+			a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid duration"))
+			return
+		}
+		duration = d
+	}
+
+	data, err := a.PathManager.APIPathsConformanceGet(pathName, duration)
 	if err != nil {
-		a.writeError(ctx, http.StatusBadRequest, err)
+		var terr defs.PathNoOnePublishingError
+		switch {
+		case errors.Is(err, conf.ErrPathNotFound), errors.As(err, &terr):
+			a.writeError(ctx, http.StatusNotFound, err)
+
+		default:
+			a.writeError(ctx, http.StatusInternalServerError, err)
+		}
 		return
 	}
-	data.PageCount = pageCount
 
 	ctx.JSON(http.StatusOK, data)
 }
 
-func (a *API) onPathsGet(ctx *gin.Context) {
-	pathName, ok := paramName(ctx)
-	if !ok {
-		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+func (a *API) setReaderPaused(
+	ctx *gin.Context,
+	setPaused func(uuid.UUID) error,
+	errNotFound error,
+	errNotReading error,
+) {
+	uuid, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.PathManager.APIPathsGet(pathName)
+	err = setPaused(uuid)
 	if err != nil {
-		if errors.Is(err, conf.ErrPathNotFound) {
+		switch {
+		case errors.Is(err, errNotFound):
 			a.writeError(ctx, http.StatusNotFound, err)
-		} else {
+		case errors.Is(err, errNotReading):
+			a.writeError(ctx, http.StatusBadRequest, err)
+		default:
 			a.writeError(ctx, http.StatusInternalServerError, err)
 		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, data)
+	ctx.Status(http.StatusOK)
 }
 
 func (a *API) onRTSPConnsList(ctx *gin.Context) {
-	data, err := a.RTSPServer.APIConnsList()
+	srv, ok := protocolServer(a, ctx, &a.RTSPServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APIConnsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -635,13 +1306,18 @@ func (a *API) onRTSPConnsList(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPConnsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.RTSPServer.APIConnsGet(uuid)
+	data, err := srv.APIConnsGet(uuid)
 	if err != nil {
 		if errors.Is(err, rtsp.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -655,7 +1331,12 @@ func (a *API) onRTSPConnsGet(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPSessionsList(ctx *gin.Context) {
-	data, err := a.RTSPServer.APISessionsList()
+	srv, ok := protocolServer(a, ctx, &a.RTSPServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APISessionsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -672,14 +1353,34 @@ func (a *API) onRTSPSessionsList(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, data)
 }
 
+func (a *API) onRTSPSessionsTransportStats(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APITransportStats()
+	if err != nil {
+		a.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
 func (a *API) onRTSPSessionsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.RTSPServer.APISessionsGet(uuid)
+	data, err := srv.APISessionsGet(uuid)
 	if err != nil {
 		if errors.Is(err, rtsp.ErrSessionNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -693,13 +1394,18 @@ func (a *API) onRTSPSessionsGet(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPSessionsKick(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = a.RTSPServer.APISessionsKick(uuid)
+	err = srv.APISessionsKick(uuid)
 	if err != nil {
 		if errors.Is(err, rtsp.ErrSessionNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -713,7 +1419,12 @@ func (a *API) onRTSPSessionsKick(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPSConnsList(ctx *gin.Context) {
-	data, err := a.RTSPSServer.APIConnsList()
+	srv, ok := protocolServer(a, ctx, &a.RTSPSServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APIConnsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -731,13 +1442,18 @@ func (a *API) onRTSPSConnsList(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPSConnsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPSServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.RTSPSServer.APIConnsGet(uuid)
+	data, err := srv.APIConnsGet(uuid)
 	if err != nil {
 		if errors.Is(err, rtsp.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -751,7 +1467,12 @@ func (a *API) onRTSPSConnsGet(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPSSessionsList(ctx *gin.Context) {
-	data, err := a.RTSPSServer.APISessionsList()
+	srv, ok := protocolServer(a, ctx, &a.RTSPSServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APISessionsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -768,14 +1489,34 @@ func (a *API) onRTSPSSessionsList(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, data)
 }
 
+func (a *API) onRTSPSSessionsTransportStats(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPSServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APITransportStats()
+	if err != nil {
+		a.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
 func (a *API) onRTSPSSessionsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPSServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.RTSPSServer.APISessionsGet(uuid)
+	data, err := srv.APISessionsGet(uuid)
 	if err != nil {
 		if errors.Is(err, rtsp.ErrSessionNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -789,13 +1530,18 @@ func (a *API) onRTSPSSessionsGet(ctx *gin.Context) {
 }
 
 func (a *API) onRTSPSSessionsKick(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTSPSServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = a.RTSPSServer.APISessionsKick(uuid)
+	err = srv.APISessionsKick(uuid)
 	if err != nil {
 		if errors.Is(err, rtsp.ErrSessionNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -809,7 +1555,12 @@ func (a *API) onRTSPSSessionsKick(ctx *gin.Context) {
 }
 
 func (a *API) onRTMPConnsList(ctx *gin.Context) {
-	data, err := a.RTMPServer.APIConnsList()
+	srv, ok := protocolServer(a, ctx, &a.RTMPServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APIConnsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -827,13 +1578,18 @@ func (a *API) onRTMPConnsList(ctx *gin.Context) {
 }
 
 func (a *API) onRTMPConnsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.RTMPServer.APIConnsGet(uuid)
+	data, err := srv.APIConnsGet(uuid)
 	if err != nil {
 		if errors.Is(err, rtmp.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -847,13 +1603,18 @@ func (a *API) onRTMPConnsGet(ctx *gin.Context) {
 }
 
 func (a *API) onRTMPConnsKick(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = a.RTMPServer.APIConnsKick(uuid)
+	err = srv.APIConnsKick(uuid)
 	if err != nil {
 		if errors.Is(err, rtmp.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -866,8 +1627,31 @@ func (a *API) onRTMPConnsKick(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (a *API) onRTMPConnsPause(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APIConnsPause, rtmp.ErrConnNotFound, rtmp.ErrConnNotReading)
+}
+
+func (a *API) onRTMPConnsResume(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APIConnsResume, rtmp.ErrConnNotFound, rtmp.ErrConnNotReading)
+}
+
 func (a *API) onRTMPSConnsList(ctx *gin.Context) {
-	data, err := a.RTMPSServer.APIConnsList()
+	srv, ok := protocolServer(a, ctx, &a.RTMPSServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APIConnsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -885,13 +1669,18 @@ func (a *API) onRTMPSConnsList(ctx *gin.Context) {
 }
 
 func (a *API) onRTMPSConnsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPSServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.RTMPSServer.APIConnsGet(uuid)
+	data, err := srv.APIConnsGet(uuid)
 	if err != nil {
 		if errors.Is(err, rtmp.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -905,13 +1694,18 @@ func (a *API) onRTMPSConnsGet(ctx *gin.Context) {
 }
 
 func (a *API) onRTMPSConnsKick(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPSServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = a.RTMPSServer.APIConnsKick(uuid)
+	err = srv.APIConnsKick(uuid)
 	if err != nil {
 		if errors.Is(err, rtmp.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -924,8 +1718,63 @@ func (a *API) onRTMPSConnsKick(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (a *API) onRTMPSConnsPause(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPSServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APIConnsPause, rtmp.ErrConnNotFound, rtmp.ErrConnNotReading)
+}
+
+func (a *API) onRTMPSConnsResume(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.RTMPSServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APIConnsResume, rtmp.ErrConnNotFound, rtmp.ErrConnNotReading)
+}
+
+func (a *API) onClusterPathsList(ctx *gin.Context) {
+	data, err := a.Coordinator.APIClusterPathsList()
+	if err != nil {
+		a.writeError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	data.ItemCount = len(data.Items)
+	pageCount, err := paginate(&data.Items, ctx.Query("itemsPerPage"), ctx.Query("page"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	data.PageCount = pageCount
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *API) onBandwidthUsersList(ctx *gin.Context) {
+	data := a.Bandwidth.APIUsersList()
+
+	data.ItemCount = len(data.Items)
+	pageCount, err := paginate(&data.Items, ctx.Query("itemsPerPage"), ctx.Query("page"))
+	if err != nil {
+		a.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	data.PageCount = pageCount
+
+	ctx.JSON(http.StatusOK, data)
+}
+
 func (a *API) onHLSMuxersList(ctx *gin.Context) {
-	data, err := a.HLSServer.APIMuxersList()
+	srv, ok := protocolServer(a, ctx, &a.HLSServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APIMuxersList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -943,13 +1792,18 @@ func (a *API) onHLSMuxersList(ctx *gin.Context) {
 }
 
 func (a *API) onHLSMuxersGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.HLSServer)
+	if !ok {
+		return
+	}
+
 	pathName, ok := paramName(ctx)
 	if !ok {
 		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
 		return
 	}
 
-	data, err := a.HLSServer.APIMuxersGet(pathName)
+	data, err := srv.APIMuxersGet(pathName)
 	if err != nil {
 		if errors.Is(err, hls.ErrMuxerNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -963,7 +1817,12 @@ func (a *API) onHLSMuxersGet(ctx *gin.Context) {
 }
 
 func (a *API) onWebRTCSessionsList(ctx *gin.Context) {
-	data, err := a.WebRTCServer.APISessionsList()
+	srv, ok := protocolServer(a, ctx, &a.WebRTCServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APISessionsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -981,13 +1840,18 @@ func (a *API) onWebRTCSessionsList(ctx *gin.Context) {
 }
 
 func (a *API) onWebRTCSessionsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.WebRTCServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.WebRTCServer.APISessionsGet(uuid)
+	data, err := srv.APISessionsGet(uuid)
 	if err != nil {
 		if errors.Is(err, webrtc.ErrSessionNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -1001,13 +1865,18 @@ func (a *API) onWebRTCSessionsGet(ctx *gin.Context) {
 }
 
 func (a *API) onWebRTCSessionsKick(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.WebRTCServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = a.WebRTCServer.APISessionsKick(uuid)
+	err = srv.APISessionsKick(uuid)
 	if err != nil {
 		if errors.Is(err, webrtc.ErrSessionNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -1020,8 +1889,31 @@ func (a *API) onWebRTCSessionsKick(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (a *API) onWebRTCSessionsPause(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.WebRTCServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APISessionsPause, webrtc.ErrSessionNotFound, webrtc.ErrSessionNotReading)
+}
+
+func (a *API) onWebRTCSessionsResume(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.WebRTCServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APISessionsResume, webrtc.ErrSessionNotFound, webrtc.ErrSessionNotReading)
+}
+
 func (a *API) onSRTConnsList(ctx *gin.Context) {
-	data, err := a.SRTServer.APIConnsList()
+	srv, ok := protocolServer(a, ctx, &a.SRTServer)
+	if !ok {
+		return
+	}
+
+	data, err := srv.APIConnsList()
 	if err != nil {
 		a.writeError(ctx, http.StatusInternalServerError, err)
 		return
@@ -1039,13 +1931,18 @@ func (a *API) onSRTConnsList(ctx *gin.Context) {
 }
 
 func (a *API) onSRTConnsGet(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.SRTServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	data, err := a.SRTServer.APIConnsGet(uuid)
+	data, err := srv.APIConnsGet(uuid)
 	if err != nil {
 		if errors.Is(err, srt.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -1059,13 +1956,18 @@ func (a *API) onSRTConnsGet(ctx *gin.Context) {
 }
 
 func (a *API) onSRTConnsKick(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.SRTServer)
+	if !ok {
+		return
+	}
+
 	uuid, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		a.writeError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = a.SRTServer.APIConnsKick(uuid)
+	err = srv.APIConnsKick(uuid)
 	if err != nil {
 		if errors.Is(err, srt.ErrConnNotFound) {
 			a.writeError(ctx, http.StatusNotFound, err)
@@ -1078,12 +1980,35 @@ func (a *API) onSRTConnsKick(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (a *API) onSRTConnsPause(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.SRTServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APIConnsPause, srt.ErrConnNotFound, srt.ErrConnNotReading)
+}
+
+func (a *API) onSRTConnsResume(ctx *gin.Context) {
+	srv, ok := protocolServer(a, ctx, &a.SRTServer)
+	if !ok {
+		return
+	}
+
+	a.setReaderPaused(ctx, srv.APIConnsResume, srt.ErrConnNotFound, srt.ErrConnNotReading)
+}
+
 func (a *API) onRecordingsList(ctx *gin.Context) {
 	a.mutex.RLock()
 	c := a.Conf
 	a.mutex.RUnlock()
 
-	pathNames := recordstore.FindAllPathsWithSegments(c.Paths)
+	var pathNames []string
+	for _, pathName := range recordstore.FindAllPathsWithSegments(c.Paths) {
+		if a.canAccessRecordings(ctx, c, pathName) {
+			pathNames = append(pathNames, pathName)
+		}
+	}
 
 	data := defs.APIRecordingList{}
 
@@ -1122,6 +2047,11 @@ func (a *API) onRecordingsGet(ctx *gin.Context) {
 		return
 	}
 
+	if !a.canAccessRecordings(ctx, c, pathName) {
+		a.writeError(ctx, http.StatusUnauthorized, fmt.Errorf("recordings of path '%s' can't be accessed", pathName))
+		return
+	}
+
 	ctx.JSON(http.StatusOK, recordingsOfPath(pathConf, pathName))
 }
 
@@ -1144,6 +2074,11 @@ func (a *API) onRecordingDeleteSegment(ctx *gin.Context) {
 		return
 	}
 
+	if !a.canAccessRecordings(ctx, c, pathName) {
+		a.writeError(ctx, http.StatusUnauthorized, fmt.Errorf("recordings of path '%s' can't be accessed", pathName))
+		return
+	}
+
 	pathFormat := recordstore.PathAddExtension(
 		strings.ReplaceAll(pathConf.RecordPath, "%path", pathName),
 		pathConf.RecordFormat,
@@ -1168,3 +2103,48 @@ func (a *API) ReloadConf(conf *conf.Conf) {
 	defer a.mutex.Unlock()
 	a.Conf = conf
 }
+
+func (a *API) onAuthSignURL(ctx *gin.Context) {
+	pathName, ok := paramName(ctx)
+	if !ok {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid name"))
+		return
+	}
+
+	action := conf.AuthActionRead
+	if v := ctx.Query("action"); v != "" {
+		action = conf.AuthAction(v)
+		if action != conf.AuthActionRead && action != conf.AuthActionPlayback {
+			a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid action: '%s'", v))
+			return
+		}
+	}
+
+	validity := time.Hour
+	if v := ctx.Query("validity"); v != "" {
+		var err error
+		validity, err = time.ParseDuration(v)
+		if err != nil || validity <= 0 {
+			a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid validity: '%s'", v))
+			return
+		}
+	}
+
+	a.mutex.RLock()
+	secret := a.Conf.AuthSignedURLSecret
+	a.mutex.RUnlock()
+
+	if secret == "" {
+		a.writeError(ctx, http.StatusBadRequest, fmt.Errorf("signed URLs are disabled"))
+		return
+	}
+
+	expires := time.Now().Add(validity).Truncate(time.Second)
+
+	ctx.JSON(http.StatusOK, &defs.APISignedURL{
+		Path:    pathName,
+		Action:  string(action),
+		Expires: expires,
+		Query:   auth.SignURLQuery(secret, action, pathName, expires),
+	})
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/stretchr/testify/require"
@@ -121,11 +122,12 @@ func TestConfigAuth(t *testing.T) {
 		AuthManager: &test.AuthManager{
 			Func: func(req *auth.Request) error {
 				require.Equal(t, &auth.Request{
-					User:   "myuser",
-					Pass:   "mypass",
-					IP:     req.IP,
-					Action: "api",
-					Query:  "key=val",
+					User:    "myuser",
+					Pass:    "mypass",
+					IP:      req.IP,
+					LocalIP: req.LocalIP,
+					Action:  "api",
+					Query:   "key=val",
 				}, req)
 				return nil
 			},
@@ -587,6 +589,238 @@ func TestConfigPathsDelete(t *testing.T) {
 	checkError(t, "path configuration not found", res.Body)
 }
 
+func TestConfigPathsImport(t *testing.T) {
+	cnf := tempConf(t, "api: yes\n"+
+		"paths:\n"+
+		"  path1:\n"+
+		"    maxReaders: 1\n"+
+		"  path2:\n")
+
+	api := API{
+		Address:     "localhost:9997",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		Conf:        cnf,
+		AuthManager: test.NilAuthManager,
+		Parent:      &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/config/paths/import",
+		map[string]interface{}{
+			"paths": map[string]interface{}{
+				"path2": map[string]interface{}{
+					"source": "rtsp://127.0.0.1:9999/mypath",
+				},
+				"path3": map[string]interface{}{
+					"maxReaders": 3,
+				},
+			},
+		}, nil)
+
+	var out map[string]interface{}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/get/path1", nil, &out)
+	require.Equal(t, float64(1), out["maxReaders"])
+
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/get/path2", nil, &out)
+	require.Equal(t, "rtsp://127.0.0.1:9999/mypath", out["source"])
+
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/get/path3", nil, &out)
+	require.Equal(t, float64(3), out["maxReaders"])
+
+	t.Run("invalid", func(t *testing.T) {
+		byts, err := json.Marshal(map[string]interface{}{
+			"paths": map[string]interface{}{
+				"path4": map[string]interface{}{},
+				"path5": map[string]interface{}{
+					"source": "invalid",
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err := hc.Post("http://localhost:9997/v3/config/paths/import", "application/json", bytes.NewReader(byts))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		res2, err := hc.Get("http://localhost:9997/v3/config/paths/get/path4")
+		require.NoError(t, err)
+		defer res2.Body.Close()
+
+		require.Equal(t, http.StatusNotFound, res2.StatusCode)
+	})
+
+	t.Run("replace all", func(t *testing.T) {
+		res, err := hc.Post("http://localhost:9997/v3/config/paths/import", "application/yaml",
+			bytes.NewReader([]byte("replaceAll: yes\n"+
+				"paths:\n"+
+				"  path6:\n"+
+				"    maxReaders: 6\n")))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		type listRes struct {
+			ItemCount int                      `json:"itemCount"`
+			Items     []map[string]interface{} `json:"items"`
+		}
+
+		var out listRes
+		httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/list", nil, &out)
+		require.Equal(t, 1, out.ItemCount)
+		require.Equal(t, "path6", out.Items[0]["name"])
+		require.Equal(t, float64(6), out.Items[0]["maxReaders"])
+	})
+}
+
+func TestConfigDryRun(t *testing.T) {
+	cnf := tempConf(t, "api: yes\n"+
+		"paths:\n"+
+		"  path1:\n"+
+		"    maxReaders: 1\n")
+
+	api := API{
+		Address:     "localhost:9997",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		Conf:        cnf,
+		AuthManager: test.NilAuthManager,
+		Parent:      &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	var out map[string]interface{}
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/path1?dryRun=true",
+		map[string]interface{}{
+			"maxReaders": 2,
+		}, &out)
+	require.Equal(t, map[string]interface{}{
+		"changes": []interface{}{
+			map[string]interface{}{
+				"key":      "paths.path1.maxReaders",
+				"oldValue": float64(1),
+				"newValue": float64(2),
+			},
+		},
+	}, out)
+
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/get/path1", nil, &out)
+	require.Equal(t, float64(1), out["maxReaders"])
+
+	out = nil
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/global/patch?dryRun=true",
+		map[string]interface{}{
+			"rtmp": false,
+		}, &out)
+	require.Equal(t, map[string]interface{}{
+		"changes": []interface{}{
+			map[string]interface{}{
+				"key":      "rtmp",
+				"oldValue": true,
+				"newValue": false,
+			},
+		},
+	}, out)
+
+	byts, err := json.Marshal(map[string]interface{}{
+		"source": "invalid",
+	})
+	require.NoError(t, err)
+
+	res, err := hc.Post("http://localhost:9997/v3/config/paths/add/path2?dryRun=true",
+		"application/json", bytes.NewReader(byts))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// values other than true and false are rejected, without applying the change
+	byts, err = json.Marshal(map[string]interface{}{
+		"maxReaders": 2,
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/path1?dryRun=yes",
+		bytes.NewReader(byts))
+	require.NoError(t, err)
+
+	res2, err := hc.Do(req)
+	require.NoError(t, err)
+	defer res2.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, res2.StatusCode)
+
+	out = nil
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/get/path1", nil, &out)
+	require.Equal(t, float64(1), out["maxReaders"])
+}
+
+func TestConfigExport(t *testing.T) {
+	cnf := tempConf(t, "api: yes\n"+
+		"paths:\n"+
+		"  path1:\n"+
+		"    maxReaders: 1\n")
+
+	api := API{
+		Address:     "localhost:9997",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		Conf:        cnf,
+		AuthManager: test.NilAuthManager,
+		Parent:      &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/config/paths/add/path2",
+		map[string]interface{}{
+			"source": "rtsp://127.0.0.1:9999/mypath",
+		}, nil)
+
+	var out map[string]interface{}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/export", nil, &out)
+	require.Equal(t, true, out["api"])
+	require.Equal(t, map[string]interface{}{
+		"path1": map[string]interface{}{
+			"maxReaders": float64(1),
+		},
+		"path2": map[string]interface{}{
+			"source": "rtsp://127.0.0.1:9999/mypath",
+		},
+	}, out["paths"])
+
+	res, err := hc.Get("http://localhost:9997/v3/config/export?format=yaml")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	byts, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	cnf2 := tempConf(t, string(byts))
+	require.Equal(t, true, cnf2.API)
+	require.Equal(t, 1, cnf2.Paths["path1"].MaxReaders)
+	require.Equal(t, "rtsp://127.0.0.1:9999/mypath", cnf2.Paths["path2"].Source)
+}
+
 func TestRecordingsList(t *testing.T) {
 	dir, err := os.MkdirTemp("", "mediamtx-playback")
 	require.NoError(t, err)
@@ -657,6 +891,70 @@ func TestRecordingsList(t *testing.T) {
 	}, out)
 }
 
+func TestRecordingsListRequireRead(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-playback")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cnf := tempConf(t, "authPlaybackRequireRead: yes\n"+
+		"pathDefaults:\n"+
+		"  recordPath: "+filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f")+"\n"+
+		"paths:\n"+
+		"  all_others:\n")
+
+	api := API{
+		Address:     "localhost:9997",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		Conf:        cnf,
+		AuthManager: &test.AuthManager{
+			Func: func(req *auth.Request) error {
+				if req.Action == conf.AuthActionPlayback && req.Path != "mypath1" {
+					return auth.Error{}
+				}
+				return nil
+			},
+		},
+		Parent: &testParent{},
+	}
+	err = api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	for _, pathName := range []string{"mypath1", "mypath2"} {
+		err = os.Mkdir(filepath.Join(dir, pathName), 0o755)
+		require.NoError(t, err)
+
+		err = os.WriteFile(filepath.Join(dir, pathName, "2008-11-07_11-22-00-500000.mp4"), []byte(""), 0o644)
+		require.NoError(t, err)
+	}
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	var out interface{}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/recordings/list", nil, &out)
+	require.Equal(t, map[string]interface{}{
+		"itemCount": float64(1),
+		"pageCount": float64(1),
+		"items": []interface{}{
+			map[string]interface{}{
+				"name": "mypath1",
+				"segments": []interface{}{
+					map[string]interface{}{
+						"start": time.Date(2008, 11, 0o7, 11, 22, 0, 500000000, time.Local).Format(time.RFC3339Nano),
+					},
+				},
+			},
+		},
+	}, out)
+
+	res, err := hc.Get("http://localhost:9997/v3/recordings/get/mypath2")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
 func TestRecordingsGet(t *testing.T) {
 	dir, err := os.MkdirTemp("", "mediamtx-playback")
 	require.NoError(t, err)
@@ -753,3 +1051,37 @@ func TestRecordingsDeleteSegment(t *testing.T) {
 	defer res.Body.Close()
 	require.Equal(t, http.StatusOK, res.StatusCode)
 }
+
+func TestAuthSignURL(t *testing.T) {
+	cnf := tempConf(t, "authSignedURLSecret: mysecret\n")
+
+	api := API{
+		Address:     "localhost:9997",
+		ReadTimeout: conf.StringDuration(10 * time.Second),
+		Conf:        cnf,
+		AuthManager: test.NilAuthManager,
+		Parent:      &testParent{},
+	}
+	err := api.Initialize()
+	require.NoError(t, err)
+	defer api.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	var out defs.APISignedURL
+	httpRequest(t, hc, http.MethodGet,
+		"http://localhost:9997/v3/auth/signurl/mypath?action=playback&validity=10m", nil, &out)
+	require.Equal(t, "mypath", out.Path)
+	require.Equal(t, "playback", out.Action)
+	require.WithinDuration(t, time.Now().Add(10*time.Minute), out.Expires, 2*time.Second)
+	require.Equal(t, auth.SignURLQuery("mysecret", conf.AuthActionPlayback, "mypath", out.Expires), out.Query)
+
+	res, err := hc.Get("http://localhost:9997/v3/auth/signurl/mypath?action=publish")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	checkError(t, "invalid action: 'publish'", res.Body)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+// queryDryRun parses the dryRun query parameter.
+func queryDryRun(ctx *gin.Context) (bool, error) {
+	switch ctx.Query("dryRun") {
+	case "", "false":
+		return false, nil
+
+	case "true":
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("invalid dryRun value: '%s'", ctx.Query("dryRun"))
+	}
+}
+
+func toGenericMap(i interface{}) map[string]interface{} {
+	if interfaceIsEmpty(i) {
+		return nil
+	}
+
+	byts, err := json.Marshal(i)
+	if err != nil {
+		panic(err)
+	}
+
+	var ret map[string]interface{}
+	err = json.Unmarshal(byts, &ret)
+	if err != nil {
+		panic(err)
+	}
+
+	return ret
+}
+
+func mapDiff(prefix string, oldMap map[string]interface{}, newMap map[string]interface{}) []*defs.APIConfigChange {
+	keys := make(map[string]struct{})
+	for key := range oldMap {
+		keys[key] = struct{}{}
+	}
+	for key := range newMap {
+		keys[key] = struct{}{}
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var ret []*defs.APIConfigChange
+
+	for _, key := range sortedKeys {
+		oldVal, newVal := oldMap[key], newMap[key]
+		if !reflect.DeepEqual(oldVal, newVal) {
+			ret = append(ret, &defs.APIConfigChange{
+				Key:      prefix + key,
+				OldValue: oldVal,
+				NewValue: newVal,
+			})
+		}
+	}
+
+	return ret
+}
+
+// configDiff returns the differences between two validated configurations.
+func configDiff(oldConf *conf.Conf, newConf *conf.Conf) []*defs.APIConfigChange {
+	ret := mapDiff("", toGenericMap(oldConf.Global()), toGenericMap(newConf.Global()))
+
+	ret = append(ret, mapDiff("pathDefaults.",
+		toGenericMap(&oldConf.PathDefaults), toGenericMap(&newConf.PathDefaults))...)
+
+	names := make(map[string]*conf.Path)
+	for name, p := range oldConf.Paths {
+		names[name] = p
+	}
+	for name, p := range newConf.Paths {
+		names[name] = p
+	}
+
+	for _, name := range sortedKeys(names) {
+		ret = append(ret, mapDiff("paths."+name+".",
+			toGenericMap(oldConf.Paths[name]), toGenericMap(newConf.Paths[name]))...)
+	}
+
+	return ret
+}
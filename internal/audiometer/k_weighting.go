@@ -0,0 +1,69 @@
+package audiometer
+
+import (
+	"math"
+)
+
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	z1, z2     float64
+}
+
+func (f *biquad) process(in float64) float64 {
+	out := f.b0*in + f.z1
+	f.z1 = f.b1*in - f.a1*out + f.z2
+	f.z2 = f.b2*in - f.a2*out
+	return out
+}
+
+// kWeighting is the K-weighting filter defined in ITU-R BS.1770,
+// computed for an arbitrary sample rate.
+type kWeighting struct {
+	shelf    biquad
+	highPass biquad
+}
+
+func newKWeighting(sampleRate int) *kWeighting {
+	rate := float64(sampleRate)
+
+	// high shelf
+	f0 := 1681.974450955533
+	g := 3.999843853973347
+	q := 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / rate)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// high pass
+	f0 = 38.13547087602444
+	q = 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / rate)
+	a0 = 1 + k/q + k*k
+
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return &kWeighting{
+		shelf:    shelf,
+		highPass: highPass,
+	}
+}
+
+func (w *kWeighting) process(in float64) float64 {
+	return w.highPass.process(w.shelf.process(in))
+}
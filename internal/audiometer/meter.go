@@ -0,0 +1,256 @@
+// Package audiometer contains an audio level meter.
+package audiometer
+
+import (
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/g711"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// Level is the level of an audio track.
+type Level struct {
+	// index of the track in the stream
+	Track int
+
+	Codec string
+
+	// momentary loudness (400ms), in LUFS
+	Loudness float64
+
+	// sample peak of the last 400ms, in dBFS
+	Peak float64
+}
+
+func decodeLPCM(samples []byte, bitDepth int) []float64 {
+	switch bitDepth {
+	case 8:
+		out := make([]float64, len(samples))
+		for i, v := range samples {
+			out[i] = (float64(v) - 128) / 128
+		}
+		return out
+
+	case 16:
+		out := make([]float64, len(samples)/2)
+		for i := range out {
+			out[i] = float64(int16(uint16(samples[i*2])<<8|uint16(samples[i*2+1]))) / 32768
+		}
+		return out
+
+	case 24:
+		out := make([]float64, len(samples)/3)
+		for i := range out {
+			v := int32(uint32(samples[i*3])<<24|uint32(samples[i*3+1])<<16|uint32(samples[i*3+2])<<8) >> 8
+			out[i] = float64(v) / 8388608
+		}
+		return out
+
+	case 32:
+		out := make([]float64, len(samples)/4)
+		for i := range out {
+			v := int32(uint32(samples[i*4])<<24 | uint32(samples[i*4+1])<<16 |
+				uint32(samples[i*4+2])<<8 | uint32(samples[i*4+3]))
+			out[i] = float64(v) / 2147483648
+		}
+		return out
+	}
+
+	return nil
+}
+
+// Meter measures the loudness and the peak level of the audio tracks of a stream.
+// Only uncompressed tracks (LPCM and G711) can be measured, since the server
+// doesn't contain decoders for compressed audio (MPEG-4 Audio, Opus, etc).
+type Meter struct {
+	WriteQueueSize   int
+	Stream           *stream.Stream
+	SilenceThreshold float64
+	SilenceDuration  time.Duration
+	OnSilence        func()
+	Parent           logger.Writer
+
+	writer          *asyncwriter.Writer
+	tracks          []*track
+	silenceNotified bool
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Meter.
+func (m *Meter) Initialize() {
+	if m.OnSilence == nil {
+		m.OnSilence = func() {}
+	}
+
+	m.terminate = make(chan struct{})
+	m.done = make(chan struct{})
+
+	m.writer = asyncwriter.New(m.WriteQueueSize, m)
+
+	index := 0
+
+	for _, media := range m.Stream.Desc().Medias {
+		for _, forma := range media.Formats {
+			if !m.setupFormat(media, forma, index) && media.Type == description.MediaTypeAudio {
+				m.Log(logger.Warn, "skipping track %d (%s): only LPCM and G711 tracks can be metered",
+					index+1, forma.Codec())
+			}
+			index++
+		}
+	}
+
+	if len(m.tracks) == 0 {
+		m.Log(logger.Warn, "stream doesn't contain any track that can be metered (supported are LPCM, G711)")
+	} else {
+		m.Log(logger.Info, "metering %d %s",
+			len(m.tracks),
+			func() string {
+				if len(m.tracks) == 1 {
+					return "track"
+				}
+				return "tracks"
+			}())
+	}
+
+	go m.run()
+}
+
+func (m *Meter) setupFormat(media *description.Media, forma format.Format, index int) bool {
+	switch forma := forma.(type) {
+	case *format.LPCM:
+		if forma.BitDepth != 8 && forma.BitDepth != 16 && forma.BitDepth != 24 && forma.BitDepth != 32 {
+			return false
+		}
+
+		t := newTrack(index, forma.Codec(), forma.SampleRate, forma.ChannelCount)
+		m.tracks = append(m.tracks, t)
+
+		m.Stream.AddReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.LPCM)
+
+			if tunit.Samples == nil {
+				return nil
+			}
+
+			m.process(t, decodeLPCM(tunit.Samples, forma.BitDepth))
+			return nil
+		})
+
+		return true
+
+	case *format.G711:
+		t := newTrack(index, forma.Codec(), forma.SampleRate, forma.ChannelCount)
+		m.tracks = append(m.tracks, t)
+
+		m.Stream.AddReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.G711)
+
+			if tunit.Samples == nil {
+				return nil
+			}
+
+			var samples []byte
+			if forma.MULaw {
+				samples = g711.DecodeMulaw(tunit.Samples)
+			} else {
+				samples = g711.DecodeAlaw(tunit.Samples)
+			}
+
+			m.process(t, decodeLPCM(samples, 16))
+			return nil
+		})
+
+		return true
+	}
+
+	return false
+}
+
+// Close closes Meter.
+func (m *Meter) Close() {
+	close(m.terminate)
+	<-m.done
+}
+
+// Log implements logger.Writer.
+func (m *Meter) Log(level logger.Level, format string, args ...interface{}) {
+	m.Parent.Log(level, "[audio meter] "+format, args...)
+}
+
+func (m *Meter) run() {
+	defer close(m.done)
+
+	m.writer.Start()
+
+	select {
+	case err := <-m.writer.Error():
+		m.Log(logger.Error, err.Error())
+		m.Stream.RemoveReader(m.writer)
+
+	case <-m.terminate:
+		m.Stream.RemoveReader(m.writer)
+		m.writer.Stop()
+	}
+}
+
+func (m *Meter) process(t *track, samples []float64) {
+	completed := t.process(samples)
+	if completed == 0 {
+		return
+	}
+
+	loudness, _ := t.levels()
+	if loudness < m.SilenceThreshold {
+		t.silentDuration += time.Duration(completed) * blockDuration
+	} else {
+		t.silentDuration = 0
+	}
+
+	if m.SilenceDuration == 0 {
+		return
+	}
+
+	// the stream is silent when all tracks are silent
+	silentDuration := t.silentDuration
+	for _, ot := range m.tracks {
+		if ot.silentDuration < silentDuration {
+			silentDuration = ot.silentDuration
+		}
+	}
+
+	if silentDuration >= m.SilenceDuration {
+		if !m.silenceNotified {
+			m.silenceNotified = true
+			m.Log(logger.Warn, "audio has been silent for more than %v", m.SilenceDuration)
+			m.OnSilence()
+		}
+	} else if m.silenceNotified {
+		m.silenceNotified = false
+		m.Log(logger.Info, "audio is not silent anymore")
+	}
+}
+
+// Levels returns the current levels of metered tracks.
+func (m *Meter) Levels() []Level {
+	ret := make([]Level, len(m.tracks))
+
+	for i, t := range m.tracks {
+		loudness, peak := t.levels()
+		ret[i] = Level{
+			Track:    t.index,
+			Codec:    t.codec,
+			Loudness: loudness,
+			Peak:     peak,
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,138 @@
+package audiometer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func sine(sampleRate int, frequency float64, amplitude float64, duration time.Duration) []float64 {
+	n := int(time.Duration(sampleRate) * duration / time.Second)
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = amplitude * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate))
+	}
+	return out
+}
+
+func TestTrackLevels(t *testing.T) {
+	for _, sampleRate := range []int{48000, 44100} {
+		tr := newTrack(0, "LPCM", sampleRate, 1)
+
+		// a 997Hz sine wave at -20dBFS has a loudness of -23LUFS
+		tr.process(sine(sampleRate, 997, 0.1, time.Second))
+
+		loudness, peak := tr.levels()
+		require.InDelta(t, -23.0, loudness, 0.1)
+		require.InDelta(t, -20.0, peak, 0.1)
+	}
+
+	tr := newTrack(0, "LPCM", 48000, 1)
+	tr.process(make([]float64, 48000))
+
+	loudness, peak := tr.levels()
+	require.Equal(t, float64(levelFloor), loudness)
+	require.Equal(t, float64(levelFloor), peak)
+}
+
+func TestMeter(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			}},
+		},
+		{
+			Type: description.MediaTypeAudio,
+			Formats: []format.Format{&format.LPCM{
+				PayloadTyp:   96,
+				BitDepth:     16,
+				SampleRate:   48000,
+				ChannelCount: 1,
+			}},
+		},
+	}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	silence := make(chan struct{}, 1)
+
+	m := &Meter{
+		WriteQueueSize:   512,
+		Stream:           strm,
+		SilenceThreshold: -60,
+		SilenceDuration:  1 * time.Second,
+		OnSilence: func() {
+			silence <- struct{}{}
+		},
+		Parent: test.NilLogger,
+	}
+	m.Initialize()
+	defer m.Close()
+
+	encode := func(samples []float64) []byte {
+		out := make([]byte, len(samples)*2)
+		for i, v := range samples {
+			s := int16(v * 32767)
+			out[i*2] = byte(uint16(s) >> 8)
+			out[i*2+1] = byte(uint16(s))
+		}
+		return out
+	}
+
+	for i := 0; i < 5; i++ {
+		strm.WriteUnit(desc.Medias[1], desc.Medias[1].Formats[0], &unit.LPCM{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 200 * time.Millisecond,
+			},
+			Samples: encode(sine(48000, 997, 0.1, 200*time.Millisecond)),
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		levels := m.Levels()
+		return len(levels) == 1 && levels[0].Track == 1 && math.Abs(levels[0].Loudness+23) < 0.2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 8; i++ {
+		strm.WriteUnit(desc.Medias[1], desc.Medias[1].Formats[0], &unit.LPCM{
+			Base: unit.Base{
+				PTS: time.Second + time.Duration(i)*200*time.Millisecond,
+			},
+			Samples: make([]byte, 48000*2/5),
+		})
+	}
+
+	select {
+	case <-silence:
+	case <-time.After(2 * time.Second):
+		t.Error("silence not detected")
+	}
+}
+
+func TestDecodeLPCM32(t *testing.T) {
+	require.Equal(t, []float64{0.5, -0.5, 0}, decodeLPCM([]byte{
+		0x40, 0x00, 0x00, 0x00,
+		0xC0, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}, 32))
+}
@@ -0,0 +1,130 @@
+package audiometer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	blockDuration = 100 * time.Millisecond
+
+	// momentary loudness is computed on 4 blocks (400ms)
+	momentaryBlocks = 4
+
+	// levels are never reported below this value
+	levelFloor = -120
+)
+
+func toDecibels(v float64) float64 {
+	if v <= 0 {
+		return levelFloor
+	}
+	return math.Max(levelFloor, 10*math.Log10(v))
+}
+
+type track struct {
+	index        int
+	codec        string
+	channelCount int
+
+	filters   []*kWeighting
+	blockSize int
+	blockPos  int
+	blockSum  float64
+	blockPeak float64
+
+	blocks     [momentaryBlocks]float64
+	blockPeaks [momentaryBlocks]float64
+	blockCount int
+
+	silentDuration time.Duration
+
+	mutex    sync.RWMutex
+	loudness float64
+	peak     float64
+}
+
+func newTrack(index int, codec string, sampleRate int, channelCount int) *track {
+	t := &track{
+		index:        index,
+		codec:        codec,
+		channelCount: channelCount,
+		blockSize:    int(time.Duration(sampleRate) * blockDuration / time.Second),
+		loudness:     levelFloor,
+		peak:         levelFloor,
+	}
+
+	t.filters = make([]*kWeighting, channelCount)
+	for i := range t.filters {
+		t.filters[i] = newKWeighting(sampleRate)
+	}
+
+	return t
+}
+
+// process processes interleaved samples normalized between -1 and 1.
+// It returns the number of completed blocks.
+func (t *track) process(samples []float64) int {
+	completed := 0
+
+	for i := 0; i+t.channelCount <= len(samples); i += t.channelCount {
+		for c := 0; c < t.channelCount; c++ {
+			v := samples[i+c]
+
+			if a := math.Abs(v); a > t.blockPeak {
+				t.blockPeak = a
+			}
+
+			w := t.filters[c].process(v)
+			t.blockSum += w * w
+		}
+
+		t.blockPos++
+
+		if t.blockPos >= t.blockSize {
+			t.completeBlock()
+			completed++
+		}
+	}
+
+	return completed
+}
+
+func (t *track) completeBlock() {
+	t.blocks[t.blockCount%momentaryBlocks] = t.blockSum / float64(t.blockSize)
+	t.blockPeaks[t.blockCount%momentaryBlocks] = t.blockPeak
+	t.blockCount++
+
+	t.blockPos = 0
+	t.blockSum = 0
+	t.blockPeak = 0
+
+	n := t.blockCount
+	if n > momentaryBlocks {
+		n = momentaryBlocks
+	}
+
+	var sum float64
+	var peak float64
+	for i := 0; i < n; i++ {
+		sum += t.blocks[i]
+		peak = math.Max(peak, t.blockPeaks[i])
+	}
+
+	loudness := -0.691 + toDecibels(sum/float64(n))
+	if loudness < levelFloor {
+		loudness = levelFloor
+	}
+
+	t.mutex.Lock()
+	t.loudness = loudness
+	t.peak = toDecibels(peak * peak)
+	t.mutex.Unlock()
+}
+
+func (t *track) levels() (float64, float64) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.loudness, t.peak
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+func interfaceHasIP(name string, ip net.IP) bool {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return false
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return false
+	}
+
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorizeInterface checks whether the action is allowed on the network interface
+// that received the request. Interfaces are resolved at every request,
+// since their addresses can change.
+func authorizeInterface(policies conf.AuthInterfacePolicies, req *Request) error {
+	if req.LocalIP == nil {
+		return nil
+	}
+
+	for _, policy := range policies {
+		if !interfaceHasIP(policy.Interface, req.LocalIP) {
+			continue
+		}
+
+		for _, action := range policy.Actions {
+			if action == req.Action {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("action '%s' is not allowed on interface '%s'", req.Action, policy.Interface)
+	}
+
+	return nil
+}
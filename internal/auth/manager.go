@@ -18,6 +18,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+	"github.com/bluenviron/mediamtx/internal/authpolicy"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -41,14 +42,17 @@ const (
 	ProtocolHLS    Protocol = "hls"
 	ProtocolWebRTC Protocol = "webrtc"
 	ProtocolSRT    Protocol = "srt"
+	ProtocolIPC    Protocol = "ipc"
+	ProtocolDASH   Protocol = "dash"
 )
 
 // Request is an authentication request.
 type Request struct {
-	User   string
-	Pass   string
-	IP     net.IP
-	Action conf.AuthAction
+	User    string
+	Pass    string
+	IP      net.IP
+	LocalIP net.IP
+	Action  conf.AuthAction
 
 	// only for ActionPublish, ActionRead, ActionPlayback
 	Path        string
@@ -130,21 +134,41 @@ func (c *customClaims) UnmarshalJSON(b []byte) error {
 
 // Manager is the authentication manager.
 type Manager struct {
-	Method          conf.AuthMethod
-	InternalUsers   []conf.AuthInternalUser
-	HTTPAddress     string
-	HTTPExclude     []conf.AuthInternalUserPermission
-	JWTJWKS         string
-	JWTClaimKey     string
-	ReadTimeout     time.Duration
-	RTSPAuthMethods []auth.ValidateMethod
-
+	Method            conf.AuthMethod
+	InternalUsers     []conf.AuthInternalUser
+	HTTPAddress       string
+	HTTPExclude       []conf.AuthInternalUserPermission
+	JWTJWKS           string
+	JWTClaimKey       string
+	ReadTimeout       time.Duration
+	RTSPAuthMethods   []auth.ValidateMethod
+	SignedURLSecret   string
+	Policy            string
+	InterfacePolicies conf.AuthInterfacePolicies
+
+	// when enabled, playback requests also need the read permission on the path.
+	PlaybackRequireRead bool
+
+	policy         *authpolicy.Policy
 	mutex          sync.RWMutex
 	jwtHTTPClient  *http.Client
 	jwtLastRefresh time.Time
 	jwtKeyFunc     keyfunc.Keyfunc
 }
 
+// Initialize initializes Manager.
+func (m *Manager) Initialize() error {
+	if m.Policy != "" {
+		var err error
+		m.policy, err = authpolicy.New(m.Policy)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ReloadInternalUsers reloads InternalUsers.
 func (m *Manager) ReloadInternalUsers(u []conf.AuthInternalUser) {
 	m.mutex.Lock()
@@ -154,10 +178,81 @@ func (m *Manager) ReloadInternalUsers(u []conf.AuthInternalUser) {
 
 // Authenticate authenticates a request.
 func (m *Manager) Authenticate(req *Request) error {
-	err := m.authenticateInner(req)
+	err := authorizeInterface(m.InterfacePolicies, req)
 	if err != nil {
 		return Error{Message: err.Error()}
 	}
+
+	signed := false
+
+	if m.SignedURLSecret != "" {
+		signed, err = checkSignedURL(m.SignedURLSecret, req)
+		if err != nil {
+			return Error{Message: err.Error()}
+		}
+	}
+
+	if !signed {
+		err = m.authenticateInner(req)
+		if err != nil {
+			return Error{Message: err.Error()}
+		}
+	}
+
+	if m.policy != nil {
+		err = m.authorizeWithPolicy(req)
+		if err != nil {
+			return Error{Message: err.Error()}
+		}
+	}
+
+	// recordings can be accessed only by users that can read the path live.
+	// Signed URLs are generated by API users and are trusted as they are.
+	if !signed && m.PlaybackRequireRead && req.Action == conf.AuthActionPlayback {
+		readReq := *req
+		readReq.Action = conf.AuthActionRead
+
+		err = m.authenticateInner(&readReq)
+		if err != nil {
+			return Error{Message: "read permission is required to access recordings: " + err.Error()}
+		}
+
+		if m.policy != nil {
+			err = m.authorizeWithPolicy(&readReq)
+			if err != nil {
+				return Error{Message: "read permission is required to access recordings: " + err.Error()}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) authorizeWithPolicy(req *Request) error {
+	query := make(map[string]string)
+	if v, err := url.ParseQuery(req.Query); err == nil {
+		for key, vals := range v {
+			query[key] = vals[0]
+		}
+	}
+
+	ok, err := m.policy.Evaluate(&authpolicy.Request{
+		User:     req.User,
+		IP:       req.IP,
+		Action:   string(req.Action),
+		Path:     req.Path,
+		Protocol: string(req.Protocol),
+		Query:    query,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("policy evaluation failed: %w", err)
+	}
+
+	if !ok {
+		return fmt.Errorf("request denied by policy")
+	}
+
 	return nil
 }
 
@@ -307,6 +402,11 @@ func (m *Manager) authenticateJWT(req *Request) error {
 		return fmt.Errorf("user doesn't have permission to perform action")
 	}
 
+	// identify the user through the subject of the token
+	if req.User == "" {
+		req.User = cc.Subject
+	}
+
 	return nil
 }
 
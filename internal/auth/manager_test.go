@@ -142,48 +142,57 @@ func TestAuthInternal(t *testing.T) {
 }
 
 func TestAuthInternalRTSPDigest(t *testing.T) {
-	m := Manager{
-		Method: conf.AuthMethodInternal,
-		InternalUsers: []conf.AuthInternalUser{
-			{
-				User: "myuser",
-				Pass: "mypass",
-				IPs:  conf.IPNetworks{mustParseCIDR("127.1.1.1/32")},
-				Permissions: []conf.AuthInternalUserPermission{{
-					Action: conf.AuthActionPublish,
-					Path:   "mypath",
-				}},
-			},
-		},
-		HTTPAddress:     "",
-		RTSPAuthMethods: []auth.ValidateMethod{auth.ValidateMethodDigestMD5},
-	}
+	for _, ca := range []string{"md5", "sha256"} {
+		t.Run(ca, func(t *testing.T) {
+			method := auth.ValidateMethodDigestMD5
+			if ca == "sha256" {
+				method = auth.ValidateMethodSHA256
+			}
 
-	u, err := base.ParseURL("rtsp://127.0.0.1:8554/mypath")
-	require.NoError(t, err)
+			m := Manager{
+				Method: conf.AuthMethodInternal,
+				InternalUsers: []conf.AuthInternalUser{
+					{
+						User: "myuser",
+						Pass: "mypass",
+						IPs:  conf.IPNetworks{mustParseCIDR("127.1.1.1/32")},
+						Permissions: []conf.AuthInternalUserPermission{{
+							Action: conf.AuthActionPublish,
+							Path:   "mypath",
+						}},
+					},
+				},
+				HTTPAddress:     "",
+				RTSPAuthMethods: []auth.ValidateMethod{method},
+			}
 
-	s, err := auth.NewSender(
-		auth.GenerateWWWAuthenticate([]auth.ValidateMethod{auth.ValidateMethodDigestMD5}, "IPCAM", "mynonce"),
-		"myuser",
-		"mypass",
-	)
-	require.NoError(t, err)
+			u, err := base.ParseURL("rtsp://127.0.0.1:8554/mypath")
+			require.NoError(t, err)
 
-	req := &base.Request{
-		Method: "ANNOUNCE",
-		URL:    u,
-	}
+			s, err := auth.NewSender(
+				auth.GenerateWWWAuthenticate([]auth.ValidateMethod{method}, "IPCAM", "mynonce"),
+				"myuser",
+				"mypass",
+			)
+			require.NoError(t, err)
 
-	s.AddAuthorization(req)
+			req := &base.Request{
+				Method: "ANNOUNCE",
+				URL:    u,
+			}
 
-	err = m.Authenticate(&Request{
-		IP:          net.ParseIP("127.1.1.1"),
-		Action:      conf.AuthActionPublish,
-		Path:        "mypath",
-		RTSPRequest: req,
-		RTSPNonce:   "mynonce",
-	})
-	require.NoError(t, err)
+			s.AddAuthorization(req)
+
+			err = m.Authenticate(&Request{
+				IP:          net.ParseIP("127.1.1.1"),
+				Action:      conf.AuthActionPublish,
+				Path:        "mypath",
+				RTSPRequest: req,
+				RTSPNonce:   "mynonce",
+			})
+			require.NoError(t, err)
+		})
+	}
 }
 
 func TestAuthHTTP(t *testing.T) {
@@ -367,3 +376,254 @@ func TestAuthJWT(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestAuthSignedURL(t *testing.T) {
+	for _, ca := range []string{
+		"ok",
+		"expired",
+		"wrong signature",
+		"wrong path",
+		"wrong action",
+		"wrong protocol",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			m := Manager{
+				Method: conf.AuthMethodInternal,
+				InternalUsers: []conf.AuthInternalUser{{
+					User: conf.Credential("myuser"),
+					Pass: conf.Credential("mypass"),
+					Permissions: []conf.AuthInternalUserPermission{{
+						Action: conf.AuthActionRead,
+					}},
+				}},
+				SignedURLSecret: "mysecret",
+			}
+
+			expires := time.Now().Add(time.Hour)
+			if ca == "expired" {
+				expires = time.Now().Add(-time.Hour)
+			}
+
+			query := SignURLQuery("mysecret", conf.AuthActionRead, "mypath", expires)
+
+			req := &Request{
+				IP:       net.ParseIP("127.0.0.1"),
+				Action:   conf.AuthActionRead,
+				Path:     "mypath",
+				Protocol: ProtocolHLS,
+				Query:    "param=value&" + query,
+			}
+
+			switch ca {
+			case "wrong signature":
+				req.Query = SignURLQuery("othersecret", conf.AuthActionRead, "mypath", expires)
+
+			case "wrong path":
+				req.Path = "otherpath"
+
+			case "wrong action":
+				req.Action = conf.AuthActionPlayback
+
+			case "wrong protocol":
+				req.Protocol = ProtocolRTSP
+			}
+
+			err := m.Authenticate(req)
+
+			switch ca {
+			case "ok":
+				require.NoError(t, err)
+
+			case "expired":
+				require.EqualError(t, err, "authentication failed: URL is expired")
+
+			case "wrong protocol":
+				// signature is ignored and credentials are required
+				require.Error(t, err)
+
+			default:
+				require.EqualError(t, err, "authentication failed: invalid URL signature")
+			}
+		})
+	}
+}
+
+func TestAuthPolicy(t *testing.T) {
+	for _, ca := range []string{
+		"ok",
+		"denied",
+		"wrong credentials",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			m := Manager{
+				Method: conf.AuthMethodInternal,
+				InternalUsers: []conf.AuthInternalUser{{
+					User: conf.Credential("myuser"),
+					Pass: conf.Credential("mypass"),
+					Permissions: []conf.AuthInternalUserPermission{{
+						Action: conf.AuthActionPublish,
+					}},
+				}},
+				Policy: `path.startsWith(user + "/") && query.token == "abc"`,
+			}
+			err := m.Initialize()
+			require.NoError(t, err)
+
+			req := &Request{
+				User:     "myuser",
+				Pass:     "mypass",
+				IP:       net.ParseIP("127.0.0.1"),
+				Action:   conf.AuthActionPublish,
+				Path:     "myuser/mypath",
+				Protocol: ProtocolRTSP,
+				Query:    "token=abc",
+			}
+
+			switch ca {
+			case "denied":
+				req.Path = "otheruser/mypath"
+
+			case "wrong credentials":
+				req.Pass = "wrongpass"
+			}
+
+			err = m.Authenticate(req)
+
+			switch ca {
+			case "ok":
+				require.NoError(t, err)
+
+			case "denied":
+				require.EqualError(t, err, "authentication failed: request denied by policy")
+
+			case "wrong credentials":
+				require.EqualError(t, err, "authentication failed: authentication failed")
+			}
+		})
+	}
+}
+
+func TestAuthPlaybackRequireRead(t *testing.T) {
+	m := Manager{
+		Method: conf.AuthMethodInternal,
+		InternalUsers: []conf.AuthInternalUser{{
+			User: conf.Credential("myuser"),
+			Pass: conf.Credential("mypass"),
+			Permissions: []conf.AuthInternalUserPermission{
+				{Action: conf.AuthActionPlayback},
+				{Action: conf.AuthActionRead, Path: "mypath"},
+			},
+		}},
+		SignedURLSecret:     "mysecret",
+		PlaybackRequireRead: true,
+	}
+	err := m.Initialize()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name  string
+		path  string
+		query string
+		err   string
+	}{
+		{"readable path", "mypath", "", ""},
+		{
+			"unreadable path",
+			"otherpath",
+			"",
+			"authentication failed: read permission is required to access recordings: authentication failed",
+		},
+		{
+			"signed URL",
+			"otherpath",
+			SignURLQuery("mysecret", conf.AuthActionPlayback, "otherpath", time.Now().Add(time.Hour)),
+			"",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			req := &Request{
+				IP:     net.ParseIP("127.0.0.1"),
+				Action: conf.AuthActionPlayback,
+				Path:   ca.path,
+				Query:  ca.query,
+			}
+			if ca.query == "" {
+				req.User = "myuser"
+				req.Pass = "mypass"
+			}
+
+			err := m.Authenticate(req)
+			if ca.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, ca.err)
+			}
+		})
+	}
+}
+
+func TestAuthInterfacePolicies(t *testing.T) {
+	// find the name of the loopback interface
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+
+	loopback := ""
+	for _, iface := range ifaces {
+		if interfaceHasIP(iface.Name, net.ParseIP("127.0.0.1")) {
+			loopback = iface.Name
+			break
+		}
+	}
+	if loopback == "" {
+		t.Skip("loopback interface not found")
+	}
+
+	m := Manager{
+		Method: conf.AuthMethodInternal,
+		InternalUsers: []conf.AuthInternalUser{{
+			User: conf.Credential("any"),
+			Permissions: []conf.AuthInternalUserPermission{
+				{Action: conf.AuthActionPublish},
+				{Action: conf.AuthActionRead},
+			},
+		}},
+		InterfacePolicies: conf.AuthInterfacePolicies{{
+			Interface: loopback,
+			Actions:   []conf.AuthAction{conf.AuthActionRead},
+		}},
+	}
+	err = m.Initialize()
+	require.NoError(t, err)
+
+	for _, ca := range []struct {
+		name    string
+		localIP net.IP
+		action  conf.AuthAction
+		err     string
+	}{
+		{"allowed action", net.ParseIP("127.0.0.1"), conf.AuthActionRead, ""},
+		{
+			"denied action",
+			net.ParseIP("127.0.0.1"),
+			conf.AuthActionPublish,
+			"authentication failed: action 'publish' is not allowed on interface '" + loopback + "'",
+		},
+		{"unlisted interface", net.ParseIP("192.0.2.1"), conf.AuthActionPublish, ""},
+		{"unknown local IP", nil, conf.AuthActionPublish, ""},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := m.Authenticate(&Request{
+				IP:       net.ParseIP("127.0.0.1"),
+				LocalIP:  ca.localIP,
+				Action:   ca.action,
+				Path:     "mypath",
+				Protocol: ProtocolRTSP,
+			})
+			if ca.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, ca.err)
+			}
+		})
+	}
+}
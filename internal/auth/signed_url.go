@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+// query parameters of signed URLs.
+const (
+	signedURLExpiresParam   = "expires"
+	signedURLSignatureParam = "signature"
+)
+
+func signedURLSignature(secret string, action conf.AuthAction, path string, expires int64) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(string(action) + "\n" + path + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SignURLQuery returns the query parameters that allow to perform an action
+// on a path until the expiration time, without providing credentials.
+func SignURLQuery(secret string, action conf.AuthAction, path string, expires time.Time) string {
+	v := url.Values{}
+	v.Set(signedURLExpiresParam, strconv.FormatInt(expires.Unix(), 10))
+	v.Set(signedURLSignatureParam, signedURLSignature(secret, action, path, expires.Unix()))
+	return v.Encode()
+}
+
+// checkSignedURL checks whether the request contains a valid URL signature.
+// It returns false when the request is not signed.
+func checkSignedURL(secret string, req *Request) (bool, error) {
+	if req.Action != conf.AuthActionRead && req.Action != conf.AuthActionPlayback {
+		return false, nil
+	}
+
+	// signed URLs can only be used with HTTP-based protocols
+	if req.Action == conf.AuthActionRead && req.Protocol != ProtocolHLS && req.Protocol != ProtocolDASH {
+		return false, nil
+	}
+
+	v, _ := url.ParseQuery(req.Query)
+
+	signature := v.Get(signedURLSignatureParam)
+	if signature == "" {
+		return false, nil
+	}
+
+	expires, err := strconv.ParseInt(v.Get(signedURLExpiresParam), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid URL expiration")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signedURLSignature(secret, req.Action, req.Path, expires))) {
+		return false, fmt.Errorf("invalid URL signature")
+	}
+
+	if time.Now().Unix() > expires {
+		return false, fmt.Errorf("URL is expired")
+	}
+
+	return true, nil
+}
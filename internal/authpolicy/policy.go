@@ -0,0 +1,122 @@
+// Package authpolicy contains authorization policies expressed with the Common Expression Language (CEL).
+package authpolicy
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Request contains the attributes of a request that are available to policies.
+type Request struct {
+	User     string
+	IP       net.IP
+	Action   string
+	Path     string
+	Protocol string
+	Query    map[string]string
+	Time     time.Time
+}
+
+func inNetwork(ip ref.Val, network ref.Val) ref.Val {
+	ipStr, ok := ip.Value().(string)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(ip)
+	}
+
+	networkStr, ok := network.Value().(string)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(network)
+	}
+
+	_, ipnet, err := net.ParseCIDR(networkStr)
+	if err != nil {
+		return types.NewErr("invalid network '%s'", networkStr)
+	}
+
+	parsed := net.ParseIP(ipStr)
+	if parsed == nil {
+		return types.False
+	}
+
+	return types.Bool(ipnet.Contains(parsed))
+}
+
+// Policy is an authorization policy.
+type Policy struct {
+	prg cel.Program
+}
+
+// New compiles a policy.
+func New(expr string) (*Policy, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("user", cel.StringType),
+		cel.Variable("ip", cel.StringType),
+		cel.Variable("action", cel.StringType),
+		cel.Variable("path", cel.StringType),
+		cel.Variable("protocol", cel.StringType),
+		cel.Variable("query", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("time", cel.TimestampType),
+		cel.Function("inNetwork",
+			cel.Overload("in_network_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(inNetwork))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("policy must evaluate to a boolean, but evaluates to %v", ast.OutputType())
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Policy{prg: prg}, nil
+}
+
+// Evaluate evaluates the policy against a request.
+func (p *Policy) Evaluate(req *Request) (bool, error) {
+	query := req.Query
+	if query == nil {
+		query = map[string]string{}
+	}
+
+	ip := ""
+	if req.IP != nil {
+		ip = req.IP.String()
+	}
+
+	out, _, err := p.prg.Eval(map[string]interface{}{
+		"user":     req.User,
+		"ip":       ip,
+		"action":   req.Action,
+		"path":     req.Path,
+		"protocol": req.Protocol,
+		"query":    query,
+		"time":     req.Time,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	v, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("policy returned a non-boolean value")
+	}
+
+	return v, nil
+}
@@ -0,0 +1,111 @@
+package authpolicy
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		expr string
+		req  Request
+		res  bool
+	}{
+		{
+			"path and action",
+			`action == "read" || path.startsWith("cam/" + user + "/")`,
+			Request{User: "myuser", Action: "publish", Path: "cam/myuser/stream"},
+			true,
+		},
+		{
+			"path and action denied",
+			`action == "read" || path.startsWith("cam/" + user + "/")`,
+			Request{User: "myuser", Action: "publish", Path: "cam/otheruser/stream"},
+			false,
+		},
+		{
+			"network",
+			`inNetwork(ip, "192.168.0.0/16") && protocol == "rtsp"`,
+			Request{IP: net.ParseIP("192.168.2.3"), Protocol: "rtsp"},
+			true,
+		},
+		{
+			"network denied",
+			`inNetwork(ip, "192.168.0.0/16")`,
+			Request{IP: net.ParseIP("10.0.0.1")},
+			false,
+		},
+		{
+			"query",
+			`"token" in query && query.token == "abc"`,
+			Request{Query: map[string]string{"token": "abc"}},
+			true,
+		},
+		{
+			"missing query",
+			`"token" in query && query.token == "abc"`,
+			Request{},
+			false,
+		},
+		{
+			"time of day",
+			`time.getHours("UTC") >= 8 && time.getHours("UTC") < 18`,
+			Request{Time: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+			true,
+		},
+		{
+			"time of day denied",
+			`time.getHours("UTC") >= 8 && time.getHours("UTC") < 18`,
+			Request{Time: time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)},
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			p, err := New(ca.expr)
+			require.NoError(t, err)
+
+			ok, err := p.Evaluate(&ca.req)
+			require.NoError(t, err)
+			require.Equal(t, ca.res, ok)
+		})
+	}
+}
+
+func TestPolicyErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{
+			"syntax",
+			`path ==`,
+			"ERROR: <input>:1:8: Syntax error: mismatched input '<EOF>' expecting " +
+				"{'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', " +
+				"NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER}\n" +
+				" | path ==\n" +
+				" | .......^",
+		},
+		{
+			"non boolean",
+			`path`,
+			"policy must evaluate to a boolean, but evaluates to string",
+		},
+		{
+			"unknown variable",
+			`host == "a"`,
+			"ERROR: <input>:1:1: undeclared reference to 'host' (in container '')\n" +
+				" | host == \"a\"\n" +
+				" | ^",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			_, err := New(ca.expr)
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
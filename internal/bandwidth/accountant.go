@@ -0,0 +1,172 @@
+// Package bandwidth contains the bandwidth usage accountant.
+package bandwidth
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+var timeNow = time.Now
+
+// Counter is a reader or publisher that counts transferred bytes.
+type Counter interface {
+	BytesReceived() uint64
+	BytesSent() uint64
+}
+
+type session struct {
+	user          string
+	bytesReceived uint64
+	bytesSent     uint64
+}
+
+type usage struct {
+	bytesReceived uint64
+	bytesSent     uint64
+}
+
+// Accountant aggregates bytes transferred by sessions of authenticated users.
+// Totals are reset every ResetPeriod, if it is not zero.
+type Accountant struct {
+	ResetPeriod time.Duration
+
+	mutex    sync.Mutex
+	since    time.Time
+	sessions map[Counter]*session
+	users    map[string]*usage
+}
+
+// Initialize initializes Accountant.
+func (a *Accountant) Initialize() {
+	a.since = timeNow()
+	a.sessions = make(map[Counter]*session)
+	a.users = make(map[string]*usage)
+}
+
+// Register starts counting bytes transferred by a session of a user.
+// Sessions of anonymous users are not counted.
+func (a *Accountant) Register(user string, c Counter) {
+	if user == "" {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.resetIfExpired()
+
+	if _, ok := a.sessions[c]; ok {
+		return
+	}
+
+	a.sessions[c] = &session{
+		user:          user,
+		bytesReceived: c.BytesReceived(),
+		bytesSent:     c.BytesSent(),
+	}
+
+	if _, ok := a.users[user]; !ok {
+		a.users[user] = &usage{}
+	}
+}
+
+// Unregister stops counting bytes transferred by a session,
+// adding them to the totals of its user.
+func (a *Accountant) Unregister(c Counter) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.resetIfExpired()
+
+	s, ok := a.sessions[c]
+	if !ok {
+		return
+	}
+
+	delete(a.sessions, c)
+
+	received, sent := s.delta(c)
+	u := a.users[s.user]
+	u.bytesReceived += received
+	u.bytesSent += sent
+}
+
+// APIUsersList returns bandwidth usage of users.
+func (a *Accountant) APIUsersList() *defs.APIBandwidthUserList {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.resetIfExpired()
+
+	items := make(map[string]*defs.APIBandwidthUser)
+
+	for user, u := range a.users {
+		items[user] = &defs.APIBandwidthUser{
+			User:          user,
+			Since:         a.since,
+			BytesReceived: u.bytesReceived,
+			BytesSent:     u.bytesSent,
+		}
+	}
+
+	// bytes of live sessions are added to totals only when sessions end
+	for c, s := range a.sessions {
+		received, sent := s.delta(c)
+		items[s.user].BytesReceived += received
+		items[s.user].BytesSent += sent
+	}
+
+	data := &defs.APIBandwidthUserList{
+		Items: make([]*defs.APIBandwidthUser, 0, len(items)),
+	}
+
+	for _, item := range items {
+		data.Items = append(data.Items, item)
+	}
+
+	sort.Slice(data.Items, func(i, j int) bool {
+		return data.Items[i].User < data.Items[j].User
+	})
+
+	return data
+}
+
+func (a *Accountant) resetIfExpired() {
+	if a.ResetPeriod == 0 {
+		return
+	}
+
+	elapsed := timeNow().Sub(a.since)
+	if elapsed < a.ResetPeriod {
+		return
+	}
+
+	// keep periods aligned to the initial time
+	a.since = a.since.Add((elapsed / a.ResetPeriod) * a.ResetPeriod)
+
+	a.users = make(map[string]*usage)
+
+	for c, s := range a.sessions {
+		s.bytesReceived = c.BytesReceived()
+		s.bytesSent = c.BytesSent()
+		a.users[s.user] = &usage{}
+	}
+}
+
+func (s *session) delta(c Counter) (uint64, uint64) {
+	received := c.BytesReceived()
+	sent := c.BytesSent()
+
+	// counters of closed connections might not be available anymore
+	if received < s.bytesReceived {
+		received = s.bytesReceived
+	}
+	if sent < s.bytesSent {
+		sent = s.bytesSent
+	}
+
+	return received - s.bytesReceived, sent - s.bytesSent
+}
@@ -0,0 +1,93 @@
+package bandwidth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+type dummyCounter struct {
+	bytesReceived uint64
+	bytesSent     uint64
+}
+
+func (c *dummyCounter) BytesReceived() uint64 {
+	return c.bytesReceived
+}
+
+func (c *dummyCounter) BytesSent() uint64 {
+	return c.bytesSent
+}
+
+func TestAccountant(t *testing.T) {
+	now := time.Date(2009, 5, 20, 22, 15, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	a := &Accountant{ResetPeriod: time.Hour}
+	a.Initialize()
+
+	c1 := &dummyCounter{bytesReceived: 100, bytesSent: 50}
+	c2 := &dummyCounter{}
+	c3 := &dummyCounter{}
+
+	a.Register("user1", c1)
+	a.Register("user1", c2)
+	a.Register("", c3)
+
+	c1.bytesReceived += 1000
+	c2.bytesSent += 300
+	c3.bytesSent += 500
+
+	a.Unregister(c2)
+	c2.bytesSent += 300
+
+	require.Equal(t, &defs.APIBandwidthUserList{
+		Items: []*defs.APIBandwidthUser{{
+			User:          "user1",
+			Since:         now,
+			BytesReceived: 1000,
+			BytesSent:     300,
+		}},
+	}, a.APIUsersList())
+
+	a.Register("user2", c3)
+	c3.bytesReceived += 200
+
+	now = now.Add(90 * time.Minute)
+	c1.bytesSent += 400
+
+	require.Equal(t, &defs.APIBandwidthUserList{
+		Items: []*defs.APIBandwidthUser{
+			{
+				User:  "user1",
+				Since: time.Date(2009, 5, 20, 23, 15, 0, 0, time.UTC),
+			},
+			{
+				User:  "user2",
+				Since: time.Date(2009, 5, 20, 23, 15, 0, 0, time.UTC),
+			},
+		},
+	}, a.APIUsersList())
+
+	c1.bytesSent += 20
+	a.Unregister(c1)
+	a.Unregister(c3)
+
+	require.Equal(t, &defs.APIBandwidthUserList{
+		Items: []*defs.APIBandwidthUser{
+			{
+				User:      "user1",
+				Since:     time.Date(2009, 5, 20, 23, 15, 0, 0, time.UTC),
+				BytesSent: 20,
+			},
+			{
+				User:  "user2",
+				Since: time.Date(2009, 5, 20, 23, 15, 0, 0, time.UTC),
+			},
+		},
+	}, a.APIUsersList())
+}
@@ -0,0 +1,429 @@
+// Package benchmark contains a load generator.
+package benchmark
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	// header + counter + timestamp
+	minPacketSize = 1 + 8 + 8
+
+	sendPeriod = 10 * time.Millisecond
+)
+
+// Benchmark spawns synthetic publishers and readers against a RTSP server
+// and measures latency and packet loss.
+// Every packet contains a counter and the time it was sent.
+type Benchmark struct {
+	Address    string
+	Publishers int
+	Readers    int
+	Codec      string
+	Bitrate    int // kbit/s
+	PacketSize int
+	Duration   time.Duration
+	Out        io.Writer
+	Parent     logger.Writer
+
+	publishers []*publisher
+	readers    []*reader
+}
+
+// Log implements logger.Writer.
+func (b *Benchmark) Log(level logger.Level, format string, args ...interface{}) {
+	b.Parent.Log(level, "[benchmark] "+format, args...)
+}
+
+func (b *Benchmark) newMedia() (*description.Media, error) {
+	switch b.Codec {
+	case "h264":
+		return &description.Media{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			}},
+		}, nil
+
+	case "opus":
+		return &description.Media{
+			Type: description.MediaTypeAudio,
+			Formats: []format.Format{&format.Opus{
+				PayloadTyp:   96,
+				ChannelCount: 2,
+			}},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported codec: '%s'", b.Codec)
+	}
+}
+
+func (b *Benchmark) pathURL(i int) string {
+	return b.Address + "/benchmark_" + strconv.FormatInt(int64(i), 10)
+}
+
+// Run runs the benchmark.
+func (b *Benchmark) Run() error {
+	if b.Publishers <= 0 {
+		return fmt.Errorf("publisher count must be greater than zero")
+	}
+	if b.Readers < 0 {
+		return fmt.Errorf("reader count must be greater than or equal to zero")
+	}
+	if b.Bitrate <= 0 {
+		return fmt.Errorf("bitrate must be greater than zero")
+	}
+	if b.PacketSize < minPacketSize {
+		return fmt.Errorf("packet size must be greater than or equal to %d", minPacketSize)
+	}
+
+	_, err := b.newMedia()
+	if err != nil {
+		return err
+	}
+
+	defer b.close()
+
+	for i := 0; i < b.Publishers; i++ {
+		media, _ := b.newMedia()
+
+		p := &publisher{
+			url:        b.pathURL(i),
+			media:      media,
+			bitrate:    b.Bitrate,
+			packetSize: b.PacketSize,
+		}
+		err = p.initialize()
+		if err != nil {
+			return fmt.Errorf("publisher %d: %w", i, err)
+		}
+		b.publishers = append(b.publishers, p)
+	}
+
+	b.Log(logger.Info, "%d %s started", b.Publishers, plural(b.Publishers, "publisher"))
+
+	// readers are distributed among publishers
+	for i := 0; i < b.Readers; i++ {
+		r := &reader{
+			url: b.pathURL(i % b.Publishers),
+		}
+		err = r.initialize()
+		if err != nil {
+			return fmt.Errorf("reader %d: %w", i, err)
+		}
+		b.readers = append(b.readers, r)
+	}
+
+	b.Log(logger.Info, "%d %s started", b.Readers, plural(b.Readers, "reader"))
+
+	start := time.Now()
+
+	for _, p := range b.publishers {
+		p.start()
+	}
+
+	select {
+	case <-time.After(b.Duration):
+	case err = <-b.anyPublisherError():
+	}
+
+	elapsed := time.Since(start)
+
+	b.close()
+	b.printStats(elapsed)
+
+	return err
+}
+
+func (b *Benchmark) anyPublisherError() chan error {
+	ch := make(chan error, len(b.publishers))
+	for _, p := range b.publishers {
+		go func(p *publisher) {
+			err := <-p.done
+			if err != nil {
+				ch <- err
+			}
+		}(p)
+	}
+	return ch
+}
+
+func (b *Benchmark) close() {
+	for _, p := range b.publishers {
+		p.close()
+	}
+
+	for _, r := range b.readers {
+		r.close()
+	}
+}
+
+func (b *Benchmark) printStats(elapsed time.Duration) {
+	fmt.Fprintf(b.Out, "statistics over %v:\n", elapsed.Round(time.Millisecond))
+
+	if len(b.readers) == 0 {
+		fmt.Fprintf(b.Out, "  no readers\n")
+		return
+	}
+
+	var latencies []time.Duration
+	var received uint64
+	var lost uint64
+	var bytes uint64
+
+	for _, r := range b.readers {
+		r.mutex.Lock()
+		latencies = append(latencies, r.latencies...)
+		received += r.received
+		lost += r.lost()
+		bytes += r.bytes
+		r.mutex.Unlock()
+	}
+
+	fmt.Fprintf(b.Out, "  received: %d packets, %.1f kbit/s per reader\n",
+		received, float64(bytes)*8/1000/elapsed.Seconds()/float64(len(b.readers)))
+
+	lossRatio := float64(0)
+	if received+lost != 0 {
+		lossRatio = float64(lost) / float64(received+lost)
+	}
+	fmt.Fprintf(b.Out, "  lost: %d packets (%.2f%%)\n", lost, lossRatio*100)
+
+	if len(latencies) == 0 {
+		return
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+
+	fmt.Fprintf(b.Out, "  latency: avg %v, p50 %v, p99 %v, max %v\n",
+		(sum / time.Duration(len(latencies))).Round(time.Microsecond),
+		latencies[len(latencies)/2].Round(time.Microsecond),
+		latencies[len(latencies)*99/100].Round(time.Microsecond),
+		latencies[len(latencies)-1].Round(time.Microsecond))
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
+
+type publisher struct {
+	url        string
+	media      *description.Media
+	bitrate    int
+	packetSize int
+
+	client    *gortsplib.Client
+	terminate chan struct{}
+	done      chan error
+}
+
+func (p *publisher) initialize() error {
+	p.client = &gortsplib.Client{}
+
+	err := p.client.StartRecording(p.url, &description.Session{Medias: []*description.Media{p.media}})
+	if err != nil {
+		return err
+	}
+
+	p.terminate = make(chan struct{})
+	p.done = make(chan error, 1)
+
+	return nil
+}
+
+func (p *publisher) start() {
+	go p.run()
+}
+
+func (p *publisher) close() {
+	select {
+	case <-p.terminate:
+		return
+	default:
+	}
+
+	close(p.terminate)
+	p.client.Close()
+}
+
+func (p *publisher) run() {
+	p.done <- p.runInner()
+	close(p.done)
+}
+
+func (p *publisher) runInner() error {
+	clockRate := p.media.Formats[0].ClockRate()
+	packetsPerSecond := float64(p.bitrate*1000) / float64(p.packetSize*8)
+
+	ticker := time.NewTicker(sendPeriod)
+	defer ticker.Stop()
+
+	start := time.Now()
+	counter := uint64(0)
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.terminate:
+			return nil
+		}
+
+		now := time.Now()
+		target := uint64(now.Sub(start).Seconds() * packetsPerSecond)
+
+		for ; counter < target; counter++ {
+			payload := make([]byte, p.packetSize)
+			payload[0] = 0x65 // H264 IDR, ignored by other codecs
+			binary.BigEndian.PutUint64(payload[1:], counter)
+			binary.BigEndian.PutUint64(payload[9:], uint64(now.UnixNano()))
+
+			err := p.client.WritePacketRTP(p.media, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: uint16(counter),
+					Timestamp:      uint32(now.Sub(start).Seconds() * float64(clockRate)),
+					SSRC:           1,
+				},
+				Payload: payload,
+			})
+			if err != nil {
+				select {
+				case <-p.terminate:
+					return nil
+				default:
+				}
+				return err
+			}
+		}
+	}
+}
+
+type reader struct {
+	url string
+
+	client *gortsplib.Client
+
+	mutex      sync.Mutex
+	received   uint64
+	bytes      uint64
+	first      uint64
+	last       uint64
+	latencies  []time.Duration
+	closed     bool
+	hasPackets bool
+}
+
+func (r *reader) initialize() error {
+	r.client = &gortsplib.Client{}
+
+	u, err := base.ParseURL(r.url)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.Start(u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+
+	desc, _, err := r.client.Describe(u)
+	if err != nil {
+		r.client.Close()
+		return err
+	}
+
+	err = r.client.SetupAll(desc.BaseURL, desc.Medias)
+	if err != nil {
+		r.client.Close()
+		return err
+	}
+
+	r.client.OnPacketRTPAny(func(_ *description.Media, _ format.Format, pkt *rtp.Packet) {
+		r.onPacket(pkt, time.Now())
+	})
+
+	_, err = r.client.Play(nil)
+	if err != nil {
+		r.client.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (r *reader) close() {
+	r.mutex.Lock()
+	closed := r.closed
+	r.closed = true
+	r.mutex.Unlock()
+
+	if !closed {
+		r.client.Close()
+	}
+}
+
+func (r *reader) onPacket(pkt *rtp.Packet, now time.Time) {
+	if len(pkt.Payload) < minPacketSize {
+		return
+	}
+
+	counter := binary.BigEndian.Uint64(pkt.Payload[1:])
+	sent := time.Unix(0, int64(binary.BigEndian.Uint64(pkt.Payload[9:])))
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	if !r.hasPackets {
+		r.hasPackets = true
+		r.first = counter
+	}
+	if counter > r.last {
+		r.last = counter
+	}
+
+	r.received++
+	r.bytes += uint64(len(pkt.Payload))
+	r.latencies = append(r.latencies, now.Sub(sent))
+}
+
+func (r *reader) lost() uint64 {
+	if !r.hasPackets {
+		return 0
+	}
+
+	expected := r.last - r.first + 1
+	if r.received >= expected {
+		return 0
+	}
+	return expected - r.received
+}
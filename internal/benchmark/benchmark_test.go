@@ -0,0 +1,126 @@
+package benchmark
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+// testServer is a server that routes a single stream from a publisher to readers.
+type testServer struct {
+	s      *gortsplib.Server
+	mutex  sync.Mutex
+	stream *gortsplib.ServerStream
+}
+
+func (sh *testServer) OnDescribe(_ *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	if sh.stream == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, nil
+	}
+	return &base.Response{StatusCode: base.StatusOK}, sh.stream, nil
+}
+
+func (sh *testServer) OnAnnounce(ctx *gortsplib.ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	sh.stream = gortsplib.NewServerStream(sh.s, ctx.Description)
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+func (sh *testServer) OnSetup(_ *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	return &base.Response{StatusCode: base.StatusOK}, sh.stream, nil
+}
+
+func (sh *testServer) OnPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+func (sh *testServer) OnRecord(ctx *gortsplib.ServerHandlerOnRecordCtx) (*base.Response, error) {
+	ctx.Session.OnPacketRTPAny(func(medi *description.Media, _ format.Format, pkt *rtp.Packet) {
+		sh.stream.WritePacketRTP(medi, pkt) //nolint:errcheck
+	})
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+func TestBenchmark(t *testing.T) {
+	for _, codec := range []string{"h264", "opus"} {
+		t.Run(codec, func(t *testing.T) {
+			h := &testServer{}
+			h.s = &gortsplib.Server{
+				Handler:     h,
+				RTSPAddress: "127.0.0.1:8555",
+			}
+
+			err := h.s.Start()
+			require.NoError(t, err)
+			defer h.s.Close()
+
+			var buf bytes.Buffer
+
+			b := &Benchmark{
+				Address:    "rtsp://127.0.0.1:8555",
+				Publishers: 1,
+				Readers:    2,
+				Codec:      codec,
+				Bitrate:    100,
+				PacketSize: 100,
+				Duration:   1 * time.Second,
+				Out:        &buf,
+				Parent:     test.NilLogger,
+			}
+			err = b.Run()
+			require.NoError(t, err)
+
+			out := buf.String()
+			require.Contains(t, out, "  lost: 0 packets (0.00%)\n")
+			require.Contains(t, out, "  latency: avg ")
+			require.NotContains(t, out, "  received: 0 packets")
+		})
+	}
+}
+
+func TestBenchmarkErrors(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		b    Benchmark
+		err  string
+	}{
+		{
+			"publishers",
+			Benchmark{Codec: "h264", Bitrate: 100, PacketSize: 100},
+			"publisher count must be greater than zero",
+		},
+		{
+			"packet size",
+			Benchmark{Codec: "h264", Publishers: 1, Bitrate: 100, PacketSize: 10},
+			"packet size must be greater than or equal to 17",
+		},
+		{
+			"codec",
+			Benchmark{Codec: "vp8", Publishers: 1, Bitrate: 100, PacketSize: 100},
+			"unsupported codec: 'vp8'",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			err := ca.b.Run()
+			require.EqualError(t, err, ca.err)
+		})
+	}
+}
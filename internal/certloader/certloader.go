@@ -2,19 +2,34 @@
 package certloader
 
 import (
+	"bytes"
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
 	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
 
 	"github.com/bluenviron/mediamtx/internal/confwatcher"
 	"github.com/bluenviron/mediamtx/internal/logger"
 )
 
+const (
+	ocspRefreshPeriod  = 12 * time.Hour
+	ocspRetryPause     = 1 * time.Minute
+	ocspRequestTimeout = 10 * time.Second
+)
+
 // CertLoader is a certificate loader. It watches for changes to the certificate and key files.
 type CertLoader struct {
 	log                     logger.Writer
 	certWatcher, keyWatcher *confwatcher.ConfWatcher
 	certPath, keyPath       string
 	done                    chan struct{}
+	ocspRefresh             chan struct{}
 
 	cert   *tls.Certificate
 	certMu sync.RWMutex
@@ -65,6 +80,13 @@ func (cl *CertLoader) Close() {
 	cl.cert = nil
 }
 
+// EnableOCSPStapling enables the periodic retrieval of an OCSP response from the
+// responder of the certificate issuer, and its stapling into TLS handshakes.
+func (cl *CertLoader) EnableOCSPStapling() {
+	cl.ocspRefresh = make(chan struct{}, 1)
+	go cl.runOCSP()
+}
+
 // GetCertificate returns a function that returns the certificate for use in a tls.Config.
 func (cl *CertLoader) GetCertificate() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 	return func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
@@ -89,6 +111,7 @@ func (cl *CertLoader) watch() {
 			cl.certMu.Unlock()
 
 			cl.log.Log(logger.Info, "certificate reloaded after change to %s", cl.certPath)
+			cl.triggerOCSPRefresh()
 		case <-cl.keyWatcher.Watch():
 			cert, err := tls.LoadX509KeyPair(cl.certPath, cl.keyPath)
 			if err != nil {
@@ -101,8 +124,120 @@ func (cl *CertLoader) watch() {
 			cl.certMu.Unlock()
 
 			cl.log.Log(logger.Info, "certificate reloaded after change to %s", cl.keyPath)
+			cl.triggerOCSPRefresh()
+		case <-cl.done:
+			return
+		}
+	}
+}
+
+func (cl *CertLoader) triggerOCSPRefresh() {
+	if cl.ocspRefresh != nil {
+		select {
+		case cl.ocspRefresh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (cl *CertLoader) runOCSP() {
+	for {
+		cl.certMu.RLock()
+		cert := cl.cert
+		cl.certMu.RUnlock()
+
+		if cert == nil {
+			return
+		}
+
+		var pause time.Duration
+
+		staple, nextUpdate, err := fetchOCSPStaple(cert)
+		if err != nil {
+			cl.log.Log(logger.Warn, "unable to fetch OCSP response: %v", err)
+			pause = ocspRetryPause
+		} else {
+			cl.certMu.Lock()
+			if cl.cert == cert {
+				stapled := *cert
+				stapled.OCSPStaple = staple
+				cl.cert = &stapled
+			}
+			cl.certMu.Unlock()
+
+			pause = ocspRefreshPeriod
+			if !nextUpdate.IsZero() {
+				if untilNext := time.Until(nextUpdate) / 2; untilNext < pause {
+					pause = untilNext
+				}
+			}
+			if pause < ocspRetryPause {
+				pause = ocspRetryPause
+			}
+		}
+
+		t := time.NewTimer(pause)
+
+		select {
+		case <-t.C:
+		case <-cl.ocspRefresh:
+			t.Stop()
 		case <-cl.done:
+			t.Stop()
 			return
 		}
 	}
 }
+
+func fetchOCSPStaple(cert *tls.Certificate) ([]byte, time.Time, error) {
+	if len(cert.Certificate) < 2 {
+		return nil, time.Time{}, fmt.Errorf("certificate chain doesn't contain the issuer")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if len(leaf.OCSPServer) == 0 {
+		return nil, time.Time{}, fmt.Errorf("certificate doesn't contain any OCSP server")
+	}
+
+	issuer, err := x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	hc := &http.Client{Timeout: ocspRequestTimeout}
+
+	res, err := hc.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("OCSP responder replied with code %d", res.StatusCode)
+	}
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	ocspRes, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if ocspRes.Status != ocsp.Good {
+		return nil, time.Time{}, fmt.Errorf("OCSP responder reported that the certificate is not valid")
+	}
+
+	return raw, ocspRes.NextUpdate, nil
+}
@@ -1,13 +1,24 @@
 package certloader
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
 )
 
 func TestCertReload(t *testing.T) {
@@ -50,3 +61,95 @@ func TestCertReload(t *testing.T) {
 	require.NotNil(t, cert)
 	require.Equal(t, &testData, cert)
 }
+
+func TestOCSPStapling(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "testca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		byts, err2 := io.ReadAll(r.Body)
+		require.NoError(t, err2)
+
+		req, err2 := ocsp.ParseRequest(byts)
+		require.NoError(t, err2)
+
+		res, err2 := ocsp.CreateResponse(caCert, caCert, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now(),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}, caKey)
+		require.NoError(t, err2)
+
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(res) //nolint:errcheck
+	}))
+	defer responder.Close()
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		OCSPServer:   []string{responder.URL},
+	}, caCert, &leafKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	leafKeyDER, err := x509.MarshalECPrivateKey(leafKey)
+	require.NoError(t, err)
+
+	certPath, err := test.CreateTempFile(append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})...))
+	require.NoError(t, err)
+	defer os.Remove(certPath)
+
+	keyPath, err := test.CreateTempFile(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: leafKeyDER}))
+	require.NoError(t, err)
+	defer os.Remove(keyPath)
+
+	loader, err := New(certPath, keyPath, test.NilLogger)
+	require.NoError(t, err)
+	defer loader.Close()
+
+	loader.EnableOCSPStapling()
+
+	getCert := loader.GetCertificate()
+
+	for i := 0; ; i++ {
+		cert, err := getCert(nil)
+		require.NoError(t, err)
+
+		if cert.OCSPStaple != nil {
+			leaf, err2 := x509.ParseCertificate(leafDER)
+			require.NoError(t, err2)
+
+			res, err2 := ocsp.ParseResponseForCert(cert.OCSPStaple, leaf, caCert)
+			require.NoError(t, err2)
+			require.Equal(t, ocsp.Good, res.Status)
+			break
+		}
+
+		require.Less(t, i, 100)
+		time.Sleep(20 * time.Millisecond)
+	}
+}
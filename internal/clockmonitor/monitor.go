@@ -0,0 +1,89 @@
+// Package clockmonitor contains utilities to detect jumps of clocks.
+package clockmonitor
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	// Threshold is the minimum difference between elapsed times that is considered a jump.
+	Threshold = 2 * time.Second
+
+	checkPeriod = 1 * time.Second
+)
+
+var timeNow = time.Now
+
+// wallNow returns the current time without the monotonic clock reading.
+var wallNow = func() time.Time {
+	return time.Now().Round(0)
+}
+
+func isJump(d time.Duration) bool {
+	return d <= -Threshold || d >= Threshold
+}
+
+// Monitor detects jumps of the system clock, caused for instance by NTP steps
+// or by manual adjustments, by comparing the system clock with the monotonic clock.
+type Monitor struct {
+	Parent logger.Writer
+
+	jumps     atomic.Uint64
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Monitor.
+func (m *Monitor) Initialize() {
+	m.terminate = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go m.run(timeNow(), wallNow())
+}
+
+// Close closes Monitor.
+func (m *Monitor) Close() {
+	close(m.terminate)
+	<-m.done
+}
+
+// Log implements logger.Writer.
+func (m *Monitor) Log(level logger.Level, format string, args ...interface{}) {
+	m.Parent.Log(level, "[clock monitor] "+format, args...)
+}
+
+func (m *Monitor) run(prevMono time.Time, prevWall time.Time) {
+	defer close(m.done)
+
+	t := time.NewTicker(checkPeriod)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			curMono := timeNow()
+			curWall := wallNow()
+
+			jump := curWall.Sub(prevWall) - curMono.Sub(prevMono)
+			if isJump(jump) {
+				m.jumps.Add(1)
+				m.Log(logger.Warn, "system clock jumped by %v, absolute timestamps of streams "+
+					"and names of recording segments may be inconsistent. Check time synchronization", jump)
+			}
+
+			prevMono = curMono
+			prevWall = curWall
+
+		case <-m.terminate:
+			return
+		}
+	}
+}
+
+// Jumps returns the number of detected jumps.
+func (m *Monitor) Jumps() uint64 {
+	return m.jumps.Load()
+}
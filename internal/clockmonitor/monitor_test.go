@@ -0,0 +1,41 @@
+package clockmonitor
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestMonitor(t *testing.T) {
+	var offset atomic.Int64
+
+	wallNow = func() time.Time {
+		return time.Now().Round(0).Add(time.Duration(offset.Load()))
+	}
+	defer func() {
+		wallNow = func() time.Time {
+			return time.Now().Round(0)
+		}
+	}()
+
+	logged := make(chan struct{})
+
+	m := &Monitor{
+		Parent: test.Logger(func(level logger.Level, _ string, _ ...interface{}) {
+			require.Equal(t, logger.Warn, level)
+			close(logged)
+		}),
+	}
+	m.Initialize()
+	defer m.Close()
+
+	offset.Store(int64(1 * time.Hour))
+
+	<-logged
+	require.Equal(t, uint64(1), m.Jumps())
+}
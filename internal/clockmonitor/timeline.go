@@ -0,0 +1,52 @@
+package clockmonitor
+
+import (
+	"time"
+)
+
+// Timeline detects jumps of the absolute timestamps of a stream caused by jumps of the system clock.
+// A jump is reported when both the system clock and absolute timestamps moved with respect
+// to the monotonic clock since the previous check. Absolute timestamps that are not
+// generated with the system clock (for instance, the ones taken from RTCP sender reports)
+// and bursts of data are therefore not considered jumps.
+type Timeline struct {
+	initialized bool
+	prevNTP     time.Time
+	prevMono    time.Time
+	prevWall    time.Time
+}
+
+// Check returns the jump of the given absolute timestamp with respect to the previous one,
+// or zero when there's no jump.
+func (t *Timeline) Check(ntp time.Time) time.Duration {
+	if ntp.IsZero() {
+		return 0
+	}
+
+	mono := timeNow()
+	wall := wallNow()
+
+	// remove the monotonic clock reading in order to compare wall clock times
+	ntp = ntp.Round(0)
+
+	if !t.initialized {
+		t.initialized = true
+		t.prevNTP = ntp
+		t.prevMono = mono
+		t.prevWall = wall
+		return 0
+	}
+
+	monoElapsed := mono.Sub(t.prevMono)
+	systemJump := wall.Sub(t.prevWall) - monoElapsed
+	jump := ntp.Sub(t.prevNTP) - monoElapsed
+
+	t.prevNTP = ntp
+	t.prevMono = mono
+	t.prevWall = wall
+
+	if !isJump(systemJump) || !isJump(jump) {
+		return 0
+	}
+	return jump
+}
@@ -0,0 +1,51 @@
+package clockmonitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline(t *testing.T) {
+	mono := time.Date(2009, 5, 20, 22, 15, 25, 0, time.UTC)
+	var offset time.Duration
+
+	timeNow = func() time.Time { return mono }
+	wallNow = func() time.Time { return mono.Add(offset) }
+	defer func() {
+		timeNow = time.Now
+		wallNow = func() time.Time { return time.Now().Round(0) }
+	}()
+
+	ntp := time.Date(2009, 5, 20, 22, 15, 20, 0, time.UTC)
+
+	var tl Timeline
+	require.Equal(t, time.Duration(0), tl.Check(ntp))
+
+	// burst of data
+	mono = mono.Add(1 * time.Second)
+	ntp = ntp.Add(500 * time.Millisecond)
+	require.Equal(t, time.Duration(0), tl.Check(ntp))
+
+	// timestamps not generated with the system clock
+	mono = mono.Add(1 * time.Second)
+	ntp = ntp.Add(1*time.Second + 1*time.Hour)
+	require.Equal(t, time.Duration(0), tl.Check(ntp))
+
+	// system clock moved forward
+	mono = mono.Add(1 * time.Second)
+	offset += 1 * time.Hour
+	ntp = ntp.Add(1*time.Second + 1*time.Hour)
+	require.Equal(t, 1*time.Hour, tl.Check(ntp))
+
+	mono = mono.Add(1 * time.Second)
+	ntp = ntp.Add(1 * time.Second)
+	require.Equal(t, time.Duration(0), tl.Check(ntp))
+
+	// system clock moved backwards
+	mono = mono.Add(1 * time.Second)
+	offset -= 10 * time.Second
+	ntp = ntp.Add(1*time.Second - 10*time.Second)
+	require.Equal(t, -10*time.Second, tl.Check(ntp))
+}
@@ -0,0 +1,225 @@
+// Package cmafpush contains an output that pushes a stream in CMAF format to a HTTP server.
+package cmafpush
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/cmafsegmenter"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+const (
+	initName             = "init.mp4"
+	playlistName         = "index.m3u8"
+	playlistSegmentCount = 7
+)
+
+type playlistSegment struct {
+	sequenceNumber uint64
+	name           string
+	duration       time.Duration
+}
+
+// pushSegment is a segment that is streamed to the server while it is generated.
+type pushSegment struct {
+	playlistSegment
+	pw   *io.PipeWriter
+	done chan error
+}
+
+// WriteChunk implements cmafsegmenter.SegmentWriter.
+func (s *pushSegment) WriteChunk(chunk []byte) error {
+	_, err := s.pw.Write(chunk)
+	return err
+}
+
+// Close implements cmafsegmenter.SegmentWriter.
+func (s *pushSegment) Close() error {
+	s.pw.Close()
+	return <-s.done
+}
+
+// Output pushes a stream to a HTTP server in CMAF format, as defined by the DASH-IF
+// ingest specification (interface 1) and by HLS push.
+// An initialization segment is uploaded first, then media segments are uploaded
+// while they are generated, one CMAF chunk at a time, with chunked transfer encoding.
+// Optionally, a HLS playlist that lists the last segments is uploaded too.
+type Output struct {
+	URL             string
+	Method          string
+	User            string
+	Pass            string
+	SegmentDuration time.Duration
+	ChunkDuration   time.Duration
+	Playlist        bool
+	Timeout         time.Duration
+	WriteQueueSize  int
+	PathName        string
+	Stream          *stream.Stream
+	Parent          logger.Writer
+
+	ctx        context.Context
+	ctxCancel  func()
+	httpClient *http.Client
+	segmenter  *cmafsegmenter.Segmenter
+
+	// accessed by the segmenter routine only
+	nextSegment      uint64
+	playlistSegments []playlistSegment
+}
+
+// Initialize initializes Output.
+func (o *Output) Initialize() {
+	o.ctx, o.ctxCancel = context.WithCancel(context.Background())
+
+	o.httpClient = &http.Client{
+		Transport: &http.Transport{
+			ResponseHeaderTimeout: o.Timeout,
+		},
+	}
+
+	o.segmenter = &cmafsegmenter.Segmenter{
+		SegmentDuration:   o.SegmentDuration,
+		ChunkDuration:     o.ChunkDuration,
+		WriteQueueSize:    o.WriteQueueSize,
+		PathName:          o.PathName,
+		Stream:            o.Stream,
+		OnInit:            o.onInit,
+		OnSegmentCreate:   o.onSegmentCreate,
+		OnSegmentComplete: o.onSegmentComplete,
+		Parent:            o,
+	}
+	o.segmenter.Initialize()
+
+	o.Log(logger.Info, "pushing %s to %s",
+		defs.MediasInfo(o.Stream.Desc().Medias), o.URL)
+}
+
+// Close closes Output.
+func (o *Output) Close() {
+	o.segmenter.Close()
+	o.ctxCancel()
+	o.httpClient.CloseIdleConnections()
+}
+
+// Log implements logger.Writer.
+func (o *Output) Log(level logger.Level, format string, args ...interface{}) {
+	o.Parent.Log(level, "[CMAF push] "+format, args...)
+}
+
+func (o *Output) objectURL(name string) string {
+	return strings.TrimSuffix(o.URL, "/") + "/" + name
+}
+
+func (o *Output) upload(name string, contentType string, body io.Reader) error {
+	req, err := http.NewRequestWithContext(o.ctx, o.Method, o.objectURL(name), body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	if o.User != "" {
+		req.SetBasicAuth(o.User, o.Pass)
+	}
+
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unable to upload '%s': bad status code: %d", name, res.StatusCode)
+	}
+
+	return nil
+}
+
+func (o *Output) onInit(init []byte) error {
+	return o.upload(initName, "video/mp4", bytes.NewReader(init))
+}
+
+func (o *Output) onSegmentCreate(_ time.Duration) (cmafsegmenter.SegmentWriter, error) {
+	pr, pw := io.Pipe()
+
+	seg := &pushSegment{
+		playlistSegment: playlistSegment{
+			sequenceNumber: o.nextSegment,
+			name:           "segment_" + strconv.FormatUint(o.nextSegment, 10) + ".m4s",
+		},
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+	o.nextSegment++
+
+	go func() {
+		err := o.upload(seg.name, "video/mp4", pr)
+
+		// unblock pending writes
+		if err != nil {
+			pr.CloseWithError(err)
+		} else {
+			pr.Close()
+		}
+
+		seg.done <- err
+	}()
+
+	return seg, nil
+}
+
+func (o *Output) onSegmentComplete(w cmafsegmenter.SegmentWriter, duration time.Duration) {
+	seg := w.(*pushSegment)
+
+	o.Log(logger.Debug, "segment %s uploaded", seg.name)
+
+	if !o.Playlist {
+		return
+	}
+
+	seg.duration = duration
+	o.playlistSegments = append(o.playlistSegments, seg.playlistSegment)
+	if len(o.playlistSegments) > playlistSegmentCount {
+		o.playlistSegments = o.playlistSegments[1:]
+	}
+
+	err := o.upload(playlistName, "application/vnd.apple.mpegurl", strings.NewReader(o.generatePlaylist()))
+	if err != nil {
+		o.Log(logger.Warn, "unable to upload playlist: %v", err)
+	}
+}
+
+func (o *Output) generatePlaylist() string {
+	targetDuration := 1
+	for _, seg := range o.playlistSegments {
+		d := int(math.Ceil(seg.duration.Seconds()))
+		if d > targetDuration {
+			targetDuration = d
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:7\n")
+	b.WriteString("#EXT-X-TARGETDURATION:" + strconv.FormatInt(int64(targetDuration), 10) + "\n")
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:" + strconv.FormatUint(o.playlistSegments[0].sequenceNumber, 10) + "\n")
+	b.WriteString("#EXT-X-MAP:URI=\"" + initName + "\"\n")
+
+	for _, seg := range o.playlistSegments {
+		b.WriteString("#EXTINF:" + strconv.FormatFloat(seg.duration.Seconds(), 'f', 5, 64) + ",\n")
+		b.WriteString(seg.name + "\n")
+	}
+
+	return b.String()
+}
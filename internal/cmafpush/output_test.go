@@ -0,0 +1,116 @@
+package cmafpush
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestOutput(t *testing.T) {
+	var mutex sync.Mutex
+	uploads := make(map[string][]byte)
+	segmentDone := make(chan struct{}, 10)
+
+	ln, err := net.Listen("tcp", "localhost:4567")
+	require.NoError(t, err)
+
+	s := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPut, r.Method)
+
+			user, pass, ok := r.BasicAuth()
+			require.True(t, ok)
+			require.Equal(t, "myuser", user)
+			require.Equal(t, "mypass", pass)
+
+			byts, err2 := io.ReadAll(r.Body)
+			require.NoError(t, err2)
+
+			mutex.Lock()
+			uploads[r.URL.Path] = byts
+			mutex.Unlock()
+
+			if strings.HasSuffix(r.URL.Path, ".m3u8") {
+				segmentDone <- struct{}{}
+			}
+		}),
+	}
+	go s.Serve(ln)
+	defer s.Close()
+
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	o := &Output{
+		URL:             "http://localhost:4567/ingest/mypath",
+		Method:          http.MethodPut,
+		User:            "myuser",
+		Pass:            "mypass",
+		SegmentDuration: 1 * time.Second,
+		ChunkDuration:   100 * time.Millisecond,
+		Playlist:        true,
+		Timeout:         10 * time.Second,
+		WriteQueueSize:  512,
+		PathName:        "mypath",
+		Stream:          strm,
+		Parent:          test.NilLogger,
+	}
+	o.Initialize()
+
+	for i := 0; i < 4; i++ {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 500 * time.Millisecond,
+				NTP: time.Now(),
+			},
+			AU: [][]byte{
+				test.FormatH264.SPS,
+				test.FormatH264.PPS,
+				{5, 1}, // IDR
+			},
+		})
+	}
+
+	select {
+	case <-segmentDone:
+	case <-time.After(5 * time.Second):
+		t.Errorf("timed out")
+	}
+
+	o.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.Equal(t, "ftyp", string(uploads["/ingest/mypath/init.mp4"][4:8]))
+	require.Equal(t, "moof", string(uploads["/ingest/mypath/segment_0.m4s"][4:8]))
+	require.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-TARGETDURATION:1\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-MAP:URI=\"init.mp4\"\n"+
+		"#EXTINF:1.00000,\n"+
+		"segment_0.m4s\n",
+		string(uploads["/ingest/mypath/index.m3u8"]))
+}
@@ -0,0 +1,309 @@
+// Package cmafsegmenter contains a component that converts a stream into CMAF segments.
+package cmafsegmenter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4/seekablebuffer"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recorder"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+// segments are identified by the recorder through their path.
+const segmentPathFormat = "%path/%Y-%m-%d_%H-%M-%S-%f"
+
+func durationGoToMp4(v time.Duration, timeScale uint32) uint64 {
+	timeScale64 := uint64(timeScale)
+	secs := v / time.Second
+	dec := v % time.Second
+	return uint64(secs)*timeScale64 + uint64(dec)*timeScale64/uint64(time.Second)
+}
+
+// SegmentWriter receives the chunks of a segment.
+type SegmentWriter interface {
+	// WriteChunk writes a chunk, made of a moof and a mdat box.
+	WriteChunk(chunk []byte) error
+
+	// Close is called when the segment ends.
+	Close() error
+}
+
+// OnInitFunc is the prototype of the function passed as OnInit.
+type OnInitFunc = func(init []byte) error
+
+// OnSegmentCreateFunc is the prototype of the function passed as OnSegmentCreate.
+type OnSegmentCreateFunc = func(start time.Duration) (SegmentWriter, error)
+
+// OnSegmentCompleteFunc is the prototype of the function passed as OnSegmentComplete.
+type OnSegmentCompleteFunc = func(w SegmentWriter, duration time.Duration)
+
+// Segmenter converts a stream into CMAF segments, that are generated one chunk at a time.
+// Timestamps of segments are continuous, even when the segmenter is restarted after an error.
+type Segmenter struct {
+	SegmentDuration   time.Duration
+	ChunkDuration     time.Duration
+	WriteQueueSize    int
+	PathName          string
+	Stream            *stream.Stream
+	OnInit            OnInitFunc
+	OnSegmentCreate   OnSegmentCreateFunc
+	OnSegmentComplete OnSegmentCompleteFunc
+	Parent            logger.Writer
+
+	recorder *recorder.Recorder
+
+	// accessed by the recorder routine only
+	lastInit   []byte
+	timeScales map[int]uint32
+	offset     time.Duration
+	segments   map[string]*segment
+}
+
+// Initialize initializes Segmenter.
+func (s *Segmenter) Initialize() {
+	if s.OnSegmentComplete == nil {
+		s.OnSegmentComplete = func(SegmentWriter, time.Duration) {}
+	}
+
+	s.segments = make(map[string]*segment)
+
+	s.recorder = &recorder.Recorder{
+		WriteQueueSize:  s.WriteQueueSize,
+		PathFormat:      segmentPathFormat,
+		Format:          conf.RecordFormatFMP4,
+		PartDuration:    s.ChunkDuration,
+		SegmentDuration: s.SegmentDuration,
+		Storage:         &segmenterStorage{s: s},
+		PathName:        s.PathName,
+		Stream:          s.Stream,
+		OnSegmentComplete: func(fpath string, duration time.Duration) {
+			s.onSegmentComplete(fpath, duration)
+		},
+		Parent: s,
+	}
+	s.recorder.Initialize()
+}
+
+// Close closes Segmenter.
+func (s *Segmenter) Close() {
+	s.recorder.Close()
+}
+
+// Log implements logger.Writer.
+func (s *Segmenter) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, format, args...)
+}
+
+func (s *Segmenter) setInit(init []byte) error {
+	if bytes.Equal(init, s.lastInit) {
+		return nil
+	}
+
+	var fi fmp4.Init
+	err := fi.Unmarshal(bytes.NewReader(init))
+	if err != nil {
+		return err
+	}
+
+	err = s.OnInit(init)
+	if err != nil {
+		return err
+	}
+
+	s.timeScales = make(map[int]uint32)
+	for _, track := range fi.Tracks {
+		s.timeScales[track.ID] = track.TimeScale
+	}
+
+	s.lastInit = init
+	return nil
+}
+
+// shiftChunk moves timestamps of a chunk from the timeline of the segment
+// to the timeline of the segmenter.
+func (s *Segmenter) shiftChunk(chunk []byte, start time.Duration) ([]byte, error) {
+	var parts fmp4.Parts
+	err := parts.Unmarshal(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf seekablebuffer.Buffer
+
+	for _, part := range parts {
+		for _, track := range part.Tracks {
+			track.BaseTime += durationGoToMp4(start, s.timeScales[track.ID])
+		}
+
+		err = part.Marshal(&buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *Segmenter) onSegmentComplete(fpath string, duration time.Duration) {
+	seg, ok := s.segments[fpath]
+	if !ok {
+		return
+	}
+	delete(s.segments, fpath)
+
+	if seg.w == nil {
+		return
+	}
+
+	s.offset = seg.start + duration
+	s.OnSegmentComplete(seg.w, duration)
+}
+
+type segmenterStorage struct {
+	s *Segmenter
+}
+
+// Create implements recordstorage.Storage.
+func (st *segmenterStorage) Create(fpath string) (io.WriteCloser, error) {
+	seg := &segment{
+		s:     st.s,
+		fpath: fpath,
+	}
+	st.s.segments[fpath] = seg
+	return seg, nil
+}
+
+// segment is a segment written by the recorder. It contains an initialization section
+// (ftyp and moov boxes) followed by chunks (moof and mdat boxes).
+type segment struct {
+	s     *Segmenter
+	fpath string
+
+	buf   []byte
+	init  []byte
+	chunk []byte
+	start time.Duration
+	w     SegmentWriter
+}
+
+// Write implements io.Writer.
+func (seg *segment) Write(p []byte) (int, error) {
+	seg.buf = append(seg.buf, p...)
+
+	for {
+		size, typ, ok, err := nextBox(seg.buf)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+
+		box := seg.buf[:size]
+		seg.buf = seg.buf[size:]
+
+		err = seg.writeBox(typ, box)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (seg *segment) writeBox(typ string, box []byte) error {
+	switch typ {
+	case "ftyp", "moov":
+		seg.init = append(seg.init, box...)
+		return nil
+
+	case "mdat":
+		seg.chunk = append(seg.chunk, box...)
+
+		if seg.w == nil {
+			err := seg.begin()
+			if err != nil {
+				return err
+			}
+		}
+
+		chunk, err := seg.s.shiftChunk(seg.chunk, seg.start)
+		seg.chunk = nil
+		if err != nil {
+			return err
+		}
+
+		return seg.w.WriteChunk(chunk)
+
+	default:
+		seg.chunk = append(seg.chunk, box...)
+		return nil
+	}
+}
+
+func (seg *segment) begin() error {
+	if seg.init == nil {
+		return fmt.Errorf("initialization section is missing")
+	}
+
+	err := seg.s.setInit(seg.init)
+	if err != nil {
+		return err
+	}
+
+	seg.start = seg.s.offset
+
+	seg.w, err = seg.s.OnSegmentCreate(seg.start)
+	return err
+}
+
+// Close implements io.Closer.
+func (seg *segment) Close() error {
+	if seg.w == nil {
+		delete(seg.s.segments, seg.fpath)
+		return nil
+	}
+
+	err := seg.w.Close()
+	if err != nil {
+		delete(seg.s.segments, seg.fpath)
+	}
+	return err
+}
+
+// nextBox returns size and type of the first box of buf, if buf contains it entirely.
+func nextBox(buf []byte) (int, string, bool, error) {
+	if len(buf) < 8 {
+		return 0, "", false, nil
+	}
+
+	size := uint64(binary.BigEndian.Uint32(buf[:4]))
+	typ := string(buf[4:8])
+	headerSize := uint64(8)
+
+	if size == 1 {
+		if len(buf) < 16 {
+			return 0, "", false, nil
+		}
+		size = binary.BigEndian.Uint64(buf[8:16])
+		headerSize = 16
+	}
+
+	if size < headerSize {
+		return 0, "", false, fmt.Errorf("invalid size of box '%s'", typ)
+	}
+
+	if uint64(len(buf)) < size {
+		return 0, "", false, nil
+	}
+
+	return int(size), typ, true, nil
+}
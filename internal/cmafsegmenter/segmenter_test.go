@@ -0,0 +1,108 @@
+package cmafsegmenter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type dummySegmentWriter struct {
+	start  time.Duration
+	chunks [][]byte
+}
+
+func (w *dummySegmentWriter) WriteChunk(chunk []byte) error {
+	w.chunks = append(w.chunks, chunk)
+	return nil
+}
+
+func (w *dummySegmentWriter) Close() error {
+	return nil
+}
+
+func TestSegmenter(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	var inits [][]byte
+	var segments []*dummySegmentWriter
+	var durations []time.Duration
+	done := make(chan struct{})
+
+	s := &Segmenter{
+		SegmentDuration: 1 * time.Second,
+		ChunkDuration:   100 * time.Millisecond,
+		WriteQueueSize:  512,
+		PathName:        "mypath",
+		Stream:          strm,
+		OnInit: func(init []byte) error {
+			inits = append(inits, init)
+			return nil
+		},
+		OnSegmentCreate: func(start time.Duration) (SegmentWriter, error) {
+			w := &dummySegmentWriter{start: start}
+			segments = append(segments, w)
+			return w, nil
+		},
+		OnSegmentComplete: func(_ SegmentWriter, duration time.Duration) {
+			durations = append(durations, duration)
+			if len(durations) == 2 {
+				close(done)
+			}
+		},
+		Parent: test.NilLogger,
+	}
+	s.Initialize()
+
+	for i := 0; i < 6; i++ {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 500 * time.Millisecond,
+				NTP: time.Now(),
+			},
+			AU: [][]byte{
+				test.FormatH264.SPS,
+				test.FormatH264.PPS,
+				{5, 1}, // IDR
+			},
+		})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("timed out")
+	}
+
+	s.Close()
+
+	require.Len(t, inits, 1)
+	require.Equal(t, "ftyp", string(inits[0][4:8]))
+	require.Equal(t, []time.Duration{1 * time.Second, 1 * time.Second}, durations[:2])
+
+	for i, seg := range segments[:2] {
+		require.Equal(t, time.Duration(i)*time.Second, seg.start)
+
+		var parts fmp4.Parts
+		err = parts.Unmarshal(seg.chunks[0])
+		require.NoError(t, err)
+		require.Equal(t, uint64(i)*90000, parts[0].Tracks[0].BaseTime)
+	}
+}
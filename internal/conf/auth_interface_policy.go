@@ -0,0 +1,40 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AuthInterfacePolicy is a list of actions that are allowed on a network interface.
+type AuthInterfacePolicy struct {
+	Interface string       `json:"interface"`
+	Actions   []AuthAction `json:"actions"`
+}
+
+// AuthInterfacePolicies is a list of AuthInterfacePolicy.
+type AuthInterfacePolicies []AuthInterfacePolicy
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *AuthInterfacePolicies) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]AuthInterfacePolicy)(s))
+}
+
+func (s AuthInterfacePolicies) validate() error {
+	seen := make(map[string]struct{})
+
+	for _, p := range s {
+		if p.Interface == "" {
+			return fmt.Errorf("'interface' of interface policies can't be empty")
+		}
+
+		if _, ok := seen[p.Interface]; ok {
+			return fmt.Errorf("interface '%s' has multiple policies", p.Interface)
+		}
+		seen[p.Interface] = struct{}{}
+	}
+
+	return nil
+}
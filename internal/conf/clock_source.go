@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ClockSource is the clockSource parameter.
+type ClockSource int
+
+// supported values.
+const (
+	ClockSourceReceiveTime ClockSource = iota
+	ClockSourceServerClock
+	ClockSourceRTCP
+)
+
+// MarshalJSON implements json.Marshaler.
+func (d ClockSource) MarshalJSON() ([]byte, error) {
+	var out string
+
+	switch d {
+	case ClockSourceServerClock:
+		out = "serverClock"
+
+	case ClockSourceRTCP:
+		out = "rtcp"
+
+	default:
+		out = "receiveTime"
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *ClockSource) UnmarshalJSON(b []byte) error {
+	var in string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	switch in {
+	case "receiveTime":
+		*d = ClockSourceReceiveTime
+
+	case "serverClock":
+		*d = ClockSourceServerClock
+
+	case "rtcp":
+		*d = ClockSourceRTCP
+
+	default:
+		return fmt.Errorf("invalid clock source '%s'", in)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *ClockSource) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
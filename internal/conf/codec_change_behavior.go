@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CodecChangeBehavior is the codecChangeBehavior parameter.
+type CodecChangeBehavior int
+
+// supported values.
+const (
+	CodecChangeBehaviorPropagate CodecChangeBehavior = iota
+	CodecChangeBehaviorDisconnectReaders
+	CodecChangeBehaviorRestartPath
+)
+
+// MarshalJSON implements json.Marshaler.
+func (d CodecChangeBehavior) MarshalJSON() ([]byte, error) {
+	var out string
+
+	switch d {
+	case CodecChangeBehaviorDisconnectReaders:
+		out = "disconnectReaders"
+
+	case CodecChangeBehaviorRestartPath:
+		out = "restartPath"
+
+	default:
+		out = "propagate"
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *CodecChangeBehavior) UnmarshalJSON(b []byte) error {
+	var in string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	switch in {
+	case "propagate":
+		*d = CodecChangeBehaviorPropagate
+
+	case "disconnectReaders":
+		*d = CodecChangeBehaviorDisconnectReaders
+
+	case "restartPath":
+		*d = CodecChangeBehaviorRestartPath
+
+	default:
+		return fmt.Errorf("invalid codec change behavior '%s'", in)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *CodecChangeBehavior) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	gourl "net/url"
 	"os"
+	"path"
 	"reflect"
 	"sort"
 	"strings"
@@ -16,7 +18,9 @@ import (
 	"github.com/bluenviron/gohlslib"
 	"github.com/bluenviron/gortsplib/v4"
 	"github.com/bluenviron/gortsplib/v4/pkg/auth"
+	"github.com/pion/webrtc/v3"
 
+	"github.com/bluenviron/mediamtx/internal/authpolicy"
 	"github.com/bluenviron/mediamtx/internal/conf/decrypt"
 	"github.com/bluenviron/mediamtx/internal/conf/env"
 	"github.com/bluenviron/mediamtx/internal/conf/yaml"
@@ -158,17 +162,33 @@ var defaultAuthInternalUsers = AuthInternalUsers{
 // WARNING: Avoid using slices directly due to https://github.com/golang/go/issues/21092
 type Conf struct {
 	// General
-	LogLevel            LogLevel        `json:"logLevel"`
-	LogDestinations     LogDestinations `json:"logDestinations"`
-	LogFile             string          `json:"logFile"`
-	ReadTimeout         StringDuration  `json:"readTimeout"`
-	WriteTimeout        StringDuration  `json:"writeTimeout"`
-	ReadBufferCount     *int            `json:"readBufferCount,omitempty"` // deprecated
-	WriteQueueSize      int             `json:"writeQueueSize"`
-	UDPMaxPayloadSize   int             `json:"udpMaxPayloadSize"`
-	RunOnConnect        string          `json:"runOnConnect"`
-	RunOnConnectRestart bool            `json:"runOnConnectRestart"`
-	RunOnDisconnect     string          `json:"runOnDisconnect"`
+	LogLevel                     LogLevel            `json:"logLevel"`
+	LogDestinations              LogDestinations     `json:"logDestinations"`
+	LogFile                      string              `json:"logFile"`
+	ReadTimeout                  StringDuration      `json:"readTimeout"`
+	WriteTimeout                 StringDuration      `json:"writeTimeout"`
+	ReadBufferCount              *int                `json:"readBufferCount,omitempty"` // deprecated
+	WriteQueueSize               int                 `json:"writeQueueSize"`
+	UDPMaxPayloadSize            int                 `json:"udpMaxPayloadSize"`
+	RunOnConnect                 string              `json:"runOnConnect"`
+	RunOnConnectRestart          bool                `json:"runOnConnectRestart"`
+	RunOnDisconnect              string              `json:"runOnDisconnect"`
+	HTTPRateLimit                int                 `json:"httpRateLimit"`
+	HTTPRateLimitBurst           int                 `json:"httpRateLimitBurst"`
+	HTTPMaxBodySize              StringSize          `json:"httpMaxBodySize"`
+	ServerHeader                 string              `json:"serverHeader"`
+	HTTPResponseHeaders          HTTPResponseHeaders `json:"httpResponseHeaders"`
+	ConnRateLimit                int                 `json:"connRateLimit"`
+	ConnRateLimitBurst           int                 `json:"connRateLimitBurst"`
+	ConnIPRateLimit              int                 `json:"connIPRateLimit"`
+	ConnIPRateLimitBurst         int                 `json:"connIPRateLimitBurst"`
+	PublicIPSource               string              `json:"publicIPSource"`
+	PublicIPRefresh              StringDuration      `json:"publicIPRefresh"`
+	PathStateFile                string              `json:"pathStateFile"`
+	BandwidthResetPeriod         StringDuration      `json:"bandwidthResetPeriod"`
+	RecordPostProcessConcurrency int                 `json:"recordPostProcessConcurrency"`
+	StaticSourceStartConcurrency int                 `json:"staticSourceStartConcurrency"`
+	PublishPathRewrites          PublishPathRewrites `json:"publishPathRewrites"`
 
 	// Authentication
 	AuthMethod                AuthMethod                  `json:"authMethod"`
@@ -178,6 +198,11 @@ type Conf struct {
 	AuthHTTPExclude           AuthInternalUserPermissions `json:"authHTTPExclude"`
 	AuthJWTJWKS               string                      `json:"authJWTJWKS"`
 	AuthJWTClaimKey           string                      `json:"authJWTClaimKey"`
+	AuthSignedURLSecret       string                      `json:"authSignedURLSecret"`
+	AuthPolicy                string                      `json:"authPolicy"`
+	AuthInterfacePolicies     AuthInterfacePolicies       `json:"authInterfacePolicies"`
+	AuthPlaybackRequireRead   bool                        `json:"authPlaybackRequireRead"`
+	AuthRotationGracePeriod   StringDuration              `json:"authRotationGracePeriod"`
 
 	// Control API
 	API               bool       `json:"api"`
@@ -185,8 +210,18 @@ type Conf struct {
 	APIEncryption     bool       `json:"apiEncryption"`
 	APIServerKey      string     `json:"apiServerKey"`
 	APIServerCert     string     `json:"apiServerCert"`
+	APITLSOptions     TLSOptions `json:"apiTLSOptions"`
 	APIAllowOrigin    string     `json:"apiAllowOrigin"`
 	APITrustedProxies IPNetworks `json:"apiTrustedProxies"`
+	APIAnonymize      bool       `json:"apiAnonymize"`
+
+	// gRPC Control API
+	GRPCAPI           bool       `json:"grpcAPI"`
+	GRPCAPIAddress    string     `json:"grpcAPIAddress"`
+	GRPCAPIEncryption bool       `json:"grpcAPIEncryption"`
+	GRPCAPIServerKey  string     `json:"grpcAPIServerKey"`
+	GRPCAPIServerCert string     `json:"grpcAPIServerCert"`
+	GRPCAPITLSOptions TLSOptions `json:"grpcAPITLSOptions"`
 
 	// Metrics
 	Metrics               bool       `json:"metrics"`
@@ -194,6 +229,7 @@ type Conf struct {
 	MetricsEncryption     bool       `json:"metricsEncryption"`
 	MetricsServerKey      string     `json:"metricsServerKey"`
 	MetricsServerCert     string     `json:"metricsServerCert"`
+	MetricsTLSOptions     TLSOptions `json:"metricsTLSOptions"`
 	MetricsAllowOrigin    string     `json:"metricsAllowOrigin"`
 	MetricsTrustedProxies IPNetworks `json:"metricsTrustedProxies"`
 
@@ -203,6 +239,7 @@ type Conf struct {
 	PPROFEncryption     bool       `json:"pprofEncryption"`
 	PPROFServerKey      string     `json:"pprofServerKey"`
 	PPROFServerCert     string     `json:"pprofServerCert"`
+	PPROFTLSOptions     TLSOptions `json:"pprofTLSOptions"`
 	PPROFAllowOrigin    string     `json:"pprofAllowOrigin"`
 	PPROFTrustedProxies IPNetworks `json:"pprofTrustedProxies"`
 
@@ -212,78 +249,140 @@ type Conf struct {
 	PlaybackEncryption     bool       `json:"playbackEncryption"`
 	PlaybackServerKey      string     `json:"playbackServerKey"`
 	PlaybackServerCert     string     `json:"playbackServerCert"`
+	PlaybackTLSOptions     TLSOptions `json:"playbackTLSOptions"`
 	PlaybackAllowOrigin    string     `json:"playbackAllowOrigin"`
 	PlaybackTrustedProxies IPNetworks `json:"playbackTrustedProxies"`
 
 	// RTSP server
-	RTSP              bool             `json:"rtsp"`
-	RTSPDisable       *bool            `json:"rtspDisable,omitempty"` // deprecated
-	Protocols         Protocols        `json:"protocols"`
-	Encryption        Encryption       `json:"encryption"`
-	RTSPAddress       string           `json:"rtspAddress"`
-	RTSPSAddress      string           `json:"rtspsAddress"`
-	RTPAddress        string           `json:"rtpAddress"`
-	RTCPAddress       string           `json:"rtcpAddress"`
-	MulticastIPRange  string           `json:"multicastIPRange"`
-	MulticastRTPPort  int              `json:"multicastRTPPort"`
-	MulticastRTCPPort int              `json:"multicastRTCPPort"`
-	ServerKey         string           `json:"serverKey"`
-	ServerCert        string           `json:"serverCert"`
-	AuthMethods       *RTSPAuthMethods `json:"authMethods,omitempty"` // deprecated
-	RTSPAuthMethods   RTSPAuthMethods  `json:"rtspAuthMethods"`
+	RTSP                     bool             `json:"rtsp"`
+	RTSPDisable              *bool            `json:"rtspDisable,omitempty"` // deprecated
+	Protocols                Protocols        `json:"protocols"`
+	Encryption               Encryption       `json:"encryption"`
+	RTSPAddress              string           `json:"rtspAddress"`
+	RTSPSAddress             string           `json:"rtspsAddress"`
+	RTSPListeners            Listeners        `json:"rtspListeners"`
+	RTPAddress               string           `json:"rtpAddress"`
+	RTCPAddress              string           `json:"rtcpAddress"`
+	MulticastIPRange         string           `json:"multicastIPRange"`
+	MulticastRTPPort         int              `json:"multicastRTPPort"`
+	MulticastRTCPPort        int              `json:"multicastRTCPPort"`
+	MulticastSSM             bool             `json:"multicastSSM"`
+	ServerKey                string           `json:"serverKey"`
+	ServerCert               string           `json:"serverCert"`
+	RTSPTLSOptions           TLSOptions       `json:"rtspTLSOptions"`
+	AuthMethods              *RTSPAuthMethods `json:"authMethods,omitempty"` // deprecated
+	RTSPAuthMethods          RTSPAuthMethods  `json:"rtspAuthMethods"`
+	RTSPAuthNonceLifetime    StringDuration   `json:"rtspAuthNonceLifetime"`
+	RTSPAuthReplayProtection bool             `json:"rtspAuthReplayProtection"`
+	RTSPTrustedProxies       IPNetworks       `json:"rtspTrustedProxies"`
+	RTSPMaxPacketSize        int              `json:"rtspMaxPacketSize"`
+	RTSPDisableSenderReports bool             `json:"rtspDisableSenderReports"`
 
 	// RTMP server
-	RTMP           bool       `json:"rtmp"`
-	RTMPDisable    *bool      `json:"rtmpDisable,omitempty"` // deprecated
-	RTMPAddress    string     `json:"rtmpAddress"`
-	RTMPEncryption Encryption `json:"rtmpEncryption"`
-	RTMPSAddress   string     `json:"rtmpsAddress"`
-	RTMPServerKey  string     `json:"rtmpServerKey"`
-	RTMPServerCert string     `json:"rtmpServerCert"`
+	RTMP               bool          `json:"rtmp"`
+	RTMPDisable        *bool         `json:"rtmpDisable,omitempty"` // deprecated
+	RTMPAddress        string        `json:"rtmpAddress"`
+	RTMPEncryption     Encryption    `json:"rtmpEncryption"`
+	RTMPSAddress       string        `json:"rtmpsAddress"`
+	RTMPListeners      Listeners     `json:"rtmpListeners"`
+	RTMPServerKey      string        `json:"rtmpServerKey"`
+	RTMPServerCert     string        `json:"rtmpServerCert"`
+	RTMPTLSOptions     TLSOptions    `json:"rtmpTLSOptions"`
+	RTMPTrustedProxies IPNetworks    `json:"rtmpTrustedProxies"`
+	RTMPPathRules      RTMPPathRules `json:"rtmpPathRules"`
 
 	// HLS server
-	HLS                bool           `json:"hls"`
-	HLSDisable         *bool          `json:"hlsDisable,omitempty"` // deprecated
-	HLSAddress         string         `json:"hlsAddress"`
-	HLSEncryption      bool           `json:"hlsEncryption"`
-	HLSServerKey       string         `json:"hlsServerKey"`
-	HLSServerCert      string         `json:"hlsServerCert"`
-	HLSAllowOrigin     string         `json:"hlsAllowOrigin"`
-	HLSTrustedProxies  IPNetworks     `json:"hlsTrustedProxies"`
-	HLSAlwaysRemux     bool           `json:"hlsAlwaysRemux"`
-	HLSVariant         HLSVariant     `json:"hlsVariant"`
-	HLSSegmentCount    int            `json:"hlsSegmentCount"`
-	HLSSegmentDuration StringDuration `json:"hlsSegmentDuration"`
-	HLSPartDuration    StringDuration `json:"hlsPartDuration"`
-	HLSSegmentMaxSize  StringSize     `json:"hlsSegmentMaxSize"`
-	HLSDirectory       string         `json:"hlsDirectory"`
-	HLSMuxerCloseAfter StringDuration `json:"hlsMuxerCloseAfter"`
+	HLS                     bool           `json:"hls"`
+	HLSDisable              *bool          `json:"hlsDisable,omitempty"` // deprecated
+	HLSAddress              string         `json:"hlsAddress"`
+	HLSEncryption           bool           `json:"hlsEncryption"`
+	HLSListeners            Listeners      `json:"hlsListeners"`
+	HLSServerKey            string         `json:"hlsServerKey"`
+	HLSServerCert           string         `json:"hlsServerCert"`
+	HLSTLSOptions           TLSOptions     `json:"hlsTLSOptions"`
+	HLSAllowOrigin          string         `json:"hlsAllowOrigin"`
+	HLSTrustedProxies       IPNetworks     `json:"hlsTrustedProxies"`
+	HLSAlwaysRemux          bool           `json:"hlsAlwaysRemux"`
+	HLSVariant              HLSVariant     `json:"hlsVariant"`
+	HLSSegmentCount         int            `json:"hlsSegmentCount"`
+	HLSSegmentDuration      StringDuration `json:"hlsSegmentDuration"`
+	HLSPartDuration         StringDuration `json:"hlsPartDuration"`
+	HLSAdaptivePartDuration bool           `json:"hlsAdaptivePartDuration"`
+	HLSPartMinDuration      StringDuration `json:"hlsPartMinDuration"`
+	HLSPartMaxDuration      StringDuration `json:"hlsPartMaxDuration"`
+	HLSSegmentMaxSize       StringSize     `json:"hlsSegmentMaxSize"`
+	HLSAudioOnlyRendition   bool           `json:"hlsAudioOnlyRendition"`
+	HLSVideoGapFilling      bool           `json:"hlsVideoGapFilling"`
+	HLSVideoGapThreshold    StringDuration `json:"hlsVideoGapThreshold"`
+	HLSDirectory            string         `json:"hlsDirectory"`
+	HLSMuxerCloseAfter      StringDuration `json:"hlsMuxerCloseAfter"`
+	HLSSessionSecret        string         `json:"hlsSessionSecret"`
+	HLSInstanceID           string         `json:"hlsInstanceID"`
 
 	// WebRTC server
-	WebRTC                      bool             `json:"webrtc"`
-	WebRTCDisable               *bool            `json:"webrtcDisable,omitempty"` // deprecated
-	WebRTCAddress               string           `json:"webrtcAddress"`
-	WebRTCEncryption            bool             `json:"webrtcEncryption"`
-	WebRTCServerKey             string           `json:"webrtcServerKey"`
-	WebRTCServerCert            string           `json:"webrtcServerCert"`
-	WebRTCAllowOrigin           string           `json:"webrtcAllowOrigin"`
-	WebRTCTrustedProxies        IPNetworks       `json:"webrtcTrustedProxies"`
-	WebRTCLocalUDPAddress       string           `json:"webrtcLocalUDPAddress"`
-	WebRTCLocalTCPAddress       string           `json:"webrtcLocalTCPAddress"`
-	WebRTCIPsFromInterfaces     bool             `json:"webrtcIPsFromInterfaces"`
-	WebRTCIPsFromInterfacesList []string         `json:"webrtcIPsFromInterfacesList"`
-	WebRTCAdditionalHosts       []string         `json:"webrtcAdditionalHosts"`
-	WebRTCICEServers2           WebRTCICEServers `json:"webrtcICEServers2"`
-	WebRTCHandshakeTimeout      StringDuration   `json:"webrtcHandshakeTimeout"`
-	WebRTCTrackGatherTimeout    StringDuration   `json:"webrtcTrackGatherTimeout"`
-	WebRTCICEUDPMuxAddress      *string          `json:"webrtcICEUDPMuxAddress,omitempty"`  // deprecated
-	WebRTCICETCPMuxAddress      *string          `json:"webrtcICETCPMuxAddress,omitempty"`  // deprecated
-	WebRTCICEHostNAT1To1IPs     *[]string        `json:"webrtcICEHostNAT1To1IPs,omitempty"` // deprecated
-	WebRTCICEServers            *[]string        `json:"webrtcICEServers,omitempty"`        // deprecated
+	WebRTC                             bool                     `json:"webrtc"`
+	WebRTCDisable                      *bool                    `json:"webrtcDisable,omitempty"` // deprecated
+	WebRTCAddress                      string                   `json:"webrtcAddress"`
+	WebRTCEncryption                   bool                     `json:"webrtcEncryption"`
+	WebRTCListeners                    Listeners                `json:"webrtcListeners"`
+	WebRTCServerKey                    string                   `json:"webrtcServerKey"`
+	WebRTCServerCert                   string                   `json:"webrtcServerCert"`
+	WebRTCTLSOptions                   TLSOptions               `json:"webrtcTLSOptions"`
+	WebRTCAllowOrigin                  string                   `json:"webrtcAllowOrigin"`
+	WebRTCTrustedProxies               IPNetworks               `json:"webrtcTrustedProxies"`
+	WebRTCLocalUDPAddress              string                   `json:"webrtcLocalUDPAddress"`
+	WebRTCLocalTCPAddress              string                   `json:"webrtcLocalTCPAddress"`
+	WebRTCUDPPortRange                 UDPPortRange             `json:"webrtcUDPPortRange"`
+	WebRTCIPsFromInterfaces            bool                     `json:"webrtcIPsFromInterfaces"`
+	WebRTCIPsFromInterfacesList        []string                 `json:"webrtcIPsFromInterfacesList"`
+	WebRTCIPsFromInterfacesExcludeList []string                 `json:"webrtcIPsFromInterfacesExcludeList"`
+	WebRTCAdditionalHosts              []string                 `json:"webrtcAdditionalHosts"`
+	WebRTCICEAddressFamilies           WebRTCICEAddressFamilies `json:"webrtcICEAddressFamilies"`
+	WebRTCICECandidateTypes            WebRTCICECandidateTypes  `json:"webrtcICECandidateTypes"`
+	WebRTCICEServers2                  WebRTCICEServers         `json:"webrtcICEServers2"`
+	WebRTCHandshakeTimeout             StringDuration           `json:"webrtcHandshakeTimeout"`
+	WebRTCTrackGatherTimeout           StringDuration           `json:"webrtcTrackGatherTimeout"`
+	WebRTCDTLSCertificateFile          string                   `json:"webrtcDTLSCertificateFile"`
+	WebRTCICEUDPMuxAddress             *string                  `json:"webrtcICEUDPMuxAddress,omitempty"`  // deprecated
+	WebRTCICETCPMuxAddress             *string                  `json:"webrtcICETCPMuxAddress,omitempty"`  // deprecated
+	WebRTCICEHostNAT1To1IPs            *[]string                `json:"webrtcICEHostNAT1To1IPs,omitempty"` // deprecated
+	WebRTCICEServers                   *[]string                `json:"webrtcICEServers,omitempty"`        // deprecated
 
 	// SRT server
-	SRT        bool   `json:"srt"`
-	SRTAddress string `json:"srtAddress"`
+	SRT                  bool             `json:"srt"`
+	SRTAddress           string           `json:"srtAddress"`
+	SRTListeners         Listeners        `json:"srtListeners"`
+	SRTAllowedIPs        IPNetworks       `json:"srtAllowedIPs"`
+	SRTStreamIDRules     SRTStreamIDRules `json:"srtStreamIDRules"`
+	SRTReadMaxSendBuffer StringDuration   `json:"srtReadMaxSendBuffer"`
+
+	// IPC server
+	IPC        bool   `json:"ipc"`
+	IPCAddress string `json:"ipcAddress"`
+
+	// DASH server
+	DASH                bool           `json:"dash"`
+	DASHAddress         string         `json:"dashAddress"`
+	DASHEncryption      bool           `json:"dashEncryption"`
+	DASHServerKey       string         `json:"dashServerKey"`
+	DASHServerCert      string         `json:"dashServerCert"`
+	DASHAllowOrigin     string         `json:"dashAllowOrigin"`
+	DASHTrustedProxies  IPNetworks     `json:"dashTrustedProxies"`
+	DASHSegmentCount    int            `json:"dashSegmentCount"`
+	DASHSegmentDuration StringDuration `json:"dashSegmentDuration"`
+	DASHChunkDuration   StringDuration `json:"dashChunkDuration"`
+	DASHMuxerCloseAfter StringDuration `json:"dashMuxerCloseAfter"`
+
+	// Coordination
+	Coordination            bool   `json:"coordination"`
+	CoordinationAddress     string `json:"coordinationAddress"`
+	CoordinationInstanceID  string `json:"coordinationInstanceID"`
+	CoordinationInstanceURL string `json:"coordinationInstanceURL"`
+
+	// Failover
+	Failover        bool           `json:"failover"`
+	FailoverLock    string         `json:"failoverLock"`
+	FailoverTimeout StringDuration `json:"failoverTimeout"`
 
 	// Record (deprecated)
 	Record                *bool           `json:"record,omitempty"`                // deprecated
@@ -310,6 +409,11 @@ func (conf *Conf) setDefaults() {
 	conf.WriteTimeout = 10 * StringDuration(time.Second)
 	conf.WriteQueueSize = 512
 	conf.UDPMaxPayloadSize = 1472
+	conf.ServerHeader = "mediamtx"
+	conf.HTTPResponseHeaders = HTTPResponseHeaders{}
+	conf.PublicIPRefresh = 5 * StringDuration(time.Minute)
+	conf.RecordPostProcessConcurrency = 1
+	conf.PublishPathRewrites = PublishPathRewrites{}
 
 	// Authentication
 	conf.AuthInternalUsers = defaultAuthInternalUsers
@@ -325,29 +429,40 @@ func (conf *Conf) setDefaults() {
 		},
 	}
 	conf.AuthJWTClaimKey = "mediamtx_permissions"
+	conf.AuthInterfacePolicies = AuthInterfacePolicies{}
 
 	// Control API
 	conf.APIAddress = ":9997"
 	conf.APIServerKey = "server.key"
 	conf.APIServerCert = "server.crt"
+	conf.APITLSOptions.setDefaults()
 	conf.APIAllowOrigin = "*"
 
+	// gRPC Control API
+	conf.GRPCAPIAddress = ":9995"
+	conf.GRPCAPIServerKey = "server.key"
+	conf.GRPCAPIServerCert = "server.crt"
+	conf.GRPCAPITLSOptions.setDefaults()
+
 	// Metrics
 	conf.MetricsAddress = ":9998"
 	conf.MetricsServerKey = "server.key"
 	conf.MetricsServerCert = "server.crt"
+	conf.MetricsTLSOptions.setDefaults()
 	conf.MetricsAllowOrigin = "*"
 
 	// PPROF
 	conf.PPROFAddress = ":9999"
 	conf.PPROFServerKey = "server.key"
 	conf.PPROFServerCert = "server.crt"
+	conf.PPROFTLSOptions.setDefaults()
 	conf.PPROFAllowOrigin = "*"
 
 	// Playback server
 	conf.PlaybackAddress = ":9996"
 	conf.PlaybackServerKey = "server.key"
 	conf.PlaybackServerCert = "server.crt"
+	conf.PlaybackTLSOptions.setDefaults()
 	conf.PlaybackAllowOrigin = "*"
 
 	// RTSP server
@@ -359,6 +474,7 @@ func (conf *Conf) setDefaults() {
 	}
 	conf.RTSPAddress = ":8554"
 	conf.RTSPSAddress = ":8322"
+	conf.RTSPListeners = Listeners{}
 	conf.RTPAddress = ":8000"
 	conf.RTCPAddress = ":8001"
 	conf.MulticastIPRange = "224.1.0.0/16"
@@ -366,38 +482,57 @@ func (conf *Conf) setDefaults() {
 	conf.MulticastRTCPPort = 8003
 	conf.ServerKey = "server.key"
 	conf.ServerCert = "server.crt"
+	conf.RTSPTLSOptions.setDefaults()
 	conf.RTSPAuthMethods = RTSPAuthMethods{auth.ValidateMethodBasic}
+	conf.RTSPMaxPacketSize = 1472
 
 	// RTMP server
 	conf.RTMP = true
 	conf.RTMPAddress = ":1935"
 	conf.RTMPSAddress = ":1936"
+	conf.RTMPListeners = Listeners{}
 	conf.RTMPServerKey = "server.key"
 	conf.RTMPServerCert = "server.crt"
+	conf.RTMPTLSOptions.setDefaults()
+	conf.RTMPPathRules = RTMPPathRules{}
 
 	// HLS
 	conf.HLS = true
 	conf.HLSAddress = ":8888"
+	conf.HLSListeners = Listeners{}
 	conf.HLSServerKey = "server.key"
 	conf.HLSServerCert = "server.crt"
+	conf.HLSTLSOptions.setDefaults()
 	conf.HLSAllowOrigin = "*"
 	conf.HLSVariant = HLSVariant(gohlslib.MuxerVariantLowLatency)
 	conf.HLSSegmentCount = 7
 	conf.HLSSegmentDuration = 1 * StringDuration(time.Second)
 	conf.HLSPartDuration = 200 * StringDuration(time.Millisecond)
+	conf.HLSPartMinDuration = 100 * StringDuration(time.Millisecond)
+	conf.HLSPartMaxDuration = 500 * StringDuration(time.Millisecond)
 	conf.HLSSegmentMaxSize = 50 * 1024 * 1024
+	conf.HLSVideoGapThreshold = 500 * StringDuration(time.Millisecond)
 	conf.HLSMuxerCloseAfter = 60 * StringDuration(time.Second)
 
 	// WebRTC server
 	conf.WebRTC = true
 	conf.WebRTCAddress = ":8889"
+	conf.WebRTCListeners = Listeners{}
 	conf.WebRTCServerKey = "server.key"
 	conf.WebRTCServerCert = "server.crt"
+	conf.WebRTCTLSOptions.setDefaults()
 	conf.WebRTCAllowOrigin = "*"
 	conf.WebRTCLocalUDPAddress = ":8189"
 	conf.WebRTCIPsFromInterfaces = true
 	conf.WebRTCIPsFromInterfacesList = []string{}
+	conf.WebRTCIPsFromInterfacesExcludeList = []string{}
 	conf.WebRTCAdditionalHosts = []string{}
+	conf.WebRTCICEAddressFamilies = WebRTCICEAddressFamilies{WebRTCICEAddressFamilyIPv4}
+	conf.WebRTCICECandidateTypes = WebRTCICECandidateTypes{
+		webrtc.ICECandidateTypeHost,
+		webrtc.ICECandidateTypeSrflx,
+		webrtc.ICECandidateTypeRelay,
+	}
 	conf.WebRTCICEServers2 = []WebRTCICEServer{}
 	conf.WebRTCHandshakeTimeout = 10 * StringDuration(time.Second)
 	conf.WebRTCTrackGatherTimeout = 2 * StringDuration(time.Second)
@@ -405,6 +540,28 @@ func (conf *Conf) setDefaults() {
 	// SRT server
 	conf.SRT = true
 	conf.SRTAddress = ":8890"
+	conf.SRTListeners = Listeners{}
+	conf.SRTStreamIDRules = SRTStreamIDRules{}
+
+	// IPC server
+	conf.IPCAddress = "mediamtx.sock"
+
+	// DASH server
+	conf.DASHAddress = ":8891"
+	conf.DASHServerKey = "server.key"
+	conf.DASHServerCert = "server.crt"
+	conf.DASHAllowOrigin = "*"
+	conf.DASHSegmentCount = 7
+	conf.DASHSegmentDuration = 2 * StringDuration(time.Second)
+	conf.DASHChunkDuration = 200 * StringDuration(time.Millisecond)
+	conf.DASHMuxerCloseAfter = 60 * StringDuration(time.Second)
+
+	// Coordination
+	conf.CoordinationAddress = "redis://localhost:6379"
+
+	// Failover
+	conf.FailoverLock = "mediamtx.lock"
+	conf.FailoverTimeout = 10 * StringDuration(time.Second)
 
 	conf.PathDefaults.setDefaults()
 }
@@ -510,6 +667,54 @@ func (conf *Conf) Validate() error {
 	if conf.UDPMaxPayloadSize > 1472 {
 		return fmt.Errorf("'udpMaxPayloadSize' must be less than 1472")
 	}
+	if conf.HTTPRateLimit < 0 {
+		return fmt.Errorf("'httpRateLimit' must not be negative")
+	}
+	if conf.HTTPRateLimitBurst < 0 {
+		return fmt.Errorf("'httpRateLimitBurst' must not be negative")
+	}
+	if strings.ContainsAny(conf.ServerHeader, "\r\n") {
+		return fmt.Errorf("'serverHeader' contains invalid characters")
+	}
+	if err := conf.HTTPResponseHeaders.validate(); err != nil {
+		return err
+	}
+	if conf.ConnRateLimit < 0 {
+		return fmt.Errorf("'connRateLimit' must not be negative")
+	}
+	if conf.ConnRateLimitBurst < 0 {
+		return fmt.Errorf("'connRateLimitBurst' must not be negative")
+	}
+	if conf.ConnIPRateLimit < 0 {
+		return fmt.Errorf("'connIPRateLimit' must not be negative")
+	}
+	if conf.ConnIPRateLimitBurst < 0 {
+		return fmt.Errorf("'connIPRateLimitBurst' must not be negative")
+	}
+	if conf.PublicIPSource != "" &&
+		!strings.HasPrefix(conf.PublicIPSource, "stun:") &&
+		!strings.HasPrefix(conf.PublicIPSource, "http://") &&
+		!strings.HasPrefix(conf.PublicIPSource, "https://") {
+		return fmt.Errorf("'publicIPSource' must be a STUN or HTTP URL")
+	}
+	if conf.PublicIPRefresh <= 0 {
+		return fmt.Errorf("'publicIPRefresh' must be greater than zero")
+	}
+	if conf.BandwidthResetPeriod < 0 {
+		return fmt.Errorf("'bandwidthResetPeriod' must not be negative")
+	}
+	if conf.RecordPostProcessConcurrency < 1 {
+		return fmt.Errorf("'recordPostProcessConcurrency' must be greater than zero")
+	}
+	if conf.StaticSourceStartConcurrency < 0 {
+		return fmt.Errorf("'staticSourceStartConcurrency' must not be negative")
+	}
+	for _, r := range conf.PublishPathRewrites {
+		err := r.validate()
+		if err != nil {
+			return err
+		}
+	}
 
 	// Authentication
 
@@ -575,6 +780,18 @@ func (conf *Conf) Validate() error {
 			return fmt.Errorf("'authJWTClaimKey' is empty")
 		}
 	}
+	if conf.AuthPolicy != "" {
+		_, err := authpolicy.New(conf.AuthPolicy)
+		if err != nil {
+			return fmt.Errorf("invalid 'authPolicy': %w", err)
+		}
+	}
+	if err := conf.AuthInterfacePolicies.validate(); err != nil {
+		return err
+	}
+	if conf.AuthRotationGracePeriod < 0 {
+		return fmt.Errorf("'authRotationGracePeriod' must not be negative")
+	}
 
 	// RTSP
 
@@ -589,10 +806,20 @@ func (conf *Conf) Validate() error {
 			return fmt.Errorf("strict encryption can't be used with the UDP-multicast transport protocol")
 		}
 	}
+	if conf.MulticastSSM {
+		_, ipnet, err := net.ParseCIDR(conf.MulticastIPRange)
+		if err != nil {
+			return fmt.Errorf("invalid 'multicastIPRange': %w", err)
+		}
+		if ones, _ := ipnet.Mask.Size(); ipnet.IP.To4() == nil || ipnet.IP.To4()[0] != 232 || ones < 8 {
+			return fmt.Errorf("when 'multicastSSM' is enabled, 'multicastIPRange' must be inside 232.0.0.0/8")
+		}
+	}
 	if conf.AuthMethods != nil {
 		conf.RTSPAuthMethods = *conf.AuthMethods
 	}
-	if contains(conf.RTSPAuthMethods, auth.ValidateMethodDigestMD5) {
+	if contains(conf.RTSPAuthMethods, auth.ValidateMethodDigestMD5) ||
+		contains(conf.RTSPAuthMethods, auth.ValidateMethodSHA256) {
 		if conf.AuthMethod != AuthMethodInternal {
 			return fmt.Errorf("when RTSP digest is enabled, the only supported auth method is 'internal'")
 		}
@@ -602,24 +829,53 @@ func (conf *Conf) Validate() error {
 			}
 		}
 	}
+	if conf.RTSPAuthNonceLifetime < 0 {
+		return fmt.Errorf("'rtspAuthNonceLifetime' must be greater than or equal to zero")
+	}
+	if conf.RTSPMaxPacketSize <= 0 || conf.RTSPMaxPacketSize > 1472 {
+		return fmt.Errorf("'rtspMaxPacketSize' must be between 1 and 1472")
+	}
+	if err := conf.RTSPListeners.validate("rtspListeners", conf.Encryption, false); err != nil {
+		return err
+	}
 
 	// RTMP
 
 	if conf.RTMPDisable != nil {
 		conf.RTMP = !*conf.RTMPDisable
 	}
+	if err := conf.RTMPListeners.validate("rtmpListeners", conf.RTMPEncryption, true); err != nil {
+		return err
+	}
 
 	// HLS
 
 	if conf.HLSDisable != nil {
 		conf.HLS = !*conf.HLSDisable
 	}
+	if err := conf.HLSListeners.validate("hlsListeners", EncryptionOptional, true); err != nil {
+		return err
+	}
+	if conf.HLSVideoGapFilling && conf.HLSVideoGapThreshold <= 0 {
+		return fmt.Errorf("'hlsVideoGapThreshold' must be greater than zero")
+	}
+	if conf.HLSAdaptivePartDuration {
+		if conf.HLSPartMinDuration <= 0 {
+			return fmt.Errorf("'hlsPartMinDuration' must be greater than zero")
+		}
+		if conf.HLSPartMaxDuration < conf.HLSPartMinDuration {
+			return fmt.Errorf("'hlsPartMaxDuration' must be greater than or equal to 'hlsPartMinDuration'")
+		}
+	}
 
 	// WebRTC
 
 	if conf.WebRTCDisable != nil {
 		conf.WebRTC = !*conf.WebRTCDisable
 	}
+	if err := conf.WebRTCListeners.validate("webrtcListeners", EncryptionOptional, true); err != nil {
+		return err
+	}
 	if conf.WebRTCICEUDPMuxAddress != nil {
 		conf.WebRTCLocalUDPAddress = *conf.WebRTCICEUDPMuxAddress
 	}
@@ -663,6 +919,78 @@ func (conf *Conf) Validate() error {
 			return fmt.Errorf("at least one between 'webrtcIPsFromInterfaces' or 'webrtcAdditionalHosts' must be filled")
 		}
 	}
+	for _, pattern := range append(append([]string(nil), conf.WebRTCIPsFromInterfacesList...),
+		conf.WebRTCIPsFromInterfacesExcludeList...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid interface pattern: '%s'", pattern)
+		}
+	}
+	if len(conf.WebRTCICEAddressFamilies) == 0 {
+		return fmt.Errorf("'webrtcICEAddressFamilies' must contain at least one address family")
+	}
+	if len(conf.WebRTCICECandidateTypes) == 0 {
+		return fmt.Errorf("'webrtcICECandidateTypes' must contain at least one candidate type")
+	}
+
+	// RTMP
+
+	for _, r := range conf.RTMPPathRules {
+		err := r.validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	// SRT
+
+	if err := conf.SRTListeners.validate("srtListeners", EncryptionNo, false); err != nil {
+		return err
+	}
+	for _, r := range conf.SRTStreamIDRules {
+		err := r.validate()
+		if err != nil {
+			return err
+		}
+	}
+	if conf.SRTReadMaxSendBuffer < 0 {
+		return fmt.Errorf("'srtReadMaxSendBuffer' must be greater than or equal to zero")
+	}
+
+	// DASH
+
+	if conf.DASHSegmentCount < 1 {
+		return fmt.Errorf("'dashSegmentCount' must be greater than zero")
+	}
+	if conf.DASHChunkDuration <= 0 {
+		return fmt.Errorf("'dashChunkDuration' must be greater than zero")
+	}
+	if conf.DASHSegmentDuration < conf.DASHChunkDuration {
+		return fmt.Errorf("'dashSegmentDuration' must be greater than or equal to 'dashChunkDuration'")
+	}
+
+	// Coordination
+
+	if conf.Coordination {
+		u, err := gourl.Parse(conf.CoordinationAddress)
+		if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			return fmt.Errorf("'coordinationAddress' must be a valid Redis URL")
+		}
+		if !strings.HasPrefix(conf.CoordinationInstanceURL, "rtsp://") &&
+			!strings.HasPrefix(conf.CoordinationInstanceURL, "rtsps://") {
+			return fmt.Errorf("'coordinationInstanceURL' must be a RTSP URL")
+		}
+	}
+
+	// Failover
+
+	if conf.Failover {
+		if conf.FailoverLock == "" {
+			return fmt.Errorf("'failoverLock' must be set")
+		}
+		if conf.FailoverTimeout < StringDuration(time.Second) {
+			return fmt.Errorf("'failoverTimeout' must be at least 1s")
+		}
+	}
 
 	// Record (deprecated)
 
@@ -782,6 +1110,18 @@ func (conf *Conf) ReplacePath(name string, optional2 *OptionalPath) error {
 	return nil
 }
 
+// ImportPaths adds or replaces several paths at once.
+// If replaceAll is true, paths that are not part of the batch are removed.
+func (conf *Conf) ImportPaths(paths map[string]*OptionalPath, replaceAll bool) {
+	if replaceAll || conf.OptionalPaths == nil {
+		conf.OptionalPaths = make(map[string]*OptionalPath)
+	}
+
+	for name, p := range paths {
+		conf.OptionalPaths[name] = p
+	}
+}
+
 // RemovePath removes a path.
 func (conf *Conf) RemovePath(name string) error {
 	if _, ok := conf.OptionalPaths[name]; !ok {
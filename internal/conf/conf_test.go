@@ -2,6 +2,7 @@ package conf
 
 import (
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/base64"
 	"io"
 	"os"
@@ -52,12 +53,35 @@ func TestConfFromFile(t *testing.T) {
 			Source:                     "publisher",
 			SourceOnDemandStartTimeout: 10 * StringDuration(time.Second),
 			SourceOnDemandCloseAfter:   10 * StringDuration(time.Second),
+			SourceRetryDelay:           5 * StringDuration(time.Second),
+			SourceRetryMultiplier:      1,
+			SourceRetryMaxDelay:        60 * StringDuration(time.Second),
+			UserAgentRules:             UserAgentRules{},
+			RTSPReaderTransportRules:   RTSPTransportRules{},
+			AudioGapFillingThreshold:   StringDuration(500 * time.Millisecond),
+			CaptureDuration:            10 * StringDuration(time.Second),
 			RecordPath:                 "./recordings/%path/%Y-%m-%d_%H-%M-%S-%f",
 			RecordFormat:               RecordFormatFMP4,
 			RecordPartDuration:         StringDuration(1 * time.Second),
 			RecordSegmentDuration:      3600000000000,
 			RecordDeleteAfter:          86400000000000,
+			RecordS3Region:             "us-east-1",
+			AudioSilenceThreshold:      -60,
+			AudioSilenceDuration:       10 * StringDuration(time.Second),
+			VideoMonitorDuration:       10 * StringDuration(time.Second),
+			MaxKeyframeInterval:        10 * StringDuration(time.Second),
+			CMAFPushMethod:             "PUT",
+			CMAFPushSegmentDuration:    4 * StringDuration(time.Second),
+			CMAFPushChunkDuration:      StringDuration(500 * time.Millisecond),
+			WatermarkPosition:          "bottomRight",
+			PTZTour:                    PTZTourSteps{},
 			OverridePublisher:          true,
+			RTSPUDPReadTimeout:         3 * StringDuration(time.Second),
+			SourcePlaylist:             PlaylistItems{},
+			SourceComposite:            []string{},
+			SourceCompositeLayout:      "pip",
+			SourceDelay:                30 * StringDuration(time.Second),
+			SourceDelayBufferSize:      200 * 1024 * 1024,
 			RPICameraWidth:             1920,
 			RPICameraHeight:            1080,
 			RPICameraContrast:          1,
@@ -111,11 +135,29 @@ func TestConfFromFile(t *testing.T) {
 		_, _, err = Load(tmpf, nil)
 		require.NoError(t, err)
 	}()
+
+	func() {
+		tmpf, err := createTempFile([]byte(
+			"paths:\n" +
+				"  mypath1:\n" +
+				"    lpcmChannelMap: 3\n" +
+				"  mypath2:\n" +
+				"    lpcmChannelMap: 1+3,2+3\n"))
+		require.NoError(t, err)
+		defer os.Remove(tmpf)
+
+		conf, _, err := Load(tmpf, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, LPCMChannelMap{{3}}, conf.Paths["mypath1"].LPCMChannelMap)
+		require.Equal(t, LPCMChannelMap{{1, 3}, {2, 3}}, conf.Paths["mypath2"].LPCMChannelMap)
+	}()
 }
 
 func TestConfFromFileAndEnv(t *testing.T) {
 	// global parameter
 	t.Setenv("RTSP_PROTOCOLS", "tcp")
+	t.Setenv("MTX_HLSTLSOPTIONS_MINVERSION", "1.3")
 
 	// path parameter
 	t.Setenv("MTX_PATHS_CAM1_SOURCE", "rtsp://testing")
@@ -136,6 +178,7 @@ func TestConfFromFileAndEnv(t *testing.T) {
 
 	require.Equal(t, Protocols{Protocol(gortsplib.TransportTCP): {}}, conf.Protocols)
 	require.Equal(t, false, conf.RTMP)
+	require.Equal(t, TLSVersion(tls.VersionTLS13), conf.HLSTLSOptions.MinVersion)
 
 	pa, ok := conf.Paths["cam1"]
 	require.Equal(t, true, ok)
@@ -285,11 +328,123 @@ func TestConfErrors(t *testing.T) {
 			"writeQueueSize: 1001\n",
 			"'writeQueueSize' must be a power of two",
 		},
+		{
+			"invalid hlsVideoGapThreshold",
+			"hlsVideoGapFilling: yes\n" +
+				"hlsVideoGapThreshold: 0s\n",
+			"'hlsVideoGapThreshold' must be greater than zero",
+		},
+		{
+			"invalid hlsPartMinDuration",
+			"hlsAdaptivePartDuration: yes\n" +
+				"hlsPartMinDuration: 0s\n",
+			"'hlsPartMinDuration' must be greater than zero",
+		},
+		{
+			"invalid hlsPartMaxDuration",
+			"hlsAdaptivePartDuration: yes\n" +
+				"hlsPartMinDuration: 500ms\n" +
+				"hlsPartMaxDuration: 200ms\n",
+			"'hlsPartMaxDuration' must be greater than or equal to 'hlsPartMinDuration'",
+		},
+		{
+			"invalid TLS version",
+			"apiTLSOptions:\n" +
+				"  minVersion: 1.4\n",
+			"invalid TLS version: '1.4'",
+		},
+		{
+			"invalid TLS cipher suite",
+			"rtspTLSOptions:\n" +
+				"  cipherSuites: [TLS_INVALID]\n",
+			"invalid TLS cipher suite: 'TLS_INVALID'",
+		},
+		{
+			"invalid rtspAuthNonceLifetime",
+			"rtspAuthNonceLifetime: -1s\n",
+			"'rtspAuthNonceLifetime' must be greater than or equal to zero",
+		},
+		{
+			"invalid rtspMaxPacketSize",
+			"rtspMaxPacketSize: 2000\n",
+			"'rtspMaxPacketSize' must be between 1 and 1472",
+		},
+		{
+			"invalid publish path rewrite",
+			"publishPathRewrites:\n" +
+				"  - match: '[live'\n",
+			"invalid 'match' of publish path rewrite: error parsing regexp: missing closing ]: `[live`",
+		},
+		{
+			"interface policy without interface",
+			"authInterfacePolicies: [{actions: [read]}]\n",
+			"'interface' of interface policies can't be empty",
+		},
+		{
+			"interface policy with invalid action",
+			"authInterfacePolicies: [{interface: eth0, actions: [write]}]\n",
+			"invalid auth action: 'write'",
+		},
+		{
+			"encrypted rtsp listener with encryption disabled",
+			"rtspListeners: [{address: ':9554', encryption: yes}]\n",
+			"'rtspListeners' contains an encrypted listener, but encryption is disabled",
+		},
+		{
+			"unencrypted rtmp listener with strict encryption",
+			"rtmpEncryption: strict\n" +
+				"rtmpListeners: [{address: ':9935'}]\n",
+			"'rtmpListeners' contains an unencrypted listener, but encryption is strict",
+		},
+		{
+			"hls listener without address",
+			"hlsListeners: [{encryption: yes}]\n",
+			"'address' of 'hlsListeners' can't be empty",
+		},
+		{
+			"srt listener with certificate",
+			"srtListeners: [{address: ':9890', serverCert: 'server.crt'}]\n",
+			"'srtListeners' contains a listener with a certificate that can't be used",
+		},
+		{
+			"invalid srtReadMaxSendBuffer",
+			"srtReadMaxSendBuffer: -1s\n",
+			"'srtReadMaxSendBuffer' must be greater than or equal to zero",
+		},
+		{
+			"invalid dashSegmentCount",
+			"dashSegmentCount: 0\n",
+			"'dashSegmentCount' must be greater than zero",
+		},
+		{
+			"invalid dashChunkDuration",
+			"dashChunkDuration: 0s\n",
+			"'dashChunkDuration' must be greater than zero",
+		},
+		{
+			"dash segment duration smaller than chunk duration",
+			"dashSegmentDuration: 100ms\n" +
+				"dashChunkDuration: 200ms\n",
+			"'dashSegmentDuration' must be greater than or equal to 'dashChunkDuration'",
+		},
+		{
+			"digest sha256 with hashed credentials",
+			"rtspAuthMethods: [digestSHA256]\n" +
+				"authInternalUsers:\n" +
+				"- user: sha256:j1tsRqDEw9xvq/D7/9tMx6Jh/jMhk3UfjwIB2f1zgMo=\n" +
+				"  pass: sha256:BdSWkrdV+ZxFBLUQQY7+7uv9RmiSVA8nrPmjGjJtZQQ=\n",
+			"when RTSP digest is enabled, hashed credentials cannot be used",
+		},
 		{
 			"invalid udpMaxPayloadSize",
 			"udpMaxPayloadSize: 5000\n",
 			"'udpMaxPayloadSize' must be less than 1472",
 		},
+		{
+			"invalid multicastSSM",
+			"multicastSSM: yes\n",
+			"when 'multicastSSM' is enabled, 'multicastIPRange' must be inside 232.0.0.0/8",
+		},
 		{
 			"invalid strict encryption 1",
 			"encryption: strict\n" +
@@ -307,6 +462,36 @@ func TestConfErrors(t *testing.T) {
 			"webrtcICEServers: [testing]\n",
 			"invalid ICE server: 'testing'",
 		},
+		{
+			"invalid staticSourceStartConcurrency",
+			"staticSourceStartConcurrency: -1\n",
+			"'staticSourceStartConcurrency' must not be negative",
+		},
+		{
+			"invalid interface pattern",
+			"webrtcIPsFromInterfacesExcludeList: ['br-[']\n",
+			"invalid interface pattern: 'br-['",
+		},
+		{
+			"invalid ICE address family",
+			"webrtcICEAddressFamilies: [ipv5]\n",
+			"invalid ICE address family: 'ipv5'",
+		},
+		{
+			"empty ICE address families",
+			"webrtcICEAddressFamilies: []\n",
+			"'webrtcICEAddressFamilies' must contain at least one address family",
+		},
+		{
+			"invalid ICE candidate type",
+			"webrtcICECandidateTypes: [prflx]\n",
+			"invalid ICE candidate type: 'prflx'",
+		},
+		{
+			"duplicate ICE candidate type",
+			"webrtcICECandidateTypes: [host, host]\n",
+			"ICE candidate type 'host' set twice",
+		},
 		{
 			"non existent parameter 2",
 			"paths:\n" +
@@ -321,6 +506,378 @@ func TestConfErrors(t *testing.T) {
 				"    source: publisher\n",
 			"invalid path name '': cannot be empty",
 		},
+		{
+			"invalid lpcmChannelMap",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    lpcmChannelMap: 1+0,2\n",
+			"invalid LPCM channel map: '1+0,2'",
+		},
+		{
+			"invalid sourceOnDemandLinger",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    sourceOnDemandLinger: -1s\n",
+			"'sourceOnDemandLinger' must be greater than or equal to zero",
+		},
+		{
+			"invalid publicIPSource",
+			"publicIPSource: ftp://myhost\n",
+			"'publicIPSource' must be a STUN or HTTP URL",
+		},
+		{
+			"invalid recordPostProcessConcurrency",
+			"recordPostProcessConcurrency: 0\n",
+			"'recordPostProcessConcurrency' must be greater than zero",
+		},
+		{
+			"negative credential rotation grace period",
+			"authRotationGracePeriod: -1s\n",
+			"'authRotationGracePeriod' must not be negative",
+		},
+		{
+			"negative bandwidth reset period",
+			"bandwidthResetPeriod: -1s\n",
+			"'bandwidthResetPeriod' must not be negative",
+		},
+		{
+			"invalid HTTP response header name",
+			"httpResponseHeaders:\n" +
+				"- name: X Frame\n" +
+				"  value: DENY\n",
+			"invalid HTTP response header name 'X Frame'",
+		},
+		{
+			"Server in HTTP response headers",
+			"httpResponseHeaders:\n" +
+				"- name: server\n" +
+				"  value: myserver\n",
+			"the Server header can't be set in 'httpResponseHeaders', use 'serverHeader'",
+		},
+		{
+			"invalid udp port range",
+			"webrtcUDPPortRange: 2000-1000\n",
+			"invalid UDP port range: '2000-1000'",
+		},
+		{
+			"invalid rtspUDPPortRange",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    rtspUDPPortRange: 10001-10004\n",
+			"'rtspUDPPortRange' must start with an even port and contain at least 2 ports",
+		},
+		{
+			"invalid cmafPushURL",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    cmafPushURL: ftp://localhost/ingest\n",
+			"'cmafPushURL' must be a HTTP or HTTPS URL",
+		},
+		{
+			"invalid cmafPushMethod",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    cmafPushMethod: PATCH\n",
+			"invalid 'cmafPushMethod': 'PATCH'",
+		},
+		{
+			"invalid rtspUDPReadTimeout",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    rtspUDPReadTimeout: 0s\n",
+			"'rtspUDPReadTimeout' must be greater than zero",
+		},
+		{
+			"invalid maxReaderDuration",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    maxReaderDuration: -1s\n",
+			"'maxReaderDuration' must be greater than or equal to zero",
+		},
+		{
+			"invalid publisherResumeTimeout",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    publisherResumeTimeout: -1s\n",
+			"'publisherResumeTimeout' must be greater than or equal to zero",
+		},
+		{
+			"invalid publishProtocols",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    publishProtocols: [ftp]\n",
+			"invalid protocol: ftp",
+		},
+		{
+			"rtmp path rule without path",
+			"rtmpPathRules:\n" +
+				"  - match: '^live/(.+)$'\n",
+			"'path' of RTMP path rules can't be empty",
+		},
+		{
+			"invalid srt stream id rule match",
+			"srtStreamIDRules:\n" +
+				"  - match: '[live'\n",
+			"invalid 'match' of SRT stream ID rule: error parsing regexp: missing closing ]: `[live`",
+		},
+		{
+			"invalid srt stream id rule action",
+			"srtStreamIDRules:\n" +
+				"  - match: live\n" +
+				"    action: play\n",
+			"invalid 'action' of SRT stream ID rule: 'play'",
+		},
+		{
+			"invalid user agent rule action",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    userAgentRules:\n" +
+				"      - match: bot\n" +
+				"        action: block\n",
+			"invalid user agent rule action: 'block'",
+		},
+		{
+			"invalid user agent rule match",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    userAgentRules:\n" +
+				"      - match: '[bot'\n" +
+				"        action: deny\n",
+			"invalid 'match' of user agent rule: error parsing regexp: missing closing ]: `[bot`",
+		},
+		{
+			"user agent rule route without path",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    userAgentRules:\n" +
+				"      - match: bot\n" +
+				"        action: route\n",
+			"invalid 'path' of user agent rule: cannot be empty",
+		},
+		{
+			"publishProtocols without publishing protocols",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    publishProtocols: [hls]\n",
+			"'publishProtocols' must contain at least one protocol that supports publishing",
+		},
+		{
+			"invalid audioGapFillingThreshold",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    audioGapFilling: yes\n" +
+				"    audioGapFillingThreshold: 0s\n",
+			"'audioGapFillingThreshold' must be greater than zero",
+		},
+		{
+			"invalid captureDuration",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    capture: yes\n" +
+				"    captureDuration: 0s\n",
+			"'captureDuration' must be greater than zero",
+		},
+		{
+			"invalid publisherIdleTimeout",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    publisherIdleTimeout: -1s\n",
+			"'publisherIdleTimeout' must be greater than or equal to zero",
+		},
+		{
+			"publisherMinBitrate without publisherIdleTimeout",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    publisherMinBitrate: 1000\n",
+			"'publisherMinBitrate' requires 'publisherIdleTimeout'",
+		},
+		{
+			"invalid clock source",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    clockSource: gps\n",
+			"invalid clock source 'gps'",
+		},
+		{
+			"invalid codec change behavior",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    codecChangeBehavior: ignore\n",
+			"invalid codec change behavior 'ignore'",
+		},
+		{
+			"invalid HLS muxer creation",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    hlsMuxerCreation: eager\n",
+			"invalid HLS muxer creation 'eager'",
+		},
+		{
+			"invalid record storage",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    recordStorage: ftp\n",
+			"invalid record storage 'ftp'",
+		},
+		{
+			"s3 record storage without bucket",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    recordStorage: s3\n" +
+				"    recordS3Endpoint: http://localhost:9000\n",
+			"'recordS3Endpoint' and 'recordS3Bucket' are required when 'recordStorage' is 's3'",
+		},
+		{
+			"webdav record storage without URL",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    recordStorage: webdav\n",
+			"'recordWebDAVURL' is required when 'recordStorage' is 'webdav'",
+		},
+		{
+			"rtspReaderTransportRules without transports",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    rtspReaderTransportRules:\n" +
+				"    - ips: [10.0.0.0/8]\n",
+			"'transports' of RTSP transport rules can't be empty",
+		},
+		{
+			"negative connection rate limit",
+			"connIPRateLimit: -1",
+			"'connIPRateLimit' must not be negative",
+		},
+		{
+			"invalid rtpOutputAddress",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    rtpOutputAddress: 239.0.0.1\n",
+			"invalid 'rtpOutputAddress': address 239.0.0.1: missing port in address",
+		},
+		{
+			"rtpOutputSDPPath without rtpOutputAddress",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    rtpOutputSDPPath: stream.sdp\n",
+			"'rtpOutputSDPPath' requires 'rtpOutputAddress'",
+		},
+		{
+			"ptzTour without ptzURL",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    ptzTour:\n" +
+				"    - preset: '1'\n" +
+				"      dwell: 10s\n",
+			"'ptzTour' requires 'ptzURL'",
+		},
+		{
+			"ptzTour without dwell",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    ptzURL: http://localhost/onvif/ptz_service\n" +
+				"    ptzProfileToken: myprofile\n" +
+				"    ptzTour:\n" +
+				"    - preset: '1'\n",
+			"dwell of PTZ tour step '1' must be greater than zero",
+		},
+		{
+			"recordPostProcess with remote storage",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    recordStorage: webdav\n" +
+				"    recordWebDAVURL: http://localhost/dav\n" +
+				"    recordPostProcess: ls\n",
+			"'recordPostProcess' requires 'recordStorage' to be 'local'",
+		},
+		{
+			"invalid maxWHEPReaders",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    maxWHEPReaders: -1\n",
+			"'maxWHEPReaders' must be greater than or equal to zero",
+		},
+		{
+			"empty playlist",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: playlist\n",
+			"'sourcePlaylist' must contain at least one item when source is 'playlist'",
+		},
+		{
+			"playlist that contains the path itself",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: playlist\n" +
+				"    sourcePlaylist:\n" +
+				"      - path: mypath\n",
+			"playlist item 'mypath' can't be the path itself",
+		},
+		{
+			"composite with wrong number of paths",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: composite\n" +
+				"    sourceComposite: [a, b, c]\n",
+			"'sourceComposite' must contain 2 paths when 'sourceCompositeLayout' is 'pip'",
+		},
+		{
+			"invalid composite layout",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: composite\n" +
+				"    sourceComposite: [a, b]\n" +
+				"    sourceCompositeLayout: test\n",
+			"invalid 'sourceCompositeLayout': 'test'",
+		},
+		{
+			"delay of the path itself",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: delay\n" +
+				"    sourceDelayPath: mypath\n",
+			"'sourceDelayPath' can't be the path itself",
+		},
+		{
+			"delay without delay",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: delay\n" +
+				"    sourceDelayPath: other\n" +
+				"    sourceDelay: 0s\n",
+			"'sourceDelay' must be greater than zero",
+		},
+		{
+			"processor of the path itself",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: processor\n" +
+				"    sourceProcessorPath: mypath\n" +
+				"    sourceProcessorCommand: cat\n",
+			"'sourceProcessorPath' can't be the path itself",
+		},
+		{
+			"processor without command",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    source: processor\n" +
+				"    sourceProcessorPath: other\n",
+			"'sourceProcessorCommand' is empty",
+		},
+		{
+			"negative max keyframe interval",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    maxKeyframeInterval: -1s\n",
+			"'maxKeyframeInterval' must be greater than or equal to zero",
+		},
+		{
+			"run on long keyframe interval without max keyframe interval",
+			"paths:\n" +
+				"  mypath:\n" +
+				"    maxKeyframeInterval: 0s\n" +
+				"    runOnLongKeyframeInterval: echo\n",
+			"'runOnLongKeyframeInterval' requires 'maxKeyframeInterval'",
+		},
 		{
 			"double raspberry pi camera",
 			"paths:\n" +
@@ -374,6 +931,11 @@ func TestConfErrors(t *testing.T) {
 				"authJWTClaimKey: \"\"",
 			"'authJWTClaimKey' is empty",
 		},
+		{
+			"invalid auth policy",
+			"authPolicy: path",
+			"invalid 'authPolicy': policy must evaluate to a boolean, but evaluates to string",
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			tmpf, err := createTempFile([]byte(ca.conf))
@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// HLSMuxerCreation is the hlsMuxerCreation parameter.
+type HLSMuxerCreation int
+
+// supported values.
+const (
+	HLSMuxerCreationGlobal HLSMuxerCreation = iota
+	HLSMuxerCreationPreWarm
+	HLSMuxerCreationLazy
+)
+
+// MarshalJSON implements json.Marshaler.
+func (d HLSMuxerCreation) MarshalJSON() ([]byte, error) {
+	var out string
+
+	switch d {
+	case HLSMuxerCreationPreWarm:
+		out = "preWarm"
+
+	case HLSMuxerCreationLazy:
+		out = "lazy"
+
+	default:
+		out = "global"
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *HLSMuxerCreation) UnmarshalJSON(b []byte) error {
+	var in string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	switch in {
+	case "global":
+		*d = HLSMuxerCreationGlobal
+
+	case "preWarm":
+		*d = HLSMuxerCreationPreWarm
+
+	case "lazy":
+		*d = HLSMuxerCreationLazy
+
+	default:
+		return fmt.Errorf("invalid HLS muxer creation '%s'", in)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *HLSMuxerCreation) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
@@ -0,0 +1,46 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var httpHeaderNameRegexp = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// HTTPResponseHeader is a header that is added to HTTP responses.
+type HTTPResponseHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HTTPResponseHeaders is a list of HTTPResponseHeader.
+type HTTPResponseHeaders []HTTPResponseHeader
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *HTTPResponseHeaders) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*h = nil
+	return json.Unmarshal(b, (*[]HTTPResponseHeader)(h))
+}
+
+func (h HTTPResponseHeaders) validate() error {
+	for _, e := range h {
+		if !httpHeaderNameRegexp.MatchString(e.Name) {
+			return fmt.Errorf("invalid HTTP response header name '%s'", e.Name)
+		}
+
+		if http.CanonicalHeaderKey(e.Name) == "Server" {
+			return fmt.Errorf("the Server header can't be set in 'httpResponseHeaders', use 'serverHeader'")
+		}
+
+		if strings.ContainsAny(e.Value, "\r\n") {
+			return fmt.Errorf("value of HTTP response header '%s' contains invalid characters", e.Name)
+		}
+	}
+
+	return nil
+}
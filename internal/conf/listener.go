@@ -0,0 +1,69 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Listener is an additional address on which a server accepts connections.
+type Listener struct {
+	Address    string `json:"address"`
+	Encryption bool   `json:"encryption"`
+	ServerKey  string `json:"serverKey"`
+	ServerCert string `json:"serverCert"`
+}
+
+// Listeners is a list of Listener.
+type Listeners []Listener
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Listeners) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]Listener)(s))
+}
+
+// WithEncryption returns listeners with the given encryption setting.
+func (s Listeners) WithEncryption(encryption bool) Listeners {
+	var ret Listeners
+	for _, l := range s {
+		if l.Encryption == encryption {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+// Addresses returns the addresses of listeners.
+func (s Listeners) Addresses() []string {
+	ret := make([]string, len(s))
+	for i, l := range s {
+		ret[i] = l.Address
+	}
+	return ret
+}
+
+// validate checks listeners against the encryption setting of the server
+// and against the support of per-listener certificates.
+func (s Listeners) validate(key string, encryption Encryption, certsAllowed bool) error {
+	for _, l := range s {
+		if l.Address == "" {
+			return fmt.Errorf("'address' of '%s' can't be empty", key)
+		}
+
+		if l.Encryption && encryption == EncryptionNo {
+			return fmt.Errorf("'%s' contains an encrypted listener, but encryption is disabled", key)
+		}
+
+		if !l.Encryption && encryption == EncryptionStrict {
+			return fmt.Errorf("'%s' contains an unencrypted listener, but encryption is strict", key)
+		}
+
+		if (l.ServerKey != "" || l.ServerCert != "") && (!certsAllowed || !l.Encryption) {
+			return fmt.Errorf("'%s' contains a listener with a certificate that can't be used", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LPCMChannelMap is a LPCM channel map.
+// Every entry is an output channel, that contains the input channels (starting from 1) that are mixed into it.
+type LPCMChannelMap [][]int
+
+// MarshalJSON implements json.Marshaler.
+func (d LPCMChannelMap) MarshalJSON() ([]byte, error) {
+	out := make([]string, len(d))
+
+	for i, channels := range d {
+		tmp := make([]string, len(channels))
+		for j, ch := range channels {
+			tmp[j] = strconv.FormatInt(int64(ch), 10)
+		}
+		out[i] = strings.Join(tmp, "+")
+	}
+
+	return json.Marshal(strings.Join(out, ","))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *LPCMChannelMap) UnmarshalJSON(b []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var in string
+
+	switch v := raw.(type) {
+	case nil:
+
+	case string:
+		in = v
+
+	case float64: // YAML turns single channels into numbers
+		in = strconv.FormatFloat(v, 'f', -1, 64)
+
+	default:
+		return fmt.Errorf("invalid LPCM channel map: %v", raw)
+	}
+
+	*d = nil
+
+	if in == "" {
+		return nil
+	}
+
+	for _, entry := range strings.Split(in, ",") {
+		var channels []int
+
+		for _, v := range strings.Split(entry, "+") {
+			ch, err := strconv.ParseUint(strings.TrimSpace(v), 10, 31)
+			if err != nil || ch == 0 {
+				return fmt.Errorf("invalid LPCM channel map: '%s'", in)
+			}
+
+			channels = append(channels, int(ch))
+		}
+
+		*d = append(*d, channels)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *LPCMChannelMap) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
@@ -7,6 +7,7 @@ import (
 	gourl "net/url"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -87,23 +88,97 @@ type Path struct {
 	Name   string         `json:"name"` // filled by Check()
 
 	// General
-	Source                     string         `json:"source"`
-	SourceFingerprint          string         `json:"sourceFingerprint"`
-	SourceOnDemand             bool           `json:"sourceOnDemand"`
-	SourceOnDemandStartTimeout StringDuration `json:"sourceOnDemandStartTimeout"`
-	SourceOnDemandCloseAfter   StringDuration `json:"sourceOnDemandCloseAfter"`
-	MaxReaders                 int            `json:"maxReaders"`
-	SRTReadPassphrase          string         `json:"srtReadPassphrase"`
-	Fallback                   string         `json:"fallback"`
+	Source                     string              `json:"source"`
+	SourceFingerprint          string              `json:"sourceFingerprint"`
+	SourceOnDemand             bool                `json:"sourceOnDemand"`
+	SourceOnDemandStartTimeout StringDuration      `json:"sourceOnDemandStartTimeout"`
+	SourceOnDemandCloseAfter   StringDuration      `json:"sourceOnDemandCloseAfter"`
+	SourceOnDemandLinger       StringDuration      `json:"sourceOnDemandLinger"`
+	SourceRetryDelay           StringDuration      `json:"sourceRetryDelay"`
+	SourceRetryMultiplier      float64             `json:"sourceRetryMultiplier"`
+	SourceRetryMaxDelay        StringDuration      `json:"sourceRetryMaxDelay"`
+	SourceRetryJitter          float64             `json:"sourceRetryJitter"`
+	SourceRetryMaxAttempts     int                 `json:"sourceRetryMaxAttempts"`
+	MaxReaders                 int                 `json:"maxReaders"`
+	MaxWHEPReaders             int                 `json:"maxWHEPReaders"`
+	MaxReaderDuration          StringDuration      `json:"maxReaderDuration"`
+	PublishProtocols           PathProtocols       `json:"publishProtocols"`
+	ReadProtocols              PathProtocols       `json:"readProtocols"`
+	UserAgentRules             UserAgentRules      `json:"userAgentRules"`
+	RTSPReaderTransportRules   RTSPTransportRules  `json:"rtspReaderTransportRules"`
+	SRTReadPassphrase          string              `json:"srtReadPassphrase"`
+	Fallback                   string              `json:"fallback"`
+	SanitizeBitstream          bool                `json:"sanitizeBitstream"`
+	InsertParameterSets        bool                `json:"insertParameterSets"`
+	HLSMuxerCreation           HLSMuxerCreation    `json:"hlsMuxerCreation"`
+	WebRTCPassthrough          bool                `json:"webrtcPassthrough"`
+	CodecChangeBehavior        CodecChangeBehavior `json:"codecChangeBehavior"`
+	LPCMLittleEndian           bool                `json:"lpcmLittleEndian"`
+	LPCMBitDepth               int                 `json:"lpcmBitDepth"`
+	LPCMChannelMap             LPCMChannelMap      `json:"lpcmChannelMap"`
+	Subtitles                  bool                `json:"subtitles"`
+	ClockSource                ClockSource         `json:"clockSource"`
+	AudioGapFilling            bool                `json:"audioGapFilling"`
+	AudioGapFillingThreshold   StringDuration      `json:"audioGapFillingThreshold"`
+	Capture                    bool                `json:"capture"`
+	CaptureDuration            StringDuration      `json:"captureDuration"`
 
 	// Record
-	Record                bool           `json:"record"`
-	Playback              *bool          `json:"playback,omitempty"` // deprecated
-	RecordPath            string         `json:"recordPath"`
-	RecordFormat          RecordFormat   `json:"recordFormat"`
-	RecordPartDuration    StringDuration `json:"recordPartDuration"`
-	RecordSegmentDuration StringDuration `json:"recordSegmentDuration"`
-	RecordDeleteAfter     StringDuration `json:"recordDeleteAfter"`
+	Record                  bool           `json:"record"`
+	Playback                *bool          `json:"playback,omitempty"` // deprecated
+	RecordPath              string         `json:"recordPath"`
+	RecordFormat            RecordFormat   `json:"recordFormat"`
+	RecordPartDuration      StringDuration `json:"recordPartDuration"`
+	RecordSegmentDuration   StringDuration `json:"recordSegmentDuration"`
+	RecordDataTracks        bool           `json:"recordDataTracks"`
+	RecordDeleteAfter       StringDuration `json:"recordDeleteAfter"`
+	RecordStorage           RecordStorage  `json:"recordStorage"`
+	RecordS3Endpoint        string         `json:"recordS3Endpoint"`
+	RecordS3Region          string         `json:"recordS3Region"`
+	RecordS3Bucket          string         `json:"recordS3Bucket"`
+	RecordS3AccessKeyID     string         `json:"recordS3AccessKeyID"`
+	RecordS3SecretAccessKey string         `json:"recordS3SecretAccessKey"`
+	RecordWebDAVURL         string         `json:"recordWebDAVURL"`
+	RecordWebDAVUser        string         `json:"recordWebDAVUser"`
+	RecordWebDAVPass        string         `json:"recordWebDAVPass"`
+	RecordPostProcess       string         `json:"recordPostProcess"`
+
+	// Audio metering
+	AudioMeter            bool           `json:"audioMeter"`
+	AudioSilenceThreshold float64        `json:"audioSilenceThreshold"`
+	AudioSilenceDuration  StringDuration `json:"audioSilenceDuration"`
+
+	// Video monitoring
+	VideoMonitor         bool           `json:"videoMonitor"`
+	VideoMonitorDuration StringDuration `json:"videoMonitorDuration"`
+
+	// GOP monitoring
+	MaxKeyframeInterval StringDuration `json:"maxKeyframeInterval"`
+
+	// RTP output
+	RTPOutputAddress string `json:"rtpOutputAddress"`
+	RTPOutputSDPPath string `json:"rtpOutputSDPPath"`
+
+	// CMAF push
+	CMAFPushURL             string         `json:"cmafPushURL"`
+	CMAFPushMethod          string         `json:"cmafPushMethod"`
+	CMAFPushUser            string         `json:"cmafPushUser"`
+	CMAFPushPass            string         `json:"cmafPushPass"`
+	CMAFPushSegmentDuration StringDuration `json:"cmafPushSegmentDuration"`
+	CMAFPushChunkDuration   StringDuration `json:"cmafPushChunkDuration"`
+	CMAFPushPlaylist        bool           `json:"cmafPushPlaylist"`
+
+	// Watermark
+	WatermarkImage    string `json:"watermarkImage"`
+	WatermarkText     string `json:"watermarkText"`
+	WatermarkPosition string `json:"watermarkPosition"`
+
+	// PTZ
+	PTZURL          string       `json:"ptzURL"`
+	PTZUser         string       `json:"ptzUser"`
+	PTZPass         string       `json:"ptzPass"`
+	PTZProfileToken string       `json:"ptzProfileToken"`
+	PTZTour         PTZTourSteps `json:"ptzTour"`
 
 	// Authentication (deprecated)
 	PublishUser *Credential `json:"publishUser,omitempty"` // deprecated
@@ -114,9 +189,12 @@ type Path struct {
 	ReadIPs     *IPNetworks `json:"readIPs,omitempty"`     // deprecated
 
 	// Publisher source
-	OverridePublisher        bool   `json:"overridePublisher"`
-	DisablePublisherOverride *bool  `json:"disablePublisherOverride,omitempty"` // deprecated
-	SRTPublishPassphrase     string `json:"srtPublishPassphrase"`
+	OverridePublisher        bool           `json:"overridePublisher"`
+	DisablePublisherOverride *bool          `json:"disablePublisherOverride,omitempty"` // deprecated
+	SRTPublishPassphrase     string         `json:"srtPublishPassphrase"`
+	PublisherResumeTimeout   StringDuration `json:"publisherResumeTimeout"`
+	PublisherIdleTimeout     StringDuration `json:"publisherIdleTimeout"`
+	PublisherMinBitrate      uint           `json:"publisherMinBitrate"`
 
 	// RTSP source
 	RTSPTransport       RTSPTransport  `json:"rtspTransport"`
@@ -125,10 +203,32 @@ type Path struct {
 	SourceAnyPortEnable *bool          `json:"sourceAnyPortEnable,omitempty"` // deprecated
 	RTSPRangeType       RTSPRangeType  `json:"rtspRangeType"`
 	RTSPRangeStart      string         `json:"rtspRangeStart"`
+	RTSPUDPPortRange    UDPPortRange   `json:"rtspUDPPortRange"`
+	RTSPUserAgent       string         `json:"rtspUserAgent"`
+	RTSPUDPReadTimeout  StringDuration `json:"rtspUDPReadTimeout"`
+
+	// WebRTC source
+	WHEPUDPPortRange UDPPortRange `json:"whepUDPPortRange"`
 
 	// Redirect source
 	SourceRedirect string `json:"sourceRedirect"`
 
+	// Playlist source
+	SourcePlaylist PlaylistItems `json:"sourcePlaylist"`
+
+	// Composite source
+	SourceComposite       []string `json:"sourceComposite"`
+	SourceCompositeLayout string   `json:"sourceCompositeLayout"`
+
+	// Delay source
+	SourceDelayPath       string         `json:"sourceDelayPath"`
+	SourceDelay           StringDuration `json:"sourceDelay"`
+	SourceDelayBufferSize StringSize     `json:"sourceDelayBufferSize"`
+
+	// Processor source
+	SourceProcessorPath    string `json:"sourceProcessorPath"`
+	SourceProcessorCommand string `json:"sourceProcessorCommand"`
+
 	// Raspberry Pi Camera source
 	RPICameraCamID             uint      `json:"rpiCameraCamID"`
 	RPICameraWidth             uint      `json:"rpiCameraWidth"`
@@ -180,8 +280,17 @@ type Path struct {
 	RunOnRead                  string         `json:"runOnRead"`
 	RunOnReadRestart           bool           `json:"runOnReadRestart"`
 	RunOnUnread                string         `json:"runOnUnread"`
+	RunOnFirstReader           string         `json:"runOnFirstReader"`
+	RunOnFirstReaderRestart    bool           `json:"runOnFirstReaderRestart"`
+	RunOnLastReader            string         `json:"runOnLastReader"`
 	RunOnRecordSegmentCreate   string         `json:"runOnRecordSegmentCreate"`
 	RunOnRecordSegmentComplete string         `json:"runOnRecordSegmentComplete"`
+	RunOnAudioSilence          string         `json:"runOnAudioSilence"`
+	RunOnVideoFrozen           string         `json:"runOnVideoFrozen"`
+	RunOnVideoBlack            string         `json:"runOnVideoBlack"`
+	RunOnOpenGOP               string         `json:"runOnOpenGOP"`
+	RunOnLongKeyframeInterval  string         `json:"runOnLongKeyframeInterval"`
+	RunOnPublisherIdle         string         `json:"runOnPublisherIdle"`
 }
 
 func (pconf *Path) setDefaults() {
@@ -189,6 +298,13 @@ func (pconf *Path) setDefaults() {
 	pconf.Source = "publisher"
 	pconf.SourceOnDemandStartTimeout = 10 * StringDuration(time.Second)
 	pconf.SourceOnDemandCloseAfter = 10 * StringDuration(time.Second)
+	pconf.SourceRetryDelay = 5 * StringDuration(time.Second)
+	pconf.SourceRetryMultiplier = 1
+	pconf.SourceRetryMaxDelay = 60 * StringDuration(time.Second)
+	pconf.UserAgentRules = UserAgentRules{}
+	pconf.RTSPReaderTransportRules = RTSPTransportRules{}
+	pconf.AudioGapFillingThreshold = StringDuration(500 * time.Millisecond)
+	pconf.CaptureDuration = 10 * StringDuration(time.Second)
 
 	// Record
 	pconf.RecordPath = "./recordings/%path/%Y-%m-%d_%H-%M-%S-%f"
@@ -196,10 +312,46 @@ func (pconf *Path) setDefaults() {
 	pconf.RecordPartDuration = StringDuration(1 * time.Second)
 	pconf.RecordSegmentDuration = 3600 * StringDuration(time.Second)
 	pconf.RecordDeleteAfter = 24 * 3600 * StringDuration(time.Second)
+	pconf.RecordS3Region = "us-east-1"
+
+	// Audio metering
+	pconf.AudioSilenceThreshold = -60
+	pconf.AudioSilenceDuration = 10 * StringDuration(time.Second)
+
+	// Video monitoring
+	pconf.VideoMonitorDuration = 10 * StringDuration(time.Second)
+
+	// GOP monitoring
+	pconf.MaxKeyframeInterval = 10 * StringDuration(time.Second)
+
+	// CMAF push
+	pconf.CMAFPushMethod = "PUT"
+	pconf.CMAFPushSegmentDuration = 4 * StringDuration(time.Second)
+	pconf.CMAFPushChunkDuration = StringDuration(500 * time.Millisecond)
+
+	// Watermark
+	pconf.WatermarkPosition = "bottomRight"
+
+	// PTZ
+	pconf.PTZTour = PTZTourSteps{}
 
 	// Publisher source
 	pconf.OverridePublisher = true
 
+	// RTSP source
+	pconf.RTSPUDPReadTimeout = 3 * StringDuration(time.Second)
+
+	// Playlist source
+	pconf.SourcePlaylist = PlaylistItems{}
+
+	// Composite source
+	pconf.SourceComposite = []string{}
+	pconf.SourceCompositeLayout = "pip"
+
+	// Delay source
+	pconf.SourceDelay = 30 * StringDuration(time.Second)
+	pconf.SourceDelayBufferSize = 200 * 1024 * 1024
+
 	// Raspberry Pi Camera source
 	pconf.RPICameraWidth = 1920
 	pconf.RPICameraHeight = 1080
@@ -351,8 +503,92 @@ func (pconf *Path) validate(
 
 	case pconf.Source == "redirect":
 
+	case pconf.Source == "cluster":
+		if !conf.Coordination {
+			return fmt.Errorf("source 'cluster' requires 'coordination' to be enabled")
+		}
+
 	case pconf.Source == "rpiCamera":
 
+	case pconf.Source == "playlist":
+		if len(pconf.SourcePlaylist) == 0 {
+			return fmt.Errorf("'sourcePlaylist' must contain at least one item when source is 'playlist'")
+		}
+
+		for _, item := range pconf.SourcePlaylist {
+			err := isValidPathName(item.Path)
+			if err != nil {
+				return fmt.Errorf("invalid playlist item '%s': %w", item.Path, err)
+			}
+
+			if item.Path == pconf.Name {
+				return fmt.Errorf("playlist item '%s' can't be the path itself", item.Path)
+			}
+
+			if item.Duration < 0 {
+				return fmt.Errorf("duration of playlist item '%s' must be greater than or equal to zero", item.Path)
+			}
+		}
+
+	case pconf.Source == "composite":
+		switch pconf.SourceCompositeLayout {
+		case "pip":
+			if len(pconf.SourceComposite) != 2 {
+				return fmt.Errorf("'sourceComposite' must contain 2 paths when 'sourceCompositeLayout' is 'pip'")
+			}
+
+		case "grid":
+			if len(pconf.SourceComposite) < 2 || len(pconf.SourceComposite) > 4 {
+				return fmt.Errorf("'sourceComposite' must contain between 2 and 4 paths when 'sourceCompositeLayout' is 'grid'")
+			}
+
+		default:
+			return fmt.Errorf("invalid 'sourceCompositeLayout': '%s'", pconf.SourceCompositeLayout)
+		}
+
+		for _, name := range pconf.SourceComposite {
+			err := isValidPathName(name)
+			if err != nil {
+				return fmt.Errorf("invalid composite path '%s': %w", name, err)
+			}
+
+			if name == pconf.Name {
+				return fmt.Errorf("composite path '%s' can't be the path itself", name)
+			}
+		}
+
+	case pconf.Source == "delay":
+		err := isValidPathName(pconf.SourceDelayPath)
+		if err != nil {
+			return fmt.Errorf("invalid 'sourceDelayPath': %w", err)
+		}
+
+		if pconf.SourceDelayPath == pconf.Name {
+			return fmt.Errorf("'sourceDelayPath' can't be the path itself")
+		}
+
+		if pconf.SourceDelay <= 0 {
+			return fmt.Errorf("'sourceDelay' must be greater than zero")
+		}
+
+		if pconf.SourceDelayBufferSize == 0 {
+			return fmt.Errorf("'sourceDelayBufferSize' must be greater than zero")
+		}
+
+	case pconf.Source == "processor":
+		err := isValidPathName(pconf.SourceProcessorPath)
+		if err != nil {
+			return fmt.Errorf("invalid 'sourceProcessorPath': %w", err)
+		}
+
+		if pconf.SourceProcessorPath == pconf.Name {
+			return fmt.Errorf("'sourceProcessorPath' can't be the path itself")
+		}
+
+		if pconf.SourceProcessorCommand == "" {
+			return fmt.Errorf("'sourceProcessorCommand' is empty")
+		}
+
 	default:
 		return fmt.Errorf("invalid source: '%s'", pconf.Source)
 	}
@@ -361,6 +597,79 @@ func (pconf *Path) validate(
 			return fmt.Errorf("'sourceOnDemand' is useless when source is 'publisher'")
 		}
 	}
+	if pconf.RTSPUDPPortRange.Size() != 0 &&
+		(pconf.RTSPUDPPortRange.Min%2 != 0 || pconf.RTSPUDPPortRange.Size() < 2) {
+		return fmt.Errorf("'rtspUDPPortRange' must start with an even port and contain at least 2 ports")
+	}
+	if strings.ContainsAny(pconf.RTSPUserAgent, "\r\n") {
+		return fmt.Errorf("'rtspUserAgent' contains invalid characters")
+	}
+	if pconf.RTSPUDPReadTimeout <= 0 {
+		return fmt.Errorf("'rtspUDPReadTimeout' must be greater than zero")
+	}
+	if pconf.SourceOnDemandLinger < 0 {
+		return fmt.Errorf("'sourceOnDemandLinger' must be greater than or equal to zero")
+	}
+	if pconf.SourceRetryDelay <= 0 {
+		return fmt.Errorf("'sourceRetryDelay' must be greater than zero")
+	}
+	if pconf.SourceRetryMultiplier < 1 {
+		return fmt.Errorf("'sourceRetryMultiplier' must be greater than or equal to 1")
+	}
+	if pconf.SourceRetryMaxDelay < pconf.SourceRetryDelay {
+		return fmt.Errorf("'sourceRetryMaxDelay' must be greater than or equal to 'sourceRetryDelay'")
+	}
+	if pconf.SourceRetryJitter < 0 || pconf.SourceRetryJitter > 1 {
+		return fmt.Errorf("'sourceRetryJitter' must be between 0 and 1")
+	}
+	if pconf.SourceRetryMaxAttempts < 0 {
+		return fmt.Errorf("'sourceRetryMaxAttempts' must be greater than or equal to zero")
+	}
+	if !pconf.PublishProtocols.Allows("rtsp") && !pconf.PublishProtocols.Allows("rtmp") &&
+		!pconf.PublishProtocols.Allows("webrtc") && !pconf.PublishProtocols.Allows("srt") &&
+		!pconf.PublishProtocols.Allows("ipc") {
+		return fmt.Errorf("'publishProtocols' must contain at least one protocol that supports publishing")
+	}
+	for _, r := range pconf.RTSPReaderTransportRules {
+		err := r.validate()
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range pconf.UserAgentRules {
+		err := r.validate()
+		if err != nil {
+			return err
+		}
+
+		if r.Path == name {
+			return fmt.Errorf("user agent rules can't route clients to the path itself")
+		}
+	}
+	if pconf.MaxReaderDuration < 0 {
+		return fmt.Errorf("'maxReaderDuration' must be greater than or equal to zero")
+	}
+	if pconf.PublisherResumeTimeout < 0 {
+		return fmt.Errorf("'publisherResumeTimeout' must be greater than or equal to zero")
+	}
+	if pconf.AudioGapFilling && pconf.AudioGapFillingThreshold <= 0 {
+		return fmt.Errorf("'audioGapFillingThreshold' must be greater than zero")
+	}
+	if pconf.Capture && pconf.CaptureDuration <= 0 {
+		return fmt.Errorf("'captureDuration' must be greater than zero")
+	}
+	if pconf.PublisherIdleTimeout < 0 {
+		return fmt.Errorf("'publisherIdleTimeout' must be greater than or equal to zero")
+	}
+	if pconf.PublisherMinBitrate != 0 && pconf.PublisherIdleTimeout == 0 {
+		return fmt.Errorf("'publisherMinBitrate' requires 'publisherIdleTimeout'")
+	}
+	if pconf.RunOnPublisherIdle != "" && pconf.PublisherIdleTimeout == 0 {
+		return fmt.Errorf("'runOnPublisherIdle' requires 'publisherIdleTimeout'")
+	}
+	if pconf.MaxWHEPReaders < 0 {
+		return fmt.Errorf("'maxWHEPReaders' must be greater than or equal to zero")
+	}
 	if pconf.SRTReadPassphrase != "" {
 		err := srtCheckPassphrase(pconf.SRTReadPassphrase)
 		if err != nil {
@@ -380,6 +689,11 @@ func (pconf *Path) validate(
 			}
 		}
 	}
+	switch pconf.LPCMBitDepth {
+	case 0, 16, 24, 32:
+	default:
+		return fmt.Errorf("invalid 'lpcmBitDepth' value")
+	}
 
 	// Record
 
@@ -397,6 +711,116 @@ func (pconf *Path) validate(
 		}
 	}
 
+	switch pconf.RecordStorage {
+	case RecordStorageS3:
+		if pconf.RecordS3Endpoint == "" || pconf.RecordS3Bucket == "" {
+			return fmt.Errorf("'recordS3Endpoint' and 'recordS3Bucket' are required when 'recordStorage' is 's3'")
+		}
+
+	case RecordStorageWebDAV:
+		if pconf.RecordWebDAVURL == "" {
+			return fmt.Errorf("'recordWebDAVURL' is required when 'recordStorage' is 'webdav'")
+		}
+	}
+	if pconf.RecordPostProcess != "" && pconf.RecordStorage != RecordStorageLocal {
+		return fmt.Errorf("'recordPostProcess' requires 'recordStorage' to be 'local'")
+	}
+
+	// Audio metering
+
+	if pconf.AudioSilenceThreshold > 0 {
+		return fmt.Errorf("'audioSilenceThreshold' must be lower or equal than zero")
+	}
+	if pconf.AudioSilenceDuration < 0 {
+		return fmt.Errorf("'audioSilenceDuration' must be greater or equal than zero")
+	}
+	if pconf.RunOnAudioSilence != "" && !pconf.AudioMeter {
+		return fmt.Errorf("'runOnAudioSilence' requires 'audioMeter'")
+	}
+
+	// Video monitoring
+
+	if pconf.VideoMonitorDuration <= 0 {
+		return fmt.Errorf("'videoMonitorDuration' must be greater than zero")
+	}
+	if (pconf.RunOnVideoFrozen != "" || pconf.RunOnVideoBlack != "") && !pconf.VideoMonitor {
+		return fmt.Errorf("'runOnVideoFrozen' and 'runOnVideoBlack' require 'videoMonitor'")
+	}
+
+	// GOP monitoring
+
+	if pconf.MaxKeyframeInterval < 0 {
+		return fmt.Errorf("'maxKeyframeInterval' must be greater than or equal to zero")
+	}
+	if pconf.RunOnLongKeyframeInterval != "" && pconf.MaxKeyframeInterval == 0 {
+		return fmt.Errorf("'runOnLongKeyframeInterval' requires 'maxKeyframeInterval'")
+	}
+
+	// RTP output
+
+	if pconf.RTPOutputAddress != "" {
+		_, port, err := net.SplitHostPort(pconf.RTPOutputAddress)
+		if err != nil {
+			return fmt.Errorf("invalid 'rtpOutputAddress': %w", err)
+		}
+		if _, err = strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("invalid 'rtpOutputAddress' port: %s", port)
+		}
+	}
+	if pconf.RTPOutputSDPPath != "" && pconf.RTPOutputAddress == "" {
+		return fmt.Errorf("'rtpOutputSDPPath' requires 'rtpOutputAddress'")
+	}
+
+	// CMAF push
+
+	if pconf.CMAFPushURL != "" &&
+		!strings.HasPrefix(pconf.CMAFPushURL, "http://") &&
+		!strings.HasPrefix(pconf.CMAFPushURL, "https://") {
+		return fmt.Errorf("'cmafPushURL' must be a HTTP or HTTPS URL")
+	}
+	if pconf.CMAFPushMethod != "PUT" && pconf.CMAFPushMethod != "POST" {
+		return fmt.Errorf("invalid 'cmafPushMethod': '%s'", pconf.CMAFPushMethod)
+	}
+	if pconf.CMAFPushSegmentDuration <= 0 {
+		return fmt.Errorf("'cmafPushSegmentDuration' must be greater than zero")
+	}
+	if pconf.CMAFPushChunkDuration <= 0 {
+		return fmt.Errorf("'cmafPushChunkDuration' must be greater than zero")
+	}
+
+	// Watermark
+
+	switch pconf.WatermarkPosition {
+	case "topLeft", "topRight", "bottomLeft", "bottomRight":
+	default:
+		return fmt.Errorf("invalid 'watermarkPosition' value")
+	}
+	if pconf.WatermarkImage != "" && !strings.HasSuffix(strings.ToLower(pconf.WatermarkImage), ".png") {
+		return fmt.Errorf("'watermarkImage' must be a PNG file")
+	}
+
+	// PTZ
+
+	if pconf.PTZURL != "" {
+		if !strings.HasPrefix(pconf.PTZURL, "http://") && !strings.HasPrefix(pconf.PTZURL, "https://") {
+			return fmt.Errorf("'ptzURL' must be a HTTP URL")
+		}
+		if pconf.PTZProfileToken == "" {
+			return fmt.Errorf("'ptzProfileToken' is required when 'ptzURL' is set")
+		}
+	}
+	if len(pconf.PTZTour) != 0 && pconf.PTZURL == "" {
+		return fmt.Errorf("'ptzTour' requires 'ptzURL'")
+	}
+	for _, step := range pconf.PTZTour {
+		if step.Preset == "" {
+			return fmt.Errorf("PTZ tour steps must have a preset")
+		}
+		if step.Dwell <= 0 {
+			return fmt.Errorf("dwell of PTZ tour step '%s' must be greater than zero", step.Preset)
+		}
+	}
+
 	// Authentication (deprecated)
 
 	if deprecatedCredentialsMode {
@@ -588,7 +1012,12 @@ func (pconf Path) HasStaticSource() bool {
 		strings.HasPrefix(pconf.Source, "srt://") ||
 		strings.HasPrefix(pconf.Source, "whep://") ||
 		strings.HasPrefix(pconf.Source, "wheps://") ||
-		pconf.Source == "rpiCamera"
+		pconf.Source == "cluster" ||
+		pconf.Source == "rpiCamera" ||
+		pconf.Source == "playlist" ||
+		pconf.Source == "composite" ||
+		pconf.Source == "delay" ||
+		pconf.Source == "processor"
 }
 
 // HasOnDemandStaticSource checks whether the path has a on demand static source.
@@ -0,0 +1,67 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PathProtocols is a list of protocols that can be used to publish or read a path.
+// An empty list means that all protocols are allowed.
+type PathProtocols []string
+
+// MarshalJSON implements json.Marshaler.
+func (d PathProtocols) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(d))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *PathProtocols) UnmarshalJSON(b []byte) error {
+	var in []string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	*d = nil
+
+	for _, proto := range in {
+		switch proto {
+		case "rtsp", "rtmp", "hls", "webrtc", "srt", "ipc", "dash":
+			*d = append(*d, proto)
+
+		default:
+			return fmt.Errorf("invalid protocol: %s", proto)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *PathProtocols) UnmarshalEnv(_ string, v string) error {
+	if v == "" {
+		*d = nil
+		return nil
+	}
+
+	byts, _ := json.Marshal(strings.Split(v, ","))
+	return d.UnmarshalJSON(byts)
+}
+
+// Allows checks whether a protocol is allowed.
+func (d PathProtocols) Allows(proto string) bool {
+	if len(d) == 0 {
+		return true
+	}
+
+	for _, p := range d {
+		if p == proto {
+			return true
+		}
+	}
+
+	return false
+}
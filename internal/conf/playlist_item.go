@@ -0,0 +1,20 @@
+package conf
+
+import "encoding/json"
+
+// PlaylistItem is an item of a playlist.
+type PlaylistItem struct {
+	Path     string         `json:"path"`
+	Duration StringDuration `json:"duration"`
+}
+
+// PlaylistItems is a list of PlaylistItem.
+type PlaylistItems []PlaylistItem
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *PlaylistItems) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]PlaylistItem)(s))
+}
@@ -0,0 +1,20 @@
+package conf
+
+import "encoding/json"
+
+// PTZTourStep is a step of a PTZ tour.
+type PTZTourStep struct {
+	Preset string         `json:"preset"`
+	Dwell  StringDuration `json:"dwell"`
+}
+
+// PTZTourSteps is a list of PTZTourStep.
+type PTZTourSteps []PTZTourStep
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *PTZTourSteps) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]PTZTourStep)(s))
+}
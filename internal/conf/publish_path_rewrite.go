@@ -0,0 +1,64 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PublishPathRewrite is a rule that rewrites path names requested by publishers.
+// Replace is a template that can contain groups of Match,
+// in the syntax accepted by regexp.Regexp.Expand ($1, $name, ${name}).
+type PublishPathRewrite struct {
+	Match     string        `json:"match"`
+	Replace   string        `json:"replace"`
+	Lowercase bool          `json:"lowercase"`
+	Protocols PathProtocols `json:"protocols"`
+}
+
+func (r PublishPathRewrite) validate() error {
+	if r.Match == "" {
+		return fmt.Errorf("'match' of publish path rewrites can't be empty")
+	}
+
+	_, err := regexp.Compile(r.Match)
+	if err != nil {
+		return fmt.Errorf("invalid 'match' of publish path rewrite: %w", err)
+	}
+
+	return nil
+}
+
+// PublishPathRewrites is a list of PublishPathRewrite.
+type PublishPathRewrites []PublishPathRewrite
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *PublishPathRewrites) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]PublishPathRewrite)(s))
+}
+
+// Apply applies rules to a path name requested by a publisher that uses the given protocol.
+// Rules are applied in order, each one to the result of the previous one.
+func (s PublishPathRewrites) Apply(name string, proto string) string {
+	for _, r := range s {
+		if !r.Protocols.Allows(proto) {
+			continue
+		}
+
+		re, err := regexp.Compile(r.Match)
+		if err != nil || !re.MatchString(name) {
+			continue
+		}
+
+		name = re.ReplaceAllString(name, r.Replace)
+
+		if r.Lowercase {
+			name = strings.ToLower(name)
+		}
+	}
+	return name
+}
@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RecordStorage is the recordStorage parameter.
+type RecordStorage int
+
+// supported values.
+const (
+	RecordStorageLocal RecordStorage = iota
+	RecordStorageS3
+	RecordStorageWebDAV
+)
+
+// MarshalJSON implements json.Marshaler.
+func (d RecordStorage) MarshalJSON() ([]byte, error) {
+	var out string
+
+	switch d {
+	case RecordStorageS3:
+		out = "s3"
+
+	case RecordStorageWebDAV:
+		out = "webdav"
+
+	default:
+		out = "local"
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *RecordStorage) UnmarshalJSON(b []byte) error {
+	var in string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	switch in {
+	case "local":
+		*d = RecordStorageLocal
+
+	case "s3":
+		*d = RecordStorageS3
+
+	case "webdav":
+		*d = RecordStorageWebDAV
+
+	default:
+		return fmt.Errorf("invalid record storage '%s'", in)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *RecordStorage) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
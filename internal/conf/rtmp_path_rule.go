@@ -0,0 +1,55 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// RTMPPathRule is a rule that maps paths of RTMP URLs to path names and credentials.
+// Fields other than Match are templates that can contain groups of Match,
+// in the syntax accepted by regexp.Regexp.Expand ($1, $name, ${name}).
+type RTMPPathRule struct {
+	Match string `json:"match"`
+	Path  string `json:"path"`
+	User  string `json:"user"`
+	Pass  string `json:"pass"`
+}
+
+func (r RTMPPathRule) validate() error {
+	if r.Match == "" {
+		return fmt.Errorf("'match' of RTMP path rules can't be empty")
+	}
+
+	_, err := regexp.Compile(r.Match)
+	if err != nil {
+		return fmt.Errorf("invalid 'match' of RTMP path rule: %w", err)
+	}
+
+	if r.Path == "" {
+		return fmt.Errorf("'path' of RTMP path rules can't be empty")
+	}
+
+	return nil
+}
+
+// RTMPPathRules is a list of RTMPPathRule.
+type RTMPPathRules []RTMPPathRule
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *RTMPPathRules) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]RTMPPathRule)(s))
+}
+
+// Find returns the first rule that matches a path, or nil.
+func (s RTMPPathRules) Find(path string) *RTMPPathRule {
+	for i, r := range s {
+		if m, _ := regexp.MatchString(r.Match, path); m {
+			return &s[i]
+		}
+	}
+	return nil
+}
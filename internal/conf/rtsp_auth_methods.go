@@ -21,8 +21,11 @@ func (d RTSPAuthMethods) MarshalJSON() ([]byte, error) {
 		case auth.ValidateMethodBasic:
 			out[i] = "basic"
 
-		default:
+		case auth.ValidateMethodDigestMD5:
 			out[i] = "digest"
+
+		default:
+			out[i] = "digestSHA256"
 		}
 	}
 
@@ -48,6 +51,9 @@ func (d *RTSPAuthMethods) UnmarshalJSON(b []byte) error {
 		case "digest":
 			*d = append(*d, auth.ValidateMethodDigestMD5)
 
+		case "digestSHA256":
+			*d = append(*d, auth.ValidateMethodSHA256)
+
 		default:
 			return fmt.Errorf("invalid authentication method: '%s'", v)
 		}
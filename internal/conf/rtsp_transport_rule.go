@@ -0,0 +1,47 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// RTSPTransportRule is a rule that restricts the RTSP transports
+// that can be used by readers that belong to certain networks.
+type RTSPTransportRule struct {
+	IPs        IPNetworks `json:"ips"`
+	Transports Protocols  `json:"transports"`
+}
+
+func (r RTSPTransportRule) validate() error {
+	if len(r.IPs) == 0 {
+		return fmt.Errorf("'ips' of RTSP transport rules can't be empty")
+	}
+
+	if len(r.Transports) == 0 {
+		return fmt.Errorf("'transports' of RTSP transport rules can't be empty")
+	}
+
+	return nil
+}
+
+// RTSPTransportRules is a list of RTSPTransportRule.
+type RTSPTransportRules []RTSPTransportRule
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *RTSPTransportRules) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]RTSPTransportRule)(s))
+}
+
+// Find returns the first rule that matches an IP, or nil.
+func (s RTSPTransportRules) Find(ip net.IP) *RTSPTransportRule {
+	for i, r := range s {
+		if r.IPs.Contains(ip) {
+			return &s[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SRTStreamIDRule is a rule that maps SRT stream IDs to actions, paths and credentials.
+// Fields other than Match are templates that can contain groups of Match,
+// in the syntax accepted by regexp.Regexp.Expand ($1, $name, ${name}).
+type SRTStreamIDRule struct {
+	Match  string `json:"match"`
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Query  string `json:"query"`
+	User   string `json:"user"`
+	Pass   string `json:"pass"`
+}
+
+func (r SRTStreamIDRule) validate() error {
+	if r.Match == "" {
+		return fmt.Errorf("'match' of SRT stream ID rules can't be empty")
+	}
+
+	_, err := regexp.Compile(r.Match)
+	if err != nil {
+		return fmt.Errorf("invalid 'match' of SRT stream ID rule: %w", err)
+	}
+
+	if r.Action != "" && !strings.Contains(r.Action, "$") {
+		switch r.Action {
+		case "read", "request", "publish":
+		default:
+			return fmt.Errorf("invalid 'action' of SRT stream ID rule: '%s'", r.Action)
+		}
+	}
+
+	return nil
+}
+
+// SRTStreamIDRules is a list of SRTStreamIDRule.
+type SRTStreamIDRules []SRTStreamIDRule
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SRTStreamIDRules) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]SRTStreamIDRule)(s))
+}
+
+// Find returns the first rule that matches a stream ID, or nil.
+func (s SRTStreamIDRules) Find(streamID string) *SRTStreamIDRule {
+	for i, r := range s {
+		if m, _ := regexp.MatchString(r.Match, streamID); m {
+			return &s[i]
+		}
+	}
+	return nil
+}
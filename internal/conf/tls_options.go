@@ -0,0 +1,160 @@
+package conf
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TLSVersion is a TLS version.
+type TLSVersion uint16
+
+// MarshalJSON implements json.Marshaler.
+func (d TLSVersion) MarshalJSON() ([]byte, error) {
+	var out string
+
+	switch d {
+	case tls.VersionTLS10:
+		out = "1.0"
+
+	case tls.VersionTLS11:
+		out = "1.1"
+
+	case tls.VersionTLS12:
+		out = "1.2"
+
+	case tls.VersionTLS13:
+		out = "1.3"
+
+	default:
+		out = ""
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *TLSVersion) UnmarshalJSON(b []byte) error {
+	var in interface{}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	var str string
+
+	switch v := in.(type) {
+	case string:
+		str = v
+
+	case float64: // YAML turns unquoted versions into numbers
+		str = strconv.FormatFloat(v, 'f', 1, 64)
+
+	default:
+		return fmt.Errorf("invalid TLS version: %v", in)
+	}
+
+	switch str {
+	case "":
+		*d = 0
+
+	case "1.0":
+		*d = tls.VersionTLS10
+
+	case "1.1":
+		*d = tls.VersionTLS11
+
+	case "1.2":
+		*d = tls.VersionTLS12
+
+	case "1.3":
+		*d = tls.VersionTLS13
+
+	default:
+		return fmt.Errorf("invalid TLS version: '%s'", str)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *TLSVersion) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
+
+// TLSCipherSuites is a list of TLS cipher suites.
+type TLSCipherSuites []uint16
+
+func cipherSuiteByName(name string) (uint16, bool) {
+	for _, s := range tls.CipherSuites() {
+		if s.Name == name {
+			return s.ID, true
+		}
+	}
+
+	for _, s := range tls.InsecureCipherSuites() {
+		if s.Name == name {
+			return s.ID, true
+		}
+	}
+
+	return 0, false
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d TLSCipherSuites) MarshalJSON() ([]byte, error) {
+	out := make([]string, len(d))
+
+	for i, v := range d {
+		out[i] = tls.CipherSuiteName(v)
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *TLSCipherSuites) UnmarshalJSON(b []byte) error {
+	var in []string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	*d = TLSCipherSuites{}
+
+	for _, v := range in {
+		id, ok := cipherSuiteByName(v)
+		if !ok {
+			return fmt.Errorf("invalid TLS cipher suite: '%s'", v)
+		}
+
+		*d = append(*d, id)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *TLSCipherSuites) UnmarshalEnv(_ string, v string) error {
+	if v == "" {
+		*d = TLSCipherSuites{}
+		return nil
+	}
+
+	byts, _ := json.Marshal(strings.Split(v, ","))
+	return d.UnmarshalJSON(byts)
+}
+
+// TLSOptions are the TLS options of a server.
+type TLSOptions struct {
+	MinVersion   TLSVersion      `json:"minVersion"`
+	CipherSuites TLSCipherSuites `json:"cipherSuites"`
+	ALPN         []string        `json:"alpn"`
+	OCSPStapling bool            `json:"ocspStapling"`
+}
+
+func (o *TLSOptions) setDefaults() {
+	o.MinVersion = tls.VersionTLS12
+	o.CipherSuites = TLSCipherSuites{}
+	o.ALPN = []string{}
+}
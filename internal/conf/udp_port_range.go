@@ -0,0 +1,76 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UDPPortRange is a range of UDP ports, in the format "min-max".
+// The zero value means that ports are chosen by the system.
+type UDPPortRange struct {
+	Min int
+	Max int
+}
+
+// IsEmpty returns whether the range is not set.
+func (d UDPPortRange) IsEmpty() bool {
+	return d.Min == 0 && d.Max == 0
+}
+
+// Size returns the number of ports in the range.
+func (d UDPPortRange) Size() int {
+	if d.IsEmpty() {
+		return 0
+	}
+	return d.Max - d.Min + 1
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d UDPPortRange) MarshalJSON() ([]byte, error) {
+	if d.IsEmpty() {
+		return json.Marshal("")
+	}
+	return json.Marshal(strconv.FormatInt(int64(d.Min), 10) + "-" + strconv.FormatInt(int64(d.Max), 10))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *UDPPortRange) UnmarshalJSON(b []byte) error {
+	var in string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	if in == "" {
+		*d = UDPPortRange{}
+		return nil
+	}
+
+	parts := strings.Split(in, "-")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid UDP port range: '%s'", in)
+	}
+
+	minPort, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil || minPort == 0 {
+		return fmt.Errorf("invalid UDP port range: '%s'", in)
+	}
+
+	maxPort, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil || maxPort < minPort {
+		return fmt.Errorf("invalid UDP port range: '%s'", in)
+	}
+
+	*d = UDPPortRange{
+		Min: int(minPort),
+		Max: int(maxPort),
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *UDPPortRange) UnmarshalEnv(_ string, v string) error {
+	return d.UnmarshalJSON([]byte(`"` + v + `"`))
+}
@@ -0,0 +1,98 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// UserAgentRuleAction is the action of a user agent rule.
+type UserAgentRuleAction string
+
+// actions.
+const (
+	UserAgentRuleActionAllow UserAgentRuleAction = "allow"
+	UserAgentRuleActionDeny  UserAgentRuleAction = "deny"
+	UserAgentRuleActionRoute UserAgentRuleAction = "route"
+)
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *UserAgentRuleAction) UnmarshalJSON(b []byte) error {
+	var in string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	switch UserAgentRuleAction(in) {
+	case UserAgentRuleActionAllow, UserAgentRuleActionDeny, UserAgentRuleActionRoute:
+		*d = UserAgentRuleAction(in)
+
+	default:
+		return fmt.Errorf("invalid user agent rule action: '%s'", in)
+	}
+
+	return nil
+}
+
+// UserAgentRule is a rule that matches the user agent of clients.
+type UserAgentRule struct {
+	Match  string              `json:"match"`
+	Action UserAgentRuleAction `json:"action"`
+	Path   string              `json:"path"`
+}
+
+// Matches checks whether a user agent matches the rule.
+func (r UserAgentRule) Matches(userAgent string) bool {
+	m, _ := regexp.MatchString(r.Match, userAgent)
+	return m
+}
+
+func (r UserAgentRule) validate() error {
+	if r.Match == "" {
+		return fmt.Errorf("'match' of user agent rules can't be empty")
+	}
+
+	_, err := regexp.Compile(r.Match)
+	if err != nil {
+		return fmt.Errorf("invalid 'match' of user agent rule: %w", err)
+	}
+
+	switch r.Action {
+	case UserAgentRuleActionRoute:
+		err = isValidPathName(r.Path)
+		if err != nil {
+			return fmt.Errorf("invalid 'path' of user agent rule: %w", err)
+		}
+
+	case UserAgentRuleActionAllow, UserAgentRuleActionDeny:
+		if r.Path != "" {
+			return fmt.Errorf("'path' of user agent rules can be used only with action 'route'")
+		}
+
+	default:
+		return fmt.Errorf("'action' of user agent rules is missing")
+	}
+
+	return nil
+}
+
+// UserAgentRules is a list of UserAgentRule.
+type UserAgentRules []UserAgentRule
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *UserAgentRules) UnmarshalJSON(b []byte) error {
+	// remove default value before loading new value
+	// https://github.com/golang/go/issues/21092
+	*s = nil
+	return json.Unmarshal(b, (*[]UserAgentRule)(s))
+}
+
+// Find returns the first rule that matches a user agent, or nil.
+func (s UserAgentRules) Find(userAgent string) *UserAgentRule {
+	for i, r := range s {
+		if r.Matches(userAgent) {
+			return &s[i]
+		}
+	}
+	return nil
+}
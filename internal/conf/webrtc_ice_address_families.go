@@ -0,0 +1,84 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WebRTCICEAddressFamily is an address family used by WebRTC ICE candidates.
+type WebRTCICEAddressFamily int
+
+// address families.
+const (
+	WebRTCICEAddressFamilyIPv4 WebRTCICEAddressFamily = iota
+	WebRTCICEAddressFamilyIPv6
+)
+
+// WebRTCICEAddressFamilies is the webrtcICEAddressFamilies parameter.
+type WebRTCICEAddressFamilies []WebRTCICEAddressFamily
+
+// MarshalJSON implements json.Marshaler.
+func (d WebRTCICEAddressFamilies) MarshalJSON() ([]byte, error) {
+	out := make([]string, len(d))
+
+	for i, v := range d {
+		switch v {
+		case WebRTCICEAddressFamilyIPv4:
+			out[i] = "ipv4"
+
+		default:
+			out[i] = "ipv6"
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *WebRTCICEAddressFamilies) UnmarshalJSON(b []byte) error {
+	var in []string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	*d = nil
+
+	for _, f := range in {
+		var v WebRTCICEAddressFamily
+		switch f {
+		case "ipv4":
+			v = WebRTCICEAddressFamilyIPv4
+
+		case "ipv6":
+			v = WebRTCICEAddressFamilyIPv6
+
+		default:
+			return fmt.Errorf("invalid ICE address family: '%s'", f)
+		}
+
+		if d.Contains(v) {
+			return fmt.Errorf("ICE address family '%s' set twice", f)
+		}
+
+		*d = append(*d, v)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *WebRTCICEAddressFamilies) UnmarshalEnv(_ string, v string) error {
+	byts, _ := json.Marshal(strings.Split(v, ","))
+	return d.UnmarshalJSON(byts)
+}
+
+// Contains checks whether an address family is in the list.
+func (d WebRTCICEAddressFamilies) Contains(v WebRTCICEAddressFamily) bool {
+	for _, item := range d {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// WebRTCICECandidateTypes is the webrtcICECandidateTypes parameter.
+type WebRTCICECandidateTypes []webrtc.ICECandidateType
+
+// MarshalJSON implements json.Marshaler.
+func (d WebRTCICECandidateTypes) MarshalJSON() ([]byte, error) {
+	out := make([]string, len(d))
+
+	for i, v := range d {
+		out[i] = v.String()
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *WebRTCICECandidateTypes) UnmarshalJSON(b []byte) error {
+	var in []string
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+
+	*d = nil
+
+	for _, t := range in {
+		var v webrtc.ICECandidateType
+		switch t {
+		case "host":
+			v = webrtc.ICECandidateTypeHost
+
+		case "srflx":
+			v = webrtc.ICECandidateTypeSrflx
+
+		case "relay":
+			v = webrtc.ICECandidateTypeRelay
+
+		default:
+			return fmt.Errorf("invalid ICE candidate type: '%s'", t)
+		}
+
+		if len(*d) != 0 && d.Contains(v) {
+			return fmt.Errorf("ICE candidate type '%s' set twice", t)
+		}
+
+		*d = append(*d, v)
+	}
+
+	return nil
+}
+
+// UnmarshalEnv implements env.Unmarshaler.
+func (d *WebRTCICECandidateTypes) UnmarshalEnv(_ string, v string) error {
+	byts, _ := json.Marshal(strings.Split(v, ","))
+	return d.UnmarshalJSON(byts)
+}
+
+// Contains checks whether a candidate type is in the list.
+// An empty list contains every candidate type.
+func (d WebRTCICECandidateTypes) Contains(v webrtc.ICECandidateType) bool {
+	if len(d) == 0 {
+		return true
+	}
+
+	for _, item := range d {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
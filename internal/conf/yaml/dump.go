@@ -0,0 +1,25 @@
+package yaml
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Dump dumps the configuration into Yaml.
+func Dump(src interface{}) ([]byte, error) {
+	// convert the source into JSON, in order to use JSON tags and marshalers
+	buf, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// load JSON into an ordered map, in order to preserve field order
+	var temp yaml.MapSlice
+	err = yaml.Unmarshal(buf, &temp)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(temp)
+}
@@ -0,0 +1,481 @@
+// Package conformance contains an analyzer that checks whether a stream
+// is suitable for being read with HLS and WebRTC.
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/av1"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/pkg/codecs/opus"
+	"github.com/bluenviron/mediacommon/pkg/codecs/vp9"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// keyframe intervals longer than this increase the duration of HLS segments
+	// and the time needed by WebRTC readers to start decoding.
+	longKeyframeInterval = 4 * time.Second
+
+	// timestamp jitter higher than this causes stuttering.
+	highJitter = 30 * time.Millisecond
+)
+
+// TrackReport is the report of a track.
+type TrackReport struct {
+	Type  string
+	Codec string
+	Units int
+
+	// video only
+	Keyframes           *int
+	MinKeyframeInterval *time.Duration
+	MaxKeyframeInterval *time.Duration
+	BFrames             *bool
+	ParameterChanges    *int
+
+	// audio only
+	AudioGaps   *int
+	MaxAudioGap *time.Duration
+
+	TimestampJitter time.Duration
+	Issues          []string
+}
+
+// Report is the conformance report of a stream.
+type Report struct {
+	Duration time.Duration
+	Tracks   []TrackReport
+}
+
+type track struct {
+	typ   string
+	codec string
+
+	mutex sync.Mutex
+	units int
+
+	keyframes           int
+	hasKeyframe         bool
+	lastKeyframe        time.Duration
+	minKeyframeInterval time.Duration
+	maxKeyframeInterval time.Duration
+	hasPrevPTS          bool
+	prevPTS             time.Duration
+	bFrames             bool
+	params              []byte
+	paramChanges        int
+
+	hasNextAudioPTS bool
+	nextAudioPTS    time.Duration
+	audioGaps       int
+	maxAudioGap     time.Duration
+
+	hasJitterRef bool
+	jitterRefNTP time.Time
+	jitterRefPTS time.Duration
+	jitter       float64
+}
+
+func (t *track) processTimestamp(u unit.Unit) {
+	t.units++
+
+	pts := u.GetPTS()
+	ntp := u.GetNTP()
+
+	// frames with a PTS lower than the previous one are reordered frames,
+	// whose receive time is not related to their PTS.
+	if t.hasJitterRef && pts <= t.jitterRefPTS {
+		return
+	}
+
+	// interarrival jitter, computed as in RFC3550
+	if t.hasJitterRef {
+		d := ntp.Sub(t.jitterRefNTP) - (pts - t.jitterRefPTS)
+		if d < 0 {
+			d = -d
+		}
+		t.jitter += (float64(d) - t.jitter) / 16
+	}
+
+	t.hasJitterRef = true
+	t.jitterRefNTP = ntp
+	t.jitterRefPTS = pts
+}
+
+func (t *track) processVideo(u unit.Unit, keyframe bool, params []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.processTimestamp(u)
+
+	pts := u.GetPTS()
+
+	if t.hasPrevPTS && pts < t.prevPTS {
+		t.bFrames = true
+	}
+	t.hasPrevPTS = true
+	t.prevPTS = pts
+
+	if params != nil {
+		if t.params != nil && !bytes.Equal(params, t.params) {
+			t.paramChanges++
+		}
+		t.params = params
+	}
+
+	if keyframe {
+		if t.hasKeyframe {
+			interval := pts - t.lastKeyframe
+			if t.keyframes == 1 || interval < t.minKeyframeInterval {
+				t.minKeyframeInterval = interval
+			}
+			if interval > t.maxKeyframeInterval {
+				t.maxKeyframeInterval = interval
+			}
+		}
+
+		t.keyframes++
+		t.hasKeyframe = true
+		t.lastKeyframe = pts
+	}
+}
+
+func (t *track) processAudio(u unit.Unit, duration time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.processTimestamp(u)
+
+	pts := u.GetPTS()
+
+	// a gap is present when at least an entire unit is missing
+	if t.hasNextAudioPTS {
+		gap := pts - t.nextAudioPTS
+		if duration > 0 && gap >= duration {
+			t.audioGaps++
+			if gap > t.maxAudioGap {
+				t.maxAudioGap = gap
+			}
+		}
+	}
+
+	t.hasNextAudioPTS = true
+	t.nextAudioPTS = pts + duration
+}
+
+func (t *track) processOther(u unit.Unit) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.processTimestamp(u)
+}
+
+func (t *track) report() TrackReport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	r := TrackReport{
+		Type:            t.typ,
+		Codec:           t.codec,
+		Units:           t.units,
+		TimestampJitter: time.Duration(t.jitter),
+		Issues:          []string{},
+	}
+
+	if t.units == 0 {
+		r.Issues = append(r.Issues, "no data has been received")
+	}
+
+	if t.typ == string(description.MediaTypeVideo) {
+		keyframes := t.keyframes
+		r.Keyframes = &keyframes
+		bFrames := t.bFrames
+		r.BFrames = &bFrames
+		paramChanges := t.paramChanges
+		r.ParameterChanges = &paramChanges
+
+		if t.keyframes >= 2 {
+			minInterval := t.minKeyframeInterval
+			r.MinKeyframeInterval = &minInterval
+			maxInterval := t.maxKeyframeInterval
+			r.MaxKeyframeInterval = &maxInterval
+		}
+
+		switch {
+		case t.units != 0 && t.keyframes == 0:
+			r.Issues = append(r.Issues, "no keyframes have been received, readers can't start decoding")
+
+		case t.keyframes >= 2 && t.maxKeyframeInterval > longKeyframeInterval:
+			r.Issues = append(r.Issues, fmt.Sprintf("keyframe interval (%v) is longer than %v, "+
+				"this increases the duration of HLS segments and the startup time of readers",
+				t.maxKeyframeInterval, longKeyframeInterval))
+		}
+
+		if t.bFrames {
+			r.Issues = append(r.Issues, "B-frames are in use, they are not supported by WebRTC")
+		}
+
+		if t.paramChanges != 0 {
+			r.Issues = append(r.Issues, fmt.Sprintf("parameters changed %d times, "+
+				"this forces HLS muxers to restart and might break decoding of WebRTC readers", t.paramChanges))
+		}
+	}
+
+	if t.typ == string(description.MediaTypeAudio) {
+		audioGaps := t.audioGaps
+		r.AudioGaps = &audioGaps
+
+		if t.audioGaps != 0 {
+			maxGap := t.maxAudioGap
+			r.MaxAudioGap = &maxGap
+
+			r.Issues = append(r.Issues, fmt.Sprintf("%d audio gaps have been detected (longest is %v), "+
+				"this causes audio and video to drift apart", t.audioGaps, t.maxAudioGap))
+		}
+	}
+
+	if r.TimestampJitter > highJitter {
+		r.Issues = append(r.Issues, fmt.Sprintf("timestamp jitter (%v) is higher than %v, "+
+			"this causes stuttering", r.TimestampJitter, highJitter))
+	}
+
+	return r
+}
+
+// Analyzer reads a stream and collects statistics
+// that allow to find out why playback of the stream misbehaves.
+type Analyzer struct {
+	WriteQueueSize int
+	Stream         *stream.Stream
+	Parent         logger.Writer
+
+	writer *asyncwriter.Writer
+	tracks []*track
+	start  time.Time
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Analyzer.
+func (a *Analyzer) Initialize() {
+	a.terminate = make(chan struct{})
+	a.done = make(chan struct{})
+
+	a.writer = asyncwriter.New(a.WriteQueueSize, a)
+
+	for _, media := range a.Stream.Desc().Medias {
+		for _, forma := range media.Formats {
+			a.setupFormat(media, forma)
+		}
+	}
+
+	a.start = time.Now()
+
+	go a.run()
+}
+
+// Close closes Analyzer.
+func (a *Analyzer) Close() {
+	close(a.terminate)
+	<-a.done
+}
+
+// Log implements logger.Writer.
+func (a *Analyzer) Log(level logger.Level, format string, args ...interface{}) {
+	a.Parent.Log(level, "[conformance] "+format, args...)
+}
+
+func (a *Analyzer) run() {
+	defer close(a.done)
+
+	a.writer.Start()
+
+	select {
+	case err := <-a.writer.Error():
+		a.Log(logger.Error, err.Error())
+		a.Stream.RemoveReader(a.writer)
+
+	case <-a.terminate:
+		a.Stream.RemoveReader(a.writer)
+		a.writer.Stop()
+	}
+}
+
+func (a *Analyzer) setupFormat(media *description.Media, forma format.Format) {
+	t := &track{
+		typ:   string(media.Type),
+		codec: forma.Codec(),
+	}
+	a.tracks = append(a.tracks, t)
+
+	switch forma := forma.(type) {
+	case *format.H264:
+		t.params, _ = forma.SafeParams()
+
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.H264)
+			if tunit.AU == nil {
+				return nil
+			}
+
+			var sps []byte
+			for _, nalu := range tunit.AU {
+				if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeSPS {
+					sps = nalu
+				}
+			}
+
+			t.processVideo(tunit, h264.IDRPresent(tunit.AU), sps)
+			return nil
+		})
+
+	case *format.H265:
+		_, t.params, _ = forma.SafeParams()
+
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.H265)
+			if tunit.AU == nil {
+				return nil
+			}
+
+			var sps []byte
+			for _, nalu := range tunit.AU {
+				if h265.NALUType((nalu[0]>>1)&0b111111) == h265.NALUType_SPS_NUT {
+					sps = nalu
+				}
+			}
+
+			t.processVideo(tunit, h265.IsRandomAccess(tunit.AU), sps)
+			return nil
+		})
+
+	case *format.AV1:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.AV1)
+			if tunit.TU == nil {
+				return nil
+			}
+
+			keyframe, err := av1.ContainsKeyFrame(tunit.TU)
+			if err != nil {
+				return nil
+			}
+
+			t.processVideo(tunit, keyframe, nil)
+			return nil
+		})
+
+	case *format.VP9:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.VP9)
+			if tunit.Frame == nil {
+				return nil
+			}
+
+			var h vp9.Header
+			err := h.Unmarshal(tunit.Frame)
+			if err != nil {
+				return nil
+			}
+
+			t.processVideo(tunit, !h.NonKeyFrame, nil)
+			return nil
+		})
+
+	case *format.VP8:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.VP8)
+			if len(tunit.Frame) == 0 {
+				return nil
+			}
+
+			t.processVideo(tunit, (tunit.Frame[0]&0x01) == 0, nil)
+			return nil
+		})
+
+	case *format.MPEG4Audio:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.MPEG4Audio)
+			if tunit.AUs == nil {
+				return nil
+			}
+
+			t.processAudio(tunit, time.Duration(len(tunit.AUs))*mpeg4audio.SamplesPerAccessUnit*
+				time.Second/time.Duration(forma.ClockRate()))
+			return nil
+		})
+
+	case *format.Opus:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.Opus)
+			if tunit.Packets == nil {
+				return nil
+			}
+
+			var duration time.Duration
+			for _, pkt := range tunit.Packets {
+				duration += opus.PacketDuration(pkt)
+			}
+
+			t.processAudio(tunit, duration)
+			return nil
+		})
+
+	case *format.G711:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.G711)
+			if tunit.Samples == nil {
+				return nil
+			}
+
+			t.processAudio(tunit, time.Duration(len(tunit.Samples)/forma.ChannelCount)*
+				time.Second/time.Duration(forma.SampleRate))
+			return nil
+		})
+
+	case *format.LPCM:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.LPCM)
+			if tunit.Samples == nil {
+				return nil
+			}
+
+			t.processAudio(tunit, time.Duration(len(tunit.Samples)/(forma.BitDepth/8*forma.ChannelCount))*
+				time.Second/time.Duration(forma.SampleRate))
+			return nil
+		})
+
+	default:
+		a.Stream.AddInternalReader(a.writer, media, forma, func(u unit.Unit) error {
+			t.processOther(u)
+			return nil
+		})
+	}
+}
+
+// Report returns the report of data collected until now.
+func (a *Analyzer) Report() *Report {
+	r := &Report{
+		Duration: time.Since(a.start),
+		Tracks:   make([]TrackReport, len(a.tracks)),
+	}
+
+	for i, t := range a.tracks {
+		r.Tracks[i] = t.report()
+	}
+
+	return r
+}
@@ -0,0 +1,144 @@
+package conformance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func durationPtr(v time.Duration) *time.Duration {
+	return &v
+}
+
+func TestAnalyzer(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				SPS:               test.FormatH264.SPS,
+				PPS:               test.FormatH264.PPS,
+				PacketizationMode: 1,
+			}},
+		},
+		{
+			Type:    description.MediaTypeAudio,
+			Formats: []format.Format{test.FormatMPEG4Audio},
+		},
+	}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	a := &Analyzer{
+		WriteQueueSize: 512,
+		Stream:         strm,
+		Parent:         test.NilLogger,
+	}
+	a.Initialize()
+	defer a.Close()
+
+	ntp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writeVideo := func(pts time.Duration, au [][]byte) {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				NTP: ntp.Add(pts),
+				PTS: pts,
+			},
+			AU: au,
+		})
+	}
+
+	writeAudio := func(pts time.Duration) {
+		strm.WriteUnit(desc.Medias[1], desc.Medias[1].Formats[0], &unit.MPEG4Audio{
+			Base: unit.Base{
+				NTP: ntp.Add(pts),
+				PTS: pts,
+			},
+			AUs: [][]byte{{1, 2, 3, 4}},
+		})
+	}
+
+	// keyframes at 0s, 2s and 7s, with a B-frame and a SPS change
+	writeVideo(0, [][]byte{{0x65, 0x01}})
+	writeVideo(200*time.Millisecond, [][]byte{{0x41, 0x01}})
+	writeVideo(100*time.Millisecond, [][]byte{{0x01, 0x01}})
+	writeVideo(2*time.Second, [][]byte{{0x65, 0x01}})
+	writeVideo(7*time.Second, [][]byte{
+		{
+			0x67, 0x42, 0xc0, 0x1f, 0xd9, 0x00, 0x78, 0x02,
+			0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+			0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9, 0x20,
+		},
+		{0x08, 0x06, 0x07, 0x08},
+		{0x65, 0x01},
+	})
+
+	// an access unit is missing after the second one
+	auDuration := 1024 * time.Second / 44100
+	writeAudio(0)
+	writeAudio(auDuration)
+	writeAudio(3 * auDuration)
+	writeAudio(4 * auDuration)
+
+	var r *Report
+
+	require.Eventually(t, func() bool {
+		r = a.Report()
+		return r.Tracks[0].Units == 5 && r.Tracks[1].Units == 4
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, TrackReport{
+		Type:                "video",
+		Codec:               "H264",
+		Units:               5,
+		Keyframes:           intPtr(3),
+		MinKeyframeInterval: durationPtr(2 * time.Second),
+		MaxKeyframeInterval: durationPtr(5 * time.Second),
+		BFrames:             boolPtr(true),
+		ParameterChanges:    intPtr(1),
+		Issues: []string{
+			"keyframe interval (5s) is longer than 4s, " +
+				"this increases the duration of HLS segments and the startup time of readers",
+			"B-frames are in use, they are not supported by WebRTC",
+			"parameters changed 1 times, " +
+				"this forces HLS muxers to restart and might break decoding of WebRTC readers",
+		},
+	}, r.Tracks[0])
+
+	require.Equal(t, TrackReport{
+		Type:        "audio",
+		Codec:       "MPEG-4 Audio",
+		Units:       4,
+		AudioGaps:   intPtr(1),
+		MaxAudioGap: durationPtr(auDuration),
+		Issues: []string{
+			"1 audio gaps have been detected (longest is 23.219954ms), " +
+				"this causes audio and video to drift apart",
+		},
+	}, r.Tracks[1])
+}
@@ -0,0 +1,196 @@
+// Package connlimiter contains a limiter of the rate of new connections.
+package connlimiter
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	cleanupPeriod = 1 * time.Minute
+)
+
+type ipEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type listenerEntry struct {
+	limiter   *rate.Limiter
+	ips       map[string]*ipEntry
+	throttled uint64
+}
+
+// Limiter limits the rate of new connections of each listener and of each IP.
+// It is shared between listeners, and each listener has its own limits and statistics.
+// Connections are rejected before any data is read from them,
+// in order to minimize resources spent on connection floods.
+type Limiter struct {
+	// maximum number of new connections per second of each listener. Zero means unlimited.
+	RateLimit int
+	// maximum number of new connections of each listener in a burst. Zero means RateLimit.
+	RateLimitBurst int
+	// maximum number of new connections per second of each IP. Zero means unlimited.
+	IPRateLimit int
+	// maximum number of new connections of each IP in a burst. Zero means IPRateLimit.
+	IPRateLimitBurst int
+
+	mutex       sync.Mutex
+	listeners   map[string]*listenerEntry
+	lastCleanup time.Time
+}
+
+func newRateLimiter(limit int, burst int) *rate.Limiter {
+	if burst == 0 {
+		burst = limit
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+// Enabled returns whether limits are set.
+func (l *Limiter) Enabled() bool {
+	return l != nil && (l.RateLimit != 0 || l.IPRateLimit != 0)
+}
+
+func (l *Limiter) listener(name string) *listenerEntry {
+	if l.listeners == nil {
+		l.listeners = make(map[string]*listenerEntry)
+	}
+
+	le, ok := l.listeners[name]
+	if !ok {
+		le = &listenerEntry{
+			ips: make(map[string]*ipEntry),
+		}
+		if l.RateLimit != 0 {
+			le.limiter = newRateLimiter(l.RateLimit, l.RateLimitBurst)
+		}
+		l.listeners[name] = le
+	}
+
+	return le
+}
+
+// Allow checks whether a new connection from an IP can be accepted by a listener.
+func (l *Limiter) Allow(listener string, ip net.IP) bool {
+	if !l.Enabled() {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastCleanup) >= cleanupPeriod {
+		l.lastCleanup = now
+
+		for _, le := range l.listeners {
+			for key, entry := range le.ips {
+				if now.Sub(entry.lastSeen) >= cleanupPeriod {
+					delete(le.ips, key)
+				}
+			}
+		}
+	}
+
+	le := l.listener(listener)
+
+	if l.IPRateLimit != 0 {
+		key := ip.String()
+
+		entry, ok := le.ips[key]
+		if !ok {
+			entry = &ipEntry{
+				limiter: newRateLimiter(l.IPRateLimit, l.IPRateLimitBurst),
+			}
+			le.ips[key] = entry
+		}
+
+		entry.lastSeen = now
+
+		if !entry.limiter.AllowN(now, 1) {
+			le.throttled++
+			return false
+		}
+	}
+
+	if le.limiter != nil && !le.limiter.AllowN(now, 1) {
+		le.throttled++
+		return false
+	}
+
+	return true
+}
+
+type wrappedListener struct {
+	net.Listener
+	limiter *Limiter
+	name    string
+}
+
+func (ln *wrappedListener) Accept() (net.Conn, error) {
+	for {
+		nconn, err := ln.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		var ip net.IP
+		if addr, ok := nconn.RemoteAddr().(*net.TCPAddr); ok {
+			ip = addr.IP
+		}
+
+		if ln.limiter.Allow(ln.name, ip) {
+			return nconn, nil
+		}
+
+		nconn.Close()
+	}
+}
+
+// Wrap wraps a listener in order to close connections that exceed limits.
+// Limits are applied to the address of the TCP connection,
+// therefore the listener must not be already wrapped by the PROXY protocol.
+func (l *Limiter) Wrap(ln net.Listener, listener string) net.Listener {
+	if !l.Enabled() {
+		return ln
+	}
+
+	return &wrappedListener{
+		Listener: ln,
+		limiter:  l,
+		name:     listener,
+	}
+}
+
+// Stats are statistics of a listener.
+type Stats struct {
+	Listener  string
+	Throttled uint64
+}
+
+// Stats returns statistics of all listeners, sorted by listener name.
+func (l *Limiter) Stats() []Stats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	ret := make([]Stats, 0, len(l.listeners))
+
+	for name, le := range l.listeners {
+		ret = append(ret, Stats{
+			Listener:  name,
+			Throttled: le.throttled,
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Listener < ret[j].Listener
+	})
+
+	return ret
+}
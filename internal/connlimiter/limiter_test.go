@@ -0,0 +1,96 @@
+package connlimiter
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterIP(t *testing.T) {
+	l := &Limiter{
+		IPRateLimit:      1,
+		IPRateLimitBurst: 2,
+	}
+
+	for i, ca := range []struct {
+		listener string
+		ip       string
+		allowed  bool
+	}{
+		{"rtsp", "1.2.3.4", true},
+		{"rtsp", "1.2.3.4", true},
+		{"rtsp", "1.2.3.4", false},
+		{"rtsp", "5.6.7.8", true},
+		{"rtmp", "1.2.3.4", true},
+	} {
+		require.Equal(t, ca.allowed, l.Allow(ca.listener, net.ParseIP(ca.ip)), i)
+	}
+
+	require.Equal(t, []Stats{
+		{
+			Listener:  "rtmp",
+			Throttled: 0,
+		},
+		{
+			Listener:  "rtsp",
+			Throttled: 1,
+		},
+	}, l.Stats())
+}
+
+func TestLimiterListener(t *testing.T) {
+	l := &Limiter{
+		RateLimit: 2,
+	}
+
+	require.True(t, l.Allow("srt", net.ParseIP("1.2.3.4")))
+	require.True(t, l.Allow("srt", net.ParseIP("5.6.7.8")))
+	require.False(t, l.Allow("srt", net.ParseIP("9.10.11.12")))
+	require.True(t, l.Allow("rtsp", net.ParseIP("9.10.11.12")))
+}
+
+func TestLimiterWrap(t *testing.T) {
+	l := &Limiter{
+		IPRateLimit: 1,
+	}
+
+	ln, err := net.Listen("tcp", "localhost:9123")
+	require.NoError(t, err)
+
+	ln = l.Wrap(ln, "rtsp")
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 2)
+
+	go func() {
+		for {
+			nconn, err2 := ln.Accept()
+			if err2 != nil {
+				return
+			}
+			accepted <- nconn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		nconn, err2 := net.Dial("tcp", "localhost:9123")
+		require.NoError(t, err2)
+		defer nconn.Close()
+	}
+
+	nconn := <-accepted
+	nconn.Close()
+
+	select {
+	case <-accepted:
+		t.Error("should not happen")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	require.Equal(t, []Stats{{
+		Listener:  "rtsp",
+		Throttled: 1,
+	}}, l.Stats())
+}
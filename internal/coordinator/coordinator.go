@@ -0,0 +1,315 @@
+// Package coordinator contains the coordinator, that shares the state of paths between instances.
+package coordinator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	keyPrefix     = "mediamtx:"
+	updatePeriod  = 2 * time.Second
+	entryTTL      = 3 * updatePeriod
+	clusterSource = "clusterSource"
+)
+
+// ErrPathNotPublished is returned when a path is not published by any other instance.
+var ErrPathNotPublished = errors.New("path is not published by any other instance")
+
+type coordinatorPathManager interface {
+	APIPathsList() (*defs.APIPathList, error)
+}
+
+type pathEntry struct {
+	Instance string `json:"instance"`
+	URL      string `json:"url"`
+}
+
+func pathKey(name string) string {
+	return keyPrefix + "path:" + name
+}
+
+func readersKey(instanceID string) string {
+	return keyPrefix + "readers:" + instanceID
+}
+
+// Coordinator shares the state of paths with other instances through a Redis server.
+// Each instance periodically publishes the paths it is publishing, together with its
+// reader counts, and entries expire automatically when an instance goes away.
+type Coordinator struct {
+	Address     string
+	InstanceID  string
+	InstanceURL string
+	Parent      logger.Writer
+
+	ctx         context.Context
+	ctxCancel   func()
+	client      *redis.Client
+	mutex       sync.RWMutex
+	pathManager coordinatorPathManager
+	published   map[string]struct{}
+
+	done chan struct{}
+}
+
+// Initialize initializes Coordinator.
+func (c *Coordinator) Initialize() error {
+	opts, err := redis.ParseURL(c.Address)
+	if err != nil {
+		return err
+	}
+
+	if c.InstanceID == "" {
+		c.InstanceID, err = os.Hostname()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.ctx, c.ctxCancel = context.WithCancel(context.Background())
+	c.client = redis.NewClient(opts)
+	c.published = make(map[string]struct{})
+	c.done = make(chan struct{})
+
+	go c.run()
+
+	c.Log(logger.Info, "sharing state as instance '%s' through %s", c.InstanceID, opts.Addr)
+
+	return nil
+}
+
+// Close closes Coordinator.
+func (c *Coordinator) Close() {
+	c.ctxCancel()
+	<-c.done
+	c.client.Close()
+}
+
+// Log implements logger.Writer.
+func (c *Coordinator) Log(level logger.Level, format string, args ...interface{}) {
+	c.Parent.Log(level, "[coordinator] "+format, args...)
+}
+
+// SetPathManager is called by core.
+func (c *Coordinator) SetPathManager(pm coordinatorPathManager) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.pathManager = pm
+}
+
+func (c *Coordinator) run() {
+	defer close(c.done)
+
+	t := time.NewTicker(updatePeriod)
+	defer t.Stop()
+
+	failing := false
+
+	for {
+		select {
+		case <-t.C:
+			err := c.update()
+			if err != nil {
+				if !failing {
+					c.Log(logger.Error, "unable to update state: %v", err)
+					failing = true
+				}
+			} else if failing {
+				c.Log(logger.Info, "state updated successfully")
+				failing = false
+			}
+
+		case <-c.ctx.Done():
+			c.clear()
+			return
+		}
+	}
+}
+
+func (c *Coordinator) update() error {
+	c.mutex.RLock()
+	pm := c.pathManager
+	c.mutex.RUnlock()
+
+	if pm == nil {
+		return nil
+	}
+
+	data, err := pm.APIPathsList()
+	if err != nil {
+		return err
+	}
+
+	ctx, ctxCancel := context.WithTimeout(c.ctx, updatePeriod)
+	defer ctxCancel()
+
+	pipe := c.client.Pipeline()
+	published := make(map[string]struct{})
+	readers := make(map[string]interface{})
+
+	for _, item := range data.Items {
+		if len(item.Readers) != 0 {
+			readers[item.Name] = len(item.Readers)
+		}
+
+		// do not publish paths that are read from other instances, in order to avoid loops
+		if !item.Ready || item.Source == nil || item.Source.Type == clusterSource {
+			continue
+		}
+
+		buf, _ := json.Marshal(pathEntry{
+			Instance: c.InstanceID,
+			URL:      strings.TrimSuffix(c.InstanceURL, "/") + "/" + item.Name,
+		})
+
+		pipe.Set(ctx, pathKey(item.Name), buf, entryTTL)
+		published[item.Name] = struct{}{}
+	}
+
+	for name := range c.published {
+		if _, ok := published[name]; !ok {
+			pipe.Del(ctx, pathKey(name))
+		}
+	}
+
+	pipe.Del(ctx, readersKey(c.InstanceID))
+	if len(readers) != 0 {
+		pipe.HSet(ctx, readersKey(c.InstanceID), readers)
+		pipe.Expire(ctx, readersKey(c.InstanceID), entryTTL)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.published = published
+	return nil
+}
+
+func (c *Coordinator) clear() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), updatePeriod)
+	defer ctxCancel()
+
+	keys := []string{readersKey(c.InstanceID)}
+	for name := range c.published {
+		keys = append(keys, pathKey(name))
+	}
+
+	c.client.Del(ctx, keys...)
+}
+
+func (c *Coordinator) getEntry(ctx context.Context, name string) (*pathEntry, error) {
+	buf, err := c.client.Get(ctx, pathKey(name)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrPathNotPublished
+		}
+		return nil, err
+	}
+
+	var entry pathEntry
+	err = json.Unmarshal(buf, &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Locate returns the URL through which a path published by another instance can be read.
+func (c *Coordinator) Locate(name string) (string, error) {
+	ctx, ctxCancel := context.WithTimeout(c.ctx, updatePeriod)
+	defer ctxCancel()
+
+	entry, err := c.getEntry(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if entry.Instance == c.InstanceID {
+		return "", ErrPathNotPublished
+	}
+
+	return entry.URL, nil
+}
+
+func (c *Coordinator) scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// APIClusterPathsList is called by api.
+func (c *Coordinator) APIClusterPathsList() (*defs.APIClusterPathList, error) {
+	ctx, ctxCancel := context.WithTimeout(c.ctx, updatePeriod)
+	defer ctxCancel()
+
+	keys, err := c.scan(ctx, readersKey("*"))
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make(map[string]int)
+
+	for _, key := range keys {
+		var vals map[string]string
+		vals, err = c.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for name, val := range vals {
+			n, _ := strconv.Atoi(val)
+			readers[name] += n
+		}
+	}
+
+	keys, err = c.scan(ctx, pathKey("*"))
+	if err != nil {
+		return nil, err
+	}
+
+	data := &defs.APIClusterPathList{
+		Items: []*defs.APIClusterPath{},
+	}
+
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, pathKey(""))
+
+		entry, err2 := c.getEntry(ctx, name)
+		if err2 != nil {
+			if errors.Is(err2, ErrPathNotPublished) {
+				continue
+			}
+			return nil, err2
+		}
+
+		data.Items = append(data.Items, &defs.APIClusterPath{
+			Name:     name,
+			Instance: entry.Instance,
+			Readers:  readers[name],
+		})
+	}
+
+	sort.Slice(data.Items, func(i, j int) bool {
+		return data.Items[i].Name < data.Items[j].Name
+	})
+
+	return data, nil
+}
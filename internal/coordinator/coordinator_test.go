@@ -0,0 +1,102 @@
+package coordinator
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+type dummyPathManager struct {
+	paths []*defs.APIPath
+}
+
+func (pm *dummyPathManager) APIPathsList() (*defs.APIPathList, error) {
+	return &defs.APIPathList{Items: pm.paths}, nil
+}
+
+func TestCoordinator(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	c1 := &Coordinator{
+		Address:     "redis://" + srv.Addr(),
+		InstanceID:  "instance1",
+		InstanceURL: "rtsp://host1:8554/",
+		Parent:      test.NilLogger,
+	}
+	err := c1.Initialize()
+	require.NoError(t, err)
+	defer func() {
+		if c1 != nil {
+			c1.Close()
+		}
+	}()
+
+	c1.SetPathManager(&dummyPathManager{
+		paths: []*defs.APIPath{
+			{
+				Name:    "mystream",
+				Ready:   true,
+				Source:  &defs.APIPathSourceOrReader{Type: "rtspSession"},
+				Readers: []defs.APIPathSourceOrReader{{Type: "hlsMuxer"}, {Type: "rtspSession"}},
+			},
+			{
+				Name: "notready",
+			},
+		},
+	})
+
+	c2 := &Coordinator{
+		Address:     "redis://" + srv.Addr(),
+		InstanceID:  "instance2",
+		InstanceURL: "rtsp://host2:8554",
+		Parent:      test.NilLogger,
+	}
+	err = c2.Initialize()
+	require.NoError(t, err)
+	defer c2.Close()
+
+	c2.SetPathManager(&dummyPathManager{
+		paths: []*defs.APIPath{
+			{
+				Name:    "mystream",
+				Ready:   true,
+				Source:  &defs.APIPathSourceOrReader{Type: "clusterSource"},
+				Readers: []defs.APIPathSourceOrReader{{Type: "webRTCSession"}},
+			},
+		},
+	})
+
+	err = c1.update()
+	require.NoError(t, err)
+
+	err = c2.update()
+	require.NoError(t, err)
+
+	u, err := c2.Locate("mystream")
+	require.NoError(t, err)
+	require.Equal(t, "rtsp://host1:8554/mystream", u)
+
+	_, err = c1.Locate("mystream")
+	require.Equal(t, ErrPathNotPublished, err)
+
+	_, err = c2.Locate("notready")
+	require.Equal(t, ErrPathNotPublished, err)
+
+	list, err := c2.APIClusterPathsList()
+	require.NoError(t, err)
+	require.Equal(t, []*defs.APIClusterPath{{
+		Name:     "mystream",
+		Instance: "instance1",
+		Readers:  3,
+	}}, list.Items)
+
+	c1.Close()
+	c1 = nil
+
+	_, err = c2.Locate("mystream")
+	require.Equal(t, ErrPathNotPublished, err)
+}
@@ -14,23 +14,38 @@ import (
 	"github.com/alecthomas/kong"
 	"github.com/bluenviron/gortsplib/v4"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/term"
 
 	"github.com/bluenviron/mediamtx/internal/api"
 	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/bandwidth"
+	"github.com/bluenviron/mediamtx/internal/benchmark"
+	"github.com/bluenviron/mediamtx/internal/clockmonitor"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/confwatcher"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
+	"github.com/bluenviron/mediamtx/internal/coordinator"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/failover"
+	"github.com/bluenviron/mediamtx/internal/grpcapi"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/metrics"
+	"github.com/bluenviron/mediamtx/internal/pathstate"
 	"github.com/bluenviron/mediamtx/internal/playback"
 	"github.com/bluenviron/mediamtx/internal/pprof"
+	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
+	"github.com/bluenviron/mediamtx/internal/publicip"
 	"github.com/bluenviron/mediamtx/internal/recordcleaner"
+	"github.com/bluenviron/mediamtx/internal/recordprocessor"
 	"github.com/bluenviron/mediamtx/internal/rlimit"
+	"github.com/bluenviron/mediamtx/internal/servers/dash"
 	"github.com/bluenviron/mediamtx/internal/servers/hls"
+	"github.com/bluenviron/mediamtx/internal/servers/ipc"
 	"github.com/bluenviron/mediamtx/internal/servers/rtmp"
 	"github.com/bluenviron/mediamtx/internal/servers/rtsp"
 	"github.com/bluenviron/mediamtx/internal/servers/srt"
 	"github.com/bluenviron/mediamtx/internal/servers/webrtc"
+	"github.com/bluenviron/mediamtx/internal/sourcecheck"
 )
 
 var version = "v0.0.0"
@@ -44,33 +59,65 @@ var defaultConfPaths = []string{
 }
 
 var cli struct {
-	Version  bool   `help:"print version"`
-	Confpath string `arg:"" default:""`
+	Version bool `help:"print version"`
+	Run     struct {
+		Confpath string `arg:"" default:""`
+	} `cmd:"" default:"withargs" help:"run the server"`
+	TestSource struct {
+		URL      string        `arg:"" help:"source URL"`
+		Duration time.Duration `default:"10s" help:"how long the source is read"`
+	} `cmd:"" help:"connect to a source, print its tracks and statistics, then exit"`
+	Benchmark struct {
+		Address    string        `arg:"" default:"rtsp://localhost:8554" help:"address of the RTSP server"`
+		Publishers int           `default:"1" help:"number of publishers"`
+		Readers    int           `default:"1" help:"number of readers, distributed among publishers"`
+		Codec      string        `default:"h264" enum:"h264,opus" help:"codec of the synthetic streams"`
+		Bitrate    int           `default:"1000" help:"bitrate of every publisher, in kbit/s"`
+		PacketSize int           `default:"1200" help:"size of RTP payloads"`
+		Duration   time.Duration `default:"10s" help:"duration of the benchmark"`
+	} `cmd:"" help:"spawn synthetic publishers and readers against a server, print latency and loss, then exit"`
+	Publish struct {
+		Path    string `arg:"" help:"path name, optionally followed by a query (for instance mypath?user=myuser&pass=mypass)"`
+		Address string `default:"mediamtx.sock" help:"path of the socket of the IPC server"`
+	} `cmd:"" help:"read a MPEG-TS or fMP4 stream from the standard input and publish it through the IPC server"`
 }
 
 // Core is an instance of MediaMTX.
 type Core struct {
-	ctx             context.Context
-	ctxCancel       func()
-	confPath        string
-	conf            *conf.Conf
-	logger          *logger.Logger
-	externalCmdPool *externalcmd.Pool
-	authManager     *auth.Manager
-	metrics         *metrics.Metrics
-	pprof           *pprof.PPROF
-	recordCleaner   *recordcleaner.Cleaner
-	playbackServer  *playback.Server
-	pathManager     *pathManager
-	rtspServer      *rtsp.Server
-	rtspsServer     *rtsp.Server
-	rtmpServer      *rtmp.Server
-	rtmpsServer     *rtmp.Server
-	hlsServer       *hls.Server
-	webRTCServer    *webrtc.Server
-	srtServer       *srt.Server
-	api             *api.API
-	confWatcher     *confwatcher.ConfWatcher
+	ctx              context.Context
+	ctxCancel        func()
+	confPath         string
+	conf             *conf.Conf
+	filePathNames    map[string]struct{}
+	logger           *logger.Logger
+	externalCmdPool  *externalcmd.Pool
+	authManager      *auth.Manager
+	requestLimiter   *httpp.RequestLimiter
+	connLimiter      *connlimiter.Limiter
+	clockMonitor     *clockmonitor.Monitor
+	metrics          *metrics.Metrics
+	pprof            *pprof.PPROF
+	recordCleaner    *recordcleaner.Cleaner
+	publicIPDetector *publicip.Detector
+	pathStateStore   *pathstate.Store
+	bandwidth        *bandwidth.Accountant
+	recordProcessor  *recordprocessor.Processor
+	playbackServer   *playback.Server
+	coordinator      *coordinator.Coordinator
+	pathManager      *pathManager
+	rtspServer       *rtsp.Server
+	rtspsServer      *rtsp.Server
+	rtmpServer       *rtmp.Server
+	rtmpsServer      *rtmp.Server
+	hlsServer        *hls.Server
+	webRTCServer     *webrtc.Server
+	srtServer        *srt.Server
+	ipcServer        *ipc.Server
+	dashServer       *dash.Server
+	api              *api.API
+	grpcAPI          *grpcapi.GRPCAPI
+	confWatcher      *confwatcher.ConfWatcher
+	elector          *failover.Elector
 
 	// in
 	chAPIConfigSet chan *conf.Conf
@@ -97,7 +144,7 @@ func New(args []string) (*Core, bool) {
 		panic(err)
 	}
 
-	_, err = parser.Parse(args)
+	kctx, err := parser.Parse(args)
 	parser.FatalIfErrorf(err)
 
 	if cli.Version {
@@ -105,6 +152,32 @@ func New(args []string) (*Core, bool) {
 		os.Exit(0)
 	}
 
+	switch kctx.Command() {
+	case "test-source <url>":
+		err = testSource()
+		if err != nil {
+			fmt.Printf("ERR: %s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "benchmark", "benchmark <address>":
+		err = benchmarkRun()
+		if err != nil {
+			fmt.Printf("ERR: %s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "publish <path>":
+		err = publishRun()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERR: %s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	p := &Core{
@@ -114,12 +187,14 @@ func New(args []string) (*Core, bool) {
 		done:           make(chan struct{}),
 	}
 
-	p.conf, p.confPath, err = conf.Load(cli.Confpath, defaultConfPaths)
+	p.conf, p.confPath, err = conf.Load(cli.Run.Confpath, defaultConfPaths)
 	if err != nil {
 		fmt.Printf("ERR: %s\n", err)
 		return nil, false
 	}
 
+	p.filePathNames = pathNames(p.conf)
+
 	err = p.createResources(true)
 	if err != nil {
 		if p.logger != nil {
@@ -136,6 +211,63 @@ func New(args []string) (*Core, bool) {
 	return p, true
 }
 
+func testSource() error {
+	cnf, _, err := conf.Load("", nil)
+	if err != nil {
+		return err
+	}
+
+	l, err := logger.New(logger.Level(cnf.LogLevel), []logger.Destination{logger.DestinationStdout}, "")
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	c := &sourcecheck.Checker{
+		Conf:              cnf,
+		Source:            cli.TestSource.URL,
+		Duration:          cli.TestSource.Duration,
+		Out:               os.Stdout,
+		Parent:            l,
+		UDPMaxPayloadSize: cnf.UDPMaxPayloadSize,
+	}
+	return c.Run()
+}
+
+func benchmarkRun() error {
+	l, err := logger.New(logger.Info, []logger.Destination{logger.DestinationStdout}, "")
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	b := &benchmark.Benchmark{
+		Address:    cli.Benchmark.Address,
+		Publishers: cli.Benchmark.Publishers,
+		Readers:    cli.Benchmark.Readers,
+		Codec:      cli.Benchmark.Codec,
+		Bitrate:    cli.Benchmark.Bitrate,
+		PacketSize: cli.Benchmark.PacketSize,
+		Duration:   cli.Benchmark.Duration,
+		Out:        os.Stdout,
+		Parent:     l,
+	}
+	return b.Run()
+}
+
+func publishRun() error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("standard input is a terminal, pipe a MPEG-TS or fMP4 stream into this command")
+	}
+
+	c := &ipc.Client{
+		Address: cli.Publish.Address,
+		Path:    cli.Publish.Path,
+		Source:  os.Stdin,
+	}
+	return c.Run()
+}
+
 // Close closes Core and waits for all goroutines to return.
 func (p *Core) Close() {
 	p.ctxCancel()
@@ -177,12 +309,16 @@ outer:
 				break outer
 			}
 
+			p.filePathNames = pathNames(newConf)
+
 			err = p.reloadConf(newConf, false)
 			if err != nil {
 				p.Log(logger.Error, "%s", err)
 				break outer
 			}
 
+			p.savePathState()
+
 		case newConf := <-p.chAPIConfigSet:
 			p.Log(logger.Info, "reloading configuration (API request)")
 
@@ -192,6 +328,19 @@ outer:
 				break outer
 			}
 
+			p.savePathState()
+
+		case <-p.failoverActivated():
+			err := p.createResources(false)
+			if err != nil {
+				p.Log(logger.Error, "%s", err)
+				break outer
+			}
+
+		case <-p.failoverLost():
+			p.Log(logger.Error, "this instance is not the active one anymore, shutting down")
+			break outer
+
 		case <-interrupt:
 			p.Log(logger.Info, "shutting down gracefully")
 			break outer
@@ -206,6 +355,20 @@ outer:
 	p.closeResources(nil, false)
 }
 
+func (p *Core) failoverActivated() chan struct{} {
+	if p.elector != nil {
+		return p.elector.Activated()
+	}
+	return nil
+}
+
+func (p *Core) failoverLost() chan struct{} {
+	if p.elector != nil {
+		return p.elector.Lost()
+	}
+	return nil
+}
+
 func (p *Core) createResources(initial bool) error {
 	var err error
 
@@ -247,31 +410,111 @@ func (p *Core) createResources(initial bool) error {
 		p.externalCmdPool = externalcmd.NewPool()
 	}
 
+	if p.conf.PathStateFile != "" &&
+		p.pathStateStore == nil {
+		i := &pathstate.Store{
+			FilePath: p.conf.PathStateFile,
+			Parent:   p,
+		}
+		err = i.Initialize()
+		if err != nil {
+			return fmt.Errorf("unable to load path state: %w", err)
+		}
+		p.pathStateStore = i
+
+		if initial {
+			p.restorePaths()
+		}
+	}
+
 	if p.authManager == nil {
 		p.authManager = &auth.Manager{
-			Method:          p.conf.AuthMethod,
-			InternalUsers:   p.conf.AuthInternalUsers,
-			HTTPAddress:     p.conf.AuthHTTPAddress,
-			HTTPExclude:     p.conf.AuthHTTPExclude,
-			JWTJWKS:         p.conf.AuthJWTJWKS,
-			JWTClaimKey:     p.conf.AuthJWTClaimKey,
-			ReadTimeout:     time.Duration(p.conf.ReadTimeout),
-			RTSPAuthMethods: p.conf.RTSPAuthMethods,
+			Method:              p.conf.AuthMethod,
+			InternalUsers:       p.conf.AuthInternalUsers,
+			HTTPAddress:         p.conf.AuthHTTPAddress,
+			HTTPExclude:         p.conf.AuthHTTPExclude,
+			JWTJWKS:             p.conf.AuthJWTJWKS,
+			JWTClaimKey:         p.conf.AuthJWTClaimKey,
+			ReadTimeout:         time.Duration(p.conf.ReadTimeout),
+			RTSPAuthMethods:     p.conf.RTSPAuthMethods,
+			SignedURLSecret:     p.conf.AuthSignedURLSecret,
+			Policy:              p.conf.AuthPolicy,
+			InterfacePolicies:   p.conf.AuthInterfacePolicies,
+			PlaybackRequireRead: p.conf.AuthPlaybackRequireRead,
+		}
+		err = p.authManager.Initialize()
+		if err != nil {
+			return err
+		}
+	}
+
+	if (p.conf.HTTPRateLimit != 0 || p.conf.HTTPMaxBodySize != 0) &&
+		p.requestLimiter == nil {
+		p.requestLimiter = &httpp.RequestLimiter{
+			RateLimit:      p.conf.HTTPRateLimit,
+			RateLimitBurst: p.conf.HTTPRateLimitBurst,
+			MaxBodySize:    int64(p.conf.HTTPMaxBodySize),
+		}
+	}
+
+	if (p.conf.ConnRateLimit != 0 || p.conf.ConnIPRateLimit != 0) &&
+		p.connLimiter == nil {
+		p.connLimiter = &connlimiter.Limiter{
+			RateLimit:        p.conf.ConnRateLimit,
+			RateLimitBurst:   p.conf.ConnRateLimitBurst,
+			IPRateLimit:      p.conf.ConnIPRateLimit,
+			IPRateLimitBurst: p.conf.ConnIPRateLimitBurst,
+		}
+	}
+
+	if p.clockMonitor == nil {
+		p.clockMonitor = &clockmonitor.Monitor{
+			Parent: p,
+		}
+		p.clockMonitor.Initialize()
+	}
+
+	if initial && p.confPath != "" {
+		p.confWatcher, err = confwatcher.New(p.confPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.conf.Failover &&
+		p.elector == nil {
+		i := &failover.Elector{
+			Lock:    p.conf.FailoverLock,
+			Timeout: p.conf.FailoverTimeout,
+			Parent:  p,
+		}
+		err = i.Initialize()
+		if err != nil {
+			return err
 		}
+		p.elector = i
+	}
+
+	// in standby, do not open any listener and do not pull any stream
+	if p.elector != nil && !p.elector.IsActive() {
+		return nil
 	}
 
 	if p.conf.Metrics &&
 		p.metrics == nil {
 		i := &metrics.Metrics{
-			Address:        p.conf.MetricsAddress,
-			Encryption:     p.conf.MetricsEncryption,
-			ServerKey:      p.conf.MetricsServerKey,
-			ServerCert:     p.conf.MetricsServerCert,
-			AllowOrigin:    p.conf.MetricsAllowOrigin,
-			TrustedProxies: p.conf.MetricsTrustedProxies,
-			ReadTimeout:    p.conf.ReadTimeout,
-			AuthManager:    p.authManager,
-			Parent:         p,
+			Address:         p.conf.MetricsAddress,
+			Encryption:      p.conf.MetricsEncryption,
+			ServerKey:       p.conf.MetricsServerKey,
+			ServerCert:      p.conf.MetricsServerCert,
+			TLSOptions:      p.conf.MetricsTLSOptions,
+			ServerHeader:    p.conf.ServerHeader,
+			ResponseHeaders: p.conf.HTTPResponseHeaders,
+			AllowOrigin:     p.conf.MetricsAllowOrigin,
+			TrustedProxies:  p.conf.MetricsTrustedProxies,
+			ReadTimeout:     p.conf.ReadTimeout,
+			AuthManager:     p.authManager,
+			Parent:          p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -280,18 +523,27 @@ func (p *Core) createResources(initial bool) error {
 		p.metrics = i
 	}
 
+	if p.metrics != nil {
+		p.metrics.SetRequestLimiter(p.requestLimiter)
+		p.metrics.SetConnLimiter(p.connLimiter)
+		p.metrics.SetClockMonitor(p.clockMonitor)
+	}
+
 	if p.conf.PPROF &&
 		p.pprof == nil {
 		i := &pprof.PPROF{
-			Address:        p.conf.PPROFAddress,
-			Encryption:     p.conf.PPROFEncryption,
-			ServerKey:      p.conf.PPROFServerKey,
-			ServerCert:     p.conf.PPROFServerCert,
-			AllowOrigin:    p.conf.PPROFAllowOrigin,
-			TrustedProxies: p.conf.PPROFTrustedProxies,
-			ReadTimeout:    p.conf.ReadTimeout,
-			AuthManager:    p.authManager,
-			Parent:         p,
+			Address:         p.conf.PPROFAddress,
+			Encryption:      p.conf.PPROFEncryption,
+			ServerKey:       p.conf.PPROFServerKey,
+			ServerCert:      p.conf.PPROFServerCert,
+			TLSOptions:      p.conf.PPROFTLSOptions,
+			ServerHeader:    p.conf.ServerHeader,
+			ResponseHeaders: p.conf.HTTPResponseHeaders,
+			AllowOrigin:     p.conf.PPROFAllowOrigin,
+			TrustedProxies:  p.conf.PPROFTrustedProxies,
+			ReadTimeout:     p.conf.ReadTimeout,
+			AuthManager:     p.authManager,
+			Parent:          p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -300,10 +552,21 @@ func (p *Core) createResources(initial bool) error {
 		p.pprof = i
 	}
 
+	if p.conf.PublicIPSource != "" &&
+		p.publicIPDetector == nil {
+		p.publicIPDetector = &publicip.Detector{
+			Source:  p.conf.PublicIPSource,
+			Refresh: p.conf.PublicIPRefresh,
+			Parent:  p,
+		}
+		p.publicIPDetector.Initialize()
+	}
+
 	if p.recordCleaner == nil {
 		p.recordCleaner = &recordcleaner.Cleaner{
-			PathConfs: p.conf.Paths,
-			Parent:    p,
+			PathConfs:    p.conf.Paths,
+			ClockMonitor: p.clockMonitor,
+			Parent:       p,
 		}
 		p.recordCleaner.Initialize()
 	}
@@ -311,16 +574,20 @@ func (p *Core) createResources(initial bool) error {
 	if p.conf.Playback &&
 		p.playbackServer == nil {
 		i := &playback.Server{
-			Address:        p.conf.PlaybackAddress,
-			Encryption:     p.conf.PlaybackEncryption,
-			ServerKey:      p.conf.PlaybackServerKey,
-			ServerCert:     p.conf.PlaybackServerCert,
-			AllowOrigin:    p.conf.PlaybackAllowOrigin,
-			TrustedProxies: p.conf.PlaybackTrustedProxies,
-			ReadTimeout:    p.conf.ReadTimeout,
-			PathConfs:      p.conf.Paths,
-			AuthManager:    p.authManager,
-			Parent:         p,
+			Address:         p.conf.PlaybackAddress,
+			Encryption:      p.conf.PlaybackEncryption,
+			ServerKey:       p.conf.PlaybackServerKey,
+			ServerCert:      p.conf.PlaybackServerCert,
+			TLSOptions:      p.conf.PlaybackTLSOptions,
+			ServerHeader:    p.conf.ServerHeader,
+			ResponseHeaders: p.conf.HTTPResponseHeaders,
+			AllowOrigin:     p.conf.PlaybackAllowOrigin,
+			TrustedProxies:  p.conf.PlaybackTrustedProxies,
+			ReadTimeout:     p.conf.ReadTimeout,
+			RequestLimiter:  p.requestLimiter,
+			PathConfs:       p.conf.Paths,
+			AuthManager:     p.authManager,
+			Parent:          p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -329,24 +596,69 @@ func (p *Core) createResources(initial bool) error {
 		p.playbackServer = i
 	}
 
+	if p.conf.Coordination &&
+		p.coordinator == nil {
+		i := &coordinator.Coordinator{
+			Address:     p.conf.CoordinationAddress,
+			InstanceID:  p.conf.CoordinationInstanceID,
+			InstanceURL: p.conf.CoordinationInstanceURL,
+			Parent:      p,
+		}
+		err = i.Initialize()
+		if err != nil {
+			return err
+		}
+		p.coordinator = i
+	}
+
+	if p.bandwidth == nil {
+		p.bandwidth = &bandwidth.Accountant{
+			ResetPeriod: time.Duration(p.conf.BandwidthResetPeriod),
+		}
+		p.bandwidth.Initialize()
+	}
+
+	if p.metrics != nil {
+		p.metrics.SetBandwidthAccountant(p.bandwidth)
+	}
+
+	if p.recordProcessor == nil {
+		p.recordProcessor = &recordprocessor.Processor{
+			Concurrency:     p.conf.RecordPostProcessConcurrency,
+			ExternalCmdPool: p.externalCmdPool,
+			Parent:          p,
+		}
+		p.recordProcessor.Initialize()
+	}
+
 	if p.pathManager == nil {
 		p.pathManager = &pathManager{
-			logLevel:          p.conf.LogLevel,
-			authManager:       p.authManager,
-			rtspAddress:       p.conf.RTSPAddress,
-			readTimeout:       p.conf.ReadTimeout,
-			writeTimeout:      p.conf.WriteTimeout,
-			writeQueueSize:    p.conf.WriteQueueSize,
-			udpMaxPayloadSize: p.conf.UDPMaxPayloadSize,
-			pathConfs:         p.conf.Paths,
-			externalCmdPool:   p.externalCmdPool,
-			parent:            p,
+			logLevel:                     p.conf.LogLevel,
+			authManager:                  p.authManager,
+			rtspAddress:                  p.conf.RTSPAddress,
+			readTimeout:                  p.conf.ReadTimeout,
+			writeTimeout:                 p.conf.WriteTimeout,
+			writeQueueSize:               p.conf.WriteQueueSize,
+			udpMaxPayloadSize:            p.conf.UDPMaxPayloadSize,
+			staticSourceStartConcurrency: p.conf.StaticSourceStartConcurrency,
+			publishPathRewrites:          p.conf.PublishPathRewrites,
+			pathConfs:                    p.conf.Paths,
+			externalCmdPool:              p.externalCmdPool,
+			coordinator:                  p.coordinator,
+			pathStateStore:               p.pathStateStore,
+			bandwidth:                    p.bandwidth,
+			recordProcessor:              p.recordProcessor,
+			parent:                       p,
 		}
 		p.pathManager.initialize()
 
 		if p.metrics != nil {
 			p.metrics.SetPathManager(p.pathManager)
 		}
+
+		if p.coordinator != nil {
+			p.coordinator.SetPathManager(p.pathManager)
+		}
 	}
 
 	if p.conf.RTSP &&
@@ -357,29 +669,39 @@ func (p *Core) createResources(initial bool) error {
 		_, useMulticast := p.conf.Protocols[conf.Protocol(gortsplib.TransportUDPMulticast)]
 
 		i := &rtsp.Server{
-			Address:             p.conf.RTSPAddress,
-			AuthMethods:         p.conf.RTSPAuthMethods,
-			ReadTimeout:         p.conf.ReadTimeout,
-			WriteTimeout:        p.conf.WriteTimeout,
-			WriteQueueSize:      p.conf.WriteQueueSize,
-			UseUDP:              useUDP,
-			UseMulticast:        useMulticast,
-			RTPAddress:          p.conf.RTPAddress,
-			RTCPAddress:         p.conf.RTCPAddress,
-			MulticastIPRange:    p.conf.MulticastIPRange,
-			MulticastRTPPort:    p.conf.MulticastRTPPort,
-			MulticastRTCPPort:   p.conf.MulticastRTCPPort,
-			IsTLS:               false,
-			ServerCert:          "",
-			ServerKey:           "",
-			RTSPAddress:         p.conf.RTSPAddress,
-			Protocols:           p.conf.Protocols,
-			RunOnConnect:        p.conf.RunOnConnect,
-			RunOnConnectRestart: p.conf.RunOnConnectRestart,
-			RunOnDisconnect:     p.conf.RunOnDisconnect,
-			ExternalCmdPool:     p.externalCmdPool,
-			PathManager:         p.pathManager,
-			Parent:              p,
+			Address:                  p.conf.RTSPAddress,
+			Listeners:                p.conf.RTSPListeners.WithEncryption(false),
+			AuthMethods:              p.conf.RTSPAuthMethods,
+			AuthNonceLifetime:        p.conf.RTSPAuthNonceLifetime,
+			AuthReplayProtection:     p.conf.RTSPAuthReplayProtection,
+			TrustedProxies:           p.conf.RTSPTrustedProxies,
+			ReadTimeout:              p.conf.ReadTimeout,
+			WriteTimeout:             p.conf.WriteTimeout,
+			WriteQueueSize:           p.conf.WriteQueueSize,
+			MaxPacketSize:            p.conf.RTSPMaxPacketSize,
+			DisableRTCPSenderReports: p.conf.RTSPDisableSenderReports,
+			UseUDP:                   useUDP,
+			UseMulticast:             useMulticast,
+			RTPAddress:               p.conf.RTPAddress,
+			RTCPAddress:              p.conf.RTCPAddress,
+			MulticastIPRange:         p.conf.MulticastIPRange,
+			MulticastRTPPort:         p.conf.MulticastRTPPort,
+			MulticastRTCPPort:        p.conf.MulticastRTCPPort,
+			MulticastSSM:             p.conf.MulticastSSM,
+			IsTLS:                    false,
+			ServerCert:               "",
+			ServerKey:                "",
+			TLSOptions:               conf.TLSOptions{},
+			ServerHeader:             p.conf.ServerHeader,
+			RTSPAddress:              p.conf.RTSPAddress,
+			Protocols:                p.conf.Protocols,
+			RunOnConnect:             p.conf.RunOnConnect,
+			RunOnConnectRestart:      p.conf.RunOnConnectRestart,
+			RunOnDisconnect:          p.conf.RunOnDisconnect,
+			ExternalCmdPool:          p.externalCmdPool,
+			ConnLimiter:              p.connLimiter,
+			PathManager:              p.pathManager,
+			Parent:                   p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -390,6 +712,14 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetRTSPServer(p.rtspServer)
 		}
+
+		if p.api != nil {
+			p.api.SetRTSPServer(p.rtspServer)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTSPServer(p.rtspServer)
+		}
 	}
 
 	if p.conf.RTSP &&
@@ -397,29 +727,39 @@ func (p *Core) createResources(initial bool) error {
 			p.conf.Encryption == conf.EncryptionOptional) &&
 		p.rtspsServer == nil {
 		i := &rtsp.Server{
-			Address:             p.conf.RTSPSAddress,
-			AuthMethods:         p.conf.RTSPAuthMethods,
-			ReadTimeout:         p.conf.ReadTimeout,
-			WriteTimeout:        p.conf.WriteTimeout,
-			WriteQueueSize:      p.conf.WriteQueueSize,
-			UseUDP:              false,
-			UseMulticast:        false,
-			RTPAddress:          "",
-			RTCPAddress:         "",
-			MulticastIPRange:    "",
-			MulticastRTPPort:    0,
-			MulticastRTCPPort:   0,
-			IsTLS:               true,
-			ServerCert:          p.conf.ServerCert,
-			ServerKey:           p.conf.ServerKey,
-			RTSPAddress:         p.conf.RTSPAddress,
-			Protocols:           p.conf.Protocols,
-			RunOnConnect:        p.conf.RunOnConnect,
-			RunOnConnectRestart: p.conf.RunOnConnectRestart,
-			RunOnDisconnect:     p.conf.RunOnDisconnect,
-			ExternalCmdPool:     p.externalCmdPool,
-			PathManager:         p.pathManager,
-			Parent:              p,
+			Address:                  p.conf.RTSPSAddress,
+			Listeners:                p.conf.RTSPListeners.WithEncryption(true),
+			AuthMethods:              p.conf.RTSPAuthMethods,
+			AuthNonceLifetime:        p.conf.RTSPAuthNonceLifetime,
+			AuthReplayProtection:     p.conf.RTSPAuthReplayProtection,
+			TrustedProxies:           p.conf.RTSPTrustedProxies,
+			ReadTimeout:              p.conf.ReadTimeout,
+			WriteTimeout:             p.conf.WriteTimeout,
+			WriteQueueSize:           p.conf.WriteQueueSize,
+			MaxPacketSize:            p.conf.RTSPMaxPacketSize,
+			DisableRTCPSenderReports: p.conf.RTSPDisableSenderReports,
+			UseUDP:                   false,
+			UseMulticast:             false,
+			RTPAddress:               "",
+			RTCPAddress:              "",
+			MulticastIPRange:         "",
+			MulticastRTPPort:         0,
+			MulticastRTCPPort:        0,
+			MulticastSSM:             false,
+			IsTLS:                    true,
+			ServerCert:               p.conf.ServerCert,
+			TLSOptions:               p.conf.RTSPTLSOptions,
+			ServerHeader:             p.conf.ServerHeader,
+			ServerKey:                p.conf.ServerKey,
+			RTSPAddress:              p.conf.RTSPAddress,
+			Protocols:                p.conf.Protocols,
+			RunOnConnect:             p.conf.RunOnConnect,
+			RunOnConnectRestart:      p.conf.RunOnConnectRestart,
+			RunOnDisconnect:          p.conf.RunOnDisconnect,
+			ExternalCmdPool:          p.externalCmdPool,
+			ConnLimiter:              p.connLimiter,
+			PathManager:              p.pathManager,
+			Parent:                   p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -430,6 +770,14 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetRTSPSServer(p.rtspsServer)
 		}
+
+		if p.api != nil {
+			p.api.SetRTSPSServer(p.rtspsServer)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTSPSServer(p.rtspsServer)
+		}
 	}
 
 	if p.conf.RTMP &&
@@ -438,17 +786,22 @@ func (p *Core) createResources(initial bool) error {
 		p.rtmpServer == nil {
 		i := &rtmp.Server{
 			Address:             p.conf.RTMPAddress,
+			Listeners:           p.conf.RTMPListeners.WithEncryption(false),
+			TrustedProxies:      p.conf.RTMPTrustedProxies,
+			PathRules:           p.conf.RTMPPathRules,
 			ReadTimeout:         p.conf.ReadTimeout,
 			WriteTimeout:        p.conf.WriteTimeout,
 			WriteQueueSize:      p.conf.WriteQueueSize,
 			IsTLS:               false,
 			ServerCert:          "",
 			ServerKey:           "",
+			TLSOptions:          conf.TLSOptions{},
 			RTSPAddress:         p.conf.RTSPAddress,
 			RunOnConnect:        p.conf.RunOnConnect,
 			RunOnConnectRestart: p.conf.RunOnConnectRestart,
 			RunOnDisconnect:     p.conf.RunOnDisconnect,
 			ExternalCmdPool:     p.externalCmdPool,
+			ConnLimiter:         p.connLimiter,
 			PathManager:         p.pathManager,
 			Parent:              p,
 		}
@@ -461,6 +814,14 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetRTMPServer(p.rtmpServer)
 		}
+
+		if p.api != nil {
+			p.api.SetRTMPServer(p.rtmpServer)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTMPServer(p.rtmpServer)
+		}
 	}
 
 	if p.conf.RTMP &&
@@ -469,17 +830,22 @@ func (p *Core) createResources(initial bool) error {
 		p.rtmpsServer == nil {
 		i := &rtmp.Server{
 			Address:             p.conf.RTMPSAddress,
+			Listeners:           p.conf.RTMPListeners.WithEncryption(true),
+			TrustedProxies:      p.conf.RTMPTrustedProxies,
+			PathRules:           p.conf.RTMPPathRules,
 			ReadTimeout:         p.conf.ReadTimeout,
 			WriteTimeout:        p.conf.WriteTimeout,
 			WriteQueueSize:      p.conf.WriteQueueSize,
 			IsTLS:               true,
 			ServerCert:          p.conf.RTMPServerCert,
+			TLSOptions:          p.conf.RTMPTLSOptions,
 			ServerKey:           p.conf.RTMPServerKey,
 			RTSPAddress:         p.conf.RTSPAddress,
 			RunOnConnect:        p.conf.RunOnConnect,
 			RunOnConnectRestart: p.conf.RunOnConnectRestart,
 			RunOnDisconnect:     p.conf.RunOnDisconnect,
 			ExternalCmdPool:     p.externalCmdPool,
+			ConnLimiter:         p.connLimiter,
 			PathManager:         p.pathManager,
 			Parent:              p,
 		}
@@ -492,29 +858,50 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetRTMPSServer(p.rtmpsServer)
 		}
+
+		if p.api != nil {
+			p.api.SetRTMPSServer(p.rtmpsServer)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTMPSServer(p.rtmpsServer)
+		}
 	}
 
 	if p.conf.HLS &&
 		p.hlsServer == nil {
 		i := &hls.Server{
-			Address:         p.conf.HLSAddress,
-			Encryption:      p.conf.HLSEncryption,
-			ServerKey:       p.conf.HLSServerKey,
-			ServerCert:      p.conf.HLSServerCert,
-			AllowOrigin:     p.conf.HLSAllowOrigin,
-			TrustedProxies:  p.conf.HLSTrustedProxies,
-			AlwaysRemux:     p.conf.HLSAlwaysRemux,
-			Variant:         p.conf.HLSVariant,
-			SegmentCount:    p.conf.HLSSegmentCount,
-			SegmentDuration: p.conf.HLSSegmentDuration,
-			PartDuration:    p.conf.HLSPartDuration,
-			SegmentMaxSize:  p.conf.HLSSegmentMaxSize,
-			Directory:       p.conf.HLSDirectory,
-			ReadTimeout:     p.conf.ReadTimeout,
-			WriteQueueSize:  p.conf.WriteQueueSize,
-			MuxerCloseAfter: p.conf.HLSMuxerCloseAfter,
-			PathManager:     p.pathManager,
-			Parent:          p,
+			Address:              p.conf.HLSAddress,
+			Listeners:            p.conf.HLSListeners,
+			Encryption:           p.conf.HLSEncryption,
+			ServerKey:            p.conf.HLSServerKey,
+			ServerCert:           p.conf.HLSServerCert,
+			TLSOptions:           p.conf.HLSTLSOptions,
+			ServerHeader:         p.conf.ServerHeader,
+			ResponseHeaders:      p.conf.HTTPResponseHeaders,
+			AllowOrigin:          p.conf.HLSAllowOrigin,
+			TrustedProxies:       p.conf.HLSTrustedProxies,
+			AlwaysRemux:          p.conf.HLSAlwaysRemux,
+			Variant:              p.conf.HLSVariant,
+			SegmentCount:         p.conf.HLSSegmentCount,
+			SegmentDuration:      p.conf.HLSSegmentDuration,
+			PartDuration:         p.conf.HLSPartDuration,
+			AdaptivePartDuration: p.conf.HLSAdaptivePartDuration,
+			PartMinDuration:      p.conf.HLSPartMinDuration,
+			PartMaxDuration:      p.conf.HLSPartMaxDuration,
+			SegmentMaxSize:       p.conf.HLSSegmentMaxSize,
+			AudioOnlyRendition:   p.conf.HLSAudioOnlyRendition,
+			VideoGapFilling:      p.conf.HLSVideoGapFilling,
+			VideoGapThreshold:    p.conf.HLSVideoGapThreshold,
+			Directory:            p.conf.HLSDirectory,
+			ReadTimeout:          p.conf.ReadTimeout,
+			WriteQueueSize:       p.conf.WriteQueueSize,
+			MuxerCloseAfter:      p.conf.HLSMuxerCloseAfter,
+			SessionSecret:        p.conf.HLSSessionSecret,
+			InstanceID:           p.conf.HLSInstanceID,
+			RequestLimiter:       p.requestLimiter,
+			PathManager:          p.pathManager,
+			Parent:               p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -527,30 +914,46 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetHLSServer(p.hlsServer)
 		}
+
+		if p.api != nil {
+			p.api.SetHLSServer(p.hlsServer)
+		}
 	}
 
 	if p.conf.WebRTC &&
 		p.webRTCServer == nil {
 		i := &webrtc.Server{
-			Address:               p.conf.WebRTCAddress,
-			Encryption:            p.conf.WebRTCEncryption,
-			ServerKey:             p.conf.WebRTCServerKey,
-			ServerCert:            p.conf.WebRTCServerCert,
-			AllowOrigin:           p.conf.WebRTCAllowOrigin,
-			TrustedProxies:        p.conf.WebRTCTrustedProxies,
-			ReadTimeout:           p.conf.ReadTimeout,
-			WriteQueueSize:        p.conf.WriteQueueSize,
-			LocalUDPAddress:       p.conf.WebRTCLocalUDPAddress,
-			LocalTCPAddress:       p.conf.WebRTCLocalTCPAddress,
-			IPsFromInterfaces:     p.conf.WebRTCIPsFromInterfaces,
-			IPsFromInterfacesList: p.conf.WebRTCIPsFromInterfacesList,
-			AdditionalHosts:       p.conf.WebRTCAdditionalHosts,
-			ICEServers:            p.conf.WebRTCICEServers2,
-			HandshakeTimeout:      p.conf.WebRTCHandshakeTimeout,
-			TrackGatherTimeout:    p.conf.WebRTCTrackGatherTimeout,
-			ExternalCmdPool:       p.externalCmdPool,
-			PathManager:           p.pathManager,
-			Parent:                p,
+			Address:                      p.conf.WebRTCAddress,
+			Listeners:                    p.conf.WebRTCListeners,
+			Encryption:                   p.conf.WebRTCEncryption,
+			ServerKey:                    p.conf.WebRTCServerKey,
+			ServerCert:                   p.conf.WebRTCServerCert,
+			TLSOptions:                   p.conf.WebRTCTLSOptions,
+			ServerHeader:                 p.conf.ServerHeader,
+			ResponseHeaders:              p.conf.HTTPResponseHeaders,
+			AllowOrigin:                  p.conf.WebRTCAllowOrigin,
+			TrustedProxies:               p.conf.WebRTCTrustedProxies,
+			ReadTimeout:                  p.conf.ReadTimeout,
+			WriteQueueSize:               p.conf.WriteQueueSize,
+			LocalUDPAddress:              p.conf.WebRTCLocalUDPAddress,
+			LocalTCPAddress:              p.conf.WebRTCLocalTCPAddress,
+			UDPPortRange:                 p.conf.WebRTCUDPPortRange,
+			IPsFromInterfaces:            p.conf.WebRTCIPsFromInterfaces,
+			IPsFromInterfacesList:        p.conf.WebRTCIPsFromInterfacesList,
+			IPsFromInterfacesExcludeList: p.conf.WebRTCIPsFromInterfacesExcludeList,
+			AdditionalHosts:              p.conf.WebRTCAdditionalHosts,
+			ICEAddressFamilies:           p.conf.WebRTCICEAddressFamilies,
+			ICECandidateTypes:            p.conf.WebRTCICECandidateTypes,
+			PublicIPDetector:             p.webRTCPublicIPDetector(),
+			ICEServers:                   p.conf.WebRTCICEServers2,
+			HandshakeTimeout:             p.conf.WebRTCHandshakeTimeout,
+			TrackGatherTimeout:           p.conf.WebRTCTrackGatherTimeout,
+			DTLSCertificateFile:          p.conf.WebRTCDTLSCertificateFile,
+			ExternalCmdPool:              p.externalCmdPool,
+			RequestLimiter:               p.requestLimiter,
+			ConnLimiter:                  p.connLimiter,
+			PathManager:                  p.pathManager,
+			Parent:                       p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -561,21 +964,34 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetWebRTCServer(p.webRTCServer)
 		}
+
+		if p.api != nil {
+			p.api.SetWebRTCServer(p.webRTCServer)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetWebRTCServer(p.webRTCServer)
+		}
 	}
 
 	if p.conf.SRT &&
 		p.srtServer == nil {
 		i := &srt.Server{
 			Address:             p.conf.SRTAddress,
+			Listeners:           p.conf.SRTListeners,
+			StreamIDRules:       p.conf.SRTStreamIDRules,
+			AllowedIPs:          p.conf.SRTAllowedIPs,
 			RTSPAddress:         p.conf.RTSPAddress,
 			ReadTimeout:         p.conf.ReadTimeout,
 			WriteTimeout:        p.conf.WriteTimeout,
 			WriteQueueSize:      p.conf.WriteQueueSize,
 			UDPMaxPayloadSize:   p.conf.UDPMaxPayloadSize,
+			MaxSendBuffer:       p.conf.SRTReadMaxSendBuffer,
 			RunOnConnect:        p.conf.RunOnConnect,
 			RunOnConnectRestart: p.conf.RunOnConnectRestart,
 			RunOnDisconnect:     p.conf.RunOnDisconnect,
 			ExternalCmdPool:     p.externalCmdPool,
+			ConnLimiter:         p.connLimiter,
 			PathManager:         p.pathManager,
 			Parent:              p,
 		}
@@ -588,29 +1004,91 @@ func (p *Core) createResources(initial bool) error {
 		if p.metrics != nil {
 			p.metrics.SetSRTServer(p.srtServer)
 		}
+
+		if p.api != nil {
+			p.api.SetSRTServer(p.srtServer)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetSRTServer(p.srtServer)
+		}
+	}
+
+	if p.conf.IPC &&
+		p.ipcServer == nil {
+		i := &ipc.Server{
+			Address:             p.conf.IPCAddress,
+			RTSPAddress:         p.conf.RTSPAddress,
+			ReadTimeout:         p.conf.ReadTimeout,
+			RunOnConnect:        p.conf.RunOnConnect,
+			RunOnConnectRestart: p.conf.RunOnConnectRestart,
+			RunOnDisconnect:     p.conf.RunOnDisconnect,
+			ExternalCmdPool:     p.externalCmdPool,
+			PathManager:         p.pathManager,
+			Parent:              p,
+		}
+		err = i.Initialize()
+		if err != nil {
+			return err
+		}
+		p.ipcServer = i
+	}
+
+	if p.conf.DASH &&
+		p.dashServer == nil {
+		i := &dash.Server{
+			Address:         p.conf.DASHAddress,
+			Encryption:      p.conf.DASHEncryption,
+			ServerKey:       p.conf.DASHServerKey,
+			ServerCert:      p.conf.DASHServerCert,
+			ServerHeader:    p.conf.ServerHeader,
+			ResponseHeaders: p.conf.HTTPResponseHeaders,
+			AllowOrigin:     p.conf.DASHAllowOrigin,
+			TrustedProxies:  p.conf.DASHTrustedProxies,
+			SegmentCount:    p.conf.DASHSegmentCount,
+			SegmentDuration: p.conf.DASHSegmentDuration,
+			ChunkDuration:   p.conf.DASHChunkDuration,
+			ReadTimeout:     p.conf.ReadTimeout,
+			WriteQueueSize:  p.conf.WriteQueueSize,
+			MuxerCloseAfter: p.conf.DASHMuxerCloseAfter,
+			PathManager:     p.pathManager,
+			Parent:          p,
+		}
+		err = i.Initialize()
+		if err != nil {
+			return err
+		}
+		p.dashServer = i
 	}
 
 	if p.conf.API &&
 		p.api == nil {
 		i := &api.API{
-			Address:        p.conf.APIAddress,
-			Encryption:     p.conf.APIEncryption,
-			ServerKey:      p.conf.APIServerKey,
-			ServerCert:     p.conf.APIServerCert,
-			AllowOrigin:    p.conf.APIAllowOrigin,
-			TrustedProxies: p.conf.APITrustedProxies,
-			ReadTimeout:    p.conf.ReadTimeout,
-			Conf:           p.conf,
-			AuthManager:    p.authManager,
-			PathManager:    p.pathManager,
-			RTSPServer:     p.rtspServer,
-			RTSPSServer:    p.rtspsServer,
-			RTMPServer:     p.rtmpServer,
-			RTMPSServer:    p.rtmpsServer,
-			HLSServer:      p.hlsServer,
-			WebRTCServer:   p.webRTCServer,
-			SRTServer:      p.srtServer,
-			Parent:         p,
+			Address:         p.conf.APIAddress,
+			Encryption:      p.conf.APIEncryption,
+			ServerKey:       p.conf.APIServerKey,
+			ServerCert:      p.conf.APIServerCert,
+			TLSOptions:      p.conf.APITLSOptions,
+			ServerHeader:    p.conf.ServerHeader,
+			ResponseHeaders: p.conf.HTTPResponseHeaders,
+			AllowOrigin:     p.conf.APIAllowOrigin,
+			TrustedProxies:  p.conf.APITrustedProxies,
+			Anonymize:       p.conf.APIAnonymize,
+			ReadTimeout:     p.conf.ReadTimeout,
+			RequestLimiter:  p.requestLimiter,
+			Conf:            p.conf,
+			AuthManager:     p.authManager,
+			PathManager:     p.pathManager,
+			Bandwidth:       p.bandwidth,
+			RTSPServer:      p.rtspServer,
+			RTSPSServer:     p.rtspsServer,
+			RTMPServer:      p.rtmpServer,
+			RTMPSServer:     p.rtmpsServer,
+			HLSServer:       p.hlsServer,
+			WebRTCServer:    p.webRTCServer,
+			SRTServer:       p.srtServer,
+			Coordinator:     p.coordinator,
+			Parent:          p,
 		}
 		err = i.Initialize()
 		if err != nil {
@@ -619,22 +1097,55 @@ func (p *Core) createResources(initial bool) error {
 		p.api = i
 	}
 
-	if initial && p.confPath != "" {
-		p.confWatcher, err = confwatcher.New(p.confPath)
+	if p.conf.GRPCAPI &&
+		p.grpcAPI == nil {
+		i := &grpcapi.GRPCAPI{
+			Address:      p.conf.GRPCAPIAddress,
+			Encryption:   p.conf.GRPCAPIEncryption,
+			ServerKey:    p.conf.GRPCAPIServerKey,
+			ServerCert:   p.conf.GRPCAPIServerCert,
+			TLSOptions:   p.conf.GRPCAPITLSOptions,
+			Conf:         p.conf,
+			AuthManager:  p.authManager,
+			PathManager:  p.pathManager,
+			RTSPServer:   p.rtspServer,
+			RTSPSServer:  p.rtspsServer,
+			RTMPServer:   p.rtmpServer,
+			RTMPSServer:  p.rtmpsServer,
+			WebRTCServer: p.webRTCServer,
+			SRTServer:    p.srtServer,
+			Parent:       p,
+		}
+		err = i.Initialize()
 		if err != nil {
 			return err
 		}
+		p.grpcAPI = i
 	}
 
 	return nil
 }
 
+// webRTCPublicIPDetector avoids passing a nil pointer inside a non-nil interface.
+func (p *Core) webRTCPublicIPDetector() interface{ IP() string } {
+	if p.publicIPDetector == nil {
+		return nil
+	}
+	return p.publicIPDetector
+}
+
 func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 	closeLogger := newConf == nil ||
 		newConf.LogLevel != p.conf.LogLevel ||
 		!reflect.DeepEqual(newConf.LogDestinations, p.conf.LogDestinations) ||
 		newConf.LogFile != p.conf.LogFile
 
+	closeElector := newConf == nil ||
+		newConf.Failover != p.conf.Failover ||
+		newConf.FailoverLock != p.conf.FailoverLock ||
+		newConf.FailoverTimeout != p.conf.FailoverTimeout ||
+		closeLogger
+
 	closeAuthManager := newConf == nil ||
 		newConf.AuthMethod != p.conf.AuthMethod ||
 		newConf.AuthHTTPAddress != p.conf.AuthHTTPAddress ||
@@ -642,21 +1153,40 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.AuthJWTJWKS != p.conf.AuthJWTJWKS ||
 		newConf.AuthJWTClaimKey != p.conf.AuthJWTClaimKey ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
-		!reflect.DeepEqual(newConf.RTSPAuthMethods, p.conf.RTSPAuthMethods)
+		!reflect.DeepEqual(newConf.RTSPAuthMethods, p.conf.RTSPAuthMethods) ||
+		newConf.AuthSignedURLSecret != p.conf.AuthSignedURLSecret ||
+		newConf.AuthPolicy != p.conf.AuthPolicy ||
+		!reflect.DeepEqual(newConf.AuthInterfacePolicies, p.conf.AuthInterfacePolicies) ||
+		newConf.AuthPlaybackRequireRead != p.conf.AuthPlaybackRequireRead
 	if !closeAuthManager && !reflect.DeepEqual(newConf.AuthInternalUsers, p.conf.AuthInternalUsers) {
 		p.authManager.ReloadInternalUsers(newConf.AuthInternalUsers)
 	}
 
+	closeRequestLimiter := newConf == nil ||
+		newConf.HTTPRateLimit != p.conf.HTTPRateLimit ||
+		newConf.HTTPRateLimitBurst != p.conf.HTTPRateLimitBurst ||
+		newConf.HTTPMaxBodySize != p.conf.HTTPMaxBodySize
+
+	closeConnLimiter := newConf == nil ||
+		newConf.ConnRateLimit != p.conf.ConnRateLimit ||
+		newConf.ConnRateLimitBurst != p.conf.ConnRateLimitBurst ||
+		newConf.ConnIPRateLimit != p.conf.ConnIPRateLimit ||
+		newConf.ConnIPRateLimitBurst != p.conf.ConnIPRateLimitBurst
+
 	closeMetrics := newConf == nil ||
 		newConf.Metrics != p.conf.Metrics ||
 		newConf.MetricsAddress != p.conf.MetricsAddress ||
 		newConf.MetricsEncryption != p.conf.MetricsEncryption ||
 		newConf.MetricsServerKey != p.conf.MetricsServerKey ||
 		newConf.MetricsServerCert != p.conf.MetricsServerCert ||
+		!reflect.DeepEqual(newConf.MetricsTLSOptions, p.conf.MetricsTLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
 		newConf.MetricsAllowOrigin != p.conf.MetricsAllowOrigin ||
 		!reflect.DeepEqual(newConf.MetricsTrustedProxies, p.conf.MetricsTrustedProxies) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		closeAuthManager ||
+		closeElector ||
 		closeLogger
 
 	closePPROF := newConf == nil ||
@@ -665,15 +1195,25 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.PPROFEncryption != p.conf.PPROFEncryption ||
 		newConf.PPROFServerKey != p.conf.PPROFServerKey ||
 		newConf.PPROFServerCert != p.conf.PPROFServerCert ||
+		!reflect.DeepEqual(newConf.PPROFTLSOptions, p.conf.PPROFTLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
 		newConf.PPROFAllowOrigin != p.conf.PPROFAllowOrigin ||
 		!reflect.DeepEqual(newConf.PPROFTrustedProxies, p.conf.PPROFTrustedProxies) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		closeAuthManager ||
+		closeElector ||
+		closeLogger
+
+	closePublicIPDetector := newConf == nil ||
+		newConf.PublicIPSource != p.conf.PublicIPSource ||
+		newConf.PublicIPRefresh != p.conf.PublicIPRefresh ||
 		closeLogger
 
 	closeRecorderCleaner := newConf == nil ||
+		closeElector ||
 		closeLogger
-	if !closeRecorderCleaner && !reflect.DeepEqual(newConf.Paths, p.conf.Paths) {
+	if !closeRecorderCleaner && p.recordCleaner != nil && !reflect.DeepEqual(newConf.Paths, p.conf.Paths) {
 		p.recordCleaner.ReloadPathConfs(newConf.Paths)
 	}
 
@@ -683,15 +1223,38 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.PlaybackEncryption != p.conf.PlaybackEncryption ||
 		newConf.PlaybackServerKey != p.conf.PlaybackServerKey ||
 		newConf.PlaybackServerCert != p.conf.PlaybackServerCert ||
+		!reflect.DeepEqual(newConf.PlaybackTLSOptions, p.conf.PlaybackTLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
 		newConf.PlaybackAllowOrigin != p.conf.PlaybackAllowOrigin ||
 		!reflect.DeepEqual(newConf.PlaybackTrustedProxies, p.conf.PlaybackTrustedProxies) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		closeAuthManager ||
+		closeRequestLimiter ||
+		closeElector ||
 		closeLogger
 	if !closePlaybackServer && p.playbackServer != nil && !reflect.DeepEqual(newConf.Paths, p.conf.Paths) {
 		p.playbackServer.ReloadPathConfs(newConf.Paths)
 	}
 
+	closeCoordinator := newConf == nil ||
+		newConf.Coordination != p.conf.Coordination ||
+		newConf.CoordinationAddress != p.conf.CoordinationAddress ||
+		newConf.CoordinationInstanceID != p.conf.CoordinationInstanceID ||
+		newConf.CoordinationInstanceURL != p.conf.CoordinationInstanceURL ||
+		closeElector ||
+		closeLogger
+
+	closePathStateStore := newConf == nil ||
+		newConf.PathStateFile != p.conf.PathStateFile
+
+	closeBandwidth := newConf == nil ||
+		newConf.BandwidthResetPeriod != p.conf.BandwidthResetPeriod
+
+	closeRecordProcessor := newConf == nil ||
+		newConf.RecordPostProcessConcurrency != p.conf.RecordPostProcessConcurrency ||
+		closeLogger
+
 	closePathManager := newConf == nil ||
 		newConf.LogLevel != p.conf.LogLevel ||
 		newConf.RTSPAddress != p.conf.RTSPAddress ||
@@ -700,27 +1263,46 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
 		newConf.UDPMaxPayloadSize != p.conf.UDPMaxPayloadSize ||
+		newConf.StaticSourceStartConcurrency != p.conf.StaticSourceStartConcurrency ||
+		!reflect.DeepEqual(newConf.PublishPathRewrites, p.conf.PublishPathRewrites) ||
 		closeMetrics ||
 		closeAuthManager ||
+		closeCoordinator ||
+		closePathStateStore ||
+		closeBandwidth ||
+		closeRecordProcessor ||
+		closeElector ||
 		closeLogger
-	if !closePathManager && !reflect.DeepEqual(newConf.Paths, p.conf.Paths) {
+	if !closePathManager && p.pathManager != nil && !reflect.DeepEqual(newConf.Paths, p.conf.Paths) {
 		p.pathManager.ReloadPathConfs(newConf.Paths)
 	}
+	if !closePathManager && p.pathManager != nil && newConf.AuthRotationGracePeriod != 0 &&
+		!reflect.DeepEqual(newConf.AuthInternalUsers, p.conf.AuthInternalUsers) {
+		p.pathManager.scheduleReauthentication(time.Duration(newConf.AuthRotationGracePeriod))
+	}
 
 	closeRTSPServer := newConf == nil ||
 		newConf.RTSP != p.conf.RTSP ||
 		newConf.Encryption != p.conf.Encryption ||
 		newConf.RTSPAddress != p.conf.RTSPAddress ||
+		!reflect.DeepEqual(newConf.RTSPListeners, p.conf.RTSPListeners) ||
 		!reflect.DeepEqual(newConf.RTSPAuthMethods, p.conf.RTSPAuthMethods) ||
+		newConf.RTSPAuthNonceLifetime != p.conf.RTSPAuthNonceLifetime ||
+		newConf.RTSPAuthReplayProtection != p.conf.RTSPAuthReplayProtection ||
+		!reflect.DeepEqual(newConf.RTSPTrustedProxies, p.conf.RTSPTrustedProxies) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
+		newConf.RTSPMaxPacketSize != p.conf.RTSPMaxPacketSize ||
+		newConf.RTSPDisableSenderReports != p.conf.RTSPDisableSenderReports ||
 		!reflect.DeepEqual(newConf.Protocols, p.conf.Protocols) ||
 		newConf.RTPAddress != p.conf.RTPAddress ||
 		newConf.RTCPAddress != p.conf.RTCPAddress ||
 		newConf.MulticastIPRange != p.conf.MulticastIPRange ||
 		newConf.MulticastRTPPort != p.conf.MulticastRTPPort ||
 		newConf.MulticastRTCPPort != p.conf.MulticastRTCPPort ||
+		newConf.MulticastSSM != p.conf.MulticastSSM ||
 		newConf.RTSPAddress != p.conf.RTSPAddress ||
 		!reflect.DeepEqual(newConf.Protocols, p.conf.Protocols) ||
 		newConf.RunOnConnect != p.conf.RunOnConnect ||
@@ -728,17 +1310,26 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.RunOnDisconnect != p.conf.RunOnDisconnect ||
 		closeMetrics ||
 		closePathManager ||
+		closeConnLimiter ||
 		closeLogger
 
 	closeRTSPSServer := newConf == nil ||
 		newConf.RTSP != p.conf.RTSP ||
 		newConf.Encryption != p.conf.Encryption ||
 		newConf.RTSPSAddress != p.conf.RTSPSAddress ||
+		!reflect.DeepEqual(newConf.RTSPListeners, p.conf.RTSPListeners) ||
 		!reflect.DeepEqual(newConf.RTSPAuthMethods, p.conf.RTSPAuthMethods) ||
+		newConf.RTSPAuthNonceLifetime != p.conf.RTSPAuthNonceLifetime ||
+		newConf.RTSPAuthReplayProtection != p.conf.RTSPAuthReplayProtection ||
+		!reflect.DeepEqual(newConf.RTSPTrustedProxies, p.conf.RTSPTrustedProxies) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
+		newConf.RTSPMaxPacketSize != p.conf.RTSPMaxPacketSize ||
+		newConf.RTSPDisableSenderReports != p.conf.RTSPDisableSenderReports ||
 		newConf.ServerCert != p.conf.ServerCert ||
+		!reflect.DeepEqual(newConf.RTSPTLSOptions, p.conf.RTSPTLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
 		newConf.ServerKey != p.conf.ServerKey ||
 		newConf.RTSPAddress != p.conf.RTSPAddress ||
 		!reflect.DeepEqual(newConf.Protocols, p.conf.Protocols) ||
@@ -747,12 +1338,16 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.RunOnDisconnect != p.conf.RunOnDisconnect ||
 		closeMetrics ||
 		closePathManager ||
+		closeConnLimiter ||
 		closeLogger
 
 	closeRTMPServer := newConf == nil ||
 		newConf.RTMP != p.conf.RTMP ||
 		newConf.RTMPEncryption != p.conf.RTMPEncryption ||
 		newConf.RTMPAddress != p.conf.RTMPAddress ||
+		!reflect.DeepEqual(newConf.RTMPListeners, p.conf.RTMPListeners) ||
+		!reflect.DeepEqual(newConf.RTMPTrustedProxies, p.conf.RTMPTrustedProxies) ||
+		!reflect.DeepEqual(newConf.RTMPPathRules, p.conf.RTMPPathRules) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
@@ -762,16 +1357,21 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.RunOnDisconnect != p.conf.RunOnDisconnect ||
 		closeMetrics ||
 		closePathManager ||
+		closeConnLimiter ||
 		closeLogger
 
 	closeRTMPSServer := newConf == nil ||
 		newConf.RTMP != p.conf.RTMP ||
 		newConf.RTMPEncryption != p.conf.RTMPEncryption ||
 		newConf.RTMPSAddress != p.conf.RTMPSAddress ||
+		!reflect.DeepEqual(newConf.RTMPListeners, p.conf.RTMPListeners) ||
+		!reflect.DeepEqual(newConf.RTMPTrustedProxies, p.conf.RTMPTrustedProxies) ||
+		!reflect.DeepEqual(newConf.RTMPPathRules, p.conf.RTMPPathRules) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
 		newConf.RTMPServerCert != p.conf.RTMPServerCert ||
+		!reflect.DeepEqual(newConf.RTMPTLSOptions, p.conf.RTMPTLSOptions) ||
 		newConf.RTMPServerKey != p.conf.RTMPServerKey ||
 		newConf.RTSPAddress != p.conf.RTSPAddress ||
 		newConf.RunOnConnect != p.conf.RunOnConnect ||
@@ -779,14 +1379,19 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.RunOnDisconnect != p.conf.RunOnDisconnect ||
 		closeMetrics ||
 		closePathManager ||
+		closeConnLimiter ||
 		closeLogger
 
 	closeHLSServer := newConf == nil ||
 		newConf.HLS != p.conf.HLS ||
 		newConf.HLSAddress != p.conf.HLSAddress ||
+		!reflect.DeepEqual(newConf.HLSListeners, p.conf.HLSListeners) ||
 		newConf.HLSEncryption != p.conf.HLSEncryption ||
 		newConf.HLSServerKey != p.conf.HLSServerKey ||
 		newConf.HLSServerCert != p.conf.HLSServerCert ||
+		!reflect.DeepEqual(newConf.HLSTLSOptions, p.conf.HLSTLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
 		newConf.HLSAllowOrigin != p.conf.HLSAllowOrigin ||
 		!reflect.DeepEqual(newConf.HLSTrustedProxies, p.conf.HLSTrustedProxies) ||
 		newConf.HLSAlwaysRemux != p.conf.HLSAlwaysRemux ||
@@ -794,11 +1399,20 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.HLSSegmentCount != p.conf.HLSSegmentCount ||
 		newConf.HLSSegmentDuration != p.conf.HLSSegmentDuration ||
 		newConf.HLSPartDuration != p.conf.HLSPartDuration ||
+		newConf.HLSAdaptivePartDuration != p.conf.HLSAdaptivePartDuration ||
+		newConf.HLSPartMinDuration != p.conf.HLSPartMinDuration ||
+		newConf.HLSPartMaxDuration != p.conf.HLSPartMaxDuration ||
 		newConf.HLSSegmentMaxSize != p.conf.HLSSegmentMaxSize ||
+		newConf.HLSAudioOnlyRendition != p.conf.HLSAudioOnlyRendition ||
+		newConf.HLSVideoGapFilling != p.conf.HLSVideoGapFilling ||
+		newConf.HLSVideoGapThreshold != p.conf.HLSVideoGapThreshold ||
 		newConf.HLSDirectory != p.conf.HLSDirectory ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
 		newConf.HLSMuxerCloseAfter != p.conf.HLSMuxerCloseAfter ||
+		newConf.HLSSessionSecret != p.conf.HLSSessionSecret ||
+		newConf.HLSInstanceID != p.conf.HLSInstanceID ||
+		closeRequestLimiter ||
 		closePathManager ||
 		closeMetrics ||
 		closeLogger
@@ -806,28 +1420,44 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 	closeWebRTCServer := newConf == nil ||
 		newConf.WebRTC != p.conf.WebRTC ||
 		newConf.WebRTCAddress != p.conf.WebRTCAddress ||
+		!reflect.DeepEqual(newConf.WebRTCListeners, p.conf.WebRTCListeners) ||
 		newConf.WebRTCEncryption != p.conf.WebRTCEncryption ||
 		newConf.WebRTCServerKey != p.conf.WebRTCServerKey ||
 		newConf.WebRTCServerCert != p.conf.WebRTCServerCert ||
+		!reflect.DeepEqual(newConf.WebRTCTLSOptions, p.conf.WebRTCTLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
 		newConf.WebRTCAllowOrigin != p.conf.WebRTCAllowOrigin ||
 		!reflect.DeepEqual(newConf.WebRTCTrustedProxies, p.conf.WebRTCTrustedProxies) ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
 		newConf.WebRTCLocalUDPAddress != p.conf.WebRTCLocalUDPAddress ||
 		newConf.WebRTCLocalTCPAddress != p.conf.WebRTCLocalTCPAddress ||
+		newConf.WebRTCUDPPortRange != p.conf.WebRTCUDPPortRange ||
 		newConf.WebRTCIPsFromInterfaces != p.conf.WebRTCIPsFromInterfaces ||
 		!reflect.DeepEqual(newConf.WebRTCIPsFromInterfacesList, p.conf.WebRTCIPsFromInterfacesList) ||
+		!reflect.DeepEqual(newConf.WebRTCIPsFromInterfacesExcludeList, p.conf.WebRTCIPsFromInterfacesExcludeList) ||
 		!reflect.DeepEqual(newConf.WebRTCAdditionalHosts, p.conf.WebRTCAdditionalHosts) ||
+		!reflect.DeepEqual(newConf.WebRTCICEAddressFamilies, p.conf.WebRTCICEAddressFamilies) ||
+		!reflect.DeepEqual(newConf.WebRTCICECandidateTypes, p.conf.WebRTCICECandidateTypes) ||
 		!reflect.DeepEqual(newConf.WebRTCICEServers2, p.conf.WebRTCICEServers2) ||
 		newConf.WebRTCHandshakeTimeout != p.conf.WebRTCHandshakeTimeout ||
 		newConf.WebRTCTrackGatherTimeout != p.conf.WebRTCTrackGatherTimeout ||
+		newConf.WebRTCDTLSCertificateFile != p.conf.WebRTCDTLSCertificateFile ||
+		closePublicIPDetector ||
+		closeRequestLimiter ||
 		closeMetrics ||
 		closePathManager ||
+		closeConnLimiter ||
 		closeLogger
 
 	closeSRTServer := newConf == nil ||
 		newConf.SRT != p.conf.SRT ||
 		newConf.SRTAddress != p.conf.SRTAddress ||
+		!reflect.DeepEqual(newConf.SRTListeners, p.conf.SRTListeners) ||
+		!reflect.DeepEqual(newConf.SRTAllowedIPs, p.conf.SRTAllowedIPs) ||
+		!reflect.DeepEqual(newConf.SRTStreamIDRules, p.conf.SRTStreamIDRules) ||
+		newConf.SRTReadMaxSendBuffer != p.conf.SRTReadMaxSendBuffer ||
 		newConf.RTSPAddress != p.conf.RTSPAddress ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		newConf.WriteTimeout != p.conf.WriteTimeout ||
@@ -837,6 +1467,37 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.RunOnConnectRestart != p.conf.RunOnConnectRestart ||
 		newConf.RunOnDisconnect != p.conf.RunOnDisconnect ||
 		closePathManager ||
+		closeConnLimiter ||
+		closeLogger
+
+	closeIPCServer := newConf == nil ||
+		newConf.IPC != p.conf.IPC ||
+		newConf.IPCAddress != p.conf.IPCAddress ||
+		newConf.RTSPAddress != p.conf.RTSPAddress ||
+		newConf.ReadTimeout != p.conf.ReadTimeout ||
+		newConf.RunOnConnect != p.conf.RunOnConnect ||
+		newConf.RunOnConnectRestart != p.conf.RunOnConnectRestart ||
+		newConf.RunOnDisconnect != p.conf.RunOnDisconnect ||
+		closePathManager ||
+		closeLogger
+
+	closeDASHServer := newConf == nil ||
+		newConf.DASH != p.conf.DASH ||
+		newConf.DASHAddress != p.conf.DASHAddress ||
+		newConf.DASHEncryption != p.conf.DASHEncryption ||
+		newConf.DASHServerKey != p.conf.DASHServerKey ||
+		newConf.DASHServerCert != p.conf.DASHServerCert ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
+		newConf.DASHAllowOrigin != p.conf.DASHAllowOrigin ||
+		!reflect.DeepEqual(newConf.DASHTrustedProxies, p.conf.DASHTrustedProxies) ||
+		newConf.DASHSegmentCount != p.conf.DASHSegmentCount ||
+		newConf.DASHSegmentDuration != p.conf.DASHSegmentDuration ||
+		newConf.DASHChunkDuration != p.conf.DASHChunkDuration ||
+		newConf.ReadTimeout != p.conf.ReadTimeout ||
+		newConf.WriteQueueSize != p.conf.WriteQueueSize ||
+		newConf.DASHMuxerCloseAfter != p.conf.DASHMuxerCloseAfter ||
+		closePathManager ||
 		closeLogger
 
 	closeAPI := newConf == nil ||
@@ -845,17 +1506,28 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		newConf.APIEncryption != p.conf.APIEncryption ||
 		newConf.APIServerKey != p.conf.APIServerKey ||
 		newConf.APIServerCert != p.conf.APIServerCert ||
+		!reflect.DeepEqual(newConf.APITLSOptions, p.conf.APITLSOptions) ||
+		newConf.ServerHeader != p.conf.ServerHeader ||
+		!reflect.DeepEqual(newConf.HTTPResponseHeaders, p.conf.HTTPResponseHeaders) ||
 		newConf.APIAllowOrigin != p.conf.APIAllowOrigin ||
 		!reflect.DeepEqual(newConf.APITrustedProxies, p.conf.APITrustedProxies) ||
+		newConf.APIAnonymize != p.conf.APIAnonymize ||
 		newConf.ReadTimeout != p.conf.ReadTimeout ||
 		closeAuthManager ||
+		closeRequestLimiter ||
+		closeCoordinator ||
+		closePathManager ||
+		closeLogger
+
+	closeGRPCAPI := newConf == nil ||
+		newConf.GRPCAPI != p.conf.GRPCAPI ||
+		newConf.GRPCAPIAddress != p.conf.GRPCAPIAddress ||
+		newConf.GRPCAPIEncryption != p.conf.GRPCAPIEncryption ||
+		newConf.GRPCAPIServerKey != p.conf.GRPCAPIServerKey ||
+		newConf.GRPCAPIServerCert != p.conf.GRPCAPIServerCert ||
+		!reflect.DeepEqual(newConf.GRPCAPITLSOptions, p.conf.GRPCAPITLSOptions) ||
+		closeAuthManager ||
 		closePathManager ||
-		closeRTSPServer ||
-		closeRTSPSServer ||
-		closeRTMPServer ||
-		closeHLSServer ||
-		closeWebRTCServer ||
-		closeSRTServer ||
 		closeLogger
 
 	if newConf == nil && p.confWatcher != nil {
@@ -872,11 +1544,38 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		}
 	}
 
+	if p.grpcAPI != nil {
+		if closeGRPCAPI {
+			p.grpcAPI.Close()
+			p.grpcAPI = nil
+		} else {
+			p.grpcAPI.ReloadConf(newConf)
+		}
+	}
+
+	if closeDASHServer && p.dashServer != nil {
+		p.dashServer.Close()
+		p.dashServer = nil
+	}
+
+	if closeIPCServer && p.ipcServer != nil {
+		p.ipcServer.Close()
+		p.ipcServer = nil
+	}
+
 	if closeSRTServer && p.srtServer != nil {
 		if p.metrics != nil {
 			p.metrics.SetSRTServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetSRTServer(nil)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetSRTServer(nil)
+		}
+
 		p.srtServer.Close()
 		p.srtServer = nil
 	}
@@ -886,6 +1585,14 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetWebRTCServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetWebRTCServer(nil)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetWebRTCServer(nil)
+		}
+
 		p.webRTCServer.Close()
 		p.webRTCServer = nil
 	}
@@ -895,6 +1602,10 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetHLSServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetHLSServer(nil)
+		}
+
 		p.pathManager.setHLSServer(nil)
 
 		p.hlsServer.Close()
@@ -906,6 +1617,14 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetRTMPSServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetRTMPSServer(nil)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTMPSServer(nil)
+		}
+
 		p.rtmpsServer.Close()
 		p.rtmpsServer = nil
 	}
@@ -915,6 +1634,14 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetRTMPServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetRTMPServer(nil)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTMPServer(nil)
+		}
+
 		p.rtmpServer.Close()
 		p.rtmpServer = nil
 	}
@@ -924,6 +1651,14 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetRTSPSServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetRTSPSServer(nil)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTSPSServer(nil)
+		}
+
 		p.rtspsServer.Close()
 		p.rtspsServer = nil
 	}
@@ -933,6 +1668,14 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetRTSPServer(nil)
 		}
 
+		if p.api != nil {
+			p.api.SetRTSPServer(nil)
+		}
+
+		if p.grpcAPI != nil {
+			p.grpcAPI.SetRTSPServer(nil)
+		}
+
 		p.rtspServer.Close()
 		p.rtspServer = nil
 	}
@@ -942,10 +1685,36 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 			p.metrics.SetPathManager(nil)
 		}
 
+		if p.coordinator != nil {
+			p.coordinator.SetPathManager(nil)
+		}
+
 		p.pathManager.close()
 		p.pathManager = nil
 	}
 
+	if closeRecordProcessor && p.recordProcessor != nil {
+		p.recordProcessor.Close()
+		p.recordProcessor = nil
+	}
+
+	if closeBandwidth && p.bandwidth != nil {
+		if p.metrics != nil {
+			p.metrics.SetBandwidthAccountant(nil)
+		}
+
+		p.bandwidth = nil
+	}
+
+	if closePathStateStore && p.pathStateStore != nil {
+		p.pathStateStore = nil
+	}
+
+	if closeCoordinator && p.coordinator != nil {
+		p.coordinator.Close()
+		p.coordinator = nil
+	}
+
 	if closePlaybackServer && p.playbackServer != nil {
 		p.playbackServer.Close()
 		p.playbackServer = nil
@@ -956,6 +1725,11 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		p.recordCleaner = nil
 	}
 
+	if closePublicIPDetector && p.publicIPDetector != nil {
+		p.publicIPDetector.Close()
+		p.publicIPDetector = nil
+	}
+
 	if closePPROF && p.pprof != nil {
 		p.pprof.Close()
 		p.pprof = nil
@@ -966,6 +1740,22 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		p.metrics = nil
 	}
 
+	if closeRequestLimiter && p.requestLimiter != nil {
+		if p.metrics != nil {
+			p.metrics.SetRequestLimiter(nil)
+		}
+
+		p.requestLimiter = nil
+	}
+
+	if closeConnLimiter && p.connLimiter != nil {
+		if p.metrics != nil {
+			p.metrics.SetConnLimiter(nil)
+		}
+
+		p.connLimiter = nil
+	}
+
 	if closeAuthManager && p.authManager != nil {
 		p.authManager = nil
 	}
@@ -975,12 +1765,72 @@ func (p *Core) closeResources(newConf *conf.Conf, calledByAPI bool) {
 		p.externalCmdPool.Close()
 	}
 
+	if closeElector && p.elector != nil {
+		p.elector.Close()
+		p.elector = nil
+	}
+
+	if closeLogger && p.clockMonitor != nil {
+		p.clockMonitor.Close()
+		p.clockMonitor = nil
+	}
+
 	if closeLogger && p.logger != nil {
 		p.logger.Close()
 		p.logger = nil
 	}
 }
 
+func pathNames(c *conf.Conf) map[string]struct{} {
+	ret := make(map[string]struct{}, len(c.OptionalPaths))
+	for name := range c.OptionalPaths {
+		ret[name] = struct{}{}
+	}
+	return ret
+}
+
+// restorePaths adds paths created at runtime before the last restart.
+// Paths of the configuration file take precedence.
+func (p *Core) restorePaths() {
+	paths := p.pathStateStore.Paths()
+	if len(paths) == 0 {
+		return
+	}
+
+	newConf := p.conf.Clone()
+
+	for name, pathConf := range paths {
+		if _, ok := newConf.OptionalPaths[name]; !ok {
+			newConf.AddPath(name, pathConf) //nolint:errcheck
+		}
+	}
+
+	err := newConf.Validate()
+	if err != nil {
+		p.Log(logger.Warn, "unable to restore paths created at runtime: %v", err)
+		return
+	}
+
+	p.Log(logger.Info, "restored %d paths created at runtime", len(paths))
+	p.conf = newConf
+}
+
+// savePathState saves the configurations of paths created at runtime.
+func (p *Core) savePathState() {
+	if p.pathStateStore == nil {
+		return
+	}
+
+	paths := make(map[string]*conf.OptionalPath)
+	for name, pathConf := range p.conf.OptionalPaths {
+		if _, ok := p.filePathNames[name]; !ok {
+			paths[name] = pathConf
+		}
+	}
+
+	p.pathStateStore.SetPaths(paths)
+}
+
 func (p *Core) reloadConf(newConf *conf.Conf, calledByAPI bool) error {
 	p.closeResources(newConf, calledByAPI)
 	p.conf = newConf
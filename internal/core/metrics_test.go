@@ -83,12 +83,20 @@ rtsp_conns_bytes_sent 0
 rtsp_sessions 0
 rtsp_sessions_bytes_received 0
 rtsp_sessions_bytes_sent 0
+rtsp_transport_rejections{transport="multicast"} 0
+rtsp_transport_rejections{transport="tcp"} 0
+rtsp_transport_rejections{transport="udp"} 0
+rtsp_transport_fallbacks 0
 rtsps_conns 0
 rtsps_conns_bytes_received 0
 rtsps_conns_bytes_sent 0
 rtsps_sessions 0
 rtsps_sessions_bytes_received 0
 rtsps_sessions_bytes_sent 0
+rtsps_transport_rejections{transport="multicast"} 0
+rtsps_transport_rejections{transport="tcp"} 0
+rtsps_transport_rejections{transport="udp"} 0
+rtsps_transport_fallbacks 0
 rtmp_conns 0
 rtmp_conns_bytes_received 0
 rtmp_conns_bytes_sent 0
@@ -98,9 +106,13 @@ rtmps_conns_bytes_sent 0
 srt_conns 0
 srt_conns_bytes_received 0
 srt_conns_bytes_sent 0
+srt_handshakes_rejected 0
 webrtc_sessions 0
 webrtc_sessions_bytes_received 0
 webrtc_sessions_bytes_sent 0
+bandwidth_users_bytes_received 0
+bandwidth_users_bytes_sent 0
+clock_jumps 0
 `, string(bo))
 	})
 
@@ -248,45 +260,125 @@ webrtc_sessions_bytes_sent 0
 			`^paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_whep_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_gop_open\{name=".*?",state="ready"\} 0`+"\n"+
+				`paths_keyframe_interval_seconds\{name=".*?",state="ready"\} [0-9.]+`+"\n"+
+				`paths_keyframe_interval_long\{name=".*?",state="ready"\} 0`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_whep_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_gop_open\{name=".*?",state="ready"\} 0`+"\n"+
+				`paths_keyframe_interval_seconds\{name=".*?",state="ready"\} [0-9.]+`+"\n"+
+				`paths_keyframe_interval_long\{name=".*?",state="ready"\} 0`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_whep_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_gop_open\{name=".*?",state="ready"\} 0`+"\n"+
+				`paths_keyframe_interval_seconds\{name=".*?",state="ready"\} [0-9.]+`+"\n"+
+				`paths_keyframe_interval_long\{name=".*?",state="ready"\} 0`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_whep_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_gop_open\{name=".*?",state="ready"\} 0`+"\n"+
+				`paths_keyframe_interval_seconds\{name=".*?",state="ready"\} [0-9.]+`+"\n"+
+				`paths_keyframe_interval_long\{name=".*?",state="ready"\} 0`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_whep_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_gop_open\{name=".*?",state="ready"\} 0`+"\n"+
+				`paths_keyframe_interval_seconds\{name=".*?",state="ready"\} [0-9.]+`+"\n"+
+				`paths_keyframe_interval_long\{name=".*?",state="ready"\} 0`+"\n"+
 				`paths\{name=".*?",state="ready"\} 1`+"\n"+
 				`paths_bytes_received\{name=".*?",state="ready"\} [0-9]+`+"\n"+
 				`paths_bytes_sent\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_whep_readers\{name=".*?",state="ready"\} [0-9]+`+"\n"+
+				`paths_gop_open\{name=".*?",state="ready"\} 0`+"\n"+
+				`paths_keyframe_interval_seconds\{name=".*?",state="ready"\} [0-9.]+`+"\n"+
+				`paths_keyframe_interval_long\{name=".*?",state="ready"\} 0`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
+				`hls_muxers_segment_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_segment_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_segments_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_part_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_part_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_parts_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_late_part_deliveries\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_playlist_update_latency\{name=".*?"\} -?[0-9.]+`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
+				`hls_muxers_segment_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_segment_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_segments_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_part_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_part_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_parts_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_late_part_deliveries\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_playlist_update_latency\{name=".*?"\} -?[0-9.]+`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
+				`hls_muxers_segment_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_segment_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_segments_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_part_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_part_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_parts_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_late_part_deliveries\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_playlist_update_latency\{name=".*?"\} -?[0-9.]+`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
+				`hls_muxers_segment_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_segment_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_segments_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_part_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_part_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_parts_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_late_part_deliveries\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_playlist_update_latency\{name=".*?"\} -?[0-9.]+`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
+				`hls_muxers_segment_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_segment_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_segments_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_part_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_part_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_parts_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_late_part_deliveries\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_playlist_update_latency\{name=".*?"\} -?[0-9.]+`+"\n"+
 				`hls_muxers\{name=".*?"\} 1`+"\n"+
 				`hls_muxers_bytes_sent\{name=".*?"\} 0`+"\n"+
+				`hls_muxers_segment_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_segment_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_segments_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_part_target_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_last_part_duration\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_parts_over_target\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_late_part_deliveries\{name=".*?"\} -?[0-9.]+`+"\n"+
+				`hls_muxers_playlist_update_latency\{name=".*?"\} -?[0-9.]+`+"\n"+
 				`rtsp_conns\{id=".*?"\} 1`+"\n"+
 				`rtsp_conns_bytes_received\{id=".*?"\} [0-9]+`+"\n"+
 				`rtsp_conns_bytes_sent\{id=".*?"\} [0-9]+`+"\n"+
 				`rtsp_sessions\{id=".*?",state="publish"\} 1`+"\n"+
 				`rtsp_sessions_bytes_received\{id=".*?",state="publish"\} 0`+"\n"+
 				`rtsp_sessions_bytes_sent\{id=".*?",state="publish"\} [0-9]+`+"\n"+
+				`rtsp_transport_rejections\{transport="multicast"\} [0-9]+`+"\n"+
+				`rtsp_transport_rejections\{transport="tcp"\} [0-9]+`+"\n"+
+				`rtsp_transport_rejections\{transport="udp"\} [0-9]+`+"\n"+
+				`rtsp_transport_fallbacks 0`+"\n"+
 				`rtsps_conns\{id=".*?"\} 1`+"\n"+
 				`rtsps_conns_bytes_received\{id=".*?"\} [0-9]+`+"\n"+
 				`rtsps_conns_bytes_sent\{id=".*?"\} [0-9]+`+"\n"+
 				`rtsps_sessions\{id=".*?",state="publish"\} 1`+"\n"+
 				`rtsps_sessions_bytes_received\{id=".*?",state="publish"\} 0`+"\n"+
 				`rtsps_sessions_bytes_sent\{id=".*?",state="publish"\} [0-9]+`+"\n"+
+				`rtsps_transport_rejections\{transport="multicast"\} [0-9]+`+"\n"+
+				`rtsps_transport_rejections\{transport="tcp"\} [0-9]+`+"\n"+
+				`rtsps_transport_rejections\{transport="udp"\} [0-9]+`+"\n"+
+				`rtsps_transport_fallbacks 0`+"\n"+
 				`rtmp_conns\{id=".*?",state="publish"\} 1`+"\n"+
 				`rtmp_conns_bytes_received\{id=".*?",state="publish"\} [0-9]+`+"\n"+
 				`rtmp_conns_bytes_sent\{id=".*?",state="publish"\} [0-9]+`+"\n"+
@@ -345,9 +437,13 @@ webrtc_sessions_bytes_sent 0
 				`srt_conns_packets_received_avg_belated_time\{id=".*?",state="publish"\} [0-9]+`+"\n"+
 				`srt_conns_packets_send_loss_rate\{id=".*?",state="publish"\} [0-9]+`+"\n"+
 				`srt_conns_packets_received_loss_rate\{id=".*?",state="publish"\} [0-9]+`+"\n"+
+				"srt_handshakes_rejected 0\n"+
 				`webrtc_sessions\{id=".*?",state="publish"\} 1`+"\n"+
 				`webrtc_sessions_bytes_received\{id=".*?",state="publish"\} [0-9]+`+"\n"+
 				`webrtc_sessions_bytes_sent\{id=".*?",state="publish"\} [0-9]+`+"\n"+
+				"bandwidth_users_bytes_received 0\n"+
+				"bandwidth_users_bytes_sent 0\n"+
+				"clock_jumps 0\n"+
 				"$",
 			string(bo))
 
@@ -368,6 +464,9 @@ webrtc_sessions_bytes_sent 0
 
 		bo := httpPullFile(t, hc, "http://localhost:9998/metrics")
 
-		require.Equal(t, "paths 0\n", string(bo))
+		require.Equal(t, "paths 0\n"+
+			"bandwidth_users_bytes_received 0\n"+
+			"bandwidth_users_bytes_sent 0\n"+
+			"clock_jumps 0\n", string(bo))
 	})
 }
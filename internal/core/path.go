@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -11,14 +12,36 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
 
+	"github.com/bluenviron/mediamtx/internal/audiometer"
+	"github.com/bluenviron/mediamtx/internal/bandwidth"
+	"github.com/bluenviron/mediamtx/internal/cmafpush"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/conformance"
+	"github.com/bluenviron/mediamtx/internal/coordinator"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/gopmonitor"
 	"github.com/bluenviron/mediamtx/internal/hooks"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/ptz"
 	"github.com/bluenviron/mediamtx/internal/recorder"
+	"github.com/bluenviron/mediamtx/internal/recordprocessor"
+	"github.com/bluenviron/mediamtx/internal/recordstorage"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/bluenviron/mediamtx/internal/rtpcapture"
+	"github.com/bluenviron/mediamtx/internal/rtpoutput"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/subtitles"
+	"github.com/bluenviron/mediamtx/internal/videomonitor"
+	"github.com/bluenviron/mediamtx/internal/watermark"
+)
+
+const (
+	subtitlesSegmentDuration = 2 * time.Second
+	subtitlesSegmentCount    = 10
 )
 
 func emptyTimer() *time.Timer {
@@ -32,6 +55,7 @@ type pathParent interface {
 	pathReady(*path)
 	pathNotReady(*path)
 	closePath(*path)
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
 }
 
 type pathOnDemandState int
@@ -44,7 +68,6 @@ const (
 )
 
 type pathAPIPathsListRes struct {
-	data  *defs.APIPathList
 	paths map[string]*path
 }
 
@@ -63,6 +86,46 @@ type pathAPIPathsGetReq struct {
 	res  chan pathAPIPathsGetRes
 }
 
+type pathAPIPathsGetTracksRes struct {
+	data *defs.APIPathTrackList
+	err  error
+}
+
+type pathAPIPathsGetTracksReq struct {
+	res chan pathAPIPathsGetTracksRes
+}
+
+type pathAPIPathsConformanceRes struct {
+	analyzer *conformance.Analyzer
+	err      error
+}
+
+type pathAPIPathsConformanceReq struct {
+	res chan pathAPIPathsConformanceRes
+}
+
+type pathAPIPathsDrainReq struct {
+	drain   bool
+	timeout time.Duration
+	res     chan struct{}
+}
+
+// pathAuthenticatedSession is a publisher or reader
+// that has been authenticated when it was added to the path.
+type pathAuthenticatedSession struct {
+	author        interface{ Close() }
+	accessRequest defs.PathAccessRequest
+}
+
+type pathAuthenticatedSessionsReq struct {
+	res chan []pathAuthenticatedSession
+}
+
+type pathCodecChangeReq struct {
+	stream *stream.Stream
+	format format.Format
+}
+
 type path struct {
 	parentCtx         context.Context
 	logLevel          conf.LogLevel
@@ -76,6 +139,11 @@ type path struct {
 	matches           []string
 	wg                *sync.WaitGroup
 	externalCmdPool   *externalcmd.Pool
+	coordinator       *coordinator.Coordinator
+	startLimiter      *staticSourceStartLimiter
+	bandwidth         *bandwidth.Accountant
+	recordProcessor   *recordprocessor.Processor
+	restoreSource     bool
 	parent            pathParent
 
 	ctx                            context.Context
@@ -83,12 +151,24 @@ type path struct {
 	confMutex                      sync.RWMutex
 	source                         defs.Source
 	publisherQuery                 string
+	publisherAccessRequest         *defs.PathAccessRequest
 	stream                         *stream.Stream
 	recorder                       *recorder.Recorder
+	audioMeter                     *audiometer.Meter
+	videoMonitor                   *videomonitor.Monitor
+	gopMonitor                     *gopmonitor.Monitor
+	rtpOutput                      *rtpoutput.Output
+	cmafPush                       *cmafpush.Output
+	subtitles                      *subtitles.Track
+	ptzTour                        *ptz.Tour
+	capture                        *rtpcapture.Buffer
 	readyTime                      time.Time
 	onUnDemandHook                 func(string)
 	onNotReadyHook                 func()
-	readers                        map[defs.Reader]struct{}
+	readers                        map[defs.Reader]hooks.ReaderInfo
+	readerDurationTimers           map[defs.Reader]*time.Timer
+	readerAccessRequests           map[defs.Reader]defs.PathAccessRequest
+	onLastReaderHook               func(hooks.ReaderInfo)
 	describeRequestsOnHold         []defs.PathDescribeReq
 	readerAddRequestsOnHold        []defs.PathAddReaderReq
 	onDemandStaticSourceState      pathOnDemandState
@@ -97,6 +177,13 @@ type path struct {
 	onDemandPublisherState         pathOnDemandState
 	onDemandPublisherReadyTimer    *time.Timer
 	onDemandPublisherCloseTimer    *time.Timer
+	publisherResumeToken           string
+	publisherResumeTimer           *time.Timer
+	publisherResuming              bool
+	publisherIdleTimer             *time.Timer
+	publisherIdleBytes             uint64
+	draining                       bool
+	drainTimer                     *time.Timer
 
 	// in
 	chReloadConf              chan *conf.Path
@@ -110,6 +197,11 @@ type path struct {
 	chAddReader               chan defs.PathAddReaderReq
 	chRemoveReader            chan defs.PathRemoveReaderReq
 	chAPIPathsGet             chan pathAPIPathsGetReq
+	chAPIPathsGetTracks       chan pathAPIPathsGetTracksReq
+	chAPIPathsConformance     chan pathAPIPathsConformanceReq
+	chAPIPathsDrain           chan pathAPIPathsDrainReq
+	chAuthenticatedSessions   chan pathAuthenticatedSessionsReq
+	chCodecChange             chan pathCodecChangeReq
 
 	// out
 	done chan struct{}
@@ -120,11 +212,16 @@ func (pa *path) initialize() {
 
 	pa.ctx = ctx
 	pa.ctxCancel = ctxCancel
-	pa.readers = make(map[defs.Reader]struct{})
+	pa.readers = make(map[defs.Reader]hooks.ReaderInfo)
+	pa.readerDurationTimers = make(map[defs.Reader]*time.Timer)
+	pa.readerAccessRequests = make(map[defs.Reader]defs.PathAccessRequest)
 	pa.onDemandStaticSourceReadyTimer = emptyTimer()
 	pa.onDemandStaticSourceCloseTimer = emptyTimer()
 	pa.onDemandPublisherReadyTimer = emptyTimer()
 	pa.onDemandPublisherCloseTimer = emptyTimer()
+	pa.publisherResumeTimer = emptyTimer()
+	pa.publisherIdleTimer = emptyTimer()
+	pa.drainTimer = emptyTimer()
 	pa.chReloadConf = make(chan *conf.Path)
 	pa.chStaticSourceSetReady = make(chan defs.PathSourceStaticSetReadyReq)
 	pa.chStaticSourceSetNotReady = make(chan defs.PathSourceStaticSetNotReadyReq)
@@ -136,8 +233,47 @@ func (pa *path) initialize() {
 	pa.chAddReader = make(chan defs.PathAddReaderReq)
 	pa.chRemoveReader = make(chan defs.PathRemoveReaderReq)
 	pa.chAPIPathsGet = make(chan pathAPIPathsGetReq)
+	pa.chAPIPathsGetTracks = make(chan pathAPIPathsGetTracksReq)
+	pa.chAPIPathsConformance = make(chan pathAPIPathsConformanceReq)
+	pa.chAPIPathsDrain = make(chan pathAPIPathsDrainReq)
+	pa.chAuthenticatedSessions = make(chan pathAuthenticatedSessionsReq)
+	pa.chCodecChange = make(chan pathCodecChangeReq, 1)
 	pa.done = make(chan struct{})
 
+	if pa.conf.Subtitles {
+		pa.subtitles = &subtitles.Track{
+			SegmentDuration: subtitlesSegmentDuration,
+			SegmentCount:    subtitlesSegmentCount,
+		}
+		pa.subtitles.Initialize()
+	}
+
+	if pa.conf.Capture {
+		pa.capture = &rtpcapture.Buffer{
+			Duration:       time.Duration(pa.conf.CaptureDuration),
+			WriteQueueSize: pa.writeQueueSize,
+			Parent:         pa,
+		}
+	}
+
+	if pa.conf.PTZURL != "" {
+		pa.ptzTour = &ptz.Tour{
+			Client: &ptz.Client{
+				URL:          pa.conf.PTZURL,
+				User:         pa.conf.PTZUser,
+				Pass:         pa.conf.PTZPass,
+				ProfileToken: pa.conf.PTZProfileToken,
+			},
+			Steps:  pa.conf.PTZTour,
+			Parent: pa,
+		}
+		pa.ptzTour.Initialize()
+
+		if len(pa.conf.PTZTour) != 0 {
+			pa.ptzTour.Start() //nolint:errcheck
+		}
+	}
+
 	pa.Log(logger.Debug, "created")
 
 	pa.wg.Add(1)
@@ -175,12 +311,19 @@ func (pa *path) run() {
 			writeTimeout:   pa.writeTimeout,
 			writeQueueSize: pa.writeQueueSize,
 			matches:        pa.matches,
+			pathName:       pa.name,
+			coordinator:    pa.coordinator,
+			startLimiter:   pa.startLimiter,
+			pathManager:    pa.parent,
 			parent:         pa,
 		}
 		pa.source.(*staticSourceHandler).initialize()
 
 		if !pa.conf.SourceOnDemand {
 			pa.source.(*staticSourceHandler).start(false, "")
+		} else if pa.restoreSource {
+			pa.Log(logger.Info, "restoring on-demand source, that was running before the restart")
+			pa.onDemandStaticSourceStart("")
 		}
 	}
 
@@ -202,6 +345,17 @@ func (pa *path) run() {
 	pa.onDemandStaticSourceCloseTimer.Stop()
 	pa.onDemandPublisherReadyTimer.Stop()
 	pa.onDemandPublisherCloseTimer.Stop()
+	pa.publisherResumeTimer.Stop()
+	pa.publisherIdleTimer.Stop()
+	pa.drainTimer.Stop()
+
+	if pa.ptzTour != nil {
+		pa.ptzTour.Close()
+	}
+
+	if pa.capture != nil {
+		pa.capture.Close()
+	}
 
 	onUnInitHook()
 
@@ -223,6 +377,7 @@ func (pa *path) run() {
 				source.close("path is closing")
 			}
 		} else if source, ok := pa.source.(defs.Publisher); ok {
+			pa.unregisterBandwidth(source)
 			source.Close()
 		}
 	}
@@ -261,6 +416,23 @@ func (pa *path) runInner() error {
 		case <-pa.onDemandPublisherCloseTimer.C:
 			pa.doOnDemandPublisherCloseTimer()
 
+		case <-pa.publisherResumeTimer.C:
+			pa.doPublisherResumeTimer()
+
+			if pa.shouldClose() {
+				return fmt.Errorf("not in use")
+			}
+
+		case <-pa.publisherIdleTimer.C:
+			pa.doPublisherIdleTimer()
+
+			if pa.shouldClose() {
+				return fmt.Errorf("not in use")
+			}
+
+		case <-pa.drainTimer.C:
+			pa.doDrainTimer()
+
 		case newConf := <-pa.chReloadConf:
 			pa.doReloadConf(newConf)
 
@@ -314,6 +486,25 @@ func (pa *path) runInner() error {
 		case req := <-pa.chAPIPathsGet:
 			pa.doAPIPathsGet(req)
 
+		case req := <-pa.chAPIPathsGetTracks:
+			pa.doAPIPathsGetTracks(req)
+
+		case req := <-pa.chAPIPathsConformance:
+			pa.doAPIPathsConformance(req)
+
+		case req := <-pa.chAPIPathsDrain:
+			pa.doAPIPathsDrain(req)
+
+		case req := <-pa.chAuthenticatedSessions:
+			pa.doAuthenticatedSessions(req)
+
+		case req := <-pa.chCodecChange:
+			pa.doCodecChange(req)
+
+			if pa.shouldClose() {
+				return fmt.Errorf("not in use")
+			}
+
 		case <-pa.ctx.Done():
 			return fmt.Errorf("terminated")
 		}
@@ -373,6 +564,10 @@ func (pa *path) doReloadConf(newConf *conf.Path) {
 	} else if pa.recorder != nil {
 		pa.recorder.Close()
 		pa.recorder = nil
+
+		if pa.subtitles != nil {
+			pa.subtitles.SetRecordingFile("", time.Time{})
+		}
 	}
 }
 
@@ -386,7 +581,7 @@ func (pa *path) doSourceStaticSetReady(req defs.PathSourceStaticSetReadyReq) {
 	if pa.conf.HasOnDemandStaticSource() {
 		pa.onDemandStaticSourceReadyTimer.Stop()
 		pa.onDemandStaticSourceReadyTimer = emptyTimer()
-		pa.onDemandStaticSourceScheduleClose()
+		pa.onDemandStaticSourceScheduleClose(time.Duration(pa.conf.SourceOnDemandCloseAfter))
 	}
 
 	pa.consumeOnHoldRequests()
@@ -407,6 +602,11 @@ func (pa *path) doSourceStaticSetNotReady(req defs.PathSourceStaticSetNotReadyRe
 }
 
 func (pa *path) doDescribe(req defs.PathDescribeReq) {
+	if pa.draining {
+		req.Res <- defs.PathDescribeRes{Err: defs.PathDrainingError{PathName: pa.name}}
+		return
+	}
+
 	if _, ok := pa.source.(*sourceRedirect); ok {
 		req.Res <- defs.PathDescribeRes{
 			Redirect: pa.conf.SourceRedirect,
@@ -457,9 +657,64 @@ func (pa *path) doDescribe(req defs.PathDescribeReq) {
 	req.Res <- defs.PathDescribeRes{Err: defs.PathNoOnePublishingError{PathName: pa.name}}
 }
 
+func (pa *path) doPublisherResumeTimer() {
+	pa.Log(logger.Info, "publisher did not resume")
+	pa.publisherResumeTimer = emptyTimer()
+	pa.publisherResuming = false
+	pa.setNotReady()
+}
+
+func (pa *path) doPublisherIdleTimer() {
+	c, ok := pa.source.(bandwidth.Counter)
+	if !ok {
+		return
+	}
+
+	period := time.Duration(pa.conf.PublisherIdleTimeout)
+	bytes := c.BytesReceived()
+	received := bytes - pa.publisherIdleBytes
+	pa.publisherIdleBytes = bytes
+	bitrate := uint64(float64(received*8) / period.Seconds())
+
+	if received != 0 && bitrate >= uint64(pa.conf.PublisherMinBitrate) {
+		pa.publisherIdleTimer = time.NewTimer(period)
+		return
+	}
+
+	desc := pa.source.APISourceDescribe()
+
+	if received == 0 {
+		pa.Log(logger.Warn, "closing %s %s: no data received in %v", desc.Type, desc.ID, period)
+	} else {
+		pa.Log(logger.Warn, "closing %s %s: bitrate (%d bit/s) is below minimum (%d bit/s)",
+			desc.Type, desc.ID, bitrate, pa.conf.PublisherMinBitrate)
+	}
+
+	if pa.conf.RunOnPublisherIdle != "" {
+		pa.Log(logger.Info, "runOnPublisherIdle command launched")
+		env := pa.ExternalCmdEnv()
+		env["MTX_SOURCE_TYPE"] = desc.Type
+		env["MTX_SOURCE_ID"] = desc.ID
+		env["MTX_BITRATE"] = strconv.FormatUint(bitrate, 10)
+		externalcmd.NewCmd(
+			pa.externalCmdPool,
+			pa.conf.RunOnPublisherIdle,
+			false,
+			env,
+			nil)
+	}
+
+	pa.source.(defs.Publisher).Close()
+	pa.executeRemovePublisher()
+}
+
 func (pa *path) doRemovePublisher(req defs.PathRemovePublisherReq) {
 	if pa.source == req.Author {
-		pa.executeRemovePublisher()
+		if pa.canResumePublisher() {
+			pa.waitPublisherResume()
+		} else {
+			pa.executeRemovePublisher()
+		}
 	}
 	close(req.Res)
 }
@@ -472,6 +727,17 @@ func (pa *path) doAddPublisher(req defs.PathAddPublisherReq) {
 		return
 	}
 
+	resumeToken := publisherResumeToken(req.AccessRequest.Query)
+
+	if pa.isWaitingPublisherResume() && resumeToken != pa.publisherResumeToken {
+		if !pa.conf.OverridePublisher {
+			req.Res <- defs.PathAddPublisherRes{Err: fmt.Errorf("someone is already publishing to path '%s'", pa.name)}
+			return
+		}
+
+		pa.stopPublisherResume()
+	}
+
 	if pa.source != nil {
 		if !pa.conf.OverridePublisher {
 			req.Res <- defs.PathAddPublisherRes{Err: fmt.Errorf("someone is already publishing to path '%s'", pa.name)}
@@ -485,6 +751,17 @@ func (pa *path) doAddPublisher(req defs.PathAddPublisherReq) {
 
 	pa.source = req.Author
 	pa.publisherQuery = req.AccessRequest.Query
+	if !req.AccessRequest.SkipAuth {
+		accessRequest := req.AccessRequest
+		pa.publisherAccessRequest = &accessRequest
+	}
+	pa.publisherResumeToken = resumeToken
+	pa.registerBandwidth(req.AccessRequest.User, req.Author)
+
+	if pa.conf.PublisherIdleTimeout != 0 {
+		pa.publisherIdleBytes = 0
+		pa.publisherIdleTimer = time.NewTimer(time.Duration(pa.conf.PublisherIdleTimeout))
+	}
 
 	req.Res <- defs.PathAddPublisherRes{Path: pa}
 }
@@ -495,6 +772,25 @@ func (pa *path) doStartPublisher(req defs.PathStartPublisherReq) {
 		return
 	}
 
+	if pa.isWaitingPublisherResume() {
+		err := pa.stream.Resume(req.Desc)
+		if err == nil {
+			pa.publisherResuming = false
+			pa.publisherResumeTimer.Stop()
+			pa.publisherResumeTimer = emptyTimer()
+
+			req.Author.Log(logger.Info, "resumed publishing to path '%s', %s",
+				pa.name,
+				defs.MediasInfo(req.Desc.Medias))
+
+			req.Res <- defs.PathStartPublisherRes{Stream: pa.stream}
+			return
+		}
+
+		pa.Log(logger.Warn, "unable to resume publisher: %v", err)
+		pa.stopPublisherResume()
+	}
+
 	err := pa.setReady(req.Desc, req.GenerateRTPPackets)
 	if err != nil {
 		req.Res <- defs.PathStartPublisherRes{Err: err}
@@ -524,6 +820,11 @@ func (pa *path) doStopPublisher(req defs.PathStopPublisherReq) {
 }
 
 func (pa *path) doAddReader(req defs.PathAddReaderReq) {
+	if pa.draining {
+		req.Res <- defs.PathAddReaderRes{Err: defs.PathDrainingError{PathName: pa.name}}
+		return
+	}
+
 	if pa.stream != nil {
 		pa.addReaderPost(req)
 		return
@@ -554,10 +855,14 @@ func (pa *path) doRemoveReader(req defs.PathRemoveReaderReq) {
 	}
 	close(req.Res)
 
+	pa.scheduleOnDemandCloseIfUnused()
+}
+
+func (pa *path) scheduleOnDemandCloseIfUnused() {
 	if len(pa.readers) == 0 {
 		if pa.conf.HasOnDemandStaticSource() {
 			if pa.onDemandStaticSourceState == pathOnDemandStateReady {
-				pa.onDemandStaticSourceScheduleClose()
+				pa.onDemandStaticSourceScheduleClose(pa.onDemandStaticSourceLinger())
 			}
 		} else if pa.conf.HasOnDemandPublisher() {
 			if pa.onDemandPublisherState == pathOnDemandStateReady {
@@ -579,6 +884,12 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 				v := pa.source.APISourceDescribe()
 				return &v
 			}(),
+			SourceRetry: func() *defs.APIPathSourceRetry {
+				if h, ok := pa.source.(*staticSourceHandler); ok {
+					return h.apiSourceRetry()
+				}
+				return nil
+			}(),
 			Ready: pa.stream != nil,
 			ReadyTime: func() *time.Time {
 				if pa.stream == nil {
@@ -593,6 +904,43 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 				}
 				return defs.MediasToCodecs(pa.stream.Desc().Medias)
 			}(),
+			AudioLevels: func() []defs.APIPathAudioLevel {
+				if pa.audioMeter == nil {
+					return nil
+				}
+				levels := pa.audioMeter.Levels()
+				ret := make([]defs.APIPathAudioLevel, len(levels))
+				for i, l := range levels {
+					ret[i] = defs.APIPathAudioLevel{
+						Track:    l.Track,
+						Codec:    l.Codec,
+						Loudness: l.Loudness,
+						Peak:     l.Peak,
+					}
+				}
+				return ret
+			}(),
+			GOPStatus: func() *defs.APIPathGOPStatus {
+				if pa.gopMonitor == nil || !pa.gopMonitor.HasTracks() {
+					return nil
+				}
+				s := pa.gopMonitor.Status()
+				return &defs.APIPathGOPStatus{
+					OpenGOP:              s.OpenGOP,
+					KeyframeInterval:     conf.StringDuration(s.KeyframeInterval),
+					LongKeyframeInterval: s.LongKeyframeInterval,
+				}
+			}(),
+			VideoStatus: func() *defs.APIPathVideoStatus {
+				if pa.videoMonitor == nil {
+					return nil
+				}
+				s := pa.videoMonitor.Status()
+				return &defs.APIPathVideoStatus{
+					Frozen: s.Frozen,
+					Black:  s.Black,
+				}
+			}(),
 			BytesReceived: func() uint64 {
 				if pa.stream == nil {
 					return 0
@@ -612,10 +960,162 @@ func (pa *path) doAPIPathsGet(req pathAPIPathsGetReq) {
 				}
 				return ret
 			}(),
+			WHEPReaders: pa.whepReaderCount(),
+			Draining:    pa.draining,
+		},
+	}
+}
+
+func (pa *path) doAPIPathsGetTracks(req pathAPIPathsGetTracksReq) {
+	if pa.stream == nil {
+		req.res <- pathAPIPathsGetTracksRes{err: defs.PathNoOnePublishingError{PathName: pa.name}}
+		return
+	}
+
+	req.res <- pathAPIPathsGetTracksRes{
+		data: &defs.APIPathTrackList{
+			Items: defs.MediasToAPITracks(pa.stream.Desc().Medias),
 		},
 	}
 }
 
+func (pa *path) doAPIPathsConformance(req pathAPIPathsConformanceReq) {
+	if pa.stream == nil {
+		req.res <- pathAPIPathsConformanceRes{err: defs.PathNoOnePublishingError{PathName: pa.name}}
+		return
+	}
+
+	a := &conformance.Analyzer{
+		WriteQueueSize: pa.writeQueueSize,
+		Stream:         pa.stream,
+		Parent:         pa,
+	}
+	a.Initialize()
+
+	req.res <- pathAPIPathsConformanceRes{analyzer: a}
+}
+
+func (pa *path) doAPIPathsDrain(req pathAPIPathsDrainReq) {
+	defer close(req.res)
+
+	if !req.drain {
+		if pa.draining {
+			pa.Log(logger.Info, "not draining anymore")
+			pa.draining = false
+			pa.drainTimer.Stop()
+		}
+		return
+	}
+
+	if pa.draining {
+		return
+	}
+
+	pa.Log(logger.Info, "draining, readers will be closed in %v", req.timeout)
+	pa.draining = true
+	pa.drainTimer = time.NewTimer(req.timeout)
+
+	for r := range pa.readers {
+		if dr, ok := r.(defs.DrainableReader); ok {
+			dr.Drain()
+		}
+	}
+}
+
+func (pa *path) doAuthenticatedSessions(req pathAuthenticatedSessionsReq) {
+	var sessions []pathAuthenticatedSession
+
+	if pa.publisherAccessRequest != nil {
+		sessions = append(sessions, pathAuthenticatedSession{
+			author:        pa.source.(defs.Publisher),
+			accessRequest: *pa.publisherAccessRequest,
+		})
+	}
+
+	for r, accessRequest := range pa.readerAccessRequests {
+		sessions = append(sessions, pathAuthenticatedSession{
+			author:        r,
+			accessRequest: accessRequest,
+		})
+	}
+
+	req.res <- sessions
+}
+
+func (pa *path) doDrainTimer() {
+	if len(pa.readers) != 0 {
+		pa.Log(logger.Info, "closing %d %s after drain",
+			len(pa.readers),
+			func() string {
+				if len(pa.readers) == 1 {
+					return "reader"
+				}
+				return "readers"
+			}())
+	}
+
+	for r := range pa.readers {
+		pa.executeRemoveReader(r)
+		r.Close()
+	}
+
+	pa.scheduleOnDemandCloseIfUnused()
+}
+
+func (pa *path) doCodecChange(req pathCodecChangeReq) {
+	// notification sent by a stream that has already been closed
+	if pa.stream == nil || req.stream != pa.stream {
+		return
+	}
+
+	switch pa.conf.CodecChangeBehavior {
+	case conf.CodecChangeBehaviorDisconnectReaders:
+		pa.Log(logger.Info, "parameters of %s track changed, closing %d %s",
+			req.format.Codec(),
+			len(pa.readers),
+			func() string {
+				if len(pa.readers) == 1 {
+					return "reader"
+				}
+				return "readers"
+			}())
+
+		for r := range pa.readers {
+			pa.executeRemoveReader(r)
+			r.Close()
+		}
+
+		pa.scheduleOnDemandCloseIfUnused()
+
+	case conf.CodecChangeBehaviorRestartPath:
+		pa.Log(logger.Info, "parameters of %s track changed, restarting path", req.format.Codec())
+
+		if pa.conf.HasStaticSource() {
+			pa.setNotReady()
+
+			if pa.conf.HasOnDemandStaticSource() {
+				query := pa.source.(*staticSourceHandler).query
+				pa.onDemandStaticSourceStop("codec parameters changed")
+				pa.onDemandStaticSourceStart(query)
+			} else {
+				pa.source.(*staticSourceHandler).stop("codec parameters changed")
+				pa.source.(*staticSourceHandler).start(false, "")
+			}
+		} else {
+			pa.source.(defs.Publisher).Close()
+			pa.executeRemovePublisher()
+		}
+
+	default:
+		pa.Log(logger.Debug, "parameters of %s track changed", req.format.Codec())
+	}
+}
+
+// Subtitles returns the subtitle track, or nil if subtitles are disabled.
+func (pa *path) Subtitles() *subtitles.Track {
+	return pa.subtitles
+}
+
 func (pa *path) SafeConf() *conf.Path {
 	pa.confMutex.RLock()
 	defer pa.confMutex.RUnlock()
@@ -642,6 +1142,7 @@ func (pa *path) ExternalCmdEnv() externalcmd.Environment {
 func (pa *path) shouldClose() bool {
 	return pa.conf.Regexp != nil &&
 		pa.source == nil &&
+		pa.stream == nil &&
 		len(pa.readers) == 0 &&
 		len(pa.describeRequestsOnHold) == 0 &&
 		len(pa.readerAddRequestsOnHold) == 0
@@ -656,9 +1157,18 @@ func (pa *path) onDemandStaticSourceStart(query string) {
 	pa.onDemandStaticSourceState = pathOnDemandStateWaitingReady
 }
 
-func (pa *path) onDemandStaticSourceScheduleClose() {
+// onDemandStaticSourceLinger returns the time the source is kept open
+// after the last reader disconnects.
+func (pa *path) onDemandStaticSourceLinger() time.Duration {
+	if pa.conf.SourceOnDemandLinger != 0 {
+		return time.Duration(pa.conf.SourceOnDemandLinger)
+	}
+	return time.Duration(pa.conf.SourceOnDemandCloseAfter)
+}
+
+func (pa *path) onDemandStaticSourceScheduleClose(closeAfter time.Duration) {
 	pa.onDemandStaticSourceCloseTimer.Stop()
-	pa.onDemandStaticSourceCloseTimer = time.NewTimer(time.Duration(pa.conf.SourceOnDemandCloseAfter))
+	pa.onDemandStaticSourceCloseTimer = time.NewTimer(closeAfter)
 
 	pa.onDemandStaticSourceState = pathOnDemandStateClosing
 }
@@ -708,22 +1218,97 @@ func (pa *path) onDemandPublisherStop(reason string) {
 	pa.onDemandPublisherState = pathOnDemandStateInitial
 }
 
+func (pa *path) loadWatermark(desc *description.Session) *watermark.Watermark {
+	if pa.conf.WatermarkImage == "" && pa.conf.WatermarkText == "" {
+		return nil
+	}
+
+	wm, err := watermark.Load(pa.conf.WatermarkImage, pa.conf.WatermarkText, pa.conf.WatermarkPosition, pa.name)
+	if err != nil {
+		pa.Log(logger.Error, "unable to load watermark: %v", err)
+		return nil
+	}
+
+	for _, media := range desc.Medias {
+		for _, forma := range media.Formats {
+			if _, ok := forma.(*format.MJPEG); !ok && media.Type == description.MediaTypeVideo {
+				pa.Log(logger.Warn, "watermark can't be applied to %s tracks, since the server doesn't transcode",
+					forma.Codec())
+			}
+		}
+	}
+
+	return wm
+}
+
 func (pa *path) setReady(desc *description.Session, allocateEncoder bool) error {
+	var strm *stream.Stream
+
+	// called by the goroutine of the publisher, that receives the stream
+	// after it has been assigned.
+	onParametersChange := func(forma format.Format) {
+		select {
+		case pa.chCodecChange <- pathCodecChangeReq{stream: strm, format: forma}:
+		default:
+		}
+	}
+
 	var err error
-	pa.stream, err = stream.New(
+	strm, err = stream.New(
 		pa.udpMaxPayloadSize,
 		desc,
 		allocateEncoder,
+		formatprocessor.Options{
+			SanitizeBitstream:   pa.conf.SanitizeBitstream,
+			InsertParameterSets: pa.conf.InsertParameterSets,
+			LPCMLittleEndian:    pa.conf.LPCMLittleEndian,
+			LPCMBitDepth:        pa.conf.LPCMBitDepth,
+			LPCMChannelMap:      pa.conf.LPCMChannelMap,
+			Watermark:           pa.loadWatermark(desc),
+			OnParametersChange:  onParametersChange,
+		},
 		logger.NewLimitedLogger(pa.source),
 	)
 	if err != nil {
 		return err
 	}
 
+	pa.stream = strm
+
+	if pa.conf.ClockSource == conf.ClockSourceServerClock {
+		pa.stream.UseServerClock()
+	}
+
+	if pa.conf.AudioGapFilling {
+		pa.stream.FillAudioGaps(time.Duration(pa.conf.AudioGapFillingThreshold))
+	}
+
 	if pa.conf.Record {
 		pa.startRecording()
 	}
 
+	if pa.conf.AudioMeter {
+		pa.startAudioMeter()
+	}
+
+	if pa.conf.VideoMonitor {
+		pa.startVideoMonitor()
+	}
+
+	pa.startGOPMonitor()
+
+	if pa.conf.RTPOutputAddress != "" {
+		pa.startRTPOutput()
+	}
+
+	if pa.conf.CMAFPushURL != "" {
+		pa.startCMAFPush()
+	}
+
+	if pa.capture != nil {
+		pa.capture.Attach(pa.stream)
+	}
+
 	pa.readyTime = time.Now()
 
 	pa.onNotReadyHook = hooks.OnReady(hooks.OnReadyParams{
@@ -732,6 +1317,7 @@ func (pa *path) setReady(desc *description.Session, allocateEncoder bool) error
 		Conf:            pa.conf,
 		ExternalCmdEnv:  pa.ExternalCmdEnv(),
 		Desc:            pa.source.APISourceDescribe(),
+		Medias:          desc.Medias,
 		Query:           pa.publisherQuery,
 	})
 
@@ -767,6 +1353,39 @@ func (pa *path) setNotReady() {
 	if pa.recorder != nil {
 		pa.recorder.Close()
 		pa.recorder = nil
+
+		if pa.subtitles != nil {
+			pa.subtitles.SetRecordingFile("", time.Time{})
+		}
+	}
+
+	if pa.audioMeter != nil {
+		pa.audioMeter.Close()
+		pa.audioMeter = nil
+	}
+
+	if pa.videoMonitor != nil {
+		pa.videoMonitor.Close()
+		pa.videoMonitor = nil
+	}
+
+	if pa.gopMonitor != nil {
+		pa.gopMonitor.Close()
+		pa.gopMonitor = nil
+	}
+
+	if pa.rtpOutput != nil {
+		pa.rtpOutput.Close()
+		pa.rtpOutput = nil
+	}
+
+	if pa.cmafPush != nil {
+		pa.cmafPush.Close()
+		pa.cmafPush = nil
+	}
+
+	if pa.capture != nil {
+		pa.capture.Detach()
 	}
 
 	if pa.stream != nil {
@@ -782,9 +1401,16 @@ func (pa *path) startRecording() {
 		Format:          pa.conf.RecordFormat,
 		PartDuration:    time.Duration(pa.conf.RecordPartDuration),
 		SegmentDuration: time.Duration(pa.conf.RecordSegmentDuration),
+		DataTracks:      pa.conf.RecordDataTracks,
+		Storage:         recordstorage.New(pa.conf, time.Duration(pa.readTimeout), time.Duration(pa.writeTimeout)),
 		PathName:        pa.name,
 		Stream:          pa.stream,
 		OnSegmentCreate: func(segmentPath string) {
+			// subtitles are appended to a local file
+			if pa.subtitles != nil && pa.conf.RecordStorage == conf.RecordStorageLocal {
+				pa.subtitles.SetRecordingFile(recordstore.SubtitlesPath(segmentPath), time.Now())
+			}
+
 			if pa.conf.RunOnRecordSegmentCreate != "" {
 				env := pa.ExternalCmdEnv()
 				env["MTX_SEGMENT_PATH"] = segmentPath
@@ -799,11 +1425,15 @@ func (pa *path) startRecording() {
 			}
 		},
 		OnSegmentComplete: func(segmentPath string, segmentDuration time.Duration) {
-			if pa.conf.RunOnRecordSegmentComplete != "" {
-				env := pa.ExternalCmdEnv()
-				env["MTX_SEGMENT_PATH"] = segmentPath
-				env["MTX_SEGMENT_DURATION"] = strconv.FormatFloat(segmentDuration.Seconds(), 'f', -1, 64)
+			env := pa.ExternalCmdEnv()
+			env["MTX_SEGMENT_PATH"] = segmentPath
+			env["MTX_SEGMENT_DURATION"] = strconv.FormatFloat(segmentDuration.Seconds(), 'f', -1, 64)
 
+			if pa.conf.RecordPostProcess != "" {
+				pa.recordProcessor.Process(pa.conf.RecordPostProcess, env, segmentPath)
+			}
+
+			if pa.conf.RunOnRecordSegmentComplete != "" {
 				pa.Log(logger.Info, "runOnRecordSegmentComplete command launched")
 				externalcmd.NewCmd(
 					pa.externalCmdPool,
@@ -818,8 +1448,194 @@ func (pa *path) startRecording() {
 	pa.recorder.Initialize()
 }
 
+func (pa *path) startAudioMeter() {
+	pa.audioMeter = &audiometer.Meter{
+		WriteQueueSize:   pa.writeQueueSize,
+		Stream:           pa.stream,
+		SilenceThreshold: pa.conf.AudioSilenceThreshold,
+		SilenceDuration:  time.Duration(pa.conf.AudioSilenceDuration),
+		OnSilence: func() {
+			if pa.conf.RunOnAudioSilence != "" {
+				pa.Log(logger.Info, "runOnAudioSilence command launched")
+				externalcmd.NewCmd(
+					pa.externalCmdPool,
+					pa.conf.RunOnAudioSilence,
+					false,
+					pa.ExternalCmdEnv(),
+					nil)
+			}
+		},
+		Parent: pa,
+	}
+	pa.audioMeter.Initialize()
+}
+
+func (pa *path) startVideoMonitor() {
+	pa.videoMonitor = &videomonitor.Monitor{
+		WriteQueueSize: pa.writeQueueSize,
+		Stream:         pa.stream,
+		Duration:       time.Duration(pa.conf.VideoMonitorDuration),
+		OnFrozen: func() {
+			if pa.conf.RunOnVideoFrozen != "" {
+				pa.Log(logger.Info, "runOnVideoFrozen command launched")
+				externalcmd.NewCmd(
+					pa.externalCmdPool,
+					pa.conf.RunOnVideoFrozen,
+					false,
+					pa.ExternalCmdEnv(),
+					nil)
+			}
+		},
+		OnBlack: func() {
+			if pa.conf.RunOnVideoBlack != "" {
+				pa.Log(logger.Info, "runOnVideoBlack command launched")
+				externalcmd.NewCmd(
+					pa.externalCmdPool,
+					pa.conf.RunOnVideoBlack,
+					false,
+					pa.ExternalCmdEnv(),
+					nil)
+			}
+		},
+		Parent: pa,
+	}
+	pa.videoMonitor.Initialize()
+}
+
+func (pa *path) startRTPOutput() {
+	o := &rtpoutput.Output{
+		Address:        pa.conf.RTPOutputAddress,
+		SDPPath:        strings.ReplaceAll(pa.conf.RTPOutputSDPPath, "%path", pa.name),
+		WriteQueueSize: pa.writeQueueSize,
+		Stream:         pa.stream,
+		Parent:         pa,
+	}
+	err := o.Initialize()
+	if err != nil {
+		pa.Log(logger.Error, "unable to start RTP output: %v", err)
+		return
+	}
+	pa.rtpOutput = o
+}
+
+func (pa *path) startCMAFPush() {
+	pa.cmafPush = &cmafpush.Output{
+		URL:             strings.ReplaceAll(pa.conf.CMAFPushURL, "%path", pa.name),
+		Method:          pa.conf.CMAFPushMethod,
+		User:            pa.conf.CMAFPushUser,
+		Pass:            pa.conf.CMAFPushPass,
+		SegmentDuration: time.Duration(pa.conf.CMAFPushSegmentDuration),
+		ChunkDuration:   time.Duration(pa.conf.CMAFPushChunkDuration),
+		Playlist:        pa.conf.CMAFPushPlaylist,
+		Timeout:         time.Duration(pa.readTimeout),
+		WriteQueueSize:  pa.writeQueueSize,
+		PathName:        pa.name,
+		Stream:          pa.stream,
+		Parent:          pa,
+	}
+	pa.cmafPush.Initialize()
+}
+
+func (pa *path) startGOPMonitor() {
+	pa.gopMonitor = &gopmonitor.Monitor{
+		WriteQueueSize:      pa.writeQueueSize,
+		Stream:              pa.stream,
+		MaxKeyframeInterval: time.Duration(pa.conf.MaxKeyframeInterval),
+		OnOpenGOP: func() {
+			if pa.conf.RunOnOpenGOP != "" {
+				pa.Log(logger.Info, "runOnOpenGOP command launched")
+				externalcmd.NewCmd(
+					pa.externalCmdPool,
+					pa.conf.RunOnOpenGOP,
+					false,
+					pa.ExternalCmdEnv(),
+					nil)
+			}
+		},
+		OnLongKeyframeInterval: func() {
+			if pa.conf.RunOnLongKeyframeInterval != "" {
+				pa.Log(logger.Info, "runOnLongKeyframeInterval command launched")
+				externalcmd.NewCmd(
+					pa.externalCmdPool,
+					pa.conf.RunOnLongKeyframeInterval,
+					false,
+					pa.ExternalCmdEnv(),
+					nil)
+			}
+		},
+		Parent: pa,
+	}
+	pa.gopMonitor.Initialize()
+}
+
+func isWHEPReader(r defs.Reader) bool {
+	return r.APIReaderDescribe().Type == "webrtcSession"
+}
+
+func (pa *path) whepReaderCount() int {
+	n := 0
+	for r := range pa.readers {
+		if isWHEPReader(r) {
+			n++
+		}
+	}
+	return n
+}
+
 func (pa *path) executeRemoveReader(r defs.Reader) {
+	info := pa.readers[r]
 	delete(pa.readers, r)
+	delete(pa.readerAccessRequests, r)
+	pa.unregisterBandwidth(r)
+
+	if t, ok := pa.readerDurationTimers[r]; ok {
+		t.Stop()
+		delete(pa.readerDurationTimers, r)
+	}
+
+	if len(pa.readers) == 0 && pa.onLastReaderHook != nil {
+		pa.onLastReaderHook(info)
+		pa.onLastReaderHook = nil
+	}
+}
+
+func publisherResumeToken(query string) string {
+	v, _ := url.ParseQuery(query)
+	return v.Get("resumeToken")
+}
+
+func (pa *path) canResumePublisher() bool {
+	_, isPublisher := pa.source.(defs.Publisher)
+	return isPublisher &&
+		pa.conf.PublisherResumeTimeout != 0 &&
+		pa.publisherResumeToken != "" &&
+		pa.stream != nil
+}
+
+// isWaitingPublisherResume returns whether the stream is kept alive
+// while waiting for a disconnected publisher to resume.
+func (pa *path) isWaitingPublisherResume() bool {
+	return pa.publisherResuming
+}
+
+func (pa *path) waitPublisherResume() {
+	pa.Log(logger.Info, "publisher disconnected, waiting %v for it to resume",
+		time.Duration(pa.conf.PublisherResumeTimeout))
+
+	pa.stopPublisherIdleTimer()
+	pa.unregisterBandwidth(pa.source)
+	pa.source = nil
+	pa.publisherAccessRequest = nil
+	pa.publisherResuming = true
+	pa.publisherResumeTimer.Stop()
+	pa.publisherResumeTimer = time.NewTimer(time.Duration(pa.conf.PublisherResumeTimeout))
+}
+
+func (pa *path) stopPublisherResume() {
+	pa.publisherResuming = false
+	pa.publisherResumeTimer.Stop()
+	pa.publisherResumeTimer = emptyTimer()
+	pa.setNotReady()
 }
 
 func (pa *path) executeRemovePublisher() {
@@ -827,7 +1643,27 @@ func (pa *path) executeRemovePublisher() {
 		pa.setNotReady()
 	}
 
+	pa.stopPublisherIdleTimer()
+	pa.unregisterBandwidth(pa.source)
 	pa.source = nil
+	pa.publisherAccessRequest = nil
+}
+
+func (pa *path) stopPublisherIdleTimer() {
+	pa.publisherIdleTimer.Stop()
+	pa.publisherIdleTimer = emptyTimer()
+}
+
+func (pa *path) registerBandwidth(user string, author interface{}) {
+	if c, ok := author.(bandwidth.Counter); ok {
+		pa.bandwidth.Register(user, c)
+	}
+}
+
+func (pa *path) unregisterBandwidth(author interface{}) {
+	if c, ok := author.(bandwidth.Counter); ok {
+		pa.bandwidth.Unregister(c)
+	}
 }
 
 func (pa *path) addReaderPost(req defs.PathAddReaderReq) {
@@ -844,7 +1680,43 @@ func (pa *path) addReaderPost(req defs.PathAddReaderReq) {
 		return
 	}
 
-	pa.readers[req.Author] = struct{}{}
+	if pa.conf.MaxWHEPReaders != 0 && isWHEPReader(req.Author) &&
+		pa.whepReaderCount() >= pa.conf.MaxWHEPReaders {
+		req.Res <- defs.PathAddReaderRes{Err: defs.PathMaxWHEPReadersError{PathName: pa.name}}
+		return
+	}
+
+	info := hooks.ReaderInfo{
+		Desc:     req.Author.APIReaderDescribe(),
+		Protocol: string(req.AccessRequest.Proto),
+		User:     req.AccessRequest.User,
+		Query:    req.AccessRequest.Query,
+	}
+	pa.readers[req.Author] = info
+	pa.registerBandwidth(req.AccessRequest.User, req.Author)
+
+	if !req.AccessRequest.SkipAuth {
+		pa.readerAccessRequests[req.Author] = req.AccessRequest
+	}
+
+	// the HLS muxer is shared among all HLS clients, therefore it can't be limited
+	if pa.conf.MaxReaderDuration != 0 && info.Desc.Type != "hlsMuxer" {
+		r := req.Author
+		pa.readerDurationTimers[r] = time.AfterFunc(time.Duration(pa.conf.MaxReaderDuration), func() {
+			pa.Log(logger.Info, "closing %s %s: maximum reader duration reached", info.Desc.Type, info.Desc.ID)
+			r.Close()
+		})
+	}
+
+	if len(pa.readers) == 1 {
+		pa.onLastReaderHook = hooks.OnFirstReader(hooks.OnFirstReaderParams{
+			Logger:          pa,
+			ExternalCmdPool: pa.externalCmdPool,
+			Conf:            pa.conf,
+			ExternalCmdEnv:  pa.ExternalCmdEnv(),
+			Reader:          info,
+		})
+	}
 
 	if pa.conf.HasOnDemandStaticSource() {
 		if pa.onDemandStaticSourceState == pathOnDemandStateClosing {
@@ -996,3 +1868,56 @@ func (pa *path) APIPathsGet(req pathAPIPathsGetReq) (*defs.APIPath, error) {
 		return nil, fmt.Errorf("terminated")
 	}
 }
+
+// APIPathsGetTracks is called by api.
+func (pa *path) APIPathsGetTracks(req pathAPIPathsGetTracksReq) (*defs.APIPathTrackList, error) {
+	req.res = make(chan pathAPIPathsGetTracksRes)
+	select {
+	case pa.chAPIPathsGetTracks <- req:
+		res := <-req.res
+		return res.data, res.err
+
+	case <-pa.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// APIPathsConformance is called by api.
+func (pa *path) APIPathsConformance(req pathAPIPathsConformanceReq) (*conformance.Analyzer, error) {
+	req.res = make(chan pathAPIPathsConformanceRes)
+	select {
+	case pa.chAPIPathsConformance <- req:
+		res := <-req.res
+		return res.analyzer, res.err
+
+	case <-pa.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// authenticatedSessions is called by pathManager.
+func (pa *path) authenticatedSessions() ([]pathAuthenticatedSession, error) {
+	req := pathAuthenticatedSessionsReq{
+		res: make(chan []pathAuthenticatedSession),
+	}
+	select {
+	case pa.chAuthenticatedSessions <- req:
+		return <-req.res, nil
+
+	case <-pa.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// APIPathsDrain is called by api.
+func (pa *path) APIPathsDrain(req pathAPIPathsDrainReq) error {
+	req.res = make(chan struct{})
+	select {
+	case pa.chAPIPathsDrain <- req:
+		<-req.res
+		return nil
+
+	case <-pa.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
@@ -3,15 +3,25 @@ package core
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/bandwidth"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/conformance"
+	"github.com/bluenviron/mediamtx/internal/coordinator"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/pathstate"
+	"github.com/bluenviron/mediamtx/internal/ptz"
+	"github.com/bluenviron/mediamtx/internal/recordprocessor"
+	"github.com/bluenviron/mediamtx/internal/rtpcapture"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/subtitles"
 )
 
 func pathConfCanBeUpdated(oldPathConf *conf.Path, newPathConf *conf.Path) bool {
@@ -19,6 +29,14 @@ func pathConfCanBeUpdated(oldPathConf *conf.Path, newPathConf *conf.Path) bool {
 
 	clone.Record = newPathConf.Record
 
+	clone.CodecChangeBehavior = newPathConf.CodecChangeBehavior
+
+	clone.SourceRetryDelay = newPathConf.SourceRetryDelay
+	clone.SourceRetryMultiplier = newPathConf.SourceRetryMultiplier
+	clone.SourceRetryMaxDelay = newPathConf.SourceRetryMaxDelay
+	clone.SourceRetryJitter = newPathConf.SourceRetryJitter
+	clone.SourceRetryMaxAttempts = newPathConf.SourceRetryMaxAttempts
+
 	clone.RPICameraBrightness = newPathConf.RPICameraBrightness
 	clone.RPICameraContrast = newPathConf.RPICameraContrast
 	clone.RPICameraSaturation = newPathConf.RPICameraSaturation
@@ -48,37 +66,35 @@ type pathManagerParent interface {
 	logger.Writer
 }
 
+// number of shards in which paths are split.
+const pathManagerShardCount = 16
+
 type pathManager struct {
-	logLevel          conf.LogLevel
-	authManager       *auth.Manager
-	rtspAddress       string
-	readTimeout       conf.StringDuration
-	writeTimeout      conf.StringDuration
-	writeQueueSize    int
-	udpMaxPayloadSize int
-	pathConfs         map[string]*conf.Path
-	externalCmdPool   *externalcmd.Pool
-	parent            pathManagerParent
-
-	ctx         context.Context
-	ctxCancel   func()
-	wg          sync.WaitGroup
-	hlsManager  pathManagerHLSServer
-	paths       map[string]*path
-	pathsByConf map[string]map[*path]struct{}
-
-	// in
-	chReloadConf   chan map[string]*conf.Path
-	chSetHLSServer chan pathManagerHLSServer
-	chClosePath    chan *path
-	chPathReady    chan *path
-	chPathNotReady chan *path
-	chFindPathConf chan defs.PathFindPathConfReq
-	chDescribe     chan defs.PathDescribeReq
-	chAddReader    chan defs.PathAddReaderReq
-	chAddPublisher chan defs.PathAddPublisherReq
-	chAPIPathsList chan pathAPIPathsListReq
-	chAPIPathsGet  chan pathAPIPathsGetReq
+	logLevel                     conf.LogLevel
+	authManager                  *auth.Manager
+	rtspAddress                  string
+	readTimeout                  conf.StringDuration
+	writeTimeout                 conf.StringDuration
+	writeQueueSize               int
+	udpMaxPayloadSize            int
+	staticSourceStartConcurrency int
+	publishPathRewrites          conf.PublishPathRewrites
+	pathConfs                    map[string]*conf.Path
+	externalCmdPool              *externalcmd.Pool
+	coordinator                  *coordinator.Coordinator
+	pathStateStore               *pathstate.Store
+	bandwidth                    *bandwidth.Accountant
+	recordProcessor              *recordprocessor.Processor
+	parent                       pathManagerParent
+
+	ctx            context.Context
+	ctxCancel      func()
+	wg             sync.WaitGroup
+	startLimiter   *staticSourceStartLimiter
+	pathConfsMutex sync.RWMutex
+	shards         []*pathManagerShard
+	reauthMutex    sync.Mutex
+	reauthTimer    *time.Timer
 }
 
 func (pm *pathManager) initialize() {
@@ -86,35 +102,55 @@ func (pm *pathManager) initialize() {
 
 	pm.ctx = ctx
 	pm.ctxCancel = ctxCancel
-	pm.paths = make(map[string]*path)
-	pm.pathsByConf = make(map[string]map[*path]struct{})
-	pm.chReloadConf = make(chan map[string]*conf.Path)
-	pm.chSetHLSServer = make(chan pathManagerHLSServer)
-	pm.chClosePath = make(chan *path)
-	pm.chPathReady = make(chan *path)
-	pm.chPathNotReady = make(chan *path)
-	pm.chFindPathConf = make(chan defs.PathFindPathConfReq)
-	pm.chDescribe = make(chan defs.PathDescribeReq)
-	pm.chAddReader = make(chan defs.PathAddReaderReq)
-	pm.chAddPublisher = make(chan defs.PathAddPublisherReq)
-	pm.chAPIPathsList = make(chan pathAPIPathsListReq)
-	pm.chAPIPathsGet = make(chan pathAPIPathsGetReq)
+
+	pm.startLimiter = &staticSourceStartLimiter{
+		concurrency: pm.staticSourceStartConcurrency,
+	}
+	pm.startLimiter.initialize()
+
+	pm.shards = make([]*pathManagerShard, pathManagerShardCount)
+
+	for i := range pm.shards {
+		pm.shards[i] = &pathManagerShard{
+			pathConfs: pm.pathConfs,
+			parent:    pm,
+		}
+		pm.shards[i].initialize()
+	}
+
+	if pm.pathStateStore != nil {
+		for _, name := range pm.pathStateStore.ActivePaths() {
+			pm.shardFor(name).restoredPaths[name] = struct{}{}
+		}
+	}
 
 	for _, pathConf := range pm.pathConfs {
 		if pathConf.Regexp == nil {
-			pm.createPath(pathConf, pathConf.Name, nil)
+			pm.shardFor(pathConf.Name).createPath(pathConf, pathConf.Name, nil)
 		}
 	}
 
+	for _, sh := range pm.shards {
+		sh.restorePaths()
+	}
+
 	pm.Log(logger.Debug, "path manager created")
 
-	pm.wg.Add(1)
-	go pm.run()
+	for _, sh := range pm.shards {
+		pm.wg.Add(1)
+		go sh.run()
+	}
 }
 
 func (pm *pathManager) close() {
 	pm.Log(logger.Debug, "path manager is shutting down")
 	pm.ctxCancel()
+
+	pm.reauthMutex.Lock()
+	if pm.reauthTimer != nil {
+		pm.reauthTimer.Stop()
+	}
+	pm.reauthMutex.Unlock()
 	pm.wg.Wait()
 }
 
@@ -123,417 +159,544 @@ func (pm *pathManager) Log(level logger.Level, format string, args ...interface{
 	pm.parent.Log(level, format, args...)
 }
 
-func (pm *pathManager) run() {
-	defer pm.wg.Done()
+// shardFor returns the shard that owns the path with the given name.
+func (pm *pathManager) shardFor(name string) *pathManagerShard {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return pm.shards[h.Sum32()%uint32(len(pm.shards))]
+}
 
-outer:
-	for {
-		select {
-		case newPaths := <-pm.chReloadConf:
-			pm.doReloadConf(newPaths)
+func (pm *pathManager) getPathConfs() map[string]*conf.Path {
+	pm.pathConfsMutex.RLock()
+	defer pm.pathConfsMutex.RUnlock()
+	return pm.pathConfs
+}
 
-		case m := <-pm.chSetHLSServer:
-			pm.doSetHLSServer(m)
+func checkPathProtocol(pathConf *conf.Path, req defs.PathAccessRequest) error {
+	// requests performed internally don't have a protocol
+	if req.Proto == "" {
+		return nil
+	}
 
-		case pa := <-pm.chClosePath:
-			pm.doClosePath(pa)
+	var allowed conf.PathProtocols
+	if req.Publish {
+		allowed = pathConf.PublishProtocols
+	} else {
+		allowed = pathConf.ReadProtocols
+	}
 
-		case pa := <-pm.chPathReady:
-			pm.doPathReady(pa)
+	if !allowed.Allows(string(req.Proto)) {
+		return defs.PathProtocolNotAllowedError{
+			PathName: req.Name,
+			Protocol: string(req.Proto),
+			Publish:  req.Publish,
+		}
+	}
 
-		case pa := <-pm.chPathNotReady:
-			pm.doPathNotReady(pa)
+	return nil
+}
 
-		case req := <-pm.chFindPathConf:
-			pm.doFindPathConf(req)
+// checkPathUserAgent applies user agent rules.
+// It returns the rule that routes the client to another path, if any.
+func checkPathUserAgent(pathConf *conf.Path, req defs.PathAccessRequest) (*conf.UserAgentRule, error) {
+	// requests performed internally don't have a protocol
+	if req.Proto == "" {
+		return nil, nil
+	}
 
-		case req := <-pm.chDescribe:
-			pm.doDescribe(req)
+	r := pathConf.UserAgentRules.Find(req.UserAgent)
+	if r == nil {
+		return nil, nil
+	}
 
-		case req := <-pm.chAddReader:
-			pm.doAddReader(req)
+	switch r.Action {
+	case conf.UserAgentRuleActionDeny:
+		return nil, defs.PathUserAgentNotAllowedError{
+			PathName:  req.Name,
+			UserAgent: req.UserAgent,
+		}
 
-		case req := <-pm.chAddPublisher:
-			pm.doAddPublisher(req)
+	case conf.UserAgentRuleActionRoute:
+		// only readers can be routed
+		if !req.Publish {
+			return r, nil
+		}
+	}
 
-		case req := <-pm.chAPIPathsList:
-			pm.doAPIPathsList(req)
+	return nil, nil
+}
 
-		case req := <-pm.chAPIPathsGet:
-			pm.doAPIPathsGet(req)
+// findReaderPathConf finds the configuration of the path requested by a reader,
+// routing the reader to another path when a user agent rule says so.
+func (pm *pathManager) findReaderPathConf(req *defs.PathAccessRequest) (*conf.Path, error) {
+	pathConfs := pm.getPathConfs()
+
+	pathConf, _, err := conf.FindPathConf(pathConfs, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkPathProtocol(pathConf, *req)
+	if err != nil {
+		return nil, err
+	}
+
+	route, err := checkPathUserAgent(pathConf, *req)
+	if err != nil {
+		return nil, err
+	}
 
-		case <-pm.ctx.Done():
-			break outer
+	if route != nil {
+		req.Name = route.Path
+
+		pathConf, _, err = conf.FindPathConf(pathConfs, req.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkPathProtocol(pathConf, *req)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	pm.ctxCancel()
+	return pathConf, nil
 }
 
-func (pm *pathManager) doReloadConf(newPaths map[string]*conf.Path) {
-	for confName, pathConf := range pm.pathConfs {
-		if newPath, ok := newPaths[confName]; ok {
-			// configuration has changed
-			if !newPath.Equal(pathConf) {
-				if pathConfCanBeUpdated(pathConf, newPath) { // paths associated with the configuration can be updated
-					for pa := range pm.pathsByConf[confName] {
-						go pa.reloadConf(newPath)
-					}
-				} else { // paths associated with the configuration must be recreated
-					for pa := range pm.pathsByConf[confName] {
-						pm.removePath(pa)
-						pa.close()
-						pa.wait() // avoid conflicts between sources
-					}
-				}
-			}
-		} else {
-			// configuration has been deleted, remove associated paths
-			for pa := range pm.pathsByConf[confName] {
-				pm.removePath(pa)
-				pa.close()
-				pa.wait() // avoid conflicts between sources
-			}
+// findPublisherPathConf finds the configuration of the path requested by a publisher,
+// rewriting the path name when a publish path rewrite says so.
+func (pm *pathManager) findPublisherPathConf(req *defs.PathAccessRequest) (*conf.Path, error) {
+	// requests performed internally don't have a protocol and are not rewritten
+	if req.Publish && req.Proto != "" {
+		name := pm.publishPathRewrites.Apply(req.Name, string(req.Proto))
+		if name != req.Name {
+			pm.Log(logger.Debug, "publisher path '%s' rewritten to '%s'", req.Name, name)
+			req.Name = name
 		}
 	}
 
-	pm.pathConfs = newPaths
+	pathConf, _, err := conf.FindPathConf(pm.getPathConfs(), req.Name)
+	if err != nil {
+		return nil, err
+	}
 
-	// add new paths
-	for pathConfName, pathConf := range pm.pathConfs {
-		if _, ok := pm.paths[pathConfName]; !ok && pathConf.Regexp == nil {
-			pm.createPath(pathConf, pathConfName, nil)
-		}
+	err = checkPathProtocol(pathConf, *req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = checkPathUserAgent(pathConf, *req)
+	if err != nil {
+		return nil, err
 	}
+
+	return pathConf, nil
 }
 
-func (pm *pathManager) doSetHLSServer(m pathManagerHLSServer) {
-	pm.hlsManager = m
+func (pm *pathManager) findPath(name string) (*path, error) {
+	return pm.shardFor(name).apiPathsGet(name)
 }
 
-func (pm *pathManager) doClosePath(pa *path) {
-	if pmpa, ok := pm.paths[pa.name]; !ok || pmpa != pa {
-		return
+// APIPathsSubtitlesAdd is called by api.
+func (pm *pathManager) APIPathsSubtitlesAdd(name string, cues []subtitles.Cue) error {
+	pa, err := pm.findPath(name)
+	if err != nil {
+		return err
 	}
-	pm.removePath(pa)
-}
 
-func (pm *pathManager) doPathReady(pa *path) {
-	if pm.hlsManager != nil {
-		pm.hlsManager.PathReady(pa)
+	if pa.subtitles == nil {
+		return subtitles.ErrDisabled
 	}
+
+	return pa.subtitles.Add(cues)
 }
 
-func (pm *pathManager) doPathNotReady(pa *path) {
-	if pm.hlsManager != nil {
-		pm.hlsManager.PathNotReady(pa)
+// APIPathsGetTracks is called by api.
+func (pm *pathManager) APIPathsGetTracks(name string) (*defs.APIPathTrackList, error) {
+	pa, err := pm.findPath(name)
+	if err != nil {
+		return nil, err
 	}
+
+	return pa.APIPathsGetTracks(pathAPIPathsGetTracksReq{})
 }
 
-func (pm *pathManager) doFindPathConf(req defs.PathFindPathConfReq) {
-	pathConf, _, err := conf.FindPathConf(pm.pathConfs, req.AccessRequest.Name)
+// APIPathsConformanceGet is called by api.
+func (pm *pathManager) APIPathsConformanceGet(name string, duration time.Duration) (*defs.APIPathConformance, error) {
+	pa, err := pm.findPath(name)
 	if err != nil {
-		req.Res <- defs.PathFindPathConfRes{Err: err}
-		return
+		return nil, err
 	}
 
-	err = pm.authManager.Authenticate(req.AccessRequest.ToAuthRequest())
+	analyzer, err := pa.APIPathsConformance(pathAPIPathsConformanceReq{})
 	if err != nil {
-		req.Res <- defs.PathFindPathConfRes{Err: err}
-		return
+		return nil, err
 	}
+	defer analyzer.Close()
 
-	req.Res <- defs.PathFindPathConfRes{Conf: pathConf}
+	select {
+	case <-time.After(duration):
+	case <-pm.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+
+	return conformanceReportToAPI(analyzer.Report()), nil
 }
 
-func (pm *pathManager) doDescribe(req defs.PathDescribeReq) {
-	pathConf, pathMatches, err := conf.FindPathConf(pm.pathConfs, req.AccessRequest.Name)
-	if err != nil {
-		req.Res <- defs.PathDescribeRes{Err: err}
-		return
+func durationToAPI(d *time.Duration) *conf.StringDuration {
+	if d == nil {
+		return nil
 	}
+	v := conf.StringDuration(*d)
+	return &v
+}
 
-	err = pm.authManager.Authenticate(req.AccessRequest.ToAuthRequest())
-	if err != nil {
-		req.Res <- defs.PathDescribeRes{Err: err}
-		return
+func conformanceReportToAPI(r *conformance.Report) *defs.APIPathConformance {
+	ret := &defs.APIPathConformance{
+		Duration: conf.StringDuration(r.Duration),
+		Tracks:   make([]defs.APIPathConformanceTrack, len(r.Tracks)),
 	}
 
-	// create path if it doesn't exist
-	if _, ok := pm.paths[req.AccessRequest.Name]; !ok {
-		pm.createPath(pathConf, req.AccessRequest.Name, pathMatches)
+	for i, t := range r.Tracks {
+		ret.Tracks[i] = defs.APIPathConformanceTrack{
+			Type:                t.Type,
+			Codec:               t.Codec,
+			Units:               t.Units,
+			Keyframes:           t.Keyframes,
+			MinKeyframeInterval: durationToAPI(t.MinKeyframeInterval),
+			MaxKeyframeInterval: durationToAPI(t.MaxKeyframeInterval),
+			BFrames:             t.BFrames,
+			ParameterChanges:    t.ParameterChanges,
+			AudioGaps:           t.AudioGaps,
+			MaxAudioGap:         durationToAPI(t.MaxAudioGap),
+			TimestampJitter:     conf.StringDuration(t.TimestampJitter),
+			Issues:              t.Issues,
+		}
 	}
 
-	req.Res <- defs.PathDescribeRes{Path: pm.paths[req.AccessRequest.Name]}
+	return ret
+}
+
+// APIPathsDrain is called by api.
+func (pm *pathManager) APIPathsDrain(name string, timeout time.Duration) error {
+	return pm.drainPath(name, true, timeout)
+}
+
+// APIPathsUndrain is called by api.
+func (pm *pathManager) APIPathsUndrain(name string) error {
+	return pm.drainPath(name, false, 0)
 }
 
-func (pm *pathManager) doAddReader(req defs.PathAddReaderReq) {
-	pathConf, pathMatches, err := conf.FindPathConf(pm.pathConfs, req.AccessRequest.Name)
+func (pm *pathManager) drainPath(name string, drain bool, timeout time.Duration) error {
+	pa, err := pm.findPath(name)
 	if err != nil {
-		req.Res <- defs.PathAddReaderRes{Err: err}
-		return
+		return err
 	}
 
-	if !req.AccessRequest.SkipAuth {
-		err = pm.authManager.Authenticate(req.AccessRequest.ToAuthRequest())
-		if err != nil {
-			req.Res <- defs.PathAddReaderRes{Err: err}
-			return
-		}
-	}
+	return pa.APIPathsDrain(pathAPIPathsDrainReq{
+		drain:   drain,
+		timeout: timeout,
+	})
+}
 
-	// create path if it doesn't exist
-	if _, ok := pm.paths[req.AccessRequest.Name]; !ok {
-		pm.createPath(pathConf, req.AccessRequest.Name, pathMatches)
+// APIPathsPTZGet is called by api.
+func (pm *pathManager) APIPathsPTZGet(name string) (*defs.APIPathPTZ, error) {
+	tour, err := pm.findPTZTour(name)
+	if err != nil {
+		return nil, err
 	}
 
-	req.Res <- defs.PathAddReaderRes{Path: pm.paths[req.AccessRequest.Name]}
+	return tour.APIPTZGet(), nil
 }
 
-func (pm *pathManager) doAddPublisher(req defs.PathAddPublisherReq) {
-	pathConf, pathMatches, err := conf.FindPathConf(pm.pathConfs, req.AccessRequest.Name)
+// APIPathsPTZTourStart is called by api.
+func (pm *pathManager) APIPathsPTZTourStart(name string) error {
+	tour, err := pm.findPTZTour(name)
 	if err != nil {
-		req.Res <- defs.PathAddPublisherRes{Err: err}
-		return
+		return err
 	}
 
-	if !req.AccessRequest.SkipAuth {
-		err = pm.authManager.Authenticate(req.AccessRequest.ToAuthRequest())
-		if err != nil {
-			req.Res <- defs.PathAddPublisherRes{Err: err}
-			return
-		}
+	return tour.Start()
+}
+
+// APIPathsPTZTourStop is called by api.
+func (pm *pathManager) APIPathsPTZTourStop(name string) error {
+	tour, err := pm.findPTZTour(name)
+	if err != nil {
+		return err
 	}
 
-	// create path if it doesn't exist
-	if _, ok := pm.paths[req.AccessRequest.Name]; !ok {
-		pm.createPath(pathConf, req.AccessRequest.Name, pathMatches)
+	tour.Stop()
+	return nil
+}
+
+// APIPathsPTZGotoPreset is called by api.
+func (pm *pathManager) APIPathsPTZGotoPreset(name string, preset string) error {
+	tour, err := pm.findPTZTour(name)
+	if err != nil {
+		return err
 	}
 
-	req.Res <- defs.PathAddPublisherRes{Path: pm.paths[req.AccessRequest.Name]}
+	return tour.GotoPreset(preset)
 }
 
-func (pm *pathManager) doAPIPathsList(req pathAPIPathsListReq) {
-	paths := make(map[string]*path)
+// APIPathsCaptureGet is called by api.
+func (pm *pathManager) APIPathsCaptureGet(name string) (*rtpcapture.Buffer, error) {
+	pa, err := pm.findPath(name)
+	if err != nil {
+		return nil, err
+	}
 
-	for name, pa := range pm.paths {
-		paths[name] = pa
+	if pa.capture == nil {
+		return nil, rtpcapture.ErrDisabled
 	}
 
-	req.res <- pathAPIPathsListRes{paths: paths}
+	return pa.capture, nil
 }
 
-func (pm *pathManager) doAPIPathsGet(req pathAPIPathsGetReq) {
-	path, ok := pm.paths[req.name]
-	if !ok {
-		req.res <- pathAPIPathsGetRes{err: conf.ErrPathNotFound}
-		return
+func (pm *pathManager) findPTZTour(name string) (*ptz.Tour, error) {
+	pa, err := pm.findPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if pa.ptzTour == nil {
+		return nil, ptz.ErrDisabled
 	}
 
-	req.res <- pathAPIPathsGetRes{path: path}
+	return pa.ptzTour, nil
 }
 
-func (pm *pathManager) createPath(
-	pathConf *conf.Path,
-	name string,
-	matches []string,
-) {
-	pa := &path{
-		parentCtx:         pm.ctx,
-		logLevel:          pm.logLevel,
-		rtspAddress:       pm.rtspAddress,
-		readTimeout:       pm.readTimeout,
-		writeTimeout:      pm.writeTimeout,
-		writeQueueSize:    pm.writeQueueSize,
-		udpMaxPayloadSize: pm.udpMaxPayloadSize,
-		conf:              pathConf,
-		name:              name,
-		matches:           matches,
-		wg:                &pm.wg,
-		externalCmdPool:   pm.externalCmdPool,
-		parent:            pm,
+// ReloadPathConfs is called by core.
+func (pm *pathManager) ReloadPathConfs(pathConfs map[string]*conf.Path) {
+	for _, sh := range pm.shards {
+		sh.reloadConf(pathConfs)
 	}
-	pa.initialize()
 
-	pm.paths[name] = pa
+	pm.pathConfsMutex.Lock()
+	pm.pathConfs = pathConfs
+	pm.pathConfsMutex.Unlock()
+}
+
+// scheduleReauthentication is called by core when credentials change.
+// After the grace period, existing publishers and readers are authenticated again
+// with the credentials they provided, and the ones that are not allowed anymore are closed.
+func (pm *pathManager) scheduleReauthentication(gracePeriod time.Duration) {
+	pm.reauthMutex.Lock()
+	defer pm.reauthMutex.Unlock()
 
-	if _, ok := pm.pathsByConf[pathConf.Name]; !ok {
-		pm.pathsByConf[pathConf.Name] = make(map[*path]struct{})
+	if pm.reauthTimer != nil {
+		pm.reauthTimer.Stop()
 	}
-	pm.pathsByConf[pathConf.Name][pa] = struct{}{}
+
+	pm.Log(logger.Info, "credentials changed, existing sessions will be authenticated again in %v", gracePeriod)
+
+	pm.reauthTimer = time.AfterFunc(gracePeriod, pm.reauthenticate)
 }
 
-func (pm *pathManager) removePath(pa *path) {
-	delete(pm.pathsByConf[pa.conf.Name], pa)
-	if len(pm.pathsByConf[pa.conf.Name]) == 0 {
-		delete(pm.pathsByConf, pa.conf.Name)
+func (pm *pathManager) reauthenticate() {
+	var sessions []pathAuthenticatedSession
+
+	for _, sh := range pm.shards {
+		paths, err := sh.apiPathsList()
+		if err != nil {
+			return
+		}
+
+		for _, pa := range paths {
+			pathSessions, err := pa.authenticatedSessions()
+			if err == nil {
+				sessions = append(sessions, pathSessions...)
+			}
+		}
 	}
-	delete(pm.paths, pa.name)
-}
 
-// ReloadPathConfs is called by core.
-func (pm *pathManager) ReloadPathConfs(pathConfs map[string]*conf.Path) {
-	select {
-	case pm.chReloadConf <- pathConfs:
-	case <-pm.ctx.Done():
+	closed := 0
+
+	for _, s := range sessions {
+		err := pm.authManager.Authenticate(s.accessRequest.ToAuthRequest())
+		if err != nil {
+			pm.Log(logger.Info, "closing session of path '%s': %v", s.accessRequest.Name, err)
+			s.author.Close()
+			closed++
+		}
 	}
+
+	pm.Log(logger.Info, "existing sessions authenticated again, %d closed", closed)
 }
 
 // pathReady is called by path.
 func (pm *pathManager) pathReady(pa *path) {
-	select {
-	case pm.chPathReady <- pa:
-	case <-pm.ctx.Done():
-	case <-pa.ctx.Done(): // in case pathManager is blocked by path.wait()
-	}
+	pm.shardFor(pa.name).pathReady(pa)
 }
 
 // pathNotReady is called by path.
 func (pm *pathManager) pathNotReady(pa *path) {
-	select {
-	case pm.chPathNotReady <- pa:
-	case <-pm.ctx.Done():
-	case <-pa.ctx.Done(): // in case pathManager is blocked by path.wait()
-	}
+	pm.shardFor(pa.name).pathNotReady(pa)
 }
 
 // closePath is called by path.
 func (pm *pathManager) closePath(pa *path) {
-	select {
-	case pm.chClosePath <- pa:
-	case <-pm.ctx.Done():
-	case <-pa.ctx.Done(): // in case pathManager is blocked by path.wait()
-	}
+	pm.shardFor(pa.name).closePath(pa)
 }
 
-// GetConfForPath is called by a reader or publisher.
+// FindPathConf is called by a reader or publisher.
 func (pm *pathManager) FindPathConf(req defs.PathFindPathConfReq) (*conf.Path, error) {
-	req.Res = make(chan defs.PathFindPathConfRes)
-	select {
-	case pm.chFindPathConf <- req:
-		res := <-req.Res
-		return res.Conf, res.Err
+	pathConf, err := pm.findPublisherPathConf(&req.AccessRequest)
+	if err != nil {
+		return nil, err
+	}
 
-	case <-pm.ctx.Done():
-		return nil, fmt.Errorf("terminated")
+	err = pm.authManager.Authenticate(req.AccessRequest.ToAuthRequest())
+	if err != nil {
+		return nil, err
 	}
+
+	return pathConf, nil
 }
 
 // Describe is called by a reader or publisher.
 func (pm *pathManager) Describe(req defs.PathDescribeReq) defs.PathDescribeRes {
-	req.Res = make(chan defs.PathDescribeRes)
-	select {
-	case pm.chDescribe <- req:
-		res1 := <-req.Res
-		if res1.Err != nil {
-			return res1
-		}
+	_, err := pm.findReaderPathConf(&req.AccessRequest)
+	if err != nil {
+		return defs.PathDescribeRes{Err: err}
+	}
 
-		res2 := res1.Path.(*path).describe(req)
-		if res2.Err != nil {
-			return res2
-		}
+	err = pm.authManager.Authenticate(req.AccessRequest.ToAuthRequest())
+	if err != nil {
+		return defs.PathDescribeRes{Err: err}
+	}
 
-		res2.Path = res1.Path
-		return res2
+	pa, err := pm.shardFor(req.AccessRequest.Name).addPath(req.AccessRequest.Name)
+	if err != nil {
+		return defs.PathDescribeRes{Err: err}
+	}
 
-	case <-pm.ctx.Done():
-		return defs.PathDescribeRes{Err: fmt.Errorf("terminated")}
+	req.Res = make(chan defs.PathDescribeRes)
+	res := pa.describe(req)
+	if res.Err != nil {
+		return res
 	}
+
+	res.Path = pa
+	return res
 }
 
 // AddPublisher is called by a publisher.
 func (pm *pathManager) AddPublisher(req defs.PathAddPublisherReq) (defs.Path, error) {
-	req.Res = make(chan defs.PathAddPublisherRes)
-	select {
-	case pm.chAddPublisher <- req:
-		res := <-req.Res
-		if res.Err != nil {
-			return nil, res.Err
+	_, err := pm.findPublisherPathConf(&req.AccessRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.AccessRequest.SkipAuth {
+		authReq := req.AccessRequest.ToAuthRequest()
+		err = pm.authManager.Authenticate(authReq)
+		if err != nil {
+			return nil, err
 		}
 
-		return res.Path.(*path).addPublisher(req)
+		// the user might have been filled during authentication
+		req.AccessRequest.User = authReq.User
+	}
 
-	case <-pm.ctx.Done():
-		return nil, fmt.Errorf("terminated")
+	pa, err := pm.shardFor(req.AccessRequest.Name).addPath(req.AccessRequest.Name)
+	if err != nil {
+		return nil, err
 	}
+
+	req.Res = make(chan defs.PathAddPublisherRes)
+	return pa.addPublisher(req)
 }
 
 // AddReader is called by a reader.
 func (pm *pathManager) AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
-	req.Res = make(chan defs.PathAddReaderRes)
-	select {
-	case pm.chAddReader <- req:
-		res := <-req.Res
-		if res.Err != nil {
-			return nil, nil, res.Err
+	_, err := pm.findReaderPathConf(&req.AccessRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !req.AccessRequest.SkipAuth {
+		authReq := req.AccessRequest.ToAuthRequest()
+		err = pm.authManager.Authenticate(authReq)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		return res.Path.(*path).addReader(req)
+		// the user might have been filled during authentication
+		req.AccessRequest.User = authReq.User
+	}
 
-	case <-pm.ctx.Done():
-		return nil, nil, fmt.Errorf("terminated")
+	pa, err := pm.shardFor(req.AccessRequest.Name).addPath(req.AccessRequest.Name)
+	if err != nil {
+		return nil, nil, err
 	}
+
+	req.Res = make(chan defs.PathAddReaderRes)
+	return pa.addReader(req)
 }
 
 // setHLSServer is called by hlsManager.
 func (pm *pathManager) setHLSServer(s pathManagerHLSServer) {
-	select {
-	case pm.chSetHLSServer <- s:
-	case <-pm.ctx.Done():
+	for _, sh := range pm.shards {
+		sh.setHLSServer(s)
 	}
 }
 
 // APIPathsList is called by api.
 func (pm *pathManager) APIPathsList() (*defs.APIPathList, error) {
-	req := pathAPIPathsListReq{
-		res: make(chan pathAPIPathsListRes),
-	}
+	items := make([][]*defs.APIPath, len(pm.shards))
+	errs := make([]error, len(pm.shards))
 
-	select {
-	case pm.chAPIPathsList <- req:
-		res := <-req.res
+	// shards are queried in parallel
+	var wg sync.WaitGroup
 
-		res.data = &defs.APIPathList{
-			Items: []*defs.APIPath{},
-		}
+	for i, sh := range pm.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-		for _, pa := range res.paths {
-			item, err := pa.APIPathsGet(pathAPIPathsGetReq{})
-			if err == nil {
-				res.data.Items = append(res.data.Items, item)
+			paths, err := sh.apiPathsList()
+			if err != nil {
+				errs[i] = err
+				return
 			}
-		}
 
-		sort.Slice(res.data.Items, func(i, j int) bool {
-			return res.data.Items[i].Name < res.data.Items[j].Name
-		})
+			for _, pa := range paths {
+				item, err := pa.APIPathsGet(pathAPIPathsGetReq{})
+				if err == nil {
+					items[i] = append(items[i], item)
+				}
+			}
+		}()
+	}
 
-		return res.data, nil
+	wg.Wait()
 
-	case <-pm.ctx.Done():
-		return nil, fmt.Errorf("terminated")
+	data := &defs.APIPathList{
+		Items: []*defs.APIPath{},
 	}
-}
 
-// APIPathsGet is called by api.
-func (pm *pathManager) APIPathsGet(name string) (*defs.APIPath, error) {
-	req := pathAPIPathsGetReq{
-		name: name,
-		res:  make(chan pathAPIPathsGetRes),
+	for i := range pm.shards {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		data.Items = append(data.Items, items[i]...)
 	}
 
-	select {
-	case pm.chAPIPathsGet <- req:
-		res := <-req.res
-		if res.err != nil {
-			return nil, res.err
-		}
+	sort.Slice(data.Items, func(i, j int) bool {
+		return data.Items[i].Name < data.Items[j].Name
+	})
 
-		data, err := res.path.APIPathsGet(req)
-		return data, err
+	return data, nil
+}
 
-	case <-pm.ctx.Done():
-		return nil, fmt.Errorf("terminated")
+// APIPathsGet is called by api.
+func (pm *pathManager) APIPathsGet(name string) (*defs.APIPath, error) {
+	pa, err := pm.findPath(name)
+	if err != nil {
+		return nil, err
 	}
+
+	return pa.APIPathsGet(pathAPIPathsGetReq{name: name})
 }
@@ -0,0 +1,360 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+type pathManagerShardAddPathRes struct {
+	path *path
+	err  error
+}
+
+type pathManagerShardAddPathReq struct {
+	name string
+	res  chan pathManagerShardAddPathRes
+}
+
+// pathManagerShard owns a subset of paths, selected by hashing their name.
+// Every shard has its own goroutine, therefore operations on paths
+// that belong to different shards don't block each other.
+type pathManagerShard struct {
+	pathConfs map[string]*conf.Path
+	parent    *pathManager
+
+	hlsManager  pathManagerHLSServer
+	paths       map[string]*path
+	pathsByConf map[string]map[*path]struct{}
+
+	// paths whose on-demand source has to be restored
+	restoredPaths map[string]struct{}
+
+	// in
+	chReloadConf   chan map[string]*conf.Path
+	chSetHLSServer chan pathManagerHLSServer
+	chClosePath    chan *path
+	chPathReady    chan *path
+	chPathNotReady chan *path
+	chAddPath      chan pathManagerShardAddPathReq
+	chAPIPathsList chan pathAPIPathsListReq
+	chAPIPathsGet  chan pathAPIPathsGetReq
+}
+
+func (sh *pathManagerShard) initialize() {
+	sh.paths = make(map[string]*path)
+	sh.pathsByConf = make(map[string]map[*path]struct{})
+	sh.restoredPaths = make(map[string]struct{})
+	sh.chReloadConf = make(chan map[string]*conf.Path)
+	sh.chSetHLSServer = make(chan pathManagerHLSServer)
+	sh.chClosePath = make(chan *path)
+	sh.chPathReady = make(chan *path)
+	sh.chPathNotReady = make(chan *path)
+	sh.chAddPath = make(chan pathManagerShardAddPathReq)
+	sh.chAPIPathsList = make(chan pathAPIPathsListReq)
+	sh.chAPIPathsGet = make(chan pathAPIPathsGetReq)
+}
+
+func (sh *pathManagerShard) run() {
+	defer sh.parent.wg.Done()
+
+outer:
+	for {
+		select {
+		case newPaths := <-sh.chReloadConf:
+			sh.doReloadConf(newPaths)
+
+		case m := <-sh.chSetHLSServer:
+			sh.hlsManager = m
+
+		case pa := <-sh.chClosePath:
+			sh.doClosePath(pa)
+
+		case pa := <-sh.chPathReady:
+			sh.doPathReady(pa)
+
+		case pa := <-sh.chPathNotReady:
+			sh.doPathNotReady(pa)
+
+		case req := <-sh.chAddPath:
+			sh.doAddPath(req)
+
+		case req := <-sh.chAPIPathsList:
+			sh.doAPIPathsList(req)
+
+		case req := <-sh.chAPIPathsGet:
+			sh.doAPIPathsGet(req)
+
+		case <-sh.parent.ctx.Done():
+			break outer
+		}
+	}
+
+	sh.parent.ctxCancel()
+}
+
+func (sh *pathManagerShard) doReloadConf(newPaths map[string]*conf.Path) {
+	var closedPaths []*path
+
+	for confName, pathConf := range sh.pathConfs {
+		if newPath, ok := newPaths[confName]; ok {
+			// configuration has changed
+			if !newPath.Equal(pathConf) {
+				if pathConfCanBeUpdated(pathConf, newPath) { // paths associated with the configuration can be updated
+					for pa := range sh.pathsByConf[confName] {
+						go pa.reloadConf(newPath)
+					}
+				} else { // paths associated with the configuration must be recreated
+					for pa := range sh.pathsByConf[confName] {
+						sh.removePath(pa)
+						pa.close()
+						closedPaths = append(closedPaths, pa)
+					}
+				}
+			}
+		} else {
+			// configuration has been deleted, remove associated paths
+			for pa := range sh.pathsByConf[confName] {
+				sh.removePath(pa)
+				pa.close()
+				closedPaths = append(closedPaths, pa)
+			}
+		}
+	}
+
+	// paths are closed in parallel, then waited before creating new ones,
+	// in order to avoid conflicts between sources
+	for _, pa := range closedPaths {
+		pa.wait()
+	}
+
+	sh.pathConfs = newPaths
+
+	// add new paths
+	for pathConfName, pathConf := range sh.pathConfs {
+		if _, ok := sh.paths[pathConfName]; !ok && pathConf.Regexp == nil &&
+			sh.parent.shardFor(pathConfName) == sh {
+			sh.createPath(pathConf, pathConfName, nil)
+		}
+	}
+}
+
+// restorePaths creates paths that were active before the last restart
+// and are not in the configuration, like the ones that match regular expressions.
+func (sh *pathManagerShard) restorePaths() {
+	for name := range sh.restoredPaths {
+		pathConf, pathMatches, err := conf.FindPathConf(sh.pathConfs, name)
+		if err != nil || !pathConf.HasOnDemandStaticSource() {
+			delete(sh.restoredPaths, name)
+			sh.parent.pathStateStore.SetPathActive(name, false)
+			continue
+		}
+
+		sh.createPath(pathConf, name, pathMatches)
+	}
+}
+
+func (sh *pathManagerShard) doClosePath(pa *path) {
+	if pmpa, ok := sh.paths[pa.name]; !ok || pmpa != pa {
+		return
+	}
+	sh.removePath(pa)
+}
+
+func (sh *pathManagerShard) doPathReady(pa *path) {
+	if sh.hlsManager != nil {
+		sh.hlsManager.PathReady(pa)
+	}
+
+	if sh.parent.pathStateStore != nil && pa.conf.HasOnDemandStaticSource() {
+		sh.parent.pathStateStore.SetPathActive(pa.name, true)
+	}
+}
+
+func (sh *pathManagerShard) doPathNotReady(pa *path) {
+	if sh.hlsManager != nil {
+		sh.hlsManager.PathNotReady(pa)
+	}
+
+	if sh.parent.pathStateStore != nil && pa.conf.HasOnDemandStaticSource() {
+		sh.parent.pathStateStore.SetPathActive(pa.name, false)
+	}
+}
+
+func (sh *pathManagerShard) doAddPath(req pathManagerShardAddPathReq) {
+	// create path if it doesn't exist.
+	// the configuration is searched again since it might have been reloaded
+	// after the request has been authenticated.
+	if _, ok := sh.paths[req.name]; !ok {
+		pathConf, pathMatches, err := conf.FindPathConf(sh.pathConfs, req.name)
+		if err != nil {
+			req.res <- pathManagerShardAddPathRes{err: err}
+			return
+		}
+
+		sh.createPath(pathConf, req.name, pathMatches)
+	}
+
+	req.res <- pathManagerShardAddPathRes{path: sh.paths[req.name]}
+}
+
+func (sh *pathManagerShard) doAPIPathsList(req pathAPIPathsListReq) {
+	paths := make(map[string]*path)
+
+	for name, pa := range sh.paths {
+		paths[name] = pa
+	}
+
+	req.res <- pathAPIPathsListRes{paths: paths}
+}
+
+func (sh *pathManagerShard) doAPIPathsGet(req pathAPIPathsGetReq) {
+	path, ok := sh.paths[req.name]
+	if !ok {
+		req.res <- pathAPIPathsGetRes{err: conf.ErrPathNotFound}
+		return
+	}
+
+	req.res <- pathAPIPathsGetRes{path: path}
+}
+
+func (sh *pathManagerShard) createPath(
+	pathConf *conf.Path,
+	name string,
+	matches []string,
+) {
+	_, restoreSource := sh.restoredPaths[name]
+	delete(sh.restoredPaths, name)
+
+	pm := sh.parent
+
+	pa := &path{
+		parentCtx:         pm.ctx,
+		logLevel:          pm.logLevel,
+		rtspAddress:       pm.rtspAddress,
+		readTimeout:       pm.readTimeout,
+		writeTimeout:      pm.writeTimeout,
+		writeQueueSize:    pm.writeQueueSize,
+		udpMaxPayloadSize: pm.udpMaxPayloadSize,
+		conf:              pathConf,
+		name:              name,
+		matches:           matches,
+		wg:                &pm.wg,
+		externalCmdPool:   pm.externalCmdPool,
+		coordinator:       pm.coordinator,
+		startLimiter:      pm.startLimiter,
+		bandwidth:         pm.bandwidth,
+		recordProcessor:   pm.recordProcessor,
+		restoreSource:     restoreSource,
+		parent:            pm,
+	}
+	pa.initialize()
+
+	sh.paths[name] = pa
+
+	if _, ok := sh.pathsByConf[pathConf.Name]; !ok {
+		sh.pathsByConf[pathConf.Name] = make(map[*path]struct{})
+	}
+	sh.pathsByConf[pathConf.Name][pa] = struct{}{}
+}
+
+func (sh *pathManagerShard) removePath(pa *path) {
+	delete(sh.pathsByConf[pa.conf.Name], pa)
+	if len(sh.pathsByConf[pa.conf.Name]) == 0 {
+		delete(sh.pathsByConf, pa.conf.Name)
+	}
+	delete(sh.paths, pa.name)
+}
+
+// reloadConf is called by pathManager.
+func (sh *pathManagerShard) reloadConf(pathConfs map[string]*conf.Path) {
+	select {
+	case sh.chReloadConf <- pathConfs:
+	case <-sh.parent.ctx.Done():
+	}
+}
+
+// setHLSServer is called by pathManager.
+func (sh *pathManagerShard) setHLSServer(s pathManagerHLSServer) {
+	select {
+	case sh.chSetHLSServer <- s:
+	case <-sh.parent.ctx.Done():
+	}
+}
+
+// pathReady is called by pathManager.
+func (sh *pathManagerShard) pathReady(pa *path) {
+	select {
+	case sh.chPathReady <- pa:
+	case <-sh.parent.ctx.Done():
+	case <-pa.ctx.Done(): // in case the shard is blocked by path.wait()
+	}
+}
+
+// pathNotReady is called by pathManager.
+func (sh *pathManagerShard) pathNotReady(pa *path) {
+	select {
+	case sh.chPathNotReady <- pa:
+	case <-sh.parent.ctx.Done():
+	case <-pa.ctx.Done(): // in case the shard is blocked by path.wait()
+	}
+}
+
+// closePath is called by pathManager.
+func (sh *pathManagerShard) closePath(pa *path) {
+	select {
+	case sh.chClosePath <- pa:
+	case <-sh.parent.ctx.Done():
+	case <-pa.ctx.Done(): // in case the shard is blocked by path.wait()
+	}
+}
+
+// addPath is called by pathManager.
+func (sh *pathManagerShard) addPath(name string) (*path, error) {
+	req := pathManagerShardAddPathReq{
+		name: name,
+		res:  make(chan pathManagerShardAddPathRes),
+	}
+
+	select {
+	case sh.chAddPath <- req:
+		res := <-req.res
+		return res.path, res.err
+
+	case <-sh.parent.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// apiPathsList is called by pathManager.
+func (sh *pathManagerShard) apiPathsList() (map[string]*path, error) {
+	req := pathAPIPathsListReq{
+		res: make(chan pathAPIPathsListRes),
+	}
+
+	select {
+	case sh.chAPIPathsList <- req:
+		res := <-req.res
+		return res.paths, nil
+
+	case <-sh.parent.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
+
+// apiPathsGet is called by pathManager.
+func (sh *pathManagerShard) apiPathsGet(name string) (*path, error) {
+	req := pathAPIPathsGetReq{
+		name: name,
+		res:  make(chan pathAPIPathsGetRes),
+	}
+
+	select {
+	case sh.chAPIPathsGet <- req:
+		res := <-req.res
+		return res.path, res.err
+
+	case <-sh.parent.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	}
+}
@@ -3,6 +3,8 @@ package core
 import (
 	"bufio"
 	"net"
+	"sort"
+	"strconv"
 	"testing"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
@@ -82,3 +84,40 @@ func TestPathAutoDeletion(t *testing.T) {
 		})
 	}
 }
+
+func TestPathManagerShards(t *testing.T) {
+	var names []string
+	for i := 0; i < 3*pathManagerShardCount; i++ {
+		names = append(names, "path"+strconv.Itoa(i))
+	}
+
+	yml := "paths:\n"
+	for _, name := range names {
+		yml += "  " + name + ":\n"
+	}
+
+	p, ok := newInstance(yml)
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	used := make(map[*pathManagerShard]struct{})
+	for _, name := range names {
+		used[p.pathManager.shardFor(name)] = struct{}{}
+	}
+	require.Greater(t, len(used), 1)
+
+	data, err := p.pathManager.APIPathsList()
+	require.NoError(t, err)
+
+	sort.Strings(names)
+	require.Equal(t, len(names), len(data.Items))
+	for i, item := range data.Items {
+		require.Equal(t, names[i], item.Name)
+	}
+
+	for _, name := range names {
+		item, err := p.pathManager.APIPathsGet(name)
+		require.NoError(t, err)
+		require.Equal(t, name, item.Name)
+	}
+}
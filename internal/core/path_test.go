@@ -2,20 +2,28 @@ package core
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v4"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
 	srt "github.com/datarhei/gosrt"
@@ -451,6 +459,70 @@ func TestPathRunOnRead(t *testing.T) {
 	}
 }
 
+func TestPathRunOnFirstReader(t *testing.T) {
+	onFirstReader := filepath.Join(os.TempDir(), "on_first_reader")
+	defer os.Remove(onFirstReader)
+
+	onLastReader := filepath.Join(os.TempDir(), "on_last_reader")
+	defer os.Remove(onLastReader)
+
+	p, ok := newInstance(fmt.Sprintf(
+		"paths:\n"+
+			"  test:\n"+
+			"    runOnFirstReader: sh -c 'echo \"$MTX_PATH $MTX_QUERY $MTX_READER_PROTOCOL $MTX_READER_COUNT\" >> %s'\n"+
+			"    runOnLastReader: sh -c 'echo \"$MTX_PATH $MTX_QUERY $MTX_READER_PROTOCOL $MTX_READER_COUNT\" >> %s'\n",
+		onFirstReader, onLastReader))
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	source := gortsplib.Client{}
+
+	err := source.StartRecording(
+		"rtsp://localhost:8554/test",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	var readers [2]*gortsplib.Client
+
+	for i := range readers {
+		readers[i] = &gortsplib.Client{}
+
+		u, err := base.ParseURL("rtsp://127.0.0.1:8554/test?query=value" + strconv.Itoa(i))
+		require.NoError(t, err)
+
+		err = readers[i].Start(u.Scheme, u.Host)
+		require.NoError(t, err)
+		defer readers[i].Close()
+
+		desc, _, err := readers[i].Describe(u)
+		require.NoError(t, err)
+
+		err = readers[i].SetupAll(desc.BaseURL, desc.Medias)
+		require.NoError(t, err)
+
+		_, err = readers[i].Play(nil)
+		require.NoError(t, err)
+	}
+
+	readers[0].Close()
+	time.Sleep(500 * time.Millisecond)
+
+	_, err = os.Stat(onLastReader)
+	require.True(t, os.IsNotExist(err))
+
+	readers[1].Close()
+	time.Sleep(500 * time.Millisecond)
+
+	byts, err := os.ReadFile(onFirstReader)
+	require.NoError(t, err)
+	require.Equal(t, "test query=value0 rtsp 1\n", string(byts))
+
+	byts, err = os.ReadFile(onLastReader)
+	require.NoError(t, err)
+	require.Equal(t, "test query=value1 rtsp 0\n", string(byts))
+}
+
 func TestPathRunOnRecordSegment(t *testing.T) {
 	onRecordSegmentCreate := filepath.Join(os.TempDir(), "on_record_segment_create")
 	defer os.Remove(onRecordSegmentCreate)
@@ -553,299 +625,1329 @@ func TestPathMaxReaders(t *testing.T) {
 	}
 }
 
-func TestPathRecord(t *testing.T) {
-	dir, err := os.MkdirTemp("", "rtsp-path-record")
-	require.NoError(t, err)
-	defer os.RemoveAll(dir)
-
-	p, ok := newInstance("api: yes\n" +
-		"record: yes\n" +
-		"recordPath: " + filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f") + "\n" +
-		"paths:\n" +
+func TestPathMaxReaderDuration(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
 		"  all_others:\n" +
-		"    record: yes\n")
+		"    maxReaderDuration: 500ms\n")
 	require.Equal(t, true, ok)
 	defer p.Close()
 
-	media0 := test.UniqueMediaH264()
-
 	source := gortsplib.Client{}
 
-	err = source.StartRecording(
+	err := source.StartRecording(
 		"rtsp://localhost:8554/mystream",
-		&description.Session{Medias: []*description.Media{media0}})
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
 	require.NoError(t, err)
 	defer source.Close()
 
-	for i := 0; i < 4; i++ {
-		err = source.WritePacketRTP(media0, &rtp.Packet{
-			Header: rtp.Header{
-				Version:        2,
-				Marker:         true,
-				PayloadType:    96,
-				SequenceNumber: 1123 + uint16(i),
-				Timestamp:      45343 + 90000*uint32(i),
-				SSRC:           563423,
-			},
-			Payload: []byte{5},
-		})
-		require.NoError(t, err)
-	}
+	reader := gortsplib.Client{}
 
-	time.Sleep(500 * time.Millisecond)
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/mystream")
+	require.NoError(t, err)
 
-	files, err := os.ReadDir(filepath.Join(dir, "mystream"))
+	err = reader.Start(u.Scheme, u.Host)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(files))
+	defer reader.Close()
 
-	tr := &http.Transport{}
-	defer tr.CloseIdleConnections()
-	hc := &http.Client{Transport: tr}
+	desc, _, err := reader.Describe(u)
+	require.NoError(t, err)
 
-	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/all_others", map[string]interface{}{
-		"record": false,
-	}, nil)
+	err = reader.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
 
-	time.Sleep(500 * time.Millisecond)
+	_, err = reader.Play(nil)
+	require.NoError(t, err)
 
-	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/all_others", map[string]interface{}{
-		"record": true,
-	}, nil)
+	start := time.Now()
 
-	time.Sleep(500 * time.Millisecond)
+	readerDone := make(chan error)
+	go func() {
+		readerDone <- reader.Wait()
+	}()
 
-	for i := 4; i < 8; i++ {
-		err = source.WritePacketRTP(media0, &rtp.Packet{
-			Header: rtp.Header{
-				Version:        2,
-				Marker:         true,
-				PayloadType:    96,
-				SequenceNumber: 1123 + uint16(i),
-				Timestamp:      45343 + 90000*uint32(i),
-				SSRC:           563423,
-			},
-			Payload: []byte{5},
-		})
-		require.NoError(t, err)
+	select {
+	case err = <-readerDone:
+		require.Error(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	case <-time.After(5 * time.Second):
+		t.Errorf("reader was not closed")
 	}
+}
 
-	time.Sleep(500 * time.Millisecond)
+func TestPathPublisherIdleTimeout(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  all_others:\n" +
+		"    publisherIdleTimeout: 500ms\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
 
-	files, err = os.ReadDir(filepath.Join(dir, "mystream"))
-	require.NoError(t, err)
-	require.Equal(t, 2, len(files))
-}
+	source := gortsplib.Client{}
 
-func TestPathFallback(t *testing.T) {
-	for _, ca := range []string{
-		"absolute",
-		"relative",
-		"source",
-	} {
-		t.Run(ca, func(t *testing.T) {
-			var conf string
+	err := source.StartRecording(
+		"rtsp://localhost:8554/mystream",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
 
-			switch ca {
-			case "absolute":
-				conf = "paths:\n" +
-					"  path1:\n" +
-					"    fallback: rtsp://localhost:8554/path2\n" +
-					"  path2:\n"
+	start := time.Now()
 
-			case "relative":
-				conf = "paths:\n" +
-					"  path1:\n" +
-					"    fallback: /path2\n" +
-					"  path2:\n"
+	sourceDone := make(chan error)
+	go func() {
+		sourceDone <- source.Wait()
+	}()
 
-			case "source":
-				conf = "paths:\n" +
-					"  path1:\n" +
-					"    fallback: /path2\n" +
-					"    source: rtsp://localhost:3333/nonexistent\n" +
-					"  path2:\n"
-			}
+	select {
+	case err = <-sourceDone:
+		require.Error(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	case <-time.After(5 * time.Second):
+		t.Errorf("publisher was not closed")
+	}
+}
 
-			p1, ok := newInstance(conf)
+func TestPathCodecChangeBehavior(t *testing.T) {
+	for _, ca := range []string{"disconnectReaders", "restartPath"} {
+		t.Run(ca, func(t *testing.T) {
+			p, ok := newInstance("paths:\n" +
+				"  all_others:\n" +
+				"    codecChangeBehavior: " + ca + "\n")
 			require.Equal(t, true, ok)
-			defer p1.Close()
+			defer p.Close()
+
+			media0 := test.UniqueMediaH264()
 
 			source := gortsplib.Client{}
-			err := source.StartRecording("rtsp://localhost:8554/path2",
-				&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+
+			err := source.StartRecording(
+				"rtsp://localhost:8554/mystream",
+				&description.Session{Medias: []*description.Media{media0}})
 			require.NoError(t, err)
 			defer source.Close()
 
-			u, err := base.ParseURL("rtsp://localhost:8554/path1")
+			reader := gortsplib.Client{}
+
+			u, err := base.ParseURL("rtsp://127.0.0.1:8554/mystream")
 			require.NoError(t, err)
 
-			dest := gortsplib.Client{}
-			err = dest.Start(u.Scheme, u.Host)
+			err = reader.Start(u.Scheme, u.Host)
 			require.NoError(t, err)
-			defer dest.Close()
+			defer reader.Close()
 
-			desc, _, err := dest.Describe(u)
+			desc, _, err := reader.Describe(u)
 			require.NoError(t, err)
-			require.Equal(t, 1, len(desc.Medias))
-		})
-	}
-}
 
-func TestPathResolveSource(t *testing.T) {
-	var stream *gortsplib.ServerStream
+			err = reader.SetupAll(desc.BaseURL, desc.Medias)
+			require.NoError(t, err)
 
-	s := gortsplib.Server{
-		Handler: &testServer{
-			onDescribe: func(ctx *gortsplib.ServerHandlerOnDescribeCtx,
-			) (*base.Response, *gortsplib.ServerStream, error) {
-				require.Equal(t, "key=val", ctx.Query)
-				require.Equal(t, "/a", ctx.Path)
-				return &base.Response{
-					StatusCode: base.StatusOK,
-				}, stream, nil
-			},
-			onSetup: func(_ *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
-				return &base.Response{
-					StatusCode: base.StatusOK,
-				}, stream, nil
-			},
-			onPlay: func(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
-				return &base.Response{
-					StatusCode: base.StatusOK,
-				}, nil
-			},
-		},
-		RTSPAddress: "127.0.0.1:8555",
-	}
+			_, err = reader.Play(nil)
+			require.NoError(t, err)
 
-	err := s.Start()
-	require.NoError(t, err)
-	defer s.Close()
+			readerDone := make(chan error)
+			go func() {
+				readerDone <- reader.Wait()
+			}()
 
-	stream = gortsplib.NewServerStream(&s, &description.Session{Medias: []*description.Media{test.MediaH264}})
-	defer stream.Close()
+			sourceDone := make(chan error)
+			go func() {
+				sourceDone <- source.Wait()
+			}()
 
-	p, ok := newInstance(
-		"paths:\n" +
-			"  '~^test_(.+)$':\n" +
-			"    source: rtsp://127.0.0.1:8555/$G1?$MTX_QUERY\n" +
-			"    sourceOnDemand: yes\n" +
-			"  'all':\n")
+			// SPS with a different level
+			err = source.WritePacketRTP(media0, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 123,
+					Timestamp:      45343,
+					SSRC:           563423,
+				},
+				Payload: []byte{
+					0x67, 0x42, 0xc0, 0x1f, 0xd9, 0x00, 0x78, 0x02,
+					0x27, 0xe5, 0x84, 0x00, 0x00, 0x03, 0x00, 0x04,
+					0x00, 0x00, 0x03, 0x00, 0xf0, 0x3c, 0x60, 0xc9, 0x20,
+				},
+			})
+			require.NoError(t, err)
+
+			select {
+			case err = <-readerDone:
+				require.Error(t, err)
+			case <-time.After(5 * time.Second):
+				t.Errorf("reader was not closed")
+			}
+
+			if ca == "restartPath" {
+				select {
+				case err = <-sourceDone:
+					require.Error(t, err)
+				case <-time.After(5 * time.Second):
+					t.Errorf("publisher was not closed")
+				}
+			} else {
+				select {
+				case <-sourceDone:
+					t.Errorf("publisher was closed")
+				case <-time.After(500 * time.Millisecond):
+				}
+			}
+		})
+	}
+}
+
+func TestPathUserAgentRules(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  legacy:\n" +
+		"  mypath:\n" +
+		"    userAgentRules:\n" +
+		"      - match: ^BadBot\n" +
+		"        action: deny\n" +
+		"      - match: ^LegacyPlayer\n" +
+		"        action: route\n" +
+		"        path: legacy\n")
 	require.Equal(t, true, ok)
 	defer p.Close()
 
-	reader := gortsplib.Client{}
-
-	u, err := base.ParseURL("rtsp://127.0.0.1:8554/test_a?key=val")
+	source := gortsplib.Client{}
+	err := source.StartRecording(
+		"rtsp://localhost:8554/mypath",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
 	require.NoError(t, err)
+	defer source.Close()
 
-	err = reader.Start(u.Scheme, u.Host)
+	source2 := gortsplib.Client{}
+	err = source2.StartRecording(
+		"rtsp://localhost:8554/legacy",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaMPEG4Audio()}})
 	require.NoError(t, err)
-	defer reader.Close()
+	defer source2.Close()
 
-	_, _, err = reader.Describe(u)
+	u, err := base.ParseURL("rtsp://localhost:8554/mypath")
 	require.NoError(t, err)
-}
 
-func TestPathOverridePublisher(t *testing.T) {
-	for _, ca := range []string{
-		"enabled",
-		"disabled",
-	} {
+	for _, ca := range []string{"allowed", "denied", "routed"} {
 		t.Run(ca, func(t *testing.T) {
-			conf := "rtmp: no\n" +
-				"paths:\n" +
-				"  all_others:\n"
+			reader := gortsplib.Client{}
 
-			if ca == "disabled" {
-				conf += "    overridePublisher: no\n"
+			switch ca {
+			case "denied":
+				reader.UserAgent = "BadBot/1.0"
+			case "routed":
+				reader.UserAgent = "LegacyPlayer/2.0"
 			}
 
-			p, ok := newInstance(conf)
-			require.Equal(t, true, ok)
-			defer p.Close()
+			err = reader.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer reader.Close()
 
-			medi := test.UniqueMediaH264()
+			desc, _, err := reader.Describe(u)
 
-			s1 := gortsplib.Client{}
+			switch ca {
+			case "allowed":
+				require.NoError(t, err)
+				require.Equal(t, description.MediaTypeVideo, desc.Medias[0].Type)
 
-			err := s1.StartRecording("rtsp://localhost:8554/teststream",
-				&description.Session{Medias: []*description.Media{medi}})
-			require.NoError(t, err)
-			defer s1.Close()
+			case "denied":
+				require.EqualError(t, err, "bad status code: 403 (Forbidden)")
 
-			s2 := gortsplib.Client{}
+			case "routed":
+				require.NoError(t, err)
+				require.Equal(t, description.MediaTypeAudio, desc.Medias[0].Type)
 
-			err = s2.StartRecording("rtsp://localhost:8554/teststream",
-				&description.Session{Medias: []*description.Media{medi}})
-			if ca == "enabled" {
+				err = reader.SetupAll(desc.BaseURL, desc.Medias)
+				require.NoError(t, err)
+
+				_, err = reader.Play(nil)
 				require.NoError(t, err)
-				defer s2.Close()
-			} else {
-				require.Error(t, err)
 			}
+		})
+	}
+}
 
-			frameRecv := make(chan struct{})
+func TestPathPublishRewrites(t *testing.T) {
+	p, ok := newInstance("publishPathRewrites:\n" +
+		"  - match: ^live/\n" +
+		"    replace: ''\n" +
+		"    protocols: [rtsp]\n" +
+		"  - match: .+\n" +
+		"    replace: $0\n" +
+		"    lowercase: yes\n" +
+		"paths:\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
 
-			c := gortsplib.Client{}
+	source := gortsplib.Client{}
+	err := source.StartRecording(
+		"rtsp://localhost:8554/live/MyStream",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
 
-			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	for _, ca := range []struct {
+		name string
+		err  string
+	}{
+		{"mystream", ""},
+		{"live/MyStream", "bad status code: 404 (Not Found)"},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			u, err := base.ParseURL("rtsp://localhost:8554/" + ca.name)
 			require.NoError(t, err)
 
-			err = c.Start(u.Scheme, u.Host)
-			require.NoError(t, err)
-			defer c.Close()
+			reader := gortsplib.Client{}
 
-			desc, _, err := c.Describe(u)
+			err = reader.Start(u.Scheme, u.Host)
 			require.NoError(t, err)
+			defer reader.Close()
 
-			err = c.SetupAll(desc.BaseURL, desc.Medias)
-			require.NoError(t, err)
+			_, _, err = reader.Describe(u)
+			if ca.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, ca.err)
+			}
+		})
+	}
+}
 
-			c.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(pkt *rtp.Packet) {
-				if ca == "enabled" {
-					require.Equal(t, []byte{5, 15, 16, 17, 18}, pkt.Payload)
-				} else {
-					require.Equal(t, []byte{5, 11, 12, 13, 14}, pkt.Payload)
-				}
-				close(frameRecv)
+func TestPathProtocols(t *testing.T) {
+	p, ok := newInstance("paths:\n" +
+		"  readhls:\n" +
+		"    publishProtocols: [rtsp]\n" +
+		"    readProtocols: [hls]\n" +
+		"  readrtsp:\n" +
+		"    publishProtocols: [srt]\n" +
+		"    readProtocols: [rtsp]\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	source := gortsplib.Client{}
+	err := source.StartRecording(
+		"rtsp://localhost:8554/readrtsp",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.EqualError(t, err, "bad status code: 400 (Bad Request)")
+
+	source = gortsplib.Client{}
+	err = source.StartRecording(
+		"rtsp://localhost:8554/readhls",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	reader := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/readhls")
+	require.NoError(t, err)
+
+	err = reader.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, _, err = reader.Describe(u)
+	require.EqualError(t, err, "bad status code: 400 (Bad Request)")
+
+	hc := &http.Client{Transport: &http.Transport{}}
+
+	res, err := hc.Get("http://localhost:8888/readrtsp/index.m3u8")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestPathMaxWHEPReaders(t *testing.T) {
+	p, ok := newInstance("api: yes\n" +
+		"paths:\n" +
+		"  all_others:\n" +
+		"    maxWHEPReaders: 1\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	media0 := test.UniqueMediaH264()
+
+	source := gortsplib.Client{}
+
+	err := source.StartRecording(
+		"rtsp://localhost:8554/mystream",
+		&description.Session{Medias: []*description.Media{media0}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	writerDone := make(chan struct{})
+	defer func() { <-writerDone }()
+
+	writerTerminate := make(chan struct{})
+	defer close(writerTerminate)
+
+	go func() {
+		defer close(writerDone)
+		i := uint16(0)
+		for {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-writerTerminate:
+				return
+			}
+			err2 := source.WritePacketRTP(media0, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: 123 + i,
+					Timestamp:      45343,
+					SSRC:           563423,
+				},
+				Payload: []byte{5},
 			})
+			require.NoError(t, err2)
+			i++
+		}
+	}()
 
-			_, err = c.Play(nil)
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	u, err := url.Parse("http://localhost:8889/mystream/whep")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		c := &whip.Client{
+			HTTPClient: hc,
+			URL:        u,
+			Log:        test.NilLogger,
+		}
+
+		_, err = c.Read(context.Background())
+		if i == 0 {
 			require.NoError(t, err)
+			defer checkClose(t, c.Close)
+		} else {
+			require.EqualError(t, err, "bad status code: 503")
+		}
+	}
 
-			if ca == "enabled" {
-				err = s1.Wait()
-				require.EqualError(t, err, "EOF")
+	var out struct {
+		WHEPReaders int `json:"whepReaders"`
+	}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/paths/get/mystream", nil, &out)
+	require.Equal(t, 1, out.WHEPReaders)
+}
 
-				err = s2.WritePacketRTP(medi, &rtp.Packet{
-					Header: rtp.Header{
-						Version:        0x02,
-						PayloadType:    96,
-						SequenceNumber: 57899,
-						Timestamp:      345234345,
-						SSRC:           978651231,
-						Marker:         true,
-					},
-					Payload: []byte{5, 15, 16, 17, 18},
-				})
-				require.NoError(t, err)
-			} else {
-				err = s1.WritePacketRTP(medi, &rtp.Packet{
-					Header: rtp.Header{
-						Version:        0x02,
-						PayloadType:    96,
-						SequenceNumber: 57899,
-						Timestamp:      345234345,
-						SSRC:           978651231,
-						Marker:         true,
-					},
-					Payload: []byte{5, 11, 12, 13, 14},
-				})
-				require.NoError(t, err)
-			}
+func TestPathRecord(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rtsp-path-record")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
 
-			<-frameRecv
+	p, ok := newInstance("api: yes\n" +
+		"record: yes\n" +
+		"recordPath: " + filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f") + "\n" +
+		"paths:\n" +
+		"  all_others:\n" +
+		"    record: yes\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	media0 := test.UniqueMediaH264()
+
+	source := gortsplib.Client{}
+
+	err = source.StartRecording(
+		"rtsp://localhost:8554/mystream",
+		&description.Session{Medias: []*description.Media{media0}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	for i := 0; i < 4; i++ {
+		err = source.WritePacketRTP(media0, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 1123 + uint16(i),
+				Timestamp:      45343 + 90000*uint32(i),
+				SSRC:           563423,
+			},
+			Payload: []byte{5},
+		})
+		require.NoError(t, err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	files, err := os.ReadDir(filepath.Join(dir, "mystream"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/all_others", map[string]interface{}{
+		"record": false,
+	}, nil)
+
+	time.Sleep(500 * time.Millisecond)
+
+	httpRequest(t, hc, http.MethodPatch, "http://localhost:9997/v3/config/paths/patch/all_others", map[string]interface{}{
+		"record": true,
+	}, nil)
+
+	time.Sleep(500 * time.Millisecond)
+
+	for i := 4; i < 8; i++ {
+		err = source.WritePacketRTP(media0, &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    96,
+				SequenceNumber: 1123 + uint16(i),
+				Timestamp:      45343 + 90000*uint32(i),
+				SSRC:           563423,
+			},
+			Payload: []byte{5},
 		})
+		require.NoError(t, err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	files, err = os.ReadDir(filepath.Join(dir, "mystream"))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+}
+
+func TestPathFallback(t *testing.T) {
+	for _, ca := range []string{
+		"absolute",
+		"relative",
+		"source",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var conf string
+
+			switch ca {
+			case "absolute":
+				conf = "paths:\n" +
+					"  path1:\n" +
+					"    fallback: rtsp://localhost:8554/path2\n" +
+					"  path2:\n"
+
+			case "relative":
+				conf = "paths:\n" +
+					"  path1:\n" +
+					"    fallback: /path2\n" +
+					"  path2:\n"
+
+			case "source":
+				conf = "paths:\n" +
+					"  path1:\n" +
+					"    fallback: /path2\n" +
+					"    source: rtsp://localhost:3333/nonexistent\n" +
+					"  path2:\n"
+			}
+
+			p1, ok := newInstance(conf)
+			require.Equal(t, true, ok)
+			defer p1.Close()
+
+			source := gortsplib.Client{}
+			err := source.StartRecording("rtsp://localhost:8554/path2",
+				&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+			require.NoError(t, err)
+			defer source.Close()
+
+			u, err := base.ParseURL("rtsp://localhost:8554/path1")
+			require.NoError(t, err)
+
+			dest := gortsplib.Client{}
+			err = dest.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer dest.Close()
+
+			desc, _, err := dest.Describe(u)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(desc.Medias))
+		})
+	}
+}
+
+func TestPathResolveSource(t *testing.T) {
+	var stream *gortsplib.ServerStream
+
+	s := gortsplib.Server{
+		Handler: &testServer{
+			onDescribe: func(ctx *gortsplib.ServerHandlerOnDescribeCtx,
+			) (*base.Response, *gortsplib.ServerStream, error) {
+				require.Equal(t, "key=val", ctx.Query)
+				require.Equal(t, "/a", ctx.Path)
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "127.0.0.1:8555",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = gortsplib.NewServerStream(&s, &description.Session{Medias: []*description.Media{test.MediaH264}})
+	defer stream.Close()
+
+	p, ok := newInstance(
+		"paths:\n" +
+			"  '~^test_(.+)$':\n" +
+			"    source: rtsp://127.0.0.1:8555/$G1?$MTX_QUERY\n" +
+			"    sourceOnDemand: yes\n" +
+			"  'all':\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	reader := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8554/test_a?key=val")
+	require.NoError(t, err)
+
+	err = reader.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, _, err = reader.Describe(u)
+	require.NoError(t, err)
+}
+
+func TestPathSourceOnDemandLinger(t *testing.T) {
+	var stream *gortsplib.ServerStream
+	describeCount := int32(0)
+
+	s := gortsplib.Server{
+		Handler: &testServer{
+			onDescribe: func(_ *gortsplib.ServerHandlerOnDescribeCtx,
+			) (*base.Response, *gortsplib.ServerStream, error) {
+				atomic.AddInt32(&describeCount, 1)
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "127.0.0.1:8555",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = gortsplib.NewServerStream(&s, &description.Session{Medias: []*description.Media{test.MediaH264}})
+	defer stream.Close()
+
+	p, ok := newInstance(
+		"paths:\n" +
+			"  test:\n" +
+			"    source: rtsp://127.0.0.1:8555/test\n" +
+			"    sourceOnDemand: yes\n" +
+			"    sourceOnDemandCloseAfter: 100ms\n" +
+			"    sourceOnDemandLinger: 5s\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		func() {
+			reader := gortsplib.Client{}
+
+			u, err := base.ParseURL("rtsp://127.0.0.1:8554/test")
+			require.NoError(t, err)
+
+			err = reader.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer reader.Close()
+
+			desc, _, err := reader.Describe(u)
+			require.NoError(t, err)
+
+			err = reader.SetupAll(desc.BaseURL, desc.Medias)
+			require.NoError(t, err)
+
+			_, err = reader.Play(nil)
+			require.NoError(t, err)
+		}()
+
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&describeCount))
+}
+
+func TestPathOverridePublisher(t *testing.T) {
+	for _, ca := range []string{
+		"enabled",
+		"disabled",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			conf := "rtmp: no\n" +
+				"paths:\n" +
+				"  all_others:\n"
+
+			if ca == "disabled" {
+				conf += "    overridePublisher: no\n"
+			}
+
+			p, ok := newInstance(conf)
+			require.Equal(t, true, ok)
+			defer p.Close()
+
+			medi := test.UniqueMediaH264()
+
+			s1 := gortsplib.Client{}
+
+			err := s1.StartRecording("rtsp://localhost:8554/teststream",
+				&description.Session{Medias: []*description.Media{medi}})
+			require.NoError(t, err)
+			defer s1.Close()
+
+			s2 := gortsplib.Client{}
+
+			err = s2.StartRecording("rtsp://localhost:8554/teststream",
+				&description.Session{Medias: []*description.Media{medi}})
+			if ca == "enabled" {
+				require.NoError(t, err)
+				defer s2.Close()
+			} else {
+				require.Error(t, err)
+			}
+
+			frameRecv := make(chan struct{})
+
+			c := gortsplib.Client{}
+
+			u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+			require.NoError(t, err)
+
+			err = c.Start(u.Scheme, u.Host)
+			require.NoError(t, err)
+			defer c.Close()
+
+			desc, _, err := c.Describe(u)
+			require.NoError(t, err)
+
+			err = c.SetupAll(desc.BaseURL, desc.Medias)
+			require.NoError(t, err)
+
+			c.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+				if ca == "enabled" {
+					require.Equal(t, []byte{5, 15, 16, 17, 18}, pkt.Payload)
+				} else {
+					require.Equal(t, []byte{5, 11, 12, 13, 14}, pkt.Payload)
+				}
+				close(frameRecv)
+			})
+
+			_, err = c.Play(nil)
+			require.NoError(t, err)
+
+			if ca == "enabled" {
+				err = s1.Wait()
+				require.EqualError(t, err, "EOF")
+
+				err = s2.WritePacketRTP(medi, &rtp.Packet{
+					Header: rtp.Header{
+						Version:        0x02,
+						PayloadType:    96,
+						SequenceNumber: 57899,
+						Timestamp:      345234345,
+						SSRC:           978651231,
+						Marker:         true,
+					},
+					Payload: []byte{5, 15, 16, 17, 18},
+				})
+				require.NoError(t, err)
+			} else {
+				err = s1.WritePacketRTP(medi, &rtp.Packet{
+					Header: rtp.Header{
+						Version:        0x02,
+						PayloadType:    96,
+						SequenceNumber: 57899,
+						Timestamp:      345234345,
+						SSRC:           978651231,
+						Marker:         true,
+					},
+					Payload: []byte{5, 11, 12, 13, 14},
+				})
+				require.NoError(t, err)
+			}
+
+			<-frameRecv
+		})
+	}
+}
+
+func TestPathPublisherResume(t *testing.T) {
+	p, ok := newInstance("rtmp: no\n" +
+		"paths:\n" +
+		"  all_others:\n" +
+		"    overridePublisher: no\n" +
+		"    publisherResumeTimeout: 5s\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	s1 := gortsplib.Client{}
+
+	err := s1.StartRecording("rtsp://localhost:8554/teststream?resumeToken=abc",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.NoError(t, err)
+
+	frameRecv := make(chan struct{})
+
+	c := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/teststream")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	c.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		require.Equal(t, []byte{5, 15, 16, 17, 18}, pkt.Payload)
+		close(frameRecv)
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	s1.Close()
+	time.Sleep(500 * time.Millisecond)
+
+	s2 := gortsplib.Client{}
+
+	err = s2.StartRecording("rtsp://localhost:8554/teststream?resumeToken=def",
+		&description.Session{Medias: []*description.Media{test.UniqueMediaH264()}})
+	require.Error(t, err)
+
+	medi := test.UniqueMediaH264()
+
+	s3 := gortsplib.Client{}
+
+	err = s3.StartRecording("rtsp://localhost:8554/teststream?resumeToken=abc",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer s3.Close()
+
+	err = s3.WritePacketRTP(medi, &rtp.Packet{
+		Header: rtp.Header{
+			Version:        0x02,
+			PayloadType:    96,
+			SequenceNumber: 57899,
+			Timestamp:      345234345,
+			SSRC:           978651231,
+			Marker:         true,
+		},
+		Payload: []byte{5, 15, 16, 17, 18},
+	})
+	require.NoError(t, err)
+
+	<-frameRecv
+}
+
+func TestPathPlaylistSource(t *testing.T) {
+	p, ok := newInstance("rtmp: no\n" +
+		"paths:\n" +
+		"  playlist:\n" +
+		"    source: playlist\n" +
+		"    sourceOnDemand: yes\n" +
+		"    sourcePlaylist:\n" +
+		"      - path: item1\n" +
+		"        duration: 500ms\n" +
+		"      - path: item2\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	for i, pathName := range []string{"item1", "item2"} {
+		medi := test.UniqueMediaH264()
+
+		s := gortsplib.Client{}
+
+		err := s.StartRecording("rtsp://localhost:8554/"+pathName,
+			&description.Session{Medias: []*description.Media{medi}})
+		require.NoError(t, err)
+		defer s.Close()
+
+		go func(i int) {
+			for j := 0; j < 50; j++ {
+				err2 := s.WritePacketRTP(medi, &rtp.Packet{
+					Header: rtp.Header{
+						Version:        0x02,
+						PayloadType:    96,
+						SequenceNumber: uint16(j),
+						Timestamp:      uint32(j * 9000),
+						SSRC:           978651231,
+						Marker:         true,
+					},
+					Payload: []byte{5, byte(i + 1)},
+				})
+				if err2 != nil {
+					return
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		}(i)
+	}
+
+	recv := make(chan byte, 100)
+
+	c := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/playlist")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	var prevTimestamp uint32
+
+	c.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(pkt *rtp.Packet) {
+		if prevTimestamp != 0 {
+			require.Greater(t, pkt.Timestamp, prevTimestamp)
+		}
+		prevTimestamp = pkt.Timestamp
+
+		select {
+		case recv <- pkt.Payload[1]:
+		default:
+		}
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	require.Equal(t, byte(1), <-recv)
+
+	for {
+		if v := <-recv; v == 2 {
+			break
+		}
+	}
+}
+
+func TestPathCompositeSource(t *testing.T) {
+	p, ok := newInstance("rtmp: no\n" +
+		"paths:\n" +
+		"  composite:\n" +
+		"    source: composite\n" +
+		"    sourceOnDemand: yes\n" +
+		"    sourceComposite: [main, pip]\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	for _, ca := range []struct {
+		pathName string
+		color    color.RGBA
+	}{
+		{"main", color.RGBA{255, 0, 0, 255}},
+		{"pip", color.RGBA{0, 0, 255, 255}},
+	} {
+		medi := &description.Media{
+			Type:    description.MediaTypeVideo,
+			Formats: []format.Format{&format.MJPEG{}},
+		}
+
+		s := gortsplib.Client{}
+
+		err := s.StartRecording("rtsp://localhost:8554/"+ca.pathName,
+			&description.Session{Medias: []*description.Media{medi}})
+		require.NoError(t, err)
+		defer s.Close()
+
+		img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+		draw.Draw(img, img.Bounds(), image.NewUniform(ca.color), image.Point{}, draw.Src)
+
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, img, nil)
+		require.NoError(t, err)
+
+		enc, err := medi.Formats[0].(*format.MJPEG).CreateEncoder()
+		require.NoError(t, err)
+
+		go func() {
+			for i := 0; i < 50; i++ {
+				pkts, err2 := enc.Encode(buf.Bytes())
+				if err2 != nil {
+					return
+				}
+
+				for _, pkt := range pkts {
+					pkt.Timestamp = uint32(i * 9000)
+					err2 = s.WritePacketRTP(medi, pkt)
+					if err2 != nil {
+						return
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		}()
+	}
+
+	recv := make(chan image.Image, 100)
+
+	c := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/composite")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	var forma *format.MJPEG
+	medi := desc.FindFormat(&forma)
+	require.NotNil(t, medi)
+
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	c.OnPacketRTP(medi, forma, func(pkt *rtp.Packet) {
+		frame, err2 := dec.Decode(pkt)
+		if err2 != nil {
+			return
+		}
+
+		img, err2 := jpeg.Decode(bytes.NewReader(frame))
+		require.NoError(t, err2)
+
+		select {
+		case recv <- img:
+		default:
+		}
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	for img := range recv {
+		require.Equal(t, image.Rect(0, 0, 320, 240), img.Bounds())
+
+		r, _, _, _ := img.At(10, 10).RGBA()
+		require.Greater(t, r, uint32(0xE000))
+
+		// wait until the picture-in-picture is available
+		_, _, b, _ := img.At(320-6-10, 240-6-10).RGBA()
+		if b > 0xE000 {
+			break
+		}
+	}
+}
+
+func TestPathDelaySource(t *testing.T) {
+	p, ok := newInstance("rtmp: no\n" +
+		"paths:\n" +
+		"  delayed:\n" +
+		"    source: delay\n" +
+		"    sourceOnDemand: yes\n" +
+		"    sourceDelayPath: main\n" +
+		"    sourceDelay: 1s\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	medi := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{test.FormatH264},
+	}
+
+	source := gortsplib.Client{}
+
+	err := source.StartRecording("rtsp://localhost:8554/main",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	sourceDone := make(chan struct{})
+	defer func() { <-sourceDone }()
+
+	sourceTerminate := make(chan struct{})
+	defer close(sourceTerminate)
+
+	go func() {
+		defer close(sourceDone)
+
+		for i := 0; ; i++ {
+			err2 := source.WritePacketRTP(medi, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: uint16(i),
+					Timestamp:      uint32(i * 4500),
+					SSRC:           123,
+				},
+				Payload: []byte{5, byte(i)}, // IDR
+			})
+			if err2 != nil {
+				return
+			}
+
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-sourceTerminate:
+				return
+			}
+		}
+	}()
+
+	recv := make(chan struct{})
+
+	c := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/delayed")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	c.OnPacketRTP(desc.Medias[0], desc.Medias[0].Formats[0], func(_ *rtp.Packet) {
+		select {
+		case <-recv:
+		default:
+			close(recv)
+		}
+	})
+
+	start := time.Now()
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	<-recv
+	require.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestPathProcessorSource(t *testing.T) {
+	p, ok := newInstance("rtmp: no\n" +
+		"paths:\n" +
+		"  processed:\n" +
+		"    source: processor\n" +
+		"    sourceOnDemand: yes\n" +
+		"    sourceProcessorPath: main\n" +
+		"    sourceProcessorCommand: cat\n" +
+		"  all_others:\n")
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	medi := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{test.FormatH264},
+	}
+
+	source := gortsplib.Client{}
+
+	err := source.StartRecording("rtsp://localhost:8554/main",
+		&description.Session{Medias: []*description.Media{medi}})
+	require.NoError(t, err)
+	defer source.Close()
+
+	sourceDone := make(chan struct{})
+	defer func() { <-sourceDone }()
+
+	sourceTerminate := make(chan struct{})
+	defer close(sourceTerminate)
+
+	go func() {
+		defer close(sourceDone)
+
+		for i := 0; ; i++ {
+			err2 := source.WritePacketRTP(medi, &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    96,
+					SequenceNumber: uint16(i),
+					Timestamp:      uint32(i * 4500),
+					SSRC:           123,
+				},
+				Payload: []byte{5, byte(i)}, // IDR
+			})
+			if err2 != nil {
+				return
+			}
+
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-sourceTerminate:
+				return
+			}
+		}
+	}()
+
+	recv := make(chan struct{})
+
+	c := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://localhost:8554/processed")
+	require.NoError(t, err)
+
+	err = c.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	require.NoError(t, err)
+
+	var forma *format.H264
+	medi2 := desc.FindFormat(&forma)
+	require.NotNil(t, medi2)
+
+	err = c.SetupAll(desc.BaseURL, desc.Medias)
+	require.NoError(t, err)
+
+	c.OnPacketRTP(medi2, forma, func(_ *rtp.Packet) {
+		select {
+		case <-recv:
+		default:
+			close(recv)
+		}
+	})
+
+	_, err = c.Play(nil)
+	require.NoError(t, err)
+
+	<-recv
+}
+
+func TestPathStateFile(t *testing.T) {
+	var stream *gortsplib.ServerStream
+
+	s := gortsplib.Server{
+		Handler: &testServer{
+			onDescribe: func(_ *gortsplib.ServerHandlerOnDescribeCtx,
+			) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "127.0.0.1:8555",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = gortsplib.NewServerStream(&s, &description.Session{Medias: []*description.Media{test.MediaH264}})
+	defer stream.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-pathstate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := "api: yes\n" +
+		"pathStateFile: " + filepath.Join(dir, "state.json") + "\n" +
+		"paths:\n" +
+		"  '~^cam.*$':\n" +
+		"    source: rtsp://127.0.0.1:8555/test\n" +
+		"    sourceOnDemand: yes\n"
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	func() {
+		p, ok := newInstance(conf)
+		require.Equal(t, true, ok)
+		defer p.Close()
+
+		httpRequest(t, hc, http.MethodPost, "http://localhost:9997/v3/config/paths/add/runtime",
+			map[string]interface{}{"maxReaders": 5}, nil)
+
+		reader := gortsplib.Client{}
+
+		u, err2 := base.ParseURL("rtsp://127.0.0.1:8554/cam1")
+		require.NoError(t, err2)
+
+		err2 = reader.Start(u.Scheme, u.Host)
+		require.NoError(t, err2)
+		defer reader.Close()
+
+		_, _, err2 = reader.Describe(u)
+		require.NoError(t, err2)
+	}()
+
+	p, ok := newInstance(conf)
+	require.Equal(t, true, ok)
+	defer p.Close()
+
+	var pathConf map[string]interface{}
+	httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/config/paths/get/runtime", nil, &pathConf)
+	require.Equal(t, float64(5), pathConf["maxReaders"])
+
+	// the on-demand source is started without readers
+	for i := 0; ; i++ {
+		var pa map[string]interface{}
+		httpRequest(t, hc, http.MethodGet, "http://localhost:9997/v3/paths/get/cam1", nil, &pa)
+
+		if pa["ready"] == true {
+			break
+		}
+
+		require.Less(t, i, 50)
+		time.Sleep(100 * time.Millisecond)
 	}
 }
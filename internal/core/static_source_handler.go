@@ -3,25 +3,44 @@ package core
 import (
 	"context"
 	"fmt"
+	"math"
+	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/coordinator"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	clustersource "github.com/bluenviron/mediamtx/internal/staticsources/cluster"
+	compositesource "github.com/bluenviron/mediamtx/internal/staticsources/composite"
+	delaysource "github.com/bluenviron/mediamtx/internal/staticsources/delay"
 	hlssource "github.com/bluenviron/mediamtx/internal/staticsources/hls"
+	playlistsource "github.com/bluenviron/mediamtx/internal/staticsources/playlist"
+	processorsource "github.com/bluenviron/mediamtx/internal/staticsources/processor"
 	rpicamerasource "github.com/bluenviron/mediamtx/internal/staticsources/rpicamera"
 	rtmpsource "github.com/bluenviron/mediamtx/internal/staticsources/rtmp"
 	rtspsource "github.com/bluenviron/mediamtx/internal/staticsources/rtsp"
 	srtsource "github.com/bluenviron/mediamtx/internal/staticsources/srt"
 	udpsource "github.com/bluenviron/mediamtx/internal/staticsources/udp"
 	webrtcsource "github.com/bluenviron/mediamtx/internal/staticsources/webrtc"
+	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
-const (
-	staticSourceHandlerRetryPause = 5 * time.Second
-)
+func staticSourceRetryPause(cnf *conf.Path, attempts int) time.Duration {
+	pause := float64(cnf.SourceRetryDelay) * math.Pow(cnf.SourceRetryMultiplier, float64(attempts-1))
+	if pause > float64(cnf.SourceRetryMaxDelay) {
+		pause = float64(cnf.SourceRetryMaxDelay)
+	}
+
+	if cnf.SourceRetryJitter != 0 {
+		pause *= 1 + cnf.SourceRetryJitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(pause)
+}
 
 func resolveSource(s string, matches []string, query string) string {
 	if len(matches) > 1 {
@@ -35,6 +54,10 @@ func resolveSource(s string, matches []string, query string) string {
 	return s
 }
 
+type staticSourceHandlerPathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
 type staticSourceHandlerParent interface {
 	logger.Writer
 	staticSourceHandlerSetReady(context.Context, defs.PathSourceStaticSetReadyReq)
@@ -49,14 +72,22 @@ type staticSourceHandler struct {
 	writeTimeout   conf.StringDuration
 	writeQueueSize int
 	matches        []string
+	pathName       string
+	coordinator    *coordinator.Coordinator
+	startLimiter   *staticSourceStartLimiter
+	pathManager    staticSourceHandlerPathManager
 	parent         staticSourceHandlerParent
 
 	ctx       context.Context
 	ctxCancel func()
 	instance  defs.StaticSource
 	running   bool
+	onDemand  bool
 	query     string
 
+	retryMutex sync.RWMutex
+	retry      defs.APIPathSourceRetry
+
 	// in
 	chReloadConf          chan *conf.Path
 	chInstanceSetReady    chan defs.PathSourceStaticSetReadyReq
@@ -115,6 +146,46 @@ func (s *staticSourceHandler) initialize() {
 			Parent:      s,
 		}
 
+	case s.conf.Source == "cluster":
+		s.instance = &clustersource.Source{
+			ReadTimeout:    s.readTimeout,
+			WriteTimeout:   s.writeTimeout,
+			WriteQueueSize: s.writeQueueSize,
+			PathName:       s.pathName,
+			Locator:        s.coordinator,
+			Parent:         s,
+		}
+
+	case s.conf.Source == "playlist":
+		s.instance = &playlistsource.Source{
+			WriteQueueSize: s.writeQueueSize,
+			PathManager:    s.pathManager,
+			Parent:         s,
+		}
+
+	case s.conf.Source == "composite":
+		s.instance = &compositesource.Source{
+			WriteQueueSize: s.writeQueueSize,
+			PathManager:    s.pathManager,
+			Parent:         s,
+		}
+
+	case s.conf.Source == "delay":
+		s.instance = &delaysource.Source{
+			WriteQueueSize: s.writeQueueSize,
+			PathManager:    s.pathManager,
+			Parent:         s,
+		}
+
+	case s.conf.Source == "processor":
+		s.instance = &processorsource.Source{
+			ReadTimeout:    s.readTimeout,
+			WriteTimeout:   s.writeTimeout,
+			WriteQueueSize: s.writeQueueSize,
+			PathManager:    s.pathManager,
+			Parent:         s,
+		}
+
 	case s.conf.Source == "rpiCamera":
 		s.instance = &rpicamerasource.Source{
 			LogLevel: s.logLevel,
@@ -136,6 +207,7 @@ func (s *staticSourceHandler) start(onDemand bool, query string) {
 	}
 
 	s.running = true
+	s.onDemand = onDemand
 	s.query = query
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 	s.done = make(chan struct{})
@@ -176,6 +248,7 @@ func (s *staticSourceHandler) run() {
 
 	var runCtx context.Context
 	var runCtxCancel func()
+	var startSlot *staticSourceStartSlot
 	runErr := make(chan error)
 	runReloadConf := make(chan *conf.Path)
 
@@ -183,7 +256,21 @@ func (s *staticSourceHandler) run() {
 		resolvedSource := resolveSource(s.conf.Source, s.matches, s.query)
 
 		runCtx, runCtxCancel = context.WithCancel(context.Background())
+
+		// on-demand sources are not limited, since readers are waiting for them
+		slot := &staticSourceStartSlot{}
+		if !s.onDemand {
+			slot = s.startLimiter.newSlot()
+		}
+		startSlot = slot
+
 		go func() {
+			err := slot.acquire(runCtx)
+			if err != nil {
+				runErr <- err
+				return
+			}
+
 			runErr <- s.instance.Run(defs.StaticSourceRunParams{
 				Context:        runCtx,
 				ResolvedSource: resolvedSource,
@@ -193,20 +280,47 @@ func (s *staticSourceHandler) run() {
 		}()
 	}
 
+	s.setRetry(defs.APIPathSourceRetry{State: defs.APIPathSourceRetryStateRunning})
 	recreate()
 
 	recreating := false
 	recreateTimer := emptyTimer()
+	attempts := 0
 
 	for {
 		select {
 		case err := <-runErr:
 			runCtxCancel()
+			startSlot.release()
 			s.instance.Log(logger.Error, err.Error())
 			recreating = true
-			recreateTimer = time.NewTimer(staticSourceHandlerRetryPause)
+			attempts++
+
+			if s.conf.SourceRetryMaxAttempts != 0 && attempts >= s.conf.SourceRetryMaxAttempts {
+				s.instance.Log(logger.Error, "giving up after %d attempts", attempts)
+				s.setRetry(defs.APIPathSourceRetry{
+					State:     defs.APIPathSourceRetryStateFailed,
+					Attempts:  attempts,
+					LastError: err.Error(),
+				})
+				continue
+			}
+
+			pause := staticSourceRetryPause(s.conf, attempts)
+			nextAttempt := time.Now().Add(pause)
+			s.setRetry(defs.APIPathSourceRetry{
+				State:       defs.APIPathSourceRetryStateWaiting,
+				Attempts:    attempts,
+				NextAttempt: &nextAttempt,
+				LastError:   err.Error(),
+			})
+			recreateTimer = time.NewTimer(pause)
 
 		case req := <-s.chInstanceSetReady:
+			// the source is working, reset the attempt counter
+			attempts = 0
+			startSlot.release()
+			s.setRetry(defs.APIPathSourceRetry{State: defs.APIPathSourceRetryStateRunning})
 			s.parent.staticSourceHandlerSetReady(s.ctx, req)
 
 		case req := <-s.chInstanceSetNotReady:
@@ -226,6 +340,10 @@ func (s *staticSourceHandler) run() {
 			}
 
 		case <-recreateTimer.C:
+			s.setRetry(defs.APIPathSourceRetry{
+				State:    defs.APIPathSourceRetryStateRunning,
+				Attempts: attempts,
+			})
 			recreate()
 			recreating = false
 
@@ -233,6 +351,7 @@ func (s *staticSourceHandler) run() {
 			if !recreating {
 				runCtxCancel()
 				<-runErr
+				startSlot.release()
 			}
 			return
 		}
@@ -254,6 +373,23 @@ func (s *staticSourceHandler) reloadConf(newConf *conf.Path) {
 	}()
 }
 
+func (s *staticSourceHandler) setRetry(retry defs.APIPathSourceRetry) {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+	s.retry = retry
+}
+
+func (s *staticSourceHandler) apiSourceRetry() *defs.APIPathSourceRetry {
+	if !s.running {
+		return nil
+	}
+
+	s.retryMutex.RLock()
+	defer s.retryMutex.RUnlock()
+	v := s.retry
+	return &v
+}
+
 // APISourceDescribe instanceements source.
 func (s *staticSourceHandler) APISourceDescribe() defs.APIPathSourceOrReader {
 	return s.instance.APISourceDescribe()
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticSourceRetryPause(t *testing.T) {
+	cnf := &conf.Path{
+		SourceRetryDelay:      conf.StringDuration(2 * time.Second),
+		SourceRetryMultiplier: 2,
+		SourceRetryMaxDelay:   conf.StringDuration(10 * time.Second),
+	}
+
+	for _, ca := range []struct {
+		attempts int
+		pause    time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{4, 10 * time.Second},
+		{10, 10 * time.Second},
+	} {
+		require.Equal(t, ca.pause, staticSourceRetryPause(cnf, ca.attempts))
+	}
+
+	cnf.SourceRetryJitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		pause := staticSourceRetryPause(cnf, 1)
+		require.GreaterOrEqual(t, pause, 1*time.Second)
+		require.LessOrEqual(t, pause, 3*time.Second)
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// staticSourceStartLimiter limits the number of static sources that are connecting at the same time.
+// A slot is taken when a source starts connecting and is released when the source is ready or fails.
+type staticSourceStartLimiter struct {
+	concurrency int
+
+	sem chan struct{}
+}
+
+func (l *staticSourceStartLimiter) initialize() {
+	if l.concurrency != 0 {
+		l.sem = make(chan struct{}, l.concurrency)
+	}
+}
+
+func (l *staticSourceStartLimiter) newSlot() *staticSourceStartSlot {
+	return &staticSourceStartSlot{limiter: l}
+}
+
+// staticSourceStartSlot is a slot of a staticSourceStartLimiter.
+type staticSourceStartSlot struct {
+	limiter *staticSourceStartLimiter
+
+	mutex    sync.Mutex
+	acquired bool
+	released bool
+}
+
+// acquire waits until the slot can be taken.
+func (s *staticSourceStartSlot) acquire(ctx context.Context) error {
+	if s.limiter == nil || s.limiter.sem == nil {
+		return nil
+	}
+
+	select {
+	case s.limiter.sem <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// slot has been released while waiting
+	if s.released {
+		<-s.limiter.sem
+		return nil
+	}
+
+	s.acquired = true
+	return nil
+}
+
+// release releases the slot. It can be called multiple times.
+func (s *staticSourceStartSlot) release() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.released {
+		return
+	}
+	s.released = true
+
+	if s.acquired {
+		<-s.limiter.sem
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticSourceStartLimiter(t *testing.T) {
+	l := &staticSourceStartLimiter{concurrency: 1}
+	l.initialize()
+
+	slot1 := l.newSlot()
+	err := slot1.acquire(context.Background())
+	require.NoError(t, err)
+
+	slot2 := l.newSlot()
+	acquired := make(chan struct{})
+
+	go func() {
+		err2 := slot2.acquire(context.Background())
+		require.NoError(t, err2)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Error("should not happen")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	slot1.release()
+	slot1.release()
+	<-acquired
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	ctxCancel()
+
+	slot3 := l.newSlot()
+	err = slot3.acquire(ctx)
+	require.Error(t, err)
+	slot3.release()
+
+	slot2.release()
+
+	slot4 := l.newSlot()
+	err = slot4.acquire(context.Background())
+	require.NoError(t, err)
+	slot4.release()
+}
+
+func TestStaticSourceStartLimiterUnlimited(t *testing.T) {
+	l := &staticSourceStartLimiter{}
+	l.initialize()
+
+	for i := 0; i < 10; i++ {
+		err := l.newSlot().acquire(context.Background())
+		require.NoError(t, err)
+	}
+}
@@ -20,23 +20,159 @@ type APIPathConfList struct {
 	Items     []*conf.Path `json:"items"`
 }
 
+// APIPathConfImport is a batch of path configurations to import.
+type APIPathConfImport struct {
+	Paths      map[string]*conf.OptionalPath `json:"paths"`
+	ReplaceAll bool                          `json:"replaceAll"`
+}
+
+// APIConfigChange is a configuration change.
+type APIConfigChange struct {
+	Key      string      `json:"key"`
+	OldValue interface{} `json:"oldValue"`
+	NewValue interface{} `json:"newValue"`
+}
+
+// APIConfigDryRun is the result of a dry run.
+type APIConfigDryRun struct {
+	Changes []*APIConfigChange `json:"changes"`
+}
+
 // APIPathSourceOrReader is a source or a reader.
 type APIPathSourceOrReader struct {
 	Type string `json:"type"`
 	ID   string `json:"id"`
 }
 
+// APIPathSourceRetryState is the retry state of a static source.
+type APIPathSourceRetryState string
+
+// retry states.
+const (
+	APIPathSourceRetryStateRunning APIPathSourceRetryState = "running"
+	APIPathSourceRetryStateWaiting APIPathSourceRetryState = "waiting"
+	APIPathSourceRetryStateFailed  APIPathSourceRetryState = "failed"
+)
+
+// APIPathSourceRetry contains the retry state of a static source.
+type APIPathSourceRetry struct {
+	State       APIPathSourceRetryState `json:"state"`
+	Attempts    int                     `json:"attempts"`
+	NextAttempt *time.Time              `json:"nextAttempt"`
+	LastError   string                  `json:"lastError"`
+}
+
+// APIPathAudioLevel is the level of an audio track.
+type APIPathAudioLevel struct {
+	Track    int     `json:"track"`
+	Codec    string  `json:"codec"`
+	Loudness float64 `json:"loudness"`
+	Peak     float64 `json:"peak"`
+}
+
+// APIPathVideoStatus is the status of the video of a path.
+type APIPathVideoStatus struct {
+	Frozen bool `json:"frozen"`
+	Black  bool `json:"black"`
+}
+
+// APIPathGOPStatus is the GOP status of a path.
+type APIPathGOPStatus struct {
+	OpenGOP              bool                `json:"openGOP"`
+	KeyframeInterval     conf.StringDuration `json:"keyframeInterval"`
+	LongKeyframeInterval bool                `json:"longKeyframeInterval"`
+}
+
 // APIPath is a path.
 type APIPath struct {
 	Name          string                  `json:"name"`
 	ConfName      string                  `json:"confName"`
 	Source        *APIPathSourceOrReader  `json:"source"`
+	SourceRetry   *APIPathSourceRetry     `json:"sourceRetry"`
 	Ready         bool                    `json:"ready"`
 	ReadyTime     *time.Time              `json:"readyTime"`
 	Tracks        []string                `json:"tracks"`
+	AudioLevels   []APIPathAudioLevel     `json:"audioLevels"`
+	VideoStatus   *APIPathVideoStatus     `json:"videoStatus"`
+	GOPStatus     *APIPathGOPStatus       `json:"gopStatus"`
 	BytesReceived uint64                  `json:"bytesReceived"`
 	BytesSent     uint64                  `json:"bytesSent"`
 	Readers       []APIPathSourceOrReader `json:"readers"`
+	WHEPReaders   int                     `json:"whepReaders"`
+	Draining      bool                    `json:"draining"`
+}
+
+// APIPathTrack is a track of a path.
+type APIPathTrack struct {
+	Type         string   `json:"type"`
+	Codec        string   `json:"codec"`
+	Profile      *string  `json:"profile"`
+	Level        *string  `json:"level"`
+	Width        *int     `json:"width"`
+	Height       *int     `json:"height"`
+	FrameRate    *float64 `json:"frameRate"`
+	VPS          []byte   `json:"vps"`
+	SPS          []byte   `json:"sps"`
+	PPS          []byte   `json:"pps"`
+	SampleRate   *int     `json:"sampleRate"`
+	ChannelCount *int     `json:"channelCount"`
+}
+
+// APIPathTrackList is a list of tracks of a path.
+type APIPathTrackList struct {
+	Items []APIPathTrack `json:"items"`
+}
+
+// APIPathConformanceTrack is the conformance report of a track.
+type APIPathConformanceTrack struct {
+	Type                string               `json:"type"`
+	Codec               string               `json:"codec"`
+	Units               int                  `json:"units"`
+	Keyframes           *int                 `json:"keyframes"`
+	MinKeyframeInterval *conf.StringDuration `json:"minKeyframeInterval"`
+	MaxKeyframeInterval *conf.StringDuration `json:"maxKeyframeInterval"`
+	BFrames             *bool                `json:"bFrames"`
+	ParameterChanges    *int                 `json:"parameterChanges"`
+	AudioGaps           *int                 `json:"audioGaps"`
+	MaxAudioGap         *conf.StringDuration `json:"maxAudioGap"`
+	TimestampJitter     conf.StringDuration  `json:"timestampJitter"`
+	Issues              []string             `json:"issues"`
+}
+
+// APIPathConformance is the conformance report of a path.
+type APIPathConformance struct {
+	Duration conf.StringDuration       `json:"duration"`
+	Tracks   []APIPathConformanceTrack `json:"tracks"`
+}
+
+// APIPathDrainReq is a request to drain a path.
+type APIPathDrainReq struct {
+	// defaults to 30 seconds
+	Timeout *conf.StringDuration `json:"timeout"`
+}
+
+// APIPathPTZ is the PTZ state of a path.
+type APIPathPTZ struct {
+	TourRunning bool   `json:"tourRunning"`
+	Preset      string `json:"preset"`
+}
+
+// APIPathPTZGotoPresetReq is a request to move a camera to a preset.
+type APIPathPTZGotoPresetReq struct {
+	Preset string `json:"preset"`
+}
+
+// APISubtitleCue is a subtitle cue.
+type APISubtitleCue struct {
+	// defaults to the current time
+	Start    *time.Time          `json:"start"`
+	Duration conf.StringDuration `json:"duration"`
+	Text     string              `json:"text"`
+}
+
+// APISubtitleCueList is a list of subtitle cues.
+type APISubtitleCueList struct {
+	Cues []APISubtitleCue `json:"cues"`
 }
 
 // APIPathList is a list of paths.
@@ -46,12 +182,34 @@ type APIPathList struct {
 	Items     []*APIPath `json:"items"`
 }
 
+// APIClusterPath is a path published by an instance of the cluster.
+type APIClusterPath struct {
+	Name     string `json:"name"`
+	Instance string `json:"instance"`
+	Readers  int    `json:"readers"`
+}
+
+// APIClusterPathList is a list of paths published by instances of the cluster.
+type APIClusterPathList struct {
+	ItemCount int               `json:"itemCount"`
+	PageCount int               `json:"pageCount"`
+	Items     []*APIClusterPath `json:"items"`
+}
+
 // APIHLSMuxer is an HLS muxer.
 type APIHLSMuxer struct {
-	Path        string    `json:"path"`
-	Created     time.Time `json:"created"`
-	LastRequest time.Time `json:"lastRequest"`
-	BytesSent   uint64    `json:"bytesSent"`
+	Path                  string    `json:"path"`
+	Created               time.Time `json:"created"`
+	LastRequest           time.Time `json:"lastRequest"`
+	BytesSent             uint64    `json:"bytesSent"`
+	SegmentTargetDuration float64   `json:"segmentTargetDuration"`
+	LastSegmentDuration   float64   `json:"lastSegmentDuration"`
+	SegmentsOverTarget    uint64    `json:"segmentsOverTarget"`
+	PartTargetDuration    float64   `json:"partTargetDuration"`
+	LastPartDuration      float64   `json:"lastPartDuration"`
+	PartsOverTarget       uint64    `json:"partsOverTarget"`
+	LatePartDeliveries    uint64    `json:"latePartDeliveries"`
+	PlaylistUpdateLatency float64   `json:"playlistUpdateLatency"`
 }
 
 // APIHLSMuxerList is a list of HLS muxers.
@@ -79,6 +237,7 @@ type APIRTMPConn struct {
 	State         APIRTMPConnState `json:"state"`
 	Path          string           `json:"path"`
 	Query         string           `json:"query"`
+	Paused        bool             `json:"paused"`
 	BytesReceived uint64           `json:"bytesReceived"`
 	BytesSent     uint64           `json:"bytesSent"`
 }
@@ -116,17 +275,37 @@ const (
 	APIRTSPSessionStatePublish APIRTSPSessionState = "publish"
 )
 
+// APIRTSPSessionEncryption is the encryption of the media of a RTSP session.
+type APIRTSPSessionEncryption string
+
+// encryptions.
+const (
+	APIRTSPSessionEncryptionNone APIRTSPSessionEncryption = "none"
+	APIRTSPSessionEncryptionTLS  APIRTSPSessionEncryption = "tls"
+)
+
+// APIRTSPSessionMedia is the transport negotiated for a media of a RTSP session.
+type APIRTSPSessionMedia struct {
+	ClientPorts      *[2]int `json:"clientPorts"`
+	ServerPorts      *[2]int `json:"serverPorts"`
+	InterleavedIDs   *[2]int `json:"interleavedIDs"`
+	MulticastAddress *string `json:"multicastAddress"`
+	MulticastPorts   *[2]int `json:"multicastPorts"`
+}
+
 // APIRTSPSession is a RTSP session.
 type APIRTSPSession struct {
-	ID            uuid.UUID           `json:"id"`
-	Created       time.Time           `json:"created"`
-	RemoteAddr    string              `json:"remoteAddr"`
-	State         APIRTSPSessionState `json:"state"`
-	Path          string              `json:"path"`
-	Query         string              `json:"query"`
-	Transport     *string             `json:"transport"`
-	BytesReceived uint64              `json:"bytesReceived"`
-	BytesSent     uint64              `json:"bytesSent"`
+	ID            uuid.UUID                `json:"id"`
+	Created       time.Time                `json:"created"`
+	RemoteAddr    string                   `json:"remoteAddr"`
+	State         APIRTSPSessionState      `json:"state"`
+	Path          string                   `json:"path"`
+	Query         string                   `json:"query"`
+	Transport     *string                  `json:"transport"`
+	Encryption    APIRTSPSessionEncryption `json:"encryption"`
+	Medias        []APIRTSPSessionMedia    `json:"medias"`
+	BytesReceived uint64                   `json:"bytesReceived"`
+	BytesSent     uint64                   `json:"bytesSent"`
 }
 
 // APIRTSPSessionList is a list of RTSP sessions.
@@ -136,6 +315,19 @@ type APIRTSPSessionList struct {
 	Items     []*APIRTSPSession `json:"items"`
 }
 
+// APIRTSPTransportFallback is a switch of RTSP clients to another transport.
+type APIRTSPTransportFallback struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count uint64 `json:"count"`
+}
+
+// APIRTSPTransportStats contains statistics about transports of RTSP clients.
+type APIRTSPTransportStats struct {
+	Rejections map[string]uint64          `json:"rejections"`
+	Fallbacks  []APIRTSPTransportFallback `json:"fallbacks"`
+}
+
 // APISRTConnState is the state of a SRT connection.
 type APISRTConnState string
 
@@ -154,6 +346,8 @@ type APISRTConn struct {
 	State      APISRTConnState `json:"state"`
 	Path       string          `json:"path"`
 	Query      string          `json:"query"`
+	Paused     bool            `json:"paused"`
+	DropReason string          `json:"dropReason"`
 
 	// The metric names/comments are pulled from GoSRT
 
@@ -304,6 +498,7 @@ type APIWebRTCSession struct {
 	State                     APIWebRTCSessionState `json:"state"`
 	Path                      string                `json:"path"`
 	Query                     string                `json:"query"`
+	Paused                    bool                  `json:"paused"`
 	BytesReceived             uint64                `json:"bytesReceived"`
 	BytesSent                 uint64                `json:"bytesSent"`
 }
@@ -332,3 +527,26 @@ type APIRecordingList struct {
 	PageCount int             `json:"pageCount"`
 	Items     []*APIRecording `json:"items"`
 }
+
+// APISignedURL is a signed URL.
+type APISignedURL struct {
+	Path    string    `json:"path"`
+	Action  string    `json:"action"`
+	Expires time.Time `json:"expires"`
+	Query   string    `json:"query"`
+}
+
+// APIBandwidthUser is the bandwidth usage of a user.
+type APIBandwidthUser struct {
+	User          string    `json:"user"`
+	Since         time.Time `json:"since"`
+	BytesReceived uint64    `json:"bytesReceived"`
+	BytesSent     uint64    `json:"bytesSent"`
+}
+
+// APIBandwidthUserList is a list of bandwidth usages of users.
+type APIBandwidthUserList struct {
+	ItemCount int                 `json:"itemCount"`
+	PageCount int                 `json:"pageCount"`
+	Items     []*APIBandwidthUser `json:"items"`
+}
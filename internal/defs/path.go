@@ -24,6 +24,52 @@ func (e PathNoOnePublishingError) Error() string {
 	return fmt.Sprintf("no one is publishing to path '%s'", e.PathName)
 }
 
+// PathMaxWHEPReadersError is returned when the maximum number of WHEP readers is reached.
+type PathMaxWHEPReadersError struct {
+	PathName string
+}
+
+// Error implements the error interface.
+func (e PathMaxWHEPReadersError) Error() string {
+	return fmt.Sprintf("maximum WHEP reader count of path '%s' reached", e.PathName)
+}
+
+// PathDrainingError is returned when a path is being drained.
+type PathDrainingError struct {
+	PathName string
+}
+
+// Error implements the error interface.
+func (e PathDrainingError) Error() string {
+	return fmt.Sprintf("path '%s' is being drained", e.PathName)
+}
+
+// PathProtocolNotAllowedError is returned when a protocol can't be used to publish or read a path.
+type PathProtocolNotAllowedError struct {
+	PathName string
+	Protocol string
+	Publish  bool
+}
+
+// Error implements the error interface.
+func (e PathProtocolNotAllowedError) Error() string {
+	if e.Publish {
+		return fmt.Sprintf("publishing to path '%s' with %s is not allowed", e.PathName, e.Protocol)
+	}
+	return fmt.Sprintf("reading from path '%s' with %s is not allowed", e.PathName, e.Protocol)
+}
+
+// PathUserAgentNotAllowedError is returned when the user agent of a client is denied by a path.
+type PathUserAgentNotAllowedError struct {
+	PathName  string
+	UserAgent string
+}
+
+// Error implements the error interface.
+func (e PathUserAgentNotAllowedError) Error() string {
+	return fmt.Sprintf("user agent '%s' is not allowed to access path '%s'", e.UserAgent, e.PathName)
+}
+
 // Path is a path.
 type Path interface {
 	Name() string
@@ -44,20 +90,25 @@ type PathAccessRequest struct {
 
 	// only if skipAuth = false
 	IP          net.IP
+	LocalIP     net.IP
 	User        string
 	Pass        string
 	Proto       auth.Protocol
 	ID          *uuid.UUID
 	RTSPRequest *base.Request
 	RTSPNonce   string
+
+	// User-Agent header (RTSP, HTTP) or flashVer (RTMP)
+	UserAgent string
 }
 
 // ToAuthRequest converts a path access request into an authentication request.
 func (r *PathAccessRequest) ToAuthRequest() *auth.Request {
 	return &auth.Request{
-		User: r.User,
-		Pass: r.Pass,
-		IP:   r.IP,
+		User:    r.User,
+		Pass:    r.Pass,
+		IP:      r.IP,
+		LocalIP: r.LocalIP,
 		Action: func() conf.AuthAction {
 			if r.Publish {
 				return conf.AuthActionPublish
@@ -73,16 +124,9 @@ func (r *PathAccessRequest) ToAuthRequest() *auth.Request {
 	}
 }
 
-// PathFindPathConfRes contains the response of FindPathConf().
-type PathFindPathConfRes struct {
-	Conf *conf.Path
-	Err  error
-}
-
 // PathFindPathConfReq contains arguments of FindPathConf().
 type PathFindPathConfReq struct {
 	AccessRequest PathAccessRequest
-	Res           chan PathFindPathConfRes
 }
 
 // PathDescribeRes contains the response of Describe().
@@ -5,3 +5,10 @@ type Reader interface {
 	Close()
 	APIReaderDescribe() APIPathSourceOrReader
 }
+
+// DrainableReader is a Reader that can notify its client that the path is being drained,
+// in order to allow the client to disconnect gracefully.
+type DrainableReader interface {
+	Reader
+	Drain()
+}
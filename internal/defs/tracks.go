@@ -0,0 +1,192 @@
+package defs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+)
+
+var h264Profiles = map[uint8]string{
+	66:  "Baseline",
+	77:  "Main",
+	88:  "Extended",
+	100: "High",
+	110: "High 10",
+	122: "High 4:2:2",
+	244: "High 4:4:4 Predictive",
+}
+
+var h265Profiles = map[uint8]string{
+	1: "Main",
+	2: "Main 10",
+	3: "Main Still Picture",
+	4: "Range Extensions",
+}
+
+var mpeg4AudioProfiles = map[mpeg4audio.ObjectType]string{
+	mpeg4audio.ObjectTypeAACLC: "AAC-LC",
+	mpeg4audio.ObjectTypeSBR:   "HE-AAC",
+	mpeg4audio.ObjectTypePS:    "HE-AACv2",
+}
+
+func profileName[T comparable](names map[T]string, v T) *string {
+	if n, ok := names[v]; ok {
+		return &n
+	}
+	n := fmt.Sprintf("%v", v)
+	return &n
+}
+
+func intToString(v *int) *string {
+	if v == nil {
+		return nil
+	}
+	s := strconv.FormatInt(int64(*v), 10)
+	return &s
+}
+
+func positiveInt(v int) *int {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+func positiveFloat(v float64) *float64 {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+func fillH264Track(t *APIPathTrack, forma *format.H264) {
+	sps, pps := forma.SafeParams()
+	t.SPS = sps
+	t.PPS = pps
+
+	if sps == nil {
+		return
+	}
+
+	var s h264.SPS
+	err := s.Unmarshal(sps)
+	if err != nil {
+		return
+	}
+
+	t.Profile = profileName(h264Profiles, s.ProfileIdc)
+	level := fmt.Sprintf("%d.%d", s.LevelIdc/10, s.LevelIdc%10)
+	t.Level = &level
+	t.Width = positiveInt(s.Width())
+	t.Height = positiveInt(s.Height())
+	t.FrameRate = positiveFloat(s.FPS())
+}
+
+func fillH265Track(t *APIPathTrack, forma *format.H265) {
+	vps, sps, pps := forma.SafeParams()
+	t.VPS = vps
+	t.SPS = sps
+	t.PPS = pps
+
+	if sps == nil {
+		return
+	}
+
+	var s h265.SPS
+	err := s.Unmarshal(sps)
+	if err != nil {
+		return
+	}
+
+	t.Profile = profileName(h265Profiles, s.ProfileTierLevel.GeneralProfileIdc)
+	// general_level_idc is 30 times the level number
+	level := fmt.Sprintf("%d.%d", s.ProfileTierLevel.GeneralLevelIdc/30, (s.ProfileTierLevel.GeneralLevelIdc%30)/3)
+	t.Level = &level
+	t.Width = positiveInt(s.Width())
+	t.Height = positiveInt(s.Height())
+	t.FrameRate = positiveFloat(s.FPS())
+}
+
+func fillMPEG4AudioTrack(t *APIPathTrack, forma *format.MPEG4Audio) {
+	conf := forma.Config
+
+	if forma.LATM && forma.StreamMuxConfig != nil &&
+		len(forma.StreamMuxConfig.Programs) != 0 && len(forma.StreamMuxConfig.Programs[0].Layers) != 0 {
+		conf = forma.StreamMuxConfig.Programs[0].Layers[0].AudioSpecificConfig
+	}
+
+	if conf == nil {
+		return
+	}
+
+	typ := conf.Type
+	sampleRate := conf.SampleRate
+	if conf.ExtensionType == mpeg4audio.ObjectTypeSBR || conf.ExtensionType == mpeg4audio.ObjectTypePS {
+		typ = conf.ExtensionType
+		sampleRate = conf.ExtensionSampleRate
+	}
+
+	t.Profile = profileName(mpeg4AudioProfiles, typ)
+	t.SampleRate = positiveInt(sampleRate)
+	t.ChannelCount = positiveInt(conf.ChannelCount)
+}
+
+// FormatToAPITrack returns a detailed description of a format.
+func FormatToAPITrack(media *description.Media, forma format.Format) APIPathTrack {
+	t := APIPathTrack{
+		Type:  string(media.Type),
+		Codec: forma.Codec(),
+	}
+
+	switch forma := forma.(type) {
+	case *format.H264:
+		fillH264Track(&t, forma)
+
+	case *format.H265:
+		fillH265Track(&t, forma)
+
+	case *format.VP9:
+		t.Profile = intToString(forma.ProfileID)
+
+	case *format.AV1:
+		t.Profile = intToString(forma.Profile)
+		t.Level = intToString(forma.LevelIdx)
+
+	case *format.MPEG4Audio:
+		fillMPEG4AudioTrack(&t, forma)
+
+	case *format.Opus:
+		t.SampleRate = positiveInt(forma.ClockRate())
+		t.ChannelCount = positiveInt(forma.ChannelCount)
+
+	case *format.G711:
+		t.SampleRate = positiveInt(forma.SampleRate)
+		t.ChannelCount = positiveInt(forma.ChannelCount)
+
+	case *format.LPCM:
+		t.SampleRate = positiveInt(forma.SampleRate)
+		t.ChannelCount = positiveInt(forma.ChannelCount)
+
+	case *format.AC3:
+		t.SampleRate = positiveInt(forma.SampleRate)
+		t.ChannelCount = positiveInt(forma.ChannelCount)
+	}
+
+	return t
+}
+
+// MediasToAPITracks returns a detailed description of the tracks of given medias.
+func MediasToAPITracks(medias []*description.Media) []APIPathTrack {
+	ret := []APIPathTrack{}
+	for _, media := range medias {
+		for _, forma := range media.Formats {
+			ret = append(ret, FormatToAPITrack(media, forma))
+		}
+	}
+	return ret
+}
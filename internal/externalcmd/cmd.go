@@ -30,6 +30,9 @@ type Cmd struct {
 
 	// in
 	terminate chan struct{}
+
+	// out
+	done chan struct{}
 }
 
 // NewCmd allocates a Cmd.
@@ -60,6 +63,7 @@ func NewCmd(
 		env:       env,
 		onExit:    onExit,
 		terminate: make(chan struct{}),
+		done:      make(chan struct{}),
 	}
 
 	pool.wg.Add(1)
@@ -74,8 +78,14 @@ func (e *Cmd) Close() {
 	close(e.terminate)
 }
 
+// Done returns a channel that is closed when the command has exited.
+func (e *Cmd) Done() <-chan struct{} {
+	return e.done
+}
+
 func (e *Cmd) run() {
 	defer e.pool.wg.Done()
+	defer close(e.done)
 
 	env := append([]string(nil), os.Environ()...)
 	for key, val := range e.env {
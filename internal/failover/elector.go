@@ -0,0 +1,190 @@
+// Package failover contains the elector, that elects the active instance between multiple instances.
+package failover
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+type lock interface {
+	tryAcquire(ctx context.Context) (bool, error)
+	renew(ctx context.Context) (bool, error)
+	release(ctx context.Context)
+}
+
+// Elector elects the active instance between multiple instances that share the same lock.
+// The instance that acquires the lock becomes active, while the others wait in standby
+// and take over when the lock is released or expires.
+type Elector struct {
+	Lock    string
+	Timeout conf.StringDuration
+	Parent  logger.Writer
+
+	ctx       context.Context
+	ctxCancel func()
+	lock      lock
+	active    atomic.Bool
+
+	// out
+	activated chan struct{}
+	lost      chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Elector.
+func (e *Elector) Initialize() error {
+	if strings.HasPrefix(e.Lock, "redis://") || strings.HasPrefix(e.Lock, "rediss://") {
+		opts, err := redis.ParseURL(e.Lock)
+		if err != nil {
+			return err
+		}
+
+		hostname, _ := os.Hostname()
+
+		e.lock = &redisLock{
+			client:  redis.NewClient(opts),
+			id:      hostname + ":" + strconv.Itoa(os.Getpid()),
+			timeout: time.Duration(e.Timeout),
+		}
+	} else {
+		e.lock = &fileLock{
+			path: e.Lock,
+		}
+	}
+
+	e.ctx, e.ctxCancel = context.WithCancel(context.Background())
+	e.activated = make(chan struct{}, 1)
+	e.lost = make(chan struct{}, 1)
+	e.done = make(chan struct{})
+
+	e.Log(logger.Info, "waiting to become the active instance")
+
+	go e.run()
+
+	return nil
+}
+
+// Close closes Elector and releases the lock.
+func (e *Elector) Close() {
+	e.ctxCancel()
+	<-e.done
+}
+
+// Log implements logger.Writer.
+func (e *Elector) Log(level logger.Level, format string, args ...interface{}) {
+	e.Parent.Log(level, "[failover] "+format, args...)
+}
+
+// IsActive returns whether this instance is the active one.
+func (e *Elector) IsActive() bool {
+	return e.active.Load()
+}
+
+// Activated returns a channel that is written when this instance becomes the active one.
+func (e *Elector) Activated() chan struct{} {
+	return e.activated
+}
+
+// Lost returns a channel that is written when this instance loses the lock.
+func (e *Elector) Lost() chan struct{} {
+	return e.lost
+}
+
+func (e *Elector) run() {
+	defer close(e.done)
+
+	period := time.Duration(e.Timeout) / 3
+
+	if !e.runAcquire(period) {
+		e.lock.release(context.Background())
+		return
+	}
+
+	e.active.Store(true)
+	e.activated <- struct{}{}
+	e.Log(logger.Info, "this instance is now the active one")
+
+	if !e.runRenew(period) {
+		e.active.Store(false)
+		e.lost <- struct{}{}
+		return
+	}
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), period)
+	defer ctxCancel()
+	e.lock.release(ctx)
+}
+
+func (e *Elector) runAcquire(period time.Duration) bool {
+	failing := false
+
+	for {
+		ok, err := func() (bool, error) {
+			ctx, ctxCancel := context.WithTimeout(e.ctx, period)
+			defer ctxCancel()
+			return e.lock.tryAcquire(ctx)
+		}()
+		if err != nil {
+			if !failing {
+				e.Log(logger.Error, "unable to acquire lock: %v", err)
+				failing = true
+			}
+		} else {
+			failing = false
+
+			if ok {
+				return true
+			}
+		}
+
+		select {
+		case <-time.After(period):
+		case <-e.ctx.Done():
+			return false
+		}
+	}
+}
+
+func (e *Elector) runRenew(period time.Duration) bool {
+	lastRenew := time.Now()
+
+	for {
+		select {
+		case <-time.After(period):
+		case <-e.ctx.Done():
+			return true
+		}
+
+		ok, err := func() (bool, error) {
+			ctx, ctxCancel := context.WithTimeout(e.ctx, period)
+			defer ctxCancel()
+			return e.lock.renew(ctx)
+		}()
+		if err != nil {
+			e.Log(logger.Warn, "unable to renew lock: %v", err)
+
+			// the lock may have expired and may have been acquired by another instance
+			if time.Since(lastRenew) >= time.Duration(e.Timeout) {
+				e.Log(logger.Error, "lock lost")
+				return false
+			}
+			continue
+		}
+
+		if !ok {
+			e.Log(logger.Error, "lock lost")
+			return false
+		}
+
+		lastRenew = time.Now()
+	}
+}
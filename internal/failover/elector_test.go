@@ -0,0 +1,73 @@
+package failover
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestElectorRedis(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	e1 := &Elector{
+		Lock:    "redis://" + srv.Addr(),
+		Timeout: conf.StringDuration(1 * time.Second),
+		Parent:  test.NilLogger,
+	}
+	err := e1.Initialize()
+	require.NoError(t, err)
+
+	<-e1.Activated()
+	require.Equal(t, true, e1.IsActive())
+
+	e2 := &Elector{
+		Lock:    "redis://" + srv.Addr(),
+		Timeout: conf.StringDuration(1 * time.Second),
+		Parent:  test.NilLogger,
+	}
+	err = e2.Initialize()
+	require.NoError(t, err)
+	defer e2.Close()
+
+	time.Sleep(500 * time.Millisecond)
+	require.Equal(t, false, e2.IsActive())
+
+	e1.Close()
+
+	select {
+	case <-e2.Activated():
+	case <-time.After(2 * time.Second):
+		t.Errorf("should not happen")
+	}
+}
+
+func TestElectorRedisLost(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	e := &Elector{
+		Lock:    "redis://" + srv.Addr(),
+		Timeout: conf.StringDuration(1 * time.Second),
+		Parent:  test.NilLogger,
+	}
+	err := e.Initialize()
+	require.NoError(t, err)
+	defer e.Close()
+
+	<-e.Activated()
+
+	// simulate another instance that acquires the lock
+	err = srv.Set(redisLockKey, "other")
+	require.NoError(t, err)
+
+	select {
+	case <-e.Lost():
+	case <-time.After(2 * time.Second):
+		t.Errorf("should not happen")
+	}
+	require.Equal(t, false, e.IsActive())
+}
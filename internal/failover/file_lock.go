@@ -0,0 +1,48 @@
+package failover
+
+import (
+	"context"
+	"os"
+	"strconv"
+)
+
+// fileLock is a lock based on a file.
+// The lock is released by the operating system when the process exits,
+// therefore it can't be lost.
+type fileLock struct {
+	path string
+
+	f *os.File
+}
+
+func (l *fileLock) tryAcquire(_ context.Context) (bool, error) {
+	if l.f == nil {
+		var err error
+		l.f, err = os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	ok, err := tryLockFile(l.f)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	// store the PID for debugging purposes
+	l.f.Truncate(0)                                        //nolint:errcheck
+	l.f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0) //nolint:errcheck
+
+	return true, nil
+}
+
+func (l *fileLock) renew(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+func (l *fileLock) release(_ context.Context) {
+	if l.f != nil {
+		l.f.Close()
+		l.f = nil
+	}
+}
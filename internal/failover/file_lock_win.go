@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package failover
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func tryLockFile(f *os.File) (bool, error) {
+	ol := new(windows.Overlapped)
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if err != nil {
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
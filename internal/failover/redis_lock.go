@@ -0,0 +1,54 @@
+package failover
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisLockKey = "mediamtx:leader"
+)
+
+// extends the expiration of the key only if it is still owned by this instance.
+var redisRenewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// deletes the key only if it is still owned by this instance.
+var redisReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// redisLock is a lock based on a Redis key with an expiration.
+// The owner periodically extends the expiration, and the lock is lost
+// when the key expires or is taken by another instance.
+type redisLock struct {
+	client  *redis.Client
+	id      string
+	timeout time.Duration
+}
+
+func (l *redisLock) tryAcquire(ctx context.Context) (bool, error) {
+	return l.client.SetNX(ctx, redisLockKey, l.id, l.timeout).Result()
+}
+
+func (l *redisLock) renew(ctx context.Context) (bool, error) {
+	res, err := redisRenewScript.Run(ctx, l.client, []string{redisLockKey}, l.id, l.timeout.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (l *redisLock) release(ctx context.Context) {
+	redisReleaseScript.Run(ctx, l.client, []string{redisLockKey}, l.id) //nolint:errcheck
+	l.client.Close()
+}
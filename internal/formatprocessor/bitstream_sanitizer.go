@@ -0,0 +1,148 @@
+package formatprocessor
+
+import (
+	"fmt"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+func h264CheckNALU(nalu []byte) error {
+	if len(nalu) == 0 {
+		return fmt.Errorf("empty NALU")
+	}
+
+	if (nalu[0] & 0x80) != 0 {
+		return fmt.Errorf("forbidden bit is set")
+	}
+
+	typ := h264.NALUType(nalu[0] & 0x1F)
+
+	switch {
+	case typ == 0 || typ >= 24:
+		return fmt.Errorf("invalid NALU type %d", typ)
+
+	case typ == h264.NALUTypeSPS:
+		var sps h264.SPS
+		err := sps.Unmarshal(nalu)
+		if err != nil {
+			return fmt.Errorf("invalid SPS: %w", err)
+		}
+
+	case typ == h264.NALUTypePPS:
+		if len(nalu) < 2 {
+			return fmt.Errorf("invalid PPS")
+		}
+	}
+
+	return nil
+}
+
+func h265CheckNALU(nalu []byte) error {
+	if len(nalu) < 2 {
+		return fmt.Errorf("NALU is too short")
+	}
+
+	if (nalu[0] & 0x80) != 0 {
+		return fmt.Errorf("forbidden bit is set")
+	}
+
+	if (nalu[1] & 0b111) == 0 {
+		return fmt.Errorf("invalid temporal ID")
+	}
+
+	typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+
+	switch {
+	case typ >= h265.NALUType_AggregationUnit:
+		return fmt.Errorf("invalid NALU type %d", typ)
+
+	case typ == h265.NALUType_VPS_NUT:
+		if len(nalu) < 6 {
+			return fmt.Errorf("invalid VPS")
+		}
+
+	case typ == h265.NALUType_SPS_NUT:
+		var sps h265.SPS
+		err := sps.Unmarshal(nalu)
+		if err != nil {
+			return fmt.Errorf("invalid SPS: %w", err)
+		}
+
+	case typ == h265.NALUType_PPS_NUT:
+		var pps h265.PPS
+		err := pps.Unmarshal(nalu)
+		if err != nil {
+			return fmt.Errorf("invalid PPS: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// bitstreamSanitizer drops malformed NALUs before they reach muxers
+// and keeps statistics about them.
+type bitstreamSanitizer struct {
+	checkNALU func([]byte) error
+	parent    logger.Writer
+
+	droppedCount uint64
+}
+
+func newH264Sanitizer(parent logger.Writer) *bitstreamSanitizer {
+	return &bitstreamSanitizer{
+		checkNALU: h264CheckNALU,
+		parent:    parent,
+	}
+}
+
+func newH265Sanitizer(parent logger.Writer) *bitstreamSanitizer {
+	return &bitstreamSanitizer{
+		checkNALU: h265CheckNALU,
+		parent:    parent,
+	}
+}
+
+// isValid checks a single NALU, without touching statistics.
+func (s *bitstreamSanitizer) isValid(nalu []byte) bool {
+	return s.checkNALU(nalu) == nil
+}
+
+// filter removes malformed NALUs from an access unit.
+func (s *bitstreamSanitizer) filter(au [][]byte) [][]byte {
+	var firstErr error
+	dropped := 0
+
+	for _, nalu := range au {
+		err := s.checkNALU(nalu)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			dropped++
+		}
+	}
+
+	if dropped == 0 {
+		return au
+	}
+
+	s.droppedCount += uint64(dropped)
+	s.parent.Log(logger.Warn, "dropped %d malformed NALUs (%v), %d dropped since the stream started",
+		dropped, firstErr, s.droppedCount)
+
+	if dropped == len(au) {
+		return nil
+	}
+
+	filtered := make([][]byte, 0, len(au)-dropped)
+	for _, nalu := range au {
+		if s.checkNALU(nalu) == nil {
+			filtered = append(filtered, nalu)
+		}
+	}
+
+	return filtered
+}
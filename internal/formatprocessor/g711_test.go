@@ -18,7 +18,7 @@ func TestG611Encode(t *testing.T) {
 			ChannelCount: 1,
 		}
 
-		p, err := New(1472, forma, true)
+		p, err := New(1472, forma, true, Options{}, nil)
 		require.NoError(t, err)
 
 		unit := &unit.G711{
@@ -47,7 +47,7 @@ func TestG611Encode(t *testing.T) {
 			ChannelCount: 1,
 		}
 
-		p, err := New(1472, forma, true)
+		p, err := New(1472, forma, true, Options{}, nil)
 		require.NoError(t, err)
 
 		unit := &unit.G711{
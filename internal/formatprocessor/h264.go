@@ -11,6 +11,7 @@ import (
 	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
@@ -88,16 +89,30 @@ type formatProcessorH264 struct {
 	timeEncoder       *rtptime.Encoder
 	encoder           *rtph264.Encoder
 	decoder           *rtph264.Decoder
+	sanitizer         *bitstreamSanitizer
+
+	insertParameterSets bool
+	onParametersChange  func()
 }
 
 func newH264(
 	udpMaxPayloadSize int,
 	forma *format.H264,
 	generateRTPPackets bool,
+	sanitizeBitstream bool,
+	insertParameterSets bool,
+	onParametersChange func(),
+	parent logger.Writer,
 ) (*formatProcessorH264, error) {
 	t := &formatProcessorH264{
-		udpMaxPayloadSize: udpMaxPayloadSize,
-		format:            forma,
+		udpMaxPayloadSize:   udpMaxPayloadSize,
+		format:              forma,
+		insertParameterSets: insertParameterSets,
+		onParametersChange:  onParametersChange,
+	}
+
+	if sanitizeBitstream {
+		t.sanitizer = newH264Sanitizer(parent)
 	}
 
 	if generateRTPPackets {
@@ -132,9 +147,30 @@ func (t *formatProcessorH264) createEncoder(
 	return t.encoder.Init()
 }
 
+func (t *formatProcessorH264) setParams(sps []byte, pps []byte) {
+	// parameters that are set for the first time are not a change
+	changed := t.format.SPS != nil && t.format.PPS != nil
+
+	t.format.SafeSetParams(sps, pps)
+
+	if changed && t.onParametersChange != nil {
+		t.onParametersChange()
+	}
+}
+
 func (t *formatProcessorH264) updateTrackParametersFromRTPPacket(payload []byte) {
 	sps, pps := rtpH264ExtractParams(payload)
 
+	// do not replace cached parameters with corrupted ones
+	if t.sanitizer != nil {
+		if sps != nil && !t.sanitizer.isValid(sps) {
+			sps = nil
+		}
+		if pps != nil && !t.sanitizer.isValid(pps) {
+			pps = nil
+		}
+	}
+
 	if (sps != nil && !bytes.Equal(sps, t.format.SPS)) ||
 		(pps != nil && !bytes.Equal(pps, t.format.PPS)) {
 		if sps == nil {
@@ -143,7 +179,7 @@ func (t *formatProcessorH264) updateTrackParametersFromRTPPacket(payload []byte)
 		if pps == nil {
 			pps = t.format.PPS
 		}
-		t.format.SafeSetParams(sps, pps)
+		t.setParams(sps, pps)
 	}
 }
 
@@ -171,7 +207,7 @@ func (t *formatProcessorH264) updateTrackParametersFromAU(au [][]byte) {
 	}
 
 	if update {
-		t.format.SafeSetParams(sps, pps)
+		t.setParams(sps, pps)
 	}
 }
 
@@ -236,6 +272,10 @@ func (t *formatProcessorH264) remuxAccessUnit(au [][]byte) [][]byte {
 func (t *formatProcessorH264) ProcessUnit(uu unit.Unit) error {
 	u := uu.(*unit.H264)
 
+	if t.sanitizer != nil {
+		u.AU = t.sanitizer.filter(u.AU)
+	}
+
 	t.updateTrackParametersFromAU(u.AU)
 	u.AU = t.remuxAccessUnit(u.AU)
 
@@ -276,8 +316,9 @@ func (t *formatProcessorH264) ProcessRTPPacket( //nolint:dupl
 		pkt.Header.Padding = false
 		pkt.PaddingSize = 0
 
-		// RTP packets exceed maximum size: start re-encoding them
-		if pkt.MarshalSize() > t.udpMaxPayloadSize {
+		// RTP packets exceed maximum size, or parameters must be inserted
+		// before IDRs sent to RTSP readers: start re-encoding them
+		if pkt.MarshalSize() > t.udpMaxPayloadSize || t.insertParameterSets {
 			v1 := pkt.SSRC
 			v2 := pkt.SequenceNumber
 			err := t.createEncoder(&v1, &v2)
@@ -311,6 +352,10 @@ func (t *formatProcessorH264) ProcessRTPPacket( //nolint:dupl
 			return nil, err
 		}
 
+		if t.sanitizer != nil {
+			au = t.sanitizer.filter(au)
+		}
+
 		u.AU = t.remuxAccessUnit(au)
 	}
 
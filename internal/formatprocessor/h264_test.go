@@ -2,6 +2,7 @@ package formatprocessor
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 
+	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
@@ -21,7 +23,7 @@ func TestH264DynamicParams(t *testing.T) {
 				PacketizationMode: 1,
 			}
 
-			p, err := New(1472, forma, false)
+			p, err := New(1472, forma, false, Options{}, nil)
 			require.NoError(t, err)
 
 			enc, err := forma.CreateEncoder()
@@ -78,6 +80,54 @@ func TestH264DynamicParams(t *testing.T) {
 	}
 }
 
+func TestH264OnParametersChange(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}
+
+	changes := 0
+
+	p, err := New(1472, forma, true, Options{
+		OnParametersChange: func(f format.Format) {
+			require.Equal(t, forma, f)
+			changes++
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	// parameters that are set for the first time are not a change
+	err = p.ProcessUnit(&unit.H264{
+		AU: [][]byte{
+			{7, 4, 5, 6}, // SPS
+			{8, 1},       // PPS
+			{byte(h264.NALUTypeIDR)},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, changes)
+
+	err = p.ProcessUnit(&unit.H264{
+		AU: [][]byte{
+			{7, 4, 5, 6}, // SPS
+			{8, 1},       // PPS
+			{byte(h264.NALUTypeIDR)},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, changes)
+
+	err = p.ProcessUnit(&unit.H264{
+		AU: [][]byte{
+			{7, 4, 5, 7}, // SPS
+			{8, 1},       // PPS
+			{byte(h264.NALUTypeIDR)},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, changes)
+}
+
 func TestH264OversizedPackets(t *testing.T) {
 	forma := &format.H264{
 		PayloadTyp:        96,
@@ -86,7 +136,7 @@ func TestH264OversizedPackets(t *testing.T) {
 		PacketizationMode: 1,
 	}
 
-	p, err := New(1472, forma, false)
+	p, err := New(1472, forma, false, Options{}, nil)
 	require.NoError(t, err)
 
 	var out []*rtp.Packet
@@ -184,7 +234,7 @@ func TestH264EmptyPacket(t *testing.T) {
 		PacketizationMode: 1,
 	}
 
-	p, err := New(1472, forma, true)
+	p, err := New(1472, forma, true, Options{}, nil)
 	require.NoError(t, err)
 
 	unit := &unit.H264{
@@ -201,6 +251,86 @@ func TestH264EmptyPacket(t *testing.T) {
 	require.Equal(t, []*rtp.Packet(nil), unit.RTPPackets)
 }
 
+func TestH264InsertParameterSets(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		SPS:               H264DefaultSPS,
+		PPS:               H264DefaultPPS,
+		PacketizationMode: 1,
+	}
+
+	p, err := New(1472, forma, false, Options{InsertParameterSets: true}, nil)
+	require.NoError(t, err)
+
+	enc, err := forma.CreateEncoder()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode([][]byte{{byte(h264.NALUTypeIDR), 0x01}})
+	require.NoError(t, err)
+
+	data, err := p.ProcessRTPPacket(pkts[0], time.Time{}, 0, false)
+	require.NoError(t, err)
+
+	dec, err := forma.CreateDecoder()
+	require.NoError(t, err)
+
+	var au [][]byte
+	for _, pkt := range data.GetRTPPackets() {
+		au, err = dec.Decode(pkt)
+	}
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{
+		H264DefaultSPS,
+		H264DefaultPPS,
+		{byte(h264.NALUTypeIDR), 0x01},
+	}, au)
+}
+
+type testLogger struct {
+	msgs []string
+}
+
+func (l *testLogger) Log(_ logger.Level, format string, args ...interface{}) {
+	l.msgs = append(l.msgs, fmt.Sprintf(format, args...))
+}
+
+func TestH264SanitizeBitstream(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		SPS:               H264DefaultSPS,
+		PPS:               H264DefaultPPS,
+		PacketizationMode: 1,
+	}
+
+	l := &testLogger{}
+
+	p, err := New(1472, forma, true, Options{SanitizeBitstream: true}, l)
+	require.NoError(t, err)
+
+	unit := &unit.H264{
+		AU: [][]byte{
+			{},                       // empty
+			{0x07, 0x01, 0x02, 0x03}, // corrupted SPS
+			{0x85, 0x01},             // forbidden bit
+			{0x05, 0x01},             // IDR
+		},
+	}
+
+	err = p.ProcessUnit(unit)
+	require.NoError(t, err)
+
+	require.Equal(t, [][]byte{
+		H264DefaultSPS,
+		H264DefaultPPS,
+		{0x05, 0x01},
+	}, unit.AU)
+
+	require.Equal(t, H264DefaultSPS, forma.SPS)
+	require.Equal(t, 1, len(l.msgs))
+	require.Contains(t, l.msgs[0], "dropped 3 malformed NALUs")
+}
+
 func FuzzRTPH264ExtractParams(f *testing.F) {
 	f.Fuzz(func(_ *testing.T, b []byte) {
 		rtpH264ExtractParams(b)
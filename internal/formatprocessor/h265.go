@@ -11,6 +11,7 @@ import (
 	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
@@ -108,16 +109,30 @@ type formatProcessorH265 struct {
 	timeEncoder       *rtptime.Encoder
 	encoder           *rtph265.Encoder
 	decoder           *rtph265.Decoder
+	sanitizer         *bitstreamSanitizer
+
+	insertParameterSets bool
+	onParametersChange  func()
 }
 
 func newH265(
 	udpMaxPayloadSize int,
 	forma *format.H265,
 	generateRTPPackets bool,
+	sanitizeBitstream bool,
+	insertParameterSets bool,
+	onParametersChange func(),
+	parent logger.Writer,
 ) (*formatProcessorH265, error) {
 	t := &formatProcessorH265{
-		udpMaxPayloadSize: udpMaxPayloadSize,
-		format:            forma,
+		udpMaxPayloadSize:   udpMaxPayloadSize,
+		format:              forma,
+		insertParameterSets: insertParameterSets,
+		onParametersChange:  onParametersChange,
+	}
+
+	if sanitizeBitstream {
+		t.sanitizer = newH265Sanitizer(parent)
 	}
 
 	if generateRTPPackets {
@@ -152,9 +167,33 @@ func (t *formatProcessorH265) createEncoder(
 	return t.encoder.Init()
 }
 
+func (t *formatProcessorH265) setParams(vps []byte, sps []byte, pps []byte) {
+	// parameters that are set for the first time are not a change
+	changed := t.format.VPS != nil && t.format.SPS != nil && t.format.PPS != nil
+
+	t.format.SafeSetParams(vps, sps, pps)
+
+	if changed && t.onParametersChange != nil {
+		t.onParametersChange()
+	}
+}
+
 func (t *formatProcessorH265) updateTrackParametersFromRTPPacket(payload []byte) {
 	vps, sps, pps := rtpH265ExtractParams(payload)
 
+	// do not replace cached parameters with corrupted ones
+	if t.sanitizer != nil {
+		if vps != nil && !t.sanitizer.isValid(vps) {
+			vps = nil
+		}
+		if sps != nil && !t.sanitizer.isValid(sps) {
+			sps = nil
+		}
+		if pps != nil && !t.sanitizer.isValid(pps) {
+			pps = nil
+		}
+	}
+
 	if (vps != nil && !bytes.Equal(vps, t.format.VPS)) ||
 		(sps != nil && !bytes.Equal(sps, t.format.SPS)) ||
 		(pps != nil && !bytes.Equal(pps, t.format.PPS)) {
@@ -167,7 +206,7 @@ func (t *formatProcessorH265) updateTrackParametersFromRTPPacket(payload []byte)
 		if pps == nil {
 			pps = t.format.PPS
 		}
-		t.format.SafeSetParams(vps, sps, pps)
+		t.setParams(vps, sps, pps)
 	}
 }
 
@@ -202,7 +241,7 @@ func (t *formatProcessorH265) updateTrackParametersFromAU(au [][]byte) {
 	}
 
 	if update {
-		t.format.SafeSetParams(vps, sps, pps)
+		t.setParams(vps, sps, pps)
 	}
 }
 
@@ -268,6 +307,10 @@ func (t *formatProcessorH265) remuxAccessUnit(au [][]byte) [][]byte {
 func (t *formatProcessorH265) ProcessUnit(uu unit.Unit) error { //nolint:dupl
 	u := uu.(*unit.H265)
 
+	if t.sanitizer != nil {
+		u.AU = t.sanitizer.filter(u.AU)
+	}
+
 	t.updateTrackParametersFromAU(u.AU)
 	u.AU = t.remuxAccessUnit(u.AU)
 
@@ -308,8 +351,9 @@ func (t *formatProcessorH265) ProcessRTPPacket( //nolint:dupl
 		pkt.Header.Padding = false
 		pkt.PaddingSize = 0
 
-		// RTP packets exceed maximum size: start re-encoding them
-		if pkt.MarshalSize() > t.udpMaxPayloadSize {
+		// RTP packets exceed maximum size, or parameters must be inserted
+		// before IDRs sent to RTSP readers: start re-encoding them
+		if pkt.MarshalSize() > t.udpMaxPayloadSize || t.insertParameterSets {
 			v1 := pkt.SSRC
 			v2 := pkt.SequenceNumber
 			err := t.createEncoder(&v1, &v2)
@@ -343,6 +387,10 @@ func (t *formatProcessorH265) ProcessRTPPacket( //nolint:dupl
 			return nil, err
 		}
 
+		if t.sanitizer != nil {
+			au = t.sanitizer.filter(au)
+		}
+
 		u.AU = t.remuxAccessUnit(au)
 	}
 
@@ -20,7 +20,7 @@ func TestH265DynamicParams(t *testing.T) {
 				PayloadTyp: 96,
 			}
 
-			p, err := New(1472, forma, false)
+			p, err := New(1472, forma, false, Options{}, nil)
 			require.NoError(t, err)
 
 			enc, err := forma.CreateEncoder()
@@ -94,7 +94,7 @@ func TestH265OversizedPackets(t *testing.T) {
 		PPS:        []byte{byte(h265.NALUType_PPS_NUT) << 1, 16, 17, 18},
 	}
 
-	p, err := New(1472, forma, false)
+	p, err := New(1472, forma, false, Options{}, nil)
 	require.NoError(t, err)
 
 	var out []*rtp.Packet
@@ -179,7 +179,7 @@ func TestH265EmptyPacket(t *testing.T) {
 		PayloadTyp: 96,
 	}
 
-	p, err := New(1472, forma, true)
+	p, err := New(1472, forma, true, Options{}, nil)
 	require.NoError(t, err)
 
 	unit := &unit.H265{
@@ -12,32 +12,149 @@ import (
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
+// lpcmConvert converts samples to big endian byte order and to another bit depth.
+func lpcmConvert(samples []byte, inBitDepth int, littleEndian bool, outBitDepth int) ([]byte, error) {
+	inSize := inBitDepth / 8
+	outSize := outBitDepth / 8
+
+	if (len(samples) % inSize) != 0 {
+		return nil, fmt.Errorf("invalid LPCM payload length: %d", len(samples))
+	}
+
+	n := len(samples) / inSize
+	out := make([]byte, n*outSize)
+
+	for i := 0; i < n; i++ {
+		in := samples[i*inSize : (i+1)*inSize]
+		dst := out[i*outSize : (i+1)*outSize]
+
+		// copy most significant bytes, fill least significant ones with zeros.
+		for j := 0; j < outSize && j < inSize; j++ {
+			if littleEndian {
+				dst[j] = in[inSize-1-j]
+			} else {
+				dst[j] = in[j]
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// lpcmMapChannels selects and mixes channels of big endian samples.
+// Mixed channels are averaged.
+func lpcmMapChannels(samples []byte, bitDepth int, inChannelCount int, channelMap [][]int) []byte {
+	sampleSize := bitDepth / 8
+	inFrameSize := sampleSize * inChannelCount
+	outFrameSize := sampleSize * len(channelMap)
+	n := len(samples) / inFrameSize
+	out := make([]byte, n*outFrameSize)
+
+	for i := 0; i < n; i++ {
+		in := samples[i*inFrameSize : (i+1)*inFrameSize]
+
+		for j, channels := range channelMap {
+			sum := int64(0)
+
+			for _, ch := range channels {
+				src := in[(ch-1)*sampleSize : ch*sampleSize]
+				v := int64(int8(src[0]))
+				for k := 1; k < sampleSize; k++ {
+					v = v<<8 | int64(src[k])
+				}
+				sum += v
+			}
+
+			v := sum / int64(len(channels))
+			dst := out[i*outFrameSize+j*sampleSize : i*outFrameSize+(j+1)*sampleSize]
+			for k := sampleSize - 1; k >= 0; k-- {
+				dst[k] = byte(v)
+				v >>= 8
+			}
+		}
+	}
+
+	return out
+}
+
 type formatProcessorLPCM struct {
 	udpMaxPayloadSize int
 	format            *format.LPCM
 	timeEncoder       *rtptime.Encoder
 	encoder           *rtplpcm.Encoder
 	decoder           *rtplpcm.Decoder
+
+	convert        bool
+	inBitDepth     int
+	inLittleEndian bool
+	inChannelCount int
+	channelMap     [][]int
 }
 
 func newLPCM(
 	udpMaxPayloadSize int,
 	forma *format.LPCM,
 	generateRTPPackets bool,
+	littleEndian bool,
+	bitDepth int,
+	channelMap [][]int,
 ) (*formatProcessorLPCM, error) {
 	t := &formatProcessorLPCM{
 		udpMaxPayloadSize: udpMaxPayloadSize,
 		format:            forma,
+		inBitDepth:        forma.BitDepth,
+		inLittleEndian:    littleEndian,
+		inChannelCount:    forma.ChannelCount,
+	}
+
+	// readers receive converted samples, that are described by a copy of the format,
+	// in order not to alter the format of the publisher.
+	if littleEndian || (bitDepth != 0 && bitDepth != forma.BitDepth) || len(channelMap) != 0 {
+		out := *forma
+		t.format = &out
+	}
+
+	if littleEndian || (bitDepth != 0 && bitDepth != forma.BitDepth) {
+		switch forma.BitDepth {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("conversion of %d-bit LPCM samples is not supported", forma.BitDepth)
+		}
+
+		t.convert = true
+
+		if bitDepth != 0 {
+			t.format.BitDepth = bitDepth
+		}
+	}
+
+	if len(channelMap) != 0 {
+		if (t.format.BitDepth % 8) != 0 {
+			return nil, fmt.Errorf("channel mapping of %d-bit LPCM samples is not supported", t.format.BitDepth)
+		}
+
+		for _, channels := range channelMap {
+			for _, ch := range channels {
+				if ch > forma.ChannelCount {
+					return nil, fmt.Errorf("channel map refers to channel %d, but track has %d channels",
+						ch, forma.ChannelCount)
+				}
+			}
+		}
+
+		t.convert = true
+		t.channelMap = channelMap
+		t.format.ChannelCount = len(channelMap)
 	}
 
 	if generateRTPPackets {
-		err := t.createEncoder()
+		err := t.createEncoder(nil, nil)
 		if err != nil {
 			return nil, err
 		}
 
 		t.timeEncoder = &rtptime.Encoder{
-			ClockRate: forma.ClockRate(),
+			ClockRate: t.format.ClockRate(),
 		}
 		err = t.timeEncoder.Initialize()
 		if err != nil {
@@ -48,12 +165,22 @@ func newLPCM(
 	return t, nil
 }
 
-func (t *formatProcessorLPCM) createEncoder() error {
+// OutputFormat implements FormatConverter.
+func (t *formatProcessorLPCM) OutputFormat() format.Format {
+	return t.format
+}
+
+func (t *formatProcessorLPCM) createEncoder(
+	ssrc *uint32,
+	initialSequenceNumber *uint16,
+) error {
 	t.encoder = &rtplpcm.Encoder{
-		PayloadMaxSize: t.udpMaxPayloadSize - 12,
-		PayloadType:    t.format.PayloadTyp,
-		BitDepth:       t.format.BitDepth,
-		ChannelCount:   t.format.ChannelCount,
+		PayloadMaxSize:        t.udpMaxPayloadSize - 12,
+		PayloadType:           t.format.PayloadTyp,
+		SSRC:                  ssrc,
+		InitialSequenceNumber: initialSequenceNumber,
+		BitDepth:              t.format.BitDepth,
+		ChannelCount:          t.format.ChannelCount,
 	}
 	return t.encoder.Init()
 }
@@ -61,6 +188,19 @@ func (t *formatProcessorLPCM) createEncoder() error {
 func (t *formatProcessorLPCM) ProcessUnit(uu unit.Unit) error { //nolint:dupl
 	u := uu.(*unit.LPCM)
 
+	// samples of units are always in big endian byte order
+	if t.convert && t.inBitDepth != t.format.BitDepth {
+		var err error
+		u.Samples, err = lpcmConvert(u.Samples, t.inBitDepth, false, t.format.BitDepth)
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.channelMap != nil {
+		u.Samples = lpcmMapChannels(u.Samples, t.format.BitDepth, t.inChannelCount, t.channelMap)
+	}
+
 	pkts, err := t.encoder.Encode(u.Samples)
 	if err != nil {
 		return err
@@ -98,11 +238,25 @@ func (t *formatProcessorLPCM) ProcessRTPPacket( //nolint:dupl
 			pkt.MarshalSize(), t.udpMaxPayloadSize)
 	}
 
+	// samples have to be converted: start re-encoding packets
+	if t.convert && t.encoder == nil {
+		v1 := pkt.SSRC
+		v2 := pkt.SequenceNumber
+		err := t.createEncoder(&v1, &v2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// decode from RTP
-	if hasNonRTSPReaders || t.decoder != nil {
+	if hasNonRTSPReaders || t.decoder != nil || t.encoder != nil {
 		if t.decoder == nil {
-			var err error
-			t.decoder, err = t.format.CreateDecoder()
+			// use original bit depth and channel count, since format may have been updated
+			t.decoder = &rtplpcm.Decoder{
+				BitDepth:     t.inBitDepth,
+				ChannelCount: t.inChannelCount,
+			}
+			err := t.decoder.Init()
 			if err != nil {
 				return nil, err
 			}
@@ -113,9 +267,35 @@ func (t *formatProcessorLPCM) ProcessRTPPacket( //nolint:dupl
 			return nil, err
 		}
 
+		if t.convert {
+			samples, err = lpcmConvert(samples, t.inBitDepth, t.inLittleEndian, t.format.BitDepth)
+			if err != nil {
+				return nil, err
+			}
+
+			if t.channelMap != nil {
+				samples = lpcmMapChannels(samples, t.format.BitDepth, t.inChannelCount, t.channelMap)
+			}
+		}
+
 		u.Samples = samples
 	}
 
 	// route packet as is
+	if t.encoder == nil {
+		return u, nil
+	}
+
+	// encode into RTP
+	pkts, err := t.encoder.Encode(u.Samples)
+	if err != nil {
+		return nil, err
+	}
+	u.RTPPackets = pkts
+
+	for _, newPKT := range u.RTPPackets {
+		newPKT.Timestamp += pkt.Timestamp
+	}
+
 	return u, nil
 }
@@ -2,6 +2,7 @@ package formatprocessor
 
 import (
 	"testing"
+	"time"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -17,7 +18,7 @@ func TestLPCMEncode(t *testing.T) {
 		ChannelCount: 2,
 	}
 
-	p, err := New(1472, forma, true)
+	p, err := New(1472, forma, true, Options{}, nil)
 	require.NoError(t, err)
 
 	unit := &unit.LPCM{
@@ -37,3 +38,167 @@ func TestLPCMEncode(t *testing.T) {
 		Payload: []byte{1, 2, 3, 4},
 	}}, unit.RTPPackets)
 }
+
+func TestLPCMConvert(t *testing.T) {
+	forma := &format.LPCM{
+		PayloadTyp:   96,
+		BitDepth:     24,
+		SampleRate:   48000,
+		ChannelCount: 1,
+	}
+
+	p, err := New(1472, forma, false, Options{
+		LPCMLittleEndian: true,
+		LPCMBitDepth:     16,
+	}, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, 24, forma.BitDepth)
+	require.Equal(t, 16, p.(FormatConverter).OutputFormat().(*format.LPCM).BitDepth)
+
+	data, err := p.ProcessRTPPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{1, 2, 3, 4, 5, 6},
+	}, time.Time{}, 0, false)
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{3, 2, 6, 5}, data.(*unit.LPCM).Samples)
+	require.Equal(t, []*rtp.Packet{{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{3, 2, 6, 5},
+	}}, data.GetRTPPackets())
+}
+
+func TestLPCMConvert32(t *testing.T) {
+	forma := &format.LPCM{
+		PayloadTyp:   96,
+		BitDepth:     16,
+		SampleRate:   48000,
+		ChannelCount: 1,
+	}
+
+	p, err := New(1472, forma, false, Options{
+		LPCMLittleEndian: true,
+		LPCMBitDepth:     32,
+	}, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, 16, forma.BitDepth)
+	require.Equal(t, 32, p.(FormatConverter).OutputFormat().(*format.LPCM).BitDepth)
+
+	data, err := p.ProcessRTPPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}, time.Time{}, 0, false)
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{2, 1, 0, 0, 4, 3, 0, 0}, data.(*unit.LPCM).Samples)
+
+	forma = &format.LPCM{
+		PayloadTyp:   96,
+		BitDepth:     32,
+		SampleRate:   48000,
+		ChannelCount: 1,
+	}
+
+	p, err = New(1472, forma, false, Options{
+		LPCMBitDepth: 24,
+	}, nil)
+	require.NoError(t, err)
+
+	data, err = p.ProcessRTPPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{1, 2, 3, 4},
+	}, time.Time{}, 0, false)
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{1, 2, 3}, data.(*unit.LPCM).Samples)
+}
+
+func TestLPCMChannelMap(t *testing.T) {
+	forma := &format.LPCM{
+		PayloadTyp:   96,
+		BitDepth:     16,
+		SampleRate:   48000,
+		ChannelCount: 3,
+	}
+
+	p, err := New(1472, forma, false, Options{
+		LPCMChannelMap: [][]int{{3}, {1, 2}},
+	}, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, 3, forma.ChannelCount)
+	require.Equal(t, 2, p.(FormatConverter).OutputFormat().(*format.LPCM).ChannelCount)
+
+	data, err := p.ProcessRTPPacket(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{
+			0x00, 0x10, 0xFF, 0xF0, 0x12, 0x34,
+			0x01, 0x00, 0x03, 0x00, 0xAB, 0xCD,
+		},
+	}, time.Time{}, 0, false)
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{
+		0x12, 0x34, 0x00, 0x00,
+		0xAB, 0xCD, 0x02, 0x00,
+	}, data.(*unit.LPCM).Samples)
+	require.Equal(t, []*rtp.Packet{{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{
+			0x12, 0x34, 0x00, 0x00,
+			0xAB, 0xCD, 0x02, 0x00,
+		},
+	}}, data.GetRTPPackets())
+}
+
+func TestLPCMChannelMapInvalid(t *testing.T) {
+	forma := &format.LPCM{
+		PayloadTyp:   96,
+		BitDepth:     16,
+		SampleRate:   48000,
+		ChannelCount: 2,
+	}
+
+	_, err := New(1472, forma, false, Options{
+		LPCMChannelMap: [][]int{{3}},
+	}, nil)
+	require.EqualError(t, err, "channel map refers to channel 3, but track has 2 channels")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/mediamtx/internal/unit"
+	"github.com/bluenviron/mediamtx/internal/watermark"
 )
 
 type formatProcessorMJPEG struct {
@@ -19,16 +20,19 @@ type formatProcessorMJPEG struct {
 	timeEncoder       *rtptime.Encoder
 	encoder           *rtpmjpeg.Encoder
 	decoder           *rtpmjpeg.Decoder
+	watermark         *watermark.Watermark
 }
 
 func newMJPEG(
 	udpMaxPayloadSize int,
 	forma *format.MJPEG,
 	generateRTPPackets bool,
+	wm *watermark.Watermark,
 ) (*formatProcessorMJPEG, error) {
 	t := &formatProcessorMJPEG{
 		udpMaxPayloadSize: udpMaxPayloadSize,
 		format:            forma,
+		watermark:         wm,
 	}
 
 	if generateRTPPackets {
@@ -50,15 +54,49 @@ func newMJPEG(
 }
 
 func (t *formatProcessorMJPEG) createEncoder() error {
+	return t.createEncoderWithParams(nil, nil)
+}
+
+func (t *formatProcessorMJPEG) createEncoderWithParams(
+	ssrc *uint32,
+	initialSequenceNumber *uint16,
+) error {
 	t.encoder = &rtpmjpeg.Encoder{
-		PayloadMaxSize: t.udpMaxPayloadSize - 12,
+		PayloadMaxSize:        t.udpMaxPayloadSize - 12,
+		SSRC:                  ssrc,
+		InitialSequenceNumber: initialSequenceNumber,
 	}
 	return t.encoder.Init()
 }
 
+// applyWatermark decodes the frame, burns the watermark into it and encodes it again.
+// This is performed synchronously by the routine of the publisher, that is blocked
+// until the frame is encoded.
+func (t *formatProcessorMJPEG) applyWatermark(u *unit.MJPEG) error {
+	ts := u.NTP
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	frame, err := t.watermark.ApplyJPEG(u.Frame, ts)
+	if err != nil {
+		return fmt.Errorf("unable to apply watermark: %w", err)
+	}
+
+	u.Frame = frame
+	return nil
+}
+
 func (t *formatProcessorMJPEG) ProcessUnit(uu unit.Unit) error { //nolint:dupl
 	u := uu.(*unit.MJPEG)
 
+	if t.watermark != nil {
+		err := t.applyWatermark(u)
+		if err != nil {
+			return err
+		}
+	}
+
 	// encode into RTP
 	pkts, err := t.encoder.Encode(u.Frame)
 	if err != nil {
@@ -97,8 +135,18 @@ func (t *formatProcessorMJPEG) ProcessRTPPacket( //nolint:dupl
 			pkt.MarshalSize(), t.udpMaxPayloadSize)
 	}
 
+	// frames have to be watermarked: start re-encoding packets
+	if t.watermark != nil && t.encoder == nil {
+		v1 := pkt.SSRC
+		v2 := pkt.SequenceNumber
+		err := t.createEncoderWithParams(&v1, &v2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// decode from RTP
-	if hasNonRTSPReaders || t.decoder != nil {
+	if hasNonRTSPReaders || t.decoder != nil || t.encoder != nil {
 		if t.decoder == nil {
 			var err error
 			t.decoder, err = t.format.CreateDecoder()
@@ -111,6 +159,10 @@ func (t *formatProcessorMJPEG) ProcessRTPPacket( //nolint:dupl
 		if err != nil {
 			if errors.Is(err, rtpmjpeg.ErrNonStartingPacketAndNoPrevious) ||
 				errors.Is(err, rtpmjpeg.ErrMorePacketsNeeded) {
+				if t.encoder != nil {
+					// packets are routed when the frame is complete
+					u.RTPPackets = nil
+				}
 				return u, nil
 			}
 			return nil, err
@@ -120,5 +172,25 @@ func (t *formatProcessorMJPEG) ProcessRTPPacket( //nolint:dupl
 	}
 
 	// route packet as is
+	if t.encoder == nil {
+		return u, nil
+	}
+
+	err := t.applyWatermark(u)
+	if err != nil {
+		return nil, err
+	}
+
+	// encode into RTP
+	pkts, err := t.encoder.Encode(u.Frame)
+	if err != nil {
+		return nil, err
+	}
+	u.RTPPackets = pkts
+
+	for _, newPKT := range u.RTPPackets {
+		newPKT.Timestamp += pkt.Timestamp
+	}
+
 	return u, nil
 }
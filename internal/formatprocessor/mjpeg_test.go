@@ -0,0 +1,73 @@
+package formatprocessor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpmjpeg"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+	"github.com/bluenviron/mediamtx/internal/watermark"
+)
+
+func TestMJPEGWatermark(t *testing.T) {
+	forma := &format.MJPEG{}
+
+	logo := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(logo, logo.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	p, err := New(1472, forma, false, Options{
+		Watermark: &watermark.Watermark{
+			Image:    logo,
+			Position: "topLeft",
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	src := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, src, nil)
+	require.NoError(t, err)
+
+	enc := &rtpmjpeg.Encoder{
+		PayloadMaxSize: 300,
+	}
+	err = enc.Init()
+	require.NoError(t, err)
+
+	pkts, err := enc.Encode(buf.Bytes())
+	require.NoError(t, err)
+	require.Greater(t, len(pkts), 1)
+
+	for i, pkt := range pkts {
+		var u Unit
+		u, err = p.ProcessRTPPacket(pkt, time.Time{}, 0, true)
+		require.NoError(t, err)
+
+		if i != len(pkts)-1 {
+			require.Nil(t, u.GetRTPPackets())
+			continue
+		}
+
+		require.NotEmpty(t, u.GetRTPPackets())
+
+		var img image.Image
+		img, err = jpeg.Decode(bytes.NewReader(u.(*unit.MJPEG).Frame))
+		require.NoError(t, err)
+
+		r, _, _, _ := img.At(10, 10).RGBA()
+		require.Greater(t, r, uint32(0xE000))
+
+		r, _, _, _ = img.At(100, 100).RGBA()
+		require.Less(t, r, uint32(0x2000))
+	}
+}
@@ -33,16 +33,20 @@ type formatProcessorMPEG4Video struct {
 	timeEncoder       *rtptime.Encoder
 	encoder           *rtpmpeg4video.Encoder
 	decoder           *rtpmpeg4video.Decoder
+
+	onParametersChange func()
 }
 
 func newMPEG4Video(
 	udpMaxPayloadSize int,
 	forma *format.MPEG4Video,
 	generateRTPPackets bool,
+	onParametersChange func(),
 ) (*formatProcessorMPEG4Video, error) {
 	t := &formatProcessorMPEG4Video{
-		udpMaxPayloadSize: udpMaxPayloadSize,
-		format:            forma,
+		udpMaxPayloadSize:  udpMaxPayloadSize,
+		format:             forma,
+		onParametersChange: onParametersChange,
 	}
 
 	if generateRTPPackets {
@@ -80,7 +84,14 @@ func (t *formatProcessorMPEG4Video) updateTrackParameters(frame []byte) {
 		conf := frame[:end+4]
 
 		if !bytes.Equal(conf, t.format.Config) {
+			// parameters that are set for the first time are not a change
+			changed := t.format.Config != nil
+
 			t.format.SafeSetParams(conf)
+
+			if changed && t.onParametersChange != nil {
+				t.onParametersChange()
+			}
 		}
 	}
 }
@@ -7,7 +7,9 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/pion/rtp"
 
+	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
+	"github.com/bluenviron/mediamtx/internal/watermark"
 )
 
 // Processor cleans and normalizes streams.
@@ -24,11 +26,55 @@ type Processor interface {
 	) (Unit, error)
 }
 
+// FormatConverter is implemented by processors that output units
+// with a format different from the input one.
+type FormatConverter interface {
+	// OutputFormat returns the format of processed units.
+	OutputFormat() format.Format
+}
+
+// Options contains optional processing settings.
+type Options struct {
+	// drop malformed H264 / H265 NALUs.
+	SanitizeBitstream bool
+
+	// insert H264 / H265 parameters before IDRs sent to RTSP readers.
+	InsertParameterSets bool
+
+	// LPCM samples received with RTP are in little endian byte order.
+	LPCMLittleEndian bool
+
+	// convert LPCM samples to this bit depth. Zero means no conversion.
+	LPCMBitDepth int
+
+	// select and mix LPCM channels. Every entry is an output channel,
+	// that contains the input channels (starting from 1) that are mixed into it.
+	LPCMChannelMap [][]int
+
+	// burn a watermark into M-JPEG frames.
+	Watermark *watermark.Watermark
+
+	// called when parameters of a H264 / H265 / MPEG-4 Video format
+	// change after they have been set for the first time.
+	OnParametersChange func(format.Format)
+}
+
+func onParametersChange(opts Options, forma format.Format) func() {
+	if opts.OnParametersChange == nil {
+		return nil
+	}
+	return func() {
+		opts.OnParametersChange(forma)
+	}
+}
+
 // New allocates a Processor.
 func New(
 	udpMaxPayloadSize int,
 	forma format.Format,
 	generateRTPPackets bool,
+	opts Options,
+	parent logger.Writer,
 ) (Processor, error) {
 	switch forma := forma.(type) {
 	case *format.AV1:
@@ -41,13 +87,29 @@ func New(
 		return newVP8(udpMaxPayloadSize, forma, generateRTPPackets)
 
 	case *format.H265:
-		return newH265(udpMaxPayloadSize, forma, generateRTPPackets)
+		return newH265(
+			udpMaxPayloadSize,
+			forma,
+			generateRTPPackets,
+			opts.SanitizeBitstream,
+			opts.InsertParameterSets,
+			onParametersChange(opts, forma),
+			parent,
+		)
 
 	case *format.H264:
-		return newH264(udpMaxPayloadSize, forma, generateRTPPackets)
+		return newH264(
+			udpMaxPayloadSize,
+			forma,
+			generateRTPPackets,
+			opts.SanitizeBitstream,
+			opts.InsertParameterSets,
+			onParametersChange(opts, forma),
+			parent,
+		)
 
 	case *format.MPEG4Video:
-		return newMPEG4Video(udpMaxPayloadSize, forma, generateRTPPackets)
+		return newMPEG4Video(udpMaxPayloadSize, forma, generateRTPPackets, onParametersChange(opts, forma))
 
 	case *format.MPEG1Video:
 		return newMPEG1Video(udpMaxPayloadSize, forma, generateRTPPackets)
@@ -62,7 +124,7 @@ func New(
 		return newMPEG1Audio(udpMaxPayloadSize, forma, generateRTPPackets)
 
 	case *format.MJPEG:
-		return newMJPEG(udpMaxPayloadSize, forma, generateRTPPackets)
+		return newMJPEG(udpMaxPayloadSize, forma, generateRTPPackets, opts.Watermark)
 
 	case *format.AC3:
 		return newAC3(udpMaxPayloadSize, forma, generateRTPPackets)
@@ -71,7 +133,8 @@ func New(
 		return newG711(udpMaxPayloadSize, forma, generateRTPPackets)
 
 	case *format.LPCM:
-		return newLPCM(udpMaxPayloadSize, forma, generateRTPPackets)
+		return newLPCM(udpMaxPayloadSize, forma, generateRTPPackets, opts.LPCMLittleEndian, opts.LPCMBitDepth,
+			opts.LPCMChannelMap)
 
 	default:
 		return newGeneric(udpMaxPayloadSize, forma, generateRTPPackets)
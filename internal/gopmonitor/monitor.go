@@ -0,0 +1,292 @@
+// Package gopmonitor contains a detector of open GOPs and long keyframe intervals.
+package gopmonitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// H264 SEI payload type of the recovery point.
+	seiPayloadTypeRecoveryPoint = 6
+)
+
+// h264RecoveryPointPresent checks whether an access unit contains a recovery point SEI,
+// that is used by encoders to mark entry points of open GOPs.
+func h264RecoveryPointPresent(au [][]byte) bool {
+	for _, nalu := range au {
+		if h264.NALUType(nalu[0]&0x1F) != h264.NALUTypeSEI {
+			continue
+		}
+
+		buf := h264.EmulationPreventionRemove(nalu[1:])
+
+		for len(buf) >= 2 && buf[0] != 0x80 {
+			payloadType := 0
+			for len(buf) > 0 && buf[0] == 0xFF {
+				payloadType += 255
+				buf = buf[1:]
+			}
+			if len(buf) == 0 {
+				break
+			}
+			payloadType += int(buf[0])
+			buf = buf[1:]
+
+			payloadSize := 0
+			for len(buf) > 0 && buf[0] == 0xFF {
+				payloadSize += 255
+				buf = buf[1:]
+			}
+			if len(buf) == 0 {
+				break
+			}
+			payloadSize += int(buf[0])
+			buf = buf[1:]
+
+			if payloadType == seiPayloadTypeRecoveryPoint {
+				return true
+			}
+
+			if payloadSize > len(buf) {
+				break
+			}
+			buf = buf[payloadSize:]
+		}
+	}
+
+	return false
+}
+
+// h265RASLPresent checks whether an access unit contains RASL pictures,
+// that reference pictures preceding the random access point, therefore belong to open GOPs.
+func h265RASLPresent(au [][]byte) bool {
+	for _, nalu := range au {
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+		if typ == h265.NALUType_RASL_N || typ == h265.NALUType_RASL_R {
+			return true
+		}
+	}
+	return false
+}
+
+type track struct {
+	index int
+
+	hasSince      bool
+	sinceKeyframe bool
+	since         time.Duration
+
+	mutex                sync.RWMutex
+	openGOP              bool
+	keyframeInterval     time.Duration
+	longKeyframeInterval bool
+}
+
+// Status is the GOP status of a stream.
+type Status struct {
+	OpenGOP              bool
+	KeyframeInterval     time.Duration
+	LongKeyframeInterval bool
+}
+
+// Monitor detects whether the video of a stream uses open GOPs
+// or keyframe intervals that are too long.
+// Both break the segmentation performed by HLS and recordings,
+// that can only start segments from closed GOPs.
+type Monitor struct {
+	WriteQueueSize         int
+	Stream                 *stream.Stream
+	MaxKeyframeInterval    time.Duration
+	OnOpenGOP              func()
+	OnLongKeyframeInterval func()
+	Parent                 logger.Writer
+
+	writer *asyncwriter.Writer
+	tracks []*track
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Monitor.
+func (m *Monitor) Initialize() {
+	if m.OnOpenGOP == nil {
+		m.OnOpenGOP = func() {}
+	}
+	if m.OnLongKeyframeInterval == nil {
+		m.OnLongKeyframeInterval = func() {}
+	}
+
+	m.terminate = make(chan struct{})
+	m.done = make(chan struct{})
+
+	m.writer = asyncwriter.New(m.WriteQueueSize, m)
+
+	index := 0
+
+	for _, media := range m.Stream.Desc().Medias {
+		for _, forma := range media.Formats {
+			m.setupFormat(media, forma, index)
+			index++
+		}
+	}
+
+	go m.run()
+}
+
+func (m *Monitor) setupFormat(media *description.Media, forma format.Format, index int) {
+	switch forma := forma.(type) {
+	case *format.H264:
+		t := &track{index: index}
+		m.tracks = append(m.tracks, t)
+
+		m.Stream.AddInternalReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.H264)
+
+			if tunit.AU == nil {
+				return nil
+			}
+
+			m.processFrame(t, tunit.PTS, h264.IDRPresent(tunit.AU),
+				!h264.IDRPresent(tunit.AU) && h264RecoveryPointPresent(tunit.AU))
+			return nil
+		})
+
+	case *format.H265:
+		t := &track{index: index}
+		m.tracks = append(m.tracks, t)
+
+		m.Stream.AddInternalReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.H265)
+
+			if tunit.AU == nil {
+				return nil
+			}
+
+			m.processFrame(t, tunit.PTS, h265.IsRandomAccess(tunit.AU), h265RASLPresent(tunit.AU))
+			return nil
+		})
+	}
+}
+
+// Close closes Monitor.
+func (m *Monitor) Close() {
+	close(m.terminate)
+	<-m.done
+}
+
+// Log implements logger.Writer.
+func (m *Monitor) Log(level logger.Level, format string, args ...interface{}) {
+	m.Parent.Log(level, "[GOP monitor] "+format, args...)
+}
+
+func (m *Monitor) run() {
+	defer close(m.done)
+
+	m.writer.Start()
+
+	select {
+	case err := <-m.writer.Error():
+		m.Log(logger.Error, err.Error())
+		m.Stream.RemoveReader(m.writer)
+
+	case <-m.terminate:
+		m.Stream.RemoveReader(m.writer)
+		m.writer.Stop()
+	}
+}
+
+func (m *Monitor) processFrame(t *track, pts time.Duration, keyframe bool, openGOP bool) {
+	t.mutex.RLock()
+	isOpenGOP := t.openGOP
+	isLong := t.longKeyframeInterval
+	t.mutex.RUnlock()
+
+	if openGOP && !isOpenGOP {
+		t.mutex.Lock()
+		t.openGOP = true
+		t.mutex.Unlock()
+
+		m.Log(logger.Warn, "video of track %d uses open GOPs, that can't be used as segment boundaries", t.index)
+		m.OnOpenGOP()
+	}
+
+	if keyframe {
+		if t.sinceKeyframe {
+			interval := pts - t.since
+			long := m.MaxKeyframeInterval != 0 && interval > m.MaxKeyframeInterval
+
+			t.mutex.Lock()
+			t.keyframeInterval = interval
+			t.longKeyframeInterval = long
+			t.mutex.Unlock()
+
+			switch {
+			case long && !isLong:
+				m.reportLongKeyframeInterval(t)
+
+			case !long && isLong:
+				m.Log(logger.Info, "keyframe interval of track %d is %v", t.index, interval)
+			}
+		}
+
+		t.hasSince = true
+		t.sinceKeyframe = true
+		t.since = pts
+		return
+	}
+
+	// when no keyframe has been received yet, the interval is measured from the first frame
+	if !t.hasSince {
+		t.hasSince = true
+		t.since = pts
+		return
+	}
+
+	if !isLong && m.MaxKeyframeInterval != 0 && (pts-t.since) > m.MaxKeyframeInterval {
+		t.mutex.Lock()
+		t.longKeyframeInterval = true
+		t.mutex.Unlock()
+
+		m.reportLongKeyframeInterval(t)
+	}
+}
+
+func (m *Monitor) reportLongKeyframeInterval(t *track) {
+	m.Log(logger.Warn, "keyframe interval of track %d is longer than %v", t.index, m.MaxKeyframeInterval)
+	m.OnLongKeyframeInterval()
+}
+
+// HasTracks returns whether the stream contains tracks that can be monitored.
+func (m *Monitor) HasTracks() bool {
+	return len(m.tracks) != 0
+}
+
+// Status returns the GOP status.
+// The keyframe interval is the longest one between tracks.
+func (m *Monitor) Status() Status {
+	var s Status
+
+	for _, t := range m.tracks {
+		t.mutex.RLock()
+		s.OpenGOP = s.OpenGOP || t.openGOP
+		s.LongKeyframeInterval = s.LongKeyframeInterval || t.longKeyframeInterval
+		if t.keyframeInterval > s.KeyframeInterval {
+			s.KeyframeInterval = t.keyframeInterval
+		}
+		t.mutex.RUnlock()
+	}
+
+	return s
+}
@@ -0,0 +1,175 @@
+package gopmonitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestH264RecoveryPointPresent(t *testing.T) {
+	require.True(t, h264RecoveryPointPresent([][]byte{
+		{0x06, 0x06, 0x01, 0x00, 0x80},
+		{0x01, 0x02},
+	}))
+
+	require.False(t, h264RecoveryPointPresent([][]byte{
+		{0x06, 0x05, 0x01, 0x00, 0x80},
+		{0x01, 0x02},
+	}))
+}
+
+func TestMonitorH264(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			SPS:               test.FormatH264.SPS,
+			PPS:               test.FormatH264.PPS,
+			PacketizationMode: 1,
+		}},
+	}}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	openGOP := make(chan struct{}, 1)
+	longInterval := make(chan struct{}, 1)
+
+	m := &Monitor{
+		WriteQueueSize:      512,
+		Stream:              strm,
+		MaxKeyframeInterval: 3 * time.Second,
+		OnOpenGOP: func() {
+			openGOP <- struct{}{}
+		},
+		OnLongKeyframeInterval: func() {
+			longInterval <- struct{}{}
+		},
+		Parent: test.NilLogger,
+	}
+	m.Initialize()
+	defer m.Close()
+
+	require.True(t, m.HasTracks())
+
+	// keyframes every 2 seconds
+	for i := 0; i < 3; i++ {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 2 * time.Second,
+			},
+			AU: [][]byte{{0x65, 0x01}},
+		})
+	}
+
+	// open GOP entry point
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+		Base: unit.Base{
+			PTS: 5 * time.Second,
+		},
+		AU: [][]byte{
+			{0x06, 0x06, 0x01, 0x00, 0x80},
+			{0x01, 0x02},
+		},
+	})
+
+	select {
+	case <-openGOP:
+	case <-longInterval:
+		t.Error("should not happen")
+	case <-time.After(2 * time.Second):
+		t.Error("timed out")
+	}
+
+	// keyframe after 6 seconds
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+		Base: unit.Base{
+			PTS: 10 * time.Second,
+		},
+		AU: [][]byte{{0x65, 0x01}},
+	})
+
+	select {
+	case <-longInterval:
+	case <-time.After(2 * time.Second):
+		t.Error("timed out")
+	}
+
+	require.Equal(t, Status{
+		OpenGOP:              true,
+		KeyframeInterval:     6 * time.Second,
+		LongKeyframeInterval: true,
+	}, m.Status())
+}
+
+func TestMonitorH265RASL(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H265{
+			PayloadTyp: 96,
+			VPS:        test.FormatH265.VPS,
+			SPS:        test.FormatH265.SPS,
+			PPS:        test.FormatH265.PPS,
+		}},
+	}}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	openGOP := make(chan struct{}, 1)
+
+	m := &Monitor{
+		WriteQueueSize: 512,
+		Stream:         strm,
+		OnOpenGOP: func() {
+			openGOP <- struct{}{}
+		},
+		Parent: test.NilLogger,
+	}
+	m.Initialize()
+	defer m.Close()
+
+	// CRA
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H265{
+		Base: unit.Base{
+			PTS: 0,
+		},
+		AU: [][]byte{{byte(21 << 1), 0x01, 0x01}},
+	})
+
+	// RASL_N
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H265{
+		Base: unit.Base{
+			PTS: 40 * time.Millisecond,
+		},
+		AU: [][]byte{{byte(8 << 1), 0x01, 0x01}},
+	})
+
+	select {
+	case <-openGOP:
+	case <-time.After(2 * time.Second):
+		t.Error("timed out")
+	}
+}
@@ -0,0 +1,126 @@
+package grpcapi
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/grpcapi/pb"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+)
+
+func timeToPB(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func sourceOrReaderToPB(in *defs.APIPathSourceOrReader) *pb.PathSourceOrReader {
+	if in == nil {
+		return nil
+	}
+	return &pb.PathSourceOrReader{
+		Type: in.Type,
+		Id:   in.ID,
+	}
+}
+
+func pathToPB(in *defs.APIPath) *pb.Path {
+	out := &pb.Path{
+		Name:          in.Name,
+		ConfName:      in.ConfName,
+		Source:        sourceOrReaderToPB(in.Source),
+		Ready:         in.Ready,
+		ReadyTime:     timeToPB(in.ReadyTime),
+		Tracks:        in.Tracks,
+		BytesReceived: in.BytesReceived,
+		BytesSent:     in.BytesSent,
+		Readers:       make([]*pb.PathSourceOrReader, len(in.Readers)),
+	}
+
+	for i, r := range in.Readers {
+		out.Readers[i] = sourceOrReaderToPB(&r)
+	}
+
+	return out
+}
+
+func rtspSessionToPB(in *defs.APIRTSPSession, typ string) *pb.Session {
+	return &pb.Session{
+		Type:          typ,
+		Id:            in.ID.String(),
+		Created:       timeToPB(&in.Created),
+		RemoteAddr:    in.RemoteAddr,
+		State:         string(in.State),
+		Path:          in.Path,
+		Query:         in.Query,
+		BytesReceived: in.BytesReceived,
+		BytesSent:     in.BytesSent,
+	}
+}
+
+func rtmpConnToPB(in *defs.APIRTMPConn, typ string) *pb.Session {
+	return &pb.Session{
+		Type:          typ,
+		Id:            in.ID.String(),
+		Created:       timeToPB(&in.Created),
+		RemoteAddr:    in.RemoteAddr,
+		State:         string(in.State),
+		Path:          in.Path,
+		Query:         in.Query,
+		BytesReceived: in.BytesReceived,
+		BytesSent:     in.BytesSent,
+	}
+}
+
+func webRTCSessionToPB(in *defs.APIWebRTCSession) *pb.Session {
+	return &pb.Session{
+		Type:          "webRTCSession",
+		Id:            in.ID.String(),
+		Created:       timeToPB(&in.Created),
+		RemoteAddr:    in.RemoteAddr,
+		State:         string(in.State),
+		Path:          in.Path,
+		Query:         in.Query,
+		BytesReceived: in.BytesReceived,
+		BytesSent:     in.BytesSent,
+	}
+}
+
+func srtConnToPB(in *defs.APISRTConn) *pb.Session {
+	return &pb.Session{
+		Type:          "srtConn",
+		Id:            in.ID.String(),
+		Created:       timeToPB(&in.Created),
+		RemoteAddr:    in.RemoteAddr,
+		State:         string(in.State),
+		Path:          in.Path,
+		Query:         in.Query,
+		BytesReceived: in.BytesReceived,
+		BytesSent:     in.BytesSent,
+	}
+}
+
+func recordingsOfPath(
+	pathConf *conf.Path,
+	pathName string,
+) *pb.Recording {
+	ret := &pb.Recording{
+		Name: pathName,
+	}
+
+	segments, _ := recordstore.FindSegments(pathConf, pathName)
+
+	ret.Segments = make([]*pb.RecordingSegment, len(segments))
+
+	for i, seg := range segments {
+		ret.Segments[i] = &pb.RecordingSegment{
+			Start: timestamppb.New(seg.Start),
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,147 @@
+package grpcapi
+
+import (
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/bluenviron/mediamtx/internal/grpcapi/pb"
+)
+
+const (
+	eventsPollPeriod = 1 * time.Second
+)
+
+type eventsState struct {
+	readyPaths map[string]*pb.Path
+	sessions   map[string]*pb.Session
+}
+
+func sortedPathKeys(m map[string]*pb.Path) []string {
+	ret := make([]string, 0, len(m))
+	for key := range m {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func sortedSessionKeys(m map[string]*pb.Session) []string {
+	ret := make([]string, 0, len(m))
+	for key := range m {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (a *GRPCAPI) pollEventsState() (*eventsState, error) {
+	paths, err := a.PathManager.APIPathsList()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := a.sessionsList()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &eventsState{
+		readyPaths: make(map[string]*pb.Path),
+		sessions:   make(map[string]*pb.Session),
+	}
+
+	for _, p := range paths.Items {
+		if p.Ready {
+			st.readyPaths[p.Name] = pathToPB(p)
+		}
+	}
+
+	for _, s := range sessions {
+		st.sessions[s.Type+"/"+s.Id] = s
+	}
+
+	return st, nil
+}
+
+// eventsDiff returns events that describe the transition between two states.
+func eventsDiff(prev *eventsState, cur *eventsState, now time.Time) []*pb.Event {
+	var ret []*pb.Event
+	ts := timestamppb.New(now)
+
+	for _, key := range sortedPathKeys(prev.readyPaths) {
+		if _, ok := cur.readyPaths[key]; !ok {
+			ret = append(ret, &pb.Event{
+				Type: pb.Event_TYPE_PATH_NOT_READY,
+				Time: ts,
+				Path: prev.readyPaths[key],
+			})
+		}
+	}
+
+	for _, key := range sortedPathKeys(cur.readyPaths) {
+		if _, ok := prev.readyPaths[key]; !ok {
+			ret = append(ret, &pb.Event{
+				Type: pb.Event_TYPE_PATH_READY,
+				Time: ts,
+				Path: cur.readyPaths[key],
+			})
+		}
+	}
+
+	for _, key := range sortedSessionKeys(prev.sessions) {
+		if _, ok := cur.sessions[key]; !ok {
+			ret = append(ret, &pb.Event{
+				Type:    pb.Event_TYPE_SESSION_CLOSED,
+				Time:    ts,
+				Session: prev.sessions[key],
+			})
+		}
+	}
+
+	for _, key := range sortedSessionKeys(cur.sessions) {
+		if _, ok := prev.sessions[key]; !ok {
+			ret = append(ret, &pb.Event{
+				Type:    pb.Event_TYPE_SESSION_CREATED,
+				Time:    ts,
+				Session: cur.sessions[key],
+			})
+		}
+	}
+
+	return ret
+}
+
+// Events implements pb.ControlServer.
+// Events are generated by periodically comparing the state of the server.
+// When the stream starts, an event is emitted for every ready path and existing session.
+func (a *GRPCAPI) Events(_ *pb.EventsRequest, srv pb.Control_EventsServer) error {
+	prev := &eventsState{}
+
+	t := time.NewTicker(eventsPollPeriod)
+	defer t.Stop()
+
+	for {
+		cur, err := a.pollEventsState()
+		if err != nil {
+			return a.writeError(codes.Internal, err)
+		}
+
+		for _, evt := range eventsDiff(prev, cur, time.Now()) {
+			err = srv.Send(evt)
+			if err != nil {
+				return err
+			}
+		}
+
+		prev = cur
+
+		select {
+		case <-t.C:
+		case <-srv.Context().Done():
+			return nil
+		}
+	}
+}
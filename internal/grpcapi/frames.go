@@ -0,0 +1,197 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/grpcapi/pb"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// framesReader is the reader associated with a Frames call.
+type framesReader struct {
+	id        uuid.UUID
+	ctxCancel func()
+}
+
+// Close implements defs.Reader.
+func (r *framesReader) Close() {
+	r.ctxCancel()
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (r *framesReader) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "grpcFrames",
+		ID:   r.id.String(),
+	}
+}
+
+func frameTimestamps(u unit.Unit, fr *pb.Frame) *pb.Frame {
+	if ntp := u.GetNTP(); !ntp.IsZero() {
+		fr.Ntp = timestamppb.New(ntp)
+	}
+	fr.Pts = int64(u.GetPTS())
+	return fr
+}
+
+// setupFramesReader adds to the stream a reader that sends frames of the requested format to send.
+func setupFramesReader(
+	strm *stream.Stream,
+	writer *asyncwriter.Writer,
+	req *pb.FramesRequest,
+	send func(*pb.Frame) error,
+) error {
+	switch req.Format {
+	case pb.FramesRequest_FORMAT_UNSPECIFIED, pb.FramesRequest_FORMAT_ACCESS_UNITS:
+		var h265Format *format.H265
+		media := strm.Desc().FindFormat(&h265Format)
+
+		if media != nil {
+			strm.AddReader(writer, media, h265Format, func(u unit.Unit) error {
+				tunit := u.(*unit.H265)
+				if tunit.AU == nil {
+					return nil
+				}
+
+				keyFrame := h265.IsRandomAccess(tunit.AU)
+				if req.KeyFramesOnly && !keyFrame {
+					return nil
+				}
+
+				return send(frameTimestamps(u, &pb.Frame{
+					Codec:    "H265",
+					KeyFrame: keyFrame,
+					Nalus:    tunit.AU,
+				}))
+			})
+			return nil
+		}
+
+		var h264Format *format.H264
+		media = strm.Desc().FindFormat(&h264Format)
+
+		if media != nil {
+			strm.AddReader(writer, media, h264Format, func(u unit.Unit) error {
+				tunit := u.(*unit.H264)
+				if tunit.AU == nil {
+					return nil
+				}
+
+				keyFrame := h264.IDRPresent(tunit.AU)
+				if req.KeyFramesOnly && !keyFrame {
+					return nil
+				}
+
+				return send(frameTimestamps(u, &pb.Frame{
+					Codec:    "H264",
+					KeyFrame: keyFrame,
+					Nalus:    tunit.AU,
+				}))
+			})
+			return nil
+		}
+
+		return fmt.Errorf("the stream doesn't contain any supported codec, which are currently H265, H264")
+
+	case pb.FramesRequest_FORMAT_JPEG:
+		var mjpegFormat *format.MJPEG
+		media := strm.Desc().FindFormat(&mjpegFormat)
+
+		if media != nil {
+			strm.AddReader(writer, media, mjpegFormat, func(u unit.Unit) error {
+				tunit := u.(*unit.MJPEG)
+				if tunit.Frame == nil {
+					return nil
+				}
+
+				return send(frameTimestamps(u, &pb.Frame{
+					Codec:    "M-JPEG",
+					KeyFrame: true,
+					Jpeg:     tunit.Frame,
+				}))
+			})
+			return nil
+		}
+
+		// H264 and H265 can't be converted into JPEG since there's no video decoder.
+		return fmt.Errorf("the stream doesn't contain any M-JPEG track")
+
+	default:
+		return fmt.Errorf("unsupported format: %v", req.Format)
+	}
+}
+
+func isPowerOfTwo(v uint32) bool {
+	return v != 0 && (v&(v-1)) == 0
+}
+
+// Frames implements pb.ControlServer.
+// Every call is a reader of the path with its own queue. When the caller
+// is too slow, frames are discarded instead of slowing down other readers.
+func (a *GRPCAPI) Frames(req *pb.FramesRequest, srv pb.Control_FramesServer) error {
+	queueSize := int(req.QueueSize)
+	if queueSize == 0 {
+		a.mutex.RLock()
+		queueSize = a.Conf.WriteQueueSize
+		a.mutex.RUnlock()
+	} else if !isPowerOfTwo(req.QueueSize) {
+		return a.writeError(codes.InvalidArgument, fmt.Errorf("queue size must be a power of two"))
+	}
+
+	ctx, ctxCancel := context.WithCancel(srv.Context())
+	defer ctxCancel()
+
+	r := &framesReader{
+		id:        uuid.New(),
+		ctxCancel: ctxCancel,
+	}
+
+	path, strm, err := a.PathManager.AddReader(defs.PathAddReaderReq{
+		Author: r,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     req.Path,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		var terr defs.PathNoOnePublishingError
+		if errors.Is(err, conf.ErrPathNotFound) || errors.As(err, &terr) {
+			return a.writeError(codes.NotFound, err)
+		}
+		return a.writeError(codes.Internal, err)
+	}
+
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: r})
+
+	writer := asyncwriter.New(queueSize, a)
+	defer strm.RemoveReader(writer)
+
+	err = setupFramesReader(strm, writer, req, srv.Send)
+	if err != nil {
+		return a.writeError(codes.FailedPrecondition, err)
+	}
+
+	writer.Start()
+	defer writer.Stop()
+
+	select {
+	case err = <-writer.Error():
+		return err
+
+	case <-ctx.Done():
+		return nil
+	}
+}
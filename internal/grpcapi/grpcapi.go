@@ -0,0 +1,455 @@
+// Package grpcapi contains the gRPC Control API server.
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/bluenviron/mediamtx/internal/api"
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/certloader"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/grpcapi/pb"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	mtxtls "github.com/bluenviron/mediamtx/internal/protocols/tls"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+func interfaceIsEmpty(i interface{}) bool {
+	return reflect.ValueOf(i).Kind() != reflect.Ptr || reflect.ValueOf(i).IsNil()
+}
+
+type grpcAPIAuthManager interface {
+	Authenticate(req *auth.Request) error
+}
+
+type grpcAPIPathManager interface {
+	api.PathManager
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+// GRPCAPI is a gRPC Control API server.
+type GRPCAPI struct {
+	Address      string
+	Encryption   bool
+	ServerKey    string
+	ServerCert   string
+	TLSOptions   conf.TLSOptions
+	Conf         *conf.Conf
+	AuthManager  grpcAPIAuthManager
+	PathManager  grpcAPIPathManager
+	RTSPServer   api.RTSPServer
+	RTSPSServer  api.RTSPServer
+	RTMPServer   api.RTMPServer
+	RTMPSServer  api.RTMPServer
+	WebRTCServer api.WebRTCServer
+	SRTServer    api.SRTServer
+	Parent       logger.Writer
+
+	pb.UnimplementedControlServer
+
+	ln     net.Listener
+	loader *certloader.CertLoader
+	server *grpc.Server
+	mutex  sync.RWMutex
+}
+
+// Initialize initializes GRPCAPI.
+func (a *GRPCAPI) Initialize() error {
+	var opts []grpc.ServerOption
+
+	if a.Encryption {
+		if a.ServerCert == "" {
+			return fmt.Errorf("server cert is missing")
+		}
+
+		var err error
+		a.loader, err = certloader.New(a.ServerCert, a.ServerKey, a)
+		if err != nil {
+			return err
+		}
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(mtxtls.ServerConfig(a.loader, a.TLSOptions))))
+	}
+
+	opts = append(opts,
+		grpc.UnaryInterceptor(a.interceptUnary),
+		grpc.StreamInterceptor(a.interceptStream))
+
+	network, address := restrictnetwork.Restrict("tcp", a.Address)
+
+	var err error
+	a.ln, err = net.Listen(network, address)
+	if err != nil {
+		if a.loader != nil {
+			a.loader.Close()
+		}
+		return err
+	}
+
+	a.server = grpc.NewServer(opts...)
+	pb.RegisterControlServer(a.server, a)
+
+	go a.server.Serve(a.ln)
+
+	a.Log(logger.Info, "listener opened on "+address)
+
+	return nil
+}
+
+// Close closes GRPCAPI.
+func (a *GRPCAPI) Close() {
+	a.Log(logger.Info, "listener is closing")
+	a.server.Stop()
+	a.ln.Close() // in case Stop() is called before Serve()
+	if a.loader != nil {
+		a.loader.Close()
+	}
+}
+
+// Log implements logger.Writer.
+func (a *GRPCAPI) Log(level logger.Level, format string, args ...interface{}) {
+	a.Parent.Log(level, "[gRPC API] "+format, args...)
+}
+
+// ReloadConf is called by core.
+func (a *GRPCAPI) ReloadConf(conf *conf.Conf) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.Conf = conf
+}
+
+// SetRTSPServer is called by core.
+func (a *GRPCAPI) SetRTSPServer(s api.RTSPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTSPServer = s
+}
+
+// SetRTSPSServer is called by core.
+func (a *GRPCAPI) SetRTSPSServer(s api.RTSPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTSPSServer = s
+}
+
+// SetRTMPServer is called by core.
+func (a *GRPCAPI) SetRTMPServer(s api.RTMPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTMPServer = s
+}
+
+// SetRTMPSServer is called by core.
+func (a *GRPCAPI) SetRTMPSServer(s api.RTMPServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.RTMPSServer = s
+}
+
+// SetWebRTCServer is called by core.
+func (a *GRPCAPI) SetWebRTCServer(s api.WebRTCServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.WebRTCServer = s
+}
+
+// SetSRTServer is called by core.
+func (a *GRPCAPI) SetSRTServer(s api.SRTServer) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.SRTServer = s
+}
+
+func (a *GRPCAPI) authenticate(ctx context.Context) error {
+	req := &auth.Request{
+		Action: conf.AuthActionAPI,
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get("authorization"); len(vals) == 1 {
+			switch {
+			case strings.HasPrefix(vals[0], "Basic "):
+				byts, err := base64.StdEncoding.DecodeString(vals[0][len("Basic "):])
+				if err == nil {
+					req.User, req.Pass, _ = strings.Cut(string(byts), ":")
+				}
+
+			case strings.HasPrefix(vals[0], "Bearer "):
+				req.Query = "jwt=" + url.QueryEscape(vals[0][len("Bearer "):])
+			}
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if addr, ok2 := p.Addr.(*net.TCPAddr); ok2 {
+			req.IP = addr.IP
+		}
+		if addr, ok2 := p.LocalAddr.(*net.TCPAddr); ok2 {
+			req.LocalIP = addr.IP
+		}
+	}
+
+	err := a.AuthManager.Authenticate(req)
+	if err != nil {
+		// wait some seconds to mitigate brute force attacks
+		<-time.After(auth.PauseAfterError)
+
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return nil
+}
+
+func (a *GRPCAPI) interceptUnary(
+	ctx context.Context,
+	req interface{},
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (a *GRPCAPI) interceptStream(
+	srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+func (a *GRPCAPI) writeError(code codes.Code, err error) error {
+	// show error in logs
+	a.Log(logger.Error, err.Error())
+
+	return status.Error(code, err.Error())
+}
+
+// PathsList implements pb.ControlServer.
+func (a *GRPCAPI) PathsList(_ context.Context, _ *pb.PathsListRequest) (*pb.PathList, error) {
+	data, err := a.PathManager.APIPathsList()
+	if err != nil {
+		return nil, a.writeError(codes.Internal, err)
+	}
+
+	ret := &pb.PathList{
+		Items: make([]*pb.Path, len(data.Items)),
+	}
+
+	for i, item := range data.Items {
+		ret.Items[i] = pathToPB(item)
+	}
+
+	return ret, nil
+}
+
+// PathsGet implements pb.ControlServer.
+func (a *GRPCAPI) PathsGet(_ context.Context, req *pb.PathsGetRequest) (*pb.Path, error) {
+	data, err := a.PathManager.APIPathsGet(req.Name)
+	if err != nil {
+		if errors.Is(err, conf.ErrPathNotFound) {
+			return nil, a.writeError(codes.NotFound, err)
+		}
+		return nil, a.writeError(codes.Internal, err)
+	}
+
+	return pathToPB(data), nil
+}
+
+func (a *GRPCAPI) sessionsList() ([]*pb.Session, error) {
+	var ret []*pb.Session
+
+	a.mutex.RLock()
+	rtspServer := a.RTSPServer
+	rtspsServer := a.RTSPSServer
+	rtmpServer := a.RTMPServer
+	rtmpsServer := a.RTMPSServer
+	webRTCServer := a.WebRTCServer
+	srtServer := a.SRTServer
+	a.mutex.RUnlock()
+
+	if !interfaceIsEmpty(rtspServer) {
+		data, err := rtspServer.APISessionsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range data.Items {
+			ret = append(ret, rtspSessionToPB(item, "rtspSession"))
+		}
+	}
+
+	if !interfaceIsEmpty(rtspsServer) {
+		data, err := rtspsServer.APISessionsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range data.Items {
+			ret = append(ret, rtspSessionToPB(item, "rtspsSession"))
+		}
+	}
+
+	if !interfaceIsEmpty(rtmpServer) {
+		data, err := rtmpServer.APIConnsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range data.Items {
+			ret = append(ret, rtmpConnToPB(item, "rtmpConn"))
+		}
+	}
+
+	if !interfaceIsEmpty(rtmpsServer) {
+		data, err := rtmpsServer.APIConnsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range data.Items {
+			ret = append(ret, rtmpConnToPB(item, "rtmpsConn"))
+		}
+	}
+
+	if !interfaceIsEmpty(webRTCServer) {
+		data, err := webRTCServer.APISessionsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range data.Items {
+			ret = append(ret, webRTCSessionToPB(item))
+		}
+	}
+
+	if !interfaceIsEmpty(srtServer) {
+		data, err := srtServer.APIConnsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range data.Items {
+			ret = append(ret, srtConnToPB(item))
+		}
+	}
+
+	return ret, nil
+}
+
+// SessionsList implements pb.ControlServer.
+func (a *GRPCAPI) SessionsList(_ context.Context, _ *pb.SessionsListRequest) (*pb.SessionList, error) {
+	items, err := a.sessionsList()
+	if err != nil {
+		return nil, a.writeError(codes.Internal, err)
+	}
+
+	return &pb.SessionList{Items: items}, nil
+}
+
+// SessionsKick implements pb.ControlServer.
+func (a *GRPCAPI) SessionsKick(_ context.Context, req *pb.SessionsKickRequest) (*pb.SessionsKickResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, a.writeError(codes.InvalidArgument, err)
+	}
+
+	var kick func(uuid.UUID) error
+
+	a.mutex.RLock()
+	rtspServer := a.RTSPServer
+	rtspsServer := a.RTSPSServer
+	rtmpServer := a.RTMPServer
+	rtmpsServer := a.RTMPSServer
+	webRTCServer := a.WebRTCServer
+	srtServer := a.SRTServer
+	a.mutex.RUnlock()
+
+	switch {
+	case req.Type == "rtspSession" && !interfaceIsEmpty(rtspServer):
+		kick = rtspServer.APISessionsKick
+
+	case req.Type == "rtspsSession" && !interfaceIsEmpty(rtspsServer):
+		kick = rtspsServer.APISessionsKick
+
+	case req.Type == "rtmpConn" && !interfaceIsEmpty(rtmpServer):
+		kick = rtmpServer.APIConnsKick
+
+	case req.Type == "rtmpsConn" && !interfaceIsEmpty(rtmpsServer):
+		kick = rtmpsServer.APIConnsKick
+
+	case req.Type == "webRTCSession" && !interfaceIsEmpty(webRTCServer):
+		kick = webRTCServer.APISessionsKick
+
+	case req.Type == "srtConn" && !interfaceIsEmpty(srtServer):
+		kick = srtServer.APIConnsKick
+
+	default:
+		return nil, a.writeError(codes.InvalidArgument, fmt.Errorf("invalid type: '%s'", req.Type))
+	}
+
+	err = kick(id)
+	if err != nil {
+		return nil, a.writeError(codes.NotFound, err)
+	}
+
+	return &pb.SessionsKickResponse{}, nil
+}
+
+// RecordingsList implements pb.ControlServer.
+func (a *GRPCAPI) RecordingsList(_ context.Context, _ *pb.RecordingsListRequest) (*pb.RecordingList, error) {
+	a.mutex.RLock()
+	c := a.Conf
+	a.mutex.RUnlock()
+
+	pathNames := recordstore.FindAllPathsWithSegments(c.Paths)
+
+	ret := &pb.RecordingList{
+		Items: make([]*pb.Recording, len(pathNames)),
+	}
+
+	for i, pathName := range pathNames {
+		pathConf, _, _ := conf.FindPathConf(c.Paths, pathName)
+		ret.Items[i] = recordingsOfPath(pathConf, pathName)
+	}
+
+	return ret, nil
+}
+
+// RecordingsGet implements pb.ControlServer.
+func (a *GRPCAPI) RecordingsGet(_ context.Context, req *pb.RecordingsGetRequest) (*pb.Recording, error) {
+	a.mutex.RLock()
+	c := a.Conf
+	a.mutex.RUnlock()
+
+	pathConf, _, err := conf.FindPathConf(c.Paths, req.Name)
+	if err != nil {
+		return nil, a.writeError(codes.InvalidArgument, err)
+	}
+
+	return recordingsOfPath(pathConf, req.Name), nil
+}
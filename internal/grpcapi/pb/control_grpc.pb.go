@@ -0,0 +1,445 @@
+// Definitions of the gRPC Control API.
+//
+// Go code can be regenerated with:
+// protoc --go_out=. --go_opt=paths=source_relative \
+//   --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: control.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Control_PathsList_FullMethodName      = "/mediamtx.v3.Control/PathsList"
+	Control_PathsGet_FullMethodName       = "/mediamtx.v3.Control/PathsGet"
+	Control_SessionsList_FullMethodName   = "/mediamtx.v3.Control/SessionsList"
+	Control_SessionsKick_FullMethodName   = "/mediamtx.v3.Control/SessionsKick"
+	Control_RecordingsList_FullMethodName = "/mediamtx.v3.Control/RecordingsList"
+	Control_RecordingsGet_FullMethodName  = "/mediamtx.v3.Control/RecordingsGet"
+	Control_Events_FullMethodName         = "/mediamtx.v3.Control/Events"
+	Control_Frames_FullMethodName         = "/mediamtx.v3.Control/Frames"
+)
+
+// ControlClient is the client API for Control service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ControlClient interface {
+	// PathsList returns all active paths.
+	PathsList(ctx context.Context, in *PathsListRequest, opts ...grpc.CallOption) (*PathList, error)
+	// PathsGet returns an active path.
+	PathsGet(ctx context.Context, in *PathsGetRequest, opts ...grpc.CallOption) (*Path, error)
+	// SessionsList returns all sessions and connections of all protocols.
+	SessionsList(ctx context.Context, in *SessionsListRequest, opts ...grpc.CallOption) (*SessionList, error)
+	// SessionsKick kicks out a session or connection.
+	SessionsKick(ctx context.Context, in *SessionsKickRequest, opts ...grpc.CallOption) (*SessionsKickResponse, error)
+	// RecordingsList returns all recordings.
+	RecordingsList(ctx context.Context, in *RecordingsListRequest, opts ...grpc.CallOption) (*RecordingList, error)
+	// RecordingsGet returns recordings of a path.
+	RecordingsGet(ctx context.Context, in *RecordingsGetRequest, opts ...grpc.CallOption) (*Recording, error)
+	// Events returns a stream of events.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Control_EventsClient, error)
+	// Frames returns a stream of frames of a path.
+	Frames(ctx context.Context, in *FramesRequest, opts ...grpc.CallOption) (Control_FramesClient, error)
+}
+
+type controlClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlClient(cc grpc.ClientConnInterface) ControlClient {
+	return &controlClient{cc}
+}
+
+func (c *controlClient) PathsList(ctx context.Context, in *PathsListRequest, opts ...grpc.CallOption) (*PathList, error) {
+	out := new(PathList)
+	err := c.cc.Invoke(ctx, Control_PathsList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PathsGet(ctx context.Context, in *PathsGetRequest, opts ...grpc.CallOption) (*Path, error) {
+	out := new(Path)
+	err := c.cc.Invoke(ctx, Control_PathsGet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) SessionsList(ctx context.Context, in *SessionsListRequest, opts ...grpc.CallOption) (*SessionList, error) {
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, Control_SessionsList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) SessionsKick(ctx context.Context, in *SessionsKickRequest, opts ...grpc.CallOption) (*SessionsKickResponse, error) {
+	out := new(SessionsKickResponse)
+	err := c.cc.Invoke(ctx, Control_SessionsKick_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) RecordingsList(ctx context.Context, in *RecordingsListRequest, opts ...grpc.CallOption) (*RecordingList, error) {
+	out := new(RecordingList)
+	err := c.cc.Invoke(ctx, Control_RecordingsList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) RecordingsGet(ctx context.Context, in *RecordingsGetRequest, opts ...grpc.CallOption) (*Recording, error) {
+	out := new(Recording)
+	err := c.cc.Invoke(ctx, Control_RecordingsGet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Control_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Control_ServiceDesc.Streams[0], Control_Events_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Control_EventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type controlEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *controlClient) Frames(ctx context.Context, in *FramesRequest, opts ...grpc.CallOption) (Control_FramesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Control_ServiceDesc.Streams[1], Control_Frames_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlFramesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Control_FramesClient interface {
+	Recv() (*Frame, error)
+	grpc.ClientStream
+}
+
+type controlFramesClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlFramesClient) Recv() (*Frame, error) {
+	m := new(Frame)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ControlServer is the server API for Control service.
+// All implementations must embed UnimplementedControlServer
+// for forward compatibility
+type ControlServer interface {
+	// PathsList returns all active paths.
+	PathsList(context.Context, *PathsListRequest) (*PathList, error)
+	// PathsGet returns an active path.
+	PathsGet(context.Context, *PathsGetRequest) (*Path, error)
+	// SessionsList returns all sessions and connections of all protocols.
+	SessionsList(context.Context, *SessionsListRequest) (*SessionList, error)
+	// SessionsKick kicks out a session or connection.
+	SessionsKick(context.Context, *SessionsKickRequest) (*SessionsKickResponse, error)
+	// RecordingsList returns all recordings.
+	RecordingsList(context.Context, *RecordingsListRequest) (*RecordingList, error)
+	// RecordingsGet returns recordings of a path.
+	RecordingsGet(context.Context, *RecordingsGetRequest) (*Recording, error)
+	// Events returns a stream of events.
+	Events(*EventsRequest, Control_EventsServer) error
+	// Frames returns a stream of frames of a path.
+	Frames(*FramesRequest, Control_FramesServer) error
+	mustEmbedUnimplementedControlServer()
+}
+
+// UnimplementedControlServer must be embedded to have forward compatible implementations.
+type UnimplementedControlServer struct {
+}
+
+func (UnimplementedControlServer) PathsList(context.Context, *PathsListRequest) (*PathList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PathsList not implemented")
+}
+func (UnimplementedControlServer) PathsGet(context.Context, *PathsGetRequest) (*Path, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PathsGet not implemented")
+}
+func (UnimplementedControlServer) SessionsList(context.Context, *SessionsListRequest) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SessionsList not implemented")
+}
+func (UnimplementedControlServer) SessionsKick(context.Context, *SessionsKickRequest) (*SessionsKickResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SessionsKick not implemented")
+}
+func (UnimplementedControlServer) RecordingsList(context.Context, *RecordingsListRequest) (*RecordingList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordingsList not implemented")
+}
+func (UnimplementedControlServer) RecordingsGet(context.Context, *RecordingsGetRequest) (*Recording, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordingsGet not implemented")
+}
+func (UnimplementedControlServer) Events(*EventsRequest, Control_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedControlServer) Frames(*FramesRequest, Control_FramesServer) error {
+	return status.Errorf(codes.Unimplemented, "method Frames not implemented")
+}
+func (UnimplementedControlServer) mustEmbedUnimplementedControlServer() {}
+
+// UnsafeControlServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ControlServer will
+// result in compilation errors.
+type UnsafeControlServer interface {
+	mustEmbedUnimplementedControlServer()
+}
+
+func RegisterControlServer(s grpc.ServiceRegistrar, srv ControlServer) {
+	s.RegisterService(&Control_ServiceDesc, srv)
+}
+
+func _Control_PathsList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathsListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).PathsList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_PathsList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).PathsList(ctx, req.(*PathsListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_PathsGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathsGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).PathsGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_PathsGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).PathsGet(ctx, req.(*PathsGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_SessionsList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionsListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).SessionsList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_SessionsList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).SessionsList(ctx, req.(*SessionsListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_SessionsKick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionsKickRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).SessionsKick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_SessionsKick_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).SessionsKick(ctx, req.(*SessionsKickRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_RecordingsList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordingsListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).RecordingsList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_RecordingsList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).RecordingsList(ctx, req.(*RecordingsListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_RecordingsGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordingsGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).RecordingsGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_RecordingsGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).RecordingsGet(ctx, req.(*RecordingsGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).Events(m, &controlEventsServer{stream})
+}
+
+type Control_EventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type controlEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Control_Frames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FramesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).Frames(m, &controlFramesServer{stream})
+}
+
+type Control_FramesServer interface {
+	Send(*Frame) error
+	grpc.ServerStream
+}
+
+type controlFramesServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlFramesServer) Send(m *Frame) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Control_ServiceDesc is the grpc.ServiceDesc for Control service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Control_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mediamtx.v3.Control",
+	HandlerType: (*ControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PathsList",
+			Handler:    _Control_PathsList_Handler,
+		},
+		{
+			MethodName: "PathsGet",
+			Handler:    _Control_PathsGet_Handler,
+		},
+		{
+			MethodName: "SessionsList",
+			Handler:    _Control_SessionsList_Handler,
+		},
+		{
+			MethodName: "SessionsKick",
+			Handler:    _Control_SessionsKick_Handler,
+		},
+		{
+			MethodName: "RecordingsList",
+			Handler:    _Control_RecordingsList_Handler,
+		},
+		{
+			MethodName: "RecordingsGet",
+			Handler:    _Control_RecordingsGet_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Control_Events_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Frames",
+			Handler:       _Control_Frames_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "control.proto",
+}
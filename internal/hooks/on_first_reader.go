@@ -0,0 +1,84 @@
+package hooks
+
+import (
+	"strconv"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// ReaderInfo contains informations about the reader that triggered
+// OnFirstReader or OnLastReader.
+type ReaderInfo struct {
+	Desc     defs.APIPathSourceOrReader
+	Protocol string
+	User     string
+	Query    string
+}
+
+// OnFirstReaderParams are the parameters of OnFirstReader.
+type OnFirstReaderParams struct {
+	Logger          logger.Writer
+	ExternalCmdPool *externalcmd.Pool
+	Conf            *conf.Path
+	ExternalCmdEnv  externalcmd.Environment
+	Reader          ReaderInfo
+}
+
+func addReaderEnv(env externalcmd.Environment, reader ReaderInfo, readerCount int) {
+	env["MTX_QUERY"] = reader.Query
+	env["MTX_READER_TYPE"] = reader.Desc.Type
+	env["MTX_READER_ID"] = reader.Desc.ID
+	env["MTX_READER_PROTOCOL"] = reader.Protocol
+	env["MTX_READER_USER"] = reader.User
+	env["MTX_READER_COUNT"] = strconv.FormatInt(int64(readerCount), 10)
+}
+
+// OnFirstReader is the OnFirstReader hook.
+// It returns a function that must be called when the last reader leaves the path.
+func OnFirstReader(params OnFirstReaderParams) func(ReaderInfo) {
+	var onFirstReaderCmd *externalcmd.Cmd
+
+	if params.Conf.RunOnFirstReader != "" {
+		env := externalcmd.Environment{}
+		for k, v := range params.ExternalCmdEnv {
+			env[k] = v
+		}
+		addReaderEnv(env, params.Reader, 1)
+
+		params.Logger.Log(logger.Info, "runOnFirstReader command started")
+		onFirstReaderCmd = externalcmd.NewCmd(
+			params.ExternalCmdPool,
+			params.Conf.RunOnFirstReader,
+			params.Conf.RunOnFirstReaderRestart,
+			env,
+			func(err error) {
+				params.Logger.Log(logger.Info, "runOnFirstReader command exited: %v", err)
+			})
+	}
+
+	return func(lastReader ReaderInfo) {
+		if onFirstReaderCmd != nil {
+			onFirstReaderCmd.Close()
+			params.Logger.Log(logger.Info, "runOnFirstReader command stopped")
+		}
+
+		if params.Conf.RunOnLastReader != "" {
+			env := externalcmd.Environment{}
+			for k, v := range params.ExternalCmdEnv {
+				env[k] = v
+			}
+			addReaderEnv(env, lastReader, 0)
+
+			params.Logger.Log(logger.Info, "runOnLastReader command launched")
+			externalcmd.NewCmd(
+				params.ExternalCmdPool,
+				params.Conf.RunOnLastReader,
+				false,
+				env,
+				nil)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package hooks
 
 import (
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
@@ -14,6 +16,7 @@ type OnReadyParams struct {
 	Conf            *conf.Path
 	ExternalCmdEnv  externalcmd.Environment
 	Desc            defs.APIPathSourceOrReader
+	Medias          []*description.Media
 	Query           string
 }
 
@@ -27,6 +30,7 @@ func OnReady(params OnReadyParams) func() {
 		env["MTX_QUERY"] = params.Query
 		env["MTX_SOURCE_TYPE"] = params.Desc.Type
 		env["MTX_SOURCE_ID"] = params.Desc.ID
+		addTrackEnv(env, params.Medias)
 	}
 
 	if params.Conf.RunOnReady != "" {
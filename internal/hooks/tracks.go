@@ -0,0 +1,101 @@
+package hooks
+
+import (
+	"strconv"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+)
+
+type trackInfo struct {
+	codec        string
+	width        int
+	height       int
+	sampleRate   int
+	channelCount int
+}
+
+func formatTrackInfo(forma format.Format) trackInfo {
+	info := trackInfo{
+		codec: forma.Codec(),
+	}
+
+	switch forma := forma.(type) {
+	case *format.H264:
+		sps, _ := forma.SafeParams()
+		if sps != nil {
+			var s h264.SPS
+			if err := s.Unmarshal(sps); err == nil {
+				info.width = s.Width()
+				info.height = s.Height()
+			}
+		}
+
+	case *format.H265:
+		_, sps, _ := forma.SafeParams()
+		if sps != nil {
+			var s h265.SPS
+			if err := s.Unmarshal(sps); err == nil {
+				info.width = s.Width()
+				info.height = s.Height()
+			}
+		}
+
+	case *format.MPEG4Audio:
+		if forma.Config != nil {
+			info.sampleRate = forma.Config.SampleRate
+			info.channelCount = forma.Config.ChannelCount
+		}
+
+	case *format.Opus:
+		info.sampleRate = forma.ClockRate()
+		info.channelCount = forma.ChannelCount
+
+	case *format.G711:
+		info.sampleRate = forma.SampleRate
+		info.channelCount = forma.ChannelCount
+
+	case *format.LPCM:
+		info.sampleRate = forma.SampleRate
+		info.channelCount = forma.ChannelCount
+
+	case *format.AC3:
+		info.sampleRate = forma.SampleRate
+		info.channelCount = forma.ChannelCount
+	}
+
+	return info
+}
+
+// addTrackEnv adds the details of every track to a command environment.
+func addTrackEnv(env externalcmd.Environment, medias []*description.Media) {
+	i := 0
+
+	for _, media := range medias {
+		for _, forma := range media.Formats {
+			info := formatTrackInfo(forma)
+			prefix := "MTX_TRACK_" + strconv.FormatInt(int64(i), 10) + "_"
+
+			env[prefix+"TYPE"] = string(media.Type)
+			env[prefix+"CODEC"] = info.codec
+
+			if info.width != 0 {
+				env[prefix+"WIDTH"] = strconv.FormatInt(int64(info.width), 10)
+				env[prefix+"HEIGHT"] = strconv.FormatInt(int64(info.height), 10)
+			}
+
+			if info.sampleRate != 0 {
+				env[prefix+"SAMPLE_RATE"] = strconv.FormatInt(int64(info.sampleRate), 10)
+				env[prefix+"CHANNEL_COUNT"] = strconv.FormatInt(int64(info.channelCount), 10)
+			}
+
+			i++
+		}
+	}
+
+	env["MTX_TRACK_COUNT"] = strconv.FormatInt(int64(i), 10)
+}
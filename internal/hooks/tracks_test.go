@@ -0,0 +1,64 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestAddTrackEnv(t *testing.T) {
+	env := externalcmd.Environment{}
+
+	addTrackEnv(env, []*description.Media{
+		test.MediaH264,
+		{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			}},
+		},
+		{
+			Type: description.MediaTypeAudio,
+			Formats: []format.Format{
+				test.FormatMPEG4Audio,
+				&format.Opus{
+					PayloadTyp:   97,
+					ChannelCount: 2,
+				},
+			},
+		},
+		{
+			Type: description.MediaTypeApplication,
+			Formats: []format.Format{&format.Generic{
+				PayloadTyp: 98,
+				RTPMa:      "private/90000",
+			}},
+		},
+	})
+
+	require.Equal(t, externalcmd.Environment{
+		"MTX_TRACK_COUNT":           "5",
+		"MTX_TRACK_0_TYPE":          "video",
+		"MTX_TRACK_0_CODEC":         "H264",
+		"MTX_TRACK_0_WIDTH":         "1920",
+		"MTX_TRACK_0_HEIGHT":        "1080",
+		"MTX_TRACK_1_TYPE":          "video",
+		"MTX_TRACK_1_CODEC":         "H264",
+		"MTX_TRACK_2_TYPE":          "audio",
+		"MTX_TRACK_2_CODEC":         "MPEG-4 Audio",
+		"MTX_TRACK_2_SAMPLE_RATE":   "44100",
+		"MTX_TRACK_2_CHANNEL_COUNT": "2",
+		"MTX_TRACK_3_TYPE":          "audio",
+		"MTX_TRACK_3_CODEC":         "Opus",
+		"MTX_TRACK_3_SAMPLE_RATE":   "48000",
+		"MTX_TRACK_3_CHANNEL_COUNT": "2",
+		"MTX_TRACK_4_TYPE":          "application",
+		"MTX_TRACK_4_CODEC":         "Generic",
+	}, env)
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -14,7 +15,10 @@ import (
 
 	"github.com/bluenviron/mediamtx/internal/api"
 	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/clockmonitor"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
+	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
 	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
@@ -28,10 +32,41 @@ func metric(key string, tags string, value int64) string {
 	return key + tags + " " + strconv.FormatInt(value, 10) + "\n"
 }
 
+func boolToInt64(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
 func metricFloat(key string, tags string, value float64) string {
 	return key + tags + " " + strconv.FormatFloat(value, 'f', -1, 64) + "\n"
 }
 
+func transportStatsMetrics(prefix string, data *defs.APIRTSPTransportStats) string {
+	out := ""
+
+	transports := make([]string, 0, len(data.Rejections))
+	for k := range data.Rejections {
+		transports = append(transports, k)
+	}
+	sort.Strings(transports)
+
+	for _, transport := range transports {
+		out += metric(prefix+"_transport_rejections", "{transport=\""+transport+"\"}", int64(data.Rejections[transport]))
+	}
+
+	if len(data.Fallbacks) != 0 {
+		for _, f := range data.Fallbacks {
+			out += metric(prefix+"_transport_fallbacks", "{from=\""+f.From+"\",to=\""+f.To+"\"}", int64(f.Count))
+		}
+	} else {
+		out += metric(prefix+"_transport_fallbacks", "", 0)
+	}
+
+	return out
+}
+
 type metricsAuthManager interface {
 	Authenticate(req *auth.Request) error
 }
@@ -42,26 +77,33 @@ type metricsParent interface {
 
 // Metrics is a metrics provider.
 type Metrics struct {
-	Address        string
-	Encryption     bool
-	ServerKey      string
-	ServerCert     string
-	AllowOrigin    string
-	TrustedProxies conf.IPNetworks
-	ReadTimeout    conf.StringDuration
-	AuthManager    metricsAuthManager
-	Parent         metricsParent
-
-	httpServer   *httpp.WrappedServer
-	mutex        sync.Mutex
-	pathManager  api.PathManager
-	rtspServer   api.RTSPServer
-	rtspsServer  api.RTSPServer
-	rtmpServer   api.RTMPServer
-	rtmpsServer  api.RTMPServer
-	srtServer    api.SRTServer
-	hlsManager   api.HLSServer
-	webRTCServer api.WebRTCServer
+	Address         string
+	Encryption      bool
+	ServerKey       string
+	ServerCert      string
+	TLSOptions      conf.TLSOptions
+	ServerHeader    string
+	ResponseHeaders conf.HTTPResponseHeaders
+	AllowOrigin     string
+	TrustedProxies  conf.IPNetworks
+	ReadTimeout     conf.StringDuration
+	AuthManager     metricsAuthManager
+	Parent          metricsParent
+
+	httpServer     *httpp.WrappedServer
+	mutex          sync.Mutex
+	pathManager    api.PathManager
+	rtspServer     api.RTSPServer
+	rtspsServer    api.RTSPServer
+	rtmpServer     api.RTMPServer
+	rtmpsServer    api.RTMPServer
+	srtServer      api.SRTServer
+	hlsManager     api.HLSServer
+	webRTCServer   api.WebRTCServer
+	bandwidth      api.BandwidthAccountant
+	requestLimiter *httpp.RequestLimiter
+	connLimiter    *connlimiter.Limiter
+	clockMonitor   *clockmonitor.Monitor
 }
 
 // Initialize initializes metrics.
@@ -73,14 +115,17 @@ func (m *Metrics) Initialize() error {
 	network, address := restrictnetwork.Restrict("tcp", m.Address)
 
 	m.httpServer = &httpp.WrappedServer{
-		Network:     network,
-		Address:     address,
-		ReadTimeout: time.Duration(m.ReadTimeout),
-		Encryption:  m.Encryption,
-		ServerCert:  m.ServerCert,
-		ServerKey:   m.ServerKey,
-		Handler:     router,
-		Parent:      m,
+		Network:         network,
+		Address:         address,
+		ReadTimeout:     time.Duration(m.ReadTimeout),
+		Encryption:      m.Encryption,
+		ServerCert:      m.ServerCert,
+		ServerKey:       m.ServerKey,
+		TLSOptions:      m.TLSOptions,
+		ServerHeader:    m.ServerHeader,
+		ResponseHeaders: m.ResponseHeaders,
+		Handler:         router,
+		Parent:          m,
 	}
 	err := m.httpServer.Initialize()
 	if err != nil {
@@ -123,11 +168,12 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 	user, pass, hasCredentials := ctx.Request.BasicAuth()
 
 	err := m.AuthManager.Authenticate(&auth.Request{
-		User:   user,
-		Pass:   pass,
-		Query:  ctx.Request.URL.RawQuery,
-		IP:     net.ParseIP(ctx.ClientIP()),
-		Action: conf.AuthActionMetrics,
+		User:    user,
+		Pass:    pass,
+		Query:   ctx.Request.URL.RawQuery,
+		IP:      net.ParseIP(ctx.ClientIP()),
+		LocalIP: httpp.LocalIP(ctx.Request),
+		Action:  conf.AuthActionMetrics,
 	})
 	if err != nil {
 		if !hasCredentials {
@@ -159,6 +205,24 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 			out += metric("paths", tags, 1)
 			out += metric("paths_bytes_received", tags, int64(i.BytesReceived))
 			out += metric("paths_bytes_sent", tags, int64(i.BytesSent))
+			out += metric("paths_whep_readers", tags, int64(i.WHEPReaders))
+
+			for _, l := range i.AudioLevels {
+				ltags := "{name=\"" + i.Name + "\",state=\"" + state + "\",track=\"" + strconv.FormatInt(int64(l.Track), 10) + "\"}"
+				out += metricFloat("paths_audio_loudness", ltags, l.Loudness)
+				out += metricFloat("paths_audio_peak", ltags, l.Peak)
+			}
+
+			if i.VideoStatus != nil {
+				out += metric("paths_video_frozen", tags, boolToInt64(i.VideoStatus.Frozen))
+				out += metric("paths_video_black", tags, boolToInt64(i.VideoStatus.Black))
+			}
+
+			if i.GOPStatus != nil {
+				out += metric("paths_gop_open", tags, boolToInt64(i.GOPStatus.OpenGOP))
+				out += metricFloat("paths_keyframe_interval_seconds", tags, time.Duration(i.GOPStatus.KeyframeInterval).Seconds())
+				out += metric("paths_keyframe_interval_long", tags, boolToInt64(i.GOPStatus.LongKeyframeInterval))
+			}
 		}
 	} else {
 		out += metric("paths", "", 0)
@@ -171,6 +235,14 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 				tags := "{name=\"" + i.Path + "\"}"
 				out += metric("hls_muxers", tags, 1)
 				out += metric("hls_muxers_bytes_sent", tags, int64(i.BytesSent))
+				out += metricFloat("hls_muxers_segment_target_duration", tags, i.SegmentTargetDuration)
+				out += metricFloat("hls_muxers_last_segment_duration", tags, i.LastSegmentDuration)
+				out += metric("hls_muxers_segments_over_target", tags, int64(i.SegmentsOverTarget))
+				out += metricFloat("hls_muxers_part_target_duration", tags, i.PartTargetDuration)
+				out += metricFloat("hls_muxers_last_part_duration", tags, i.LastPartDuration)
+				out += metric("hls_muxers_parts_over_target", tags, int64(i.PartsOverTarget))
+				out += metric("hls_muxers_late_part_deliveries", tags, int64(i.LatePartDeliveries))
+				out += metricFloat("hls_muxers_playlist_update_latency", tags, i.PlaylistUpdateLatency)
 			}
 		} else {
 			out += metric("hls_muxers", "", 0)
@@ -210,6 +282,11 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 				out += metric("rtsp_sessions_bytes_sent", "", 0)
 			}
 		}()
+
+		data, err := m.rtspServer.APITransportStats()
+		if err == nil {
+			out += transportStatsMetrics("rtsp", data)
+		}
 	}
 
 	if !interfaceIsEmpty(m.rtspsServer) { //nolint:dupl
@@ -244,6 +321,11 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 				out += metric("rtsps_sessions_bytes_sent", "", 0)
 			}
 		}()
+
+		data, err := m.rtspsServer.APITransportStats()
+		if err == nil {
+			out += transportStatsMetrics("rtsps", data)
+		}
 	}
 
 	if !interfaceIsEmpty(m.rtmpServer) {
@@ -341,6 +423,8 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 			out += metric("srt_conns_bytes_received", "", 0)
 			out += metric("srt_conns_bytes_sent", "", 0)
 		}
+
+		out += metric("srt_handshakes_rejected", "", int64(m.srtServer.HandshakesRejected()))
 	}
 
 	if !interfaceIsEmpty(m.webRTCServer) {
@@ -359,6 +443,38 @@ func (m *Metrics) onRequest(ctx *gin.Context) {
 		}
 	}
 
+	if !interfaceIsEmpty(m.bandwidth) {
+		data := m.bandwidth.APIUsersList()
+		if len(data.Items) != 0 {
+			for _, i := range data.Items {
+				tags := "{user=\"" + i.User + "\"}"
+				out += metric("bandwidth_users_bytes_received", tags, int64(i.BytesReceived))
+				out += metric("bandwidth_users_bytes_sent", tags, int64(i.BytesSent))
+			}
+		} else {
+			out += metric("bandwidth_users_bytes_received", "", 0)
+			out += metric("bandwidth_users_bytes_sent", "", 0)
+		}
+	}
+
+	if m.requestLimiter != nil && m.requestLimiter.RateLimit != 0 {
+		for _, st := range m.requestLimiter.Stats() {
+			tags := "{server=\"" + st.Server + "\"}"
+			out += metric("http_requests_throttled", tags, int64(st.Throttled))
+		}
+	}
+
+	if m.connLimiter != nil {
+		for _, st := range m.connLimiter.Stats() {
+			tags := "{listener=\"" + st.Listener + "\"}"
+			out += metric("connections_throttled", tags, int64(st.Throttled))
+		}
+	}
+
+	if m.clockMonitor != nil {
+		out += metric("clock_jumps", "", int64(m.clockMonitor.Jumps()))
+	}
+
 	ctx.Writer.WriteHeader(http.StatusOK)
 	io.WriteString(ctx.Writer, out) //nolint:errcheck
 }
@@ -418,3 +534,31 @@ func (m *Metrics) SetWebRTCServer(s api.WebRTCServer) {
 	defer m.mutex.Unlock()
 	m.webRTCServer = s
 }
+
+// SetBandwidthAccountant is called by core.
+func (m *Metrics) SetBandwidthAccountant(a api.BandwidthAccountant) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.bandwidth = a
+}
+
+// SetRequestLimiter is called by core.
+func (m *Metrics) SetRequestLimiter(l *httpp.RequestLimiter) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.requestLimiter = l
+}
+
+// SetClockMonitor is called by core.
+func (m *Metrics) SetClockMonitor(cm *clockmonitor.Monitor) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.clockMonitor = cm
+}
+
+// SetConnLimiter is called by core.
+func (m *Metrics) SetConnLimiter(l *connlimiter.Limiter) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.connLimiter = l
+}
@@ -0,0 +1,151 @@
+// Package pathstate contains a store that persists the state of paths across restarts.
+package pathstate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+type state struct {
+	// configurations of paths created at runtime through the Control API.
+	Paths map[string]*conf.OptionalPath `json:"paths"`
+
+	// paths whose on-demand source was running.
+	ActivePaths []string `json:"activePaths"`
+}
+
+// Store persists the state of paths into a file.
+type Store struct {
+	FilePath string
+	Parent   logger.Writer
+
+	mutex       sync.Mutex
+	paths       map[string]*conf.OptionalPath
+	activePaths map[string]struct{}
+}
+
+// Initialize initializes Store, loading the existing state, if any.
+func (s *Store) Initialize() error {
+	s.paths = make(map[string]*conf.OptionalPath)
+	s.activePaths = make(map[string]struct{})
+
+	byts, err := os.ReadFile(s.FilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var st state
+	err = json.Unmarshal(byts, &st)
+	if err != nil {
+		return err
+	}
+
+	if st.Paths != nil {
+		s.paths = st.Paths
+	}
+
+	for _, name := range st.ActivePaths {
+		s.activePaths[name] = struct{}{}
+	}
+
+	return nil
+}
+
+// Log implements logger.Writer.
+func (s *Store) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[path state] "+format, args...)
+}
+
+// Paths returns the configurations of paths created at runtime.
+func (s *Store) Paths() map[string]*conf.OptionalPath {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := make(map[string]*conf.OptionalPath, len(s.paths))
+	for name, p := range s.paths {
+		ret[name] = p
+	}
+	return ret
+}
+
+// SetPaths sets the configurations of paths created at runtime.
+func (s *Store) SetPaths(paths map[string]*conf.OptionalPath) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.paths = paths
+	s.save()
+}
+
+// ActivePaths returns the paths whose on-demand source was running.
+func (s *Store) ActivePaths() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.sortedActivePaths()
+}
+
+// SetPathActive sets whether the on-demand source of a path is running.
+func (s *Store) SetPathActive(name string, active bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.activePaths[name]
+	if ok == active {
+		return
+	}
+
+	if active {
+		s.activePaths[name] = struct{}{}
+	} else {
+		delete(s.activePaths, name)
+	}
+
+	s.save()
+}
+
+func (s *Store) sortedActivePaths() []string {
+	ret := make([]string, 0, len(s.activePaths))
+	for name := range s.activePaths {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (s *Store) save() {
+	byts, err := json.MarshalIndent(state{
+		Paths:       s.paths,
+		ActivePaths: s.sortedActivePaths(),
+	}, "", "  ")
+	if err != nil {
+		s.Log(logger.Error, "%v", err)
+		return
+	}
+
+	// write into a temporary file and rename it,
+	// in order not to leave a truncated file in case of crashes.
+	tmpPath := filepath.Join(filepath.Dir(s.FilePath), "."+filepath.Base(s.FilePath)+".tmp")
+
+	err = os.WriteFile(tmpPath, byts, 0o644)
+	if err != nil {
+		s.Log(logger.Error, "%v", err)
+		return
+	}
+
+	err = os.Rename(tmpPath, s.FilePath)
+	if err != nil {
+		os.Remove(tmpPath)
+		s.Log(logger.Error, "%v", err)
+	}
+}
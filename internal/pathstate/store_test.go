@@ -0,0 +1,54 @@
+package pathstate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-pathstate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fpath := filepath.Join(dir, "state.json")
+
+	s := &Store{
+		FilePath: fpath,
+		Parent:   test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	require.Empty(t, s.Paths())
+	require.Empty(t, s.ActivePaths())
+
+	var p conf.OptionalPath
+	err = json.Unmarshal([]byte(`{"source":"publisher","maxReaders":5}`), &p)
+	require.NoError(t, err)
+
+	s.SetPaths(map[string]*conf.OptionalPath{"mypath": &p})
+	s.SetPathActive("cam2", true)
+	s.SetPathActive("cam1", true)
+	s.SetPathActive("cam3", true)
+	s.SetPathActive("cam3", false)
+
+	s = &Store{
+		FilePath: fpath,
+		Parent:   test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"cam1", "cam2"}, s.ActivePaths())
+
+	byts, err := json.Marshal(s.Paths()["mypath"])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"source":"publisher","maxReaders":5}`, string(byts))
+}
@@ -0,0 +1,124 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// byteRange is a range of bytes requested through the Range header.
+type byteRange struct {
+	start int64
+	end   int64 // exclusive
+}
+
+// contentRange returns the value of the Content-Range header.
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.end-1, size)
+}
+
+func parsePosition(raw string) (int64, bool) {
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseByteRange parses the Range header.
+// It returns nil when the header is missing, invalid or contains multiple ranges,
+// since in these cases the header must be ignored and the entire file must be sent.
+func parseByteRange(header string, size int64) (*byteRange, error) {
+	raw, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(raw, ",") {
+		return nil, nil
+	}
+
+	startRaw, endRaw, ok := strings.Cut(strings.TrimSpace(raw), "-")
+	if !ok {
+		return nil, nil
+	}
+
+	// suffix range, containing the last N bytes
+	if startRaw == "" {
+		n, ok := parsePosition(endRaw)
+		if !ok {
+			return nil, nil
+		}
+
+		if n == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+
+		if n > size {
+			n = size
+		}
+
+		return &byteRange{start: size - n, end: size}, nil
+	}
+
+	start, ok := parsePosition(startRaw)
+	if !ok {
+		return nil, nil
+	}
+
+	end := size
+
+	if endRaw != "" {
+		last, ok := parsePosition(endRaw)
+		if !ok || last < start {
+			return nil, nil
+		}
+
+		if (last + 1) < end {
+			end = last + 1
+		}
+	}
+
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+
+	return &byteRange{start: start, end: end}, nil
+}
+
+// rangeWriter is a writer that forwards to W only the bytes inside [Start, End).
+type rangeWriter struct {
+	W     io.Writer
+	Start int64
+	End   int64
+
+	pos int64
+}
+
+// Write implements io.Writer.
+func (w *rangeWriter) Write(p []byte) (int, error) {
+	start := w.Start - w.pos
+	if start < 0 {
+		start = 0
+	}
+
+	end := w.End - w.pos
+	if end > int64(len(p)) {
+		end = int64(len(p))
+	}
+
+	if start < end {
+		_, err := w.W.Write(p[start:end])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	w.pos += int64(len(p))
+	return len(p), nil
+}
+
+// skips returns whether the next n bytes are entirely outside the range.
+func (w *rangeWriter) skips(n int64) bool {
+	return (w.pos+n) <= w.Start || w.pos >= w.End
+}
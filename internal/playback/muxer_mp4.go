@@ -24,6 +24,10 @@ func findTrackMP4(tracks []*muxerMP4Track, id int) *muxerMP4Track {
 type muxerMP4 struct {
 	w io.Writer
 
+	// if set, it is called with the size of the file before writing it,
+	// and returns the range of bytes to write.
+	onSize func(size int64) (start int64, end int64)
+
 	tracks   []*muxerMP4Track
 	curTrack *muxerMP4Track
 }
@@ -101,5 +105,47 @@ func (w *muxerMP4) flush() error {
 		h.Tracks[i] = &track.Track
 	}
 
-	return h.Marshal(w.w)
+	if w.onSize == nil {
+		return h.Marshal(w.w)
+	}
+
+	var rw *rangeWriter
+	var zeros []byte
+
+	// do not read payloads that are outside the range
+	for _, track := range w.tracks {
+		for _, sa := range track.Samples {
+			getPayload := sa.GetPayload
+			payloadSize := sa.PayloadSize
+
+			sa.GetPayload = func() ([]byte, error) {
+				if rw.skips(int64(payloadSize)) {
+					if uint32(len(zeros)) < payloadSize {
+						zeros = make([]byte, payloadSize)
+					}
+					return zeros[:payloadSize], nil
+				}
+				return getPayload()
+			}
+		}
+	}
+
+	// compute size
+	rw = &rangeWriter{W: io.Discard}
+	err := h.Marshal(rw)
+	if err != nil {
+		return err
+	}
+
+	start, end := w.onSize(rw.pos)
+	if start == end {
+		return nil
+	}
+
+	rw = &rangeWriter{
+		W:     w.w,
+		Start: start,
+		End:   end,
+	}
+	return h.Marshal(rw)
 }
@@ -30,6 +30,43 @@ func (w *writerWrapper) Write(p []byte) (int, error) {
 	return w.ctx.Writer.Write(p)
 }
 
+// writeRangeHeaders writes the response headers of a MP4 file with the given size,
+// taking into account the Range header, and returns the range of bytes to send.
+func writeRangeHeaders(ctx *gin.Context, ww *writerWrapper, size int64) (int64, int64) {
+	// headers are written here, prevent writerWrapper from overriding them
+	ww.written = true
+
+	ctx.Header("Accept-Ranges", "bytes")
+	ctx.Header("Content-Type", "video/mp4")
+
+	r, err := parseByteRange(ctx.GetHeader("Range"), size)
+	if err != nil {
+		ctx.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		ctx.Header("Content-Length", "0")
+		ctx.Status(http.StatusRequestedRangeNotSatisfiable)
+		return 0, 0
+	}
+
+	var start int64
+	end := size
+
+	if r != nil {
+		start, end = r.start, r.end
+		ctx.Header("Content-Range", r.contentRange(size))
+		ctx.Status(http.StatusPartialContent)
+	} else {
+		ctx.Status(http.StatusOK)
+	}
+
+	ctx.Header("Content-Length", strconv.FormatInt(end-start, 10))
+
+	if ctx.Request.Method == http.MethodHead {
+		return 0, 0
+	}
+
+	return start, end
+}
+
 func parseDuration(raw string) (time.Duration, error) {
 	// seconds
 	if secs, err := strconv.ParseFloat(raw, 64); err == nil {
@@ -140,7 +177,12 @@ func (s *Server) onGet(ctx *gin.Context) {
 		m = &muxerFMP4{w: ww}
 
 	case "mp4":
-		m = &muxerMP4{w: ww}
+		m = &muxerMP4{
+			w: ww,
+			onSize: func(size int64) (int64, int64) {
+				return writeRangeHeaders(ctx, ww, size)
+			},
+		}
 
 	default:
 		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid format: %s", format))
@@ -163,6 +205,15 @@ func (s *Server) onGet(ctx *gin.Context) {
 		return
 	}
 
+	// the size of fMP4 files is not known in advance, therefore HEAD requests are
+	// answered without generating the file.
+	if ctx.Request.Method == http.MethodHead && format != "mp4" {
+		ctx.Header("Accept-Ranges", "none")
+		ctx.Header("Content-Type", "video/mp4")
+		ctx.Status(http.StatusOK)
+		return
+	}
+
 	err = seekAndMux(pathConf.RecordFormat, segments, start, duration, m)
 	if err != nil {
 		// user aborted the download
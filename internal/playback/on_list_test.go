@@ -39,12 +39,13 @@ func TestOnList(t *testing.T) {
 		AuthManager: &test.AuthManager{
 			Func: func(req *auth.Request) error {
 				require.Equal(t, &auth.Request{
-					User:   "myuser",
-					Pass:   "mypass",
-					IP:     req.IP,
-					Action: "playback",
-					Query:  "path=mypath",
-					Path:   "mypath",
+					User:    "myuser",
+					Pass:    "mypass",
+					IP:      req.IP,
+					LocalIP: req.LocalIP,
+					Action:  "playback",
+					Query:   "path=mypath",
+					Path:    "mypath",
 				}, req)
 				return nil
 			},
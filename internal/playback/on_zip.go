@@ -0,0 +1,176 @@
+package playback
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/gin-gonic/gin"
+)
+
+type zipManifestEntry struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	Size  int64     `json:"size"`
+}
+
+type zipManifest struct {
+	Path     string             `json:"path"`
+	Start    time.Time          `json:"start"`
+	Duration listEntryDuration  `json:"duration"`
+	Files    []zipManifestEntry `json:"files"`
+}
+
+// segmentSidecars returns the files that are written beside a segment.
+func segmentSidecars(segmentPath string) []string {
+	var out []string
+
+	for _, fpath := range []string{
+		recordstore.SubtitlesPath(segmentPath),
+		recordstore.DataTracksPath(segmentPath),
+	} {
+		if _, err := os.Stat(fpath); err == nil {
+			out = append(out, fpath)
+		}
+	}
+
+	return out
+}
+
+func zipAddFile(zw *zip.Writer, name string, fpath string) (int64, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// recordings are already compressed, therefore they are stored as they are.
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: fi.ModTime(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return io.Copy(w, f)
+}
+
+func writeZip(
+	w io.Writer,
+	pathName string,
+	segments []*recordstore.Segment,
+	start time.Time,
+	duration time.Duration,
+) error {
+	zw := zip.NewWriter(w)
+
+	manifest := zipManifest{
+		Path:     pathName,
+		Start:    start,
+		Duration: listEntryDuration(duration),
+		Files:    []zipManifestEntry{},
+	}
+
+	for _, seg := range segments {
+		for _, fpath := range append([]string{seg.Fpath}, segmentSidecars(seg.Fpath)...) {
+			name := filepath.Base(fpath)
+
+			size, err := zipAddFile(zw, name, fpath)
+			if err != nil {
+				return err
+			}
+
+			manifest.Files = append(manifest.Files, zipManifestEntry{
+				Name:  name,
+				Start: seg.Start,
+				Size:  size,
+			})
+		}
+	}
+
+	mw, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func (s *Server) onZip(ctx *gin.Context) {
+	pathName := ctx.Query("path")
+
+	if !s.doAuth(ctx, pathName) {
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, ctx.Query("start"))
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid start: %w", err))
+		return
+	}
+
+	duration, err := parseDuration(ctx.Query("duration"))
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid duration: %w", err))
+		return
+	}
+
+	pathConf, err := s.safeFindPathConf(pathName)
+	if err != nil {
+		s.writeError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	segments, err := recordstore.FindSegmentsInTimespan(pathConf, pathName, start, duration)
+	if err != nil {
+		if errors.Is(err, recordstore.ErrNoSegmentsFound) {
+			s.writeError(ctx, http.StatusNotFound, err)
+		} else {
+			s.writeError(ctx, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	fname := strings.ReplaceAll(pathName, "/", "_") + "_" + start.UTC().Format("20060102T150405Z") + ".zip"
+
+	ctx.Header("Accept-Ranges", "none")
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", `attachment; filename="`+fname+`"`)
+
+	err = writeZip(ctx.Writer, pathName, segments, start, duration)
+	if err != nil {
+		// user aborted the download
+		var neterr *net.OpError
+		if errors.As(err, &neterr) {
+			return
+		}
+
+		// headers have already been written: abort and write logs only
+		s.Log(logger.Error, err.Error())
+		return
+	}
+}
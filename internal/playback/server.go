@@ -22,16 +22,20 @@ type serverAuthManager interface {
 
 // Server is the playback server.
 type Server struct {
-	Address        string
-	Encryption     bool
-	ServerKey      string
-	ServerCert     string
-	AllowOrigin    string
-	TrustedProxies conf.IPNetworks
-	ReadTimeout    conf.StringDuration
-	PathConfs      map[string]*conf.Path
-	AuthManager    serverAuthManager
-	Parent         logger.Writer
+	Address         string
+	Encryption      bool
+	ServerKey       string
+	ServerCert      string
+	TLSOptions      conf.TLSOptions
+	ServerHeader    string
+	ResponseHeaders conf.HTTPResponseHeaders
+	AllowOrigin     string
+	TrustedProxies  conf.IPNetworks
+	ReadTimeout     conf.StringDuration
+	RequestLimiter  *httpp.RequestLimiter
+	PathConfs       map[string]*conf.Path
+	AuthManager     serverAuthManager
+	Parent          logger.Writer
 
 	httpServer *httpp.WrappedServer
 	mutex      sync.RWMutex
@@ -42,23 +46,32 @@ func (s *Server) Initialize() error {
 	router := gin.New()
 	router.SetTrustedProxies(s.TrustedProxies.ToTrustedProxies()) //nolint:errcheck
 
+	if s.RequestLimiter != nil {
+		router.Use(s.RequestLimiter.Handler("playback"))
+	}
+
 	router.NoRoute(s.middlewareOrigin)
 	group := router.Group("/", s.middlewareOrigin)
 
 	group.GET("/list", s.onList)
 	group.GET("/get", s.onGet)
+	group.HEAD("/get", s.onGet)
+	group.GET("/zip", s.onZip)
 
 	network, address := restrictnetwork.Restrict("tcp", s.Address)
 
 	s.httpServer = &httpp.WrappedServer{
-		Network:     network,
-		Address:     address,
-		ReadTimeout: time.Duration(s.ReadTimeout),
-		Encryption:  s.Encryption,
-		ServerCert:  s.ServerCert,
-		ServerKey:   s.ServerKey,
-		Handler:     router,
-		Parent:      s,
+		Network:         network,
+		Address:         address,
+		ReadTimeout:     time.Duration(s.ReadTimeout),
+		Encryption:      s.Encryption,
+		ServerCert:      s.ServerCert,
+		ServerKey:       s.ServerKey,
+		TLSOptions:      s.TLSOptions,
+		ServerHeader:    s.ServerHeader,
+		ResponseHeaders: s.ResponseHeaders,
+		Handler:         router,
+		Parent:          s,
 	}
 	err := s.httpServer.Initialize()
 	if err != nil {
@@ -111,8 +124,8 @@ func (s *Server) middlewareOrigin(ctx *gin.Context) {
 	// preflight requests
 	if ctx.Request.Method == http.MethodOptions &&
 		ctx.Request.Header.Get("Access-Control-Request-Method") != "" {
-		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization")
+		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, HEAD")
+		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Range")
 		ctx.AbortWithStatus(http.StatusNoContent)
 		return
 	}
@@ -122,12 +135,13 @@ func (s *Server) doAuth(ctx *gin.Context, pathName string) bool {
 	user, pass, hasCredentials := ctx.Request.BasicAuth()
 
 	err := s.AuthManager.Authenticate(&auth.Request{
-		User:   user,
-		Pass:   pass,
-		Query:  ctx.Request.URL.RawQuery,
-		IP:     net.ParseIP(ctx.ClientIP()),
-		Action: conf.AuthActionPlayback,
-		Path:   pathName,
+		User:    user,
+		Pass:    pass,
+		Query:   ctx.Request.URL.RawQuery,
+		IP:      net.ParseIP(ctx.ClientIP()),
+		LocalIP: httpp.LocalIP(ctx.Request),
+		Action:  conf.AuthActionPlayback,
+		Path:    pathName,
 	})
 	if err != nil {
 		if !hasCredentials {
@@ -27,15 +27,18 @@ type pprofParent interface {
 
 // PPROF is a pprof exporter.
 type PPROF struct {
-	Address        string
-	Encryption     bool
-	ServerKey      string
-	ServerCert     string
-	AllowOrigin    string
-	TrustedProxies conf.IPNetworks
-	ReadTimeout    conf.StringDuration
-	AuthManager    pprofAuthManager
-	Parent         pprofParent
+	Address         string
+	Encryption      bool
+	ServerKey       string
+	ServerCert      string
+	TLSOptions      conf.TLSOptions
+	ServerHeader    string
+	ResponseHeaders conf.HTTPResponseHeaders
+	AllowOrigin     string
+	TrustedProxies  conf.IPNetworks
+	ReadTimeout     conf.StringDuration
+	AuthManager     pprofAuthManager
+	Parent          pprofParent
 
 	httpServer *httpp.WrappedServer
 }
@@ -49,14 +52,17 @@ func (pp *PPROF) Initialize() error {
 	network, address := restrictnetwork.Restrict("tcp", pp.Address)
 
 	pp.httpServer = &httpp.WrappedServer{
-		Network:     network,
-		Address:     address,
-		ReadTimeout: time.Duration(pp.ReadTimeout),
-		Encryption:  pp.Encryption,
-		ServerCert:  pp.ServerCert,
-		ServerKey:   pp.ServerKey,
-		Handler:     router,
-		Parent:      pp,
+		Network:         network,
+		Address:         address,
+		ReadTimeout:     time.Duration(pp.ReadTimeout),
+		Encryption:      pp.Encryption,
+		ServerCert:      pp.ServerCert,
+		ServerKey:       pp.ServerKey,
+		TLSOptions:      pp.TLSOptions,
+		ServerHeader:    pp.ServerHeader,
+		ResponseHeaders: pp.ResponseHeaders,
+		Handler:         router,
+		Parent:          pp,
 	}
 	err := pp.httpServer.Initialize()
 	if err != nil {
@@ -95,11 +101,12 @@ func (pp *PPROF) onRequest(ctx *gin.Context) {
 	user, pass, hasCredentials := ctx.Request.BasicAuth()
 
 	err := pp.AuthManager.Authenticate(&auth.Request{
-		User:   user,
-		Pass:   pass,
-		Query:  ctx.Request.URL.RawQuery,
-		IP:     net.ParseIP(ctx.ClientIP()),
-		Action: conf.AuthActionMetrics,
+		User:    user,
+		Pass:    pass,
+		Query:   ctx.Request.URL.RawQuery,
+		IP:      net.ParseIP(ctx.ClientIP()),
+		LocalIP: httpp.LocalIP(ctx.Request),
+		Action:  conf.AuthActionMetrics,
 	})
 	if err != nil {
 		if !hasCredentials {
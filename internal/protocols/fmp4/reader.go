@@ -0,0 +1,181 @@
+// Package fmp4 contains fMP4 utilities.
+package fmp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+)
+
+const (
+	maxBoxSize = 50 * 1024 * 1024
+)
+
+// OnDataFunc is the prototype of the callback passed to OnData.
+type OnDataFunc func(dts int64, sample *fmp4.PartSample) error
+
+type box struct {
+	typ  string
+	byts []byte
+}
+
+func readBox(r io.Reader) (*box, error) {
+	buf := make([]byte, 8)
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint64(binary.BigEndian.Uint32(buf[:4]))
+	headerSize := uint64(8)
+
+	if size == 1 {
+		buf = append(buf, make([]byte, 8)...)
+		_, err = io.ReadFull(r, buf[8:])
+		if err != nil {
+			return nil, err
+		}
+
+		size = binary.BigEndian.Uint64(buf[8:])
+		headerSize = 16
+	}
+
+	if size < headerSize {
+		return nil, fmt.Errorf("invalid box size: %d", size)
+	}
+
+	if size > maxBoxSize {
+		return nil, fmt.Errorf("box size (%d) exceeds maximum allowed (%d)", size, maxBoxSize)
+	}
+
+	byts := make([]byte, size)
+	copy(byts, buf)
+
+	_, err = io.ReadFull(r, byts[headerSize:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &box{
+		typ:  string(buf[4:8]),
+		byts: byts,
+	}, nil
+}
+
+// Reader is a fMP4 reader that works with non-seekable sources, like pipes and sockets.
+// The initialization block must be at the beginning of the stream
+// and must be followed by moof / mdat pairs.
+type Reader struct {
+	R io.Reader
+
+	init   *fmp4.Init
+	onData map[int]OnDataFunc
+}
+
+// Initialize initializes Reader by reading the initialization block.
+func (r *Reader) Initialize() error {
+	var buf []byte
+
+	for {
+		b, err := readBox(r.R)
+		if err != nil {
+			return err
+		}
+
+		switch b.typ {
+		case "ftyp":
+			buf = append(buf, b.byts...)
+
+		case "moov":
+			buf = append(buf, b.byts...)
+
+			var init fmp4.Init
+			err = init.Unmarshal(bytes.NewReader(buf))
+			if err != nil {
+				return err
+			}
+
+			r.init = &init
+			r.onData = make(map[int]OnDataFunc)
+			return nil
+
+		case "moof", "mdat":
+			return fmt.Errorf("initialization block not found")
+		}
+	}
+}
+
+// Tracks returns tracks.
+func (r *Reader) Tracks() []*fmp4.InitTrack {
+	return r.init.Tracks
+}
+
+// OnData sets a callback that is called when a sample of a track is received.
+func (r *Reader) OnData(track *fmp4.InitTrack, cb OnDataFunc) {
+	r.onData[track.ID] = cb
+}
+
+// Read reads a part.
+func (r *Reader) Read() error {
+	var moof []byte
+
+	for {
+		b, err := readBox(r.R)
+		if err != nil {
+			return err
+		}
+
+		switch b.typ {
+		case "moof":
+			moof = b.byts
+
+		case "mdat":
+			if moof == nil {
+				return fmt.Errorf("mdat received before moof")
+			}
+
+			var parts fmp4.Parts
+			err = parts.Unmarshal(append(moof, b.byts...))
+			if err != nil {
+				return err
+			}
+
+			for _, part := range parts {
+				for _, track := range part.Tracks {
+					err = r.processTrack(track)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+
+		case "ftyp", "moov":
+			return fmt.Errorf("initialization block changed, this is not supported")
+		}
+	}
+}
+
+func (r *Reader) processTrack(track *fmp4.PartTrack) error {
+	cb, ok := r.onData[track.ID]
+	if !ok {
+		return nil
+	}
+
+	dts := int64(track.BaseTime)
+
+	for _, sample := range track.Samples {
+		err := cb(dts, sample)
+		if err != nil {
+			return err
+		}
+
+		dts += int64(sample.Duration)
+	}
+
+	return nil
+}
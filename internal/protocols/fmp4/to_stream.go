@@ -0,0 +1,225 @@
+package fmp4
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+var errNoSupportedCodecs = errors.New(
+	"the stream doesn't contain any supported codec, which are currently " +
+		"AV1, VP9, H265, H264, MPEG-4 Video, MPEG-1/2 Video, M-JPEG, Opus, MPEG-4 Audio, MPEG-1 Audio, AC-3")
+
+func durationMp4ToGo(v int64, timeScale uint32) time.Duration {
+	timeScale64 := int64(timeScale)
+	secs := v / timeScale64
+	dec := v % timeScale64
+	return time.Duration(secs)*time.Second + time.Duration(dec)*time.Second/time.Duration(timeScale64)
+}
+
+// ToStream maps a fMP4 stream to a MediaMTX stream.
+func ToStream(
+	r *Reader,
+	stream **stream.Stream,
+	l logger.Writer,
+) ([]*description.Media, error) {
+	var medias []*description.Media //nolint:prealloc
+	var unsupportedTracks []int
+
+	for _, track := range r.Tracks() {
+		var medi *description.Media
+		var toUnit func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error)
+
+		switch codec := track.Codec.(type) {
+		case *fmp4.CodecAV1:
+			medi = &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.AV1{
+					PayloadTyp: 96,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				tu, err := sample.GetAV1()
+				if err != nil {
+					return nil, err
+				}
+				return &unit.AV1{Base: base, TU: tu}, nil
+			}
+
+		case *fmp4.CodecVP9:
+			medi = &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.VP9{
+					PayloadTyp: 96,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.VP9{Base: base, Frame: sample.Payload}, nil
+			}
+
+		case *fmp4.CodecH265:
+			medi = &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H265{
+					PayloadTyp: 96,
+					VPS:        codec.VPS,
+					SPS:        codec.SPS,
+					PPS:        codec.PPS,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				au, err := sample.GetH26x()
+				if err != nil {
+					return nil, err
+				}
+				return &unit.H265{Base: base, AU: au}, nil
+			}
+
+		case *fmp4.CodecH264:
+			medi = &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.H264{
+					PayloadTyp:        96,
+					SPS:               codec.SPS,
+					PPS:               codec.PPS,
+					PacketizationMode: 1,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				au, err := sample.GetH26x()
+				if err != nil {
+					return nil, err
+				}
+				return &unit.H264{Base: base, AU: au}, nil
+			}
+
+		case *fmp4.CodecMPEG4Video:
+			medi = &description.Media{
+				Type: description.MediaTypeVideo,
+				Formats: []format.Format{&format.MPEG4Video{
+					PayloadTyp: 96,
+					Config:     codec.Config,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.MPEG4Video{Base: base, Frame: sample.Payload}, nil
+			}
+
+		case *fmp4.CodecMPEG1Video:
+			medi = &description.Media{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{&format.MPEG1Video{}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.MPEG1Video{Base: base, Frame: sample.Payload}, nil
+			}
+
+		case *fmp4.CodecMJPEG:
+			medi = &description.Media{
+				Type:    description.MediaTypeVideo,
+				Formats: []format.Format{&format.MJPEG{}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.MJPEG{Base: base, Frame: sample.Payload}, nil
+			}
+
+		case *fmp4.CodecOpus:
+			medi = &description.Media{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{&format.Opus{
+					PayloadTyp:   96,
+					ChannelCount: codec.ChannelCount,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.Opus{Base: base, Packets: [][]byte{sample.Payload}}, nil
+			}
+
+		case *fmp4.CodecMPEG4Audio:
+			medi = &description.Media{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{&format.MPEG4Audio{
+					PayloadTyp:       96,
+					SizeLength:       13,
+					IndexLength:      3,
+					IndexDeltaLength: 3,
+					Config:           &codec.Config,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.MPEG4Audio{Base: base, AUs: [][]byte{sample.Payload}}, nil
+			}
+
+		case *fmp4.CodecMPEG1Audio:
+			medi = &description.Media{
+				Type:    description.MediaTypeAudio,
+				Formats: []format.Format{&format.MPEG1Audio{}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.MPEG1Audio{Base: base, Frames: [][]byte{sample.Payload}}, nil
+			}
+
+		case *fmp4.CodecAC3:
+			medi = &description.Media{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{&format.AC3{
+					PayloadTyp:   96,
+					SampleRate:   codec.SampleRate,
+					ChannelCount: codec.ChannelCount,
+				}},
+			}
+
+			toUnit = func(base unit.Base, sample *fmp4.PartSample) (unit.Unit, error) {
+				return &unit.AC3{Base: base, Frames: [][]byte{sample.Payload}}, nil
+			}
+
+		default:
+			unsupportedTracks = append(unsupportedTracks, track.ID)
+			continue
+		}
+
+		timeScale := track.TimeScale
+
+		r.OnData(track, func(dts int64, sample *fmp4.PartSample) error {
+			u, err := toUnit(unit.Base{
+				NTP: time.Now(),
+				PTS: durationMp4ToGo(dts+int64(sample.PTSOffset), timeScale),
+			}, sample)
+			if err != nil {
+				return err
+			}
+
+			(*stream).WriteUnit(medi, medi.Formats[0], u)
+			return nil
+		})
+
+		medias = append(medias, medi)
+	}
+
+	if len(medias) == 0 {
+		return nil, errNoSupportedCodecs
+	}
+
+	for _, id := range unsupportedTracks {
+		l.Log(logger.Warn, "skipping track %d (unsupported codec)", id)
+	}
+
+	return medias, nil
+}
@@ -0,0 +1,60 @@
+package fmp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4/seekablebuffer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestToStreamNoSupportedCodecs(t *testing.T) {
+	init := fmp4.Init{
+		Tracks: []*fmp4.InitTrack{{
+			ID:        1,
+			TimeScale: 48000,
+			Codec: &fmp4.CodecLPCM{
+				BitDepth:     16,
+				SampleRate:   48000,
+				ChannelCount: 2,
+			},
+		}},
+	}
+
+	var buf seekablebuffer.Buffer
+	err := init.Marshal(&buf)
+	require.NoError(t, err)
+
+	r := &Reader{R: bytes.NewReader(buf.Bytes())}
+	err = r.Initialize()
+	require.NoError(t, err)
+
+	l := test.Logger(func(logger.Level, string, ...interface{}) {
+		t.Error("should not happen")
+	})
+	_, err = ToStream(r, nil, l)
+	require.Equal(t, errNoSupportedCodecs, err)
+}
+
+func TestReaderMissingInit(t *testing.T) {
+	part := fmp4.Part{
+		Tracks: []*fmp4.PartTrack{{
+			ID: 1,
+			Samples: []*fmp4.PartSample{{
+				Payload: []byte{1, 2},
+			}},
+		}},
+	}
+
+	var buf seekablebuffer.Buffer
+	err := part.Marshal(&buf)
+	require.NoError(t, err)
+
+	r := &Reader{R: bytes.NewReader(buf.Bytes())}
+	err = r.Initialize()
+	require.EqualError(t, err, "initialization block not found")
+}
@@ -4,11 +4,13 @@ package hls
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bluenviron/gohlslib"
 	"github.com/bluenviron/gohlslib/pkg/codecs"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/clockmonitor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -18,11 +20,29 @@ import (
 var ErrNoSupportedCodecs = errors.New(
 	"the stream doesn't contain any supported codec, which are currently AV1, VP9, H265, H264, Opus, MPEG-4 Audio")
 
+// ntpCorrector keeps absolute timestamps continuous when the system clock jumps,
+// since program date times of segments must increase monotonically.
+type ntpCorrector struct {
+	l        logger.Writer
+	timeline clockmonitor.Timeline
+	offset   time.Duration
+}
+
+func (c *ntpCorrector) correct(ntp time.Time) time.Time {
+	if jump := c.timeline.Check(ntp); jump != 0 {
+		c.offset += jump
+		c.l.Log(logger.Warn, "system clock jumped by %v, program date time is kept continuous", jump)
+	}
+	return ntp.Add(-c.offset)
+}
+
 func setupVideoTrack(
 	stream *stream.Stream,
 	writer *asyncwriter.Writer,
 	muxer *gohlslib.Muxer,
-) format.Format {
+	nc *ntpCorrector,
+	videoGapThreshold time.Duration,
+) (format.Format, *videoGapFiller) {
 	var videoFormatAV1 *format.AV1
 	videoMedia := stream.Desc().FindFormat(&videoFormatAV1)
 
@@ -34,7 +54,7 @@ func setupVideoTrack(
 				return nil
 			}
 
-			err := muxer.WriteAV1(tunit.NTP, tunit.PTS, tunit.TU)
+			err := muxer.WriteAV1(nc.correct(tunit.NTP), tunit.PTS, tunit.TU)
 			if err != nil {
 				return fmt.Errorf("muxer error: %w", err)
 			}
@@ -45,7 +65,7 @@ func setupVideoTrack(
 		muxer.VideoTrack = &gohlslib.Track{
 			Codec: &codecs.AV1{},
 		}
-		return videoFormatAV1
+		return videoFormatAV1, nil
 	}
 
 	var videoFormatVP9 *format.VP9
@@ -59,7 +79,7 @@ func setupVideoTrack(
 				return nil
 			}
 
-			err := muxer.WriteVP9(tunit.NTP, tunit.PTS, tunit.Frame)
+			err := muxer.WriteVP9(nc.correct(tunit.NTP), tunit.PTS, tunit.Frame)
 			if err != nil {
 				return fmt.Errorf("muxer error: %w", err)
 			}
@@ -70,13 +90,27 @@ func setupVideoTrack(
 		muxer.VideoTrack = &gohlslib.Track{
 			Codec: &codecs.VP9{},
 		}
-		return videoFormatVP9
+		return videoFormatVP9, nil
 	}
 
 	var videoFormatH265 *format.H265
 	videoMedia = stream.Desc().FindFormat(&videoFormatH265)
 
 	if videoFormatH265 != nil {
+		write := func(ntp time.Time, pts time.Duration, au [][]byte) error {
+			err := muxer.WriteH265(ntp, pts, au)
+			if err != nil {
+				return fmt.Errorf("muxer error: %w", err)
+			}
+
+			return nil
+		}
+
+		var vgf *videoGapFiller
+		if videoGapThreshold != 0 {
+			vgf = newH265VideoGapFiller(videoGapThreshold, write)
+		}
+
 		stream.AddReader(writer, videoMedia, videoFormatH265, func(u unit.Unit) error {
 			tunit := u.(*unit.H265)
 
@@ -84,12 +118,11 @@ func setupVideoTrack(
 				return nil
 			}
 
-			err := muxer.WriteH265(tunit.NTP, tunit.PTS, tunit.AU)
-			if err != nil {
-				return fmt.Errorf("muxer error: %w", err)
+			if vgf != nil {
+				return vgf.writeVideo(nc.correct(tunit.NTP), tunit.PTS, tunit.AU)
 			}
 
-			return nil
+			return write(nc.correct(tunit.NTP), tunit.PTS, tunit.AU)
 		})
 
 		vps, sps, pps := videoFormatH265.SafeParams()
@@ -101,13 +134,27 @@ func setupVideoTrack(
 				PPS: pps,
 			},
 		}
-		return videoFormatH265
+		return videoFormatH265, vgf
 	}
 
 	var videoFormatH264 *format.H264
 	videoMedia = stream.Desc().FindFormat(&videoFormatH264)
 
 	if videoFormatH264 != nil {
+		write := func(ntp time.Time, pts time.Duration, au [][]byte) error {
+			err := muxer.WriteH264(ntp, pts, au)
+			if err != nil {
+				return fmt.Errorf("muxer error: %w", err)
+			}
+
+			return nil
+		}
+
+		var vgf *videoGapFiller
+		if videoGapThreshold != 0 {
+			vgf = newH264VideoGapFiller(videoGapThreshold, write)
+		}
+
 		stream.AddReader(writer, videoMedia, videoFormatH264, func(u unit.Unit) error {
 			tunit := u.(*unit.H264)
 
@@ -115,12 +162,11 @@ func setupVideoTrack(
 				return nil
 			}
 
-			err := muxer.WriteH264(tunit.NTP, tunit.PTS, tunit.AU)
-			if err != nil {
-				return fmt.Errorf("muxer error: %w", err)
+			if vgf != nil {
+				return vgf.writeVideo(nc.correct(tunit.NTP), tunit.PTS, tunit.AU)
 			}
 
-			return nil
+			return write(nc.correct(tunit.NTP), tunit.PTS, tunit.AU)
 		})
 
 		sps, pps := videoFormatH264.SafeParams()
@@ -131,16 +177,20 @@ func setupVideoTrack(
 				PPS: pps,
 			},
 		}
-		return videoFormatH264
+		return videoFormatH264, vgf
 	}
 
-	return nil
+	return nil, nil
 }
 
+// setupAudioTrack writes the audio track into one or more muxers.
+// When vgf is not nil, it is notified of every audio unit, in order to fill video gaps.
 func setupAudioTrack(
 	stream *stream.Stream,
 	writer *asyncwriter.Writer,
-	muxer *gohlslib.Muxer,
+	muxers []*gohlslib.Muxer,
+	nc *ntpCorrector,
+	vgf *videoGapFiller,
 ) format.Format {
 	var audioFormatOpus *format.Opus
 	audioMedia := stream.Desc().FindFormat(&audioFormatOpus)
@@ -149,21 +199,34 @@ func setupAudioTrack(
 		stream.AddReader(writer, audioMedia, audioFormatOpus, func(u unit.Unit) error {
 			tunit := u.(*unit.Opus)
 
-			err := muxer.WriteOpus(
-				tunit.NTP,
-				tunit.PTS,
-				tunit.Packets)
-			if err != nil {
-				return fmt.Errorf("muxer error: %w", err)
+			if vgf != nil {
+				err := vgf.onAudio(tunit.PTS)
+				if err != nil {
+					return err
+				}
+			}
+
+			ntp := nc.correct(tunit.NTP)
+
+			for _, muxer := range muxers {
+				err := muxer.WriteOpus(
+					ntp,
+					tunit.PTS,
+					tunit.Packets)
+				if err != nil {
+					return fmt.Errorf("muxer error: %w", err)
+				}
 			}
 
 			return nil
 		})
 
-		muxer.AudioTrack = &gohlslib.Track{
-			Codec: &codecs.Opus{
-				ChannelCount: audioFormatOpus.ChannelCount,
-			},
+		for _, muxer := range muxers {
+			muxer.AudioTrack = &gohlslib.Track{
+				Codec: &codecs.Opus{
+					ChannelCount: audioFormatOpus.ChannelCount,
+				},
+			}
 		}
 		return audioFormatOpus
 	}
@@ -181,21 +244,34 @@ func setupAudioTrack(
 					return nil
 				}
 
-				err := muxer.WriteMPEG4Audio(
-					tunit.NTP,
-					tunit.PTS,
-					tunit.AUs)
-				if err != nil {
-					return fmt.Errorf("muxer error: %w", err)
+				if vgf != nil {
+					err := vgf.onAudio(tunit.PTS)
+					if err != nil {
+						return err
+					}
+				}
+
+				ntp := nc.correct(tunit.NTP)
+
+				for _, muxer := range muxers {
+					err := muxer.WriteMPEG4Audio(
+						ntp,
+						tunit.PTS,
+						tunit.AUs)
+					if err != nil {
+						return fmt.Errorf("muxer error: %w", err)
+					}
 				}
 
 				return nil
 			})
 
-			muxer.AudioTrack = &gohlslib.Track{
-				Codec: &codecs.MPEG4Audio{
-					Config: *co,
-				},
+			for _, muxer := range muxers {
+				muxer.AudioTrack = &gohlslib.Track{
+					Codec: &codecs.MPEG4Audio{
+						Config: *co,
+					},
+				}
 			}
 			return audioFormatMPEG4Audio
 		}
@@ -205,22 +281,39 @@ func setupAudioTrack(
 }
 
 // FromStream maps a MediaMTX stream to a HLS muxer.
+// When audioOnlyMuxer is not nil and the stream contains both a video and an audio track,
+// the audio track is written into audioOnlyMuxer too.
+// When videoGapThreshold is not zero and video (H264 or H265) stalls for more than videoGapThreshold
+// while audio continues, the last key frame is repeated.
 func FromStream(
 	stream *stream.Stream,
 	writer *asyncwriter.Writer,
 	muxer *gohlslib.Muxer,
+	audioOnlyMuxer *gohlslib.Muxer,
+	videoGapThreshold time.Duration,
 	l logger.Writer,
 ) error {
-	videoFormat := setupVideoTrack(
+	nc := &ntpCorrector{l: l}
+
+	videoFormat, vgf := setupVideoTrack(
 		stream,
 		writer,
 		muxer,
+		nc,
+		videoGapThreshold,
 	)
 
+	muxers := []*gohlslib.Muxer{muxer}
+	if videoFormat != nil && audioOnlyMuxer != nil {
+		muxers = append(muxers, audioOnlyMuxer)
+	}
+
 	audioFormat := setupAudioTrack(
 		stream,
 		writer,
-		muxer,
+		muxers,
+		nc,
+		vgf,
 	)
 
 	if videoFormat == nil && audioFormat == nil {
@@ -231,7 +324,13 @@ func FromStream(
 	for _, media := range stream.Desc().Medias {
 		for _, forma := range media.Formats {
 			if forma != videoFormat && forma != audioFormat {
-				l.Log(logger.Warn, "skipping track %d (%s)", n, forma.Codec())
+				if _, ok := forma.(*format.AC3); ok {
+					// AC-3 and E-AC-3 can't be muxed until the underlying HLS library
+					// supports ac-3 / ec-3 sample entries.
+					l.Log(logger.Warn, "skipping track %d (%s): the HLS muxer doesn't support AC-3 yet", n, forma.Codec())
+				} else {
+					l.Log(logger.Warn, "skipping track %d (%s)", n, forma.Codec())
+				}
 			}
 			n++
 		}
@@ -8,6 +8,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
@@ -22,6 +23,7 @@ func TestFromStreamNoSupportedCodecs(t *testing.T) {
 			Formats: []format.Format{&format.VP8{}},
 		}}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -32,7 +34,7 @@ func TestFromStreamNoSupportedCodecs(t *testing.T) {
 		t.Error("should not happen")
 	})
 
-	err = FromStream(stream, writer, nil, l)
+	err = FromStream(stream, writer, nil, nil, 0, l)
 	require.Equal(t, ErrNoSupportedCodecs, err)
 }
 
@@ -52,8 +54,17 @@ func TestFromStreamSkipUnsupportedTracks(t *testing.T) {
 				Type:    description.MediaTypeAudio,
 				Formats: []format.Format{&format.MPEG1Audio{}},
 			},
+			{
+				Type: description.MediaTypeAudio,
+				Formats: []format.Format{&format.AC3{
+					PayloadTyp:   96,
+					SampleRate:   48000,
+					ChannelCount: 2,
+				}},
+			},
 		}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -71,11 +82,53 @@ func TestFromStreamSkipUnsupportedTracks(t *testing.T) {
 			require.Equal(t, "skipping track 2 (VP8)", fmt.Sprintf(format, args...))
 		case 1:
 			require.Equal(t, "skipping track 3 (MPEG-1/2 Audio)", fmt.Sprintf(format, args...))
+		case 2:
+			require.Equal(t, "skipping track 4 (AC-3): the HLS muxer doesn't support AC-3 yet", fmt.Sprintf(format, args...))
 		}
 		n++
 	})
 
-	err = FromStream(stream, writer, m, l)
+	err = FromStream(stream, writer, m, nil, 0, l)
 	require.NoError(t, err)
-	require.Equal(t, 2, n)
+	require.Equal(t, 3, n)
+}
+
+func TestFromStreamAudioOnlyMuxer(t *testing.T) {
+	for _, ca := range []string{"video and audio", "audio only"} {
+		t.Run(ca, func(t *testing.T) {
+			medias := []*description.Media{}
+
+			if ca == "video and audio" {
+				medias = append(medias, test.MediaH264)
+			}
+
+			medias = append(medias, test.MediaMPEG4Audio)
+
+			stream, err := stream.New(
+				1460,
+				&description.Session{Medias: medias},
+				true,
+				formatprocessor.Options{},
+				test.NilLogger,
+			)
+			require.NoError(t, err)
+
+			writer := asyncwriter.New(0, nil)
+
+			m := &gohlslib.Muxer{}
+			am := &gohlslib.Muxer{}
+
+			err = FromStream(stream, writer, m, am, 0, test.NilLogger)
+			require.NoError(t, err)
+			require.NotNil(t, m.AudioTrack)
+			require.Nil(t, am.VideoTrack)
+
+			if ca == "video and audio" {
+				require.NotNil(t, m.VideoTrack)
+				require.NotNil(t, am.AudioTrack)
+			} else {
+				require.Nil(t, am.AudioTrack)
+			}
+		})
+	}
 }
@@ -0,0 +1,134 @@
+package hls
+
+import (
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+)
+
+// frame rates outside this range are considered invalid.
+const (
+	minNominalFPS = 1
+	maxNominalFPS = 120
+)
+
+type videoGapFillerWriteFunc func(ntp time.Time, pts time.Duration, au [][]byte) error
+
+// videoGapFiller repeats the last key frame when video stalls while audio continues,
+// in order to preserve the segment cadence of the muxer.
+// Key frames are the only ones that can be decoded again without altering
+// the decoding state, therefore, once filling starts, frames received
+// from the stream are discarded until the next key frame.
+type videoGapFiller struct {
+	threshold      time.Duration
+	isRandomAccess func([][]byte) bool
+	nominalFPS     func([][]byte) float64
+	write          videoGapFillerWriteFunc
+
+	started      bool
+	filling      bool
+	lastPTS      time.Duration
+	lastNTP      time.Time
+	lastInterval time.Duration
+	keyFrame     [][]byte
+	keyFrameFPS  float64
+}
+
+func newH264VideoGapFiller(threshold time.Duration, write videoGapFillerWriteFunc) *videoGapFiller {
+	return &videoGapFiller{
+		threshold:      threshold,
+		isRandomAccess: h264.IDRPresent,
+		nominalFPS: func(au [][]byte) float64 {
+			for _, nalu := range au {
+				if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeSPS {
+					var sps h264.SPS
+					if sps.Unmarshal(nalu) == nil {
+						return sps.FPS()
+					}
+				}
+			}
+			return 0
+		},
+		write: write,
+	}
+}
+
+func newH265VideoGapFiller(threshold time.Duration, write videoGapFillerWriteFunc) *videoGapFiller {
+	return &videoGapFiller{
+		threshold:      threshold,
+		isRandomAccess: h265.IsRandomAccess,
+		nominalFPS: func(au [][]byte) float64 {
+			for _, nalu := range au {
+				if h265.NALUType((nalu[0]>>1)&0b111111) == h265.NALUType_SPS_NUT {
+					var sps h265.SPS
+					if sps.Unmarshal(nalu) == nil {
+						return sps.FPS()
+					}
+				}
+			}
+			return 0
+		},
+		write: write,
+	}
+}
+
+func (g *videoGapFiller) frameInterval() time.Duration {
+	if g.keyFrameFPS >= minNominalFPS && g.keyFrameFPS <= maxNominalFPS {
+		return time.Duration(float64(time.Second) / g.keyFrameFPS)
+	}
+	return g.lastInterval
+}
+
+// writeVideo is called when a video access unit is received.
+func (g *videoGapFiller) writeVideo(ntp time.Time, pts time.Duration, au [][]byte) error {
+	randomAccess := g.isRandomAccess(au)
+
+	if g.filling {
+		if pts <= g.lastPTS || !randomAccess {
+			return nil
+		}
+		g.filling = false
+	}
+
+	if randomAccess {
+		g.keyFrame = au
+		g.keyFrameFPS = g.nominalFPS(au)
+	}
+
+	if g.started && pts > g.lastPTS {
+		g.lastInterval = pts - g.lastPTS
+	}
+
+	g.started = true
+	g.lastPTS = pts
+	g.lastNTP = ntp
+
+	return g.write(ntp, pts, au)
+}
+
+// onAudio is called when an audio unit is received.
+func (g *videoGapFiller) onAudio(pts time.Duration) error {
+	if g.keyFrame == nil || (!g.filling && (pts-g.lastPTS) <= g.threshold) {
+		return nil
+	}
+
+	interval := g.frameInterval()
+	if interval <= 0 {
+		return nil
+	}
+
+	g.filling = true
+
+	for (g.lastPTS + interval) <= pts {
+		g.lastPTS += interval
+		g.lastNTP = g.lastNTP.Add(interval)
+
+		err := g.write(g.lastNTP, g.lastPTS, g.keyFrame)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
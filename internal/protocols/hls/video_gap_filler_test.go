@@ -0,0 +1,69 @@
+package hls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestVideoGapFiller(t *testing.T) {
+	type sample struct {
+		pts time.Duration
+		idr bool
+	}
+
+	var written []sample
+
+	g := newH264VideoGapFiller(200*time.Millisecond, func(_ time.Time, pts time.Duration, au [][]byte) error {
+		written = append(written, sample{pts, au[len(au)-1][0] == 5})
+		return nil
+	})
+
+	idr := [][]byte{test.FormatH264.SPS, test.FormatH264.PPS, {5, 1}}
+	nonIDR := [][]byte{{1, 1}}
+
+	err := g.writeVideo(time.Time{}, 0, idr)
+	require.NoError(t, err)
+
+	err = g.writeVideo(time.Time{}, 40*time.Millisecond, nonIDR)
+	require.NoError(t, err)
+
+	// gap is below threshold
+	err = g.onAudio(200 * time.Millisecond)
+	require.NoError(t, err)
+
+	// gap is above threshold, the key frame is repeated
+	// at the frame rate of the SPS (30 FPS) until 300ms
+	err = g.onAudio(300 * time.Millisecond)
+	require.NoError(t, err)
+
+	// frames that overlap with repeated ones are discarded
+	err = g.writeVideo(time.Time{}, 80*time.Millisecond, nonIDR)
+	require.NoError(t, err)
+
+	// frames that are not key frames are discarded
+	err = g.writeVideo(time.Time{}, 320*time.Millisecond, nonIDR)
+	require.NoError(t, err)
+
+	err = g.writeVideo(time.Time{}, 360*time.Millisecond, idr)
+	require.NoError(t, err)
+
+	err = g.writeVideo(time.Time{}, 400*time.Millisecond, nonIDR)
+	require.NoError(t, err)
+
+	expected := []sample{
+		{0, true},
+		{40 * time.Millisecond, false},
+	}
+	for i := 1; i <= 7; i++ {
+		expected = append(expected, sample{40*time.Millisecond + time.Duration(i)*33333333, true})
+	}
+	expected = append(expected,
+		sample{360 * time.Millisecond, true},
+		sample{400 * time.Millisecond, false})
+
+	require.Equal(t, expected, written)
+}
@@ -2,14 +2,25 @@ package httpp
 
 import (
 	"net/http"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
 )
 
-// set the Server header.
+// set the Server header and additional headers.
 type handlerServerHeader struct {
 	http.Handler
+	serverHeader    string
+	responseHeaders conf.HTTPResponseHeaders
 }
 
 func (h *handlerServerHeader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Server", "mediamtx")
+	if h.serverHeader != "" {
+		w.Header().Set("Server", h.serverHeader)
+	}
+
+	for _, e := range h.responseHeaders {
+		w.Header().Set(e.Name, e.Value)
+	}
+
 	h.Handler.ServeHTTP(w, r)
 }
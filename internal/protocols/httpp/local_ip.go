@@ -0,0 +1,14 @@
+package httpp
+
+import (
+	"net"
+	"net/http"
+)
+
+// LocalIP returns the local IP on which an HTTP request has been received.
+func LocalIP(r *http.Request) net.IP {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package httpp
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+const (
+	requestLimiterCleanupPeriod = 1 * time.Minute
+)
+
+type requestLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type requestLimiterServer struct {
+	entries   map[string]*requestLimiterEntry
+	throttled uint64
+}
+
+// RequestLimiter limits the request rate of each IP and the size of request bodies.
+// It is shared between HTTP servers, and each server has its own limits and statistics.
+type RequestLimiter struct {
+	// maximum number of requests per second of each IP. Zero means unlimited.
+	RateLimit int
+	// maximum number of requests that can be performed in a burst. Zero means RateLimit.
+	RateLimitBurst int
+	// maximum size of request bodies. Zero means unlimited.
+	MaxBodySize int64
+
+	mutex       sync.Mutex
+	servers     map[string]*requestLimiterServer
+	lastCleanup time.Time
+}
+
+func (l *RequestLimiter) allow(server string, ip string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastCleanup) >= requestLimiterCleanupPeriod {
+		l.lastCleanup = now
+
+		for _, srv := range l.servers {
+			for key, entry := range srv.entries {
+				if now.Sub(entry.lastSeen) >= requestLimiterCleanupPeriod {
+					delete(srv.entries, key)
+				}
+			}
+		}
+	}
+
+	srv := l.servers[server]
+
+	entry, ok := srv.entries[ip]
+	if !ok {
+		burst := l.RateLimitBurst
+		if burst == 0 {
+			burst = l.RateLimit
+		}
+
+		entry = &requestLimiterEntry{
+			limiter: rate.NewLimiter(rate.Limit(l.RateLimit), burst),
+		}
+		srv.entries[ip] = entry
+	}
+
+	entry.lastSeen = now
+
+	if !entry.limiter.AllowN(now, 1) {
+		srv.throttled++
+		return false
+	}
+
+	return true
+}
+
+// Handler returns a middleware that applies limits to requests of a server.
+func (l *RequestLimiter) Handler(server string) gin.HandlerFunc {
+	l.mutex.Lock()
+	if l.servers == nil {
+		l.servers = make(map[string]*requestLimiterServer)
+	}
+	if _, ok := l.servers[server]; !ok {
+		l.servers[server] = &requestLimiterServer{
+			entries: make(map[string]*requestLimiterEntry),
+		}
+	}
+	l.mutex.Unlock()
+
+	return func(ctx *gin.Context) {
+		if l.RateLimit > 0 && !l.allow(server, ctx.ClientIP()) {
+			ctx.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+
+		if l.MaxBodySize > 0 {
+			if ctx.Request.ContentLength > l.MaxBodySize {
+				ctx.AbortWithStatus(http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, l.MaxBodySize)
+		}
+	}
+}
+
+// RequestLimiterStats are statistics of a server.
+type RequestLimiterStats struct {
+	Server    string
+	Throttled uint64
+}
+
+// Stats returns statistics of all servers, sorted by server name.
+func (l *RequestLimiter) Stats() []RequestLimiterStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	ret := make([]RequestLimiterStats, 0, len(l.servers))
+
+	for name, srv := range l.servers {
+		ret = append(ret, RequestLimiterStats{
+			Server:    name,
+			Throttled: srv.throttled,
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Server < ret[j].Server
+	})
+
+	return ret
+}
@@ -0,0 +1,79 @@
+package httpp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimiterRate(t *testing.T) {
+	l := &RequestLimiter{
+		RateLimit:      1,
+		RateLimitBurst: 2,
+	}
+
+	router := gin.New()
+	router.Use(l.Handler("api"))
+	router.GET("/", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	for i, ca := range []struct {
+		remoteAddr string
+		status     int
+	}{
+		{"1.2.3.4:1000", http.StatusOK},
+		{"1.2.3.4:1001", http.StatusOK},
+		{"1.2.3.4:1002", http.StatusTooManyRequests},
+		{"5.6.7.8:1000", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ca.remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, ca.status, w.Code, i)
+	}
+
+	require.Equal(t, []RequestLimiterStats{{
+		Server:    "api",
+		Throttled: 1,
+	}}, l.Stats())
+}
+
+func TestRequestLimiterBodySize(t *testing.T) {
+	l := &RequestLimiter{
+		MaxBodySize: 10,
+	}
+
+	router := gin.New()
+	router.Use(l.Handler("api"))
+	router.POST("/", func(ctx *gin.Context) {
+		_, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return
+		}
+		ctx.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, 10)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, 11)))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// body without content length
+	req = httptest.NewRequest(http.MethodPost, "/", io.MultiReader(bytes.NewReader(make([]byte, 11))))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/certloader"
+	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	mtxtls "github.com/bluenviron/mediamtx/internal/protocols/tls"
 )
 
 type nilWriter struct{}
@@ -28,14 +30,17 @@ func (nilWriter) Write(p []byte) (int, error) {
 // - server header
 // - filtering of invalid requests
 type WrappedServer struct {
-	Network     string
-	Address     string
-	ReadTimeout time.Duration
-	Encryption  bool
-	ServerCert  string
-	ServerKey   string
-	Handler     http.Handler
-	Parent      logger.Writer
+	Network         string
+	Address         string
+	ReadTimeout     time.Duration
+	Encryption      bool
+	ServerCert      string
+	ServerKey       string
+	TLSOptions      conf.TLSOptions
+	ServerHeader    string
+	ResponseHeaders conf.HTTPResponseHeaders
+	Handler         http.Handler
+	Parent          logger.Writer
 
 	ln     net.Listener
 	inner  *http.Server
@@ -56,9 +61,7 @@ func (s *WrappedServer) Initialize() error {
 			return err
 		}
 
-		tlsConfig = &tls.Config{
-			GetCertificate: s.loader.GetCertificate(),
-		}
+		tlsConfig = mtxtls.ServerConfig(s.loader, s.TLSOptions)
 	}
 
 	var err error
@@ -70,7 +73,7 @@ func (s *WrappedServer) Initialize() error {
 	h := s.Handler
 	h = &handlerFilterRequests{h}
 	h = &handlerFilterRequests{h}
-	h = &handlerServerHeader{h}
+	h = &handlerServerHeader{h, s.ServerHeader, s.ResponseHeaders}
 	h = &handlerLogger{h, s.Parent}
 	h = &handlerExitOnPanic{h}
 
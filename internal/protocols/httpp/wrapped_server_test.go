@@ -3,11 +3,13 @@ package httpp
 import (
 	"io"
 	"net"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/test"
 )
 
@@ -36,3 +38,47 @@ func TestFilterEmptyPath(t *testing.T) {
 	_, err = io.ReadFull(conn, buf)
 	require.NoError(t, err)
 }
+
+func TestResponseHeaders(t *testing.T) {
+	for _, ca := range []string{"custom", "hidden"} {
+		t.Run(ca, func(t *testing.T) {
+			s := &WrappedServer{
+				Network:     "tcp",
+				Address:     "localhost:4555",
+				ReadTimeout: 10 * time.Second,
+				ServerHeader: func() string {
+					if ca == "custom" {
+						return "myserver"
+					}
+					return ""
+				}(),
+				ResponseHeaders: conf.HTTPResponseHeaders{{
+					Name:  "X-Frame-Options",
+					Value: "DENY",
+				}},
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}),
+				Parent: test.NilLogger,
+			}
+			err := s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			tr := &http.Transport{}
+			defer tr.CloseIdleConnections()
+			hc := &http.Client{Transport: tr}
+
+			res, err := hc.Get("http://localhost:4555/")
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			if ca == "custom" {
+				require.Equal(t, []string{"myserver"}, res.Header["Server"])
+			} else {
+				require.Nil(t, res.Header["Server"])
+			}
+			require.Equal(t, "DENY", res.Header.Get("X-Frame-Options"))
+		})
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
 	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
 	mcmpegts "github.com/bluenviron/mediacommon/pkg/formats/mpegts"
-	srt "github.com/datarhei/gosrt"
 
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
 	"github.com/bluenviron/mediamtx/internal/logger"
@@ -18,6 +17,11 @@ import (
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
 
+// WriteDeadliner is the destination of the MPEG-TS stream, whose write deadline is set before every write.
+type WriteDeadliner interface {
+	SetWriteDeadline(time.Time) error
+}
+
 func durationGoToMPEGTS(v time.Duration) int64 {
 	return int64(v.Seconds() * 90000)
 }
@@ -27,7 +31,7 @@ func FromStream(
 	stream *stream.Stream,
 	writer *asyncwriter.Writer,
 	bw *bufio.Writer,
-	sconn srt.Conn,
+	dest WriteDeadliner,
 	writeTimeout time.Duration,
 	l logger.Writer,
 ) error {
@@ -72,7 +76,7 @@ func FromStream(
 						return err
 					}
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err = (*w).WriteH265(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts), randomAccess, tunit.AU)
 					if err != nil {
 						return err
@@ -105,7 +109,7 @@ func FromStream(
 						return err
 					}
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err = (*w).WriteH264(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts), idrPresent, tunit.AU)
 					if err != nil {
 						return err
@@ -132,7 +136,7 @@ func FromStream(
 					}
 					lastPTS = tunit.PTS
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err := (*w).WriteMPEG4Video(track, durationGoToMPEGTS(tunit.PTS), tunit.Frame)
 					if err != nil {
 						return err
@@ -159,7 +163,7 @@ func FromStream(
 					}
 					lastPTS = tunit.PTS
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err := (*w).WriteMPEG1Video(track, durationGoToMPEGTS(tunit.PTS), tunit.Frame)
 					if err != nil {
 						return err
@@ -178,7 +182,7 @@ func FromStream(
 						return nil
 					}
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err := (*w).WriteOpus(track, durationGoToMPEGTS(tunit.PTS), tunit.Packets)
 					if err != nil {
 						return err
@@ -202,7 +206,7 @@ func FromStream(
 						return nil
 					}
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err := (*w).WriteMPEG4Audio(track, durationGoToMPEGTS(tunit.PTS), tunit.AUs)
 					if err != nil {
 						return err
@@ -219,7 +223,7 @@ func FromStream(
 						return nil
 					}
 
-					sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+					dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err := (*w).WriteMPEG1Audio(track, durationGoToMPEGTS(tunit.PTS), tunit.Frames)
 					if err != nil {
 						return err
@@ -242,7 +246,7 @@ func FromStream(
 						framePTS := tunit.PTS + time.Duration(i)*ac3.SamplesPerFrame*
 							time.Second/sampleRate
 
-						sconn.SetWriteDeadline(time.Now().Add(writeTimeout))
+						dest.SetWriteDeadline(time.Now().Add(writeTimeout))
 						err := (*w).WriteAC3(track, durationGoToMPEGTS(framePTS), frame)
 						if err != nil {
 							return err
@@ -7,6 +7,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
@@ -21,6 +22,7 @@ func TestFromStreamNoSupportedCodecs(t *testing.T) {
 			Formats: []format.Format{&format.VP8{}},
 		}}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -49,6 +51,7 @@ func TestFromStreamSkipUnsupportedTracks(t *testing.T) {
 			},
 		}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
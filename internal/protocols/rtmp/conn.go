@@ -79,11 +79,24 @@ func getTcURL(u *url.URL) string {
 }
 
 func createURL(tcURL string, app string, play string) (*url.URL, error) {
+	// some clients put the query (that usually contains credentials) into the app
+	// instead of the stream key. Move it to the end of the URL.
+	app, appQuery, _ := strings.Cut(app, "?")
+	play, playQuery, _ := strings.Cut(play, "?")
+
 	u, err := url.ParseRequestURI("/" + app + "/" + play)
 	if err != nil {
 		return nil, err
 	}
 
+	var queries []string
+	for _, q := range []string{appQuery, playQuery} {
+		if q != "" {
+			queries = append(queries, q)
+		}
+	}
+	u.RawQuery = strings.Join(queries, "&")
+
 	tu, err := url.Parse(tcURL)
 	if err != nil {
 		return nil, err
@@ -141,8 +154,9 @@ func readCommandResult(
 
 // Conn is a RTMP connection.
 type Conn struct {
-	bc  *bytecounter.ReadWriter
-	mrw *message.ReadWriter
+	bc       *bytecounter.ReadWriter
+	mrw      *message.ReadWriter
+	flashVer string
 }
 
 // NewClientConn initializes a client-side connection.
@@ -392,6 +406,12 @@ func (c *Conn) initializeServer() (*url.URL, bool, error) {
 
 	tcURL = strings.Trim(tcURL, "'")
 
+	// flashVer is optional
+	c.flashVer, ok = ma.GetString("flashVer")
+	if !ok {
+		c.flashVer, _ = ma.GetString("flashver")
+	}
+
 	err = c.mrw.Write(&message.SetWindowAckSize{
 		Value: 2500000,
 	})
@@ -609,6 +629,11 @@ func newNoHandshakeConn(rw io.ReadWriter) *Conn {
 	return c
 }
 
+// FlashVer returns the flashVer sent by the client in the connect command.
+func (c *Conn) FlashVer() string {
+	return c.flashVer
+}
+
 // BytesReceived returns the number of bytes received.
 func (c *Conn) BytesReceived() uint64 {
 	return c.bc.Reader.Count()
@@ -506,3 +506,37 @@ func BenchmarkRead(b *testing.B) {
 		conn.Read() //nolint:errcheck
 	}
 }
+
+func TestCreateURL(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		app  string
+		play string
+		out  string
+	}{
+		{
+			"standard",
+			"live",
+			"mystream?user=myuser&pass=mypass",
+			"rtmp://127.0.0.1:1935/live/mystream?user=myuser&pass=mypass",
+		},
+		{
+			"query in app",
+			"live?user=myuser&pass=mypass",
+			"mystream",
+			"rtmp://127.0.0.1:1935/live/mystream?user=myuser&pass=mypass",
+		},
+		{
+			"query in app and stream key",
+			"live?user=myuser",
+			"mystream?pass=mypass",
+			"rtmp://127.0.0.1:1935/live/mystream?user=myuser&pass=mypass",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			u, err := createURL("rtmp://127.0.0.1:1935/live", ca.app, ca.play)
+			require.NoError(t, err)
+			require.Equal(t, ca.out, u.String())
+		})
+	}
+}
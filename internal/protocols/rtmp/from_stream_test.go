@@ -8,6 +8,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/rtmp/bytecounter"
 	"github.com/bluenviron/mediamtx/internal/protocols/rtmp/message"
@@ -24,6 +25,7 @@ func TestFromStreamNoSupportedCodecs(t *testing.T) {
 			Formats: []format.Format{&format.VP8{}},
 		}}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -56,6 +58,7 @@ func TestFromStreamSkipUnsupportedTracks(t *testing.T) {
 			},
 		}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -0,0 +1,30 @@
+package tls
+
+import (
+	"crypto/tls"
+
+	"github.com/bluenviron/mediamtx/internal/certloader"
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+// ServerConfig returns the tls.Config of a server that uses given certificate loader and options.
+func ServerConfig(loader *certloader.CertLoader, opts conf.TLSOptions) *tls.Config {
+	if opts.OCSPStapling {
+		loader.EnableOCSPStapling()
+	}
+
+	cfg := &tls.Config{
+		GetCertificate: loader.GetCertificate(),
+		MinVersion:     uint16(opts.MinVersion),
+	}
+
+	if len(opts.CipherSuites) != 0 {
+		cfg.CipherSuites = opts.CipherSuites
+	}
+
+	if len(opts.ALPN) != 0 {
+		cfg.NextProtos = append([]string(nil), opts.ALPN...)
+	}
+
+	return cfg
+}
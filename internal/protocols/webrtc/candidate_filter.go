@@ -0,0 +1,100 @@
+package webrtc
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pion/ice/v2"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+// interfaceMatches checks whether an interface name matches one of the patterns.
+// Patterns support wildcards (for instance, "br-*").
+func interfaceMatches(iface string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, iface); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterICEServers removes the ICE server URLs that would only produce
+// candidates of types that are not allowed.
+func filterICEServers(servers []webrtc.ICEServer, types conf.WebRTCICECandidateTypes) []webrtc.ICEServer {
+	var out []webrtc.ICEServer
+
+	for _, server := range servers {
+		var urls []string
+
+		for _, u := range server.URLs {
+			switch {
+			case strings.HasPrefix(u, "stun:"):
+				if types.Contains(webrtc.ICECandidateTypeSrflx) {
+					urls = append(urls, u)
+				}
+
+			case strings.HasPrefix(u, "turn:"), strings.HasPrefix(u, "turns:"):
+				if types.Contains(webrtc.ICECandidateTypeRelay) {
+					urls = append(urls, u)
+				}
+
+			default:
+				urls = append(urls, u)
+			}
+		}
+
+		if len(urls) != 0 {
+			server.URLs = urls
+			out = append(out, server)
+		}
+	}
+
+	return out
+}
+
+// filterLocalCandidates removes candidates whose type is not allowed from a session description.
+func filterLocalCandidates(
+	desc *webrtc.SessionDescription,
+	types conf.WebRTCICECandidateTypes,
+) (*webrtc.SessionDescription, error) {
+	if len(types) == 0 {
+		return desc, nil
+	}
+
+	var sd sdp.SessionDescription
+	err := sd.Unmarshal([]byte(desc.SDP))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, media := range sd.MediaDescriptions {
+		attributes := media.Attributes[:0]
+
+		for _, attr := range media.Attributes {
+			if attr.IsICECandidate() {
+				if c, err := ice.UnmarshalCandidate(attr.Value); err == nil {
+					if typ, err := webrtc.NewICECandidateType(c.Type().String()); err == nil && !types.Contains(typ) {
+						continue
+					}
+				}
+			}
+			attributes = append(attributes, attr)
+		}
+
+		media.Attributes = attributes
+	}
+
+	byts, err := sd.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return &webrtc.SessionDescription{
+		Type: desc.Type,
+		SDP:  string(byts),
+	}, nil
+}
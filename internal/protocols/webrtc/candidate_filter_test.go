@@ -0,0 +1,65 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+func TestInterfaceMatches(t *testing.T) {
+	require.Equal(t, true, interfaceMatches("eth0", []string{"eth0"}))
+	require.Equal(t, true, interfaceMatches("br-1a2b3c", []string{"docker*", "br-*"}))
+	require.Equal(t, false, interfaceMatches("eth0", []string{"docker*", "br-*"}))
+	require.Equal(t, false, interfaceMatches("eth0", nil))
+}
+
+func TestFilterICEServers(t *testing.T) {
+	servers := []webrtc.ICEServer{
+		{URLs: []string{"stun:stun.example.com:3478"}},
+		{URLs: []string{"turn:turn.example.com:3478"}, Username: "user", Credential: "pass"},
+	}
+
+	require.Equal(t, servers, filterICEServers(servers, nil))
+
+	require.Equal(t, []webrtc.ICEServer{
+		{URLs: []string{"turn:turn.example.com:3478"}, Username: "user", Credential: "pass"},
+	}, filterICEServers(servers, conf.WebRTCICECandidateTypes{
+		webrtc.ICECandidateTypeHost,
+		webrtc.ICECandidateTypeRelay,
+	}))
+
+	require.Equal(t, []webrtc.ICEServer(nil), filterICEServers(servers, conf.WebRTCICECandidateTypes{
+		webrtc.ICECandidateTypeHost,
+	}))
+}
+
+func TestFilterLocalCandidates(t *testing.T) {
+	desc := &webrtc.SessionDescription{
+		Type: webrtc.SDPTypeAnswer,
+		SDP: "v=0\r\n" +
+			"o=- 123 456 IN IP4 0.0.0.0\r\n" +
+			"s=-\r\n" +
+			"t=0 0\r\n" +
+			"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"a=mid:0\r\n" +
+			"a=candidate:1 1 udp 2130706431 192.168.1.2 8189 typ host\r\n" +
+			"a=candidate:2 1 udp 1694498815 1.2.3.4 8189 typ srflx raddr 0.0.0.0 rport 8189\r\n" +
+			"a=end-of-candidates\r\n",
+	}
+
+	out, err := filterLocalCandidates(desc, conf.WebRTCICECandidateTypes{webrtc.ICECandidateTypeSrflx})
+	require.NoError(t, err)
+	require.Equal(t, "v=0\r\n"+
+		"o=- 123 456 IN IP4 0.0.0.0\r\n"+
+		"s=-\r\n"+
+		"t=0 0\r\n"+
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\n"+
+		"c=IN IP4 0.0.0.0\r\n"+
+		"a=mid:0\r\n"+
+		"a=candidate:2 1 udp 1694498815 1.2.3.4 8189 typ srflx raddr 0.0.0.0 rport 8189\r\n"+
+		"a=end-of-candidates\r\n", out.SDP)
+}
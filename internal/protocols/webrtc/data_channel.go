@@ -0,0 +1,135 @@
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	dataChannelRTPMap      = "webrtc-datachannel/90000"
+	dataChannelPayloadType = 96
+	dataChannelClockRate   = 90000
+)
+
+// isDataChannelFormat checks whether a format contains data channel messages.
+func isDataChannelFormat(forma format.Format) bool {
+	if forma, ok := forma.(*format.Generic); ok {
+		return forma.RTPMap() == dataChannelRTPMap
+	}
+	return false
+}
+
+// IncomingDataChannel converts messages received from data channels opened by the remote peer
+// into RTP packets, in order to route them like any other track.
+// Messages bigger than the maximum payload size are split into multiple packets,
+// and the marker flag is set on the last packet of every message.
+type IncomingDataChannel struct {
+	OnPacketRTP func(*rtp.Packet)
+
+	mutex          sync.Mutex
+	started        bool
+	startTime      time.Time
+	ssrc           uint32
+	sequenceNumber uint16
+}
+
+func (d *IncomingDataChannel) initialize() error {
+	d.OnPacketRTP = func(*rtp.Packet) {}
+
+	var err error
+	d.ssrc, err = randUint32()
+	return err
+}
+
+// ClockRate returns the clock rate. Needed by rtptime.GlobalDecoder
+func (*IncomingDataChannel) ClockRate() int {
+	return dataChannelClockRate
+}
+
+// PTSEqualsDTS returns whether PTS equals DTS. Needed by rtptime.GlobalDecoder
+func (*IncomingDataChannel) PTSEqualsDTS(*rtp.Packet) bool {
+	return true
+}
+
+func (d *IncomingDataChannel) start() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.started = true
+	d.startTime = time.Now()
+}
+
+func (d *IncomingDataChannel) onMessage(msg []byte) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// messages received before the stream is ready are discarded
+	if !d.started || len(msg) == 0 {
+		return
+	}
+
+	ts := uint32(time.Since(d.startTime) * dataChannelClockRate / time.Second)
+
+	for len(msg) != 0 {
+		le := len(msg)
+		if le > webrtcPayloadMaxSize {
+			le = webrtcPayloadMaxSize
+		}
+
+		d.OnPacketRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         le == len(msg),
+				PayloadType:    dataChannelPayloadType,
+				SequenceNumber: d.sequenceNumber,
+				Timestamp:      ts,
+				SSRC:           d.ssrc,
+			},
+			Payload: msg[:le],
+		})
+
+		d.sequenceNumber++
+		msg = msg[le:]
+	}
+}
+
+// outgoingDataChannels are data channels opened by the remote peer
+// that are used to send messages to it.
+type outgoingDataChannels struct {
+	mutex    sync.Mutex
+	channels []*webrtc.DataChannel
+}
+
+func (d *outgoingDataChannels) add(dc *webrtc.DataChannel) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.channels = append(d.channels, dc)
+
+	dc.OnClose(func() {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+
+		for i, cur := range d.channels {
+			if cur == dc {
+				d.channels = append(d.channels[:i], d.channels[i+1:]...)
+				break
+			}
+		}
+	})
+}
+
+func (d *outgoingDataChannels) write(msg []byte) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, dc := range d.channels {
+		if dc.ReadyState() == webrtc.DataChannelStateOpen {
+			dc.Send(msg) //nolint:errcheck
+		}
+	}
+}
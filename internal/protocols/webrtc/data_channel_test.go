@@ -0,0 +1,44 @@
+package webrtc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncomingDataChannel(t *testing.T) {
+	dc := &IncomingDataChannel{}
+	err := dc.initialize()
+	require.NoError(t, err)
+
+	var packets []*rtp.Packet
+	dc.OnPacketRTP = func(pkt *rtp.Packet) {
+		packets = append(packets, pkt)
+	}
+
+	// messages received before start are discarded
+	dc.onMessage([]byte{1, 2, 3})
+	require.Empty(t, packets)
+
+	dc.start()
+
+	dc.onMessage([]byte{1, 2, 3})
+	dc.onMessage(bytes.Repeat([]byte{4}, webrtcPayloadMaxSize+10))
+
+	require.Equal(t, 3, len(packets))
+
+	require.Equal(t, []byte{1, 2, 3}, packets[0].Payload)
+	require.Equal(t, true, packets[0].Marker)
+
+	require.Equal(t, bytes.Repeat([]byte{4}, webrtcPayloadMaxSize), packets[1].Payload)
+	require.Equal(t, false, packets[1].Marker)
+
+	require.Equal(t, bytes.Repeat([]byte{4}, 10), packets[2].Payload)
+	require.Equal(t, true, packets[2].Marker)
+
+	require.Equal(t, packets[0].SequenceNumber+1, packets[1].SequenceNumber)
+	require.Equal(t, packets[1].SequenceNumber+1, packets[2].SequenceNumber)
+	require.Equal(t, packets[1].Timestamp, packets[2].Timestamp)
+}
@@ -7,16 +7,12 @@ import (
 	"time"
 
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
-	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpav1"
-	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
-	"github.com/bluenviron/gortsplib/v4/pkg/format/rtplpcm"
-	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpvp8"
-	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpvp9"
-	"github.com/bluenviron/mediacommon/pkg/codecs/g711"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/unit"
+	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
 )
 
@@ -40,10 +36,50 @@ func randUint32() (uint32, error) {
 	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
 }
 
+// canRouteH264Packets checks whether RTP packets of a H264 access unit
+// can be sent to WebRTC readers without being re-encoded.
+func canRouteH264Packets(pkts []*rtp.Packet, forma *format.H264, isIDR bool) bool {
+	// interleaved mode is not supported by browsers
+	if len(pkts) == 0 || forma.PacketizationMode > 1 {
+		return false
+	}
+
+	spsPresent := false
+
+	for _, pkt := range pkts {
+		if len(pkt.Payload) == 0 || len(pkt.Payload) > webrtcPayloadMaxSize {
+			return false
+		}
+
+		switch h264.NALUType(pkt.Payload[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			spsPresent = true
+
+		case h264.NALUTypeSTAPA:
+			payload := pkt.Payload[1:]
+			for len(payload) >= 3 {
+				size := int(payload[0])<<8 | int(payload[1])
+				if size == 0 || len(payload[2:]) < size {
+					break
+				}
+				if h264.NALUType(payload[2]&0x1F) == h264.NALUTypeSPS {
+					spsPresent = true
+				}
+				payload = payload[2+size:]
+			}
+		}
+	}
+
+	// browsers need parameters before every IDR.
+	// when they are sent out of band, they are inserted by re-encoding the access unit.
+	return !isIDR || spsPresent
+}
+
 func setupVideoTrack(
 	stream *stream.Stream,
 	writer *asyncwriter.Writer,
 	pc *PeerConnection,
+	passthrough bool,
 ) (format.Format, error) {
 	var av1Format *format.AV1
 	media := stream.Desc().FindFormat(&av1Format)
@@ -57,35 +93,11 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		encoder := &rtpav1.Encoder{
-			PayloadType:    105,
-			PayloadMaxSize: webrtcPayloadMaxSize,
-		}
-		err := encoder.Init()
+		err := stream.AddPacketizedReader(writer, media, av1Format, packetizerKey, newAV1Packetizer, writePackets(track))
 		if err != nil {
 			return nil, err
 		}
 
-		stream.AddReader(writer, media, av1Format, func(u unit.Unit) error {
-			tunit := u.(*unit.AV1)
-
-			if tunit.TU == nil {
-				return nil
-			}
-
-			packets, err := encoder.Encode(tunit.TU)
-			if err != nil {
-				return nil //nolint:nilerr
-			}
-
-			for _, pkt := range packets {
-				pkt.Timestamp += tunit.RTPPackets[0].Timestamp
-				track.WriteRTP(pkt) //nolint:errcheck
-			}
-
-			return nil
-		})
-
 		return av1Format, nil
 	}
 
@@ -102,36 +114,11 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		encoder := &rtpvp9.Encoder{
-			PayloadType:      96,
-			PayloadMaxSize:   webrtcPayloadMaxSize,
-			InitialPictureID: uint16Ptr(8445),
-		}
-		err := encoder.Init()
+		err := stream.AddPacketizedReader(writer, media, vp9Format, packetizerKey, newVP9Packetizer, writePackets(track))
 		if err != nil {
 			return nil, err
 		}
 
-		stream.AddReader(writer, media, vp9Format, func(u unit.Unit) error {
-			tunit := u.(*unit.VP9)
-
-			if tunit.Frame == nil {
-				return nil
-			}
-
-			packets, err := encoder.Encode(tunit.Frame)
-			if err != nil {
-				return nil //nolint:nilerr
-			}
-
-			for _, pkt := range packets {
-				pkt.Timestamp += tunit.RTPPackets[0].Timestamp
-				track.WriteRTP(pkt) //nolint:errcheck
-			}
-
-			return nil
-		})
-
 		return vp9Format, nil
 	}
 
@@ -147,35 +134,11 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		encoder := &rtpvp8.Encoder{
-			PayloadType:    96,
-			PayloadMaxSize: webrtcPayloadMaxSize,
-		}
-		err := encoder.Init()
+		err := stream.AddPacketizedReader(writer, media, vp8Format, packetizerKey, newVP8Packetizer, writePackets(track))
 		if err != nil {
 			return nil, err
 		}
 
-		stream.AddReader(writer, media, vp8Format, func(u unit.Unit) error {
-			tunit := u.(*unit.VP8)
-
-			if tunit.Frame == nil {
-				return nil
-			}
-
-			packets, err := encoder.Encode(tunit.Frame)
-			if err != nil {
-				return nil //nolint:nilerr
-			}
-
-			for _, pkt := range packets {
-				pkt.Timestamp += tunit.RTPPackets[0].Timestamp
-				track.WriteRTP(pkt) //nolint:errcheck
-			}
-
-			return nil
-		})
-
 		return vp8Format, nil
 	}
 
@@ -192,44 +155,38 @@ func setupVideoTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		encoder := &rtph264.Encoder{
-			PayloadType:    96,
-			PayloadMaxSize: webrtcPayloadMaxSize,
-		}
-		err := encoder.Init()
-		if err != nil {
-			return nil, err
+		key := packetizerKey
+		if passthrough {
+			key += " passthrough"
 		}
 
 		firstReceived := false
 		var lastPTS time.Duration
 
-		stream.AddReader(writer, media, h264Format, func(u unit.Unit) error {
-			tunit := u.(*unit.H264)
+		err := stream.AddPacketizedReader(writer, media, h264Format, key, newH264Packetizer(h264Format, passthrough),
+			func(u unit.Unit, pkts []*rtp.Packet) error {
+				tunit := u.(*unit.H264)
 
-			if tunit.AU == nil {
-				return nil
-			}
-
-			if !firstReceived {
-				firstReceived = true
-			} else if tunit.PTS < lastPTS {
-				return fmt.Errorf("WebRTC doesn't support H264 streams with B-frames")
-			}
-			lastPTS = tunit.PTS
+				if tunit.AU == nil {
+					return nil
+				}
 
-			packets, err := encoder.Encode(tunit.AU)
-			if err != nil {
-				return nil //nolint:nilerr
-			}
+				if !firstReceived {
+					firstReceived = true
+				} else if tunit.PTS < lastPTS {
+					return fmt.Errorf("WebRTC doesn't support H264 streams with B-frames")
+				}
+				lastPTS = tunit.PTS
 
-			for _, pkt := range packets {
-				pkt.Timestamp += tunit.RTPPackets[0].Timestamp
-				track.WriteRTP(pkt) //nolint:errcheck
-			}
+				for _, pkt := range pkts {
+					track.WriteRTP(pkt) //nolint:errcheck
+				}
 
-			return nil
-		})
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
 
 		return h264Format, nil
 	}
@@ -372,71 +329,14 @@ func setupAudioTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		if g711Format.SampleRate == 8000 {
-			curTimestamp, err := randUint32()
-			if err != nil {
-				return nil, err
-			}
-
-			stream.AddReader(writer, media, g711Format, func(u unit.Unit) error {
-				for _, pkt := range u.GetRTPPackets() {
-					// recompute timestamp from scratch.
-					// Chrome requires a precise timestamp that FFmpeg doesn't provide.
-					pkt.Timestamp = curTimestamp
-					curTimestamp += uint32(len(pkt.Payload)) / uint32(g711Format.ChannelCount)
-
-					track.WriteRTP(pkt) //nolint:errcheck
-				}
-
-				return nil
-			})
-		} else {
-			encoder := &rtplpcm.Encoder{
-				PayloadType:    96,
-				PayloadMaxSize: webrtcPayloadMaxSize,
-				BitDepth:       16,
-				ChannelCount:   g711Format.ChannelCount,
-			}
-			err := encoder.Init()
-			if err != nil {
-				return nil, err
-			}
-
-			curTimestamp, err := randUint32()
-			if err != nil {
-				return nil, err
-			}
-
-			stream.AddReader(writer, media, g711Format, func(u unit.Unit) error {
-				tunit := u.(*unit.G711)
-
-				if tunit.Samples == nil {
-					return nil
-				}
-
-				var lpcmSamples []byte
-				if g711Format.MULaw {
-					lpcmSamples = g711.DecodeMulaw(tunit.Samples)
-				} else {
-					lpcmSamples = g711.DecodeAlaw(tunit.Samples)
-				}
-
-				packets, err := encoder.Encode(lpcmSamples)
-				if err != nil {
-					return nil //nolint:nilerr
-				}
-
-				for _, pkt := range packets {
-					// recompute timestamp from scratch.
-					// Chrome requires a precise timestamp that FFmpeg doesn't provide.
-					pkt.Timestamp = curTimestamp
-					curTimestamp += uint32(len(pkt.Payload)) / 2 / uint32(g711Format.ChannelCount)
-
-					track.WriteRTP(pkt) //nolint:errcheck
-				}
+		newPacketizer := newG711Packetizer(g711Format)
+		if g711Format.SampleRate != 8000 {
+			newPacketizer = newLPCMPacketizer(g711Format, g711Format.ChannelCount)
+		}
 
-				return nil
-			})
+		err := stream.AddPacketizedReader(writer, media, g711Format, packetizerKey, newPacketizer, writePackets(track))
+		if err != nil {
+			return nil, err
 		}
 
 		return g711Format, nil
@@ -470,60 +370,61 @@ func setupAudioTrack(
 		}
 		pc.OutgoingTracks = append(pc.OutgoingTracks, track)
 
-		encoder := &rtplpcm.Encoder{
-			PayloadType:    96,
-			BitDepth:       16,
-			ChannelCount:   lpcmFormat.ChannelCount,
-			PayloadMaxSize: webrtcPayloadMaxSize,
-		}
-		err := encoder.Init()
-		if err != nil {
-			return nil, err
-		}
-
-		curTimestamp, err := randUint32()
+		err := stream.AddPacketizedReader(writer, media, lpcmFormat, packetizerKey,
+			newLPCMPacketizer(nil, lpcmFormat.ChannelCount), writePackets(track))
 		if err != nil {
 			return nil, err
 		}
 
-		stream.AddReader(writer, media, lpcmFormat, func(u unit.Unit) error {
-			tunit := u.(*unit.LPCM)
+		return lpcmFormat, nil
+	}
 
-			if tunit.Samples == nil {
-				return nil
-			}
+	return nil, nil
+}
 
-			packets, err := encoder.Encode(tunit.Samples)
-			if err != nil {
-				return nil //nolint:nilerr
+func setupDataChannel(
+	stream *stream.Stream,
+	writer *asyncwriter.Writer,
+	pc *PeerConnection,
+) format.Format {
+	for _, media := range stream.Desc().Medias {
+		for _, forma := range media.Formats {
+			if !isDataChannelFormat(forma) {
+				continue
 			}
 
-			for _, pkt := range packets {
-				// recompute timestamp from scratch.
-				// Chrome requires a precise timestamp that FFmpeg doesn't provide.
-				pkt.Timestamp = curTimestamp
-				curTimestamp += uint32(len(pkt.Payload)) / 2 / uint32(lpcmFormat.ChannelCount)
+			var buf []byte
 
-				track.WriteRTP(pkt) //nolint:errcheck
-			}
+			stream.AddReader(writer, media, forma, func(u unit.Unit) error {
+				for _, pkt := range u.GetRTPPackets() {
+					buf = append(buf, pkt.Payload...)
 
-			return nil
-		})
+					if pkt.Marker {
+						pc.outgoingData.write(buf)
+						buf = nil
+					}
+				}
+				return nil
+			})
 
-		return lpcmFormat, nil
+			return forma
+		}
 	}
 
-	return nil, nil
+	return nil
 }
 
-// FromStream maps a MediaMTX stream to a WebRTC connection
+// FromStream maps a MediaMTX stream to a WebRTC connection.
+// When passthrough is true, H264 RTP packets of the stream are sent as they are,
+// without being re-encoded, when they are compatible with WebRTC.
 func FromStream(
 	stream *stream.Stream,
 	writer *asyncwriter.Writer,
 	pc *PeerConnection,
+	passthrough bool,
 	l logger.Writer,
 ) error {
-	videoFormat, err := setupVideoTrack(stream, writer, pc)
+	videoFormat, err := setupVideoTrack(stream, writer, pc, passthrough)
 	if err != nil {
 		return err
 	}
@@ -537,10 +438,12 @@ func FromStream(
 		return errNoSupportedCodecsFrom
 	}
 
+	dataFormat := setupDataChannel(stream, writer, pc)
+
 	n := 1
 	for _, media := range stream.Desc().Medias {
 		for _, forma := range media.Formats {
-			if forma != videoFormat && forma != audioFormat {
+			if forma != videoFormat && forma != audioFormat && forma != dataFormat {
 				l.Log(logger.Warn, "skipping track %d (%s)", n, forma.Codec())
 			}
 			n++
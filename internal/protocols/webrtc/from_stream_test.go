@@ -7,9 +7,11 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,6 +23,7 @@ func TestFromStreamNoSupportedCodecs(t *testing.T) {
 			Formats: []format.Format{&format.H265{}},
 		}}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -31,7 +34,7 @@ func TestFromStreamNoSupportedCodecs(t *testing.T) {
 		t.Error("should not happen")
 	})
 
-	err = FromStream(stream, writer, nil, l)
+	err = FromStream(stream, writer, nil, false, l)
 	require.Equal(t, errNoSupportedCodecsFrom, err)
 }
 
@@ -49,6 +52,7 @@ func TestFromStreamSkipUnsupportedTracks(t *testing.T) {
 			},
 		}},
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -67,7 +71,7 @@ func TestFromStreamSkipUnsupportedTracks(t *testing.T) {
 
 	pc := &PeerConnection{}
 
-	err = FromStream(stream, writer, pc, l)
+	err = FromStream(stream, writer, pc, false, l)
 	require.NoError(t, err)
 	require.Equal(t, 1, n)
 }
@@ -86,6 +90,7 @@ func TestFromStream(t *testing.T) {
 					}},
 				},
 				false,
+				formatprocessor.Options{},
 				test.NilLogger,
 			)
 			require.NoError(t, err)
@@ -95,10 +100,72 @@ func TestFromStream(t *testing.T) {
 
 			pc := &PeerConnection{}
 
-			err = FromStream(stream, writer, pc, nil)
+			err = FromStream(stream, writer, pc, false, nil)
 			require.NoError(t, err)
 
 			require.Equal(t, ca.webrtcCaps, pc.OutgoingTracks[0].Caps)
 		})
 	}
 }
+
+func TestCanRouteH264Packets(t *testing.T) {
+	forma := &format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}
+
+	for _, ca := range []struct {
+		name  string
+		pkts  []*rtp.Packet
+		isIDR bool
+		ok    bool
+	}{
+		{
+			"non-idr",
+			[]*rtp.Packet{{Payload: []byte{0x01, 0x02}}},
+			false,
+			true,
+		},
+		{
+			"idr with sps",
+			[]*rtp.Packet{
+				{Payload: []byte{0x67, 0x01}},
+				{Payload: []byte{0x68, 0x01}},
+				{Payload: []byte{0x65, 0x01}},
+			},
+			true,
+			true,
+		},
+		{
+			"idr with sps in stap-a",
+			[]*rtp.Packet{
+				{Payload: []byte{0x78, 0x00, 0x02, 0x67, 0x01, 0x00, 0x02, 0x68, 0x01}},
+				{Payload: []byte{0x65, 0x01}},
+			},
+			true,
+			true,
+		},
+		{
+			"idr without sps",
+			[]*rtp.Packet{{Payload: []byte{0x65, 0x01}}},
+			true,
+			false,
+		},
+		{
+			"packet too big",
+			[]*rtp.Packet{{Payload: append([]byte{0x01}, make([]byte, webrtcPayloadMaxSize)...)}},
+			false,
+			false,
+		},
+		{
+			"no packets",
+			nil,
+			false,
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			require.Equal(t, ca.ok, canRouteH264Packets(ca.pkts, forma, ca.isIDR))
+		})
+	}
+}
@@ -20,7 +20,8 @@ var multichannelOpusSDP = map[int]string{
 type OutgoingTrack struct {
 	Caps webrtc.RTPCodecCapability
 
-	track *webrtc.TrackLocalStaticRTP
+	track  *webrtc.TrackLocalStaticRTP
+	sender *webrtc.RTPSender
 }
 
 func (t *OutgoingTrack) isVideo() bool {
@@ -50,6 +51,8 @@ func (t *OutgoingTrack) setup(p *PeerConnection) error {
 		return err
 	}
 
+	t.sender = sender
+
 	// read incoming RTCP packets to make interceptors work
 	go func() {
 		buf := make([]byte, 1500)
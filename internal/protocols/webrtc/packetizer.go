@@ -0,0 +1,343 @@
+package webrtc
+
+import (
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpav1"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtplpcm"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpvp8"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpvp9"
+	"github.com/bluenviron/mediacommon/pkg/codecs/g711"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// packetizers are shared between all WebRTC readers of a stream,
+// therefore this key must change when parameters of a packetizer change.
+const packetizerKey = "webrtc"
+
+func writePackets(track *OutgoingTrack) stream.PacketizedReadFunc {
+	return func(_ unit.Unit, pkts []*rtp.Packet) error {
+		for _, pkt := range pkts {
+			track.WriteRTP(pkt) //nolint:errcheck
+		}
+		return nil
+	}
+}
+
+type av1Packetizer struct {
+	encoder *rtpav1.Encoder
+}
+
+func newAV1Packetizer() (stream.Packetizer, error) {
+	p := &av1Packetizer{
+		encoder: &rtpav1.Encoder{
+			PayloadType:    105,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		},
+	}
+	return p, p.encoder.Init()
+}
+
+// Packetize implements stream.Packetizer.
+func (p *av1Packetizer) Packetize(u unit.Unit) []*rtp.Packet {
+	tunit := u.(*unit.AV1)
+
+	if tunit.TU == nil {
+		return nil
+	}
+
+	packets, err := p.encoder.Encode(tunit.TU)
+	if err != nil {
+		return nil
+	}
+
+	for _, pkt := range packets {
+		pkt.Timestamp += tunit.RTPPackets[0].Timestamp
+	}
+
+	return packets
+}
+
+type vp9Packetizer struct {
+	encoder *rtpvp9.Encoder
+}
+
+func newVP9Packetizer() (stream.Packetizer, error) {
+	p := &vp9Packetizer{
+		encoder: &rtpvp9.Encoder{
+			PayloadType:      96,
+			PayloadMaxSize:   webrtcPayloadMaxSize,
+			InitialPictureID: uint16Ptr(8445),
+		},
+	}
+	return p, p.encoder.Init()
+}
+
+// Packetize implements stream.Packetizer.
+func (p *vp9Packetizer) Packetize(u unit.Unit) []*rtp.Packet {
+	tunit := u.(*unit.VP9)
+
+	if tunit.Frame == nil {
+		return nil
+	}
+
+	packets, err := p.encoder.Encode(tunit.Frame)
+	if err != nil {
+		return nil
+	}
+
+	for _, pkt := range packets {
+		pkt.Timestamp += tunit.RTPPackets[0].Timestamp
+	}
+
+	return packets
+}
+
+type vp8Packetizer struct {
+	encoder *rtpvp8.Encoder
+}
+
+func newVP8Packetizer() (stream.Packetizer, error) {
+	p := &vp8Packetizer{
+		encoder: &rtpvp8.Encoder{
+			PayloadType:    96,
+			PayloadMaxSize: webrtcPayloadMaxSize,
+		},
+	}
+	return p, p.encoder.Init()
+}
+
+// Packetize implements stream.Packetizer.
+func (p *vp8Packetizer) Packetize(u unit.Unit) []*rtp.Packet {
+	tunit := u.(*unit.VP8)
+
+	if tunit.Frame == nil {
+		return nil
+	}
+
+	packets, err := p.encoder.Encode(tunit.Frame)
+	if err != nil {
+		return nil
+	}
+
+	for _, pkt := range packets {
+		pkt.Timestamp += tunit.RTPPackets[0].Timestamp
+	}
+
+	return packets
+}
+
+type h264Packetizer struct {
+	format      *format.H264
+	passthrough bool
+
+	encoder   *rtph264.Encoder
+	curSeqNum uint16
+	pending   []*rtp.Packet
+}
+
+func newH264Packetizer(forma *format.H264, passthrough bool) func() (stream.Packetizer, error) {
+	return func() (stream.Packetizer, error) {
+		p := &h264Packetizer{
+			format:      forma,
+			passthrough: passthrough,
+			encoder: &rtph264.Encoder{
+				PayloadType:    96,
+				PayloadMaxSize: webrtcPayloadMaxSize,
+			},
+		}
+
+		err := p.encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		// in passthrough mode, sequence numbers are regenerated
+		// since packets can either be routed or re-encoded.
+		seqNum, err := randUint32()
+		if err != nil {
+			return nil, err
+		}
+		p.curSeqNum = uint16(seqNum)
+
+		return p, nil
+	}
+}
+
+func (p *h264Packetizer) setSequenceNumber(pkt *rtp.Packet) *rtp.Packet {
+	pkt.SequenceNumber = p.curSeqNum
+	p.curSeqNum++
+	return pkt
+}
+
+// Packetize implements stream.Packetizer.
+func (p *h264Packetizer) Packetize(u unit.Unit) []*rtp.Packet {
+	tunit := u.(*unit.H264)
+
+	if p.passthrough {
+		p.pending = append(p.pending, tunit.RTPPackets...)
+	}
+
+	if tunit.AU == nil {
+		return nil
+	}
+
+	if p.passthrough {
+		pkts := p.pending
+		p.pending = nil
+
+		if canRouteH264Packets(pkts, p.format, h264.IDRPresent(tunit.AU)) {
+			out := make([]*rtp.Packet, len(pkts))
+
+			for i, pkt := range pkts {
+				// packets of the stream are shared with other readers and can't be modified.
+				out[i] = p.setSequenceNumber(&rtp.Packet{
+					Header: rtp.Header{
+						Version:     2,
+						Marker:      pkt.Marker,
+						PayloadType: 96,
+						Timestamp:   pkt.Timestamp,
+					},
+					Payload: pkt.Payload,
+				})
+			}
+
+			return out
+		}
+	}
+
+	packets, err := p.encoder.Encode(tunit.AU)
+	if err != nil {
+		return nil
+	}
+
+	for _, pkt := range packets {
+		pkt.Timestamp += tunit.RTPPackets[0].Timestamp
+
+		if p.passthrough {
+			p.setSequenceNumber(pkt)
+		}
+	}
+
+	return packets
+}
+
+// g711Packetizer routes G711 packets, recomputing their timestamps.
+type g711Packetizer struct {
+	format       *format.G711
+	curTimestamp uint32
+}
+
+func newG711Packetizer(forma *format.G711) func() (stream.Packetizer, error) {
+	return func() (stream.Packetizer, error) {
+		curTimestamp, err := randUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		return &g711Packetizer{
+			format:       forma,
+			curTimestamp: curTimestamp,
+		}, nil
+	}
+}
+
+// Packetize implements stream.Packetizer.
+func (p *g711Packetizer) Packetize(u unit.Unit) []*rtp.Packet {
+	pkts := u.GetRTPPackets()
+	out := make([]*rtp.Packet, len(pkts))
+
+	for i, pkt := range pkts {
+		// packets of the stream are shared with other readers and can't be modified.
+		clone := *pkt
+		out[i] = &clone
+
+		// recompute timestamp from scratch.
+		// Chrome requires a precise timestamp that FFmpeg doesn't provide.
+		out[i].Timestamp = p.curTimestamp
+		p.curTimestamp += uint32(len(pkt.Payload)) / uint32(p.format.ChannelCount)
+	}
+
+	return out
+}
+
+// lpcmPacketizer encodes LPCM samples, or G711 samples converted into LPCM.
+type lpcmPacketizer struct {
+	g711Format   *format.G711
+	channelCount int
+
+	encoder      *rtplpcm.Encoder
+	curTimestamp uint32
+}
+
+func newLPCMPacketizer(g711Format *format.G711, channelCount int) func() (stream.Packetizer, error) {
+	return func() (stream.Packetizer, error) {
+		p := &lpcmPacketizer{
+			g711Format:   g711Format,
+			channelCount: channelCount,
+			encoder: &rtplpcm.Encoder{
+				PayloadType:    96,
+				PayloadMaxSize: webrtcPayloadMaxSize,
+				BitDepth:       16,
+				ChannelCount:   channelCount,
+			},
+		}
+
+		err := p.encoder.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		p.curTimestamp, err = randUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}
+}
+
+// Packetize implements stream.Packetizer.
+func (p *lpcmPacketizer) Packetize(u unit.Unit) []*rtp.Packet {
+	var samples []byte
+
+	if p.g711Format != nil {
+		tunit := u.(*unit.G711)
+
+		if tunit.Samples == nil {
+			return nil
+		}
+
+		if p.g711Format.MULaw {
+			samples = g711.DecodeMulaw(tunit.Samples)
+		} else {
+			samples = g711.DecodeAlaw(tunit.Samples)
+		}
+	} else {
+		tunit := u.(*unit.LPCM)
+
+		if tunit.Samples == nil {
+			return nil
+		}
+
+		samples = tunit.Samples
+	}
+
+	packets, err := p.encoder.Encode(samples)
+	if err != nil {
+		return nil
+	}
+
+	for _, pkt := range packets {
+		// recompute timestamp from scratch.
+		// Chrome requires a precise timestamp that FFmpeg doesn't provide.
+		pkt.Timestamp = p.curTimestamp
+		p.curTimestamp += uint32(len(pkt.Payload)) / 2 / uint32(p.channelCount)
+	}
+
+	return packets
+}
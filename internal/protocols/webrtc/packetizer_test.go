@@ -0,0 +1,101 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestH264PacketizerPassthrough(t *testing.T) {
+	newPacketizer := newH264Packetizer(&format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+	}, true)
+
+	p, err := newPacketizer()
+	require.NoError(t, err)
+
+	src := []*rtp.Packet{
+		{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    100,
+				SequenceNumber: 5000,
+				Timestamp:      45343,
+				SSRC:           563423,
+			},
+			Payload: []byte{0x7c, 0x81, 0xaa},
+		},
+		{
+			Header: rtp.Header{
+				Version:        2,
+				Marker:         true,
+				PayloadType:    100,
+				SequenceNumber: 5001,
+				Timestamp:      45343,
+				SSRC:           563423,
+			},
+			Payload: []byte{0x7c, 0x41, 0xbb},
+		},
+	}
+
+	pkts := p.Packetize(&unit.H264{
+		Base: unit.Base{RTPPackets: src[:1]},
+	})
+	require.Empty(t, pkts)
+
+	pkts = p.Packetize(&unit.H264{
+		Base: unit.Base{RTPPackets: src[1:]},
+		AU:   [][]byte{{0x61, 0xaa, 0xbb}},
+	})
+	require.Len(t, pkts, 2)
+
+	for i, pkt := range pkts {
+		require.Equal(t, uint8(96), pkt.PayloadType)
+		require.Equal(t, uint32(45343), pkt.Timestamp)
+		require.Equal(t, src[i].Marker, pkt.Marker)
+		require.Equal(t, src[i].Payload, pkt.Payload)
+	}
+	require.Equal(t, pkts[0].SequenceNumber+1, pkts[1].SequenceNumber)
+
+	// source packets are left untouched
+	require.Equal(t, uint16(5000), src[0].SequenceNumber)
+	require.Equal(t, uint8(100), src[0].PayloadType)
+}
+
+func TestG711PacketizerDoesNotModifySource(t *testing.T) {
+	newPacketizer := newG711Packetizer(&format.G711{
+		MULaw:        true,
+		SampleRate:   8000,
+		ChannelCount: 1,
+	})
+
+	p, err := newPacketizer()
+	require.NoError(t, err)
+
+	src := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    0,
+			SequenceNumber: 1123,
+			Timestamp:      45343,
+		},
+		Payload: []byte{1, 2, 3},
+	}
+
+	pkts := p.Packetize(&unit.G711{
+		Base: unit.Base{RTPPackets: []*rtp.Packet{src}},
+	})
+	require.Len(t, pkts, 1)
+
+	pkts2 := p.Packetize(&unit.G711{
+		Base: unit.Base{RTPPackets: []*rtp.Packet{src}},
+	})
+	require.Equal(t, pkts[0].Timestamp+3, pkts2[0].Timestamp)
+
+	require.Equal(t, uint32(45343), src.Timestamp)
+}
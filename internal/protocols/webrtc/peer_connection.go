@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/ice/v2"
 	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
 
@@ -22,19 +24,11 @@ const (
 	webrtcStreamID = "mediamtx"
 )
 
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
-		}
-	}
-	return false
-}
-
 // TracksAreValid checks whether tracks in the SDP are valid
 func TracksAreValid(medias []*sdp.MediaDescription) error {
 	videoTrack := false
 	audioTrack := false
+	dataChannel := false
 
 	for _, media := range medias {
 		switch media.MediaName.Media {
@@ -50,6 +44,12 @@ func TracksAreValid(medias []*sdp.MediaDescription) error {
 			}
 			audioTrack = true
 
+		case "application":
+			if dataChannel {
+				return fmt.Errorf("only a single data channel section is supported")
+			}
+			dataChannel = true
+
 		default:
 			return fmt.Errorf("unsupported media '%s'", media.MediaName.Media)
 		}
@@ -62,6 +62,15 @@ func TracksAreValid(medias []*sdp.MediaDescription) error {
 	return nil
 }
 
+func hasDataChannel(medias []*sdp.MediaDescription) bool {
+	for _, media := range medias {
+		if media.MediaName.Media == "application" {
+			return true
+		}
+	}
+	return false
+}
+
 type trackRecvPair struct {
 	track    *webrtc.TrackRemote
 	receiver *webrtc.RTPReceiver
@@ -69,18 +78,23 @@ type trackRecvPair struct {
 
 // PeerConnection is a wrapper around webrtc.PeerConnection.
 type PeerConnection struct {
-	ICEServers            []webrtc.ICEServer
-	ICEUDPMux             ice.UDPMux
-	ICETCPMux             ice.TCPMux
-	HandshakeTimeout      conf.StringDuration
-	TrackGatherTimeout    conf.StringDuration
-	LocalRandomUDP        bool
-	IPsFromInterfaces     bool
-	IPsFromInterfacesList []string
-	AdditionalHosts       []string
-	Publish               bool
-	OutgoingTracks        []*OutgoingTrack
-	Log                   logger.Writer
+	ICEServers                   []webrtc.ICEServer
+	ICEUDPMux                    ice.UDPMux
+	ICETCPMux                    ice.TCPMux
+	ICEUDPPortRange              conf.UDPPortRange
+	HandshakeTimeout             conf.StringDuration
+	TrackGatherTimeout           conf.StringDuration
+	LocalRandomUDP               bool
+	IPsFromInterfaces            bool
+	IPsFromInterfacesList        []string
+	IPsFromInterfacesExcludeList []string
+	AdditionalHosts              []string
+	ICEAddressFamilies           conf.WebRTCICEAddressFamilies
+	ICECandidateTypes            conf.WebRTCICECandidateTypes
+	DTLSCertificate              *webrtc.Certificate
+	Publish                      bool
+	OutgoingTracks               []*OutgoingTrack
+	Log                          logger.Writer
 
 	wr                *webrtc.PeerConnection
 	stateChangeMutex  sync.Mutex
@@ -93,6 +107,13 @@ type PeerConnection struct {
 	ctx               context.Context
 	ctxCancel         context.CancelFunc
 	incomingTracks    []*IncomingTrack
+	incomingData      *IncomingDataChannel
+	outgoingData      outgoingDataChannels
+
+	// statistics are not available after closing
+	closed              atomic.Bool
+	closedBytesReceived uint64
+	closedBytesSent     uint64
 }
 
 // Start starts the peer connection.
@@ -101,23 +122,43 @@ func (co *PeerConnection) Start() error {
 
 	settingsEngine.SetInterfaceFilter(func(iface string) bool {
 		return co.IPsFromInterfaces && (len(co.IPsFromInterfacesList) == 0 ||
-			stringInSlice(iface, co.IPsFromInterfacesList))
+			interfaceMatches(iface, co.IPsFromInterfacesList)) &&
+			!interfaceMatches(iface, co.IPsFromInterfacesExcludeList)
 	})
 
 	settingsEngine.SetAdditionalHosts(co.AdditionalHosts)
 
+	addressFamilies := co.ICEAddressFamilies
+	if len(addressFamilies) == 0 {
+		addressFamilies = conf.WebRTCICEAddressFamilies{conf.WebRTCICEAddressFamilyIPv4}
+	}
+
 	var networkTypes []webrtc.NetworkType
 
 	// always enable UDP in order to support STUN/TURN
-	networkTypes = append(networkTypes, webrtc.NetworkTypeUDP4)
+	if addressFamilies.Contains(conf.WebRTCICEAddressFamilyIPv4) {
+		networkTypes = append(networkTypes, webrtc.NetworkTypeUDP4)
+	}
+	if addressFamilies.Contains(conf.WebRTCICEAddressFamilyIPv6) {
+		networkTypes = append(networkTypes, webrtc.NetworkTypeUDP6)
+	}
 
 	if co.ICEUDPMux != nil {
 		settingsEngine.SetICEUDPMux(co.ICEUDPMux)
+	} else if co.ICEUDPPortRange.Size() != 0 {
+		err := settingsEngine.SetEphemeralUDPPortRange(uint16(co.ICEUDPPortRange.Min), uint16(co.ICEUDPPortRange.Max))
+		if err != nil {
+			return err
+		}
 	}
 
 	if co.ICETCPMux != nil {
 		settingsEngine.SetICETCPMux(co.ICETCPMux)
-		networkTypes = append(networkTypes, webrtc.NetworkTypeTCP4)
+
+		// TCP over IPv6 is not supported yet
+		if addressFamilies.Contains(conf.WebRTCICEAddressFamilyIPv4) {
+			networkTypes = append(networkTypes, webrtc.NetworkTypeTCP4)
+		}
 	}
 
 	if co.LocalRandomUDP {
@@ -205,9 +246,19 @@ func (co *PeerConnection) Start() error {
 		webrtc.WithMediaEngine(mediaEngine),
 		webrtc.WithInterceptorRegistry(interceptorRegistry))
 
-	co.wr, err = api.NewPeerConnection(webrtc.Configuration{
-		ICEServers: co.ICEServers,
-	})
+	configuration := webrtc.Configuration{
+		ICEServers: filterICEServers(co.ICEServers, co.ICECandidateTypes),
+	}
+
+	if len(co.ICECandidateTypes) == 1 && co.ICECandidateTypes[0] == webrtc.ICECandidateTypeRelay {
+		configuration.ICETransportPolicy = webrtc.ICETransportPolicyRelay
+	}
+
+	if co.DTLSCertificate != nil {
+		configuration.Certificates = []webrtc.Certificate{*co.DTLSCertificate}
+	}
+
+	co.wr, err = api.NewPeerConnection(configuration)
 	if err != nil {
 		return err
 	}
@@ -221,6 +272,27 @@ func (co *PeerConnection) Start() error {
 
 	co.ctx, co.ctxCancel = context.WithCancel(context.Background())
 
+	if !co.Publish {
+		co.incomingData = &IncomingDataChannel{}
+		err = co.incomingData.initialize()
+		if err != nil {
+			co.wr.Close() //nolint:errcheck
+			return err
+		}
+	}
+
+	co.wr.OnDataChannel(func(dc *webrtc.DataChannel) {
+		co.Log.Log(logger.Debug, "data channel '%s' opened", dc.Label())
+
+		if co.Publish {
+			co.outgoingData.add(dc)
+		} else {
+			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+				co.incomingData.onMessage(msg.Data)
+			})
+		}
+	})
+
 	if co.Publish {
 		for _, tr := range co.OutgoingTracks {
 			err = tr.setup(co)
@@ -291,6 +363,12 @@ func (co *PeerConnection) Start() error {
 
 	co.wr.OnICECandidate(func(i *webrtc.ICECandidate) {
 		if i != nil {
+			// host candidates are always gathered, since they are needed
+			// to compute server reflexive candidates.
+			if !co.ICECandidateTypes.Contains(i.Typ) {
+				return
+			}
+
 			v := i.ToJSON()
 			select {
 			case co.newLocalCandidate <- &v:
@@ -308,10 +386,36 @@ func (co *PeerConnection) Start() error {
 // Close closes the connection.
 func (co *PeerConnection) Close() {
 	co.ctxCancel()
+
+	co.closedBytesReceived = co.BytesReceived()
+	co.closedBytesSent = co.BytesSent()
+	co.closed.Store(true)
+
 	co.wr.Close() //nolint:errcheck
 	<-co.done
 }
 
+// SendGoodbye sends a RTCP BYE packet for every outgoing track,
+// in order to notify the remote peer that tracks are about to end.
+func (co *PeerConnection) SendGoodbye() error {
+	var ssrcs []uint32
+
+	for _, tr := range co.OutgoingTracks {
+		if tr.sender == nil {
+			continue
+		}
+		for _, enc := range tr.sender.GetParameters().Encodings {
+			ssrcs = append(ssrcs, uint32(enc.SSRC))
+		}
+	}
+
+	if len(ssrcs) == 0 {
+		return nil
+	}
+
+	return co.wr.WriteRTCP([]rtcp.Packet{&rtcp.Goodbye{Sources: ssrcs}})
+}
+
 // CreatePartialOffer creates a partial offer.
 func (co *PeerConnection) CreatePartialOffer() (*webrtc.SessionDescription, error) {
 	offer, err := co.wr.CreateOffer(nil)
@@ -365,7 +469,7 @@ func (co *PeerConnection) CreateFullAnswer(
 		return nil, err
 	}
 
-	return co.wr.LocalDescription(), nil
+	return filterLocalCandidates(co.wr.LocalDescription(), co.ICECandidateTypes)
 }
 
 func (co *PeerConnection) waitGatheringDone(ctx context.Context) error {
@@ -409,7 +513,12 @@ func (co *PeerConnection) GatherIncomingTracks(ctx context.Context) ([]*Incoming
 	var sdp sdp.SessionDescription
 	sdp.Unmarshal([]byte(co.wr.RemoteDescription().SDP)) //nolint:errcheck
 
-	maxTrackCount := len(sdp.MediaDescriptions)
+	maxTrackCount := 0
+	for _, media := range sdp.MediaDescriptions {
+		if media.MediaName.Media != "application" {
+			maxTrackCount++
+		}
+	}
 
 	t := time.NewTimer(time.Duration(co.TrackGatherTimeout))
 	defer t.Stop()
@@ -492,6 +601,23 @@ func (co *PeerConnection) StartReading() {
 	for _, track := range co.incomingTracks {
 		track.start()
 	}
+
+	if co.incomingData != nil {
+		co.incomingData.start()
+	}
+}
+
+// incomingDataChannel returns the data channel receiver,
+// if the remote peer announced a data channel section.
+func (co *PeerConnection) incomingDataChannel() *IncomingDataChannel {
+	var sdp sdp.SessionDescription
+	sdp.Unmarshal([]byte(co.wr.RemoteDescription().SDP)) //nolint:errcheck
+
+	if !hasDataChannel(sdp.MediaDescriptions) {
+		return nil
+	}
+
+	return co.incomingData
 }
 
 // RemoteCandidate returns the remote candidate.
@@ -518,6 +644,10 @@ func (co *PeerConnection) RemoteCandidate() string {
 
 // BytesReceived returns received bytes.
 func (co *PeerConnection) BytesReceived() uint64 {
+	if co.closed.Load() {
+		return co.closedBytesReceived
+	}
+
 	for _, stats := range co.wr.GetStats() {
 		if tstats, ok := stats.(webrtc.TransportStats); ok {
 			if tstats.ID == "iceTransport" {
@@ -530,6 +660,10 @@ func (co *PeerConnection) BytesReceived() uint64 {
 
 // BytesSent returns sent bytes.
 func (co *PeerConnection) BytesSent() uint64 {
+	if co.closed.Load() {
+		return co.closedBytesSent
+	}
+
 	for _, stats := range co.wr.GetStats() {
 		if tstats, ok := stats.(webrtc.TransportStats); ok {
 			if tstats.ID == "iceTransport" {
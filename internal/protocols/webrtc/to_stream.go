@@ -157,5 +157,32 @@ func ToStream(
 		return nil, errNoSupportedCodecsTo
 	}
 
+	if dc := pc.incomingDataChannel(); dc != nil {
+		forma := &format.Generic{
+			PayloadTyp: dataChannelPayloadType,
+			RTPMa:      dataChannelRTPMap,
+		}
+		err := forma.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		medi := &description.Media{
+			Type:    description.MediaTypeApplication,
+			Formats: []format.Format{forma},
+		}
+
+		dc.OnPacketRTP = func(pkt *rtp.Packet) {
+			pts, ok := timeDecoder.Decode(dc, pkt)
+			if !ok {
+				return
+			}
+
+			(*stream).WriteRTPPacket(medi, forma, pkt, time.Now(), pts)
+		}
+
+		medias = append(medias, medi)
+	}
+
 	return medias, nil
 }
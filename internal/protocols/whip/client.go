@@ -26,9 +26,10 @@ const (
 
 // Client is a WHIP client.
 type Client struct {
-	HTTPClient *http.Client
-	URL        *url.URL
-	Log        logger.Writer
+	HTTPClient      *http.Client
+	URL             *url.URL
+	ICEUDPPortRange conf.UDPPortRange
+	Log             logger.Writer
 
 	pc               *webrtc.PeerConnection
 	patchIsSupported bool
@@ -48,6 +49,7 @@ func (c *Client) Publish(
 		ICEServers:         iceServers,
 		HandshakeTimeout:   conf.StringDuration(10 * time.Second),
 		TrackGatherTimeout: conf.StringDuration(2 * time.Second),
+		ICEUDPPortRange:    c.ICEUDPPortRange,
 		LocalRandomUDP:     true,
 		IPsFromInterfaces:  true,
 		Publish:            true,
@@ -124,6 +126,7 @@ func (c *Client) Read(ctx context.Context) ([]*webrtc.IncomingTrack, error) {
 		ICEServers:         iceServers,
 		HandshakeTimeout:   conf.StringDuration(10 * time.Second),
 		TrackGatherTimeout: conf.StringDuration(2 * time.Second),
+		ICEUDPPortRange:    c.ICEUDPPortRange,
 		LocalRandomUDP:     true,
 		IPsFromInterfaces:  true,
 		Publish:            false,
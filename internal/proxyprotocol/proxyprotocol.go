@@ -0,0 +1,48 @@
+// Package proxyprotocol contains utilities to handle the PROXY protocol.
+package proxyprotocol
+
+import (
+	"net"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+)
+
+// Listen is a replacement for net.Listen that reads PROXY protocol (v1 and v2) headers
+// sent by trusted proxies and uses the address they contain as remote address of the connection.
+// Connections from other sources are left untouched.
+// When trustedProxies is empty, the PROXY protocol is disabled.
+func Listen(trustedProxies conf.IPNetworks) func(network string, address string) (net.Listener, error) {
+	return func(network string, address string) (net.Listener, error) {
+		ln, err := net.Listen(network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		return Wrap(ln, trustedProxies), nil
+	}
+}
+
+// Wrap wraps a listener in order to read PROXY protocol headers sent by trusted proxies.
+func Wrap(ln net.Listener, trustedProxies conf.IPNetworks) net.Listener {
+	if len(trustedProxies) == 0 {
+		return ln
+	}
+
+	return &proxyproto.Listener{
+		Listener: ln,
+		Policy: func(upstream net.Addr) (proxyproto.Policy, error) {
+			if addr, ok := upstream.(*net.TCPAddr); ok && trustedProxies.Contains(addr.IP) {
+				return proxyproto.USE, nil
+			}
+			return proxyproto.SKIP, nil
+		},
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+}
@@ -0,0 +1,69 @@
+package proxyprotocol
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+func TestListen(t *testing.T) {
+	for _, ca := range []string{
+		"trusted",
+		"untrusted",
+		"disabled",
+	} {
+		t.Run(ca, func(t *testing.T) {
+			var trustedProxies conf.IPNetworks
+
+			switch ca {
+			case "trusted":
+				err := trustedProxies.UnmarshalJSON([]byte(`["127.0.0.0/8"]`))
+				require.NoError(t, err)
+
+			case "untrusted":
+				err := trustedProxies.UnmarshalJSON([]byte(`["192.168.0.0/16"]`))
+				require.NoError(t, err)
+			}
+
+			ln, err := Listen(trustedProxies)("tcp", "localhost:9123")
+			require.NoError(t, err)
+			defer ln.Close()
+
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+
+				nconn, err2 := net.Dial("tcp", "localhost:9123")
+				require.NoError(t, err2)
+				defer nconn.Close()
+
+				_, err2 = nconn.Write([]byte("PROXY TCP4 10.0.0.5 10.0.0.1 5678 8554\r\ntest"))
+				require.NoError(t, err2)
+			}()
+
+			nconn, err := ln.Accept()
+			require.NoError(t, err)
+			defer nconn.Close()
+
+			buf := make([]byte, 4)
+
+			if ca == "trusted" {
+				_, err = nconn.Read(buf)
+				require.NoError(t, err)
+				require.Equal(t, []byte("test"), buf)
+				require.Equal(t, "10.0.0.5:5678", nconn.RemoteAddr().String())
+			} else {
+				_, err = nconn.Read(buf)
+				require.NoError(t, err)
+				require.Equal(t, []byte("PROX"), buf)
+				require.Equal(t, "127.0.0.1", nconn.RemoteAddr().(*net.TCPAddr).IP.String())
+			}
+
+			<-done
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package ptz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	gotoPresetTimeout = 10 * time.Second
+)
+
+// ErrDisabled is returned when PTZ is not configured.
+var ErrDisabled = errors.New("PTZ is not configured on this path")
+
+// Tour moves a camera through a sequence of presets (guard tour).
+// The camera can also be moved manually to a preset, stopping the tour.
+type Tour struct {
+	Client *Client
+	Steps  conf.PTZTourSteps
+	Parent logger.Writer
+
+	ctx       context.Context
+	ctxCancel func()
+
+	mutex         sync.Mutex
+	tourCtxCancel func()
+	tourDone      chan struct{}
+	preset        string
+}
+
+// Initialize initializes Tour.
+func (t *Tour) Initialize() {
+	t.ctx, t.ctxCancel = context.WithCancel(context.Background())
+}
+
+// Close closes Tour.
+func (t *Tour) Close() {
+	t.Stop()
+	t.ctxCancel()
+}
+
+// Log implements logger.Writer.
+func (t *Tour) Log(level logger.Level, format string, args ...interface{}) {
+	t.Parent.Log(level, "[ptz] "+format, args...)
+}
+
+// Start starts the tour from the first step.
+func (t *Tour) Start() error {
+	if len(t.Steps) == 0 {
+		return fmt.Errorf("tour has no steps")
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.tourCtxCancel != nil {
+		return nil
+	}
+
+	var tourCtx context.Context
+	tourCtx, t.tourCtxCancel = context.WithCancel(t.ctx)
+	t.tourDone = make(chan struct{})
+
+	go t.runTour(tourCtx, t.tourDone)
+
+	t.Log(logger.Info, "tour started")
+
+	return nil
+}
+
+// Stop stops the tour.
+func (t *Tour) Stop() {
+	t.mutex.Lock()
+	tourCtxCancel := t.tourCtxCancel
+	tourDone := t.tourDone
+	t.tourCtxCancel = nil
+	t.tourDone = nil
+	t.mutex.Unlock()
+
+	if tourCtxCancel != nil {
+		tourCtxCancel()
+		<-tourDone
+		t.Log(logger.Info, "tour stopped")
+	}
+}
+
+// GotoPreset stops the tour and moves the camera to a preset.
+func (t *Tour) GotoPreset(preset string) error {
+	t.Stop()
+
+	ctx, ctxCancel := context.WithTimeout(t.ctx, gotoPresetTimeout)
+	defer ctxCancel()
+
+	err := t.Client.GotoPreset(ctx, preset)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.preset = preset
+	t.mutex.Unlock()
+
+	return nil
+}
+
+// APIPTZGet returns the state of the tour.
+func (t *Tour) APIPTZGet() *defs.APIPathPTZ {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return &defs.APIPathPTZ{
+		TourRunning: t.tourCtxCancel != nil,
+		Preset:      t.preset,
+	}
+}
+
+func (t *Tour) runTour(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		for _, step := range t.Steps {
+			err := func() error {
+				ctx2, ctxCancel := context.WithTimeout(ctx, gotoPresetTimeout)
+				defer ctxCancel()
+				return t.Client.GotoPreset(ctx2, step.Preset)
+			}()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				t.Log(logger.Warn, "unable to move to preset '%s': %v", step.Preset, err)
+			} else {
+				t.mutex.Lock()
+				t.preset = step.Preset
+				t.mutex.Unlock()
+			}
+
+			select {
+			case <-time.After(time.Duration(step.Dwell)):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
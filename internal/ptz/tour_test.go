@@ -0,0 +1,122 @@
+package ptz
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+type testEnvelope struct {
+	Username     string `xml:"Header>Security>UsernameToken>Username"`
+	Password     string `xml:"Header>Security>UsernameToken>Password"`
+	Nonce        string `xml:"Header>Security>UsernameToken>Nonce"`
+	Created      string `xml:"Header>Security>UsernameToken>Created"`
+	ProfileToken string `xml:"Body>GotoPreset>ProfileToken"`
+	PresetToken  string `xml:"Body>GotoPreset>PresetToken"`
+}
+
+func TestTour(t *testing.T) {
+	var mutex sync.Mutex
+	var presets []string
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env testEnvelope
+		err := xml.NewDecoder(r.Body).Decode(&env)
+		require.NoError(t, err)
+
+		require.Equal(t, "myuser", env.Username)
+		require.Equal(t, "myprofile", env.ProfileToken)
+
+		nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+		require.NoError(t, err)
+
+		h := sha1.New() //nolint:gosec
+		h.Write(nonce)
+		h.Write([]byte(env.Created))
+		h.Write([]byte("mypass"))
+		require.Equal(t, base64.StdEncoding.EncodeToString(h.Sum(nil)), env.Password)
+
+		mutex.Lock()
+		presets = append(presets, env.PresetToken)
+		mutex.Unlock()
+	}))
+	defer s.Close()
+
+	tour := &Tour{
+		Client: &Client{
+			URL:          s.URL,
+			User:         "myuser",
+			Pass:         "mypass",
+			ProfileToken: "myprofile",
+		},
+		Steps: conf.PTZTourSteps{
+			{Preset: "p1", Dwell: conf.StringDuration(100 * time.Millisecond)},
+			{Preset: "p2", Dwell: conf.StringDuration(100 * time.Millisecond)},
+		},
+		Parent: test.NilLogger,
+	}
+	tour.Initialize()
+	defer tour.Close()
+
+	err := tour.Start()
+	require.NoError(t, err)
+
+	// wait for the tour to restart from the first preset
+	for {
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		n := len(presets)
+		mutex.Unlock()
+		if n >= 3 {
+			break
+		}
+	}
+
+	require.Equal(t, true, tour.APIPTZGet().TourRunning)
+
+	err = tour.GotoPreset("p3")
+	require.NoError(t, err)
+
+	require.Equal(t, false, tour.APIPTZGet().TourRunning)
+	require.Equal(t, "p3", tour.APIPTZGet().Preset)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.Equal(t, []string{"p1", "p2", "p1"}, presets[:3])
+	require.Equal(t, "p3", presets[len(presets)-1])
+}
+
+func TestTourFault(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body><s:Fault>` + //nolint:errcheck
+			`<s:Reason><s:Text>No such PTZ preset</s:Text></s:Reason></s:Fault></s:Body></s:Envelope>`))
+	}))
+	defer s.Close()
+
+	tour := &Tour{
+		Client: &Client{
+			URL:          s.URL,
+			ProfileToken: "myprofile",
+		},
+		Parent: test.NilLogger,
+	}
+	tour.Initialize()
+	defer tour.Close()
+
+	err := tour.GotoPreset("p1")
+	require.EqualError(t, err, "GotoPreset failed: No such PTZ preset")
+
+	err = tour.Start()
+	require.EqualError(t, err, "tour has no steps")
+}
@@ -0,0 +1,184 @@
+// Package publicip contains a public IP detector.
+package publicip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/stun"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	detectTimeout = 10 * time.Second
+	maxBodySize   = 1024
+)
+
+func detectWithSTUN(ctx context.Context, address string) (net.IP, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp4", address)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := stun.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer c.Close()
+
+	// interrupt the transaction when the context is canceled
+	stop := context.AfterFunc(ctx, func() {
+		c.Close()
+	})
+	defer stop()
+
+	var ip net.IP
+	var doErr error
+
+	err = c.Do(stun.MustBuild(stun.TransactionID, stun.BindingRequest), func(e stun.Event) {
+		if e.Error != nil {
+			doErr = e.Error
+			return
+		}
+
+		var addr stun.XORMappedAddress
+		doErr = addr.GetFrom(e.Message)
+		if doErr == nil {
+			ip = addr.IP
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if doErr != nil {
+		return nil, doErr
+	}
+
+	return ip, nil
+}
+
+func detectWithHTTP(ctx context.Context, ur string) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ur, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status code: %d", res.StatusCode)
+	}
+
+	byts, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(byts)))
+	if ip == nil {
+		return nil, fmt.Errorf("response does not contain a valid IP")
+	}
+
+	return ip, nil
+}
+
+// Detector periodically detects the public IP of the server.
+type Detector struct {
+	Source  string
+	Refresh conf.StringDuration
+	Parent  logger.Writer
+
+	ctx       context.Context
+	ctxCancel func()
+	mutex     sync.RWMutex
+	ip        net.IP
+
+	done chan struct{}
+}
+
+// Initialize initializes Detector.
+func (d *Detector) Initialize() {
+	d.ctx, d.ctxCancel = context.WithCancel(context.Background())
+	d.done = make(chan struct{})
+
+	go d.run()
+}
+
+// Close closes Detector.
+func (d *Detector) Close() {
+	d.ctxCancel()
+	<-d.done
+}
+
+// Log implements logger.Writer.
+func (d *Detector) Log(level logger.Level, format string, args ...interface{}) {
+	d.Parent.Log(level, "[public IP] "+format, args...)
+}
+
+// IP returns the last detected public IP, or an empty string if the IP is not known yet.
+func (d *Detector) IP() string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.ip == nil {
+		return ""
+	}
+	return d.ip.String()
+}
+
+func (d *Detector) run() {
+	defer close(d.done)
+
+	for {
+		d.detect()
+
+		select {
+		case <-time.After(time.Duration(d.Refresh)):
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Detector) detect() {
+	ctx, ctxCancel := context.WithTimeout(d.ctx, detectTimeout)
+	defer ctxCancel()
+
+	var ip net.IP
+	var err error
+
+	if strings.HasPrefix(d.Source, "stun:") {
+		ip, err = detectWithSTUN(ctx, d.Source[len("stun:"):])
+	} else {
+		ip, err = detectWithHTTP(ctx, d.Source)
+	}
+
+	if err != nil {
+		if d.ctx.Err() == nil {
+			d.Log(logger.Warn, "unable to detect public IP: %v", err)
+		}
+		return
+	}
+
+	d.mutex.Lock()
+	changed := !ip.Equal(d.ip)
+	d.ip = ip
+	d.mutex.Unlock()
+
+	if changed {
+		d.Log(logger.Info, "public IP is %s", ip)
+	}
+}
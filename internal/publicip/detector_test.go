@@ -0,0 +1,96 @@
+package publicip
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pion/stun"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func waitIP(t *testing.T, d *Detector) string {
+	for i := 0; i < 50; i++ {
+		if ip := d.IP(); ip != "" {
+			return ip
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Error("IP not detected")
+	return ""
+}
+
+func TestDetectorHTTP(t *testing.T) {
+	httpServ := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("203.0.113.5\n")) //nolint:errcheck
+		}),
+	}
+
+	ln, err := net.Listen("tcp", "localhost:9120")
+	require.NoError(t, err)
+
+	go httpServ.Serve(ln)
+	defer httpServ.Close()
+
+	d := &Detector{
+		Source:  "http://localhost:9120/ip",
+		Refresh: conf.StringDuration(time.Hour),
+		Parent:  test.NilLogger,
+	}
+	d.Initialize()
+	defer d.Close()
+
+	require.Equal(t, "203.0.113.5", waitIP(t, d))
+}
+
+func TestDetectorSTUN(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:9121")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err2 := pc.ReadFrom(buf)
+			if err2 != nil {
+				return
+			}
+
+			req := &stun.Message{Raw: append([]byte(nil), buf[:n]...)}
+			err2 = req.Decode()
+			if err2 != nil {
+				continue
+			}
+
+			res, err2 := stun.Build(
+				stun.NewTransactionIDSetter(req.TransactionID),
+				stun.BindingSuccess,
+				&stun.XORMappedAddress{
+					IP:   net.ParseIP("198.51.100.7"),
+					Port: addr.(*net.UDPAddr).Port,
+				},
+				stun.Fingerprint,
+			)
+			if err2 != nil {
+				continue
+			}
+
+			pc.WriteTo(res.Raw, addr) //nolint:errcheck
+		}
+	}()
+
+	d := &Detector{
+		Source:  "stun:127.0.0.1:9121",
+		Refresh: conf.StringDuration(time.Hour),
+		Parent:  test.NilLogger,
+	}
+	d.Initialize()
+	defer d.Close()
+
+	require.Equal(t, "198.51.100.7", waitIP(t, d))
+}
@@ -13,10 +13,17 @@ import (
 
 var timeNow = time.Now
 
+type cleanerClockMonitor interface {
+	Jumps() uint64
+}
+
 // Cleaner removes expired recording segments from disk.
 type Cleaner struct {
-	PathConfs map[string]*conf.Path
-	Parent    logger.Writer
+	PathConfs    map[string]*conf.Path
+	ClockMonitor cleanerClockMonitor
+	Parent       logger.Writer
+
+	lastClockJumps uint64
 
 	ctx       context.Context
 	ctxCancel func()
@@ -99,6 +106,17 @@ func (c *Cleaner) cleanInterval() time.Duration {
 }
 
 func (c *Cleaner) doRun() {
+	// the age of segments is computed with the system clock.
+	// When the clock jumps, segments may look older than they actually are.
+	if c.ClockMonitor != nil {
+		jumps := c.ClockMonitor.Jumps()
+		if jumps != c.lastClockJumps {
+			c.lastClockJumps = jumps
+			c.Log(logger.Warn, "system clock jumped since the previous run, skipping cleanup")
+			return
+		}
+	}
+
 	now := timeNow()
 
 	pathNames := recordstore.FindAllPathsWithSegments(c.PathConfs)
@@ -127,6 +145,8 @@ func (c *Cleaner) processPath(now time.Time, pathName string) error {
 		if now.Sub(seg.Start) > time.Duration(pathConf.RecordDeleteAfter) {
 			c.Log(logger.Debug, "removing %s", seg.Fpath)
 			os.Remove(seg.Fpath)
+			os.Remove(recordstore.SubtitlesPath(seg.Fpath))
+			os.Remove(recordstore.DataTracksPath(seg.Fpath))
 		}
 	}
 
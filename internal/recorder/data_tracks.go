@@ -0,0 +1,147 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	rtspformat "github.com/bluenviron/gortsplib/v4/pkg/format"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	dataTrackMaxPayloadSize = 1024 * 1024
+)
+
+// dataTrackEntry is an entry of the data track file.
+type dataTrackEntry struct {
+	Track   int       `json:"track"`
+	RTPMap  string    `json:"rtpMap"`
+	NTP     time.Time `json:"ntp"`
+	PTS     float64   `json:"pts"`
+	Payload []byte    `json:"payload"`
+}
+
+type dataTrack struct {
+	n      int
+	rtpMap string
+
+	buf []byte
+}
+
+// dataTracks writes application tracks (ONVIF metadata, custom payloads)
+// into a sidecar file of every segment, in JSON Lines format.
+// Payloads split into multiple RTP packets are joined together.
+type dataTracks struct {
+	ai *agentInstance
+
+	formats map[rtspformat.Format]*dataTrack
+	fi      io.WriteCloser
+	bw      *bufio.Writer
+}
+
+func (d *dataTracks) initialize() {
+	d.formats = make(map[rtspformat.Format]*dataTrack)
+	var setuppedFormats []rtspformat.Format
+
+	n := 1
+	for _, media := range d.ai.agent.Stream.Desc().Medias {
+		for _, forma := range media.Formats {
+			if forma, ok := forma.(*rtspformat.Generic); ok && media.Type == description.MediaTypeApplication {
+				track := &dataTrack{
+					n:      n,
+					rtpMap: forma.RTPMap(),
+				}
+				d.formats[forma] = track
+				setuppedFormats = append(setuppedFormats, forma)
+
+				d.ai.agent.Stream.AddReader(d.ai.writer, media, forma, func(u unit.Unit) error {
+					return d.write(track, u.(*unit.Generic))
+				})
+			}
+			n++
+		}
+	}
+
+	if len(setuppedFormats) != 0 {
+		d.ai.Log(logger.Info, "recording data of %s", defs.FormatsInfo(setuppedFormats))
+	}
+}
+
+func (d *dataTracks) handles(forma rtspformat.Format) bool {
+	_, ok := d.formats[forma]
+	return ok
+}
+
+func (d *dataTracks) openFile(segmentPath string) {
+	if len(d.formats) == 0 {
+		return
+	}
+
+	fpath := recordstore.DataTracksPath(segmentPath)
+
+	fi, err := d.ai.agent.Storage.Create(fpath)
+	if err != nil {
+		d.ai.Log(logger.Warn, "unable to create data track file: %v", err)
+		return
+	}
+
+	d.fi = fi
+	d.bw = bufio.NewWriter(fi)
+}
+
+func (d *dataTracks) closeFile() {
+	if d.fi == nil {
+		return
+	}
+
+	d.bw.Flush() //nolint:errcheck
+	d.fi.Close()
+	d.fi = nil
+	d.bw = nil
+}
+
+func (d *dataTracks) write(track *dataTrack, u *unit.Generic) error {
+	for _, pkt := range u.RTPPackets {
+		track.buf = append(track.buf, pkt.Payload...)
+
+		if len(track.buf) > dataTrackMaxPayloadSize {
+			d.ai.Log(logger.Warn, "data track payload is too big, discarding")
+			track.buf = nil
+			continue
+		}
+
+		if !pkt.Marker {
+			continue
+		}
+
+		payload := track.buf
+		track.buf = nil
+
+		// entries received before the first segment are discarded
+		if d.fi == nil {
+			continue
+		}
+
+		byts, _ := json.Marshal(dataTrackEntry{
+			Track:   track.n,
+			RTPMap:  track.rtpMap,
+			NTP:     u.NTP,
+			PTS:     u.PTS.Seconds(),
+			Payload: payload,
+		})
+
+		_, err := d.bw.Write(append(byts, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
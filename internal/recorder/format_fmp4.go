@@ -19,6 +19,7 @@ import (
 	"github.com/bluenviron/mediacommon/pkg/codecs/vp9"
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
 
+	"github.com/bluenviron/mediamtx/internal/clockmonitor"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
@@ -44,6 +45,169 @@ func mpeg1audioChannelCount(cm mpeg1audio.ChannelMode) int {
 	}
 }
 
+const (
+	av1OBUTypeTemporalDelimiter av1.OBUType = 2
+	av1OBUTypeFrameHeader       av1.OBUType = 3
+	av1OBUTypeFrame             av1.OBUType = 6
+	av1OBUTypePadding           av1.OBUType = 15
+)
+
+// av1OBU is an OBU of a temporal unit.
+type av1OBU struct {
+	typ av1.OBUType
+
+	// OBU header, including the extension header and excluding the size field.
+	header  []byte
+	payload []byte
+}
+
+// av1ParseOBU parses an OBU.
+// OBUs received from WebRTC publishers usually don't contain the size field
+// and can contain the extension header, that is used by scalable streams.
+func av1ParseOBU(buf []byte) (*av1OBU, error) {
+	if len(buf) < 1 {
+		return nil, fmt.Errorf("not enough bytes")
+	}
+
+	if (buf[0] >> 7) != 0 {
+		return nil, fmt.Errorf("forbidden bit is set")
+	}
+
+	o := &av1OBU{
+		typ: av1.OBUType((buf[0] >> 3) & 0b1111),
+	}
+
+	headerLen := 1
+	if ((buf[0] >> 2) & 0b1) != 0 {
+		headerLen = 2
+	}
+
+	if len(buf) < headerLen {
+		return nil, fmt.Errorf("not enough bytes")
+	}
+
+	o.header = make([]byte, headerLen)
+	copy(o.header, buf[:headerLen])
+	o.header[0] &^= 0b10
+	payload := buf[headerLen:]
+
+	if ((buf[0] >> 1) & 0b1) != 0 {
+		size, n, err := av1.LEB128Unmarshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = payload[n:]
+
+		if len(payload) < int(size) {
+			return nil, fmt.Errorf("not enough bytes")
+		}
+		payload = payload[:size]
+	}
+
+	o.payload = payload
+
+	return o, nil
+}
+
+// av1ParseTemporalUnit parses the OBUs of a temporal unit.
+func av1ParseTemporalUnit(tu [][]byte) ([]*av1OBU, error) {
+	obus := make([]*av1OBU, len(tu))
+
+	for i, buf := range tu {
+		var err error
+		obus[i], err = av1ParseOBU(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return obus, nil
+}
+
+// av1BitstreamMarshal encodes OBUs in the format required by ISOBMFF,
+// in which every OBU has the size field and temporal delimiters and padding are removed.
+func av1BitstreamMarshal(obus []*av1OBU) []byte {
+	n := 0
+
+	for _, o := range obus {
+		if o.typ == av1OBUTypeTemporalDelimiter || o.typ == av1OBUTypePadding {
+			continue
+		}
+		n += len(o.header) + av1.LEB128MarshalSize(uint(len(o.payload))) + len(o.payload)
+	}
+
+	buf := make([]byte, n)
+	n = 0
+
+	for _, o := range obus {
+		if o.typ == av1OBUTypeTemporalDelimiter || o.typ == av1OBUTypePadding {
+			continue
+		}
+		n += copy(buf[n:], o.header)
+		buf[n-len(o.header)] |= 0b10
+		n += av1.LEB128MarshalTo(uint(len(o.payload)), buf[n:])
+		n += copy(buf[n:], o.payload)
+	}
+
+	return buf
+}
+
+// av1ContainsKeyFrame checks whether a temporal unit contains a key frame, by parsing frame headers.
+func av1ContainsKeyFrame(tu [][]byte, sh *av1.SequenceHeader) (bool, error) {
+	obus, err := av1ParseTemporalUnit(tu)
+	if err != nil {
+		return false, err
+	}
+
+	return av1OBUsContainKeyFrame(obus, sh)
+}
+
+func av1OBUsContainKeyFrame(obus []*av1OBU, sh *av1.SequenceHeader) (bool, error) {
+	for _, o := range obus {
+		if o.typ != av1OBUTypeFrameHeader && o.typ != av1OBUTypeFrame {
+			continue
+		}
+
+		if sh.ReducedStillPictureHeader {
+			return true, nil
+		}
+
+		if len(o.payload) < 1 {
+			return false, fmt.Errorf("frame header is too short")
+		}
+
+		// show_existing_frame
+		if (o.payload[0] >> 7) != 0 {
+			return false, nil
+		}
+
+		// frame_type
+		return ((o.payload[0] >> 5) & 0b11) == 0, nil
+	}
+
+	return false, nil
+}
+
+// vp9UpdateCodec fills codec parameters with the ones of a key frame.
+// It returns whether parameters have changed.
+func vp9UpdateCodec(codec *fmp4.CodecVP9, h *vp9.Header) bool {
+	newCodec := fmp4.CodecVP9{
+		Width:             h.Width(),
+		Height:            h.Height(),
+		Profile:           h.Profile,
+		BitDepth:          h.ColorConfig.BitDepth,
+		ChromaSubsampling: h.ChromaSubsampling(),
+		ColorRange:        h.ColorConfig.ColorRange,
+	}
+
+	if *codec == newCodec {
+		return false
+	}
+
+	*codec = newCodec
+	return true
+}
+
 func jpegExtractSize(image []byte) (int, int, error) {
 	l := len(image)
 	if l < 2 || image[0] != 0xFF || image[1] != jpeg.MarkerStartOfImage {
@@ -106,6 +270,8 @@ type formatFMP4 struct {
 	hasVideo           bool
 	currentSegment     *formatFMP4Segment
 	nextSequenceNumber uint32
+	timeline           clockmonitor.Timeline
+	clockJumped        bool
 }
 
 func (f *formatFMP4) initialize() {
@@ -146,12 +312,13 @@ func (f *formatFMP4) initialize() {
 		for _, forma := range media.Formats {
 			switch forma := forma.(type) {
 			case *rtspformat.AV1:
-				codec := &fmp4.CodecAV1{
-					SequenceHeader: formatprocessor.AV1DefaultSequenceHeader,
-				}
+				// sequence header is not provided by the format.
+				// wait for it before writing the initialization segment.
+				codec := &fmp4.CodecAV1{}
 				track := addTrack(forma, codec)
+				track.paramsMissing = true
 
-				firstReceived := false
+				var sequenceHeader *av1.SequenceHeader
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.AV1)
@@ -159,57 +326,62 @@ func (f *formatFMP4) initialize() {
 						return nil
 					}
 
-					randomAccess := false
+					obus, err := av1ParseTemporalUnit(tunit.TU)
+					if err != nil {
+						return err
+					}
 
-					for _, obu := range tunit.TU {
-						var h av1.OBUHeader
-						err := h.Unmarshal(obu)
-						if err != nil {
-							return err
-						}
+					for _, o := range obus {
+						if o.typ == av1.OBUTypeSequenceHeader {
+							// store the sequence header in the format accepted by av1.SequenceHeader
+							obu := append([]byte{byte(o.typ) << 3}, o.payload...)
 
-						if h.Type == av1.OBUTypeSequenceHeader {
 							if !bytes.Equal(codec.SequenceHeader, obu) {
+								var sh av1.SequenceHeader
+								err = sh.Unmarshal(obu)
+								if err != nil {
+									return err
+								}
+
+								sequenceHeader = &sh
 								codec.SequenceHeader = obu
 								updateCodecs()
 							}
-							randomAccess = true
 						}
 					}
 
-					if !firstReceived {
-						if !randomAccess {
-							return nil
-						}
-						firstReceived = true
+					if sequenceHeader == nil {
+						return nil
 					}
 
-					sampl, err := fmp4.NewPartSampleAV1(
-						randomAccess,
-						tunit.TU)
+					randomAccess, err := av1OBUsContainKeyFrame(obus, sequenceHeader)
 					if err != nil {
 						return err
 					}
 
+					if track.paramsMissing {
+						if !randomAccess {
+							return nil
+						}
+						track.paramsMissing = false
+					}
+
 					return track.write(&sample{
-						PartSample: sampl,
-						dts:        tunit.PTS,
-						ntp:        tunit.NTP,
+						PartSample: &fmp4.PartSample{
+							IsNonSyncSample: !randomAccess,
+							Payload:         av1BitstreamMarshal(obus),
+						},
+						dts: tunit.PTS,
+						ntp: tunit.NTP,
 					})
 				})
 
 			case *rtspformat.VP9:
-				codec := &fmp4.CodecVP9{
-					Width:             1280,
-					Height:            720,
-					Profile:           1,
-					BitDepth:          8,
-					ChromaSubsampling: 1,
-					ColorRange:        false,
-				}
+				// parameters are not provided by the format.
+				// wait for a key frame before writing the initialization segment.
+				codec := &fmp4.CodecVP9{}
 				track := addTrack(forma, codec)
-
-				firstReceived := false
+				track.paramsMissing = true
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.VP9)
@@ -223,42 +395,19 @@ func (f *formatFMP4) initialize() {
 						return err
 					}
 
-					randomAccess := false
+					randomAccess := !h.ShowExistingFrame && !h.NonKeyFrame
 
-					if !h.NonKeyFrame {
-						randomAccess = true
-
-						if w := h.Width(); codec.Width != w {
-							codec.Width = w
-							updateCodecs()
-						}
-						if h := h.Width(); codec.Height != h {
-							codec.Height = h
-							updateCodecs()
-						}
-						if codec.Profile != h.Profile {
-							codec.Profile = h.Profile
-							updateCodecs()
-						}
-						if codec.BitDepth != h.ColorConfig.BitDepth {
-							codec.BitDepth = h.ColorConfig.BitDepth
-							updateCodecs()
-						}
-						if c := h.ChromaSubsampling(); codec.ChromaSubsampling != c {
-							codec.ChromaSubsampling = c
-							updateCodecs()
-						}
-						if codec.ColorRange != h.ColorConfig.ColorRange {
-							codec.ColorRange = h.ColorConfig.ColorRange
+					if randomAccess {
+						if vp9UpdateCodec(codec, &h) {
 							updateCodecs()
 						}
 					}
 
-					if !firstReceived {
+					if track.paramsMissing {
 						if !randomAccess {
 							return nil
 						}
-						firstReceived = true
+						track.paramsMissing = false
 					}
 
 					return track.write(&sample{
@@ -438,7 +587,7 @@ func (f *formatFMP4) initialize() {
 				track := addTrack(forma, codec)
 
 				firstReceived := false
-				var lastPTS time.Duration
+				var dtsExtractor mpegVideoDTSExtractor
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.MPEG4Video)
@@ -465,17 +614,23 @@ func (f *formatFMP4) initialize() {
 							return nil
 						}
 						firstReceived = true
-					} else if tunit.PTS < lastPTS {
-						return fmt.Errorf("MPEG-4 Video streams with B-frames are not supported (yet)")
 					}
-					lastPTS = tunit.PTS
+
+					dts, ok, err := dtsExtractor.extract(tunit.PTS, mpeg4VideoIsBFrame(tunit.Frame))
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return nil
+					}
 
 					return track.write(&sample{
 						PartSample: &fmp4.PartSample{
+							PTSOffset:       int32(durationGoToMp4(tunit.PTS-dts, 90000)),
 							Payload:         tunit.Frame,
 							IsNonSyncSample: !randomAccess,
 						},
-						dts: tunit.PTS,
+						dts: dts,
 						ntp: tunit.NTP,
 					})
 				})
@@ -487,7 +642,7 @@ func (f *formatFMP4) initialize() {
 				track := addTrack(forma, codec)
 
 				firstReceived := false
-				var lastPTS time.Duration
+				var dtsExtractor mpegVideoDTSExtractor
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.MPEG1Video)
@@ -514,29 +669,33 @@ func (f *formatFMP4) initialize() {
 							return nil
 						}
 						firstReceived = true
-					} else if tunit.PTS < lastPTS {
-						return fmt.Errorf("MPEG-1 Video streams with B-frames are not supported (yet)")
 					}
-					lastPTS = tunit.PTS
+
+					dts, ok, err := dtsExtractor.extract(tunit.PTS, mpeg1VideoIsBFrame(tunit.Frame))
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return nil
+					}
 
 					return track.write(&sample{
 						PartSample: &fmp4.PartSample{
+							PTSOffset:       int32(durationGoToMp4(tunit.PTS-dts, 90000)),
 							Payload:         tunit.Frame,
 							IsNonSyncSample: !randomAccess,
 						},
-						dts: tunit.PTS,
+						dts: dts,
 						ntp: tunit.NTP,
 					})
 				})
 
 			case *rtspformat.MJPEG:
-				codec := &fmp4.CodecMJPEG{
-					Width:  800,
-					Height: 600,
-				}
+				// size is not provided by the format.
+				// wait for the first frame before writing the initialization segment.
+				codec := &fmp4.CodecMJPEG{}
 				track := addTrack(forma, codec)
-
-				parsed := false
+				track.paramsMissing = true
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.MJPEG)
@@ -544,24 +703,46 @@ func (f *formatFMP4) initialize() {
 						return nil
 					}
 
-					if !parsed {
-						parsed = true
-						width, height, err := jpegExtractSize(tunit.Frame)
-						if err != nil {
-							return err
-						}
+					width, height, err := jpegExtractSize(tunit.Frame)
+					if err != nil {
+						return err
+					}
+
+					if track.paramsMissing {
 						codec.Width = width
 						codec.Height = height
-						updateCodecs()
+						track.paramsMissing = false
 					}
 
-					return track.write(&sample{
+					err = track.write(&sample{
 						PartSample: &fmp4.PartSample{
 							Payload: tunit.Frame,
 						},
 						dts: tunit.PTS,
 						ntp: tunit.NTP,
 					})
+					if err != nil {
+						return err
+					}
+
+					// size has changed (i.e. camera switched between day and night mode).
+					// close current segment, that contains frames with the previous size,
+					// in order to store the new size in a new segment.
+					if width != codec.Width || height != codec.Height {
+						if f.currentSegment != nil {
+							f.currentSegment.lastDTS = tunit.PTS
+							err = f.currentSegment.close()
+							f.currentSegment = nil
+							if err != nil {
+								return err
+							}
+						}
+
+						codec.Width = width
+						codec.Height = height
+					}
+
+					return nil
 				})
 
 			case *rtspformat.Opus:
@@ -815,7 +996,7 @@ func (f *formatFMP4) initialize() {
 	n := 1
 	for _, medi := range f.ai.agent.Stream.Desc().Medias {
 		for _, forma := range medi.Formats {
-			if _, ok := setuppedFormatsMap[forma]; !ok {
+			if _, ok := setuppedFormatsMap[forma]; !ok && !f.ai.isDataTrack(forma) {
 				f.ai.Log(logger.Warn, "skipping track %d (%s)", n, forma.Codec())
 			}
 			n++
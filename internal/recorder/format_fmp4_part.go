@@ -2,15 +2,12 @@ package recorder
 
 import (
 	"io"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4/seekablebuffer"
 
 	"github.com/bluenviron/mediamtx/internal/logger"
-	"github.com/bluenviron/mediamtx/internal/recordstore"
 )
 
 func writePart(
@@ -55,20 +52,15 @@ func (p *formatFMP4Part) initialize() {
 
 func (p *formatFMP4Part) close() error {
 	if p.s.fi == nil {
-		p.s.path = recordstore.Path{Start: p.s.startNTP}.Encode(p.s.f.ai.pathFormat)
+		p.s.path = p.s.f.ai.segmentPath(p.s.startNTP)
 		p.s.f.ai.Log(logger.Debug, "creating segment %s", p.s.path)
 
-		err := os.MkdirAll(filepath.Dir(p.s.path), 0o755)
+		fi, err := p.s.f.ai.agent.Storage.Create(p.s.path)
 		if err != nil {
 			return err
 		}
 
-		fi, err := os.Create(p.s.path)
-		if err != nil {
-			return err
-		}
-
-		p.s.f.ai.agent.OnSegmentCreate(p.s.path)
+		p.s.f.ai.onSegmentCreate(p.s.path)
 
 		err = writeInit(fi, p.s.f.tracks)
 		if err != nil {
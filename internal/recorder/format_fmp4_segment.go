@@ -2,7 +2,6 @@ package recorder
 
 import (
 	"io"
-	"os"
 	"time"
 
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
@@ -37,7 +36,7 @@ type formatFMP4Segment struct {
 	startNTP time.Time
 
 	path    string
-	fi      *os.File
+	fi      io.WriteCloser
 	curPart *formatFMP4Part
 	lastDTS time.Duration
 }
@@ -55,6 +54,7 @@ func (s *formatFMP4Segment) close() error {
 
 	if s.fi != nil {
 		s.f.ai.Log(logger.Debug, "closing segment %s", s.path)
+		s.f.ai.onSegmentClose()
 		err2 := s.fi.Close()
 		if err == nil {
 			err = err2
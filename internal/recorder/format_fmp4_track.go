@@ -2,21 +2,37 @@ package recorder
 
 import (
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+
+	"github.com/bluenviron/mediamtx/internal/logger"
 )
 
 type formatFMP4Track struct {
-	f         *formatFMP4
-	initTrack *fmp4.InitTrack
+	f             *formatFMP4
+	initTrack     *fmp4.InitTrack
+	paramsMissing bool
 
 	nextSample *sample
 }
 
 func (t *formatFMP4Track) write(sample *sample) error {
+	// wait until codec parameters of every track are available,
+	// otherwise the initialization segment would be invalid.
+	for _, track := range t.f.tracks {
+		if track.paramsMissing {
+			return nil
+		}
+	}
+
 	// wait the first video sample before setting hasVideo
 	if t.initTrack.Codec.IsVideo() {
 		t.f.hasVideo = true
 	}
 
+	if jump := t.f.timeline.Check(sample.ntp); jump != 0 {
+		t.f.ai.Log(logger.Warn, "system clock jumped by %v, starting a new segment", jump)
+		t.f.clockJumped = true
+	}
+
 	sample, t.nextSample = t.nextSample, sample
 	if sample == nil {
 		return nil
@@ -42,7 +58,8 @@ func (t *formatFMP4Track) write(sample *sample) error {
 
 	if (!t.f.hasVideo || t.initTrack.Codec.IsVideo()) &&
 		!t.nextSample.IsNonSyncSample &&
-		(t.nextSample.dts-t.f.currentSegment.startDTS) >= t.f.ai.agent.SegmentDuration {
+		((t.nextSample.dts-t.f.currentSegment.startDTS) >= t.f.ai.agent.SegmentDuration || t.f.clockJumped) {
+		t.f.clockJumped = false
 		t.f.currentSegment.lastDTS = t.nextSample.dts
 		err := t.f.currentSegment.close()
 		if err != nil {
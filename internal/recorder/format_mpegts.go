@@ -3,7 +3,6 @@ package recorder
 import (
 	"bufio"
 	"bytes"
-	"fmt"
 	"io"
 	"time"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4video"
 	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
 
+	"github.com/bluenviron/mediamtx/internal/clockmonitor"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -44,9 +44,11 @@ type formatMPEGTS struct {
 
 	dw             *dynamicWriter
 	bw             *bufio.Writer
-	mw             *mpegts.Writer
+	mw             *mpegtsWriter
 	hasVideo       bool
 	currentSegment *formatMPEGTSSegment
+	timeline       clockmonitor.Timeline
+	clockJumped    bool
 }
 
 func (f *formatMPEGTS) initialize() {
@@ -99,7 +101,7 @@ func (f *formatMPEGTS) initialize() {
 						true,
 						randomAccess,
 						func() error {
-							return f.mw.WriteH265(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts), randomAccess, tunit.AU)
+							return f.mw.writeH265(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts), randomAccess, tunit.AU)
 						},
 					)
 				})
@@ -135,7 +137,7 @@ func (f *formatMPEGTS) initialize() {
 						true,
 						randomAccess,
 						func() error {
-							return f.mw.WriteH264(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts), randomAccess, tunit.AU)
+							return f.mw.writeH264(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts), randomAccess, tunit.AU)
 						},
 					)
 				})
@@ -143,8 +145,7 @@ func (f *formatMPEGTS) initialize() {
 			case *rtspformat.MPEG4Video:
 				track := addTrack(forma, &mpegts.CodecMPEG4Video{})
 
-				firstReceived := false
-				var lastPTS time.Duration
+				var dtsExtractor *mpegVideoDTSExtractor
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.MPEG4Video)
@@ -152,22 +153,31 @@ func (f *formatMPEGTS) initialize() {
 						return nil
 					}
 
-					if !firstReceived {
-						firstReceived = true
-					} else if tunit.PTS < lastPTS {
-						return fmt.Errorf("MPEG-4 Video streams with B-frames are not supported (yet)")
+					randomAccess := bytes.Contains(tunit.Frame, []byte{0, 0, 1, byte(mpeg4video.GroupOfVOPStartCode)})
+
+					if dtsExtractor == nil {
+						if !randomAccess {
+							return nil
+						}
+						dtsExtractor = &mpegVideoDTSExtractor{}
 					}
-					lastPTS = tunit.PTS
 
-					randomAccess := bytes.Contains(tunit.Frame, []byte{0, 0, 1, byte(mpeg4video.GroupOfVOPStartCode)})
+					dts, ok, err := dtsExtractor.extract(tunit.PTS, mpeg4VideoIsBFrame(tunit.Frame))
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return nil
+					}
 
 					return f.write(
-						tunit.PTS,
+						dts,
 						tunit.NTP,
 						true,
 						randomAccess,
 						func() error {
-							return f.mw.WriteMPEG4Video(track, durationGoToMPEGTS(tunit.PTS), tunit.Frame)
+							return f.mw.writeMPEG4Video(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts),
+								randomAccess, tunit.Frame)
 						},
 					)
 				})
@@ -175,8 +185,7 @@ func (f *formatMPEGTS) initialize() {
 			case *rtspformat.MPEG1Video:
 				track := addTrack(forma, &mpegts.CodecMPEG1Video{})
 
-				firstReceived := false
-				var lastPTS time.Duration
+				var dtsExtractor *mpegVideoDTSExtractor
 
 				f.ai.agent.Stream.AddReader(f.ai.writer, media, forma, func(u unit.Unit) error {
 					tunit := u.(*unit.MPEG1Video)
@@ -184,22 +193,31 @@ func (f *formatMPEGTS) initialize() {
 						return nil
 					}
 
-					if !firstReceived {
-						firstReceived = true
-					} else if tunit.PTS < lastPTS {
-						return fmt.Errorf("MPEG-1 Video streams with B-frames are not supported (yet)")
+					randomAccess := bytes.Contains(tunit.Frame, []byte{0, 0, 1, 0xB8})
+
+					if dtsExtractor == nil {
+						if !randomAccess {
+							return nil
+						}
+						dtsExtractor = &mpegVideoDTSExtractor{}
 					}
-					lastPTS = tunit.PTS
 
-					randomAccess := bytes.Contains(tunit.Frame, []byte{0, 0, 1, 0xB8})
+					dts, ok, err := dtsExtractor.extract(tunit.PTS, mpeg1VideoIsBFrame(tunit.Frame))
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return nil
+					}
 
 					return f.write(
-						tunit.PTS,
+						dts,
 						tunit.NTP,
 						true,
 						randomAccess,
 						func() error {
-							return f.mw.WriteMPEG1Video(track, durationGoToMPEGTS(tunit.PTS), tunit.Frame)
+							return f.mw.writeMPEG1Video(track, durationGoToMPEGTS(tunit.PTS), durationGoToMPEGTS(dts),
+								randomAccess, tunit.Frame)
 						},
 					)
 				})
@@ -221,7 +239,7 @@ func (f *formatMPEGTS) initialize() {
 						false,
 						true,
 						func() error {
-							return f.mw.WriteOpus(track, durationGoToMPEGTS(tunit.PTS), tunit.Packets)
+							return f.mw.writeOpus(track, durationGoToMPEGTS(tunit.PTS), tunit.Packets)
 						},
 					)
 				})
@@ -247,7 +265,7 @@ func (f *formatMPEGTS) initialize() {
 							false,
 							true,
 							func() error {
-								return f.mw.WriteMPEG4Audio(track, durationGoToMPEGTS(tunit.PTS), tunit.AUs)
+								return f.mw.writeMPEG4Audio(track, durationGoToMPEGTS(tunit.PTS), tunit.AUs)
 							},
 						)
 					})
@@ -268,7 +286,7 @@ func (f *formatMPEGTS) initialize() {
 						false,
 						true,
 						func() error {
-							return f.mw.WriteMPEG1Audio(track, durationGoToMPEGTS(tunit.PTS), tunit.Frames)
+							return f.mw.writeMPEG1Audio(track, durationGoToMPEGTS(tunit.PTS), tunit.Frames)
 						},
 					)
 				})
@@ -294,7 +312,7 @@ func (f *formatMPEGTS) initialize() {
 								framePTS := tunit.PTS + time.Duration(i)*ac3.SamplesPerFrame*
 									time.Second/sampleRate
 
-								err := f.mw.WriteAC3(track, durationGoToMPEGTS(framePTS), frame)
+								err := f.mw.writeAC3(track, durationGoToMPEGTS(framePTS), frame)
 								if err != nil {
 									return err
 								}
@@ -316,7 +334,7 @@ func (f *formatMPEGTS) initialize() {
 	n := 1
 	for _, medi := range f.ai.agent.Stream.Desc().Medias {
 		for _, forma := range medi.Formats {
-			if _, ok := setuppedFormatsMap[forma]; !ok {
+			if _, ok := setuppedFormatsMap[forma]; !ok && !f.ai.isDataTrack(forma) {
 				f.ai.Log(logger.Warn, "skipping track %d (%s)", n, forma.Codec())
 			}
 			n++
@@ -325,7 +343,8 @@ func (f *formatMPEGTS) initialize() {
 
 	f.dw = &dynamicWriter{}
 	f.bw = bufio.NewWriterSize(f.dw, mpegtsMaxBufferSize)
-	f.mw = mpegts.NewWriter(f.bw, tracks)
+	f.mw = &mpegtsWriter{}
+	f.mw.initialize(f.bw, tracks)
 
 	f.ai.Log(logger.Info, "recording %s",
 		defs.FormatsInfo(setuppedFormats))
@@ -348,6 +367,11 @@ func (f *formatMPEGTS) write(
 		f.hasVideo = true
 	}
 
+	if jump := f.timeline.Check(ntp); jump != 0 {
+		f.ai.Log(logger.Warn, "system clock jumped by %v, starting a new segment", jump)
+		f.clockJumped = true
+	}
+
 	switch {
 	case f.currentSegment == nil:
 		f.currentSegment = &formatMPEGTSSegment{
@@ -358,7 +382,8 @@ func (f *formatMPEGTS) write(
 		f.currentSegment.initialize()
 	case (!f.hasVideo || isVideo) &&
 		randomAccess &&
-		(dts-f.currentSegment.startDTS) >= f.ai.agent.SegmentDuration:
+		((dts-f.currentSegment.startDTS) >= f.ai.agent.SegmentDuration || f.clockJumped):
+		f.clockJumped = false
 		f.currentSegment.lastDTS = dts
 		err := f.currentSegment.close()
 		if err != nil {
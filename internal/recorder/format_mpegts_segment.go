@@ -1,12 +1,10 @@
 package recorder
 
 import (
-	"os"
-	"path/filepath"
+	"io"
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/logger"
-	"github.com/bluenviron/mediamtx/internal/recordstore"
 )
 
 type formatMPEGTSSegment struct {
@@ -15,7 +13,7 @@ type formatMPEGTSSegment struct {
 	startNTP time.Time
 
 	path      string
-	fi        *os.File
+	fi        io.WriteCloser
 	lastFlush time.Duration
 	lastDTS   time.Duration
 }
@@ -31,6 +29,7 @@ func (s *formatMPEGTSSegment) close() error {
 
 	if s.fi != nil {
 		s.f.ai.Log(logger.Debug, "closing segment %s", s.path)
+		s.f.ai.onSegmentClose()
 		err2 := s.fi.Close()
 		if err == nil {
 			err = err2
@@ -47,20 +46,15 @@ func (s *formatMPEGTSSegment) close() error {
 
 func (s *formatMPEGTSSegment) Write(p []byte) (int, error) {
 	if s.fi == nil {
-		s.path = recordstore.Path{Start: s.startNTP}.Encode(s.f.ai.pathFormat)
+		s.path = s.f.ai.segmentPath(s.startNTP)
 		s.f.ai.Log(logger.Debug, "creating segment %s", s.path)
 
-		err := os.MkdirAll(filepath.Dir(s.path), 0o755)
+		fi, err := s.f.ai.agent.Storage.Create(s.path)
 		if err != nil {
 			return 0, err
 		}
 
-		fi, err := os.Create(s.path)
-		if err != nil {
-			return 0, err
-		}
-
-		s.f.ai.agent.OnSegmentCreate(s.path)
+		s.f.ai.onSegmentCreate(s.path)
 
 		s.fi = fi
 	}
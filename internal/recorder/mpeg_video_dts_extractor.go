@@ -0,0 +1,85 @@
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4video"
+)
+
+// mpeg4VideoIsBFrame checks whether a MPEG-4 Video frame contains a B-VOP.
+func mpeg4VideoIsBFrame(frame []byte) bool {
+	i := bytes.Index(frame, []byte{0, 0, 1, byte(mpeg4video.VOPStartCode)})
+	if i < 0 || (i+4) >= len(frame) {
+		return false
+	}
+
+	// vop_coding_type
+	return (frame[i+4] >> 6) == 2
+}
+
+// mpeg1VideoIsBFrame checks whether a MPEG-1 Video frame contains a B-picture.
+func mpeg1VideoIsBFrame(frame []byte) bool {
+	i := bytes.Index(frame, []byte{0, 0, 1, 0})
+	if i < 0 || (i+5) >= len(frame) {
+		return false
+	}
+
+	// picture_coding_type, after temporal_reference
+	return ((frame[i+5] >> 3) & 0x07) == 3
+}
+
+// mpegVideoDTSExtractor computes DTS of MPEG-4 Video and MPEG-1 Video frames,
+// that may contain B-frames.
+// Since B-frames are not used as reference, they are decoded and presented at the same time,
+// while reference frames are decoded when the previous reference frame is presented.
+type mpegVideoDTSExtractor struct {
+	refCount      int
+	prevRefPTS    time.Duration
+	prevDTS       time.Duration
+	prevDTSFilled bool
+}
+
+// extract returns the DTS of a frame. Frames must be provided in decoding order.
+// B-frames that precede the first reference frame in presentation order (open GOP)
+// can't be decoded and must be discarded; in this case, ok is false.
+func (d *mpegVideoDTSExtractor) extract(pts time.Duration, isBFrame bool) (time.Duration, bool, error) {
+	var dts time.Duration
+
+	switch {
+	case isBFrame:
+		if d.refCount == 0 || (d.refCount == 1 && pts < d.prevRefPTS) {
+			return 0, false, nil
+		}
+		dts = pts
+
+	case d.refCount == 0:
+		// DTS of the next reference frame will be equal to the PTS of this one.
+		dts = pts - 1*time.Millisecond
+
+	default:
+		dts = d.prevRefPTS
+	}
+
+	if dts > pts {
+		return 0, false, fmt.Errorf("DTS is greater than PTS")
+	}
+
+	if d.prevDTSFilled && dts < d.prevDTS {
+		return 0, false, fmt.Errorf("DTS is not monotonically increasing, was %v, now is %v",
+			d.prevDTS, dts)
+	}
+
+	if !isBFrame {
+		d.prevRefPTS = pts
+		if d.refCount < 2 {
+			d.refCount++
+		}
+	}
+
+	d.prevDTS = dts
+	d.prevDTSFilled = true
+
+	return dts, true, nil
+}
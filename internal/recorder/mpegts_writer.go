@@ -0,0 +1,373 @@
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/asticode/go-astits"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg1audio"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+)
+
+const (
+	mpegtsStreamIDVideo = 224
+	mpegtsStreamIDAudio = 192
+
+	// PCR is needed to read H265 tracks with VLC+VDPAU hardware encoder
+	// (and is probably needed by other combinations too)
+	mpegtsDTSPCRDiff = (90000 / 10)
+)
+
+func mpegtsMarshalTrack(track *mpegts.Track) astits.PMTElementaryStream {
+	es := astits.PMTElementaryStream{
+		ElementaryPID: track.PID,
+	}
+
+	switch codec := track.Codec.(type) {
+	case *mpegts.CodecH265:
+		es.StreamType = astits.StreamTypeH265Video
+
+	case *mpegts.CodecH264:
+		es.StreamType = astits.StreamTypeH264Video
+
+	case *mpegts.CodecMPEG4Video:
+		es.StreamType = astits.StreamTypeMPEG4Video
+
+	case *mpegts.CodecMPEG1Video:
+		// we use MPEG-2 to notify readers that video can be either MPEG-1 or MPEG-2
+		es.StreamType = astits.StreamTypeMPEG2Video
+
+	case *mpegts.CodecOpus:
+		es.StreamType = astits.StreamTypePrivateData
+		es.ElementaryStreamDescriptors = []*astits.Descriptor{
+			{
+				Length: 4,
+				Tag:    astits.DescriptorTagRegistration,
+				Registration: &astits.DescriptorRegistration{
+					FormatIdentifier: 'O'<<24 | 'p'<<16 | 'u'<<8 | 's',
+				},
+			},
+			{
+				Length: 2,
+				Tag:    astits.DescriptorTagExtension,
+				Extension: &astits.DescriptorExtension{
+					Tag:     0x80,
+					Unknown: &[]uint8{uint8(codec.ChannelCount)},
+				},
+			},
+		}
+
+	case *mpegts.CodecMPEG4Audio:
+		es.StreamType = astits.StreamTypeAACAudio
+
+	case *mpegts.CodecMPEG1Audio:
+		es.StreamType = astits.StreamTypeMPEG1Audio
+
+	case *mpegts.CodecAC3:
+		es.StreamType = astits.StreamTypeAC3Audio
+
+	default:
+		panic("this should not happen")
+	}
+
+	return es
+}
+
+// mpegtsMarshalOpus encodes Opus packets into access units
+// with a control header and no trimming.
+func mpegtsMarshalOpus(packets [][]byte) []byte {
+	n := 0
+	for _, packet := range packets {
+		n += 2 + len(packet)/255 + 1 + len(packet)
+	}
+
+	enc := make([]byte, n)
+	n = 0
+
+	for _, packet := range packets {
+		enc[n] = 0x7F
+		enc[n+1] = 0xE0
+		n += 2
+
+		for i := 0; i < len(packet)/255; i++ {
+			enc[n] = 255
+			n++
+		}
+		enc[n] = byte(len(packet) % 255)
+		n++
+
+		n += copy(enc[n:], packet)
+	}
+
+	return enc
+}
+
+// mpegtsWriter is a MPEG-TS writer.
+// It is equivalent to the one provided by mediacommon,
+// but allows to set the DTS of MPEG-4 Video and MPEG-1 Video frames,
+// that is needed to record streams with B-frames.
+type mpegtsWriter struct {
+	mux          *astits.Muxer
+	pcrCounter   int
+	leadingPID   uint16
+	leadingFound bool
+	mp3Checked   map[*mpegts.Track]struct{}
+}
+
+func (w *mpegtsWriter) initialize(bw io.Writer, tracks []*mpegts.Track) {
+	w.mux = astits.NewMuxer(context.Background(), bw)
+	w.mp3Checked = make(map[*mpegts.Track]struct{})
+
+	nextPID := uint16(256)
+
+	for _, track := range tracks {
+		if track.PID == 0 {
+			track.PID = nextPID
+			nextPID++
+		}
+
+		// PIDs are unique, therefore this can't fail
+		w.mux.AddElementaryStream(mpegtsMarshalTrack(track)) //nolint:errcheck
+	}
+}
+
+func (w *mpegtsWriter) writeH265(
+	track *mpegts.Track,
+	pts int64,
+	dts int64,
+	randomAccess bool,
+	au [][]byte,
+) error {
+	// prepend an AUD. This is required by video.js, iOS, QuickTime
+	if au[0][0] != byte(h265.NALUType_AUD_NUT<<1) {
+		au = append([][]byte{
+			{byte(h265.NALUType_AUD_NUT) << 1, 1, 0x50},
+		}, au...)
+	}
+
+	enc, err := h264.AnnexBMarshal(au)
+	if err != nil {
+		return err
+	}
+
+	return w.writeVideo(track, pts, dts, randomAccess, enc)
+}
+
+func (w *mpegtsWriter) writeH264(
+	track *mpegts.Track,
+	pts int64,
+	dts int64,
+	randomAccess bool,
+	au [][]byte,
+) error {
+	// prepend an AUD. This is required by video.js, iOS, QuickTime
+	if au[0][0] != byte(h264.NALUTypeAccessUnitDelimiter) {
+		au = append([][]byte{
+			{byte(h264.NALUTypeAccessUnitDelimiter), 240},
+		}, au...)
+	}
+
+	enc, err := h264.AnnexBMarshal(au)
+	if err != nil {
+		return err
+	}
+
+	return w.writeVideo(track, pts, dts, randomAccess, enc)
+}
+
+func (w *mpegtsWriter) writeMPEG4Video(
+	track *mpegts.Track,
+	pts int64,
+	dts int64,
+	randomAccess bool,
+	frame []byte,
+) error {
+	return w.writeVideo(track, pts, dts, randomAccess, frame)
+}
+
+func (w *mpegtsWriter) writeMPEG1Video(
+	track *mpegts.Track,
+	pts int64,
+	dts int64,
+	randomAccess bool,
+	frame []byte,
+) error {
+	return w.writeVideo(track, pts, dts, randomAccess, frame)
+}
+
+func (w *mpegtsWriter) writeOpus(
+	track *mpegts.Track,
+	pts int64,
+	packets [][]byte,
+) error {
+	return w.writeAudio(track, pts, mpegtsMarshalOpus(packets))
+}
+
+func (w *mpegtsWriter) writeMPEG4Audio(
+	track *mpegts.Track,
+	pts int64,
+	aus [][]byte,
+) error {
+	aacCodec := track.Codec.(*mpegts.CodecMPEG4Audio)
+	pkts := make(mpeg4audio.ADTSPackets, len(aus))
+
+	for i, au := range aus {
+		pkts[i] = &mpeg4audio.ADTSPacket{
+			Type:         aacCodec.Config.Type,
+			SampleRate:   aacCodec.Config.SampleRate,
+			ChannelCount: aacCodec.Config.ChannelCount,
+			AU:           au,
+		}
+	}
+
+	enc, err := pkts.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return w.writeAudio(track, pts, enc)
+}
+
+func (w *mpegtsWriter) writeMPEG1Audio(
+	track *mpegts.Track,
+	pts int64,
+	frames [][]byte,
+) error {
+	if _, ok := w.mp3Checked[track]; !ok {
+		var h mpeg1audio.FrameHeader
+		err := h.Unmarshal(frames[0])
+		if err != nil {
+			return err
+		}
+
+		if h.MPEG2 {
+			return fmt.Errorf("only MPEG-1 audio is supported")
+		}
+
+		w.mp3Checked[track] = struct{}{}
+	}
+
+	n := 0
+	for _, frame := range frames {
+		n += len(frame)
+	}
+
+	enc := make([]byte, n)
+	n = 0
+	for _, frame := range frames {
+		n += copy(enc[n:], frame)
+	}
+
+	return w.writeAudio(track, pts, enc)
+}
+
+func (w *mpegtsWriter) writeAC3(
+	track *mpegts.Track,
+	pts int64,
+	frame []byte,
+) error {
+	return w.writeAudio(track, pts, frame)
+}
+
+func (w *mpegtsWriter) isLeading(track *mpegts.Track) bool {
+	if !w.leadingFound {
+		w.leadingFound = true
+		w.leadingPID = track.PID
+		w.mux.SetPCRPID(track.PID)
+	}
+
+	return track.PID == w.leadingPID
+}
+
+func (w *mpegtsWriter) writeVideo(
+	track *mpegts.Track,
+	pts int64,
+	dts int64,
+	randomAccess bool,
+	data []byte,
+) error {
+	leading := w.isLeading(track)
+
+	var af *astits.PacketAdaptationField
+
+	if randomAccess {
+		af = &astits.PacketAdaptationField{}
+		af.RandomAccessIndicator = true
+	}
+
+	if leading {
+		if randomAccess || w.pcrCounter == 0 {
+			if af == nil {
+				af = &astits.PacketAdaptationField{}
+			}
+			af.HasPCR = true
+			af.PCR = &astits.ClockReference{Base: dts - mpegtsDTSPCRDiff}
+			w.pcrCounter = 3
+		}
+		w.pcrCounter--
+	}
+
+	oh := &astits.PESOptionalHeader{
+		MarkerBits: 2,
+	}
+
+	if dts == pts {
+		oh.PTSDTSIndicator = astits.PTSDTSIndicatorOnlyPTS
+		oh.PTS = &astits.ClockReference{Base: pts}
+	} else {
+		oh.PTSDTSIndicator = astits.PTSDTSIndicatorBothPresent
+		oh.DTS = &astits.ClockReference{Base: dts}
+		oh.PTS = &astits.ClockReference{Base: pts}
+	}
+
+	_, err := w.mux.WriteData(&astits.MuxerData{
+		PID:             track.PID,
+		AdaptationField: af,
+		PES: &astits.PESData{
+			Header: &astits.PESHeader{
+				OptionalHeader: oh,
+				StreamID:       mpegtsStreamIDVideo,
+			},
+			Data: data,
+		},
+	})
+	return err
+}
+
+func (w *mpegtsWriter) writeAudio(track *mpegts.Track, pts int64, data []byte) error {
+	leading := w.isLeading(track)
+
+	af := &astits.PacketAdaptationField{
+		RandomAccessIndicator: true,
+	}
+
+	if leading {
+		if w.pcrCounter == 0 {
+			af.HasPCR = true
+			af.PCR = &astits.ClockReference{Base: pts - mpegtsDTSPCRDiff}
+			w.pcrCounter = 3
+		}
+		w.pcrCounter--
+	}
+
+	_, err := w.mux.WriteData(&astits.MuxerData{
+		PID:             track.PID,
+		AdaptationField: af,
+		PES: &astits.PESData{
+			Header: &astits.PESHeader{
+				OptionalHeader: &astits.PESOptionalHeader{
+					MarkerBits:      2,
+					PTSDTSIndicator: astits.PTSDTSIndicatorOnlyPTS,
+					PTS:             &astits.ClockReference{Base: pts},
+				},
+				StreamID: mpegtsStreamIDAudio,
+			},
+			Data: data,
+		},
+	})
+	return err
+}
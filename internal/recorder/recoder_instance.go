@@ -4,14 +4,20 @@ import (
 	"strings"
 	"time"
 
+	rtspformat "github.com/bluenviron/gortsplib/v4/pkg/format"
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
 
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstorage"
 	"github.com/bluenviron/mediamtx/internal/recordstore"
 )
 
+const (
+	maxSegmentPathAttempts = 1000
+)
+
 type sample struct {
 	*fmp4.PartSample
 	dts time.Duration
@@ -24,6 +30,9 @@ type agentInstance struct {
 	pathFormat string
 	writer     *asyncwriter.Writer
 	format     format
+	dataTracks *dataTracks
+
+	lastSegmentPath string
 
 	terminate chan struct{}
 	done      chan struct{}
@@ -47,6 +56,13 @@ func (ai *agentInstance) initialize() {
 
 	ai.writer = asyncwriter.New(ai.agent.WriteQueueSize, ai.agent)
 
+	if ai.agent.DataTracks {
+		ai.dataTracks = &dataTracks{
+			ai: ai,
+		}
+		ai.dataTracks.initialize()
+	}
+
 	switch ai.agent.Format {
 	case conf.RecordFormatMPEGTS:
 		ai.format = &formatMPEGTS{
@@ -64,6 +80,52 @@ func (ai *agentInstance) initialize() {
 	go ai.run()
 }
 
+func (ai *agentInstance) isDataTrack(forma rtspformat.Format) bool {
+	return ai.dataTracks != nil && ai.dataTracks.handles(forma)
+}
+
+// segmentPath returns the path of a new segment.
+// When a segment with the same name already exists, as happens when the system clock
+// is moved backwards, the start date is increased until the name is free.
+func (ai *agentInstance) segmentPath(start time.Time) string {
+	step := time.Second
+	if strings.Contains(ai.pathFormat, "%f") {
+		step = time.Microsecond
+	}
+
+	checker, _ := ai.agent.Storage.(recordstorage.Checker)
+
+	for i := 0; ; i++ {
+		path := recordstore.Path{Start: start}.Encode(ai.pathFormat)
+
+		if i == maxSegmentPathAttempts ||
+			(path != ai.lastSegmentPath && (checker == nil || !checker.Exists(path))) {
+			if i != 0 {
+				ai.Log(logger.Warn, "a segment with the same name already exists, using %s", path)
+			}
+
+			ai.lastSegmentPath = path
+			return path
+		}
+
+		start = start.Add(step)
+	}
+}
+
+func (ai *agentInstance) onSegmentCreate(path string) {
+	if ai.dataTracks != nil {
+		ai.dataTracks.openFile(path)
+	}
+
+	ai.agent.OnSegmentCreate(path)
+}
+
+func (ai *agentInstance) onSegmentClose() {
+	if ai.dataTracks != nil {
+		ai.dataTracks.closeFile()
+	}
+}
+
 func (ai *agentInstance) close() {
 	close(ai.terminate)
 	<-ai.done
@@ -2,10 +2,14 @@
 package recorder
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstorage"
+	"github.com/bluenviron/mediamtx/internal/recordstore"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
@@ -15,13 +19,15 @@ type OnSegmentCreateFunc = func(path string)
 // OnSegmentCompleteFunc is the prototype of the function passed as OnSegmentComplete
 type OnSegmentCompleteFunc = func(path string, duration time.Duration)
 
-// Recorder writes recordings to disk.
+// Recorder writes recordings to a storage.
 type Recorder struct {
 	WriteQueueSize    int
 	PathFormat        string
 	Format            conf.RecordFormat
 	PartDuration      time.Duration
 	SegmentDuration   time.Duration
+	DataTracks        bool
+	Storage           recordstorage.Storage
 	PathName          string
 	Stream            *stream.Stream
 	OnSegmentCreate   OnSegmentCreateFunc
@@ -46,10 +52,17 @@ func (w *Recorder) Initialize() {
 		w.OnSegmentComplete = func(string, time.Duration) {
 		}
 	}
+	if w.Storage == nil {
+		w.Storage = &recordstorage.Local{}
+	}
 	if w.restartPause == 0 {
 		w.restartPause = 2 * time.Second
 	}
 
+	if l, ok := w.Storage.(*recordstorage.Local); ok {
+		w.recoverSegments(l)
+	}
+
 	w.terminate = make(chan struct{})
 	w.done = make(chan struct{})
 
@@ -61,6 +74,30 @@ func (w *Recorder) Initialize() {
 	go w.run()
 }
 
+// recoverSegments completes segments of the path that were left incomplete
+// by a previous run. They can't be still in use, since each path has a single recorder.
+func (w *Recorder) recoverSegments(l *recordstorage.Local) {
+	pathFormat := recordstore.PathAddExtension(
+		strings.ReplaceAll(w.PathFormat, "%path", w.PathName),
+		w.Format,
+	)
+
+	// paths returned by Walk() must share elements with pathFormat
+	pathFormat, _ = filepath.Abs(pathFormat)
+
+	recovered, err := l.Recover(recordstore.CommonPath(pathFormat), func(fpath string) bool {
+		var pa recordstore.Path
+		return pa.Decode(pathFormat, fpath)
+	})
+	if err != nil {
+		w.Log(logger.Warn, "unable to recover incomplete segments: %v", err)
+	}
+
+	for _, fpath := range recovered {
+		w.Log(logger.Warn, "recovered incomplete segment %s", fpath)
+	}
+}
+
 // Log implements logger.Writer.
 func (w *Recorder) Log(level logger.Level, format string, args ...interface{}) {
 	w.Parent.Log(level, "[recorder] "+format, args...)
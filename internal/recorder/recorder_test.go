@@ -1,21 +1,34 @@
 package recorder
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/jpeg"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/asticode/go-astits"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	rtspformat "github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/av1"
 	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
 	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4video"
 	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/recordstorage"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -128,6 +141,7 @@ func TestRecorder(t *testing.T) {
 				1460,
 				desc,
 				true,
+				formatprocessor.Options{},
 				test.NilLogger,
 			)
 			require.NoError(t, err)
@@ -312,6 +326,133 @@ func TestRecorder(t *testing.T) {
 	}
 }
 
+func TestRecorderDataTracks(t *testing.T) {
+	for _, ca := range []string{"fmp4", "mpegts"} {
+		t.Run(ca, func(t *testing.T) {
+			desc := &description.Session{Medias: []*description.Media{
+				{
+					Type: description.MediaTypeVideo,
+					Formats: []rtspformat.Format{&rtspformat.H264{
+						PayloadTyp:        96,
+						PacketizationMode: 1,
+					}},
+				},
+				{
+					Type: description.MediaTypeApplication,
+					Formats: []rtspformat.Format{&rtspformat.Generic{
+						PayloadTyp: 107,
+						RTPMa:      "vnd.onvif.metadata/90000",
+						ClockRat:   90000,
+					}},
+				},
+			}}
+
+			stream, err := stream.New(
+				1460,
+				desc,
+				false,
+				formatprocessor.Options{},
+				test.NilLogger,
+			)
+			require.NoError(t, err)
+			defer stream.Close()
+
+			dir, err := os.MkdirTemp("", "mediamtx-agent")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			recordPath := filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f")
+
+			var fo conf.RecordFormat
+			var ext string
+			if ca == "fmp4" {
+				fo = conf.RecordFormatFMP4
+				ext = ".mp4"
+			} else {
+				fo = conf.RecordFormatMPEGTS
+				ext = ".ts"
+			}
+
+			segCreated := make(chan struct{}, 1)
+
+			w := &Recorder{
+				WriteQueueSize:  1024,
+				PathFormat:      recordPath,
+				Format:          fo,
+				PartDuration:    100 * time.Millisecond,
+				SegmentDuration: 1 * time.Second,
+				DataTracks:      true,
+				PathName:        "mypath",
+				Stream:          stream,
+				OnSegmentCreate: func(_ string) {
+					select {
+					case segCreated <- struct{}{}:
+					default:
+					}
+				},
+				Parent: test.NilLogger,
+			}
+			w.Initialize()
+
+			for i := 0; i < 5; i++ {
+				for j, nalu := range [][]byte{
+					test.FormatH264.SPS,
+					test.FormatH264.PPS,
+					{5}, // IDR
+				} {
+					stream.WriteRTPPacket(desc.Medias[0], desc.Medias[0].Formats[0], &rtp.Packet{
+						Header: rtp.Header{
+							Version:        2,
+							Marker:         j == 2,
+							PayloadType:    96,
+							SequenceNumber: uint16(i*3 + j),
+						},
+						Payload: nalu,
+					}, time.Date(2008, 5, 20, 22, 15, 25, 0, time.UTC), time.Duration(i)*200*time.Millisecond)
+				}
+			}
+
+			select {
+			case <-segCreated:
+			case <-time.After(2 * time.Second):
+				t.Errorf("segment not created")
+			}
+
+			for i, payload := range []string{"<a>", "</a>"} {
+				stream.WriteRTPPacket(desc.Medias[1], desc.Medias[1].Formats[0], &rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         i == 1,
+						PayloadType:    107,
+						SequenceNumber: uint16(i),
+					},
+					Payload: []byte(payload),
+				}, time.Date(2008, 5, 20, 22, 15, 26, 0, time.UTC), 1*time.Second)
+			}
+
+			time.Sleep(50 * time.Millisecond)
+
+			w.Close()
+
+			_, err = os.Stat(filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000000"+ext))
+			require.NoError(t, err)
+
+			byts, err := os.ReadFile(filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000000.data.jsonl"))
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSuffix(string(byts), "\n"), "\n")
+			require.NotEmpty(t, lines)
+
+			var entry dataTrackEntry
+			err = json.Unmarshal([]byte(lines[0]), &entry)
+			require.NoError(t, err)
+			require.Equal(t, 2, entry.Track)
+			require.Equal(t, "vnd.onvif.metadata/90000", entry.RTPMap)
+			require.Equal(t, []byte("<a></a>"), entry.Payload)
+		})
+	}
+}
+
 func TestRecorderFMP4NegativeDTS(t *testing.T) {
 	desc := &description.Session{Medias: []*description.Media{
 		{
@@ -341,6 +482,7 @@ func TestRecorderFMP4NegativeDTS(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -410,6 +552,262 @@ func TestRecorderFMP4NegativeDTS(t *testing.T) {
 	require.Equal(t, true, found)
 }
 
+func TestRecorderFMP4MJPEGSizeChange(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []rtspformat.Format{&rtspformat.MJPEG{}},
+		},
+	}}
+
+	stream, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recordPath := filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f")
+
+	w := &Recorder{
+		WriteQueueSize:  1024,
+		PathFormat:      recordPath,
+		Format:          conf.RecordFormatFMP4,
+		PartDuration:    100 * time.Millisecond,
+		SegmentDuration: 1 * time.Hour,
+		PathName:        "mypath",
+		Stream:          stream,
+		Parent:          test.NilLogger,
+	}
+	w.Initialize()
+
+	for i, size := range [][2]int{{64, 48}, {64, 48}, {128, 96}, {128, 96}} {
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size[0], size[1])), nil)
+		require.NoError(t, err)
+
+		stream.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.MJPEG{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 100 * time.Millisecond,
+				NTP: time.Date(2008, 5, 20, 22, 15, 25, 0, time.UTC).Add(time.Duration(i) * time.Second),
+			},
+			Frame: buf.Bytes(),
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	w.Close()
+
+	for _, ca := range []struct {
+		fname  string
+		width  int
+		height int
+	}{
+		{"2008-05-20_22-15-25-000000.mp4", 64, 48},
+		{"2008-05-20_22-15-27-000000.mp4", 128, 96},
+	} {
+		byts, err := os.ReadFile(filepath.Join(dir, "mypath", ca.fname))
+		require.NoError(t, err)
+
+		var init fmp4.Init
+		err = init.Unmarshal(bytes.NewReader(byts))
+		require.NoError(t, err)
+
+		require.Equal(t, &fmp4.CodecMJPEG{
+			Width:  ca.width,
+			Height: ca.height,
+		}, init.Tracks[0].Codec)
+	}
+}
+
+func TestRecorderFMP4VP9(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []rtspformat.Format{&rtspformat.VP9{PayloadTyp: 96}},
+		},
+	}}
+
+	stream, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recordPath := filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f")
+
+	w := &Recorder{
+		WriteQueueSize:  1024,
+		PathFormat:      recordPath,
+		Format:          conf.RecordFormatFMP4,
+		PartDuration:    100 * time.Millisecond,
+		SegmentDuration: 1 * time.Hour,
+		PathName:        "mypath",
+		Stream:          stream,
+		Parent:          test.NilLogger,
+	}
+	w.Initialize()
+
+	keyFrame := []byte{
+		0x82, 0x49, 0x83, 0x42, 0x00, 0x77, 0xf0, 0x32,
+		0x34, 0x30, 0x38, 0x24, 0x1c, 0x19, 0x40, 0x18,
+		0x03, 0x40, 0x5f, 0xb4,
+	}
+	nonKeyFrame := []byte{0x86, 0x00, 0x00, 0x00}
+
+	// frames that precede the first key frame are discarded.
+	for i, frame := range [][]byte{nonKeyFrame, keyFrame, nonKeyFrame, nonKeyFrame} {
+		stream.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.VP9{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 100 * time.Millisecond,
+				NTP: time.Date(2008, 5, 20, 22, 15, 25, 0, time.UTC).Add(time.Duration(i) * time.Second),
+			},
+			Frame: frame,
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	w.Close()
+
+	byts, err := os.ReadFile(filepath.Join(dir, "mypath", "2008-05-20_22-15-26-000000.mp4"))
+	require.NoError(t, err)
+
+	var init fmp4.Init
+	err = init.Unmarshal(bytes.NewReader(byts))
+	require.NoError(t, err)
+
+	require.Equal(t, &fmp4.CodecVP9{
+		Width:             1920,
+		Height:            804,
+		Profile:           0,
+		BitDepth:          8,
+		ChromaSubsampling: 1,
+		ColorRange:        false,
+	}, init.Tracks[0].Codec)
+}
+
+func TestRecorderMPEGTSMPEG4VideoBFrames(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []rtspformat.Format{&rtspformat.MPEG4Video{PayloadTyp: 96}},
+		},
+	}}
+
+	stream, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recordPath := filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f")
+
+	w := &Recorder{
+		WriteQueueSize:  1024,
+		PathFormat:      recordPath,
+		Format:          conf.RecordFormatMPEGTS,
+		PartDuration:    100 * time.Millisecond,
+		SegmentDuration: 1 * time.Hour,
+		PathName:        "mypath",
+		Stream:          stream,
+		Parent:          test.NilLogger,
+	}
+	w.Initialize()
+
+	vop := func(codingType byte) []byte {
+		return []byte{0, 0, 1, byte(mpeg4video.VOPStartCode), codingType << 6, 1, 2, 3}
+	}
+	iFrame := append([]byte{0, 0, 1, byte(mpeg4video.GroupOfVOPStartCode), 0x10, 0x20}, vop(0)...)
+
+	for _, frame := range []struct {
+		pts   time.Duration
+		frame []byte
+	}{
+		{1000 * time.Millisecond, iFrame},
+		{1120 * time.Millisecond, vop(1)},
+		{1040 * time.Millisecond, vop(2)},
+		{1080 * time.Millisecond, vop(2)},
+		{1240 * time.Millisecond, vop(1)},
+	} {
+		stream.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.MPEG4Video{
+			Base: unit.Base{
+				PTS: frame.pts,
+				NTP: time.Date(2008, 5, 20, 22, 15, 25, 0, time.UTC),
+			},
+			Frame: frame.frame,
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	w.Close()
+
+	f, err := os.Open(filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000000.ts"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	type timestamps struct {
+		pts int64
+		dts int64
+	}
+
+	var tss []timestamps
+
+	dem := astits.NewDemuxer(context.Background(), f)
+
+	for {
+		data, err := dem.NextData()
+		if errors.Is(err, astits.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+
+		if data.PES == nil {
+			continue
+		}
+
+		oh := data.PES.Header.OptionalHeader
+		ts := timestamps{pts: oh.PTS.Base, dts: oh.PTS.Base}
+		if oh.DTS != nil {
+			ts.dts = oh.DTS.Base
+		}
+		tss = append(tss, ts)
+	}
+
+	require.Equal(t, []timestamps{
+		{90000, 89910},
+		{100800, 90000},
+		{93600, 93600},
+		{97200, 97200},
+		{111600, 100800},
+	}, tss)
+}
+
 func TestRecorderSkipTracks(t *testing.T) {
 	for _, ca := range []string{"fmp4", "mpegts"} {
 		t.Run(ca, func(t *testing.T) {
@@ -428,6 +826,7 @@ func TestRecorderSkipTracks(t *testing.T) {
 				1460,
 				desc,
 				true,
+				formatprocessor.Options{},
 				test.NilLogger,
 			)
 			require.NoError(t, err)
@@ -473,3 +872,201 @@ func TestRecorderSkipTracks(t *testing.T) {
 		})
 	}
 }
+
+func TestRecorderRecoverSegments(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Formats: []rtspformat.Format{&rtspformat.H264{}},
+		},
+	}}
+
+	stream, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	orphan := filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000000.mp4")
+
+	err = os.WriteFile(recordstorage.LocalTempPath(orphan), []byte{1}, 0o644)
+	require.NoError(t, err)
+
+	var logs []string
+
+	w := &Recorder{
+		WriteQueueSize:  1024,
+		PathFormat:      filepath.Join(dir, "%path/%Y-%m-%d_%H-%M-%S-%f"),
+		Format:          conf.RecordFormatFMP4,
+		PartDuration:    100 * time.Millisecond,
+		SegmentDuration: 1 * time.Hour,
+		PathName:        "mypath",
+		Stream:          stream,
+		Parent: test.Logger(func(_ logger.Level, format string, args ...interface{}) {
+			logs = append(logs, fmt.Sprintf(format, args...))
+		}),
+	}
+	w.Initialize()
+	w.Close()
+
+	_, err = os.Stat(orphan)
+	require.NoError(t, err)
+
+	require.Contains(t, logs, "[recorder] recovered incomplete segment "+orphan)
+}
+
+func TestRecorderSegmentPathCollision(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ai := &agentInstance{
+		agent: &Recorder{
+			Storage: &recordstorage.Local{},
+			Parent:  test.NilLogger,
+		},
+		pathFormat: filepath.Join(dir, "mypath", "%Y-%m-%d_%H-%M-%S-%f.mp4"),
+	}
+
+	start := time.Date(2008, 5, 20, 22, 15, 25, 0, time.Local)
+
+	err = os.MkdirAll(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000000.mp4"), []byte{1}, 0o644)
+	require.NoError(t, err)
+
+	path := ai.segmentPath(start)
+	require.Equal(t, filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000001.mp4"), path)
+
+	// the previous segment has not been written yet
+	path = ai.segmentPath(start)
+	require.Equal(t, filepath.Join(dir, "mypath", "2008-05-20_22-15-25-000002.mp4"), path)
+}
+
+func TestMPEGVideoDTSExtractor(t *testing.T) {
+	type frame struct {
+		pts      time.Duration
+		isBFrame bool
+		dts      time.Duration
+		ok       bool
+	}
+
+	for _, ca := range []struct {
+		name   string
+		frames []frame
+	}{
+		{
+			"closed gop",
+			[]frame{
+				{0, false, -1 * time.Millisecond, true},
+				{120 * time.Millisecond, false, 0, true},
+				{40 * time.Millisecond, true, 40 * time.Millisecond, true},
+				{80 * time.Millisecond, true, 80 * time.Millisecond, true},
+				{240 * time.Millisecond, false, 120 * time.Millisecond, true},
+				{160 * time.Millisecond, true, 160 * time.Millisecond, true},
+				{200 * time.Millisecond, true, 200 * time.Millisecond, true},
+			},
+		},
+		{
+			"open gop",
+			[]frame{
+				{80 * time.Millisecond, false, 79 * time.Millisecond, true},
+				{0, true, 0, false},
+				{40 * time.Millisecond, true, 0, false},
+				{200 * time.Millisecond, false, 80 * time.Millisecond, true},
+				{120 * time.Millisecond, true, 120 * time.Millisecond, true},
+				{160 * time.Millisecond, true, 160 * time.Millisecond, true},
+			},
+		},
+		{
+			"no b-frames",
+			[]frame{
+				{0, false, -1 * time.Millisecond, true},
+				{40 * time.Millisecond, false, 0, true},
+				{80 * time.Millisecond, false, 40 * time.Millisecond, true},
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var ex mpegVideoDTSExtractor
+
+			for _, f := range ca.frames {
+				dts, ok, err := ex.extract(f.pts, f.isBFrame)
+				require.NoError(t, err)
+				require.Equal(t, f.ok, ok)
+				if ok {
+					require.Equal(t, f.dts, dts)
+				}
+			}
+		})
+	}
+}
+
+func TestAV1ContainsKeyFrame(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		tu   [][]byte
+		key  bool
+	}{
+		{
+			"key frame",
+			[][]byte{{0x32, 0x01, 0x10}},
+			true,
+		},
+		{
+			"inter frame",
+			[][]byte{{0x32, 0x01, 0x30}},
+			false,
+		},
+		{
+			"key frame with extension header",
+			[][]byte{{0x34, 0x28, 0x10}},
+			true,
+		},
+		{
+			"shown existing frame",
+			[][]byte{{0x1a, 0x01, 0x80}},
+			false,
+		},
+		{
+			"no frame",
+			[][]byte{{0x12, 0x00}},
+			false,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			key, err := av1ContainsKeyFrame(ca.tu, &av1.SequenceHeader{})
+			require.NoError(t, err)
+			require.Equal(t, ca.key, key)
+		})
+	}
+}
+
+func TestAV1BitstreamMarshal(t *testing.T) {
+	obus, err := av1ParseTemporalUnit([][]byte{
+		// temporal delimiter
+		{0x12, 0x00},
+		// frame with extension header and without size field
+		{0x34, 0x28, 0x10, 0x20},
+		// frame with size field
+		{0x32, 0x02, 0x30, 0x40},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []byte{
+		0x36, 0x28, 0x02, 0x10, 0x20,
+		0x32, 0x02, 0x30, 0x40,
+	}, av1BitstreamMarshal(obus))
+}
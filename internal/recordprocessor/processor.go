@@ -0,0 +1,110 @@
+// Package recordprocessor contains the post-processor of recording segments.
+package recordprocessor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	queueSize = 1024
+)
+
+type job struct {
+	cmdstr      string
+	env         externalcmd.Environment
+	segmentPath string
+}
+
+// Processor remuxes or transcodes completed recording segments through external commands.
+// Commands are queued and at most Concurrency of them are run at the same time,
+// in order to avoid saturating the machine with encoders.
+type Processor struct {
+	Concurrency     int
+	ExternalCmdPool *externalcmd.Pool
+	Parent          logger.Writer
+
+	ctx       context.Context
+	ctxCancel func()
+	wg        sync.WaitGroup
+	queue     chan job
+}
+
+// Initialize initializes Processor.
+func (p *Processor) Initialize() {
+	p.ctx, p.ctxCancel = context.WithCancel(context.Background())
+	p.queue = make(chan job, queueSize)
+
+	for i := 0; i < p.Concurrency; i++ {
+		p.wg.Add(1)
+		go p.runWorker()
+	}
+}
+
+// Close closes Processor. Running commands are terminated and queued ones are discarded.
+func (p *Processor) Close() {
+	p.ctxCancel()
+	p.wg.Wait()
+}
+
+// Log implements logger.Writer.
+func (p *Processor) Log(level logger.Level, format string, args ...interface{}) {
+	p.Parent.Log(level, "[record processor] "+format, args...)
+}
+
+// Process enqueues the post-processing of a segment.
+func (p *Processor) Process(cmdstr string, env externalcmd.Environment, segmentPath string) {
+	select {
+	case p.queue <- job{
+		cmdstr:      cmdstr,
+		env:         env,
+		segmentPath: segmentPath,
+	}:
+	default:
+		p.Log(logger.Warn, "queue is full, discarding post-processing of %s", segmentPath)
+	}
+}
+
+func (p *Processor) runWorker() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case j := <-p.queue:
+			p.runJob(j)
+
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Processor) runJob(j job) {
+	p.Log(logger.Debug, "post-processing %s", j.segmentPath)
+
+	failed := false
+
+	cmd := externalcmd.NewCmd(
+		p.ExternalCmdPool,
+		j.cmdstr,
+		false,
+		j.env,
+		func(err error) {
+			failed = true
+			p.Log(logger.Warn, "post-processing of %s failed: %v", j.segmentPath, err)
+		})
+
+	select {
+	case <-cmd.Done():
+		if !failed {
+			p.Log(logger.Debug, "post-processing of %s completed", j.segmentPath)
+		}
+
+	case <-p.ctx.Done():
+		cmd.Close()
+		<-cmd.Done()
+	}
+}
@@ -0,0 +1,53 @@
+package recordprocessor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorConcurrency(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recordprocessor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outPath := filepath.Join(dir, "out.txt")
+
+	pool := externalcmd.NewPool()
+	defer pool.Close()
+
+	p := &Processor{
+		Concurrency:     1,
+		ExternalCmdPool: pool,
+		Parent:          test.NilLogger,
+	}
+	p.Initialize()
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		p.Process("sh -c 'echo start >> $OUT_PATH; sleep 0.1; echo end >> $OUT_PATH'",
+			externalcmd.Environment{"OUT_PATH": outPath},
+			"segment.mp4")
+	}
+
+	var lines []string
+
+	for i := 0; i < 50; i++ {
+		byts, err := os.ReadFile(outPath)
+		if err == nil {
+			lines = strings.Split(strings.TrimSpace(string(byts)), "\n")
+			if len(lines) == 6 {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	require.Equal(t, []string{"start", "end", "start", "end", "start", "end"}, lines)
+}
@@ -0,0 +1,115 @@
+package recordstorage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalTempPath returns the path where a file is written before being completed.
+func LocalTempPath(fpath string) string {
+	return filepath.Join(filepath.Dir(fpath), "."+filepath.Base(fpath)+".tmp")
+}
+
+// localFinalPath returns the path of a file written with LocalTempPath.
+func localFinalPath(tmpPath string) (string, bool) {
+	base := filepath.Base(tmpPath)
+	if len(base) <= len("..tmp") || !strings.HasPrefix(base, ".") || !strings.HasSuffix(base, ".tmp") {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(tmpPath), base[1:len(base)-len(".tmp")]), true
+}
+
+type localWriter struct {
+	*os.File
+	fpath string
+}
+
+// Close implements io.Closer.
+func (w *localWriter) Close() error {
+	err := w.File.Close()
+	if err != nil {
+		os.Remove(w.File.Name())
+		return err
+	}
+
+	return os.Rename(w.File.Name(), w.fpath)
+}
+
+// Local is a storage that writes files on the local file system.
+// Files are written with a temporary name and renamed once complete,
+// in order to prevent other processes from reading incomplete files.
+type Local struct{}
+
+// Create implements Storage.
+func (*Local) Create(fpath string) (io.WriteCloser, error) {
+	err := os.MkdirAll(filepath.Dir(fpath), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(LocalTempPath(fpath))
+	if err != nil {
+		return nil, err
+	}
+
+	return &localWriter{
+		File:  f,
+		fpath: fpath,
+	}, nil
+}
+
+// Recover renames files that were left incomplete inside dir,
+// for instance because the server was killed, to their final path.
+// Only files whose final path is accepted by match are recovered.
+// Files whose final path is already in use are left untouched.
+func (*Local) Recover(dir string, match func(fpath string) bool) ([]string, error) {
+	var recovered []string
+
+	err := filepath.Walk(dir, func(fpath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		finalPath, ok := localFinalPath(fpath)
+		if !ok || !match(finalPath) {
+			return nil
+		}
+
+		if _, err2 := os.Stat(finalPath); !errors.Is(err2, os.ErrNotExist) {
+			return nil
+		}
+
+		err = os.Rename(fpath, finalPath)
+		if err != nil {
+			return err
+		}
+
+		recovered = append(recovered, finalPath)
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return recovered, err
+	}
+
+	return recovered, nil
+}
+
+// Exists implements Checker.
+func (*Local) Exists(fpath string) bool {
+	_, err := os.Stat(fpath)
+	if err == nil {
+		return true
+	}
+
+	// the file may be still being written
+	_, err = os.Stat(LocalTempPath(fpath))
+	return !errors.Is(err, os.ErrNotExist)
+}
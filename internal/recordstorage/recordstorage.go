@@ -0,0 +1,56 @@
+// Package recordstorage contains storage backends of recordings.
+package recordstorage
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+)
+
+// Storage is a backend where recording segments are written.
+type Storage interface {
+	// Create creates a file. The file is complete once closed.
+	Create(fpath string) (io.WriteCloser, error)
+}
+
+// Checker is implemented by storages that are able to check whether a file exists.
+type Checker interface {
+	// Exists returns whether a file exists.
+	Exists(fpath string) bool
+}
+
+// New allocates the storage backend of a path.
+func New(pathConf *conf.Path, readTimeout time.Duration, writeTimeout time.Duration) Storage {
+	switch pathConf.RecordStorage {
+	case conf.RecordStorageS3:
+		return &S3{
+			Endpoint:        pathConf.RecordS3Endpoint,
+			Region:          pathConf.RecordS3Region,
+			Bucket:          pathConf.RecordS3Bucket,
+			AccessKeyID:     pathConf.RecordS3AccessKeyID,
+			SecretAccessKey: pathConf.RecordS3SecretAccessKey,
+			ReadTimeout:     readTimeout,
+			WriteTimeout:    writeTimeout,
+		}
+
+	case conf.RecordStorageWebDAV:
+		return &WebDAV{
+			URL:          pathConf.RecordWebDAVURL,
+			User:         pathConf.RecordWebDAVUser,
+			Pass:         pathConf.RecordWebDAVPass,
+			ReadTimeout:  readTimeout,
+			WriteTimeout: writeTimeout,
+		}
+
+	default:
+		return &Local{}
+	}
+}
+
+// objectKey converts a file path into the key of a remote object.
+func objectKey(fpath string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(fpath)), "/")
+}
@@ -0,0 +1,314 @@
+package recordstorage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recordstorage")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fpath := filepath.Join(dir, "mypath", "2009-05-20_22-15-25-000000.mp4")
+
+	w, err := (&Local{}).Create(fpath)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("testing"))
+	require.NoError(t, err)
+
+	// incomplete files are not visible
+	_, err = os.Stat(fpath)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.True(t, (&Local{}).Exists(fpath))
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	_, err = os.Stat(LocalTempPath(fpath))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	byts, err := os.ReadFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, []byte("testing"), byts)
+}
+
+func TestLocalRecover(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-recordstorage")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "mypath"), 0o755)
+	require.NoError(t, err)
+
+	orphan := filepath.Join(dir, "mypath", "2009-05-20_22-15-25-000000.mp4")
+	other := filepath.Join(dir, "mypath", "other.mp4")
+	existing := filepath.Join(dir, "mypath", "2009-05-20_22-16-25-000000.mp4")
+
+	for _, fpath := range []string{orphan, other, existing} {
+		err = os.WriteFile(LocalTempPath(fpath), []byte("testing"), 0o644)
+		require.NoError(t, err)
+	}
+
+	err = os.WriteFile(existing, []byte("complete"), 0o644)
+	require.NoError(t, err)
+
+	recovered, err := (&Local{}).Recover(dir, func(fpath string) bool {
+		return fpath != other
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{orphan}, recovered)
+
+	byts, err := os.ReadFile(orphan)
+	require.NoError(t, err)
+	require.Equal(t, []byte("testing"), byts)
+
+	// files that don't match are left untouched
+	_, err = os.Stat(LocalTempPath(other))
+	require.NoError(t, err)
+
+	// complete files are not overwritten
+	byts, err = os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, []byte("complete"), byts)
+
+	// non-existing directories are not an error
+	recovered, err = (&Local{}).Recover(filepath.Join(dir, "missing"), func(string) bool { return true })
+	require.NoError(t, err)
+	require.Empty(t, recovered)
+}
+
+func TestS3(t *testing.T) {
+	for _, ca := range []string{"single", "multipart"} {
+		t.Run(ca, func(t *testing.T) {
+			var mutex sync.Mutex
+			parts := make(map[string][]byte)
+			var object []byte
+
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				require.Equal(t, "/mybucket/recordings/mypath/2009-05-20_22-15-25-000000.mp4", r.URL.Path)
+				require.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+					"AWS4-HMAC-SHA256 Credential=myaccesskey/"))
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				q := r.URL.Query()
+
+				switch {
+				case r.Method == http.MethodPut && q.Get("partNumber") != "":
+					require.Equal(t, "myupload", q.Get("uploadId"))
+					parts[q.Get("partNumber")] = body
+					w.Header().Set("ETag", `"etag`+q.Get("partNumber")+`"`)
+
+				case r.Method == http.MethodPut:
+					object = body
+
+				case r.Method == http.MethodPost && q.Has("uploads"):
+					w.Write([]byte("<InitiateMultipartUploadResult><UploadId>myupload</UploadId>" + //nolint:errcheck
+						"</InitiateMultipartUploadResult>"))
+
+				case r.Method == http.MethodPost:
+					require.Equal(t, "myupload", q.Get("uploadId"))
+
+					var req s3CompleteMultipartUpload
+					err = xml.Unmarshal(body, &req)
+					require.NoError(t, err)
+
+					for _, part := range req.Parts {
+						require.Equal(t, `"etag`+strconv.Itoa(part.PartNumber)+`"`, part.ETag)
+						object = append(object, parts[strconv.Itoa(part.PartNumber)]...)
+					}
+
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+				}
+			}))
+			defer s.Close()
+
+			st := &S3{
+				Endpoint:        s.URL,
+				Bucket:          "mybucket",
+				AccessKeyID:     "myaccesskey",
+				SecretAccessKey: "mysecretkey",
+			}
+
+			w, err := st.Create("./recordings/mypath/2009-05-20_22-15-25-000000.mp4")
+			require.NoError(t, err)
+
+			var payload []byte
+			if ca == "single" {
+				payload = []byte("testing")
+			} else {
+				payload = bytes.Repeat([]byte{1, 2, 3, 4}, (s3MinPartSize*2+1000)/4)
+			}
+
+			for i := 0; i < len(payload); i += 1000 {
+				end := i + 1000
+				if end > len(payload) {
+					end = len(payload)
+				}
+				_, err = w.Write(payload[i:end])
+				require.NoError(t, err)
+			}
+
+			err = w.Close()
+			require.NoError(t, err)
+
+			require.Equal(t, payload, object)
+
+			if ca == "multipart" {
+				require.Equal(t, 3, len(parts))
+			}
+		})
+	}
+}
+
+func TestWebDAV(t *testing.T) {
+	var collections []string
+	var object []byte
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "myuser", user)
+		require.Equal(t, "mypass", pass)
+
+		switch r.Method {
+		case "MKCOL":
+			collections = append(collections, r.URL.Path)
+			if r.URL.Path == "/dav/recordings/" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			} else {
+				w.WriteHeader(http.StatusCreated)
+			}
+
+		case http.MethodPut:
+			require.Equal(t, "/dav/recordings/my path/2009-05-20_22-15-25-000000.ts", r.URL.Path)
+
+			var err error
+			object, err = io.ReadAll(r.Body)
+			require.NoError(t, err)
+			w.WriteHeader(http.StatusCreated)
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+	}))
+	defer s.Close()
+
+	st := &WebDAV{
+		URL:  s.URL + "/dav/",
+		User: "myuser",
+		Pass: "mypass",
+	}
+
+	w, err := st.Create("./recordings/my path/2009-05-20_22-15-25-000000.ts")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("test"))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("ing"))
+	require.NoError(t, err)
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"/dav/recordings/", "/dav/recordings/my path/"}, collections)
+	require.Equal(t, []byte("testing"), object)
+}
+
+func TestRemoteTimeout(t *testing.T) {
+	for _, ca := range []string{"s3", "webdav mkcol", "webdav put"} {
+		t.Run(ca, func(t *testing.T) {
+			// the server accepts connections but never replies
+			ln, err := net.Listen("tcp", "localhost:0")
+			require.NoError(t, err)
+			defer ln.Close()
+
+			var mutex sync.Mutex
+			var conns []net.Conn
+
+			defer func() {
+				mutex.Lock()
+				defer mutex.Unlock()
+				for _, c := range conns {
+					c.Close()
+				}
+			}()
+
+			go func() {
+				for {
+					c, err2 := ln.Accept()
+					if err2 != nil {
+						return
+					}
+					mutex.Lock()
+					conns = append(conns, c)
+					mutex.Unlock()
+				}
+			}()
+
+			var st Storage
+			fpath := "2009-05-20_22-15-25-000000.ts"
+
+			switch ca {
+			case "s3":
+				st = &S3{
+					Endpoint:     "http://" + ln.Addr().String(),
+					Bucket:       "mybucket",
+					ReadTimeout:  500 * time.Millisecond,
+					WriteTimeout: 500 * time.Millisecond,
+				}
+
+			case "webdav mkcol":
+				fpath = "mypath/" + fpath
+				fallthrough
+
+			default:
+				st = &WebDAV{
+					URL:          "http://" + ln.Addr().String() + "/dav/",
+					ReadTimeout:  500 * time.Millisecond,
+					WriteTimeout: 500 * time.Millisecond,
+				}
+			}
+
+			start := time.Now()
+
+			err = func() error {
+				w, err2 := st.Create(fpath)
+				if err2 != nil {
+					return err2
+				}
+
+				_, err2 = w.Write([]byte("testing"))
+				if err2 != nil {
+					w.Close()
+					return err2
+				}
+
+				return w.Close()
+			}()
+			require.Error(t, err)
+			require.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package recordstorage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// minimum size of parts allowed by S3, except the last one.
+	s3MinPartSize = 5 * 1024 * 1024
+
+	s3DefaultRegion = "us-east-1"
+)
+
+var timeNow = time.Now
+
+type s3InitiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// s3Escape encodes a string as required by AWS Signature Version 4.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = s3Escape(k, false) + "=" + s3Escape(query.Get(k), false)
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// S3 is a storage that uploads files to a S3-compatible object storage.
+// Files are streamed through multipart uploads, therefore they don't need to
+// be buffered entirely in memory or on disk.
+type S3 struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PartSize        int
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+}
+
+// Create implements Storage.
+func (s *S3) Create(fpath string) (io.WriteCloser, error) {
+	return &s3Writer{
+		s:   s,
+		key: objectKey(fpath),
+	}, nil
+}
+
+func (s *S3) partSize() int {
+	if s.PartSize < s3MinPartSize {
+		return s3MinPartSize
+	}
+	return s.PartSize
+}
+
+func (s *S3) region() string {
+	if s.Region == "" {
+		return s3DefaultRegion
+	}
+	return s.Region
+}
+
+func (s *S3) httpClient() *http.Client {
+	// each request sends a part and then waits for the response.
+	return &http.Client{
+		Timeout: s.WriteTimeout + s.ReadTimeout,
+	}
+}
+
+func (s *S3) sign(req *http.Request, payloadHash string) {
+	amzDate := timeNow().UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	if s.AccessKeyID == "" {
+		return
+	}
+
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region() + "/s3/aws4_request"
+
+	stringToSign := "AWS4-HMAC-SHA256\n" +
+		amzDate + "\n" +
+		scope + "\n" +
+		hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.region())
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+hex.EncodeToString(hmacSHA256(key, stringToSign)))
+}
+
+func (s *S3) do(method string, key string, query url.Values, body []byte) (http.Header, []byte, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u.Path = "/" + s.Bucket + "/" + key
+	u.RawPath = "/" + s3Escape(s.Bucket, false) + "/" + s3Escape(key, true)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.sign(req, hexSHA256(body))
+
+	res, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("bad status code: %d (%s)", res.StatusCode, strings.TrimSpace(string(resBody)))
+	}
+
+	// some errors are returned with a 200 status code
+	if bytes.Contains(resBody, []byte("<Error>")) {
+		var serr s3Error
+		err = xml.Unmarshal(resBody, &serr)
+		if err == nil {
+			return nil, nil, fmt.Errorf("%s: %s", serr.Code, serr.Message)
+		}
+	}
+
+	return res.Header, resBody, nil
+}
+
+type s3Writer struct {
+	s   *S3
+	key string
+
+	buf      []byte
+	uploadID string
+	parts    []s3CompletedPart
+}
+
+// Write implements io.Writer.
+func (w *s3Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	if len(w.buf) >= w.s.partSize() {
+		err := w.uploadPart()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close implements io.Closer.
+func (w *s3Writer) Close() error {
+	// files smaller than a part are uploaded with a single request
+	if w.uploadID == "" {
+		_, _, err := w.s.do(http.MethodPut, w.key, nil, w.buf)
+		return err
+	}
+
+	if len(w.buf) != 0 {
+		err := w.uploadPart()
+		if err != nil {
+			w.abort()
+			return err
+		}
+	}
+
+	byts, err := xml.Marshal(s3CompleteMultipartUpload{Parts: w.parts})
+	if err != nil {
+		w.abort()
+		return err
+	}
+
+	_, _, err = w.s.do(http.MethodPost, w.key, url.Values{"uploadId": []string{w.uploadID}}, byts)
+	if err != nil {
+		w.abort()
+		return err
+	}
+
+	return nil
+}
+
+func (w *s3Writer) uploadPart() error {
+	if w.uploadID == "" {
+		_, body, err := w.s.do(http.MethodPost, w.key, url.Values{"uploads": []string{""}}, nil)
+		if err != nil {
+			return err
+		}
+
+		var res s3InitiateMultipartUploadResult
+		err = xml.Unmarshal(body, &res)
+		if err != nil {
+			return err
+		}
+
+		if res.UploadID == "" {
+			return fmt.Errorf("upload ID not provided")
+		}
+
+		w.uploadID = res.UploadID
+	}
+
+	partNumber := len(w.parts) + 1
+
+	header, _, err := w.s.do(http.MethodPut, w.key, url.Values{
+		"partNumber": []string{strconv.FormatInt(int64(partNumber), 10)},
+		"uploadId":   []string{w.uploadID},
+	}, w.buf)
+	if err != nil {
+		return err
+	}
+
+	w.parts = append(w.parts, s3CompletedPart{
+		PartNumber: partNumber,
+		ETag:       header.Get("ETag"),
+	})
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
+func (w *s3Writer) abort() {
+	w.s.do(http.MethodDelete, w.key, url.Values{"uploadId": []string{w.uploadID}}, nil) //nolint:errcheck
+}
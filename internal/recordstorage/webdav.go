@@ -0,0 +1,171 @@
+package recordstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func webDAVEscape(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// WebDAV is a storage that uploads files to a WebDAV server.
+// Files are streamed to the server while they are written.
+type WebDAV struct {
+	URL          string
+	User         string
+	Pass         string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// Create implements Storage.
+func (d *WebDAV) Create(fpath string) (io.WriteCloser, error) {
+	key := objectKey(fpath)
+	base := strings.TrimSuffix(d.URL, "/")
+
+	// create parent collections, since the server doesn't create them automatically
+	dirs := strings.Split(key, "/")
+	for i := 1; i < len(dirs); i++ {
+		err := d.mkcol(base + "/" + webDAVEscape(strings.Join(dirs[:i], "/")) + "/")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pr, pw := io.Pipe()
+
+	// the upload lasts as long as the file is written, therefore
+	// timeouts are applied to every write and to the final response.
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/"+webDAVEscape(key), pr)
+	if err != nil {
+		ctxCancel()
+		return nil, err
+	}
+
+	if d.User != "" {
+		req.SetBasicAuth(d.User, d.Pass)
+	}
+
+	w := &webDAVWriter{
+		d:         d,
+		pw:        pw,
+		ctxCancel: ctxCancel,
+		done:      make(chan error, 1),
+	}
+
+	go func() {
+		err := d.do(req)
+
+		// unblock pending writes
+		if err != nil {
+			pr.CloseWithError(err)
+		} else {
+			pr.Close()
+		}
+
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+func (d *WebDAV) httpClient() *http.Client {
+	return &http.Client{
+		Timeout: d.WriteTimeout + d.ReadTimeout,
+	}
+}
+
+func (d *WebDAV) mkcol(u string) error {
+	req, err := http.NewRequest("MKCOL", u, nil)
+	if err != nil {
+		return err
+	}
+
+	if d.User != "" {
+		req.SetBasicAuth(d.User, d.Pass)
+	}
+
+	res, err := d.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// 405 is returned when the collection already exists
+	if res.StatusCode != http.StatusMethodNotAllowed &&
+		(res.StatusCode < 200 || res.StatusCode > 299) {
+		return fmt.Errorf("unable to create collection '%s': bad status code: %d", u, res.StatusCode)
+	}
+
+	return nil
+}
+
+func (d *WebDAV) do(req *http.Request) error {
+	res, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("bad status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+type webDAVWriter struct {
+	d         *WebDAV
+	pw        *io.PipeWriter
+	ctxCancel func()
+	done      chan error
+}
+
+// Write implements io.Writer.
+func (w *webDAVWriter) Write(p []byte) (int, error) {
+	if w.d.WriteTimeout != 0 {
+		t := time.AfterFunc(w.d.WriteTimeout, func() {
+			w.ctxCancel()
+			w.pw.CloseWithError(fmt.Errorf("write timed out"))
+		})
+		defer t.Stop()
+	}
+
+	return w.pw.Write(p)
+}
+
+// Close implements io.Closer.
+func (w *webDAVWriter) Close() error {
+	defer w.ctxCancel()
+
+	w.pw.Close()
+
+	if w.d.ReadTimeout == 0 {
+		return <-w.done
+	}
+
+	t := time.NewTimer(w.d.ReadTimeout)
+	defer t.Stop()
+
+	select {
+	case err := <-w.done:
+		return err
+
+	case <-t.C:
+		w.ctxCancel()
+		<-w.done
+		return fmt.Errorf("response timed out")
+	}
+}
@@ -1,6 +1,7 @@
 package recordstore
 
 import (
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -198,6 +199,16 @@ func (p *Path) Decode(format string, v string) bool {
 	return true
 }
 
+// SubtitlesPath returns the path of the subtitle file of a segment.
+func SubtitlesPath(segmentPath string) string {
+	return strings.TrimSuffix(segmentPath, filepath.Ext(segmentPath)) + ".vtt"
+}
+
+// DataTracksPath returns the path of the data track file of a segment.
+func DataTracksPath(segmentPath string) string {
+	return strings.TrimSuffix(segmentPath, filepath.Ext(segmentPath)) + ".data.jsonl"
+}
+
 // Encode encodes a path.
 func (p Path) Encode(format string) string {
 	format = strings.ReplaceAll(format, "%path", p.Path)
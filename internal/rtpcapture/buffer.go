@@ -0,0 +1,168 @@
+// Package rtpcapture contains a ring buffer of RTP packets, that can be exported in pcap format.
+package rtpcapture
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// maximum size of RTP packets kept in the buffer,
+	// regardless of their duration.
+	maxBufferSize = 64 * 1024 * 1024
+)
+
+// ErrDisabled is returned when the capture is disabled.
+var ErrDisabled = errors.New("capture is disabled on this path")
+
+var timeNow = time.Now
+
+type entry struct {
+	ntp   time.Time
+	media int
+	byts  []byte
+}
+
+// Buffer keeps the RTP packets received in the last Duration.
+// It keeps its content when the stream is detached,
+// in order to allow to inspect packets received before a failure.
+type Buffer struct {
+	Duration       time.Duration
+	WriteQueueSize int
+	Parent         logger.Writer
+
+	mutex   sync.Mutex
+	entries []entry
+	size    int
+
+	stream    *stream.Stream
+	writer    *asyncwriter.Writer
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Close closes Buffer.
+func (b *Buffer) Close() {
+	b.Detach()
+}
+
+// Log implements logger.Writer.
+func (b *Buffer) Log(level logger.Level, format string, args ...interface{}) {
+	b.Parent.Log(level, "[capture] "+format, args...)
+}
+
+// Attach starts capturing packets of a stream.
+func (b *Buffer) Attach(strm *stream.Stream) {
+	b.Detach()
+
+	b.stream = strm
+	b.writer = asyncwriter.New(b.WriteQueueSize, b)
+	b.terminate = make(chan struct{})
+	b.done = make(chan struct{})
+
+	for i, media := range strm.Desc().Medias {
+		for _, forma := range media.Formats {
+			cmedia := i
+			strm.AddInternalReader(b.writer, media, forma, func(u unit.Unit) error {
+				for _, pkt := range u.GetRTPPackets() {
+					b.add(cmedia, pkt)
+				}
+				return nil
+			})
+		}
+	}
+
+	go b.run(b.writer, b.terminate, b.done)
+}
+
+// Detach stops capturing packets.
+func (b *Buffer) Detach() {
+	if b.stream == nil {
+		return
+	}
+
+	b.stream.RemoveReader(b.writer)
+	close(b.terminate)
+	<-b.done
+
+	b.stream = nil
+	b.writer = nil
+}
+
+func (b *Buffer) run(writer *asyncwriter.Writer, terminate chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	writer.Start()
+	defer writer.Stop()
+
+	select {
+	case err := <-writer.Error():
+		b.Log(logger.Error, err.Error())
+		<-terminate
+
+	case <-terminate:
+	}
+}
+
+func (b *Buffer) add(media int, pkt *rtp.Packet) {
+	byts, err := pkt.Marshal()
+	if err != nil {
+		return
+	}
+
+	now := timeNow()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.entries = append(b.entries, entry{
+		ntp:   now,
+		media: media,
+		byts:  byts,
+	})
+	b.size += len(byts)
+
+	oldest := now.Add(-b.Duration)
+	n := 0
+	for n < len(b.entries) && (b.entries[n].ntp.Before(oldest) || b.size > maxBufferSize) {
+		b.size -= len(b.entries[n].byts)
+		n++
+	}
+
+	b.entries = b.entries[n:]
+}
+
+// WritePcap writes buffered packets in pcap format.
+func (b *Buffer) WritePcap(w io.Writer) error {
+	b.mutex.Lock()
+	entries := b.entries
+	b.mutex.Unlock()
+
+	err := writePcapHeader(w)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err = writePcapPacket(w, e.ntp, mediaPort(e.media), e.byts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mediaPort returns the UDP port associated with a media.
+func mediaPort(media int) int {
+	return 5000 + media*2
+}
@@ -0,0 +1,122 @@
+package rtpcapture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+func TestBuffer(t *testing.T) {
+	now := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	desc := &description.Session{}
+
+	for i := 0; i < 2; i++ {
+		forma := &format.Generic{
+			PayloadTyp: 98,
+			RTPMa:      "private/90000",
+		}
+		err := forma.Init()
+		require.NoError(t, err)
+
+		desc.Medias = append(desc.Medias, &description.Media{
+			Type:    description.MediaTypeApplication,
+			Formats: []format.Format{forma},
+		})
+	}
+
+	strm, err := stream.New(1460, desc, false, formatprocessor.Options{}, test.NilLogger)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	b := &Buffer{
+		Duration:       1500 * time.Millisecond,
+		WriteQueueSize: 512,
+		Parent:         test.NilLogger,
+	}
+	defer b.Close()
+
+	b.Attach(strm)
+
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Second)
+
+		for j, medi := range desc.Medias {
+			strm.WriteRTPPacket(medi, medi.Formats[0], &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					Marker:         true,
+					PayloadType:    medi.Formats[0].PayloadType(),
+					SequenceNumber: uint16(i),
+					SSRC:           1234,
+				},
+				Payload: []byte{5, byte(i), byte(j)},
+			}, time.Time{}, 0)
+		}
+
+		// wait for packets to be processed, since the reader is asynchronous
+		for {
+			b.mutex.Lock()
+			done := len(b.entries) != 0 && b.entries[len(b.entries)-1].byts[12+1] == byte(i) &&
+				b.entries[len(b.entries)-1].media == 1
+			b.mutex.Unlock()
+			if done {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	b.Detach()
+
+	var buf bytes.Buffer
+	err = b.WritePcap(&buf)
+	require.NoError(t, err)
+
+	byts := buf.Bytes()
+	require.Equal(t, uint32(pcapMagic), binary.LittleEndian.Uint32(byts))
+	require.Equal(t, uint32(pcapLinkTypeRaw), binary.LittleEndian.Uint32(byts[20:]))
+	byts = byts[pcapHeaderSize:]
+
+	type record struct {
+		sec     uint32
+		port    uint16
+		payload []byte
+	}
+
+	var records []record
+
+	for len(byts) != 0 {
+		le := binary.LittleEndian.Uint32(byts[8:])
+		pkt := byts[pcapRecordHeader : pcapRecordHeader+le]
+		require.Equal(t, uint16(0), ipv4Checksum(pkt[:ipv4HeaderSize]))
+
+		records = append(records, record{
+			sec:     binary.LittleEndian.Uint32(byts),
+			port:    binary.BigEndian.Uint16(pkt[ipv4HeaderSize+2:]),
+			payload: pkt[ipv4HeaderSize+udpHeaderSize+12:],
+		})
+		byts = byts[pcapRecordHeader+le:]
+	}
+
+	// packets older than Duration are discarded
+	start := uint32(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	require.Equal(t, []record{
+		{start + 2, 5000, []byte{5, 1, 0}},
+		{start + 2, 5002, []byte{5, 1, 1}},
+		{start + 3, 5000, []byte{5, 2, 0}},
+		{start + 3, 5002, []byte{5, 2, 1}},
+	}, records)
+}
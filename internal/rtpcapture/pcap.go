@@ -0,0 +1,73 @@
+package rtpcapture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	pcapMagic        = 0xa1b2c3d4
+	pcapSnapLen      = 65535
+	pcapLinkTypeRaw  = 101 // raw IPv4 / IPv6
+	ipv4HeaderSize   = 20
+	udpHeaderSize    = 8
+	ipProtocolUDP    = 17
+	defaultIPv4TTL   = 64
+	pcapHeaderSize   = 24
+	pcapRecordHeader = 16
+)
+
+func writePcapHeader(w io.Writer) error {
+	buf := make([]byte, pcapHeaderSize)
+	binary.LittleEndian.PutUint32(buf[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(buf[4:], 2) // major version
+	binary.LittleEndian.PutUint16(buf[6:], 4) // minor version
+	binary.LittleEndian.PutUint32(buf[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(buf[20:], pcapLinkTypeRaw)
+	_, err := w.Write(buf)
+	return err
+}
+
+func ipv4Checksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+	for sum > 0xFFFF {
+		sum = (sum >> 16) + (sum & 0xFFFF)
+	}
+	return ^uint16(sum)
+}
+
+// writePcapPacket writes a RTP packet wrapped into a UDP/IPv4 datagram
+// sent from and to 127.0.0.1, in order to allow analyzers to decode it.
+func writePcapPacket(w io.Writer, ntp time.Time, port int, payload []byte) error {
+	le := ipv4HeaderSize + udpHeaderSize + len(payload)
+	buf := make([]byte, pcapRecordHeader+le)
+
+	binary.LittleEndian.PutUint32(buf[0:], uint32(ntp.Unix()))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(ntp.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(le))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(le))
+
+	ip := buf[pcapRecordHeader:]
+	ip[0] = 0x45 // version 4, header length 20
+	binary.BigEndian.PutUint16(ip[2:], uint16(le))
+	ip[8] = defaultIPv4TTL
+	ip[9] = ipProtocolUDP
+	copy(ip[12:], []byte{127, 0, 0, 1})
+	copy(ip[16:], []byte{127, 0, 0, 1})
+	binary.BigEndian.PutUint16(ip[10:], ipv4Checksum(ip[:ipv4HeaderSize]))
+
+	udp := ip[ipv4HeaderSize:]
+	binary.BigEndian.PutUint16(udp[0:], uint16(port))
+	binary.BigEndian.PutUint16(udp[2:], uint16(port))
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpHeaderSize+len(payload)))
+	// UDP checksum is optional in IPv4
+
+	copy(udp[udpHeaderSize:], payload)
+
+	_, err := w.Write(buf)
+	return err
+}
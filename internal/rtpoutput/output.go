@@ -0,0 +1,265 @@
+// Package rtpoutput contains an output that sends a stream to a UDP destination in RTP format.
+package rtpoutput
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/rtcpsender"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+	"github.com/pion/rtcp"
+	psdp "github.com/pion/sdp/v3"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	senderReportPeriod = 10 * time.Second
+)
+
+// GenerateSDP generates a SDP that allows to receive medias of a stream
+// sent to host. Every media is sent to two consecutive ports (RTP and RTCP),
+// starting from port.
+func GenerateSDP(desc *description.Session, host string, port int) ([]byte, error) {
+	addressType := "IP4"
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		addressType = "IP6"
+	}
+
+	out := &sdp.SessionDescription{
+		SessionName: psdp.SessionName("Stream"),
+		Origin: psdp.Origin{
+			Username:       "-",
+			NetworkType:    "IN",
+			AddressType:    "IP4",
+			UnicastAddress: "127.0.0.1",
+		},
+		ConnectionInformation: &psdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: addressType,
+			Address:     &psdp.Address{Address: host},
+		},
+		TimeDescriptions: []psdp.TimeDescription{
+			{Timing: psdp.Timing{StartTime: 0, StopTime: 0}},
+		},
+		MediaDescriptions: make([]*psdp.MediaDescription, len(desc.Medias)),
+	}
+
+	for i, media := range desc.Medias {
+		md := media.Marshal()
+		md.MediaName.Port = psdp.RangedPort{Value: port + i*2}
+
+		// control attributes are used by RTSP only
+		attrs := md.Attributes[:0]
+		for _, attr := range md.Attributes {
+			if attr.Key != "control" {
+				attrs = append(attrs, attr)
+			}
+		}
+		md.Attributes = attrs
+
+		out.MediaDescriptions[i] = md
+	}
+
+	return out.Marshal()
+}
+
+type outputMedia struct {
+	rtpConn     net.Conn
+	rtcpConn    net.Conn
+	rtcpSenders []*rtcpsender.RTCPSender
+}
+
+func (m *outputMedia) close() {
+	for _, s := range m.rtcpSenders {
+		s.Close()
+	}
+	if m.rtcpConn != nil {
+		m.rtcpConn.Close()
+	}
+	if m.rtpConn != nil {
+		m.rtpConn.Close()
+	}
+}
+
+// Output sends a stream to a unicast or multicast UDP destination in RTP format,
+// together with RTCP sender reports, and writes a SDP file that describes the stream.
+type Output struct {
+	Address        string
+	SDPPath        string
+	WriteQueueSize int
+	Stream         *stream.Stream
+	Parent         logger.Writer
+
+	writer  *asyncwriter.Writer
+	medias  []*outputMedia
+	sdpPath string
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Output.
+func (o *Output) Initialize() error {
+	host, portStr, err := net.SplitHostPort(o.Address)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return err
+	}
+
+	o.writer = asyncwriter.New(o.WriteQueueSize, o)
+
+	for i, media := range o.Stream.Desc().Medias {
+		om, err := o.setupMedia(media, host, int(port)+i*2)
+		if err != nil {
+			o.Stream.RemoveReader(o.writer)
+			o.closeMedias()
+			return err
+		}
+		o.medias = append(o.medias, om)
+	}
+
+	if o.SDPPath != "" {
+		err = o.writeSDP(host, int(port))
+		if err != nil {
+			o.Stream.RemoveReader(o.writer)
+			o.closeMedias()
+			return err
+		}
+	}
+
+	o.terminate = make(chan struct{})
+	o.done = make(chan struct{})
+
+	o.Log(logger.Info, "sending %s to %s",
+		defs.MediasInfo(o.Stream.Desc().Medias), o.Address)
+
+	go o.run()
+
+	return nil
+}
+
+// Close closes Output.
+func (o *Output) Close() {
+	o.Stream.RemoveReader(o.writer)
+
+	close(o.terminate)
+	<-o.done
+
+	o.closeMedias()
+
+	if o.sdpPath != "" {
+		os.Remove(o.sdpPath)
+	}
+}
+
+// Log implements logger.Writer.
+func (o *Output) Log(level logger.Level, format string, args ...interface{}) {
+	o.Parent.Log(level, "[RTP output] "+format, args...)
+}
+
+func (o *Output) closeMedias() {
+	for _, m := range o.medias {
+		m.close()
+	}
+	o.medias = nil
+}
+
+func (o *Output) setupMedia(media *description.Media, host string, port int) (*outputMedia, error) {
+	om := &outputMedia{}
+
+	var err error
+	om.rtpConn, err = net.Dial("udp", net.JoinHostPort(host, strconv.FormatInt(int64(port), 10)))
+	if err != nil {
+		return nil, err
+	}
+
+	om.rtcpConn, err = net.Dial("udp", net.JoinHostPort(host, strconv.FormatInt(int64(port+1), 10)))
+	if err != nil {
+		om.close()
+		return nil, err
+	}
+
+	for _, forma := range media.Formats {
+		o.setupFormat(om, media, forma)
+	}
+
+	return om, nil
+}
+
+func (o *Output) setupFormat(om *outputMedia, media *description.Media, forma format.Format) {
+	rtcpSender := rtcpsender.New(
+		forma.ClockRate(),
+		senderReportPeriod,
+		nil,
+		func(pkt rtcp.Packet) {
+			byts, err := pkt.Marshal()
+			if err == nil {
+				om.rtcpConn.Write(byts) //nolint:errcheck
+			}
+		})
+	om.rtcpSenders = append(om.rtcpSenders, rtcpSender)
+
+	o.Stream.AddReader(o.writer, media, forma, func(u unit.Unit) error {
+		for _, pkt := range u.GetRTPPackets() {
+			byts, err := pkt.Marshal()
+			if err != nil {
+				return err
+			}
+
+			// errors are ignored since destinations may be not listening yet.
+			om.rtpConn.Write(byts) //nolint:errcheck
+
+			rtcpSender.ProcessPacket(pkt, u.GetNTP(), forma.PTSEqualsDTS(pkt))
+		}
+		return nil
+	})
+}
+
+func (o *Output) writeSDP(host string, port int) error {
+	byts, err := GenerateSDP(o.Stream.Desc(), host, port)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(o.SDPPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(o.SDPPath, byts, 0o644)
+	if err != nil {
+		return err
+	}
+
+	o.sdpPath = o.SDPPath
+	return nil
+}
+
+func (o *Output) run() {
+	defer close(o.done)
+
+	o.writer.Start()
+	defer o.writer.Stop()
+
+	select {
+	case err := <-o.writer.Error():
+		o.Log(logger.Error, err.Error())
+		<-o.terminate
+
+	case <-o.terminate:
+	}
+}
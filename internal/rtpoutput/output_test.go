@@ -0,0 +1,114 @@
+package rtpoutput
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestGenerateSDP(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type:    description.MediaTypeVideo,
+			Control: "trackID=0",
+			Formats: []format.Format{&format.VP8{
+				PayloadTyp: 96,
+			}},
+		},
+		{
+			Type:    description.MediaTypeAudio,
+			Control: "trackID=1",
+			Formats: []format.Format{&format.G711{
+				PayloadTyp:   0,
+				MULaw:        true,
+				SampleRate:   8000,
+				ChannelCount: 1,
+			}},
+		},
+	}}
+
+	byts, err := GenerateSDP(desc, "239.0.0.1", 5004)
+	require.NoError(t, err)
+
+	require.Equal(t, "v=0\r\n"+
+		"o=- 0 0 IN IP4 127.0.0.1\r\n"+
+		"s=Stream\r\n"+
+		"c=IN IP4 239.0.0.1\r\n"+
+		"t=0 0\r\n"+
+		"m=video 5004 RTP/AVP 96\r\n"+
+		"a=rtpmap:96 VP8/90000\r\n"+
+		"m=audio 5006 RTP/AVP 0\r\n"+
+		"a=rtpmap:0 PCMU/8000\r\n",
+		string(byts))
+}
+
+func TestOutput(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:5004")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	dir, err := os.MkdirTemp("", "mediamtx-rtpoutput")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sdpPath := filepath.Join(dir, "sub", "stream.sdp")
+
+	o := &Output{
+		Address:        "127.0.0.1:5004",
+		SDPPath:        sdpPath,
+		WriteQueueSize: 512,
+		Stream:         strm,
+		Parent:         test.NilLogger,
+	}
+	err = o.Initialize()
+	require.NoError(t, err)
+
+	_, err = os.Stat(sdpPath)
+	require.NoError(t, err)
+
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+		Base: unit.Base{
+			NTP: time.Now(),
+		},
+		AU: [][]byte{{1, 2}},
+	})
+
+	buf := make([]byte, 1500)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+
+	var pkt rtp.Packet
+	err = pkt.Unmarshal(buf[:n])
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2}, pkt.Payload)
+
+	o.Close()
+
+	_, err = os.Stat(sdpPath)
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,206 @@
+package dash
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	gopath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
+	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
+)
+
+const (
+	mpdName       = "index.mpd"
+	initName      = "init.mp4"
+	segmentPrefix = "segment_"
+	segmentSuffix = ".m4s"
+)
+
+func addJWTFromAuthorization(rawQuery string, auth string) string {
+	jwt := strings.TrimPrefix(auth, "Bearer ")
+	if rawQuery != "" {
+		if v, err := url.ParseQuery(rawQuery); err == nil && v.Get("jwt") == "" {
+			v.Set("jwt", jwt)
+			return v.Encode()
+		}
+	}
+	return url.Values{"jwt": []string{jwt}}.Encode()
+}
+
+func parseSegmentName(fname string) (uint64, bool) {
+	if !strings.HasPrefix(fname, segmentPrefix) || !strings.HasSuffix(fname, segmentSuffix) {
+		return 0, false
+	}
+
+	v, err := strconv.ParseUint(fname[len(segmentPrefix):len(fname)-len(segmentSuffix)], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
+
+type httpServer struct {
+	address         string
+	encryption      bool
+	serverKey       string
+	serverCert      string
+	serverHeader    string
+	responseHeaders conf.HTTPResponseHeaders
+	allowOrigin     string
+	trustedProxies  conf.IPNetworks
+	readTimeout     conf.StringDuration
+	pathManager     serverPathManager
+	parent          *Server
+
+	inner *httpp.WrappedServer
+}
+
+func (s *httpServer) initialize() error {
+	router := gin.New()
+	router.SetTrustedProxies(s.trustedProxies.ToTrustedProxies()) //nolint:errcheck
+	router.NoRoute(s.onRequest)
+
+	network, address := restrictnetwork.Restrict("tcp", s.address)
+
+	s.inner = &httpp.WrappedServer{
+		Network:         network,
+		Address:         address,
+		ReadTimeout:     time.Duration(s.readTimeout),
+		Encryption:      s.encryption,
+		ServerCert:      s.serverCert,
+		ServerKey:       s.serverKey,
+		ServerHeader:    s.serverHeader,
+		ResponseHeaders: s.responseHeaders,
+		Handler:         router,
+		Parent:          s,
+	}
+	return s.inner.Initialize()
+}
+
+// Log implements logger.Writer.
+func (s *httpServer) Log(level logger.Level, format string, args ...interface{}) {
+	s.parent.Log(level, format, args...)
+}
+
+func (s *httpServer) close() {
+	s.inner.Close()
+}
+
+func (s *httpServer) onRequest(ctx *gin.Context) {
+	ctx.Writer.Header().Set("Access-Control-Allow-Origin", s.allowOrigin)
+	ctx.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+
+	switch ctx.Request.Method {
+	case http.MethodOptions:
+		if ctx.Request.Header.Get("Access-Control-Request-Method") != "" {
+			ctx.Writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET")
+			ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Range")
+			ctx.Writer.WriteHeader(http.StatusNoContent)
+		}
+		return
+
+	case http.MethodGet:
+
+	default:
+		return
+	}
+
+	// remove leading prefix
+	pa := ctx.Request.URL.Path[1:]
+
+	dir, fname := gopath.Dir(pa), gopath.Base(pa)
+
+	segmentNumber, isSegment := parseSegmentName(fname)
+
+	if (fname != mpdName && fname != initName && !isSegment) || dir == "." || dir == "" {
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	user, pass, hasCredentials := ctx.Request.BasicAuth()
+
+	q := ctx.Request.URL.RawQuery
+	if h := ctx.Request.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		q = addJWTFromAuthorization(q, h)
+	}
+
+	_, err := s.pathManager.FindPathConf(defs.PathFindPathConfReq{
+		AccessRequest: defs.PathAccessRequest{
+			Name:      dir,
+			Query:     q,
+			Publish:   false,
+			IP:        net.ParseIP(ctx.ClientIP()),
+			LocalIP:   httpp.LocalIP(ctx.Request),
+			User:      user,
+			Pass:      pass,
+			Proto:     auth.ProtocolDASH,
+			UserAgent: ctx.Request.UserAgent(),
+		},
+	})
+	if err != nil {
+		var terr auth.Error
+		if errors.As(err, &terr) {
+			if !hasCredentials {
+				ctx.Header("WWW-Authenticate", `Basic realm="mediamtx"`)
+				ctx.Writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			s.Log(logger.Info, "connection %v failed to authenticate: %v", httpp.RemoteAddr(ctx), terr.Message)
+
+			// wait some seconds to mitigate brute force attacks
+			<-time.After(auth.PauseAfterError)
+
+			ctx.Writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var terr2 defs.PathProtocolNotAllowedError
+		if errors.As(err, &terr2) {
+			ctx.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var terr3 defs.PathUserAgentNotAllowedError
+		if errors.As(err, &terr3) {
+			ctx.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	mux, err := s.parent.getMuxer(serverGetMuxerReq{
+		path:       dir,
+		remoteAddr: httpp.RemoteAddr(ctx),
+		query:      ctx.Request.URL.RawQuery,
+	})
+	if err != nil {
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case fname == mpdName:
+		mux.handleMPD(ctx)
+
+	case fname == initName:
+		mux.handleInit(ctx)
+
+	default:
+		mux.handleSegment(ctx, segmentNumber)
+	}
+}
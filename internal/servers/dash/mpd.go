@@ -0,0 +1,177 @@
+package dash
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func formatDuration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+type mpdS struct {
+	T int64 `xml:"t,attr"`
+	D int64 `xml:"d,attr"`
+}
+
+type mpdSegmentTemplate struct {
+	Timescale                int     `xml:"timescale,attr"`
+	Initialization           string  `xml:"initialization,attr"`
+	Media                    string  `xml:"media,attr"`
+	StartNumber              uint64  `xml:"startNumber,attr"`
+	AvailabilityTimeOffset   float64 `xml:"availabilityTimeOffset,attr"`
+	AvailabilityTimeComplete bool    `xml:"availabilityTimeComplete,attr"`
+	SegmentTimeline          []mpdS  `xml:"SegmentTimeline>S"`
+}
+
+type mpdRepresentation struct {
+	ID              string             `xml:"id,attr"`
+	Bandwidth       uint64             `xml:"bandwidth,attr"`
+	Codecs          string             `xml:"codecs,attr"`
+	SegmentTemplate mpdSegmentTemplate `xml:"SegmentTemplate"`
+}
+
+type mpdAdaptationSet struct {
+	ID               string            `xml:"id,attr"`
+	MimeType         string            `xml:"mimeType,attr"`
+	SegmentAlignment bool              `xml:"segmentAlignment,attr"`
+	StartWithSAP     int               `xml:"startWithSAP,attr"`
+	Representation   mpdRepresentation `xml:"Representation"`
+}
+
+type mpdPeriod struct {
+	ID            string           `xml:"id,attr"`
+	Start         string           `xml:"start,attr"`
+	AdaptationSet mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdLatency struct {
+	Target int64 `xml:"target,attr"`
+}
+
+type mpdServiceDescription struct {
+	ID      string     `xml:"id,attr"`
+	Latency mpdLatency `xml:"Latency"`
+}
+
+type mpdUTCTiming struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+type mpd struct {
+	XMLName               xml.Name              `xml:"MPD"`
+	XMLNS                 string                `xml:"xmlns,attr"`
+	Profiles              string                `xml:"profiles,attr"`
+	Type                  string                `xml:"type,attr"`
+	AvailabilityStartTime string                `xml:"availabilityStartTime,attr"`
+	PublishTime           string                `xml:"publishTime,attr"`
+	MinimumUpdatePeriod   string                `xml:"minimumUpdatePeriod,attr"`
+	MinBufferTime         string                `xml:"minBufferTime,attr"`
+	TimeShiftBufferDepth  string                `xml:"timeShiftBufferDepth,attr"`
+	MaxSegmentDuration    string                `xml:"maxSegmentDuration,attr"`
+	ServiceDescription    mpdServiceDescription `xml:"ServiceDescription"`
+	Period                mpdPeriod             `xml:"Period"`
+	UTCTiming             mpdUTCTiming          `xml:"UTCTiming"`
+}
+
+// generateMPD generates a dynamic MPD that describes available segments.
+// The segment that is being generated is listed with its expected duration,
+// and can be downloaded as soon as its first chunk is available.
+// It must be called with the mutex locked.
+func (m *muxer) generateMPD() []byte {
+	segmentDuration := time.Duration(m.segmentDuration)
+	chunkDuration := time.Duration(m.chunkDuration)
+
+	maxSegmentDuration := segmentDuration
+	var totalSize uint64
+	var totalDuration time.Duration
+
+	timeline := make([]mpdS, len(m.segments))
+
+	for i, seg := range m.segments {
+		d := seg.duration
+		if !seg.complete {
+			d = segmentDuration
+		} else {
+			totalSize += seg.size
+			totalDuration += seg.duration
+		}
+
+		if d > maxSegmentDuration {
+			maxSegmentDuration = d
+		}
+
+		timeline[i] = mpdS{
+			T: seg.start.Milliseconds(),
+			D: d.Milliseconds(),
+		}
+	}
+
+	bandwidth := uint64(1)
+	if totalDuration > 0 {
+		bandwidth = uint64(float64(totalSize*8) / totalDuration.Seconds())
+	}
+
+	mimeType := "audio/mp4"
+	if m.hasVideo {
+		mimeType = "video/mp4"
+	}
+
+	now := time.Now()
+
+	v := mpd{
+		XMLNS:                 "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:              "urn:mpeg:dash:profile:isoff-live:2011,urn:mpeg:dash:profile:cmaf:2019",
+		Type:                  "dynamic",
+		AvailabilityStartTime: formatTime(m.availabilityStartTime),
+		PublishTime:           formatTime(now),
+		MinimumUpdatePeriod:   formatDuration(segmentDuration),
+		MinBufferTime:         formatDuration(chunkDuration),
+		TimeShiftBufferDepth:  formatDuration(segmentDuration * time.Duration(m.segmentCount)),
+		MaxSegmentDuration:    formatDuration(maxSegmentDuration),
+		ServiceDescription: mpdServiceDescription{
+			ID: "0",
+			Latency: mpdLatency{
+				Target: (3 * chunkDuration).Milliseconds(),
+			},
+		},
+		Period: mpdPeriod{
+			ID:    "0",
+			Start: "PT0S",
+			AdaptationSet: mpdAdaptationSet{
+				ID:               "0",
+				MimeType:         mimeType,
+				SegmentAlignment: true,
+				StartWithSAP:     1,
+				Representation: mpdRepresentation{
+					ID:        "0",
+					Bandwidth: bandwidth,
+					Codecs:    strings.Join(m.codecs, ","),
+					SegmentTemplate: mpdSegmentTemplate{
+						Timescale:                1000,
+						Initialization:           initName,
+						Media:                    segmentPrefix + "$Number$" + segmentSuffix,
+						StartNumber:              m.segments[0].number,
+						AvailabilityTimeOffset:   (segmentDuration - chunkDuration).Seconds(),
+						AvailabilityTimeComplete: false,
+						SegmentTimeline:          timeline,
+					},
+				},
+			},
+		},
+		UTCTiming: mpdUTCTiming{
+			SchemeIDURI: "urn:mpeg:dash:utc:direct:2014",
+			Value:       formatTime(now),
+		},
+	}
+
+	byts, _ := xml.MarshalIndent(v, "", "  ")
+	return append([]byte(xml.Header), byts...)
+}
@@ -0,0 +1,402 @@
+package dash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/gohlslib/pkg/codecparams"
+	"github.com/bluenviron/gohlslib/pkg/codecs"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bluenviron/mediamtx/internal/cmafsegmenter"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+const (
+	closeCheckPeriod = 1 * time.Second
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+type muxerSegment struct {
+	number   uint64
+	start    time.Duration
+	duration time.Duration
+	chunks   [][]byte
+	size     uint64
+	complete bool
+}
+
+type muxerSegmentWriter struct {
+	m   *muxer
+	seg *muxerSegment
+}
+
+// WriteChunk implements cmafsegmenter.SegmentWriter.
+func (w *muxerSegmentWriter) WriteChunk(chunk []byte) error {
+	w.m.mutex.Lock()
+	defer w.m.mutex.Unlock()
+
+	w.seg.chunks = append(w.seg.chunks, chunk)
+	w.seg.size += uint64(len(chunk))
+	w.m.notify()
+
+	return nil
+}
+
+// Close implements cmafsegmenter.SegmentWriter.
+func (w *muxerSegmentWriter) Close() error {
+	return nil
+}
+
+type muxer struct {
+	parentCtx       context.Context
+	remoteAddr      string
+	segmentCount    int
+	segmentDuration conf.StringDuration
+	chunkDuration   conf.StringDuration
+	writeQueueSize  int
+	closeAfter      conf.StringDuration
+	wg              *sync.WaitGroup
+	pathName        string
+	pathManager     serverPathManager
+	parent          *Server
+	query           string
+
+	ctx             context.Context
+	ctxCancel       func()
+	lastRequestTime *int64
+	chError         chan error
+
+	mutex                 sync.Mutex
+	changed               chan struct{}
+	init                  []byte
+	codecs                []string
+	hasVideo              bool
+	availabilityStartTime time.Time
+	segments              []*muxerSegment
+	nextSegmentNumber     uint64
+}
+
+func (m *muxer) initialize() {
+	ctx, ctxCancel := context.WithCancel(m.parentCtx)
+
+	m.ctx = ctx
+	m.ctxCancel = ctxCancel
+	m.lastRequestTime = int64Ptr(time.Now().UnixNano())
+	m.chError = make(chan error, 1)
+	m.changed = make(chan struct{})
+
+	m.Log(logger.Info, "created (requested by %s)", m.remoteAddr)
+
+	m.wg.Add(1)
+	go m.run()
+}
+
+// Close implements reader.
+func (m *muxer) Close() {
+	m.ctxCancel()
+}
+
+// Log implements logger.Writer.
+func (m *muxer) Log(level logger.Level, format string, args ...interface{}) {
+	m.parent.Log(level, "[muxer %s] "+format, append([]interface{}{m.pathName}, args...)...)
+}
+
+func (m *muxer) run() {
+	defer m.wg.Done()
+
+	err := m.runInner()
+
+	m.ctxCancel()
+
+	m.parent.closeMuxer(m)
+
+	m.Log(logger.Info, "destroyed: %v", err)
+}
+
+func (m *muxer) runInner() error {
+	path, stream, err := m.pathManager.AddReader(defs.PathAddReaderReq{
+		Author: m,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     m.pathName,
+			SkipAuth: true,
+			Query:    m.query,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: m})
+
+	segmenter := &cmafsegmenter.Segmenter{
+		SegmentDuration:   time.Duration(m.segmentDuration),
+		ChunkDuration:     time.Duration(m.chunkDuration),
+		WriteQueueSize:    m.writeQueueSize,
+		PathName:          m.pathName,
+		Stream:            stream,
+		OnInit:            m.onInit,
+		OnSegmentCreate:   m.onSegmentCreate,
+		OnSegmentComplete: m.onSegmentComplete,
+		Parent:            m,
+	}
+	segmenter.Initialize()
+	defer segmenter.Close()
+
+	m.Log(logger.Info, "is converting into DASH, %s",
+		defs.MediasInfo(stream.Desc().Medias))
+
+	activityCheckTimer := time.NewTimer(closeCheckPeriod)
+	defer activityCheckTimer.Stop()
+
+	for {
+		select {
+		case err := <-m.chError:
+			return err
+
+		case <-activityCheckTimer.C:
+			t := time.Unix(0, atomic.LoadInt64(m.lastRequestTime))
+			if time.Since(t) >= time.Duration(m.closeAfter) {
+				return fmt.Errorf("not used anymore")
+			}
+			activityCheckTimer = time.NewTimer(closeCheckPeriod)
+
+		case <-m.ctx.Done():
+			return errors.New("terminated")
+		}
+	}
+}
+
+// notify wakes up requests that are waiting for changes. It must be called with the mutex locked.
+func (m *muxer) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *muxer) onInit(init []byte) error {
+	var fi fmp4.Init
+	err := fi.Unmarshal(bytes.NewReader(init))
+	if err != nil {
+		return err
+	}
+
+	codecStrings := make([]string, len(fi.Tracks))
+	hasVideo := false
+
+	for i, track := range fi.Tracks {
+		codecStrings[i] = codecparams.Marshal(codecs.FromFMP4(track.Codec))
+		if codecStrings[i] == "" {
+			err = fmt.Errorf("codec %T is not supported by DASH", track.Codec)
+			select {
+			case m.chError <- err:
+			default:
+			}
+			return err
+		}
+
+		if track.Codec.IsVideo() {
+			hasVideo = true
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// segments generated with a different initialization section can't be decoded anymore.
+	for _, seg := range m.segments {
+		seg.complete = true
+	}
+	m.segments = nil
+
+	m.init = init
+	m.codecs = codecStrings
+	m.hasVideo = hasVideo
+	m.notify()
+
+	return nil
+}
+
+func (m *muxer) onSegmentCreate(start time.Duration) (cmafsegmenter.SegmentWriter, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// the first segment starts now.
+	if m.availabilityStartTime.IsZero() {
+		m.availabilityStartTime = time.Now().Add(-start)
+	}
+
+	seg := &muxerSegment{
+		number: m.nextSegmentNumber,
+		start:  start,
+	}
+	m.nextSegmentNumber++
+	m.segments = append(m.segments, seg)
+	m.notify()
+
+	return &muxerSegmentWriter{m: m, seg: seg}, nil
+}
+
+func (m *muxer) onSegmentComplete(w cmafsegmenter.SegmentWriter, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	seg := w.(*muxerSegmentWriter).seg
+	seg.duration = duration
+	seg.complete = true
+
+	completeCount := 0
+	for _, seg := range m.segments {
+		if seg.complete {
+			completeCount++
+		}
+	}
+
+	if completeCount > m.segmentCount {
+		m.segments = m.segments[completeCount-m.segmentCount:]
+	}
+
+	m.notify()
+}
+
+// waitUntil waits until cond returns true. cond is called with the mutex locked.
+func (m *muxer) waitUntil(ctx *gin.Context, cond func() bool) bool {
+	for {
+		m.mutex.Lock()
+		ok := cond()
+		changed := m.changed
+		m.mutex.Unlock()
+
+		if ok {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-m.ctx.Done():
+			return false
+		}
+	}
+}
+
+func (m *muxer) handleMPD(ctx *gin.Context) {
+	atomic.StoreInt64(m.lastRequestTime, time.Now().UnixNano())
+
+	var byts []byte
+
+	ok := m.waitUntil(ctx, func() bool {
+		if m.init == nil || len(m.segments) == 0 {
+			return false
+		}
+		byts = m.generateMPD()
+		return true
+	})
+	if !ok {
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Type", "application/dash+xml")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.Write(byts)
+}
+
+func (m *muxer) handleInit(ctx *gin.Context) {
+	atomic.StoreInt64(m.lastRequestTime, time.Now().UnixNano())
+
+	var init []byte
+
+	ok := m.waitUntil(ctx, func() bool {
+		init = m.init
+		return init != nil
+	})
+	if !ok {
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Type", "video/mp4")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.Write(init)
+}
+
+// handleSegment serves a segment. Segments that are being generated are sent
+// one chunk at a time, with chunked transfer encoding.
+// The segment that follows the last one can be requested in advance.
+func (m *muxer) handleSegment(ctx *gin.Context, number uint64) {
+	atomic.StoreInt64(m.lastRequestTime, time.Now().UnixNano())
+
+	var seg *muxerSegment
+
+	ok := m.waitUntil(ctx, func() bool {
+		for _, s := range m.segments {
+			if s.number == number {
+				seg = s
+				return true
+			}
+		}
+
+		// segment is expired or too far in the future
+		return number != m.nextSegmentNumber
+	})
+	if !ok || seg == nil {
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	ctx.Writer.Header().Set("Content-Type", "video/mp4")
+	ctx.Writer.WriteHeader(http.StatusOK)
+
+	sent := 0
+
+	for {
+		var chunks [][]byte
+		var complete bool
+
+		ok = m.waitUntil(ctx, func() bool {
+			chunks = seg.chunks[sent:]
+			complete = seg.complete
+			return len(chunks) != 0 || complete
+		})
+		if !ok {
+			return
+		}
+
+		for _, chunk := range chunks {
+			_, err := ctx.Writer.Write(chunk)
+			if err != nil {
+				return
+			}
+		}
+
+		ctx.Writer.Flush()
+		sent += len(chunks)
+
+		if complete {
+			return
+		}
+	}
+}
+
+// APIReaderDescribe implements reader.
+func (m *muxer) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "dashMuxer",
+		ID:   "",
+	}
+}
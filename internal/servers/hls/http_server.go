@@ -49,39 +49,66 @@ func addJWTFromAuthorization(rawQuery string, auth string) string {
 }
 
 type httpServer struct {
-	address        string
-	encryption     bool
-	serverKey      string
-	serverCert     string
-	allowOrigin    string
-	trustedProxies conf.IPNetworks
-	readTimeout    conf.StringDuration
-	pathManager    serverPathManager
-	parent         *Server
-
-	inner *httpp.WrappedServer
+	address         string
+	listeners       conf.Listeners
+	encryption      bool
+	serverKey       string
+	serverCert      string
+	tlsOptions      conf.TLSOptions
+	serverHeader    string
+	responseHeaders conf.HTTPResponseHeaders
+	allowOrigin     string
+	trustedProxies  conf.IPNetworks
+	readTimeout     conf.StringDuration
+	requestLimiter  *httpp.RequestLimiter
+	sessionTokens   *sessionTokens
+	pathManager     serverPathManager
+	parent          *Server
+
+	inners []*httpp.WrappedServer
 }
 
 func (s *httpServer) initialize() error {
 	router := gin.New()
 	router.SetTrustedProxies(s.trustedProxies.ToTrustedProxies()) //nolint:errcheck
+	if s.requestLimiter != nil {
+		router.Use(s.requestLimiter.Handler("hls"))
+	}
 	router.NoRoute(s.onRequest)
 
-	network, address := restrictnetwork.Restrict("tcp", s.address)
-
-	s.inner = &httpp.WrappedServer{
-		Network:     network,
-		Address:     address,
-		ReadTimeout: time.Duration(s.readTimeout),
-		Encryption:  s.encryption,
-		ServerCert:  s.serverCert,
-		ServerKey:   s.serverKey,
-		Handler:     router,
-		Parent:      s,
-	}
-	err := s.inner.Initialize()
-	if err != nil {
-		return err
+	listeners := append(conf.Listeners{{
+		Address:    s.address,
+		Encryption: s.encryption,
+	}}, s.listeners...)
+
+	for _, l := range listeners {
+		serverCert, serverKey := s.serverCert, s.serverKey
+		if l.ServerCert != "" {
+			serverCert, serverKey = l.ServerCert, l.ServerKey
+		}
+
+		network, address := restrictnetwork.Restrict("tcp", l.Address)
+
+		inner := &httpp.WrappedServer{
+			Network:         network,
+			Address:         address,
+			ReadTimeout:     time.Duration(s.readTimeout),
+			Encryption:      l.Encryption,
+			ServerCert:      serverCert,
+			ServerKey:       serverKey,
+			TLSOptions:      s.tlsOptions,
+			ServerHeader:    s.serverHeader,
+			ResponseHeaders: s.responseHeaders,
+			Handler:         router,
+			Parent:          s,
+		}
+		err := inner.Initialize()
+		if err != nil {
+			s.close()
+			return err
+		}
+
+		s.inners = append(s.inners, inner)
 	}
 
 	return nil
@@ -93,7 +120,9 @@ func (s *httpServer) Log(level logger.Level, format string, args ...interface{})
 }
 
 func (s *httpServer) close() {
-	s.inner.Close()
+	for _, inner := range s.inners {
+		inner.Close()
+	}
 }
 
 func (s *httpServer) onRequest(ctx *gin.Context) {
@@ -166,13 +195,15 @@ func (s *httpServer) onRequest(ctx *gin.Context) {
 
 	pathConf, err := s.pathManager.FindPathConf(defs.PathFindPathConfReq{
 		AccessRequest: defs.PathAccessRequest{
-			Name:    dir,
-			Query:   q,
-			Publish: false,
-			IP:      net.ParseIP(ctx.ClientIP()),
-			User:    user,
-			Pass:    pass,
-			Proto:   auth.ProtocolHLS,
+			Name:      dir,
+			Query:     q,
+			Publish:   false,
+			IP:        net.ParseIP(ctx.ClientIP()),
+			LocalIP:   httpp.LocalIP(ctx.Request),
+			User:      user,
+			Pass:      pass,
+			Proto:     auth.ProtocolHLS,
+			UserAgent: ctx.Request.UserAgent(),
 		},
 	})
 	if err != nil {
@@ -193,6 +224,18 @@ func (s *httpServer) onRequest(ctx *gin.Context) {
 			return
 		}
 
+		var terr2 defs.PathProtocolNotAllowedError
+		if errors.As(err, &terr2) {
+			ctx.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var terr3 defs.PathUserAgentNotAllowedError
+		if errors.As(err, &terr3) {
+			ctx.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
 		ctx.Writer.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -205,11 +248,15 @@ func (s *httpServer) onRequest(ctx *gin.Context) {
 		ctx.Writer.Write(hlsIndex)
 
 	default:
+		if s.sessionTokens != nil && !s.checkSessionToken(ctx, dir, fname) {
+			return
+		}
+
 		mux, err := s.parent.getMuxer(serverGetMuxerReq{
-			path:           dir,
-			remoteAddr:     httpp.RemoteAddr(ctx),
-			query:          ctx.Request.URL.RawQuery,
-			sourceOnDemand: pathConf.SourceOnDemand,
+			path:       dir,
+			remoteAddr: httpp.RemoteAddr(ctx),
+			query:      ctx.Request.URL.RawQuery,
+			pathConf:   pathConf,
 		})
 		if err != nil {
 			ctx.Writer.WriteHeader(http.StatusNotFound)
@@ -226,3 +273,43 @@ func (s *httpServer) onRequest(ctx *gin.Context) {
 		mi.handleRequest(ctx)
 	}
 }
+
+// checkSessionToken redirects clients that request the multivariant playlist without a session token,
+// and rejects requests with invalid tokens.
+func (s *httpServer) checkSessionToken(ctx *gin.Context, dir string, fname string) bool {
+	query := ctx.Request.URL.Query()
+	token := query.Get(sessionTokenParam)
+
+	if token == "" {
+		if fname != "index.m3u8" {
+			return true
+		}
+
+		var err error
+		token, err = s.sessionTokens.generate(dir)
+		if err != nil {
+			ctx.Writer.WriteHeader(http.StatusInternalServerError)
+			return false
+		}
+
+		query.Set(sessionTokenParam, token)
+
+		ctx.Writer.Header().Set("Cache-Control", "no-store")
+		ctx.Writer.Header().Set("Location", mergePathAndQuery(ctx.Request.URL.Path, query.Encode()))
+		ctx.Writer.WriteHeader(http.StatusFound)
+		return false
+	}
+
+	instanceID, ok := s.sessionTokens.verify(token, dir)
+	if !ok {
+		s.Log(logger.Info, "connection %v sent an invalid session token", httpp.RemoteAddr(ctx))
+		ctx.Writer.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+
+	if instanceID != s.sessionTokens.instanceID {
+		s.Log(logger.Debug, "serving session of instance '%s'", instanceID)
+	}
+
+	return true
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/hls"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/subtitles"
 )
 
 const (
@@ -41,26 +43,43 @@ func (w *responseWriterWithCounter) Write(p []byte) (int, error) {
 	return n, err
 }
 
+type subtitlesPath interface {
+	Subtitles() *subtitles.Track
+}
+
+func pathSubtitles(path defs.Path) *subtitles.Track {
+	if sp, ok := path.(subtitlesPath); ok {
+		return sp.Subtitles()
+	}
+	return nil
+}
+
 type muxerGetInstanceReq struct {
 	res chan *muxerInstance
 }
 
 type muxer struct {
-	parentCtx       context.Context
-	remoteAddr      string
-	variant         conf.HLSVariant
-	segmentCount    int
-	segmentDuration conf.StringDuration
-	partDuration    conf.StringDuration
-	segmentMaxSize  conf.StringSize
-	directory       string
-	writeQueueSize  int
-	closeAfter      conf.StringDuration
-	wg              *sync.WaitGroup
-	pathName        string
-	pathManager     serverPathManager
-	parent          *Server
-	query           string
+	parentCtx            context.Context
+	remoteAddr           string
+	variant              conf.HLSVariant
+	segmentCount         int
+	segmentDuration      conf.StringDuration
+	partDuration         conf.StringDuration
+	adaptivePartDuration bool
+	partMinDuration      conf.StringDuration
+	partMaxDuration      conf.StringDuration
+	segmentMaxSize       conf.StringSize
+	audioOnlyRendition   bool
+	videoGapFilling      bool
+	videoGapThreshold    conf.StringDuration
+	directory            string
+	writeQueueSize       int
+	closeAfter           conf.StringDuration
+	wg                   *sync.WaitGroup
+	pathName             string
+	pathManager          serverPathManager
+	parent               *Server
+	query                string
 
 	ctx             context.Context
 	ctxCancel       func()
@@ -68,6 +87,7 @@ type muxer struct {
 	path            defs.Path
 	lastRequestTime *int64
 	bytesSent       *uint64
+	stats           *muxerStats
 
 	// in
 	chGetInstance chan muxerGetInstanceReq
@@ -81,6 +101,7 @@ func (m *muxer) initialize() {
 	m.created = time.Now()
 	m.lastRequestTime = int64Ptr(time.Now().UnixNano())
 	m.bytesSent = new(uint64)
+	m.stats = &muxerStats{}
 	m.chGetInstance = make(chan muxerGetInstanceReq)
 
 	m.Log(logger.Info, "created %s", func() string {
@@ -137,23 +158,48 @@ func (m *muxer) runInner() error {
 
 	defer m.path.RemoveReader(defs.PathRemoveReaderReq{Author: m})
 
+	partDuration := m.partDuration
+	var pendingReqs []muxerGetInstanceReq
+
+	if m.adaptivePartDuration {
+		partDuration, pendingReqs, err = m.estimatePartDuration(stream)
+		if err != nil {
+			return err
+		}
+	}
+
 	var instanceError chan error
 	var recreateTimer *time.Timer
 
 	mi := &muxerInstance{
-		variant:         m.variant,
-		segmentCount:    m.segmentCount,
-		segmentDuration: m.segmentDuration,
-		partDuration:    m.partDuration,
-		segmentMaxSize:  m.segmentMaxSize,
-		directory:       m.directory,
-		writeQueueSize:  m.writeQueueSize,
-		pathName:        m.pathName,
-		stream:          stream,
-		bytesSent:       m.bytesSent,
-		parent:          m,
+		variant:            m.variant,
+		segmentCount:       m.segmentCount,
+		segmentDuration:    m.segmentDuration,
+		partDuration:       partDuration,
+		segmentMaxSize:     m.segmentMaxSize,
+		audioOnlyRendition: m.audioOnlyRendition,
+		videoGapFilling:    m.videoGapFilling,
+		videoGapThreshold:  m.videoGapThreshold,
+		directory:          m.directory,
+		writeQueueSize:     m.writeQueueSize,
+		pathName:           m.pathName,
+		stream:             stream,
+		subtitles:          pathSubtitles(path),
+		bytesSent:          m.bytesSent,
+		stats:              m.stats,
+		parent:             m,
 	}
 	err = mi.initialize()
+
+	// serve requests received during the estimation of the part duration
+	for _, req := range pendingReqs {
+		if err != nil {
+			req.res <- nil
+		} else {
+			req.res <- mi
+		}
+	}
+
 	if err != nil {
 		if m.remoteAddr != "" || errors.Is(err, hls.ErrNoSupportedCodecs) {
 			return err
@@ -199,17 +245,22 @@ func (m *muxer) runInner() error {
 
 		case <-recreateTimer.C:
 			mi = &muxerInstance{
-				variant:         m.variant,
-				segmentCount:    m.segmentCount,
-				segmentDuration: m.segmentDuration,
-				partDuration:    m.partDuration,
-				segmentMaxSize:  m.segmentMaxSize,
-				directory:       m.directory,
-				writeQueueSize:  m.writeQueueSize,
-				pathName:        m.pathName,
-				stream:          stream,
-				bytesSent:       m.bytesSent,
-				parent:          m,
+				variant:            m.variant,
+				segmentCount:       m.segmentCount,
+				segmentDuration:    m.segmentDuration,
+				partDuration:       partDuration,
+				segmentMaxSize:     m.segmentMaxSize,
+				audioOnlyRendition: m.audioOnlyRendition,
+				videoGapFilling:    m.videoGapFilling,
+				videoGapThreshold:  m.videoGapThreshold,
+				directory:          m.directory,
+				writeQueueSize:     m.writeQueueSize,
+				pathName:           m.pathName,
+				stream:             stream,
+				subtitles:          pathSubtitles(path),
+				bytesSent:          m.bytesSent,
+				stats:              m.stats,
+				parent:             m,
 			}
 			err := mi.initialize()
 			if err != nil {
@@ -233,6 +284,57 @@ func (m *muxer) runInner() error {
 	}
 }
 
+// estimatePartDuration observes the stream in order to choose a part duration
+// that fits its frame rate and key frame interval.
+// Requests received in the meantime are returned, in order to be served once the instance is created.
+func (m *muxer) estimatePartDuration(strm *stream.Stream) (conf.StringDuration, []muxerGetInstanceReq, error) {
+	e := &partDurationEstimator{
+		writeQueueSize: m.writeQueueSize,
+		stream:         strm,
+		parent:         m,
+	}
+	e.initialize()
+
+	timer := time.NewTimer(partDurationEstimationTimeout)
+	defer timer.Stop()
+
+	var pendingReqs []muxerGetInstanceReq
+
+outer:
+	for {
+		select {
+		case <-e.done:
+			break outer
+
+		case <-timer.C:
+			break outer
+
+		case req := <-m.chGetInstance:
+			pendingReqs = append(pendingReqs, req)
+
+		case <-m.ctx.Done():
+			e.close()
+			for _, req := range pendingReqs {
+				req.res <- nil
+			}
+			return 0, nil, errors.New("terminated")
+		}
+	}
+
+	e.close()
+
+	partDuration := adaptPartDuration(
+		e.frameInterval,
+		e.keyFrameInterval,
+		time.Duration(m.partMinDuration),
+		time.Duration(m.partMaxDuration))
+
+	m.Log(logger.Info, "part duration set to %v (frame interval %v, key frame interval %v)",
+		partDuration, e.frameInterval, e.keyFrameInterval)
+
+	return conf.StringDuration(partDuration), pendingReqs, nil
+}
+
 func (m *muxer) getInstance() *muxerInstance {
 	atomic.StoreInt64(m.lastRequestTime, time.Now().UnixNano())
 
@@ -256,10 +358,12 @@ func (m *muxer) APIReaderDescribe() defs.APIPathSourceOrReader {
 }
 
 func (m *muxer) apiItem() *defs.APIHLSMuxer {
-	return &defs.APIHLSMuxer{
+	item := &defs.APIHLSMuxer{
 		Path:        m.pathName,
 		Created:     m.created,
 		LastRequest: time.Unix(0, atomic.LoadInt64(m.lastRequestTime)),
 		BytesSent:   atomic.LoadUint64(m.bytesSent),
 	}
+	m.stats.fillAPIItem(item)
+	return item
 }
@@ -1,39 +1,80 @@
 package hls
 
 import (
+	"bytes"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bluenviron/gohlslib"
+	"github.com/bluenviron/gohlslib/pkg/playlist"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/hls"
 	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/subtitles"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	subtitlesPlaylistName = "subtitles.m3u8"
+
+	// files of the audio-only rendition are served with this prefix,
+	// since they are produced by a dedicated muxer.
+	audioOnlyPrefix = "audio_"
+)
+
+type bufferedResponseWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *bufferedResponseWriter) WriteHeader(statusCode int) {
+	w.code = statusCode
+}
+
 type muxerInstance struct {
-	variant         conf.HLSVariant
-	segmentCount    int
-	segmentDuration conf.StringDuration
-	partDuration    conf.StringDuration
-	segmentMaxSize  conf.StringSize
-	directory       string
-	writeQueueSize  int
-	pathName        string
-	stream          *stream.Stream
-	bytesSent       *uint64
-	parent          logger.Writer
-
-	writer *asyncwriter.Writer
-	hmuxer *gohlslib.Muxer
+	variant            conf.HLSVariant
+	segmentCount       int
+	segmentDuration    conf.StringDuration
+	partDuration       conf.StringDuration
+	segmentMaxSize     conf.StringSize
+	audioOnlyRendition bool
+	videoGapFilling    bool
+	videoGapThreshold  conf.StringDuration
+	directory          string
+	writeQueueSize     int
+	pathName           string
+	stream             *stream.Stream
+	subtitles          *subtitles.Track
+	bytesSent          *uint64
+	stats              *muxerStats
+	parent             logger.Writer
+
+	writer         *asyncwriter.Writer
+	hmuxer         *gohlslib.Muxer
+	audioOnlyMuxer *gohlslib.Muxer
+	statsObserver  *muxerStatsObserver
+	compressor     *playlistCompressor
 }
 
 func (mi *muxerInstance) initialize() error {
 	mi.writer = asyncwriter.New(mi.writeQueueSize, mi)
+	mi.compressor = &playlistCompressor{}
 
 	var muxerDirectory string
 	if mi.directory != "" {
@@ -50,23 +91,59 @@ func (mi *muxerInstance) initialize() error {
 		Directory:       muxerDirectory,
 	}
 
-	err := hls.FromStream(mi.stream, mi.writer, mi.hmuxer, mi)
+	if mi.audioOnlyRendition {
+		mi.audioOnlyMuxer = &gohlslib.Muxer{
+			Variant:         gohlslib.MuxerVariant(mi.variant),
+			SegmentCount:    mi.segmentCount,
+			SegmentDuration: time.Duration(mi.segmentDuration),
+			PartDuration:    time.Duration(mi.partDuration),
+			SegmentMaxSize:  uint64(mi.segmentMaxSize),
+		}
+	}
+
+	var videoGapThreshold time.Duration
+	if mi.videoGapFilling {
+		videoGapThreshold = time.Duration(mi.videoGapThreshold)
+	}
+
+	err := hls.FromStream(mi.stream, mi.writer, mi.hmuxer, mi.audioOnlyMuxer, videoGapThreshold, mi)
 	if err != nil {
 		mi.stream.RemoveReader(mi.writer)
 		return err
 	}
 
+	// the audio-only rendition is useful only when the stream contains video
+	if mi.audioOnlyMuxer != nil && mi.audioOnlyMuxer.AudioTrack == nil {
+		mi.audioOnlyMuxer = nil
+	}
+
 	err = mi.hmuxer.Start()
 	if err != nil {
 		mi.stream.RemoveReader(mi.writer)
 		return err
 	}
 
+	if mi.audioOnlyMuxer != nil {
+		err = mi.audioOnlyMuxer.Start()
+		if err != nil {
+			mi.hmuxer.Close()
+			mi.stream.RemoveReader(mi.writer)
+			return err
+		}
+	}
+
 	mi.Log(logger.Info, "is converting into HLS, %s",
 		defs.FormatsInfo(mi.stream.FormatsForReader(mi.writer)))
 
 	mi.writer.Start()
 
+	mi.statsObserver = &muxerStatsObserver{
+		hmuxer:     mi.hmuxer,
+		lowLatency: mi.variant == conf.HLSVariant(gohlslib.MuxerVariantLowLatency),
+		stats:      mi.stats,
+	}
+	mi.statsObserver.initialize()
+
 	return nil
 }
 
@@ -76,8 +153,13 @@ func (mi *muxerInstance) Log(level logger.Level, format string, args ...interfac
 }
 
 func (mi *muxerInstance) close() {
+	mi.statsObserver.close()
 	mi.writer.Stop()
 	mi.hmuxer.Close()
+	if mi.audioOnlyMuxer != nil {
+		mi.audioOnlyMuxer.Close()
+	}
+	mi.statsObserver.wait()
 	mi.stream.RemoveReader(mi.writer)
 	if mi.hmuxer.Directory != "" {
 		os.Remove(mi.hmuxer.Directory)
@@ -94,5 +176,219 @@ func (mi *muxerInstance) handleRequest(ctx *gin.Context) {
 		bytesSent:      mi.bytesSent,
 	}
 
-	mi.hmuxer.Handle(w, ctx.Request)
+	if strings.HasSuffix(ctx.Request.URL.Path, ".m3u8") {
+		mi.compressor.serve(w, ctx.Request, mi.handleFile)
+		return
+	}
+
+	mi.handleFile(w, ctx.Request)
+}
+
+func (mi *muxerInstance) handleFile(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+
+	if mi.audioOnlyMuxer != nil {
+		switch {
+		case name == "index.m3u8":
+			mi.handleMultivariantPlaylist(w, r)
+			return
+
+		case strings.HasPrefix(name, audioOnlyPrefix):
+			r.URL.Path = name[len(audioOnlyPrefix):]
+
+			if r.URL.Path == "stream.m3u8" {
+				mi.handleAudioOnlyMediaPlaylist(w, r)
+			} else {
+				mi.audioOnlyMuxer.Handle(w, r)
+			}
+			return
+		}
+	}
+
+	if mi.subtitles != nil {
+		switch {
+		case name == "index.m3u8":
+			mi.handleMultivariantPlaylist(w, r)
+			return
+
+		case name == subtitlesPlaylistName:
+			buf, err := mi.subtitles.Playlist()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			w.Write(buf)
+			return
+
+		case strings.HasPrefix(name, "subtitles_") && strings.HasSuffix(name, ".vtt"):
+			n, err := strconv.ParseInt(name[len("subtitles_"):len(name)-len(".vtt")], 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			buf, err := mi.subtitles.Segment(int(n))
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/vtt")
+			w.Header().Set("Cache-Control", "max-age=3600")
+			w.WriteHeader(http.StatusOK)
+			w.Write(buf)
+			return
+		}
+	}
+
+	mi.hmuxer.Handle(w, r)
+}
+
+func handleBuffered(hmuxer *gohlslib.Muxer, r *http.Request) *bufferedResponseWriter {
+	rec := &bufferedResponseWriter{
+		header: make(http.Header),
+		code:   http.StatusOK,
+	}
+	hmuxer.Handle(rec, r)
+	return rec
+}
+
+func copyHeader(w http.ResponseWriter, rec *bufferedResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+}
+
+// handleMultivariantPlaylist adds the WebVTT rendition and the audio-only rendition
+// to the multivariant playlist.
+func (mi *muxerInstance) handleMultivariantPlaylist(w http.ResponseWriter, r *http.Request) {
+	rec := handleBuffered(mi.hmuxer, r)
+	copyHeader(w, rec)
+
+	if rec.code != http.StatusOK {
+		w.WriteHeader(rec.code)
+		w.Write(rec.buf.Bytes())
+		return
+	}
+
+	pl, err := playlist.Unmarshal(rec.buf.Bytes())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	mpl, ok := pl.(*playlist.Multivariant)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if mi.audioOnlyMuxer != nil {
+		rec = handleBuffered(mi.audioOnlyMuxer, r)
+		if rec.code != http.StatusOK {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var audioPl playlist.Playlist
+		audioPl, err = playlist.Unmarshal(rec.buf.Bytes())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		audioMpl, ok := audioPl.(*playlist.Multivariant)
+		if !ok || len(audioMpl.Variants) != 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		v := audioMpl.Variants[0]
+		v.URI = audioOnlyPrefix + v.URI
+		mpl.Variants = append(mpl.Variants, v)
+	}
+
+	if mi.subtitles != nil {
+		uri := subtitlesPlaylistName
+		if r.URL.RawQuery != "" {
+			uri += "?" + r.URL.RawQuery
+		}
+
+		mpl.Renditions = append(mpl.Renditions, &playlist.MultivariantRendition{
+			Type:       playlist.MultivariantRenditionTypeSubtitles,
+			GroupID:    "subs",
+			Name:       "subtitles",
+			URI:        uri,
+			Default:    true,
+			Autoselect: true,
+		})
+
+		for _, v := range mpl.Variants {
+			v.Subtitles = "subs"
+		}
+	}
+
+	buf, err := mpl.Marshal()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
+
+// handleAudioOnlyMediaPlaylist adds the prefix of the audio-only rendition
+// to the URIs of the audio-only media playlist.
+func (mi *muxerInstance) handleAudioOnlyMediaPlaylist(w http.ResponseWriter, r *http.Request) {
+	rec := handleBuffered(mi.audioOnlyMuxer, r)
+	copyHeader(w, rec)
+
+	if rec.code != http.StatusOK {
+		w.WriteHeader(rec.code)
+		w.Write(rec.buf.Bytes())
+		return
+	}
+
+	var mpl playlist.Media
+	err := mpl.Unmarshal(rec.buf.Bytes())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if mpl.Map != nil {
+		mpl.Map.URI = audioOnlyPrefix + mpl.Map.URI
+	}
+
+	for _, seg := range mpl.Segments {
+		seg.URI = audioOnlyPrefix + seg.URI
+
+		for _, part := range seg.Parts {
+			part.URI = audioOnlyPrefix + part.URI
+		}
+	}
+
+	for _, part := range mpl.Parts {
+		part.URI = audioOnlyPrefix + part.URI
+	}
+
+	if mpl.PreloadHint != nil {
+		mpl.PreloadHint.URI = audioOnlyPrefix + mpl.PreloadHint.URI
+	}
+
+	buf, err := mpl.Marshal()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
 }
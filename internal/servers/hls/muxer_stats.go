@@ -0,0 +1,227 @@
+package hls
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gohlslib"
+	"github.com/bluenviron/gohlslib/pkg/playlist"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+const (
+	statsPollPeriod = 250 * time.Millisecond
+)
+
+// muxerStats contains timing statistics of segments and parts.
+// They are shared between subsequent muxer instances.
+type muxerStats struct {
+	mutex                 sync.Mutex
+	segmentTargetDuration time.Duration
+	lastSegmentDuration   time.Duration
+	segmentsOverTarget    uint64
+	partTargetDuration    time.Duration
+	lastPartDuration      time.Duration
+	partsOverTarget       uint64
+	latePartDeliveries    uint64
+	playlistUpdateLatency time.Duration
+}
+
+func (s *muxerStats) fillAPIItem(item *defs.APIHLSMuxer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item.SegmentTargetDuration = s.segmentTargetDuration.Seconds()
+	item.LastSegmentDuration = s.lastSegmentDuration.Seconds()
+	item.SegmentsOverTarget = s.segmentsOverTarget
+	item.PartTargetDuration = s.partTargetDuration.Seconds()
+	item.LastPartDuration = s.lastPartDuration.Seconds()
+	item.PartsOverTarget = s.partsOverTarget
+	item.LatePartDeliveries = s.latePartDeliveries
+	item.PlaylistUpdateLatency = s.playlistUpdateLatency.Seconds()
+}
+
+func (s *muxerStats) addSegment(duration time.Duration, end *time.Time, now time.Time) {
+	s.lastSegmentDuration = duration
+
+	if s.segmentTargetDuration != 0 && duration > s.segmentTargetDuration {
+		s.segmentsOverTarget++
+	}
+
+	if end != nil {
+		s.playlistUpdateLatency = now.Sub(*end)
+	}
+}
+
+func (s *muxerStats) addPart(duration time.Duration, end *time.Time, now time.Time) {
+	s.lastPartDuration = duration
+
+	if s.partTargetDuration != 0 && duration > s.partTargetDuration {
+		s.partsOverTarget++
+	}
+
+	if end != nil {
+		s.playlistUpdateLatency = now.Sub(*end)
+
+		if s.partTargetDuration != 0 && s.playlistUpdateLatency > s.partTargetDuration {
+			s.latePartDeliveries++
+		}
+	}
+}
+
+func addDuration(t *time.Time, d time.Duration) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.Add(d)
+	return &v
+}
+
+// muxerStatsObserver fills muxerStats by reading the media playlist of a muxer,
+// in the same way a client does. Low-Latency playlists are read with blocking
+// requests, the others are polled periodically.
+type muxerStatsObserver struct {
+	hmuxer     *gohlslib.Muxer
+	lowLatency bool
+	stats      *muxerStats
+
+	initialized bool
+	lastMSN     int
+	lastParts   int
+	terminate   chan struct{}
+	done        chan struct{}
+}
+
+func (o *muxerStatsObserver) initialize() {
+	o.terminate = make(chan struct{})
+	o.done = make(chan struct{})
+
+	go o.run()
+}
+
+// close must be called before closing the muxer, and waited with wait() after.
+func (o *muxerStatsObserver) close() {
+	close(o.terminate)
+}
+
+func (o *muxerStatsObserver) wait() {
+	<-o.done
+}
+
+func (o *muxerStatsObserver) run() {
+	defer close(o.done)
+
+	for {
+		u := "/stream.m3u8"
+		if o.lowLatency && o.initialized {
+			u += "?_HLS_msn=" + strconv.Itoa(o.lastMSN+1) + "&_HLS_part=" + strconv.Itoa(o.lastParts)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		w := &bufferedResponseWriter{
+			header: make(http.Header),
+			code:   http.StatusOK,
+		}
+		o.hmuxer.Handle(w, req)
+
+		now := time.Now()
+
+		select {
+		case <-o.terminate:
+			return
+		default:
+		}
+
+		ok := false
+
+		if w.code == http.StatusOK {
+			var pl playlist.Media
+			err := pl.Unmarshal(w.buf.Bytes())
+			if err == nil {
+				o.process(&pl, now)
+				ok = true
+			}
+		}
+
+		if !ok {
+			o.initialized = false
+		}
+
+		if o.lowLatency && ok {
+			continue
+		}
+
+		select {
+		case <-time.After(statsPollPeriod):
+		case <-o.terminate:
+			return
+		}
+	}
+}
+
+func (o *muxerStatsObserver) process(pl *playlist.Media, now time.Time) {
+	if len(pl.Segments) == 0 {
+		return
+	}
+
+	o.stats.mutex.Lock()
+	defer o.stats.mutex.Unlock()
+
+	o.stats.segmentTargetDuration = time.Duration(pl.TargetDuration) * time.Second
+	if pl.PartInf != nil {
+		o.stats.partTargetDuration = pl.PartInf.PartTarget
+	}
+
+	lastMSN := pl.MediaSequence + len(pl.Segments) - 1
+
+	// the first playlist is used to initialize the state only
+	if !o.initialized {
+		o.initialized = true
+		o.lastMSN = lastMSN
+		o.lastParts = len(pl.Parts)
+		return
+	}
+
+	var end *time.Time
+
+	for i, seg := range pl.Segments {
+		msn := pl.MediaSequence + i
+		end = addDuration(seg.DateTime, seg.Duration)
+
+		if msn <= o.lastMSN {
+			continue
+		}
+
+		partsToSkip := 0
+		if msn == o.lastMSN+1 {
+			partsToSkip = o.lastParts
+		}
+
+		partEnd := seg.DateTime
+		for j, part := range seg.Parts {
+			partEnd = addDuration(partEnd, part.Duration)
+			if j >= partsToSkip {
+				o.stats.addPart(part.Duration, partEnd, now)
+			}
+		}
+
+		o.stats.addSegment(seg.Duration, end, now)
+		o.lastMSN = msn
+		o.lastParts = 0
+	}
+
+	// parts of the segment that is being generated
+	partEnd := end
+	for j, part := range pl.Parts {
+		partEnd = addDuration(partEnd, part.Duration)
+		if j >= o.lastParts {
+			o.stats.addPart(part.Duration, partEnd, now)
+		}
+	}
+
+	o.lastMSN = lastMSN
+	o.lastParts = len(pl.Parts)
+}
@@ -0,0 +1,82 @@
+package hls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gohlslib/pkg/playlist"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+func TestMuxerStatsObserverProcess(t *testing.T) {
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	o := &muxerStatsObserver{stats: &muxerStats{}}
+
+	o.process(&playlist.Media{
+		TargetDuration: 1,
+		PartInf:        &playlist.MediaPartInf{PartTarget: 200 * time.Millisecond},
+		MediaSequence:  5,
+		Segments: []*playlist.MediaSegment{{
+			DateTime: &start,
+			Duration: 1 * time.Second,
+			Parts: []*playlist.MediaPart{
+				{Duration: 500 * time.Millisecond},
+				{Duration: 500 * time.Millisecond},
+			},
+		}},
+		Parts: []*playlist.MediaPart{
+			{Duration: 200 * time.Millisecond},
+		},
+	}, start.Add(1300*time.Millisecond))
+
+	// the first playlist initializes the state only
+	var item defs.APIHLSMuxer
+	o.stats.fillAPIItem(&item)
+	require.Equal(t, defs.APIHLSMuxer{
+		SegmentTargetDuration: 1,
+		PartTargetDuration:    0.2,
+	}, item)
+
+	second := start.Add(1 * time.Second)
+
+	o.process(&playlist.Media{
+		TargetDuration: 1,
+		PartInf:        &playlist.MediaPartInf{PartTarget: 200 * time.Millisecond},
+		MediaSequence:  5,
+		Segments: []*playlist.MediaSegment{
+			{
+				DateTime: &start,
+				Duration: 1 * time.Second,
+			},
+			{
+				DateTime: &second,
+				Duration: 1500 * time.Millisecond,
+				Parts: []*playlist.MediaPart{
+					{Duration: 200 * time.Millisecond},
+					{Duration: 300 * time.Millisecond},
+					{Duration: 1 * time.Second},
+				},
+			},
+		},
+		Parts: []*playlist.MediaPart{
+			{Duration: 200 * time.Millisecond},
+		},
+	}, start.Add(3*time.Second))
+
+	o.stats.fillAPIItem(&item)
+	require.Equal(t, defs.APIHLSMuxer{
+		SegmentTargetDuration: 1,
+		LastSegmentDuration:   1.5,
+		SegmentsOverTarget:    1,
+		PartTargetDuration:    0.2,
+		LastPartDuration:      0.2,
+		PartsOverTarget:       2,
+		LatePartDeliveries:    3,
+		PlaylistUpdateLatency: 0.3,
+	}, item)
+	require.Equal(t, 6, o.lastMSN)
+	require.Equal(t, 1, o.lastParts)
+}
@@ -0,0 +1,184 @@
+package hls
+
+import (
+	"math"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// maximum time spent observing the stream before choosing the part duration.
+	partDurationEstimationTimeout = 10 * time.Second
+
+	// frames that are observed when key frames can't be detected.
+	partDurationEstimationFrames = 30
+)
+
+// adaptPartDuration returns a part duration between minDuration and maxDuration
+// that contains an integer number of frames and that, when possible,
+// divides the key frame interval, in order to produce segments made of parts with the same duration.
+// The duration is truncated to milliseconds, since parts are closed
+// when their duration reaches it.
+func adaptPartDuration(
+	frameInterval time.Duration,
+	keyFrameInterval time.Duration,
+	minDuration time.Duration,
+	maxDuration time.Duration,
+) time.Duration {
+	if frameInterval <= 0 {
+		return minDuration
+	}
+
+	framesPerKeyFrame := int64(0)
+	if keyFrameInterval > 0 {
+		framesPerKeyFrame = int64(math.Round(float64(keyFrameInterval) / float64(frameInterval)))
+	}
+
+	// tolerate rounding errors of the frame interval
+	minFrames := int64(math.Ceil(float64(minDuration)/float64(frameInterval) - 0.01))
+	if minFrames < 1 {
+		minFrames = 1
+	}
+
+	for n := minFrames; time.Duration(n)*frameInterval <= maxDuration; n++ {
+		if framesPerKeyFrame == 0 || framesPerKeyFrame%n == 0 {
+			return (time.Duration(n) * frameInterval).Truncate(time.Millisecond)
+		}
+	}
+
+	d := time.Duration(minFrames) * frameInterval
+	if d > maxDuration {
+		return maxDuration
+	}
+	return d.Truncate(time.Millisecond)
+}
+
+// partDurationEstimator observes the video track of a stream
+// in order to measure its frame interval and key frame interval.
+type partDurationEstimator struct {
+	writeQueueSize int
+	stream         *stream.Stream
+	parent         logger.Writer
+
+	writer       *asyncwriter.Writer
+	randomAccess func(u unit.Unit) (bool, bool)
+	done         chan struct{}
+
+	frameCount       int
+	lastPTS          time.Duration
+	firstKeyFramePTS *time.Duration
+	frameInterval    time.Duration
+	keyFrameInterval time.Duration
+}
+
+func (e *partDurationEstimator) initialize() {
+	e.done = make(chan struct{})
+
+	medi, forma := firstVideoFormat(e.stream.Desc())
+	if forma == nil {
+		close(e.done)
+		return
+	}
+
+	e.writer = asyncwriter.New(e.writeQueueSize, e.parent)
+
+	switch forma.(type) {
+	case *format.H264:
+		e.randomAccess = func(u unit.Unit) (bool, bool) {
+			tunit := u.(*unit.H264)
+			if tunit.AU == nil {
+				return false, false
+			}
+			return h264.IDRPresent(tunit.AU), true
+		}
+
+	case *format.H265:
+		e.randomAccess = func(u unit.Unit) (bool, bool) {
+			tunit := u.(*unit.H265)
+			if tunit.AU == nil {
+				return false, false
+			}
+			return h265.IsRandomAccess(tunit.AU), true
+		}
+	}
+
+	e.stream.AddReader(e.writer, medi, forma, e.onUnit)
+	e.writer.Start()
+}
+
+func (e *partDurationEstimator) close() {
+	if e.writer != nil {
+		e.stream.RemoveReader(e.writer)
+		e.writer.Stop()
+	}
+}
+
+func firstVideoFormat(desc *description.Session) (*description.Media, format.Format) {
+	for _, medi := range desc.Medias {
+		if medi.Type == description.MediaTypeVideo {
+			return medi, medi.Formats[0]
+		}
+	}
+	return nil, nil
+}
+
+func (e *partDurationEstimator) isDone() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *partDurationEstimator) onUnit(u unit.Unit) error {
+	if e.isDone() {
+		return nil
+	}
+
+	isRandomAccess := false
+
+	if e.randomAccess != nil {
+		var ok bool
+		isRandomAccess, ok = e.randomAccess(u)
+		if !ok {
+			return nil
+		}
+	}
+
+	pts := u.GetPTS()
+
+	// when B-frames are present, the smallest PTS difference is the frame interval
+	if e.frameCount != 0 {
+		if d := pts - e.lastPTS; d > 0 && (e.frameInterval == 0 || d < e.frameInterval) {
+			e.frameInterval = d
+		}
+	}
+	e.lastPTS = pts
+	e.frameCount++
+
+	if isRandomAccess {
+		if e.firstKeyFramePTS == nil {
+			e.firstKeyFramePTS = &pts
+		} else if pts > *e.firstKeyFramePTS {
+			e.keyFrameInterval = pts - *e.firstKeyFramePTS
+			close(e.done)
+			return nil
+		}
+	}
+
+	if e.randomAccess == nil && e.frameCount >= partDurationEstimationFrames {
+		close(e.done)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package hls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestAdaptPartDuration(t *testing.T) {
+	for _, ca := range []struct {
+		name             string
+		frameInterval    time.Duration
+		keyFrameInterval time.Duration
+		out              time.Duration
+	}{
+		{
+			"unknown frame rate",
+			0,
+			0,
+			100 * time.Millisecond,
+		},
+		{
+			"15 fps, unknown key frame interval",
+			time.Second / 15,
+			0,
+			133 * time.Millisecond,
+		},
+		{
+			"15 fps, key frame every 2 seconds",
+			time.Second / 15,
+			2 * time.Second,
+			133 * time.Millisecond,
+		},
+		{
+			"30 fps, key frame every second",
+			time.Second / 30,
+			time.Second,
+			99 * time.Millisecond,
+		},
+		{
+			"60 fps, key frame every 28 frames",
+			time.Second / 60,
+			28 * time.Second / 60,
+			116 * time.Millisecond,
+		},
+		{
+			"key frame interval with no divisors in range",
+			time.Second / 30,
+			31 * time.Second / 30,
+			99 * time.Millisecond,
+		},
+		{
+			"1 fps",
+			time.Second,
+			10 * time.Second,
+			500 * time.Millisecond,
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			out := adaptPartDuration(ca.frameInterval, ca.keyFrameInterval,
+				100*time.Millisecond, 500*time.Millisecond)
+			require.Equal(t, ca.out, out)
+		})
+	}
+}
+
+func TestPartDurationEstimator(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	e := &partDurationEstimator{
+		writeQueueSize: 512,
+		stream:         strm,
+		parent:         test.NilLogger,
+	}
+	e.initialize()
+
+	frameInterval := time.Second / 25
+
+	for i := 0; i <= 50; i++ {
+		au := [][]byte{{1, 2}} // non-IDR
+		if i%25 == 0 {
+			au = [][]byte{{5, 2}} // IDR
+		}
+
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * frameInterval,
+			},
+			AU: au,
+		})
+	}
+
+	select {
+	case <-e.done:
+	case <-time.After(2 * time.Second):
+		t.Errorf("estimation did not complete")
+	}
+
+	e.close()
+
+	require.Equal(t, frameInterval, e.frameInterval)
+	require.Equal(t, time.Second, e.keyFrameInterval)
+}
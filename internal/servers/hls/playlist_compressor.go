@@ -0,0 +1,97 @@
+package hls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// number of compressed playlists that are kept in memory.
+// LL-HLS playlists change several times per second,
+// but each version is fetched by all clients.
+const playlistCompressorCacheSize = 16
+
+// playlists smaller than this are served uncompressed.
+const playlistCompressorMinSize = 256
+
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			enc, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+			if strings.EqualFold(enc, "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// playlistCompressor serves playlists compressed with gzip to clients that support it,
+// and caches compressed playlists in order to compress each of them once.
+type playlistCompressor struct {
+	mutex sync.Mutex
+	cache map[string][]byte
+	order []string
+}
+
+func (c *playlistCompressor) compress(byts []byte) []byte {
+	key := string(byts)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.cache == nil {
+		c.cache = make(map[string][]byte)
+	}
+
+	if compressed, ok := c.cache[key]; ok {
+		return compressed
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(byts) //nolint:errcheck
+	gw.Close()
+	compressed := buf.Bytes()
+
+	if len(c.order) >= playlistCompressorCacheSize {
+		delete(c.cache, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.cache[key] = compressed
+	c.order = append(c.order, key)
+
+	return compressed
+}
+
+func (c *playlistCompressor) serve(
+	w http.ResponseWriter,
+	r *http.Request,
+	handler func(w http.ResponseWriter, r *http.Request),
+) {
+	if !acceptsGzip(r) {
+		handler(w, r)
+		return
+	}
+
+	rec := &bufferedResponseWriter{
+		header: make(http.Header),
+		code:   http.StatusOK,
+	}
+	handler(rec, r)
+	copyHeader(w, rec)
+
+	if rec.code != http.StatusOK || rec.buf.Len() < playlistCompressorMinSize {
+		w.WriteHeader(rec.code)
+		w.Write(rec.buf.Bytes())
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.WriteHeader(http.StatusOK)
+	w.Write(c.compress(rec.buf.Bytes()))
+}
@@ -0,0 +1,84 @@
+package hls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	for _, ca := range []struct {
+		header string
+		out    bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=1.0, *;q=0.5", true},
+		{"br, GZIP", true},
+		{"gzip;q=0", false},
+		{"identity", false},
+	} {
+		t.Run(ca.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/stream.m3u8", nil)
+			if ca.header != "" {
+				r.Header.Set("Accept-Encoding", ca.header)
+			}
+			require.Equal(t, ca.out, acceptsGzip(r))
+		})
+	}
+}
+
+func TestPlaylistCompressor(t *testing.T) {
+	playlist := "#EXTM3U\n" + strings.Repeat("#EXTINF:1.00000,\nseg.mp4\n", 20)
+
+	calls := 0
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(playlist))
+	}
+
+	c := &playlistCompressor{}
+
+	var compressed [][]byte
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/stream.m3u8", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		c.serve(w, r, handler)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		require.Equal(t, "application/vnd.apple.mpegurl", w.Header().Get("Content-Type"))
+
+		gr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		dec, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		require.Equal(t, playlist, string(dec))
+
+		compressed = append(compressed, w.Body.Bytes())
+	}
+
+	// the compressed playlist is cached
+	require.Equal(t, compressed[0], compressed[1])
+	require.Equal(t, 1, len(c.cache))
+
+	r := httptest.NewRequest(http.MethodGet, "/stream.m3u8", nil)
+	w := httptest.NewRecorder()
+
+	c.serve(w, r, handler)
+
+	require.Equal(t, "", w.Header().Get("Content-Encoding"))
+	require.Equal(t, playlist, w.Body.String())
+	require.Equal(t, 3, calls)
+}
@@ -5,12 +5,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
@@ -23,11 +26,11 @@ type serverGetMuxerRes struct {
 }
 
 type serverGetMuxerReq struct {
-	path           string
-	remoteAddr     string
-	query          string
-	sourceOnDemand bool
-	res            chan serverGetMuxerRes
+	path       string
+	remoteAddr string
+	query      string
+	pathConf   *conf.Path
+	res        chan serverGetMuxerRes
 }
 
 type serverAPIMuxersListRes struct {
@@ -60,24 +63,37 @@ type serverParent interface {
 
 // Server is a HLS server.
 type Server struct {
-	Address         string
-	Encryption      bool
-	ServerKey       string
-	ServerCert      string
-	AllowOrigin     string
-	TrustedProxies  conf.IPNetworks
-	AlwaysRemux     bool
-	Variant         conf.HLSVariant
-	SegmentCount    int
-	SegmentDuration conf.StringDuration
-	PartDuration    conf.StringDuration
-	SegmentMaxSize  conf.StringSize
-	Directory       string
-	ReadTimeout     conf.StringDuration
-	WriteQueueSize  int
-	MuxerCloseAfter conf.StringDuration
-	PathManager     serverPathManager
-	Parent          serverParent
+	Address              string
+	Listeners            conf.Listeners
+	Encryption           bool
+	ServerKey            string
+	ServerCert           string
+	TLSOptions           conf.TLSOptions
+	ServerHeader         string
+	ResponseHeaders      conf.HTTPResponseHeaders
+	AllowOrigin          string
+	TrustedProxies       conf.IPNetworks
+	AlwaysRemux          bool
+	Variant              conf.HLSVariant
+	SegmentCount         int
+	SegmentDuration      conf.StringDuration
+	PartDuration         conf.StringDuration
+	AdaptivePartDuration bool
+	PartMinDuration      conf.StringDuration
+	PartMaxDuration      conf.StringDuration
+	SegmentMaxSize       conf.StringSize
+	AudioOnlyRendition   bool
+	VideoGapFilling      bool
+	VideoGapThreshold    conf.StringDuration
+	Directory            string
+	ReadTimeout          conf.StringDuration
+	WriteQueueSize       int
+	MuxerCloseAfter      conf.StringDuration
+	SessionSecret        string
+	InstanceID           string
+	RequestLimiter       *httpp.RequestLimiter
+	PathManager          serverPathManager
+	Parent               serverParent
 
 	ctx        context.Context
 	ctxCancel  func()
@@ -108,16 +124,41 @@ func (s *Server) Initialize() error {
 	s.chAPIMuxerList = make(chan serverAPIMuxersListReq)
 	s.chAPIMuxerGet = make(chan serverAPIMuxersGetReq)
 
+	var tokens *sessionTokens
+
+	if s.SessionSecret != "" {
+		instanceID := s.InstanceID
+		if instanceID == "" {
+			var err error
+			instanceID, err = os.Hostname()
+			if err != nil {
+				ctxCancel()
+				return err
+			}
+		}
+
+		tokens = &sessionTokens{
+			instanceID: instanceID,
+			secret:     []byte(s.SessionSecret),
+		}
+	}
+
 	s.httpServer = &httpServer{
-		address:        s.Address,
-		encryption:     s.Encryption,
-		serverKey:      s.ServerKey,
-		serverCert:     s.ServerCert,
-		allowOrigin:    s.AllowOrigin,
-		trustedProxies: s.TrustedProxies,
-		readTimeout:    s.ReadTimeout,
-		pathManager:    s.PathManager,
-		parent:         s,
+		address:         s.Address,
+		listeners:       s.Listeners,
+		encryption:      s.Encryption,
+		serverKey:       s.ServerKey,
+		serverCert:      s.ServerCert,
+		tlsOptions:      s.TLSOptions,
+		serverHeader:    s.ServerHeader,
+		responseHeaders: s.ResponseHeaders,
+		allowOrigin:     s.AllowOrigin,
+		trustedProxies:  s.TrustedProxies,
+		readTimeout:     s.ReadTimeout,
+		requestLimiter:  s.RequestLimiter,
+		sessionTokens:   tokens,
+		pathManager:     s.PathManager,
+		parent:          s,
 	}
 	err := s.httpServer.initialize()
 	if err != nil {
@@ -125,7 +166,8 @@ func (s *Server) Initialize() error {
 		return err
 	}
 
-	s.Log(logger.Info, "listener opened on "+s.Address)
+	s.Log(logger.Info, "listener opened on %s",
+		strings.Join(append([]string{s.Address}, s.Listeners.Addresses()...), ", "))
 
 	s.wg.Add(1)
 	go s.run()
@@ -152,7 +194,7 @@ outer:
 	for {
 		select {
 		case pa := <-s.chPathReady:
-			if s.AlwaysRemux && !pa.SafeConf().SourceOnDemand {
+			if s.muxerIsPreWarmed(pa.SafeConf()) {
 				if _, ok := s.muxers[pa.Name()]; !ok {
 					s.createMuxer(pa.Name(), "", "")
 				}
@@ -170,7 +212,7 @@ outer:
 			switch {
 			case ok:
 				req.res <- serverGetMuxerRes{muxer: mux}
-			case s.AlwaysRemux && !req.sourceOnDemand:
+			case s.muxerIsPreWarmed(req.pathConf):
 				req.res <- serverGetMuxerRes{err: fmt.Errorf("muxer is waiting to be created")}
 			default:
 				req.res <- serverGetMuxerRes{muxer: s.createMuxer(req.path, req.remoteAddr, req.query)}
@@ -217,23 +259,49 @@ outer:
 	s.httpServer.close()
 }
 
+// muxerIsPreWarmed returns whether the muxer of a path is created
+// as soon as the path is ready, instead of on first request.
+func (s *Server) muxerIsPreWarmed(pathConf *conf.Path) bool {
+	// muxers would keep on-demand sources running forever
+	if pathConf.SourceOnDemand {
+		return false
+	}
+
+	switch pathConf.HLSMuxerCreation {
+	case conf.HLSMuxerCreationPreWarm:
+		return true
+
+	case conf.HLSMuxerCreationLazy:
+		return false
+
+	default:
+		return s.AlwaysRemux
+	}
+}
+
 func (s *Server) createMuxer(pathName string, remoteAddr string, query string) *muxer {
 	r := &muxer{
-		parentCtx:       s.ctx,
-		remoteAddr:      remoteAddr,
-		variant:         s.Variant,
-		segmentCount:    s.SegmentCount,
-		segmentDuration: s.SegmentDuration,
-		partDuration:    s.PartDuration,
-		segmentMaxSize:  s.SegmentMaxSize,
-		directory:       s.Directory,
-		writeQueueSize:  s.WriteQueueSize,
-		wg:              &s.wg,
-		pathName:        pathName,
-		pathManager:     s.PathManager,
-		parent:          s,
-		query:           query,
-		closeAfter:      s.MuxerCloseAfter,
+		parentCtx:            s.ctx,
+		remoteAddr:           remoteAddr,
+		variant:              s.Variant,
+		segmentCount:         s.SegmentCount,
+		segmentDuration:      s.SegmentDuration,
+		partDuration:         s.PartDuration,
+		adaptivePartDuration: s.AdaptivePartDuration,
+		partMinDuration:      s.PartMinDuration,
+		partMaxDuration:      s.PartMaxDuration,
+		segmentMaxSize:       s.SegmentMaxSize,
+		audioOnlyRendition:   s.AudioOnlyRendition,
+		videoGapFilling:      s.VideoGapFilling,
+		videoGapThreshold:    s.VideoGapThreshold,
+		directory:            s.Directory,
+		writeQueueSize:       s.WriteQueueSize,
+		wg:                   &s.wg,
+		pathName:             pathName,
+		pathManager:          s.PathManager,
+		parent:               s,
+		query:                query,
+		closeAfter:           s.MuxerCloseAfter,
 	}
 	r.initialize()
 	s.muxers[pathName] = r
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 	"time"
 
@@ -15,19 +17,25 @@ import (
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/bluenviron/mediamtx/internal/unit"
 	"github.com/stretchr/testify/require"
 )
 
-type dummyPath struct{}
+type dummyPath struct {
+	conf *conf.Path
+}
 
 func (pa *dummyPath) Name() string {
 	return "mystream"
 }
 
 func (pa *dummyPath) SafeConf() *conf.Path {
+	if pa.conf != nil {
+		return pa.conf
+	}
 	return &conf.Path{}
 }
 
@@ -166,6 +174,129 @@ func TestServerNotFound(t *testing.T) {
 	}
 }
 
+func TestServerListeners(t *testing.T) {
+	pm := &dummyPathManager{
+		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
+			return &conf.Path{}, nil
+		},
+	}
+
+	s := &Server{
+		Address:         "127.0.0.1:8888",
+		Listeners:       conf.Listeners{{Address: "127.0.0.1:8887"}},
+		Variant:         conf.HLSVariant(gohlslib.MuxerVariantMPEGTS),
+		SegmentCount:    7,
+		SegmentDuration: conf.StringDuration(1 * time.Second),
+		PartDuration:    conf.StringDuration(200 * time.Millisecond),
+		SegmentMaxSize:  50 * 1024 * 1024,
+		TrustedProxies:  conf.IPNetworks{},
+		ReadTimeout:     conf.StringDuration(10 * time.Second),
+		WriteQueueSize:  512,
+		PathManager:     pm,
+		Parent:          test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	for _, address := range []string{"127.0.0.1:8888", "127.0.0.1:8887"} {
+		func() {
+			req, err := http.NewRequest(http.MethodGet, "http://"+address+"/mypath/", nil)
+			require.NoError(t, err)
+
+			res, err := hc.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, http.StatusOK, res.StatusCode)
+		}()
+	}
+}
+
+func TestServerSessionToken(t *testing.T) {
+	pm := &dummyPathManager{
+		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
+			return &conf.Path{}, nil
+		},
+		addReader: func(_ defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+			return nil, nil, fmt.Errorf("not found")
+		},
+	}
+
+	s := &Server{
+		Address:         "127.0.0.1:8888",
+		Variant:         conf.HLSVariant(gohlslib.MuxerVariantMPEGTS),
+		SegmentCount:    7,
+		SegmentDuration: conf.StringDuration(1 * time.Second),
+		PartDuration:    conf.StringDuration(200 * time.Millisecond),
+		SegmentMaxSize:  50 * 1024 * 1024,
+		TrustedProxies:  conf.IPNetworks{},
+		ReadTimeout:     conf.StringDuration(10 * time.Second),
+		WriteQueueSize:  512,
+		SessionSecret:   "mysecret",
+		InstanceID:      "instance1",
+		PathManager:     pm,
+		Parent:          test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{
+		Transport: tr,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	get := func(u string) *http.Response {
+		res, err2 := hc.Get(u)
+		require.NoError(t, err2)
+		res.Body.Close()
+		return res
+	}
+
+	res := get("http://127.0.0.1:8888/mystream/index.m3u8?key=value")
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/mystream/index.m3u8", loc.Path)
+	require.Equal(t, "value", loc.Query().Get("key"))
+
+	token := loc.Query().Get("hlsSession")
+	instanceID, ok := s.httpServer.sessionTokens.verify(token, "mystream")
+	require.True(t, ok)
+	require.Equal(t, "instance1", instanceID)
+
+	// token is valid, stream is not
+	res = get("http://127.0.0.1:8888" + loc.String())
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// token generated by another instance that shares the same secret
+	other := &sessionTokens{instanceID: "instance2", secret: []byte("mysecret")}
+	token, err = other.generate("mystream")
+	require.NoError(t, err)
+	res = get("http://127.0.0.1:8888/mystream/stream.m3u8?hlsSession=" + url.QueryEscape(token))
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// token bound to another path
+	res = get("http://127.0.0.1:8888/otherstream/stream.m3u8?hlsSession=" + url.QueryEscape(token))
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// token generated with another secret
+	other = &sessionTokens{instanceID: "instance1", secret: []byte("othersecret")}
+	token, err = other.generate("mystream")
+	require.NoError(t, err)
+	res = get("http://127.0.0.1:8888/mystream/stream.m3u8?hlsSession=" + url.QueryEscape(token))
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
 func TestServerRead(t *testing.T) {
 	t.Run("always remux off", func(t *testing.T) {
 		desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
@@ -174,6 +305,7 @@ func TestServerRead(t *testing.T) {
 			1460,
 			desc,
 			true,
+			formatprocessor.Options{},
 			test.NilLogger,
 		)
 		require.NoError(t, err)
@@ -269,6 +401,7 @@ func TestServerRead(t *testing.T) {
 			1460,
 			desc,
 			true,
+			formatprocessor.Options{},
 			test.NilLogger,
 		)
 		require.NoError(t, err)
@@ -359,6 +492,77 @@ func TestServerRead(t *testing.T) {
 	})
 }
 
+func TestServerMuxerCreation(t *testing.T) {
+	for _, ca := range []struct {
+		name        string
+		alwaysRemux bool
+		creation    conf.HLSMuxerCreation
+		onDemand    bool
+		created     bool
+	}{
+		{"global off", false, conf.HLSMuxerCreationGlobal, false, false},
+		{"global on", true, conf.HLSMuxerCreationGlobal, false, true},
+		{"pre-warm", false, conf.HLSMuxerCreationPreWarm, false, true},
+		{"pre-warm on demand", false, conf.HLSMuxerCreationPreWarm, true, false},
+		{"lazy", true, conf.HLSMuxerCreationLazy, false, false},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+			str, err := stream.New(
+				1460,
+				desc,
+				true,
+				formatprocessor.Options{},
+				test.NilLogger,
+			)
+			require.NoError(t, err)
+
+			pathConf := &conf.Path{
+				HLSMuxerCreation: ca.creation,
+				SourceOnDemand:   ca.onDemand,
+			}
+
+			pm := &dummyPathManager{
+				addReader: func(_ defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+					return &dummyPath{conf: pathConf}, str, nil
+				},
+			}
+
+			s := &Server{
+				Address:         "127.0.0.1:8888",
+				AlwaysRemux:     ca.alwaysRemux,
+				Variant:         conf.HLSVariant(gohlslib.MuxerVariantMPEGTS),
+				SegmentCount:    7,
+				SegmentDuration: conf.StringDuration(1 * time.Second),
+				PartDuration:    conf.StringDuration(200 * time.Millisecond),
+				SegmentMaxSize:  50 * 1024 * 1024,
+				TrustedProxies:  conf.IPNetworks{},
+				ReadTimeout:     conf.StringDuration(10 * time.Second),
+				WriteQueueSize:  512,
+				PathManager:     pm,
+				Parent:          test.NilLogger,
+			}
+			err = s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			s.PathReady(&dummyPath{conf: pathConf})
+
+			time.Sleep(100 * time.Millisecond)
+
+			list, err := s.APIMuxersList()
+			require.NoError(t, err)
+
+			if ca.created {
+				require.Equal(t, 1, len(list.Items))
+			} else {
+				require.Equal(t, 0, len(list.Items))
+			}
+		})
+	}
+}
+
 func TestServerReadAuthorizationHeader(t *testing.T) {
 	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
 
@@ -366,6 +570,7 @@ func TestServerReadAuthorizationHeader(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -466,6 +671,7 @@ func TestDirectory(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -506,3 +712,96 @@ func TestDirectory(t *testing.T) {
 	_, err = os.Stat(filepath.Join(dir, "mydir", "mystream"))
 	require.NoError(t, err)
 }
+
+func TestServerAudioOnlyRendition(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264, test.MediaMPEG4Audio}}
+
+	str, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+
+	pm := &dummyPathManager{
+		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
+			return &conf.Path{}, nil
+		},
+		addReader: func(_ defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+			return &dummyPath{}, str, nil
+		},
+	}
+
+	s := &Server{
+		Address:            "127.0.0.1:8888",
+		AlwaysRemux:        true,
+		Variant:            conf.HLSVariant(gohlslib.MuxerVariantMPEGTS),
+		SegmentCount:       7,
+		SegmentDuration:    conf.StringDuration(1 * time.Second),
+		PartDuration:       conf.StringDuration(200 * time.Millisecond),
+		SegmentMaxSize:     50 * 1024 * 1024,
+		AudioOnlyRendition: true,
+		TrustedProxies:     conf.IPNetworks{},
+		ReadTimeout:        conf.StringDuration(10 * time.Second),
+		WriteQueueSize:     512,
+		PathManager:        pm,
+		Parent:             test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	s.PathReady(&dummyPath{})
+
+	time.Sleep(100 * time.Millisecond)
+
+	// audio-only MPEG-TS segments require at least 100 access units
+	for i := 0; i < 300; i++ {
+		if i%100 == 0 {
+			str.WriteUnit(test.MediaH264, test.FormatH264, &unit.H264{
+				Base: unit.Base{
+					PTS: time.Duration(i) * 10 * time.Millisecond,
+				},
+				AU: [][]byte{
+					{5, 1}, // IDR
+				},
+			})
+		}
+
+		str.WriteUnit(test.MediaMPEG4Audio, test.FormatMPEG4Audio, &unit.MPEG4Audio{
+			Base: unit.Base{
+				PTS: time.Duration(i) * 10 * time.Millisecond,
+			},
+			AUs: [][]byte{{1, 2, 3, 4}},
+		})
+	}
+
+	get := func(u string) string {
+		hc := &http.Client{Transport: &http.Transport{}}
+
+		res, err2 := hc.Get(u)
+		require.NoError(t, err2)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		byts, err2 := io.ReadAll(res.Body)
+		require.NoError(t, err2)
+
+		return string(byts)
+	}
+
+	mpl := get("http://127.0.0.1:8888/mystream/index.m3u8")
+	require.Regexp(t, `CODECS="avc1.42c028,mp4a.40.2",.+?\n`+
+		`stream.m3u8\n`+
+		`#EXT-X-STREAM-INF:BANDWIDTH=[0-9]+,AVERAGE-BANDWIDTH=[0-9]+,CODECS="mp4a.40.2"\n`+
+		`audio_stream.m3u8\n$`, mpl)
+
+	pl := get("http://127.0.0.1:8888/mystream/audio_stream.m3u8")
+	require.Regexp(t, `\naudio_[0-9a-f]+_seg0.ts\n`, pl)
+
+	seg := regexp.MustCompile(`audio_[0-9a-f]+_seg0.ts`).FindString(pl)
+	get("http://127.0.0.1:8888/mystream/" + seg)
+}
@@ -0,0 +1,62 @@
+package hls
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// query parameter that contains the session token.
+const sessionTokenParam = "hlsSession"
+
+// sessionTokens generates and verifies session tokens.
+// A token is embedded in playlist URLs and contains the ID of the instance
+// that generated it, allowing load balancers to route all requests of a session
+// to the same instance.
+// A token is signed with a secret shared between instances, therefore any instance
+// can validate it and serve the session by re-creating the muxer.
+type sessionTokens struct {
+	instanceID string
+	secret     []byte
+}
+
+func (t *sessionTokens) sign(instanceID string, session string, path string) string {
+	h := hmac.New(sha256.New, t.secret)
+	h.Write([]byte(instanceID + "\n" + session + "\n" + path))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (t *sessionTokens) generate(path string) (string, error) {
+	var buf [8]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return "", err
+	}
+	session := hex.EncodeToString(buf[:])
+
+	return t.instanceID + "." + session + "." + t.sign(t.instanceID, session, path), nil
+}
+
+// verify checks whether a token is valid for the given path and returns the ID of the instance that generated it.
+func (t *sessionTokens) verify(token string, path string) (string, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", false
+	}
+	rest, signature := token[:i], token[i+1:]
+
+	i = strings.LastIndexByte(rest, '.')
+	if i < 0 {
+		return "", false
+	}
+	instanceID, session := rest[:i], rest[i+1:]
+
+	if !hmac.Equal([]byte(signature), []byte(t.sign(instanceID, session, path))) {
+		return "", false
+	}
+
+	return instanceID, true
+}
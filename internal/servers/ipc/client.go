@@ -0,0 +1,84 @@
+package ipc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Client publishes a stream to an IPC server.
+type Client struct {
+	// path of the socket.
+	Address string
+	// path name, optionally followed by a query.
+	Path string
+	// source of the MPEG-TS or fMP4 stream.
+	Source io.Reader
+}
+
+// Run publishes the stream until the source ends or the server reports an error.
+func (c *Client) Run() error {
+	// wait for the first bytes of the source and check its format before connecting,
+	// in order to report errors locally and not to hit the read timeout of the server
+	// while the source is starting up.
+	br := bufio.NewReader(c.Source)
+	_, err := detectContainerFormat(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("source is empty")
+		}
+		return err
+	}
+
+	nconn, err := net.Dial("unix", c.Address)
+	if err != nil {
+		return err
+	}
+	defer nconn.Close()
+
+	_, err = nconn.Write([]byte(strings.TrimPrefix(c.Path, "/") + "\n"))
+	if err != nil {
+		return err
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		line, err2 := bufio.NewReader(nconn).ReadString('\n')
+		if err2 != nil {
+			serverErr <- nil
+			return
+		}
+		serverErr <- errors.New(strings.TrimPrefix(strings.TrimRight(line, "\r\n"), "ERR: "))
+	}()
+
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err2 := io.Copy(nconn, br)
+		copyErr <- err2
+	}()
+
+	select {
+	case err = <-serverErr:
+		if err != nil {
+			return err
+		}
+		return errors.New("connection closed by the server")
+
+	case err = <-copyErr:
+		if err != nil {
+			// writes fail when the server closes the connection, prefer the error reported by the server
+			select {
+			case err2 := <-serverErr:
+				if err2 != nil {
+					return err2
+				}
+			case <-time.After(time.Second):
+			}
+		}
+		return err
+	}
+}
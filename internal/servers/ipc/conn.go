@@ -0,0 +1,313 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	mcmpegts "github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+	"github.com/google/uuid"
+
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/hooks"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/fmp4"
+	"github.com/bluenviron/mediamtx/internal/protocols/mpegts"
+	"github.com/bluenviron/mediamtx/internal/protocols/rtmp/bytecounter"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+const (
+	maxHeaderSize = 1024
+)
+
+type containerFormat int
+
+const (
+	containerFormatMPEGTS containerFormat = iota
+	containerFormatFMP4
+)
+
+// header is the first line sent by clients, in the format "path?query".
+type header struct {
+	path  string
+	query string
+	user  string
+	pass  string
+}
+
+func (h *header) unmarshal(br *bufio.Reader) error {
+	line, err := br.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return fmt.Errorf("header is too long")
+		}
+		return err
+	}
+
+	h.path = strings.TrimRight(string(line), "\r\n")
+
+	if i := strings.IndexByte(h.path, '?'); i >= 0 {
+		h.query = h.path[i+1:]
+		h.path = h.path[:i]
+	}
+
+	h.path = strings.TrimPrefix(h.path, "/")
+
+	if h.path == "" {
+		return fmt.Errorf("path name is missing")
+	}
+
+	values, err := url.ParseQuery(h.query)
+	if err != nil {
+		return err
+	}
+
+	h.user = values.Get("user")
+	h.pass = values.Get("pass")
+
+	return nil
+}
+
+func detectContainerFormat(br *bufio.Reader) (containerFormat, error) {
+	buf, err := br.Peek(8)
+	if err != nil {
+		return 0, err
+	}
+
+	if buf[0] == 0x47 {
+		return containerFormatMPEGTS, nil
+	}
+
+	switch string(buf[4:8]) {
+	case "ftyp", "moov", "styp", "free":
+		return containerFormatFMP4, nil
+	}
+
+	return 0, fmt.Errorf("unable to detect the format of the stream, only MPEG-TS and fMP4 are supported")
+}
+
+type conn struct {
+	parentCtx           context.Context
+	rtspAddress         string
+	readTimeout         conf.StringDuration
+	nconn               net.Conn
+	runOnConnect        string
+	runOnConnectRestart bool
+	runOnDisconnect     string
+	wg                  *sync.WaitGroup
+	externalCmdPool     *externalcmd.Pool
+	pathManager         serverPathManager
+	parent              *Server
+
+	ctx       context.Context
+	ctxCancel func()
+	uuid      uuid.UUID
+	bc        *bytecounter.Reader
+}
+
+func (c *conn) initialize() {
+	c.ctx, c.ctxCancel = context.WithCancel(c.parentCtx)
+
+	c.uuid = uuid.New()
+	c.bc = bytecounter.NewReader(c.nconn)
+
+	c.Log(logger.Info, "opened")
+
+	c.wg.Add(1)
+	go c.run()
+}
+
+// Log implements logger.Writer.
+func (c *conn) Log(level logger.Level, format string, args ...interface{}) {
+	c.parent.Log(level, "[conn %v] "+format, append([]interface{}{c.uuid}, args...)...)
+}
+
+// Close closes the connection.
+func (c *conn) Close() {
+	c.ctxCancel()
+}
+
+func (c *conn) run() {
+	defer c.wg.Done()
+
+	onDisconnectHook := hooks.OnConnect(hooks.OnConnectParams{
+		Logger:              c,
+		ExternalCmdPool:     c.externalCmdPool,
+		RunOnConnect:        c.runOnConnect,
+		RunOnConnectRestart: c.runOnConnectRestart,
+		RunOnDisconnect:     c.runOnDisconnect,
+		RTSPAddress:         c.rtspAddress,
+		Desc:                c.APISourceDescribe(),
+	})
+	defer onDisconnectHook()
+
+	readerErr := make(chan error)
+	go func() {
+		readerErr <- c.runReader()
+	}()
+
+	var err error
+
+	select {
+	case err = <-readerErr:
+		// report the error to the client, that might be waiting for it
+		c.nconn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.nconn.Write([]byte("ERR: " + err.Error() + "\n")) //nolint:errcheck
+		c.nconn.Close()
+
+	case <-c.ctx.Done():
+		c.nconn.Close()
+		<-readerErr
+		err = errors.New("terminated")
+	}
+
+	c.ctxCancel()
+
+	c.parent.closeConn(c)
+
+	c.Log(logger.Info, "closed: %v", err)
+}
+
+func (c *conn) runReader() error {
+	c.nconn.SetReadDeadline(time.Now().Add(time.Duration(c.readTimeout)))
+	br := bufio.NewReaderSize(c.bc, maxHeaderSize)
+
+	var h header
+	err := h.unmarshal(br)
+	if err != nil {
+		return fmt.Errorf("invalid header: %w", err)
+	}
+
+	path, err := c.pathManager.AddPublisher(defs.PathAddPublisherReq{
+		Author: c,
+		AccessRequest: defs.PathAccessRequest{
+			Name:    h.path,
+			Query:   h.query,
+			Publish: true,
+			IP:      net.IPv4(127, 0, 0, 1),
+			User:    h.user,
+			Pass:    h.pass,
+			Proto:   auth.ProtocolIPC,
+			ID:      &c.uuid,
+		},
+	})
+	if err != nil {
+		var terr auth.Error
+		if errors.As(err, &terr) {
+			// wait some seconds to mitigate brute force attacks
+			<-time.After(auth.PauseAfterError)
+			return terr
+		}
+		return err
+	}
+
+	defer path.RemovePublisher(defs.PathRemovePublisherReq{Author: c})
+
+	format, err := detectContainerFormat(br)
+	if err != nil {
+		return err
+	}
+
+	if format == containerFormatMPEGTS {
+		return c.runMPEGTS(br, path)
+	}
+	return c.runFMP4(br, path)
+}
+
+func (c *conn) runMPEGTS(br *bufio.Reader, path defs.Path) error {
+	r, err := mcmpegts.NewReader(mcmpegts.NewBufferedReader(br))
+	if err != nil {
+		return err
+	}
+
+	decodeErrLogger := logger.NewLimitedLogger(c)
+
+	r.OnDecodeError(func(err error) {
+		decodeErrLogger.Log(logger.Warn, err.Error())
+	})
+
+	var stream *stream.Stream
+
+	medias, err := mpegts.ToStream(r, &stream, c)
+	if err != nil {
+		return err
+	}
+
+	stream, err = path.StartPublisher(defs.PathStartPublisherReq{
+		Author:             c,
+		Desc:               &description.Session{Medias: medias},
+		GenerateRTPPackets: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		c.nconn.SetReadDeadline(time.Now().Add(time.Duration(c.readTimeout)))
+		err = r.Read()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *conn) runFMP4(br *bufio.Reader, path defs.Path) error {
+	r := &fmp4.Reader{R: br}
+	err := r.Initialize()
+	if err != nil {
+		return err
+	}
+
+	var stream *stream.Stream
+
+	medias, err := fmp4.ToStream(r, &stream, c)
+	if err != nil {
+		return err
+	}
+
+	stream, err = path.StartPublisher(defs.PathStartPublisherReq{
+		Author:             c,
+		Desc:               &description.Session{Medias: medias},
+		GenerateRTPPackets: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		c.nconn.SetReadDeadline(time.Now().Add(time.Duration(c.readTimeout)))
+		err = r.Read()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// APISourceDescribe implements source.
+func (c *conn) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "ipcConn",
+		ID:   c.uuid.String(),
+	}
+}
+
+// BytesReceived implements bandwidth.Counter.
+func (c *conn) BytesReceived() uint64 {
+	return c.bc.Count()
+}
+
+// BytesSent implements bandwidth.Counter.
+func (c *conn) BytesSent() uint64 {
+	return 0
+}
@@ -0,0 +1,36 @@
+package ipc
+
+import (
+	"net"
+	"sync"
+)
+
+type listener struct {
+	ln     net.Listener
+	wg     *sync.WaitGroup
+	parent *Server
+}
+
+func (l *listener) initialize() {
+	l.wg.Add(1)
+	go l.run()
+}
+
+func (l *listener) run() {
+	defer l.wg.Done()
+
+	err := l.runInner()
+
+	l.parent.acceptError(err)
+}
+
+func (l *listener) runInner() error {
+	for {
+		nconn, err := l.ln.Accept()
+		if err != nil {
+			return err
+		}
+
+		l.parent.newConn(nconn)
+	}
+}
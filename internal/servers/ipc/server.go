@@ -0,0 +1,179 @@
+// Package ipc contains a server that allows to publish streams through a local socket.
+package ipc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+type serverPathManager interface {
+	AddPublisher(req defs.PathAddPublisherReq) (defs.Path, error)
+}
+
+type serverParent interface {
+	logger.Writer
+}
+
+// removeStaleSocket removes a socket left by a previous instance that was not closed properly.
+func removeStaleSocket(address string) error {
+	fi, err := os.Stat(address)
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("'%s' already exists and is not a socket", address)
+	}
+
+	// do not remove sockets that are still in use
+	nconn, err := net.Dial("unix", address)
+	if err == nil {
+		nconn.Close()
+		return fmt.Errorf("'%s' is in use by another process", address)
+	}
+
+	return os.Remove(address)
+}
+
+// Server is a IPC server.
+type Server struct {
+	Address             string
+	RTSPAddress         string
+	ReadTimeout         conf.StringDuration
+	RunOnConnect        string
+	RunOnConnectRestart bool
+	RunOnDisconnect     string
+	ExternalCmdPool     *externalcmd.Pool
+	PathManager         serverPathManager
+	Parent              serverParent
+
+	ctx       context.Context
+	ctxCancel func()
+	wg        sync.WaitGroup
+	ln        net.Listener
+	conns     map[*conn]struct{}
+
+	// in
+	chNewConn   chan net.Conn
+	chAcceptErr chan error
+	chCloseConn chan *conn
+}
+
+// Initialize initializes the server.
+func (s *Server) Initialize() error {
+	err := removeStaleSocket(s.Address)
+	if err != nil {
+		return err
+	}
+
+	s.ln, err = net.Listen("unix", s.Address)
+	if err != nil {
+		return err
+	}
+
+	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
+
+	s.conns = make(map[*conn]struct{})
+	s.chNewConn = make(chan net.Conn)
+	s.chAcceptErr = make(chan error)
+	s.chCloseConn = make(chan *conn)
+
+	s.Log(logger.Info, "listener opened on "+s.Address)
+
+	l := &listener{
+		ln:     s.ln,
+		wg:     &s.wg,
+		parent: s,
+	}
+	l.initialize()
+
+	s.wg.Add(1)
+	go s.run()
+
+	return nil
+}
+
+// Log implements logger.Writer.
+func (s *Server) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[IPC] "+format, args...)
+}
+
+// Close closes the server.
+func (s *Server) Close() {
+	s.Log(logger.Info, "listener is closing")
+	s.ctxCancel()
+	s.wg.Wait()
+}
+
+func (s *Server) run() {
+	defer s.wg.Done()
+
+outer:
+	for {
+		select {
+		case err := <-s.chAcceptErr:
+			s.Log(logger.Error, "%s", err)
+			break outer
+
+		case nconn := <-s.chNewConn:
+			c := &conn{
+				parentCtx:           s.ctx,
+				rtspAddress:         s.RTSPAddress,
+				readTimeout:         s.ReadTimeout,
+				nconn:               nconn,
+				runOnConnect:        s.RunOnConnect,
+				runOnConnectRestart: s.RunOnConnectRestart,
+				runOnDisconnect:     s.RunOnDisconnect,
+				wg:                  &s.wg,
+				externalCmdPool:     s.ExternalCmdPool,
+				pathManager:         s.PathManager,
+				parent:              s,
+			}
+			c.initialize()
+			s.conns[c] = struct{}{}
+
+		case c := <-s.chCloseConn:
+			delete(s.conns, c)
+
+		case <-s.ctx.Done():
+			break outer
+		}
+	}
+
+	s.ctxCancel()
+
+	s.ln.Close()
+}
+
+// newConn is called by listener.
+func (s *Server) newConn(nconn net.Conn) {
+	select {
+	case s.chNewConn <- nconn:
+	case <-s.ctx.Done():
+		nconn.Close()
+	}
+}
+
+// acceptError is called by listener.
+func (s *Server) acceptError(err error) {
+	select {
+	case s.chAcceptErr <- err:
+	case <-s.ctx.Done():
+	}
+}
+
+// closeConn is called by conn.
+func (s *Server) closeConn(c *conn) {
+	select {
+	case s.chCloseConn <- c:
+	case <-s.ctx.Done():
+	}
+}
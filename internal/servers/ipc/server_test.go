@@ -0,0 +1,273 @@
+package ipc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/pkg/formats/fmp4/seekablebuffer"
+	"github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/auth"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type dummyPath struct {
+	stream        *stream.Stream
+	streamCreated chan struct{}
+}
+
+func (p *dummyPath) Name() string {
+	return "teststream"
+}
+
+func (p *dummyPath) SafeConf() *conf.Path {
+	return &conf.Path{}
+}
+
+func (p *dummyPath) ExternalCmdEnv() externalcmd.Environment {
+	return externalcmd.Environment{}
+}
+
+func (p *dummyPath) StartPublisher(req defs.PathStartPublisherReq) (*stream.Stream, error) {
+	var err error
+	p.stream, err = stream.New(
+		1460,
+		req.Desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	if err != nil {
+		return nil, err
+	}
+	close(p.streamCreated)
+	return p.stream, nil
+}
+
+func (p *dummyPath) StopPublisher(_ defs.PathStopPublisherReq) {
+}
+
+func (p *dummyPath) RemovePublisher(_ defs.PathRemovePublisherReq) {
+}
+
+func (p *dummyPath) RemoveReader(_ defs.PathRemoveReaderReq) {
+}
+
+type dummyPathManager struct {
+	path *dummyPath
+}
+
+func (pm *dummyPathManager) AddPublisher(req defs.PathAddPublisherReq) (defs.Path, error) {
+	if req.AccessRequest.User != "myuser" || req.AccessRequest.Pass != "mypass" {
+		return nil, auth.Error{}
+	}
+	return pm.path, nil
+}
+
+type streamWriter func(dts int64, au [][]byte)
+
+func newMPEGTSWriter(t *testing.T, w io.Writer) streamWriter {
+	track := &mpegts.Track{
+		Codec: &mpegts.CodecH264{},
+	}
+
+	bw := bufio.NewWriter(w)
+	mw := mpegts.NewWriter(bw, []*mpegts.Track{track})
+
+	return func(dts int64, au [][]byte) {
+		err := mw.WriteH264(track, dts, dts, true, au)
+		require.NoError(t, err)
+
+		err = bw.Flush()
+		require.NoError(t, err)
+	}
+}
+
+func newFMP4Writer(t *testing.T, w io.Writer) streamWriter {
+	init := fmp4.Init{
+		Tracks: []*fmp4.InitTrack{{
+			ID:        1,
+			TimeScale: 90000,
+			Codec: &fmp4.CodecH264{
+				SPS: test.FormatH264.SPS,
+				PPS: test.FormatH264.PPS,
+			},
+		}},
+	}
+
+	var buf seekablebuffer.Buffer
+	err := init.Marshal(&buf)
+	require.NoError(t, err)
+
+	_, err = w.Write(buf.Bytes())
+	require.NoError(t, err)
+
+	seqNum := uint32(0)
+
+	return func(dts int64, au [][]byte) {
+		sample, err2 := fmp4.NewPartSampleH26x(0, true, au)
+		require.NoError(t, err2)
+		sample.Duration = 90000
+
+		part := fmp4.Part{
+			SequenceNumber: seqNum,
+			Tracks: []*fmp4.PartTrack{{
+				ID:       1,
+				BaseTime: uint64(dts),
+				Samples:  []*fmp4.PartSample{sample},
+			}},
+		}
+		seqNum++
+
+		var buf2 seekablebuffer.Buffer
+		err2 = part.Marshal(&buf2)
+		require.NoError(t, err2)
+
+		_, err2 = w.Write(buf2.Bytes())
+		require.NoError(t, err2)
+	}
+}
+
+func TestServerPublish(t *testing.T) {
+	for _, ca := range []string{"mpegts", "fmp4"} {
+		t.Run(ca, func(t *testing.T) {
+			externalCmdPool := externalcmd.NewPool()
+			defer externalCmdPool.Close()
+
+			path := &dummyPath{
+				streamCreated: make(chan struct{}),
+			}
+
+			pathManager := &dummyPathManager{path: path}
+
+			address := filepath.Join(t.TempDir(), "mediamtx.sock")
+
+			s := &Server{
+				Address:         address,
+				ReadTimeout:     conf.StringDuration(10 * time.Second),
+				ExternalCmdPool: externalCmdPool,
+				PathManager:     pathManager,
+				Parent:          test.NilLogger,
+			}
+			err := s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			pr, pw := io.Pipe()
+
+			c := &Client{
+				Address: address,
+				Path:    "mypath?user=myuser&pass=mypass",
+				Source:  pr,
+			}
+
+			clientDone := make(chan struct{})
+			defer func() {
+				pw.Close()
+				<-clientDone
+			}()
+
+			go func() {
+				defer close(clientDone)
+				c.Run() //nolint:errcheck
+			}()
+
+			var write streamWriter
+			if ca == "mpegts" {
+				write = newMPEGTSWriter(t, pw)
+			} else {
+				write = newFMP4Writer(t, pw)
+			}
+
+			write(0, [][]byte{
+				test.FormatH264.SPS,
+				test.FormatH264.PPS,
+				{5, 1}, // IDR
+			})
+
+			<-path.streamCreated
+
+			aw := asyncwriter.New(512, test.NilLogger)
+
+			recv := make(chan struct{})
+
+			path.stream.AddReader(aw,
+				path.stream.Desc().Medias[0],
+				path.stream.Desc().Medias[0].Formats[0],
+				func(u unit.Unit) error {
+					au := u.(*unit.H264).AU
+					if bytes.Equal(au[len(au)-1], []byte{5, 2}) {
+						close(recv)
+					}
+					return nil
+				})
+
+			aw.Start()
+			defer aw.Stop()
+
+			// MPEG-TS access units are decoded when the next one is received
+			write(90000, [][]byte{{5, 2}})
+			write(180000, [][]byte{{5, 3}})
+
+			<-recv
+		})
+	}
+}
+
+func TestServerAuthError(t *testing.T) {
+	externalCmdPool := externalcmd.NewPool()
+	defer externalCmdPool.Close()
+
+	pathManager := &dummyPathManager{path: &dummyPath{}}
+
+	address := filepath.Join(t.TempDir(), "mediamtx.sock")
+
+	s := &Server{
+		Address:         address,
+		ReadTimeout:     conf.StringDuration(10 * time.Second),
+		ExternalCmdPool: externalCmdPool,
+		PathManager:     pathManager,
+		Parent:          test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	c := &Client{
+		Address: address,
+		Path:    "mypath?user=myuser&pass=wrong",
+		Source:  io.MultiReader(bytes.NewReader(append([]byte{0x47}, make([]byte, 187)...)), pr),
+	}
+	err = c.Run()
+	require.EqualError(t, err, "authentication failed: ")
+}
+
+func TestClientInvalidSource(t *testing.T) {
+	c := &Client{
+		Address: filepath.Join(t.TempDir(), "mediamtx.sock"),
+		Path:    "mypath",
+		Source:  bytes.NewReader([]byte("hello world")),
+	}
+	err := c.Run()
+	require.EqualError(t, err, "unable to detect the format of the stream, only MPEG-TS and fMP4 are supported")
+
+	c.Source = bytes.NewReader(nil)
+	err = c.Run()
+	require.EqualError(t, err, "source is empty")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -24,12 +25,38 @@ import (
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
-func pathNameAndQuery(inURL *url.URL) (string, url.Values, string) {
+func pathNameAndQuery(inURL *url.URL, rules conf.RTMPPathRules) (string, url.Values, string) {
 	// remove leading and trailing slashes inserted by OBS and some other clients
 	tmp := strings.TrimRight(inURL.String(), "/")
 	ur, _ := url.Parse(tmp)
-	pathName := strings.TrimLeft(ur.Path, "/")
-	return pathName, ur.Query(), ur.RawQuery
+	pathName := strings.Trim(ur.Path, "/")
+
+	// remove duplicate slashes caused by empty apps or stream keys
+	for strings.Contains(pathName, "//") {
+		pathName = strings.ReplaceAll(pathName, "//", "/")
+	}
+
+	query := ur.Query()
+
+	if rule := rules.Find(pathName); rule != nil {
+		re := regexp.MustCompile(rule.Match)
+		match := re.FindStringSubmatchIndex(pathName)
+
+		expand := func(template string) string {
+			return string(re.ExpandString(nil, template, pathName, match))
+		}
+
+		if user := expand(rule.User); user != "" {
+			query.Set("user", user)
+		}
+		if pass := expand(rule.Pass); pass != "" {
+			query.Set("pass", pass)
+		}
+
+		pathName = expand(rule.Path)
+	}
+
+	return pathName, query, ur.RawQuery
 }
 
 type connState int
@@ -43,6 +70,7 @@ type conn struct {
 	parentCtx           context.Context
 	isTLS               bool
 	rtspAddress         string
+	pathRules           conf.RTMPPathRules
 	readTimeout         conf.StringDuration
 	writeTimeout        conf.StringDuration
 	writeQueueSize      int
@@ -64,6 +92,9 @@ type conn struct {
 	state     connState
 	pathName  string
 	query     string
+	stream    *stream.Stream
+	writer    *asyncwriter.Writer
+	paused    bool
 }
 
 func (c *conn) initialize() {
@@ -95,6 +126,13 @@ func (c *conn) ip() net.IP {
 	return c.nconn.RemoteAddr().(*net.TCPAddr).IP
 }
 
+func (c *conn) localIP() net.IP {
+	if addr, ok := c.nconn.LocalAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
 func (c *conn) run() { //nolint:dupl
 	defer c.wg.Done()
 
@@ -155,18 +193,20 @@ func (c *conn) runReader() error {
 }
 
 func (c *conn) runRead(conn *rtmp.Conn, u *url.URL) error {
-	pathName, query, rawQuery := pathNameAndQuery(u)
+	pathName, query, rawQuery := pathNameAndQuery(u, c.pathRules)
 
 	path, stream, err := c.pathManager.AddReader(defs.PathAddReaderReq{
 		Author: c,
 		AccessRequest: defs.PathAccessRequest{
-			Name:  pathName,
-			Query: rawQuery,
-			IP:    c.ip(),
-			User:  query.Get("user"),
-			Pass:  query.Get("pass"),
-			Proto: auth.ProtocolRTMP,
-			ID:    &c.uuid,
+			Name:      pathName,
+			Query:     rawQuery,
+			IP:        c.ip(),
+			LocalIP:   c.localIP(),
+			User:      query.Get("user"),
+			Pass:      query.Get("pass"),
+			Proto:     auth.ProtocolRTMP,
+			ID:        &c.uuid,
+			UserAgent: conn.FlashVer(),
 		},
 	})
 	if err != nil {
@@ -195,6 +235,11 @@ func (c *conn) runRead(conn *rtmp.Conn, u *url.URL) error {
 		return err
 	}
 
+	c.mutex.Lock()
+	c.stream = stream
+	c.writer = writer
+	c.mutex.Unlock()
+
 	c.Log(logger.Info, "is reading from path '%s', %s",
 		path.Name(), defs.FormatsInfo(stream.FormatsForReader(writer)))
 
@@ -224,19 +269,21 @@ func (c *conn) runRead(conn *rtmp.Conn, u *url.URL) error {
 }
 
 func (c *conn) runPublish(conn *rtmp.Conn, u *url.URL) error {
-	pathName, query, rawQuery := pathNameAndQuery(u)
+	pathName, query, rawQuery := pathNameAndQuery(u, c.pathRules)
 
 	path, err := c.pathManager.AddPublisher(defs.PathAddPublisherReq{
 		Author: c,
 		AccessRequest: defs.PathAccessRequest{
-			Name:    pathName,
-			Query:   rawQuery,
-			Publish: true,
-			IP:      c.ip(),
-			User:    query.Get("user"),
-			Pass:    query.Get("pass"),
-			Proto:   auth.ProtocolRTMP,
-			ID:      &c.uuid,
+			Name:      pathName,
+			Query:     rawQuery,
+			Publish:   true,
+			IP:        c.ip(),
+			LocalIP:   c.localIP(),
+			User:      query.Get("user"),
+			Pass:      query.Get("pass"),
+			Proto:     auth.ProtocolRTMP,
+			ID:        &c.uuid,
+			UserAgent: conn.FlashVer(),
 		},
 	})
 	if err != nil {
@@ -253,7 +300,7 @@ func (c *conn) runPublish(conn *rtmp.Conn, u *url.URL) error {
 
 	c.mutex.Lock()
 	c.state = connStatePublish
-	c.pathName = pathName
+	c.pathName = path.Name()
 	c.query = rawQuery
 	c.mutex.Unlock()
 
@@ -308,6 +355,46 @@ func (c *conn) APISourceDescribe() defs.APIPathSourceOrReader {
 	return c.APIReaderDescribe()
 }
 
+// BytesReceived implements bandwidth.Counter.
+func (c *conn) BytesReceived() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.rconn == nil {
+		return 0
+	}
+	return c.rconn.BytesReceived()
+}
+
+// BytesSent implements bandwidth.Counter.
+func (c *conn) BytesSent() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.rconn == nil {
+		return 0
+	}
+	return c.rconn.BytesSent()
+}
+
+func (c *conn) setPaused(paused bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.writer == nil {
+		return ErrConnNotReading
+	}
+
+	if paused {
+		c.stream.PauseReader(c.writer)
+	} else {
+		c.stream.ResumeReader(c.writer)
+	}
+
+	c.paused = paused
+	return nil
+}
+
 func (c *conn) apiItem() *defs.APIRTMPConn {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -338,6 +425,7 @@ func (c *conn) apiItem() *defs.APIRTMPConn {
 		}(),
 		Path:          c.pathName,
 		Query:         c.query,
+		Paused:        c.paused,
 		BytesReceived: bytesReceived,
 		BytesSent:     bytesSent,
 	}
@@ -8,15 +8,19 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/google/uuid"
 
 	"github.com/bluenviron/mediamtx/internal/certloader"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	mtxtls "github.com/bluenviron/mediamtx/internal/protocols/tls"
+	"github.com/bluenviron/mediamtx/internal/proxyprotocol"
 	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
@@ -24,6 +28,9 @@ import (
 // ErrConnNotFound is returned when a connection is not found.
 var ErrConnNotFound = errors.New("connection not found")
 
+// ErrConnNotReading is returned when a connection is not reading.
+var ErrConnNotReading = errors.New("connection is not reading")
+
 type serverAPIConnsListRes struct {
 	data *defs.APIRTMPConnList
 	err  error
@@ -52,6 +59,16 @@ type serverAPIConnsKickReq struct {
 	res  chan serverAPIConnsKickRes
 }
 
+type serverAPIConnsPauseRes struct {
+	err error
+}
+
+type serverAPIConnsPauseReq struct {
+	uuid   uuid.UUID
+	paused bool
+	res    chan serverAPIConnsPauseRes
+}
+
 type serverPathManager interface {
 	AddPublisher(req defs.PathAddPublisherReq) (defs.Path, error)
 	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
@@ -63,12 +80,17 @@ type serverParent interface {
 
 // Server is a RTMP server.
 type Server struct {
+	TrustedProxies      conf.IPNetworks
+	PathRules           conf.RTMPPathRules
+	ConnLimiter         *connlimiter.Limiter
 	Address             string
+	Listeners           conf.Listeners
 	ReadTimeout         conf.StringDuration
 	WriteTimeout        conf.StringDuration
 	WriteQueueSize      int
 	IsTLS               bool
 	ServerCert          string
+	TLSOptions          conf.TLSOptions
 	ServerKey           string
 	RTSPAddress         string
 	RunOnConnect        string
@@ -81,42 +103,44 @@ type Server struct {
 	ctx       context.Context
 	ctxCancel func()
 	wg        sync.WaitGroup
-	ln        net.Listener
+	lns       []net.Listener
 	conns     map[*conn]struct{}
-	loader    *certloader.CertLoader
+	loaders   []*certloader.CertLoader
 
 	// in
-	chNewConn      chan net.Conn
-	chAcceptErr    chan error
-	chCloseConn    chan *conn
-	chAPIConnsList chan serverAPIConnsListReq
-	chAPIConnsGet  chan serverAPIConnsGetReq
-	chAPIConnsKick chan serverAPIConnsKickReq
+	chNewConn       chan net.Conn
+	chAcceptErr     chan error
+	chCloseConn     chan *conn
+	chAPIConnsList  chan serverAPIConnsListReq
+	chAPIConnsGet   chan serverAPIConnsGetReq
+	chAPIConnsKick  chan serverAPIConnsKickReq
+	chAPIConnsPause chan serverAPIConnsPauseReq
 }
 
 // Initialize initializes the server.
 func (s *Server) Initialize() error {
-	ln, err := func() (net.Listener, error) {
-		if !s.IsTLS {
-			return net.Listen(restrictnetwork.Restrict("tcp", s.Address))
+	ln, err := s.listen(s.Address, s.ServerCert, s.ServerKey)
+	if err != nil {
+		return err
+	}
+	s.lns = []net.Listener{ln}
+
+	for _, l := range s.Listeners {
+		serverCert, serverKey := s.ServerCert, s.ServerKey
+		if l.ServerCert != "" {
+			serverCert, serverKey = l.ServerCert, l.ServerKey
 		}
 
-		var err error
-		s.loader, err = certloader.New(s.ServerCert, s.ServerKey, s.Parent)
+		ln, err = s.listen(l.Address, serverCert, serverKey)
 		if err != nil {
-			return nil, err
+			s.closeListeners()
+			return err
 		}
-
-		network, address := restrictnetwork.Restrict("tcp", s.Address)
-		return tls.Listen(network, address, &tls.Config{GetCertificate: s.loader.GetCertificate()})
-	}()
-	if err != nil {
-		return err
+		s.lns = append(s.lns, ln)
 	}
 
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 
-	s.ln = ln
 	s.conns = make(map[*conn]struct{})
 	s.chNewConn = make(chan net.Conn)
 	s.chAcceptErr = make(chan error)
@@ -124,15 +148,19 @@ func (s *Server) Initialize() error {
 	s.chAPIConnsList = make(chan serverAPIConnsListReq)
 	s.chAPIConnsGet = make(chan serverAPIConnsGetReq)
 	s.chAPIConnsKick = make(chan serverAPIConnsKickReq)
+	s.chAPIConnsPause = make(chan serverAPIConnsPauseReq)
 
-	s.Log(logger.Info, "listener opened on %s", s.Address)
+	s.Log(logger.Info, "listener opened on %s",
+		strings.Join(append([]string{s.Address}, s.Listeners.Addresses()...), ", "))
 
-	l := &listener{
-		ln:     s.ln,
-		wg:     &s.wg,
-		parent: s,
+	for _, ln := range s.lns {
+		l := &listener{
+			ln:     ln,
+			wg:     &s.wg,
+			parent: s,
+		}
+		l.initialize()
 	}
-	l.initialize()
 
 	s.wg.Add(1)
 	go s.run()
@@ -140,6 +168,43 @@ func (s *Server) Initialize() error {
 	return nil
 }
 
+func (s *Server) listen(address string, serverCert string, serverKey string) (net.Listener, error) {
+	ln, err := net.Listen(restrictnetwork.Restrict("tcp", address))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.IsTLS {
+		ln = s.ConnLimiter.Wrap(ln, "rtmps")
+	} else {
+		ln = s.ConnLimiter.Wrap(ln, "rtmp")
+	}
+
+	ln = proxyprotocol.Wrap(ln, s.TrustedProxies)
+
+	if !s.IsTLS {
+		return ln, nil
+	}
+
+	loader, err := certloader.New(serverCert, serverKey, s.Parent)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	s.loaders = append(s.loaders, loader)
+
+	return tls.NewListener(ln, mtxtls.ServerConfig(loader, s.TLSOptions)), nil
+}
+
+func (s *Server) closeListeners() {
+	for _, ln := range s.lns {
+		ln.Close()
+	}
+	for _, loader := range s.loaders {
+		loader.Close()
+	}
+}
+
 // Log implements logger.Writer.
 func (s *Server) Log(level logger.Level, format string, args ...interface{}) {
 	label := func() string {
@@ -156,8 +221,8 @@ func (s *Server) Close() {
 	s.Log(logger.Info, "listener is closing")
 	s.ctxCancel()
 	s.wg.Wait()
-	if s.loader != nil {
-		s.loader.Close()
+	for _, loader := range s.loaders {
+		loader.Close()
 	}
 }
 
@@ -176,6 +241,7 @@ outer:
 				parentCtx:           s.ctx,
 				isTLS:               s.IsTLS,
 				rtspAddress:         s.RTSPAddress,
+				pathRules:           s.PathRules,
 				readTimeout:         s.ReadTimeout,
 				writeTimeout:        s.WriteTimeout,
 				writeQueueSize:      s.WriteQueueSize,
@@ -229,6 +295,15 @@ outer:
 			c.Close()
 			req.res <- serverAPIConnsKickRes{}
 
+		case req := <-s.chAPIConnsPause:
+			c := s.findConnByUUID(req.uuid)
+			if c == nil {
+				req.res <- serverAPIConnsPauseRes{err: ErrConnNotFound}
+				continue
+			}
+
+			req.res <- serverAPIConnsPauseRes{err: c.setPaused(req.paused)}
+
 		case <-s.ctx.Done():
 			break outer
 		}
@@ -236,7 +311,9 @@ outer:
 
 	s.ctxCancel()
 
-	s.ln.Close()
+	for _, ln := range s.lns {
+		ln.Close()
+	}
 }
 
 func (s *Server) findConnByUUID(uuid uuid.UUID) *conn {
@@ -322,3 +399,30 @@ func (s *Server) APIConnsKick(uuid uuid.UUID) error {
 		return fmt.Errorf("terminated")
 	}
 }
+
+// APIConnsPause is called by api.
+func (s *Server) APIConnsPause(uuid uuid.UUID) error {
+	return s.apiConnsSetPaused(uuid, true)
+}
+
+// APIConnsResume is called by api.
+func (s *Server) APIConnsResume(uuid uuid.UUID) error {
+	return s.apiConnsSetPaused(uuid, false)
+}
+
+func (s *Server) apiConnsSetPaused(uuid uuid.UUID, paused bool) error {
+	req := serverAPIConnsPauseReq{
+		uuid:   uuid,
+		paused: paused,
+		res:    make(chan serverAPIConnsPauseRes),
+	}
+
+	select {
+	case s.chAPIConnsPause <- req:
+		res := <-req.res
+		return res.err
+
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/protocols/rtmp"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
@@ -44,6 +45,7 @@ func (p *dummyPath) StartPublisher(req defs.PathStartPublisherReq) (*stream.Stre
 		1460,
 		req.Desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	if err != nil {
@@ -200,6 +202,7 @@ func TestServerRead(t *testing.T) {
 				1460,
 				desc,
 				true,
+				formatprocessor.Options{},
 				test.NilLogger,
 			)
 			require.NoError(t, err)
@@ -271,3 +274,65 @@ func TestServerRead(t *testing.T) {
 		})
 	}
 }
+
+func TestPathNameAndQuery(t *testing.T) {
+	rules := conf.RTMPPathRules{
+		{
+			Match: `^live/([^_]+)_([^_]+)_(.+)$`,
+			Path:  "$1",
+			User:  "$2",
+			Pass:  "$3",
+		},
+		{
+			Match: `^app/(?P<key>.+)$`,
+			Path:  "streams/${key}",
+		},
+	}
+
+	for _, ca := range []struct {
+		name     string
+		url      string
+		pathName string
+		user     string
+		pass     string
+	}{
+		{
+			"standard",
+			"rtmp://127.0.0.1/mypath?user=myuser&pass=mypass",
+			"mypath",
+			"myuser",
+			"mypass",
+		},
+		{
+			"slashes",
+			"rtmp://127.0.0.1//mypath//sub/?user=myuser",
+			"mypath/sub",
+			"myuser",
+			"",
+		},
+		{
+			"credentials in stream key",
+			"rtmp://127.0.0.1/live/mypath_myuser_mypass",
+			"mypath",
+			"myuser",
+			"mypass",
+		},
+		{
+			"multi-level app",
+			"rtmp://127.0.0.1/app/cam1/main?pass=mypass",
+			"streams/cam1/main",
+			"",
+			"mypass",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			u, err := url.Parse(ca.url)
+			require.NoError(t, err)
+
+			pathName, query, _ := pathNameAndQuery(u, rules)
+			require.Equal(t, ca.pathName, pathName)
+			require.Equal(t, ca.user, query.Get("user"))
+			require.Equal(t, ca.pass, query.Get("pass"))
+		})
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v4"
 	rtspauth "github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/google/uuid"
 
 	"github.com/bluenviron/mediamtx/internal/auth"
@@ -23,25 +25,41 @@ const (
 	rtspAuthRealm = "IPCAM"
 )
 
+func userAgent(req *base.Request) string {
+	if v, ok := req.Header["User-Agent"]; ok && len(v) == 1 {
+		return v[0]
+	}
+	return ""
+}
+
 type conn struct {
-	isTLS               bool
-	rtspAddress         string
-	authMethods         []rtspauth.ValidateMethod
-	readTimeout         conf.StringDuration
-	runOnConnect        string
-	runOnConnectRestart bool
-	runOnDisconnect     string
-	externalCmdPool     *externalcmd.Pool
-	pathManager         serverPathManager
-	rconn               *gortsplib.ServerConn
-	rserver             *gortsplib.Server
-	parent              *Server
-
-	uuid             uuid.UUID
-	created          time.Time
-	onDisconnectHook func()
-	authNonce        string
-	authFailures     int
+	isTLS                bool
+	rtspAddress          string
+	authMethods          []rtspauth.ValidateMethod
+	authNonceLifetime    conf.StringDuration
+	authReplayProtection bool
+	multicastSSM         bool
+	readTimeout          conf.StringDuration
+	runOnConnect         string
+	runOnConnectRestart  bool
+	runOnDisconnect      string
+	externalCmdPool      *externalcmd.Pool
+	pathManager          serverPathManager
+	rconn                *gortsplib.ServerConn
+	rserver              *gortsplib.Server
+	parent               *Server
+
+	uuid              uuid.UUID
+	created           time.Time
+	onDisconnectHook  func()
+	authNonce         string
+	authNonceCreated  time.Time
+	authUsedResponses map[string]struct{}
+	authPendingResp   string
+	authFailures      int
+	lastRequest       *base.Request
+	setupSession      *session
+	rejectedTransport string
 }
 
 func (c *conn) initialize() {
@@ -89,6 +107,13 @@ func (c *conn) ip() net.IP {
 	return c.rconn.NetConn().RemoteAddr().(*net.TCPAddr).IP
 }
 
+func (c *conn) localIP() net.IP {
+	if addr, ok := c.rconn.NetConn().LocalAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
 // onClose is called by rtspServer.
 func (c *conn) onClose(err error) {
 	c.Log(logger.Info, "closed: %v", err)
@@ -99,13 +124,99 @@ func (c *conn) onClose(err error) {
 // onRequest is called by rtspServer.
 func (c *conn) onRequest(req *base.Request) {
 	c.Log(logger.Debug, "[c->s] %v", req)
+	c.lastRequest = req
 }
 
 // OnResponse is called by rtspServer.
 func (c *conn) OnResponse(res *base.Response) {
+	if c.multicastSSM && c.lastRequest != nil && res.StatusCode == base.StatusOK {
+		c.addSSMSource(res)
+	}
+
+	c.markAuthResponseUsed(res)
+
+	if c.lastRequest != nil && c.lastRequest.Method == base.Setup {
+		c.updateTransportStats(res)
+
+		if c.setupSession != nil {
+			if res.StatusCode == base.StatusOK {
+				var th headers.Transport
+				if err := th.Unmarshal(res.Header["Transport"]); err == nil {
+					c.setupSession.onSetupResponse(&th)
+				}
+			}
+			c.setupSession = nil
+		}
+	}
+
 	c.Log(logger.Debug, "[s->c] %v", res)
 }
 
+// updateTransportStats detects clients that switch to another transport
+// after the one they asked for has been rejected.
+func (c *conn) updateTransportStats(res *base.Response) {
+	switch res.StatusCode {
+	case base.StatusUnsupportedTransport:
+		var ths headers.Transports
+		err := ths.Unmarshal(c.lastRequest.Header["Transport"])
+		if err != nil || len(ths) == 0 {
+			return
+		}
+
+		transport := transportName(ths[0])
+		c.parent.stats.addRejection(transport)
+
+		if c.rejectedTransport == "" {
+			c.rejectedTransport = transport
+		}
+
+	case base.StatusOK:
+		if c.rejectedTransport == "" {
+			return
+		}
+
+		var th headers.Transport
+		err := th.Unmarshal(res.Header["Transport"])
+		if err != nil {
+			return
+		}
+
+		transport := transportName(th)
+		if transport != c.rejectedTransport {
+			c.Log(logger.Info, "client switched from %s to %s transport", c.rejectedTransport, transport)
+			c.parent.stats.addFallback(c.rejectedTransport, transport)
+		}
+
+		c.rejectedTransport = ""
+	}
+}
+
+func (c *conn) addSSMSource(res *base.Response) {
+	host, _, err := net.SplitHostPort(c.rconn.NetConn().LocalAddr().String())
+	if err != nil {
+		return
+	}
+
+	// multicast is available on IPv4 only
+	source := net.ParseIP(host).To4()
+	if source == nil {
+		return
+	}
+
+	switch c.lastRequest.Method {
+	case base.Setup:
+		ssmAddTransportSource(res, source)
+
+	case base.Describe:
+		// the SDP contains a multicast address only when vlcmulticast is provided
+		if q, err := url.ParseQuery(c.lastRequest.URL.RawQuery); err == nil {
+			if _, ok := q["vlcmulticast"]; ok {
+				ssmAddSDPSourceFilter(res, source)
+			}
+		}
+	}
+}
+
 // onDescribe is called by rtspServer.
 func (c *conn) onDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx,
 ) (*base.Response, *gortsplib.ServerStream, error) {
@@ -116,14 +227,9 @@ func (c *conn) onDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx,
 	}
 	ctx.Path = ctx.Path[1:]
 
-	if c.authNonce == "" {
-		var err error
-		c.authNonce, err = rtspauth.GenerateNonce()
-		if err != nil {
-			return &base.Response{
-				StatusCode: base.StatusInternalServerError,
-			}, nil, err
-		}
+	authRes, err := c.checkAuthNonce(ctx.Request)
+	if authRes != nil {
+		return authRes, nil, err
 	}
 
 	res := c.pathManager.Describe(defs.PathDescribeReq{
@@ -131,10 +237,12 @@ func (c *conn) onDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx,
 			Name:        ctx.Path,
 			Query:       ctx.Query,
 			IP:          c.ip(),
+			LocalIP:     c.localIP(),
 			Proto:       auth.ProtocolRTSP,
 			ID:          &c.uuid,
 			RTSPRequest: ctx.Request,
 			RTSPNonce:   c.authNonce,
+			UserAgent:   userAgent(ctx.Request),
 		},
 	})
 
@@ -152,6 +260,20 @@ func (c *conn) onDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx,
 			}, nil, res.Err
 		}
 
+		var terr4 defs.PathUserAgentNotAllowedError
+		if errors.As(res.Err, &terr4) {
+			return &base.Response{
+				StatusCode: base.StatusForbidden,
+			}, nil, res.Err
+		}
+
+		var terr3 defs.PathDrainingError
+		if errors.As(res.Err, &terr3) {
+			return &base.Response{
+				StatusCode: base.StatusServiceUnavailable,
+			}, nil, res.Err
+		}
+
 		return &base.Response{
 			StatusCode: base.StatusBadRequest,
 		}, nil, res.Err
@@ -178,6 +300,117 @@ func (c *conn) onDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx,
 	}, stream, nil
 }
 
+// checkAuthNonce generates the digest nonce, renews it when expired and detects
+// replayed digest responses. When a response is returned, the request must be rejected.
+func (c *conn) checkAuthNonce(req *base.Request) (*base.Response, error) {
+	c.authPendingResp = ""
+
+	expired := c.authNonce != "" && c.authNonceLifetime != 0 &&
+		time.Since(c.authNonceCreated) >= time.Duration(c.authNonceLifetime)
+
+	if c.authNonce == "" || expired {
+		err := c.renewAuthNonce()
+		if err != nil {
+			return &base.Response{
+				StatusCode: base.StatusInternalServerError,
+			}, err
+		}
+	}
+
+	var authHeader headers.Authorization
+	err := authHeader.Unmarshal(req.Header["Authorization"])
+	if err != nil || authHeader.Method != headers.AuthMethodDigest {
+		return nil, nil
+	}
+
+	if authHeader.Nonce != c.authNonce {
+		if expired {
+			c.Log(logger.Debug, "digest nonce is expired, asking for a new authentication")
+			return c.staleNonceResponse(), nil
+		}
+		return nil, nil
+	}
+
+	if c.authReplayProtection {
+		if _, ok := c.authUsedResponses[authHeader.Response]; ok {
+			c.Log(logger.Warn, "replayed digest response detected")
+			c.authFailures++
+
+			err := c.renewAuthNonce()
+			if err != nil {
+				return &base.Response{
+					StatusCode: base.StatusInternalServerError,
+				}, err
+			}
+
+			if c.authFailures <= 3 {
+				return c.staleNonceResponse(), nil
+			}
+
+			<-time.After(auth.PauseAfterError)
+
+			return &base.Response{
+				StatusCode: base.StatusUnauthorized,
+			}, auth.Error{Message: "replayed digest response"}
+		}
+
+		c.authPendingResp = authHeader.Response
+	}
+
+	return nil, nil
+}
+
+// markAuthResponseUsed records the digest response of the last request,
+// if the request has been accepted. Since qop and nc are not supported,
+// a client that retries a rejected request (for instance, a request rejected
+// by the external authentication service) sends the same response,
+// that therefore can't be considered replayed.
+func (c *conn) markAuthResponseUsed(res *base.Response) {
+	if c.authPendingResp == "" {
+		return
+	}
+
+	if res.StatusCode < base.StatusBadRequest {
+		c.authUsedResponses[c.authPendingResp] = struct{}{}
+	}
+
+	c.authPendingResp = ""
+}
+
+func (c *conn) renewAuthNonce() error {
+	nonce, err := rtspauth.GenerateNonce()
+	if err != nil {
+		return err
+	}
+
+	c.authNonce = nonce
+	c.authNonceCreated = time.Now()
+	c.authUsedResponses = make(map[string]struct{})
+	return nil
+}
+
+func (c *conn) staleNonceResponse() *base.Response {
+	var v base.HeaderValue
+
+	for _, entry := range rtspauth.GenerateWWWAuthenticate(c.authMethods, rtspAuthRealm, c.authNonce) {
+		var h headers.Authenticate
+		err := h.Unmarshal(base.HeaderValue{entry})
+		if err == nil && h.Method == headers.AuthMethodDigest {
+			stale := "true"
+			h.Stale = &stale
+			entry = h.Marshal()[0]
+		}
+		v = append(v, entry)
+	}
+
+	return &base.Response{
+		StatusCode: base.StatusUnauthorized,
+		Header: base.Header{
+			"WWW-Authenticate": v,
+		},
+	}
+}
+
 func (c *conn) handleAuthError(authErr error) (*base.Response, error) {
 	c.authFailures++
 
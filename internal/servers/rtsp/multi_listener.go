@@ -0,0 +1,81 @@
+package rtsp
+
+import (
+	"net"
+	"sync"
+)
+
+// multiListener is a net.Listener that accepts connections from multiple listeners.
+type multiListener struct {
+	lns []net.Listener
+
+	closeOnce sync.Once
+	done      chan struct{}
+	chConn    chan net.Conn
+	chErr     chan error
+}
+
+func newMultiListener(lns []net.Listener) *multiListener {
+	l := &multiListener{
+		lns:    lns,
+		done:   make(chan struct{}),
+		chConn: make(chan net.Conn),
+		chErr:  make(chan error),
+	}
+
+	for _, ln := range lns {
+		go l.runListener(ln)
+	}
+
+	return l
+}
+
+func (l *multiListener) runListener(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case l.chErr <- err:
+			case <-l.done:
+			}
+			return
+		}
+
+		select {
+		case l.chConn <- conn:
+		case <-l.done:
+			conn.Close()
+			return
+		}
+	}
+}
+
+// Accept implements net.Listener.
+func (l *multiListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.chConn:
+		return conn, nil
+
+	case err := <-l.chErr:
+		return nil, err
+
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener.
+func (l *multiListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		for _, ln := range l.lns {
+			ln.Close()
+		}
+	})
+	return nil
+}
+
+// Addr implements net.Listener.
+func (l *multiListener) Addr() net.Addr {
+	return l.lns[0].Addr()
+}
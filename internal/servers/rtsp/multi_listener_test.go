@@ -0,0 +1,37 @@
+package rtsp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiListener(t *testing.T) {
+	ln1, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ln2, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	l := newMultiListener([]net.Listener{ln1, ln2})
+	defer l.Close()
+
+	require.Equal(t, ln1.Addr(), l.Addr())
+
+	for _, ln := range []net.Listener{ln1, ln2} {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, err)
+		defer c.Close()
+
+		sc, err := l.Accept()
+		require.NoError(t, err)
+		require.Equal(t, ln.Addr(), sc.LocalAddr())
+		sc.Close()
+	}
+
+	l.Close()
+
+	_, err = l.Accept()
+	require.Error(t, err)
+}
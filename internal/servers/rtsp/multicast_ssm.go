@@ -0,0 +1,44 @@
+package rtsp
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+)
+
+// ssmAddTransportSource adds the source address to the Transport header
+// of a multicast SETUP response, in order to allow clients to perform
+// source-specific joins (IGMPv3).
+func ssmAddTransportSource(res *base.Response, source net.IP) {
+	var th headers.Transport
+	err := th.Unmarshal(res.Header["Transport"])
+	if err != nil {
+		return
+	}
+
+	if th.Delivery == nil || *th.Delivery != headers.TransportDeliveryMulticast {
+		return
+	}
+
+	th.Source = &source
+	res.Header["Transport"] = th.Marshal()
+}
+
+// ssmAddSDPSourceFilter adds a session-level source filter (RFC 4570)
+// to a multicast SDP.
+func ssmAddSDPSourceFilter(res *base.Response, source net.IP) {
+	i := bytes.Index(res.Body, []byte("\r\nm="))
+	if i < 0 {
+		return
+	}
+
+	filter := []byte("\r\na=source-filter: incl IN IP4 * " + source.String())
+
+	body := make([]byte, 0, len(res.Body)+len(filter))
+	body = append(body, res.Body[:i]...)
+	body = append(body, filter...)
+	body = append(body, res.Body[i:]...)
+	res.Body = body
+}
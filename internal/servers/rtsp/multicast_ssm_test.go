@@ -0,0 +1,63 @@
+package rtsp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSMAddTransportSource(t *testing.T) {
+	for _, ca := range []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			"multicast",
+			"RTP/AVP;multicast;destination=232.1.0.1;port=8002-8003;ttl=127",
+			"RTP/AVP;multicast;source=192.168.1.10;destination=232.1.0.1;port=8002-8003;ttl=127",
+		},
+		{
+			"unicast",
+			"RTP/AVP;unicast;client_port=3000-3001;server_port=8000-8001",
+			"RTP/AVP;unicast;client_port=3000-3001;server_port=8000-8001",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			res := &base.Response{
+				StatusCode: base.StatusOK,
+				Header: base.Header{
+					"Transport": base.HeaderValue{ca.in},
+				},
+			}
+			ssmAddTransportSource(res, net.ParseIP("192.168.1.10").To4())
+			require.Equal(t, base.HeaderValue{ca.out}, res.Header["Transport"])
+		})
+	}
+}
+
+func TestSSMAddSDPSourceFilter(t *testing.T) {
+	res := &base.Response{
+		StatusCode: base.StatusOK,
+		Body: []byte("v=0\r\n" +
+			"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+			"s=Stream\r\n" +
+			"c=IN IP4 224.1.0.0\r\n" +
+			"t=0 0\r\n" +
+			"m=video 0 RTP/AVP 96\r\n" +
+			"a=rtpmap:96 H264/90000\r\n"),
+	}
+
+	ssmAddSDPSourceFilter(res, net.ParseIP("192.168.1.10").To4())
+
+	require.Equal(t, "v=0\r\n"+
+		"o=- 0 0 IN IP4 127.0.0.1\r\n"+
+		"s=Stream\r\n"+
+		"c=IN IP4 224.1.0.0\r\n"+
+		"t=0 0\r\n"+
+		"a=source-filter: incl IN IP4 * 192.168.1.10\r\n"+
+		"m=video 0 RTP/AVP 96\r\n"+
+		"a=rtpmap:96 H264/90000\r\n", string(res.Body))
+}
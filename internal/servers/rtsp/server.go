@@ -3,9 +3,9 @@ package rtsp
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strings"
 	"sync"
@@ -19,9 +19,13 @@ import (
 
 	"github.com/bluenviron/mediamtx/internal/certloader"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	mtxtls "github.com/bluenviron/mediamtx/internal/protocols/tls"
+	"github.com/bluenviron/mediamtx/internal/proxyprotocol"
+	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
 
@@ -31,11 +35,15 @@ var ErrConnNotFound = errors.New("connection not found")
 // ErrSessionNotFound is returned when a session is not found.
 var ErrSessionNotFound = errors.New("session not found")
 
-func printAddresses(srv *gortsplib.Server) string {
+func printAddresses(srv *gortsplib.Server, listeners conf.Listeners) string {
 	var ret []string
 
 	ret = append(ret, fmt.Sprintf("%s (TCP)", srv.RTSPAddress))
 
+	for _, l := range listeners {
+		ret = append(ret, fmt.Sprintf("%s (TCP)", l.Address))
+	}
+
 	if srv.UDPRTPAddress != "" {
 		ret = append(ret, fmt.Sprintf("%s (UDP/RTP)", srv.UDPRTPAddress))
 	}
@@ -59,29 +67,39 @@ type serverParent interface {
 
 // Server is a RTSP server.
 type Server struct {
-	Address             string
-	AuthMethods         []auth.ValidateMethod
-	ReadTimeout         conf.StringDuration
-	WriteTimeout        conf.StringDuration
-	WriteQueueSize      int
-	UseUDP              bool
-	UseMulticast        bool
-	RTPAddress          string
-	RTCPAddress         string
-	MulticastIPRange    string
-	MulticastRTPPort    int
-	MulticastRTCPPort   int
-	IsTLS               bool
-	ServerCert          string
-	ServerKey           string
-	RTSPAddress         string
-	Protocols           map[conf.Protocol]struct{}
-	RunOnConnect        string
-	RunOnConnectRestart bool
-	RunOnDisconnect     string
-	ExternalCmdPool     *externalcmd.Pool
-	PathManager         serverPathManager
-	Parent              serverParent
+	Address                  string
+	Listeners                conf.Listeners
+	AuthMethods              []auth.ValidateMethod
+	AuthNonceLifetime        conf.StringDuration
+	AuthReplayProtection     bool
+	TrustedProxies           conf.IPNetworks
+	ConnLimiter              *connlimiter.Limiter
+	ReadTimeout              conf.StringDuration
+	WriteTimeout             conf.StringDuration
+	WriteQueueSize           int
+	MaxPacketSize            int
+	DisableRTCPSenderReports bool
+	UseUDP                   bool
+	UseMulticast             bool
+	RTPAddress               string
+	RTCPAddress              string
+	MulticastIPRange         string
+	MulticastRTPPort         int
+	MulticastRTCPPort        int
+	MulticastSSM             bool
+	IsTLS                    bool
+	ServerCert               string
+	TLSOptions               conf.TLSOptions
+	ServerKey                string
+	ServerHeader             string
+	RTSPAddress              string
+	Protocols                map[conf.Protocol]struct{}
+	RunOnConnect             string
+	RunOnConnectRestart      bool
+	RunOnDisconnect          string
+	ExternalCmdPool          *externalcmd.Pool
+	PathManager              serverPathManager
+	Parent                   serverParent
 
 	ctx       context.Context
 	ctxCancel func()
@@ -91,6 +109,7 @@ type Server struct {
 	conns     map[*gortsplib.ServerConn]*conn
 	sessions  map[*gortsplib.ServerSession]*session
 	loader    *certloader.CertLoader
+	stats     transportStats
 }
 
 // Initialize initializes the server.
@@ -99,13 +118,17 @@ func (s *Server) Initialize() error {
 
 	s.conns = make(map[*gortsplib.ServerConn]*conn)
 	s.sessions = make(map[*gortsplib.ServerSession]*session)
+	s.stats.initialize()
 
 	s.srv = &gortsplib.Server{
-		Handler:        s,
-		ReadTimeout:    time.Duration(s.ReadTimeout),
-		WriteTimeout:   time.Duration(s.WriteTimeout),
-		WriteQueueSize: s.WriteQueueSize,
-		RTSPAddress:    s.Address,
+		Handler:                  s,
+		ReadTimeout:              time.Duration(s.ReadTimeout),
+		WriteTimeout:             time.Duration(s.WriteTimeout),
+		WriteQueueSize:           s.WriteQueueSize,
+		MaxPacketSize:            s.MaxPacketSize,
+		DisableRTCPSenderReports: s.DisableRTCPSenderReports,
+		RTSPAddress:              s.Address,
+		Listen:                   s.listen,
 	}
 
 	if s.UseUDP {
@@ -126,7 +149,7 @@ func (s *Server) Initialize() error {
 			return err
 		}
 
-		s.srv.TLSConfig = &tls.Config{GetCertificate: s.loader.GetCertificate()}
+		s.srv.TLSConfig = mtxtls.ServerConfig(s.loader, s.TLSOptions)
 	}
 
 	err := s.srv.Start()
@@ -134,7 +157,7 @@ func (s *Server) Initialize() error {
 		return err
 	}
 
-	s.Log(logger.Info, "listener opened on %s", printAddresses(s.srv))
+	s.Log(logger.Info, "listener opened on %s", printAddresses(s.srv, s.Listeners))
 
 	s.wg.Add(1)
 	go s.run()
@@ -142,6 +165,40 @@ func (s *Server) Initialize() error {
 	return nil
 }
 
+func (s *Server) listen(network string, address string) (net.Listener, error) {
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.Listeners) != 0 {
+		lns := []net.Listener{ln}
+
+		for _, l := range s.Listeners {
+			var extraLn net.Listener
+			extraLn, err = net.Listen(restrictnetwork.Restrict("tcp", l.Address))
+			if err != nil {
+				for _, ln := range lns {
+					ln.Close()
+				}
+				return nil, err
+			}
+
+			lns = append(lns, extraLn)
+		}
+
+		ln = newMultiListener(lns)
+	}
+
+	if s.IsTLS {
+		ln = s.ConnLimiter.Wrap(ln, "rtsps")
+	} else {
+		ln = s.ConnLimiter.Wrap(ln, "rtsp")
+	}
+
+	return proxyprotocol.Wrap(ln, s.TrustedProxies), nil
+}
+
 // Log implements logger.Writer.
 func (s *Server) Log(level logger.Level, format string, args ...interface{}) {
 	label := func() string {
@@ -189,18 +246,21 @@ outer:
 // OnConnOpen implements gortsplib.ServerHandlerOnConnOpen.
 func (s *Server) OnConnOpen(ctx *gortsplib.ServerHandlerOnConnOpenCtx) {
 	c := &conn{
-		isTLS:               s.IsTLS,
-		rtspAddress:         s.RTSPAddress,
-		authMethods:         s.AuthMethods,
-		readTimeout:         s.ReadTimeout,
-		runOnConnect:        s.RunOnConnect,
-		runOnConnectRestart: s.RunOnConnectRestart,
-		runOnDisconnect:     s.RunOnDisconnect,
-		externalCmdPool:     s.ExternalCmdPool,
-		pathManager:         s.PathManager,
-		rconn:               ctx.Conn,
-		rserver:             s.srv,
-		parent:              s,
+		isTLS:                s.IsTLS,
+		rtspAddress:          s.RTSPAddress,
+		authMethods:          s.AuthMethods,
+		authNonceLifetime:    s.AuthNonceLifetime,
+		authReplayProtection: s.AuthReplayProtection,
+		multicastSSM:         s.UseMulticast && s.MulticastSSM,
+		readTimeout:          s.ReadTimeout,
+		runOnConnect:         s.RunOnConnect,
+		runOnConnectRestart:  s.RunOnConnectRestart,
+		runOnDisconnect:      s.RunOnDisconnect,
+		externalCmdPool:      s.ExternalCmdPool,
+		pathManager:          s.PathManager,
+		rconn:                ctx.Conn,
+		rserver:              s.srv,
+		parent:               s,
 	}
 	c.initialize()
 	s.mutex.Lock()
@@ -227,6 +287,12 @@ func (s *Server) OnRequest(sc *gortsplib.ServerConn, req *base.Request) {
 
 // OnResponse implements gortsplib.ServerHandlerOnResponse.
 func (s *Server) OnResponse(sc *gortsplib.ServerConn, res *base.Response) {
+	if s.ServerHeader != "" {
+		res.Header["Server"] = base.HeaderValue{s.ServerHeader}
+	} else {
+		delete(res.Header, "Server")
+	}
+
 	c := sc.UserData().(*conn)
 	c.OnResponse(res)
 }
@@ -427,6 +493,17 @@ func (s *Server) APISessionsGet(uuid uuid.UUID) (*defs.APIRTSPSession, error) {
 	return sx.apiItem(), nil
 }
 
+// APITransportStats is called by api and metrics.
+func (s *Server) APITransportStats() (*defs.APIRTSPTransportStats, error) {
+	select {
+	case <-s.ctx.Done():
+		return nil, fmt.Errorf("terminated")
+	default:
+	}
+
+	return s.stats.apiItem(), nil
+}
+
 // APISessionsKick is called by api.
 func (s *Server) APISessionsKick(uuid uuid.UUID) error {
 	select {
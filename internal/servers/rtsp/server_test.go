@@ -1,18 +1,24 @@
 package rtsp
 
 import (
+	"net"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/bluenviron/gortsplib/v4"
 	"github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	rtspconn "github.com/bluenviron/gortsplib/v4/pkg/conn"
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	mtxauth "github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -21,6 +27,7 @@ import (
 )
 
 type dummyPath struct {
+	conf          *conf.Path
 	stream        *stream.Stream
 	streamCreated chan struct{}
 }
@@ -30,6 +37,9 @@ func (p *dummyPath) Name() string {
 }
 
 func (p *dummyPath) SafeConf() *conf.Path {
+	if p.conf != nil {
+		return p.conf
+	}
 	return &conf.Path{}
 }
 
@@ -43,6 +53,7 @@ func (p *dummyPath) StartPublisher(req defs.PathStartPublisherReq) (*stream.Stre
 		1460,
 		req.Desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	if err != nil {
@@ -174,6 +185,7 @@ func TestServerRead(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -264,3 +276,266 @@ func TestServerRead(t *testing.T) {
 
 	<-recv
 }
+
+func TestServerReadTransportRules(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+	stream, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+
+	var ips conf.IPNetworks
+	err = ips.UnmarshalJSON([]byte(`["127.0.0.0/8"]`))
+	require.NoError(t, err)
+
+	path := &dummyPath{
+		conf: &conf.Path{
+			RTSPReaderTransportRules: conf.RTSPTransportRules{{
+				IPs:        ips,
+				Transports: conf.Protocols{conf.Protocol(gortsplib.TransportTCP): {}},
+			}},
+		},
+		stream: stream,
+	}
+
+	pathManager := &dummyPathManager{path: path}
+
+	s := &Server{
+		Address:        "127.0.0.1:8557",
+		ReadTimeout:    conf.StringDuration(10 * time.Second),
+		WriteTimeout:   conf.StringDuration(10 * time.Second),
+		WriteQueueSize: 512,
+		UseUDP:         true,
+		RTPAddress:     "127.0.0.1:8050",
+		RTCPAddress:    "127.0.0.1:8051",
+		Protocols: map[conf.Protocol]struct{}{
+			conf.Protocol(gortsplib.TransportUDP): {},
+			conf.Protocol(gortsplib.TransportTCP): {},
+		},
+		PathManager: pathManager,
+		Parent:      test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	reader := gortsplib.Client{}
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8557/teststream")
+	require.NoError(t, err)
+
+	err = reader.Start(u.Scheme, u.Host)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	desc2, _, err := reader.Describe(u)
+	require.NoError(t, err)
+
+	// the client tries UDP first, receives 461 and switches to TCP
+	err = reader.SetupAll(desc2.BaseURL, desc2.Medias)
+	require.NoError(t, err)
+
+	stats, err := s.APITransportStats()
+	require.NoError(t, err)
+
+	require.Equal(t, &defs.APIRTSPTransportStats{
+		Rejections: map[string]uint64{
+			"multicast": 0,
+			"tcp":       0,
+			"udp":       1,
+		},
+		Fallbacks: []defs.APIRTSPTransportFallback{{
+			From:  "udp",
+			To:    "tcp",
+			Count: 1,
+		}},
+	}, stats)
+}
+
+type dummyAuthPathManager struct {
+	dummyPathManager
+	rejectValid int
+}
+
+func (pm *dummyAuthPathManager) Describe(req defs.PathDescribeReq) defs.PathDescribeRes {
+	err := auth.Validate(req.AccessRequest.RTSPRequest, "myuser", "mypass",
+		[]auth.ValidateMethod{auth.ValidateMethodSHA256}, "IPCAM", req.AccessRequest.RTSPNonce)
+	if err != nil {
+		return defs.PathDescribeRes{Err: mtxauth.Error{Message: err.Error()}}
+	}
+
+	// simulate an authentication backend that rejects valid credentials
+	if pm.rejectValid > 0 {
+		pm.rejectValid--
+		return defs.PathDescribeRes{Err: mtxauth.Error{Message: "temporarily unavailable"}}
+	}
+
+	return defs.PathDescribeRes{Redirect: "rtsp://127.0.0.1:8557/otherstream"}
+}
+
+func TestServerAuthNonceRetry(t *testing.T) {
+	s := &Server{
+		Address:              "127.0.0.1:8557",
+		AuthMethods:          []auth.ValidateMethod{auth.ValidateMethodSHA256},
+		AuthReplayProtection: true,
+		ReadTimeout:          conf.StringDuration(10 * time.Second),
+		WriteTimeout:         conf.StringDuration(10 * time.Second),
+		WriteQueueSize:       512,
+		Protocols:            map[conf.Protocol]struct{}{conf.Protocol(gortsplib.TransportTCP): {}},
+		PathManager:          &dummyAuthPathManager{rejectValid: 1},
+		Parent:               test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	nconn, err := net.Dial("tcp", "127.0.0.1:8557")
+	require.NoError(t, err)
+	defer nconn.Close()
+	conn := rtspconn.NewConn(nconn)
+
+	u, err := base.ParseURL("rtsp://127.0.0.1:8557/teststream")
+	require.NoError(t, err)
+
+	cseq := 0
+
+	describe := func(sender *auth.Sender) *base.Response {
+		cseq++
+		req := &base.Request{
+			Method: base.Describe,
+			URL:    u,
+			Header: base.Header{
+				"CSeq": base.HeaderValue{strconv.FormatInt(int64(cseq), 10)},
+			},
+		}
+		if sender != nil {
+			sender.AddAuthorization(req)
+		}
+
+		err = conn.WriteRequest(req)
+		require.NoError(t, err)
+
+		var res *base.Response
+		res, err = conn.ReadResponse()
+		require.NoError(t, err)
+		return res
+	}
+
+	res := describe(nil)
+	require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+
+	sender, err := auth.NewSender(res.Header["WWW-Authenticate"], "myuser", "mypass")
+	require.NoError(t, err)
+
+	res = describe(sender)
+	require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+
+	var h headers.Authenticate
+	err = h.Unmarshal(res.Header["WWW-Authenticate"])
+	require.NoError(t, err)
+	require.Nil(t, h.Stale)
+
+	// requests that are rejected can be retried with the same digest response.
+	res = describe(sender)
+	require.Equal(t, base.StatusMovedPermanently, res.StatusCode)
+
+	// responses of accepted requests can't be used again.
+	res = describe(sender)
+	require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+
+	err = h.Unmarshal(res.Header["WWW-Authenticate"])
+	require.NoError(t, err)
+	require.Equal(t, "true", *h.Stale)
+}
+
+func TestServerAuthNonce(t *testing.T) {
+	for _, ca := range []string{"replay", "expired"} {
+		t.Run(ca, func(t *testing.T) {
+			s := &Server{
+				Address:              "127.0.0.1:8557",
+				AuthMethods:          []auth.ValidateMethod{auth.ValidateMethodSHA256},
+				AuthNonceLifetime:    0,
+				AuthReplayProtection: ca == "replay",
+				ReadTimeout:          conf.StringDuration(10 * time.Second),
+				WriteTimeout:         conf.StringDuration(10 * time.Second),
+				WriteQueueSize:       512,
+				Protocols:            map[conf.Protocol]struct{}{conf.Protocol(gortsplib.TransportTCP): {}},
+				PathManager:          &dummyAuthPathManager{},
+				Parent:               test.NilLogger,
+			}
+
+			if ca == "expired" {
+				s.AuthNonceLifetime = conf.StringDuration(200 * time.Millisecond)
+			}
+
+			err := s.Initialize()
+			require.NoError(t, err)
+			defer s.Close()
+
+			nconn, err := net.Dial("tcp", "127.0.0.1:8557")
+			require.NoError(t, err)
+			defer nconn.Close()
+			conn := rtspconn.NewConn(nconn)
+
+			u, err := base.ParseURL("rtsp://127.0.0.1:8557/teststream")
+			require.NoError(t, err)
+
+			cseq := 0
+
+			describe := func(sender *auth.Sender) *base.Response {
+				cseq++
+				req := &base.Request{
+					Method: base.Describe,
+					URL:    u,
+					Header: base.Header{
+						"CSeq": base.HeaderValue{strconv.FormatInt(int64(cseq), 10)},
+					},
+				}
+				if sender != nil {
+					sender.AddAuthorization(req)
+				}
+
+				err = conn.WriteRequest(req)
+				require.NoError(t, err)
+
+				var res *base.Response
+				res, err = conn.ReadResponse()
+				require.NoError(t, err)
+				return res
+			}
+
+			res := describe(nil)
+			require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+
+			sender, err := auth.NewSender(res.Header["WWW-Authenticate"], "myuser", "mypass")
+			require.NoError(t, err)
+
+			res = describe(sender)
+			require.Equal(t, base.StatusMovedPermanently, res.StatusCode)
+
+			if ca == "expired" {
+				time.Sleep(300 * time.Millisecond)
+			}
+
+			res = describe(sender)
+			require.Equal(t, base.StatusUnauthorized, res.StatusCode)
+
+			var h headers.Authenticate
+			err = h.Unmarshal(res.Header["WWW-Authenticate"])
+			require.NoError(t, err)
+			require.Equal(t, "true", *h.Stale)
+			require.Equal(t, headers.AuthAlgorithmSHA256, *h.Algorithm)
+
+			sender, err = auth.NewSender(res.Header["WWW-Authenticate"], "myuser", "mypass")
+			require.NoError(t, err)
+
+			res = describe(sender)
+			require.Equal(t, base.StatusMovedPermanently, res.StatusCode)
+		})
+	}
+}
@@ -9,9 +9,10 @@ import (
 	"time"
 
 	"github.com/bluenviron/gortsplib/v4"
-	rtspauth "github.com/bluenviron/gortsplib/v4/pkg/auth"
 	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
 	"github.com/google/uuid"
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/mediamtx/internal/auth"
@@ -41,6 +42,7 @@ type session struct {
 	mutex           sync.Mutex
 	state           gortsplib.ServerSessionState
 	transport       *gortsplib.Transport
+	medias          []defs.APIRTSPSessionMedia
 	pathName        string
 	query           string
 	decodeErrLogger logger.Writer
@@ -101,14 +103,9 @@ func (s *session) onAnnounce(c *conn, ctx *gortsplib.ServerHandlerOnAnnounceCtx)
 	}
 	ctx.Path = ctx.Path[1:]
 
-	if c.authNonce == "" {
-		var err error
-		c.authNonce, err = rtspauth.GenerateNonce()
-		if err != nil {
-			return &base.Response{
-				StatusCode: base.StatusInternalServerError,
-			}, err
-		}
+	authRes, err := c.checkAuthNonce(ctx.Request)
+	if authRes != nil {
+		return authRes, err
 	}
 
 	path, err := s.pathManager.AddPublisher(defs.PathAddPublisherReq{
@@ -118,10 +115,12 @@ func (s *session) onAnnounce(c *conn, ctx *gortsplib.ServerHandlerOnAnnounceCtx)
 			Query:       ctx.Query,
 			Publish:     true,
 			IP:          c.ip(),
+			LocalIP:     c.localIP(),
 			Proto:       auth.ProtocolRTSP,
 			ID:          &c.uuid,
 			RTSPRequest: ctx.Request,
 			RTSPNonce:   c.authNonce,
+			UserAgent:   userAgent(ctx.Request),
 		},
 	})
 	if err != nil {
@@ -130,6 +129,13 @@ func (s *session) onAnnounce(c *conn, ctx *gortsplib.ServerHandlerOnAnnounceCtx)
 			return c.handleAuthError(terr)
 		}
 
+		var terr2 defs.PathUserAgentNotAllowedError
+		if errors.As(err, &terr2) {
+			return &base.Response{
+				StatusCode: base.StatusForbidden,
+			}, err
+		}
+
 		return &base.Response{
 			StatusCode: base.StatusBadRequest,
 		}, err
@@ -139,7 +145,7 @@ func (s *session) onAnnounce(c *conn, ctx *gortsplib.ServerHandlerOnAnnounceCtx)
 
 	s.mutex.Lock()
 	s.state = gortsplib.ServerSessionStatePreRecord
-	s.pathName = ctx.Path
+	s.pathName = path.Name()
 	s.query = ctx.Query
 	s.mutex.Unlock()
 
@@ -158,6 +164,9 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 	}
 	ctx.Path = ctx.Path[1:]
 
+	// the negotiated transport is read from the response
+	c.setupSession = s
+
 	// in case the client is setupping a stream with UDP or UDP-multicast, and these
 	// transport protocols are disabled, gortsplib already blocks the request.
 	// we have only to handle the case in which the transport protocol is TCP
@@ -172,14 +181,9 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 
 	switch s.rsession.State() {
 	case gortsplib.ServerSessionStateInitial, gortsplib.ServerSessionStatePrePlay: // play
-		if c.authNonce == "" {
-			var err error
-			c.authNonce, err = rtspauth.GenerateNonce()
-			if err != nil {
-				return &base.Response{
-					StatusCode: base.StatusInternalServerError,
-				}, nil, err
-			}
+		authRes, err := c.checkAuthNonce(ctx.Request)
+		if authRes != nil {
+			return authRes, nil, err
 		}
 
 		path, stream, err := s.pathManager.AddReader(defs.PathAddReaderReq{
@@ -188,10 +192,12 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 				Name:        ctx.Path,
 				Query:       ctx.Query,
 				IP:          c.ip(),
+				LocalIP:     c.localIP(),
 				Proto:       auth.ProtocolRTSP,
 				ID:          &c.uuid,
 				RTSPRequest: ctx.Request,
 				RTSPNonce:   c.authNonce,
+				UserAgent:   userAgent(ctx.Request),
 			},
 		})
 		if err != nil {
@@ -213,6 +219,17 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 			}, nil, err
 		}
 
+		// let the client switch to another transport
+		if rule := path.SafeConf().RTSPReaderTransportRules.Find(c.ip()); rule != nil {
+			if _, ok := rule.Transports[conf.Protocol(ctx.Transport)]; !ok {
+				path.RemoveReader(defs.PathRemoveReaderReq{Author: s})
+
+				return &base.Response{
+					StatusCode: base.StatusUnsupportedTransport,
+				}, nil, nil
+			}
+		}
+
 		s.path = path
 		s.stream = stream
 
@@ -240,6 +257,27 @@ func (s *session) onSetup(c *conn, ctx *gortsplib.ServerHandlerOnSetupCtx,
 	}
 }
 
+// onSetupResponse is called by conn.
+func (s *session) onSetupResponse(th *headers.Transport) {
+	m := defs.APIRTSPSessionMedia{
+		ClientPorts:    th.ClientPorts,
+		ServerPorts:    th.ServerPorts,
+		InterleavedIDs: th.InterleavedIDs,
+	}
+
+	if th.Delivery != nil && *th.Delivery == headers.TransportDeliveryMulticast {
+		if th.Destination != nil {
+			v := th.Destination.String()
+			m.MulticastAddress = &v
+		}
+		m.MulticastPorts = th.Ports
+	}
+
+	s.mutex.Lock()
+	s.medias = append(s.medias, m)
+	s.mutex.Unlock()
+}
+
 // onPlay is called by rtspServer.
 func (s *session) onPlay(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
 	h := make(base.Header)
@@ -286,6 +324,8 @@ func (s *session) onRecord(_ *gortsplib.ServerHandlerOnRecordCtx) (*base.Respons
 
 	s.stream = stream
 
+	useRTCP := s.path.SafeConf().ClockSource == conf.ClockSourceRTCP
+
 	for _, medi := range s.rsession.AnnouncedDescription().Medias {
 		for _, forma := range medi.Formats {
 			cmedi := medi
@@ -297,7 +337,14 @@ func (s *session) onRecord(_ *gortsplib.ServerHandlerOnRecordCtx) (*base.Respons
 					return
 				}
 
-				stream.WriteRTPPacket(cmedi, cforma, pkt, time.Now(), pts)
+				ntp := time.Now()
+				if useRTCP {
+					if v, ok := s.rsession.PacketNTP(cmedi, pkt); ok {
+						ntp = v
+					}
+				}
+
+				stream.WriteRTPPacket(cmedi, cforma, pkt, ntp, pts)
 			})
 		}
 	}
@@ -348,6 +395,29 @@ func (s *session) APIReaderDescribe() defs.APIPathSourceOrReader {
 	}
 }
 
+// Drain implements defs.DrainableReader.
+func (s *session) Drain() {
+	s.mutex.Lock()
+	state := s.state
+	s.mutex.Unlock()
+
+	if state != gortsplib.ServerSessionStatePlay {
+		return
+	}
+
+	for _, medi := range s.rsession.SetuppedMedias() {
+		ssrcs := s.stream.SSRCs(medi)
+		if len(ssrcs) == 0 {
+			continue
+		}
+
+		err := s.rsession.WritePacketRTCP(medi, &rtcp.Goodbye{Sources: ssrcs})
+		if err != nil {
+			s.Log(logger.Warn, "unable to send goodbye: %v", err)
+		}
+	}
+}
+
 // APISourceDescribe implements source.
 func (s *session) APISourceDescribe() defs.APIPathSourceOrReader {
 	return s.APIReaderDescribe()
@@ -368,6 +438,16 @@ func (s *session) onStreamWriteError(ctx *gortsplib.ServerHandlerOnStreamWriteEr
 	s.writeErrLogger.Log(logger.Warn, ctx.Error.Error())
 }
 
+// BytesReceived implements bandwidth.Counter.
+func (s *session) BytesReceived() uint64 {
+	return s.rsession.BytesReceived()
+}
+
+// BytesSent implements bandwidth.Counter.
+func (s *session) BytesSent() uint64 {
+	return s.rsession.BytesSent()
+}
+
 func (s *session) apiItem() *defs.APIRTSPSession {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -397,6 +477,14 @@ func (s *session) apiItem() *defs.APIRTSPSession {
 			v := s.transport.String()
 			return &v
 		}(),
+		Encryption: func() defs.APIRTSPSessionEncryption {
+			// RTSPS supports TCP only, therefore media is inside the TLS connection
+			if s.isTLS {
+				return defs.APIRTSPSessionEncryptionTLS
+			}
+			return defs.APIRTSPSessionEncryptionNone
+		}(),
+		Medias:        append([]defs.APIRTSPSessionMedia{}, s.medias...),
 		BytesReceived: s.rsession.BytesReceived(),
 		BytesSent:     s.rsession.BytesSent(),
 	}
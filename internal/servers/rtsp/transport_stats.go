@@ -0,0 +1,83 @@
+package rtsp
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+
+	"github.com/bluenviron/mediamtx/internal/defs"
+)
+
+var transportNames = []string{"multicast", "tcp", "udp"}
+
+func transportName(th headers.Transport) string {
+	if th.Protocol == headers.TransportProtocolTCP {
+		return "tcp"
+	}
+	if th.Delivery != nil && *th.Delivery == headers.TransportDeliveryMulticast {
+		return "multicast"
+	}
+	return "udp"
+}
+
+type transportFallback struct {
+	from string
+	to   string
+}
+
+// transportStats counts transports that are rejected during SETUP
+// and clients that switch to another transport after a rejection.
+type transportStats struct {
+	mutex      sync.Mutex
+	rejections map[string]uint64
+	fallbacks  map[transportFallback]uint64
+}
+
+func (ts *transportStats) initialize() {
+	ts.rejections = make(map[string]uint64)
+	ts.fallbacks = make(map[transportFallback]uint64)
+}
+
+func (ts *transportStats) addRejection(transport string) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.rejections[transport]++
+}
+
+func (ts *transportStats) addFallback(from string, to string) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.fallbacks[transportFallback{from: from, to: to}]++
+}
+
+func (ts *transportStats) apiItem() *defs.APIRTSPTransportStats {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	out := &defs.APIRTSPTransportStats{
+		Rejections: make(map[string]uint64),
+		Fallbacks:  []defs.APIRTSPTransportFallback{},
+	}
+
+	for _, name := range transportNames {
+		out.Rejections[name] = ts.rejections[name]
+	}
+
+	for k, v := range ts.fallbacks {
+		out.Fallbacks = append(out.Fallbacks, defs.APIRTSPTransportFallback{
+			From:  k.from,
+			To:    k.to,
+			Count: v,
+		})
+	}
+
+	sort.Slice(out.Fallbacks, func(i, j int) bool {
+		if out.Fallbacks[i].From != out.Fallbacks[j].From {
+			return out.Fallbacks[i].From < out.Fallbacks[j].From
+		}
+		return out.Fallbacks[i].To < out.Fallbacks[j].To
+	})
+
+	return out
+}
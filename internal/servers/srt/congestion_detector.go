@@ -0,0 +1,43 @@
+package srt
+
+import (
+	"fmt"
+	"time"
+
+	srt "github.com/datarhei/gosrt"
+)
+
+const (
+	// period between two checks of the send buffer.
+	congestionCheckPeriod = 1 * time.Second
+
+	// consecutive checks in which the send buffer must exceed the limit
+	// before the reader is dropped.
+	congestionMaxExceededChecks = 5
+)
+
+// congestionDetector detects readers that can't keep up with the stream,
+// by checking whether their send buffer persistently exceeds a limit.
+type congestionDetector struct {
+	maxSendBuffer time.Duration
+
+	exceededChecks int
+}
+
+// check returns an error when the reader has to be dropped.
+func (d *congestionDetector) check(s *srt.Statistics) error {
+	sendBuffer := time.Duration(s.Instantaneous.MsSendBuf) * time.Millisecond
+
+	if sendBuffer <= d.maxSendBuffer {
+		d.exceededChecks = 0
+		return nil
+	}
+
+	d.exceededChecks++
+	if d.exceededChecks < congestionMaxExceededChecks {
+		return nil
+	}
+
+	return fmt.Errorf("reader is too slow: send buffer contains %v of data, more than %v",
+		sendBuffer, d.maxSendBuffer)
+}
@@ -0,0 +1,34 @@
+package srt
+
+import (
+	"testing"
+	"time"
+
+	srt "github.com/datarhei/gosrt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCongestionDetector(t *testing.T) {
+	d := &congestionDetector{
+		maxSendBuffer: 2 * time.Second,
+	}
+
+	var s srt.Statistics
+
+	s.Instantaneous.MsSendBuf = 3000
+	for i := 0; i < congestionMaxExceededChecks-1; i++ {
+		require.NoError(t, d.check(&s))
+	}
+
+	// a single check below the limit resets the detector
+	s.Instantaneous.MsSendBuf = 1000
+	require.NoError(t, d.check(&s))
+
+	s.Instantaneous.MsSendBuf = 3000
+	for i := 0; i < congestionMaxExceededChecks-1; i++ {
+		require.NoError(t, d.check(&s))
+	}
+
+	err := d.check(&s)
+	require.EqualError(t, err, "reader is too slow: send buffer contains 3s of data, more than 2s")
+}
@@ -52,11 +52,14 @@ const (
 type conn struct {
 	parentCtx           context.Context
 	rtspAddress         string
+	streamIDRules       conf.SRTStreamIDRules
 	readTimeout         conf.StringDuration
 	writeTimeout        conf.StringDuration
 	writeQueueSize      int
 	udpMaxPayloadSize   int
+	maxSendBuffer       conf.StringDuration
 	connReq             srt.ConnRequest
+	localIP             net.IP
 	runOnConnect        string
 	runOnConnectRestart bool
 	runOnDisconnect     string
@@ -65,15 +68,19 @@ type conn struct {
 	pathManager         serverPathManager
 	parent              *Server
 
-	ctx       context.Context
-	ctxCancel func()
-	created   time.Time
-	uuid      uuid.UUID
-	mutex     sync.RWMutex
-	state     connState
-	pathName  string
-	query     string
-	sconn     srt.Conn
+	ctx        context.Context
+	ctxCancel  func()
+	created    time.Time
+	uuid       uuid.UUID
+	mutex      sync.RWMutex
+	state      connState
+	pathName   string
+	query      string
+	sconn      srt.Conn
+	stream     *stream.Stream
+	writer     *asyncwriter.Writer
+	paused     bool
+	dropReason string
 }
 
 func (c *conn) initialize() {
@@ -126,7 +133,7 @@ func (c *conn) run() { //nolint:dupl
 
 func (c *conn) runInner() error {
 	var streamID streamID
-	err := streamID.unmarshal(c.connReq.StreamId())
+	err := streamID.unmarshal(c.connReq.StreamId(), c.streamIDRules)
 	if err != nil {
 		c.connReq.Reject(srt.REJ_PEER)
 		return fmt.Errorf("invalid stream ID '%s': %w", c.connReq.StreamId(), err)
@@ -144,6 +151,7 @@ func (c *conn) runPublish(streamID *streamID) error {
 		AccessRequest: defs.PathAccessRequest{
 			Name:    streamID.path,
 			IP:      c.ip(),
+			LocalIP: c.localIP,
 			Publish: true,
 			User:    streamID.user,
 			Pass:    streamID.pass,
@@ -179,7 +187,7 @@ func (c *conn) runPublish(streamID *streamID) error {
 
 	c.mutex.Lock()
 	c.state = connStatePublish
-	c.pathName = streamID.path
+	c.pathName = path.Name()
 	c.query = streamID.query
 	c.sconn = sconn
 	c.mutex.Unlock()
@@ -242,13 +250,14 @@ func (c *conn) runRead(streamID *streamID) error {
 	path, stream, err := c.pathManager.AddReader(defs.PathAddReaderReq{
 		Author: c,
 		AccessRequest: defs.PathAccessRequest{
-			Name:  streamID.path,
-			IP:    c.ip(),
-			User:  streamID.user,
-			Pass:  streamID.pass,
-			Proto: auth.ProtocolSRT,
-			ID:    &c.uuid,
-			Query: streamID.query,
+			Name:    streamID.path,
+			IP:      c.ip(),
+			LocalIP: c.localIP,
+			User:    streamID.user,
+			Pass:    streamID.pass,
+			Proto:   auth.ProtocolSRT,
+			ID:      &c.uuid,
+			Query:   streamID.query,
 		},
 	})
 	if err != nil {
@@ -294,6 +303,11 @@ func (c *conn) runRead(streamID *streamID) error {
 		return err
 	}
 
+	c.mutex.Lock()
+	c.stream = stream
+	c.writer = writer
+	c.mutex.Unlock()
+
 	c.Log(logger.Info, "is reading from path '%s', %s",
 		path.Name(), defs.FormatsInfo(stream.FormatsForReader(writer)))
 
@@ -313,12 +327,39 @@ func (c *conn) runRead(streamID *streamID) error {
 	writer.Start()
 	defer writer.Stop()
 
-	select {
-	case <-c.ctx.Done():
-		return fmt.Errorf("terminated")
+	var congestionCheck <-chan time.Time
+	var detector *congestionDetector
 
-	case err = <-writer.Error():
-		return err
+	if c.maxSendBuffer != 0 {
+		ticker := time.NewTicker(congestionCheckPeriod)
+		defer ticker.Stop()
+		congestionCheck = ticker.C
+
+		detector = &congestionDetector{
+			maxSendBuffer: time.Duration(c.maxSendBuffer),
+		}
+	}
+
+	for {
+		select {
+		case <-congestionCheck:
+			var s srt.Statistics
+			sconn.Stats(&s)
+
+			err = detector.check(&s)
+			if err != nil {
+				c.mutex.Lock()
+				c.dropReason = err.Error()
+				c.mutex.Unlock()
+				return err
+			}
+
+		case <-c.ctx.Done():
+			return fmt.Errorf("terminated")
+
+		case err = <-writer.Error():
+			return err
+		}
 	}
 }
 
@@ -335,6 +376,52 @@ func (c *conn) APISourceDescribe() defs.APIPathSourceOrReader {
 	return c.APIReaderDescribe()
 }
 
+// BytesReceived implements bandwidth.Counter.
+func (c *conn) BytesReceived() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.sconn == nil {
+		return 0
+	}
+
+	var s srt.Statistics
+	c.sconn.Stats(&s)
+	return s.Accumulated.ByteRecv
+}
+
+// BytesSent implements bandwidth.Counter.
+func (c *conn) BytesSent() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.sconn == nil {
+		return 0
+	}
+
+	var s srt.Statistics
+	c.sconn.Stats(&s)
+	return s.Accumulated.ByteSent
+}
+
+func (c *conn) setPaused(paused bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.writer == nil {
+		return ErrConnNotReading
+	}
+
+	if paused {
+		c.stream.PauseReader(c.writer)
+	} else {
+		c.stream.ResumeReader(c.writer)
+	}
+
+	c.paused = paused
+	return nil
+}
+
 func (c *conn) apiItem() *defs.APISRTConn {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -355,8 +442,10 @@ func (c *conn) apiItem() *defs.APISRTConn {
 				return defs.APISRTConnStateIdle
 			}
 		}(),
-		Path:  c.pathName,
-		Query: c.query,
+		Path:       c.pathName,
+		Query:      c.query,
+		Paused:     c.paused,
+		DropReason: c.dropReason,
 	}
 
 	if c.sconn != nil {
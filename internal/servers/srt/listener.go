@@ -1,6 +1,7 @@
 package srt
 
 import (
+	"net"
 	"sync"
 
 	srt "github.com/datarhei/gosrt"
@@ -26,12 +27,32 @@ func (l *listener) run() {
 }
 
 func (l *listener) runInner() error {
+	// the local IP of connections is known only when the listener is bound to a specific IP
+	var localIP net.IP
+	if addr, ok := l.ln.Addr().(*net.UDPAddr); ok && !addr.IP.IsUnspecified() {
+		localIP = addr.IP
+	}
+
 	for {
 		req, err := l.ln.Accept2()
 		if err != nil {
 			return err
 		}
 
-		l.parent.newConnRequest(req)
+		if addr, ok := req.RemoteAddr().(*net.UDPAddr); ok {
+			// reject connections from IPs that are not allowed before performing the crypto handshake
+			if !l.parent.ipAllowed(addr.IP) {
+				req.Reject(srt.REJX_FORBIDDEN)
+				continue
+			}
+
+			// reject connections that exceed limits before processing stream IDs and passphrases
+			if !l.parent.ConnLimiter.Allow("srt", addr.IP) {
+				req.Reject(srt.REJ_BACKLOG)
+				continue
+			}
+		}
+
+		l.parent.newConnRequest(req, localIP)
 	}
 }
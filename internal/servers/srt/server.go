@@ -5,14 +5,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	srt "github.com/datarhei/gosrt"
 	"github.com/google/uuid"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
@@ -22,6 +26,9 @@ import (
 // ErrConnNotFound is returned when a connection is not found.
 var ErrConnNotFound = errors.New("connection not found")
 
+// ErrConnNotReading is returned when a connection is not reading.
+var ErrConnNotReading = errors.New("connection is not reading")
+
 func srtMaxPayloadSize(u int) int {
 	return ((u - 16) / 188) * 188 // 16 = SRT header, 188 = MPEG-TS packet
 }
@@ -54,6 +61,16 @@ type serverAPIConnsKickReq struct {
 	res  chan serverAPIConnsKickRes
 }
 
+type serverAPIConnsPauseRes struct {
+	err error
+}
+
+type serverAPIConnsPauseReq struct {
+	uuid   uuid.UUID
+	paused bool
+	res    chan serverAPIConnsPauseRes
+}
+
 type serverPathManager interface {
 	AddPublisher(req defs.PathAddPublisherReq) (defs.Path, error)
 	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
@@ -63,14 +80,24 @@ type serverParent interface {
 	logger.Writer
 }
 
+type serverNewConnReq struct {
+	connReq srt.ConnRequest
+	localIP net.IP
+}
+
 // Server is a SRT server.
 type Server struct {
 	Address             string
+	Listeners           conf.Listeners
+	StreamIDRules       conf.SRTStreamIDRules
+	AllowedIPs          conf.IPNetworks
 	RTSPAddress         string
 	ReadTimeout         conf.StringDuration
 	WriteTimeout        conf.StringDuration
 	WriteQueueSize      int
 	UDPMaxPayloadSize   int
+	MaxSendBuffer       conf.StringDuration
+	ConnLimiter         *connlimiter.Limiter
 	RunOnConnect        string
 	RunOnConnectRestart bool
 	RunOnDisconnect     string
@@ -81,16 +108,19 @@ type Server struct {
 	ctx       context.Context
 	ctxCancel func()
 	wg        sync.WaitGroup
-	ln        srt.Listener
+	lns       []srt.Listener
 	conns     map[*conn]struct{}
 
+	handshakesRejected *uint64
+
 	// in
-	chNewConnRequest chan srt.ConnRequest
+	chNewConnRequest chan serverNewConnReq
 	chAcceptErr      chan error
 	chCloseConn      chan *conn
 	chAPIConnsList   chan serverAPIConnsListReq
 	chAPIConnsGet    chan serverAPIConnsGetReq
 	chAPIConnsKick   chan serverAPIConnsKickReq
+	chAPIConnsPause  chan serverAPIConnsPauseReq
 }
 
 // Initialize initializes the server.
@@ -99,30 +129,46 @@ func (s *Server) Initialize() error {
 	conf.ConnectionTimeout = time.Duration(s.ReadTimeout)
 	conf.PayloadSize = uint32(srtMaxPayloadSize(s.UDPMaxPayloadSize))
 
-	var err error
-	s.ln, err = srt.Listen("srt", s.Address, conf)
+	ln, err := srt.Listen("srt", s.Address, conf)
 	if err != nil {
 		return err
 	}
+	s.lns = []srt.Listener{ln}
+
+	for _, l := range s.Listeners {
+		ln, err = srt.Listen("srt", l.Address, conf)
+		if err != nil {
+			for _, ln := range s.lns {
+				ln.Close()
+			}
+			return err
+		}
+		s.lns = append(s.lns, ln)
+	}
 
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 
 	s.conns = make(map[*conn]struct{})
-	s.chNewConnRequest = make(chan srt.ConnRequest)
+	s.handshakesRejected = new(uint64)
+	s.chNewConnRequest = make(chan serverNewConnReq)
 	s.chAcceptErr = make(chan error)
 	s.chCloseConn = make(chan *conn)
 	s.chAPIConnsList = make(chan serverAPIConnsListReq)
 	s.chAPIConnsGet = make(chan serverAPIConnsGetReq)
 	s.chAPIConnsKick = make(chan serverAPIConnsKickReq)
+	s.chAPIConnsPause = make(chan serverAPIConnsPauseReq)
 
-	s.Log(logger.Info, "listener opened on "+s.Address+" (UDP)")
+	s.Log(logger.Info, "listener opened on %s (UDP)",
+		strings.Join(append([]string{s.Address}, s.Listeners.Addresses()...), ", "))
 
-	l := &listener{
-		ln:     s.ln,
-		wg:     &s.wg,
-		parent: s,
+	for _, ln := range s.lns {
+		l := &listener{
+			ln:     ln,
+			wg:     &s.wg,
+			parent: s,
+		}
+		l.initialize()
 	}
-	l.initialize()
 
 	s.wg.Add(1)
 	go s.run()
@@ -156,11 +202,14 @@ outer:
 			c := &conn{
 				parentCtx:           s.ctx,
 				rtspAddress:         s.RTSPAddress,
+				streamIDRules:       s.StreamIDRules,
 				readTimeout:         s.ReadTimeout,
 				writeTimeout:        s.WriteTimeout,
 				writeQueueSize:      s.WriteQueueSize,
 				udpMaxPayloadSize:   s.UDPMaxPayloadSize,
-				connReq:             req,
+				maxSendBuffer:       s.MaxSendBuffer,
+				connReq:             req.connReq,
+				localIP:             req.localIP,
 				runOnConnect:        s.RunOnConnect,
 				runOnConnectRestart: s.RunOnConnectRestart,
 				runOnDisconnect:     s.RunOnDisconnect,
@@ -210,6 +259,15 @@ outer:
 			c.Close()
 			req.res <- serverAPIConnsKickRes{}
 
+		case req := <-s.chAPIConnsPause:
+			c := s.findConnByUUID(req.uuid)
+			if c == nil {
+				req.res <- serverAPIConnsPauseRes{err: ErrConnNotFound}
+				continue
+			}
+
+			req.res <- serverAPIConnsPauseRes{err: c.setPaused(req.paused)}
+
 		case <-s.ctx.Done():
 			break outer
 		}
@@ -217,7 +275,9 @@ outer:
 
 	s.ctxCancel()
 
-	s.ln.Close()
+	for _, ln := range s.lns {
+		ln.Close()
+	}
 }
 
 func (s *Server) findConnByUUID(uuid uuid.UUID) *conn {
@@ -229,10 +289,24 @@ func (s *Server) findConnByUUID(uuid uuid.UUID) *conn {
 	return nil
 }
 
+// ipAllowed is called by srtListener.
+func (s *Server) ipAllowed(ip net.IP) bool {
+	if len(s.AllowedIPs) == 0 || s.AllowedIPs.Contains(ip) {
+		return true
+	}
+	atomic.AddUint64(s.handshakesRejected, 1)
+	return false
+}
+
+// HandshakesRejected returns the number of handshakes rejected because of their IP.
+func (s *Server) HandshakesRejected() uint64 {
+	return atomic.LoadUint64(s.handshakesRejected)
+}
+
 // newConnRequest is called by srtListener.
-func (s *Server) newConnRequest(connReq srt.ConnRequest) {
+func (s *Server) newConnRequest(connReq srt.ConnRequest, localIP net.IP) {
 	select {
-	case s.chNewConnRequest <- connReq:
+	case s.chNewConnRequest <- serverNewConnReq{connReq: connReq, localIP: localIP}:
 	case <-s.ctx.Done():
 		connReq.Reject(srt.REJ_CLOSE)
 	}
@@ -303,3 +377,30 @@ func (s *Server) APIConnsKick(uuid uuid.UUID) error {
 		return fmt.Errorf("terminated")
 	}
 }
+
+// APIConnsPause is called by api.
+func (s *Server) APIConnsPause(uuid uuid.UUID) error {
+	return s.apiConnsSetPaused(uuid, true)
+}
+
+// APIConnsResume is called by api.
+func (s *Server) APIConnsResume(uuid uuid.UUID) error {
+	return s.apiConnsSetPaused(uuid, false)
+}
+
+func (s *Server) apiConnsSetPaused(uuid uuid.UUID, paused bool) error {
+	req := serverAPIConnsPauseReq{
+		uuid:   uuid,
+		paused: paused,
+		res:    make(chan serverAPIConnsPauseRes),
+	}
+
+	select {
+	case s.chAPIConnsPause <- req:
+		res := <-req.res
+		return res.err
+
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
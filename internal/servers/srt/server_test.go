@@ -2,6 +2,7 @@ package srt
 
 import (
 	"bufio"
+	"net"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/stream"
 	"github.com/bluenviron/mediamtx/internal/test"
 	"github.com/bluenviron/mediamtx/internal/unit"
@@ -42,6 +44,7 @@ func (p *dummyPath) StartPublisher(req defs.PathStartPublisherReq) (*stream.Stre
 		1460,
 		req.Desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	if err != nil {
@@ -179,6 +182,7 @@ func TestServerRead(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -266,3 +270,34 @@ func TestServerRead(t *testing.T) {
 		}
 	}
 }
+
+func TestServerAllowedIPs(t *testing.T) {
+	externalCmdPool := externalcmd.NewPool()
+	defer externalCmdPool.Close()
+
+	_, allowed, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	s := &Server{
+		Address:           "127.0.0.1:8890",
+		AllowedIPs:        conf.IPNetworks{*allowed},
+		ReadTimeout:       conf.StringDuration(10 * time.Second),
+		WriteTimeout:      conf.StringDuration(10 * time.Second),
+		WriteQueueSize:    512,
+		UDPMaxPayloadSize: 1472,
+		ExternalCmdPool:   externalCmdPool,
+		PathManager:       &dummyPathManager{path: &dummyPath{}},
+		Parent:            test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	srtConf := srt.DefaultConfig()
+	srtConf.StreamId = "publish:mypath"
+
+	_, err = srt.Dial("srt", "127.0.0.1:8890", srtConf)
+	require.Error(t, err)
+
+	require.Equal(t, uint64(1), s.HandshakesRejected())
+}
@@ -2,7 +2,10 @@ package srt
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
 )
 
 type streamIDMode int
@@ -20,7 +23,46 @@ type streamID struct {
 	pass  string
 }
 
-func (s *streamID) unmarshal(raw string) error {
+func (s *streamID) unmarshalRule(raw string, rule *conf.SRTStreamIDRule) error {
+	re := regexp.MustCompile(rule.Match)
+	match := re.FindStringSubmatchIndex(raw)
+
+	expand := func(template string, defaultTemplate string) string {
+		if template == "" {
+			template = defaultTemplate
+		}
+		return string(re.ExpandString(nil, template, raw, match))
+	}
+
+	switch action := expand(rule.Action, "${action}"); action {
+	case "read", "request":
+		s.mode = streamIDModeRead
+
+	case "publish":
+		s.mode = streamIDModePublish
+
+	default:
+		return fmt.Errorf("unsupported action '%s'", action)
+	}
+
+	s.path = expand(rule.Path, "${path}")
+	if s.path == "" {
+		return fmt.Errorf("path is empty")
+	}
+
+	s.query = expand(rule.Query, "${query}")
+	s.user = expand(rule.User, "${user}")
+	s.pass = expand(rule.Pass, "${pass}")
+
+	return nil
+}
+
+func (s *streamID) unmarshal(raw string, rules conf.SRTStreamIDRules) error {
+	// custom syntax
+	if rule := rules.Find(raw); rule != nil {
+		return s.unmarshalRule(raw, rule)
+	}
+
 	// standard syntax
 	// https://github.com/Haivision/srt/blob/master/docs/features/access-control.md
 	if strings.HasPrefix(raw, "#!::") {
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
 )
 
 func TestStreamIDUnmarshal(t *testing.T) {
@@ -53,9 +55,72 @@ func TestStreamIDUnmarshal(t *testing.T) {
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			var sid streamID
-			err := sid.unmarshal(ca.raw)
+			err := sid.unmarshal(ca.raw, nil)
+			require.NoError(t, err)
+			require.Equal(t, ca.dec, sid)
+		})
+	}
+}
+
+func TestStreamIDUnmarshalRules(t *testing.T) {
+	rules := conf.SRTStreamIDRules{
+		{
+			Match: `^#!::r=(?P<path>[^,]+),m=(?P<action>[a-z]+),key=(?P<pass>.+)$`,
+			User:  "encoder",
+		},
+		{
+			Match:  `^live/(?P<camera>[0-9]+)(?:\?(?P<query>.*))?$`,
+			Action: "publish",
+			Path:   "cameras/cam${camera}",
+		},
+		{
+			Match:  `^play/(?P<path>.*)$`,
+			Action: "read",
+		},
+	}
+
+	for _, ca := range []struct {
+		name string
+		raw  string
+		dec  streamID
+	}{
+		{
+			"named groups",
+			"#!::r=mypath,m=publish,key=mykey",
+			streamID{
+				mode: streamIDModePublish,
+				path: "mypath",
+				user: "encoder",
+				pass: "mykey",
+			},
+		},
+		{
+			"templates",
+			"live/12?token=abc",
+			streamID{
+				mode:  streamIDModePublish,
+				path:  "cameras/cam12",
+				query: "token=abc",
+			},
+		},
+		{
+			"no match",
+			"read:mypath",
+			streamID{
+				mode: streamIDModeRead,
+				path: "mypath",
+			},
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			var sid streamID
+			err := sid.unmarshal(ca.raw, rules)
 			require.NoError(t, err)
 			require.Equal(t, ca.dec, sid)
 		})
 	}
+
+	var sid streamID
+	err := sid.unmarshal("play/", rules)
+	require.EqualError(t, err, "path is empty")
 }
@@ -0,0 +1,86 @@
+package webrtc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	pwebrtc "github.com/pion/webrtc/v3"
+)
+
+// validity of generated DTLS certificates.
+// Certificates are long-lived in order to keep the fingerprint stable.
+const dtlsCertificateValidity = 10 * 365 * 24 * time.Hour
+
+func generateDTLSCertificate() (*pwebrtc.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	return pwebrtc.NewCertificate(key, x509.Certificate{
+		Issuer:       pkix.Name{CommonName: "mediamtx"},
+		Subject:      pkix.Name{CommonName: "mediamtx"},
+		NotBefore:    time.Now().AddDate(0, 0, -1),
+		NotAfter:     time.Now().Add(dtlsCertificateValidity),
+		SerialNumber: serialNumber,
+		Version:      2,
+	})
+}
+
+// loadDTLSCertificate loads a DTLS certificate from a file.
+// When the file doesn't exist or the certificate is expired,
+// a new certificate is generated and saved into the file.
+func loadDTLSCertificate(fpath string) (*pwebrtc.Certificate, bool, error) {
+	byts, err := os.ReadFile(fpath)
+	if err == nil {
+		var cert *pwebrtc.Certificate
+		cert, err = pwebrtc.CertificateFromPEM(string(byts))
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to load DTLS certificate: %w", err)
+		}
+
+		if time.Now().Before(cert.Expires()) {
+			return cert, false, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	cert, err := generateDTLSCertificate()
+	if err != nil {
+		return nil, false, err
+	}
+
+	enc, err := cert.PEM()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = os.WriteFile(fpath, []byte(enc), 0o600)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to save DTLS certificate: %w", err)
+	}
+
+	return cert, true, nil
+}
+
+func dtlsFingerprint(cert *pwebrtc.Certificate) string {
+	fingerprints, err := cert.GetFingerprints()
+	if err != nil || len(fingerprints) == 0 {
+		return ""
+	}
+	return fingerprints[0].Algorithm + " " + fingerprints[0].Value
+}
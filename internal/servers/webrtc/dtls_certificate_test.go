@@ -0,0 +1,28 @@
+package webrtc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDTLSCertificate(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-dtls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fpath := filepath.Join(dir, "dtls.pem")
+
+	cert1, generated, err := loadDTLSCertificate(fpath)
+	require.NoError(t, err)
+	require.Equal(t, true, generated)
+
+	cert2, generated, err := loadDTLSCertificate(fpath)
+	require.NoError(t, err)
+	require.Equal(t, false, generated)
+
+	require.NotEqual(t, "", dtlsFingerprint(cert1))
+	require.Equal(t, dtlsFingerprint(cert1), dtlsFingerprint(cert2))
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/bluenviron/mediamtx/internal/auth"
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
@@ -72,39 +73,66 @@ func addJWTFromAuthorization(rawQuery string, auth string) string {
 }
 
 type httpServer struct {
-	address        string
-	encryption     bool
-	serverKey      string
-	serverCert     string
-	allowOrigin    string
-	trustedProxies conf.IPNetworks
-	readTimeout    conf.StringDuration
-	pathManager    serverPathManager
-	parent         *Server
-
-	inner *httpp.WrappedServer
+	address         string
+	listeners       conf.Listeners
+	encryption      bool
+	serverKey       string
+	serverCert      string
+	tlsOptions      conf.TLSOptions
+	serverHeader    string
+	responseHeaders conf.HTTPResponseHeaders
+	allowOrigin     string
+	trustedProxies  conf.IPNetworks
+	readTimeout     conf.StringDuration
+	requestLimiter  *httpp.RequestLimiter
+	connLimiter     *connlimiter.Limiter
+	pathManager     serverPathManager
+	parent          *Server
+
+	inners []*httpp.WrappedServer
 }
 
 func (s *httpServer) initialize() error {
 	router := gin.New()
 	router.SetTrustedProxies(s.trustedProxies.ToTrustedProxies()) //nolint:errcheck
+	if s.requestLimiter != nil {
+		router.Use(s.requestLimiter.Handler("webrtc"))
+	}
 	router.NoRoute(s.onRequest)
 
-	network, address := restrictnetwork.Restrict("tcp", s.address)
-
-	s.inner = &httpp.WrappedServer{
-		Network:     network,
-		Address:     address,
-		ReadTimeout: time.Duration(s.readTimeout),
-		Encryption:  s.encryption,
-		ServerCert:  s.serverCert,
-		ServerKey:   s.serverKey,
-		Handler:     router,
-		Parent:      s,
-	}
-	err := s.inner.Initialize()
-	if err != nil {
-		return err
+	listeners := append(conf.Listeners{{
+		Address:    s.address,
+		Encryption: s.encryption,
+	}}, s.listeners...)
+
+	for _, l := range listeners {
+		serverCert, serverKey := s.serverCert, s.serverKey
+		if l.ServerCert != "" {
+			serverCert, serverKey = l.ServerCert, l.ServerKey
+		}
+
+		network, address := restrictnetwork.Restrict("tcp", l.Address)
+
+		inner := &httpp.WrappedServer{
+			Network:         network,
+			Address:         address,
+			ReadTimeout:     time.Duration(s.readTimeout),
+			Encryption:      l.Encryption,
+			ServerCert:      serverCert,
+			ServerKey:       serverKey,
+			TLSOptions:      s.tlsOptions,
+			ServerHeader:    s.serverHeader,
+			ResponseHeaders: s.responseHeaders,
+			Handler:         router,
+			Parent:          s,
+		}
+		err := inner.Initialize()
+		if err != nil {
+			s.close()
+			return err
+		}
+
+		s.inners = append(s.inners, inner)
 	}
 
 	return nil
@@ -116,7 +144,9 @@ func (s *httpServer) Log(level logger.Level, format string, args ...interface{})
 }
 
 func (s *httpServer) close() {
-	s.inner.Close()
+	for _, inner := range s.inners {
+		inner.Close()
+	}
 }
 
 func (s *httpServer) checkAuthOutsideSession(ctx *gin.Context, pathName string, publish bool) bool {
@@ -136,13 +166,15 @@ func (s *httpServer) checkAuthOutsideSession(ctx *gin.Context, pathName string,
 
 	_, err := s.pathManager.FindPathConf(defs.PathFindPathConfReq{
 		AccessRequest: defs.PathAccessRequest{
-			Name:    pathName,
-			Query:   q,
-			Publish: publish,
-			IP:      net.ParseIP(ctx.ClientIP()),
-			User:    user,
-			Pass:    pass,
-			Proto:   auth.ProtocolWebRTC,
+			Name:      pathName,
+			Query:     q,
+			Publish:   publish,
+			IP:        net.ParseIP(ctx.ClientIP()),
+			LocalIP:   httpp.LocalIP(ctx.Request),
+			User:      user,
+			Pass:      pass,
+			Proto:     auth.ProtocolWebRTC,
+			UserAgent: ctx.Request.UserAgent(),
 		},
 	})
 	if err != nil {
@@ -163,6 +195,18 @@ func (s *httpServer) checkAuthOutsideSession(ctx *gin.Context, pathName string,
 			return false
 		}
 
+		var terr2 defs.PathProtocolNotAllowedError
+		if errors.As(err, &terr2) {
+			writeError(ctx, http.StatusForbidden, terr2)
+			return false
+		}
+
+		var terr3 defs.PathUserAgentNotAllowedError
+		if errors.As(err, &terr3) {
+			writeError(ctx, http.StatusForbidden, terr3)
+			return false
+		}
+
 		writeError(ctx, http.StatusInternalServerError, err)
 		return false
 	}
@@ -189,6 +233,11 @@ func (s *httpServer) onWHIPOptions(ctx *gin.Context, pathName string, publish bo
 }
 
 func (s *httpServer) onWHIPPost(ctx *gin.Context, pathName string, publish bool) {
+	if !s.connLimiter.Allow("webrtc", net.ParseIP(ctx.ClientIP())) {
+		writeError(ctx, http.StatusTooManyRequests, fmt.Errorf("too many sessions"))
+		return
+	}
+
 	contentType := httpp.ParseContentType(ctx.Request.Header.Get("Content-Type"))
 	if contentType != "application/sdp" {
 		writeError(ctx, http.StatusBadRequest, fmt.Errorf("invalid Content-Type"))
@@ -217,11 +266,13 @@ func (s *httpServer) onWHIPPost(ctx *gin.Context, pathName string, publish bool)
 	res := s.parent.newSession(webRTCNewSessionReq{
 		pathName:   pathName,
 		remoteAddr: httpp.RemoteAddr(ctx),
+		localIP:    httpp.LocalIP(ctx.Request),
 		query:      q,
 		user:       user,
 		pass:       pass,
 		offer:      offer,
 		publish:    publish,
+		userAgent:  ctx.Request.UserAgent(),
 	})
 	if res.err != nil {
 		writeError(ctx, res.errStatusCode, res.err)
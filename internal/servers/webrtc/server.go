@@ -22,9 +22,11 @@ import (
 	pwebrtc "github.com/pion/webrtc/v3"
 
 	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/connlimiter"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
 	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/httpp"
 	"github.com/bluenviron/mediamtx/internal/restrictnetwork"
 	"github.com/bluenviron/mediamtx/internal/stream"
 )
@@ -36,6 +38,9 @@ const (
 // ErrSessionNotFound is returned when a session is not found.
 var ErrSessionNotFound = errors.New("session not found")
 
+// ErrSessionNotReading is returned when a session is not reading.
+var ErrSessionNotReading = errors.New("session is not reading")
+
 type nilWriter struct{}
 
 func (nilWriter) Write(p []byte) (int, error) {
@@ -125,6 +130,16 @@ type serverAPISessionsKickReq struct {
 	res  chan serverAPISessionsKickRes
 }
 
+type serverAPISessionsPauseRes struct {
+	err error
+}
+
+type serverAPISessionsPauseReq struct {
+	uuid   uuid.UUID
+	paused bool
+	res    chan serverAPISessionsPauseRes
+}
+
 type webRTCNewSessionRes struct {
 	sx            *session
 	answer        []byte
@@ -135,11 +150,13 @@ type webRTCNewSessionRes struct {
 type webRTCNewSessionReq struct {
 	pathName   string
 	remoteAddr string
+	localIP    net.IP
 	query      string
 	user       string
 	pass       string
 	offer      []byte
 	publish    bool
+	userAgent  string
 	res        chan webRTCNewSessionRes
 }
 
@@ -171,31 +188,47 @@ type serverPathManager interface {
 	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
 }
 
+type serverPublicIPDetector interface {
+	IP() string
+}
+
 type serverParent interface {
 	logger.Writer
 }
 
 // Server is a WebRTC server.
 type Server struct {
-	Address               string
-	Encryption            bool
-	ServerKey             string
-	ServerCert            string
-	AllowOrigin           string
-	TrustedProxies        conf.IPNetworks
-	ReadTimeout           conf.StringDuration
-	WriteQueueSize        int
-	LocalUDPAddress       string
-	LocalTCPAddress       string
-	IPsFromInterfaces     bool
-	IPsFromInterfacesList []string
-	AdditionalHosts       []string
-	ICEServers            []conf.WebRTCICEServer
-	HandshakeTimeout      conf.StringDuration
-	TrackGatherTimeout    conf.StringDuration
-	ExternalCmdPool       *externalcmd.Pool
-	PathManager           serverPathManager
-	Parent                serverParent
+	Address                      string
+	Listeners                    conf.Listeners
+	Encryption                   bool
+	ServerKey                    string
+	ServerCert                   string
+	TLSOptions                   conf.TLSOptions
+	ServerHeader                 string
+	ResponseHeaders              conf.HTTPResponseHeaders
+	AllowOrigin                  string
+	TrustedProxies               conf.IPNetworks
+	ReadTimeout                  conf.StringDuration
+	WriteQueueSize               int
+	LocalUDPAddress              string
+	LocalTCPAddress              string
+	UDPPortRange                 conf.UDPPortRange
+	IPsFromInterfaces            bool
+	IPsFromInterfacesList        []string
+	IPsFromInterfacesExcludeList []string
+	AdditionalHosts              []string
+	ICEAddressFamilies           conf.WebRTCICEAddressFamilies
+	ICECandidateTypes            conf.WebRTCICECandidateTypes
+	PublicIPDetector             serverPublicIPDetector
+	ICEServers                   []conf.WebRTCICEServer
+	HandshakeTimeout             conf.StringDuration
+	TrackGatherTimeout           conf.StringDuration
+	DTLSCertificateFile          string
+	ExternalCmdPool              *externalcmd.Pool
+	RequestLimiter               *httpp.RequestLimiter
+	ConnLimiter                  *connlimiter.Limiter
+	PathManager                  serverPathManager
+	Parent                       serverParent
 
 	ctx              context.Context
 	ctxCancel        func()
@@ -206,6 +239,7 @@ type Server struct {
 	iceTCPMux        ice.TCPMux
 	sessions         map[*session]struct{}
 	sessionsBySecret map[uuid.UUID]*session
+	dtlsCertificate  *pwebrtc.Certificate
 
 	// in
 	chNewSession           chan webRTCNewSessionReq
@@ -215,6 +249,7 @@ type Server struct {
 	chAPISessionsList      chan serverAPISessionsListReq
 	chAPISessionsGet       chan serverAPISessionsGetReq
 	chAPIConnsKick         chan serverAPISessionsKickReq
+	chAPISessionsPause     chan serverAPISessionsPauseReq
 
 	// out
 	done chan struct{}
@@ -222,6 +257,21 @@ type Server struct {
 
 // Initialize initializes the server.
 func (s *Server) Initialize() error {
+	if s.DTLSCertificateFile != "" {
+		var generated bool
+		var err error
+		s.dtlsCertificate, generated, err = loadDTLSCertificate(s.DTLSCertificateFile)
+		if err != nil {
+			return err
+		}
+
+		if generated {
+			s.Log(logger.Info, "generated DTLS certificate, fingerprint: %s", dtlsFingerprint(s.dtlsCertificate))
+		} else {
+			s.Log(logger.Info, "loaded DTLS certificate, fingerprint: %s", dtlsFingerprint(s.dtlsCertificate))
+		}
+	}
+
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	s.ctx = ctx
@@ -235,18 +285,25 @@ func (s *Server) Initialize() error {
 	s.chAPISessionsList = make(chan serverAPISessionsListReq)
 	s.chAPISessionsGet = make(chan serverAPISessionsGetReq)
 	s.chAPIConnsKick = make(chan serverAPISessionsKickReq)
+	s.chAPISessionsPause = make(chan serverAPISessionsPauseReq)
 	s.done = make(chan struct{})
 
 	s.httpServer = &httpServer{
-		address:        s.Address,
-		encryption:     s.Encryption,
-		serverKey:      s.ServerKey,
-		serverCert:     s.ServerCert,
-		allowOrigin:    s.AllowOrigin,
-		trustedProxies: s.TrustedProxies,
-		readTimeout:    s.ReadTimeout,
-		pathManager:    s.PathManager,
-		parent:         s,
+		address:         s.Address,
+		listeners:       s.Listeners,
+		encryption:      s.Encryption,
+		serverKey:       s.ServerKey,
+		serverCert:      s.ServerCert,
+		tlsOptions:      s.TLSOptions,
+		serverHeader:    s.ServerHeader,
+		responseHeaders: s.ResponseHeaders,
+		allowOrigin:     s.AllowOrigin,
+		trustedProxies:  s.TrustedProxies,
+		readTimeout:     s.ReadTimeout,
+		requestLimiter:  s.RequestLimiter,
+		connLimiter:     s.ConnLimiter,
+		pathManager:     s.PathManager,
+		parent:          s,
 	}
 	err := s.httpServer.initialize()
 	if err != nil {
@@ -276,6 +333,9 @@ func (s *Server) Initialize() error {
 	}
 
 	str := "listener opened on " + s.Address + " (HTTP)"
+	for _, l := range s.Listeners {
+		str += ", " + l.Address + " (HTTP)"
+	}
 	if s.udpMuxLn != nil {
 		str += ", " + s.LocalUDPAddress + " (ICE/UDP)"
 	}
@@ -294,6 +354,25 @@ func (s *Server) Log(level logger.Level, format string, args ...interface{}) {
 	s.Parent.Log(level, "[WebRTC] "+format, args...)
 }
 
+func (s *Server) additionalHosts() []string {
+	if s.PublicIPDetector == nil {
+		return s.AdditionalHosts
+	}
+
+	ip := s.PublicIPDetector.IP()
+	if ip == "" {
+		return s.AdditionalHosts
+	}
+
+	for _, host := range s.AdditionalHosts {
+		if host == ip {
+			return s.AdditionalHosts
+		}
+	}
+
+	return append(append([]string(nil), s.AdditionalHosts...), ip)
+}
+
 // Close closes the server.
 func (s *Server) Close() {
 	s.Log(logger.Info, "listener is closing")
@@ -315,7 +394,7 @@ outer:
 				writeQueueSize:        s.WriteQueueSize,
 				ipsFromInterfaces:     s.IPsFromInterfaces,
 				ipsFromInterfacesList: s.IPsFromInterfacesList,
-				additionalHosts:       s.AdditionalHosts,
+				additionalHosts:       s.additionalHosts(),
 				iceUDPMux:             s.iceUDPMux,
 				iceTCPMux:             s.iceTCPMux,
 				req:                   req,
@@ -392,6 +471,15 @@ outer:
 
 			req.res <- serverAPISessionsKickRes{}
 
+		case req := <-s.chAPISessionsPause:
+			sx := s.findSessionByUUID(req.uuid)
+			if sx == nil {
+				req.res <- serverAPISessionsPauseRes{err: ErrSessionNotFound}
+				continue
+			}
+
+			req.res <- serverAPISessionsPauseRes{err: sx.setPaused(req.paused)}
+
 		case <-s.ctx.Done():
 			break outer
 		}
@@ -560,3 +648,30 @@ func (s *Server) APISessionsKick(uuid uuid.UUID) error {
 		return fmt.Errorf("terminated")
 	}
 }
+
+// APISessionsPause is called by api.
+func (s *Server) APISessionsPause(uuid uuid.UUID) error {
+	return s.apiSessionsSetPaused(uuid, true)
+}
+
+// APISessionsResume is called by api.
+func (s *Server) APISessionsResume(uuid uuid.UUID) error {
+	return s.apiSessionsSetPaused(uuid, false)
+}
+
+func (s *Server) apiSessionsSetPaused(uuid uuid.UUID, paused bool) error {
+	req := serverAPISessionsPauseReq{
+		uuid:   uuid,
+		paused: paused,
+		res:    make(chan serverAPISessionsPauseRes),
+	}
+
+	select {
+	case s.chAPISessionsPause <- req:
+		res := <-req.res
+		return res.err
+
+	case <-s.ctx.Done():
+		return fmt.Errorf("terminated")
+	}
+}
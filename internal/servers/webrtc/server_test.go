@@ -16,6 +16,7 @@ import (
 	"github.com/bluenviron/mediamtx/internal/conf"
 	"github.com/bluenviron/mediamtx/internal/defs"
 	"github.com/bluenviron/mediamtx/internal/externalcmd"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/protocols/webrtc"
 	"github.com/bluenviron/mediamtx/internal/protocols/whip"
 	"github.com/bluenviron/mediamtx/internal/stream"
@@ -36,6 +37,7 @@ func checkClose(t *testing.T, closeFunc func() error) {
 }
 
 type dummyPath struct {
+	conf          *conf.Path
 	stream        *stream.Stream
 	streamCreated chan struct{}
 }
@@ -45,6 +47,9 @@ func (p *dummyPath) Name() string {
 }
 
 func (p *dummyPath) SafeConf() *conf.Path {
+	if p.conf != nil {
+		return p.conf
+	}
 	return &conf.Path{}
 }
 
@@ -57,7 +62,8 @@ func (p *dummyPath) StartPublisher(req defs.PathStartPublisherReq) (*stream.Stre
 	p.stream, err = stream.New(
 		1460,
 		req.Desc,
-		true,
+		req.GenerateRTPPackets,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	if err != nil {
@@ -368,6 +374,151 @@ func TestServerPublish(t *testing.T) {
 	aw.Stop()
 }
 
+func TestServerPublishDataChannel(t *testing.T) {
+	path := &dummyPath{
+		streamCreated: make(chan struct{}),
+	}
+
+	pathManager := &dummyPathManager{
+		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
+			return &conf.Path{}, nil
+		},
+		addPublisher: func(_ defs.PathAddPublisherReq) (defs.Path, error) {
+			return path, nil
+		},
+	}
+
+	s := &Server{
+		Address:               "127.0.0.1:8886",
+		ReadTimeout:           conf.StringDuration(10 * time.Second),
+		WriteQueueSize:        512,
+		LocalUDPAddress:       "127.0.0.1:8887",
+		LocalTCPAddress:       "127.0.0.1:8887",
+		IPsFromInterfaces:     true,
+		IPsFromInterfacesList: []string{},
+		AdditionalHosts:       []string{},
+		ICEServers:            []conf.WebRTCICEServer{},
+		HandshakeTimeout:      conf.StringDuration(10 * time.Second),
+		TrackGatherTimeout:    conf.StringDuration(2 * time.Second),
+		PathManager:           pathManager,
+		Parent:                test.NilLogger,
+	}
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	settingEngine := pwebrtc.SettingEngine{}
+	settingEngine.SetICEUDPRandom(true)
+	settingEngine.SetNetworkTypes([]pwebrtc.NetworkType{pwebrtc.NetworkTypeUDP4})
+
+	mediaEngine := &pwebrtc.MediaEngine{}
+	err = mediaEngine.RegisterDefaultCodecs()
+	require.NoError(t, err)
+
+	pc, err := pwebrtc.NewAPI(pwebrtc.WithSettingEngine(settingEngine), pwebrtc.WithMediaEngine(mediaEngine)).
+		NewPeerConnection(pwebrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close() //nolint:errcheck
+
+	track, err := pwebrtc.NewTrackLocalStaticRTP(pwebrtc.RTPCodecCapability{
+		MimeType:    pwebrtc.MimeTypeH264,
+		ClockRate:   90000,
+		SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+	}, "video", "mediamtx")
+	require.NoError(t, err)
+
+	_, err = pc.AddTrack(track)
+	require.NoError(t, err)
+
+	dc, err := pc.CreateDataChannel("telemetry", nil)
+	require.NoError(t, err)
+
+	dcOpen := make(chan struct{})
+	dc.OnOpen(func() {
+		close(dcOpen)
+	})
+
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+
+	gatherComplete := pwebrtc.GatheringCompletePromise(pc)
+
+	err = pc.SetLocalDescription(offer)
+	require.NoError(t, err)
+
+	<-gatherComplete
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	res, err := hc.Post("http://localhost:8886/teststream/whip", "application/sdp",
+		bytes.NewReader([]byte(pc.LocalDescription().SDP)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	answer, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	err = pc.SetRemoteDescription(pwebrtc.SessionDescription{
+		Type: pwebrtc.SDPTypeAnswer,
+		SDP:  string(answer),
+	})
+	require.NoError(t, err)
+
+	<-dcOpen
+
+	err = track.WriteRTP(&rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{1},
+	})
+	require.NoError(t, err)
+
+	<-path.streamCreated
+
+	require.Equal(t, 2, len(path.stream.Desc().Medias))
+	require.Equal(t, description.MediaTypeApplication, path.stream.Desc().Medias[1].Type)
+
+	aw := asyncwriter.New(512, test.NilLogger)
+
+	recv := make(chan []byte, 1)
+
+	path.stream.AddReader(aw,
+		path.stream.Desc().Medias[1],
+		path.stream.Desc().Medias[1].Formats[0],
+		func(u unit.Unit) error {
+			select {
+			case recv <- u.GetRTPPackets()[0].Payload:
+			default:
+			}
+			return nil
+		})
+
+	aw.Start()
+	defer aw.Stop()
+
+	for {
+		err = dc.SendText("position")
+		require.NoError(t, err)
+
+		select {
+		case payload := <-recv:
+			require.Equal(t, []byte("position"), payload)
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 func TestServerRead(t *testing.T) {
 	for _, ca := range []struct {
 		name          string
@@ -525,6 +676,7 @@ func TestServerRead(t *testing.T) {
 				1460,
 				desc,
 				reflect.TypeOf(ca.unit) != reflect.TypeOf(&unit.Generic{}),
+				formatprocessor.Options{},
 				test.NilLogger,
 			)
 			require.NoError(t, err)
@@ -633,6 +785,118 @@ func TestServerRead(t *testing.T) {
 	}
 }
 
+func TestServerReadPassthrough(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
+
+	str, err := stream.New(
+		1460,
+		desc,
+		false,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+
+	path := &dummyPath{
+		conf:   &conf.Path{WebRTCPassthrough: true},
+		stream: str,
+	}
+
+	pathManager := &dummyPathManager{
+		findPathConf: func(_ defs.PathFindPathConfReq) (*conf.Path, error) {
+			return &conf.Path{}, nil
+		},
+		addReader: func(_ defs.PathAddReaderReq) (defs.Path, *stream.Stream, error) {
+			return path, str, nil
+		},
+	}
+
+	s := &Server{
+		Address:               "127.0.0.1:8886",
+		ReadTimeout:           conf.StringDuration(10 * time.Second),
+		WriteQueueSize:        512,
+		LocalUDPAddress:       "127.0.0.1:8887",
+		LocalTCPAddress:       "127.0.0.1:8887",
+		IPsFromInterfaces:     true,
+		IPsFromInterfacesList: []string{},
+		AdditionalHosts:       []string{},
+		ICEServers:            []conf.WebRTCICEServer{},
+		HandshakeTimeout:      conf.StringDuration(10 * time.Second),
+		TrackGatherTimeout:    conf.StringDuration(2 * time.Second),
+		PathManager:           pathManager,
+		Parent:                test.NilLogger,
+	}
+	err = s.Initialize()
+	require.NoError(t, err)
+	defer s.Close()
+
+	u, err := url.Parse("http://localhost:8886/teststream/whep")
+	require.NoError(t, err)
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	hc := &http.Client{Transport: tr}
+
+	wc := &whip.Client{
+		HTTPClient: hc,
+		URL:        u,
+		Log:        test.NilLogger,
+	}
+
+	writerDone := make(chan struct{})
+	defer func() { <-writerDone }()
+
+	writerTerminate := make(chan struct{})
+	defer close(writerTerminate)
+
+	go func() {
+		defer close(writerDone)
+		seqNum := uint16(1123)
+		for {
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-writerTerminate:
+				return
+			}
+
+			// a NALU split into two FU-A packets, that would be merged by re-encoding
+			for _, payload := range [][]byte{{0x7c, 0x81, 0xaa}, {0x7c, 0x41, 0xbb}} {
+				str.WriteRTPPacket(desc.Medias[0], desc.Medias[0].Formats[0], &rtp.Packet{
+					Header: rtp.Header{
+						Version:        2,
+						Marker:         payload[1] == 0x41,
+						PayloadType:    96,
+						SequenceNumber: seqNum,
+						Timestamp:      45343,
+						SSRC:           563423,
+					},
+					Payload: payload,
+				}, time.Time{}, 0)
+				seqNum++
+			}
+		}
+	}()
+
+	tracks, err := wc.Read(context.Background())
+	require.NoError(t, err)
+	defer checkClose(t, wc.Close)
+
+	done := make(chan struct{})
+
+	tracks[0].OnPacketRTP = func(pkt *rtp.Packet) {
+		select {
+		case <-done:
+		default:
+			require.Equal(t, []byte{0x7c, 0x81, 0xaa}, pkt.Payload)
+			close(done)
+		}
+	}
+
+	wc.StartReading()
+
+	<-done
+}
+
 func TestServerReadAuthorizationBearerJWT(t *testing.T) {
 	desc := &description.Session{Medias: []*description.Media{test.MediaH264}}
 
@@ -640,6 +904,7 @@ func TestServerReadAuthorizationBearerJWT(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -717,6 +982,7 @@ func TestServerReadAuthorizationUserPass(t *testing.T) {
 		1460,
 		desc,
 		true,
+		formatprocessor.Options{},
 		test.NilLogger,
 	)
 	require.NoError(t, err)
@@ -54,6 +54,9 @@ type session struct {
 	secret    uuid.UUID
 	mutex     sync.RWMutex
 	pc        *webrtc.PeerConnection
+	stream    *stream.Stream
+	writer    *asyncwriter.Writer
+	paused    bool
 
 	chNew           chan webRTCNewSessionReq
 	chAddCandidates chan webRTCAddSessionCandidatesReq
@@ -130,14 +133,16 @@ func (s *session) runPublish() (int, error) {
 	path, err := s.pathManager.AddPublisher(defs.PathAddPublisherReq{
 		Author: s,
 		AccessRequest: defs.PathAccessRequest{
-			Name:    s.req.pathName,
-			Query:   s.req.query,
-			Publish: true,
-			IP:      net.ParseIP(ip),
-			User:    s.req.user,
-			Pass:    s.req.pass,
-			Proto:   auth.ProtocolWebRTC,
-			ID:      &s.uuid,
+			Name:      s.req.pathName,
+			Query:     s.req.query,
+			Publish:   true,
+			IP:        net.ParseIP(ip),
+			LocalIP:   s.req.localIP,
+			User:      s.req.user,
+			Pass:      s.req.pass,
+			Proto:     auth.ProtocolWebRTC,
+			ID:        &s.uuid,
+			UserAgent: s.req.userAgent,
 		},
 	})
 	if err != nil {
@@ -160,16 +165,21 @@ func (s *session) runPublish() (int, error) {
 	}
 
 	pc := &webrtc.PeerConnection{
-		ICEServers:            iceServers,
-		HandshakeTimeout:      s.parent.HandshakeTimeout,
-		TrackGatherTimeout:    s.parent.TrackGatherTimeout,
-		IPsFromInterfaces:     s.ipsFromInterfaces,
-		IPsFromInterfacesList: s.ipsFromInterfacesList,
-		AdditionalHosts:       s.additionalHosts,
-		ICEUDPMux:             s.iceUDPMux,
-		ICETCPMux:             s.iceTCPMux,
-		Publish:               false,
-		Log:                   s,
+		ICEServers:                   iceServers,
+		HandshakeTimeout:             s.parent.HandshakeTimeout,
+		TrackGatherTimeout:           s.parent.TrackGatherTimeout,
+		IPsFromInterfaces:            s.ipsFromInterfaces,
+		IPsFromInterfacesList:        s.ipsFromInterfacesList,
+		IPsFromInterfacesExcludeList: s.parent.IPsFromInterfacesExcludeList,
+		AdditionalHosts:              s.additionalHosts,
+		ICEAddressFamilies:           s.parent.ICEAddressFamilies,
+		ICECandidateTypes:            s.parent.ICECandidateTypes,
+		ICEUDPMux:                    s.iceUDPMux,
+		ICETCPMux:                    s.iceTCPMux,
+		ICEUDPPortRange:              s.parent.UDPPortRange,
+		DTLSCertificate:              s.parent.dtlsCertificate,
+		Publish:                      false,
+		Log:                          s,
 	}
 	err = pc.Start()
 	if err != nil {
@@ -251,13 +261,15 @@ func (s *session) runRead() (int, error) {
 	path, stream, err := s.pathManager.AddReader(defs.PathAddReaderReq{
 		Author: s,
 		AccessRequest: defs.PathAccessRequest{
-			Name:  s.req.pathName,
-			Query: s.req.query,
-			IP:    net.ParseIP(ip),
-			User:  s.req.user,
-			Pass:  s.req.pass,
-			Proto: auth.ProtocolWebRTC,
-			ID:    &s.uuid,
+			Name:      s.req.pathName,
+			Query:     s.req.query,
+			IP:        net.ParseIP(ip),
+			LocalIP:   s.req.localIP,
+			User:      s.req.user,
+			Pass:      s.req.pass,
+			Proto:     auth.ProtocolWebRTC,
+			ID:        &s.uuid,
+			UserAgent: s.req.userAgent,
 		},
 	})
 	if err != nil {
@@ -273,6 +285,16 @@ func (s *session) runRead() (int, error) {
 			return http.StatusNotFound, err
 		}
 
+		var terr3 defs.PathMaxWHEPReadersError
+		if errors.As(err, &terr3) {
+			return http.StatusServiceUnavailable, err
+		}
+
+		var terr4 defs.PathDrainingError
+		if errors.As(err, &terr4) {
+			return http.StatusServiceUnavailable, err
+		}
+
 		return http.StatusBadRequest, err
 	}
 
@@ -287,23 +309,33 @@ func (s *session) runRead() (int, error) {
 	defer stream.RemoveReader(writer)
 
 	pc := &webrtc.PeerConnection{
-		ICEServers:            iceServers,
-		HandshakeTimeout:      s.parent.HandshakeTimeout,
-		TrackGatherTimeout:    s.parent.TrackGatherTimeout,
-		IPsFromInterfaces:     s.ipsFromInterfaces,
-		IPsFromInterfacesList: s.ipsFromInterfacesList,
-		AdditionalHosts:       s.additionalHosts,
-		ICEUDPMux:             s.iceUDPMux,
-		ICETCPMux:             s.iceTCPMux,
-		Publish:               true,
-		Log:                   s,
-	}
-
-	err = webrtc.FromStream(stream, writer, pc, s)
+		ICEServers:                   iceServers,
+		HandshakeTimeout:             s.parent.HandshakeTimeout,
+		TrackGatherTimeout:           s.parent.TrackGatherTimeout,
+		IPsFromInterfaces:            s.ipsFromInterfaces,
+		IPsFromInterfacesList:        s.ipsFromInterfacesList,
+		IPsFromInterfacesExcludeList: s.parent.IPsFromInterfacesExcludeList,
+		AdditionalHosts:              s.additionalHosts,
+		ICEAddressFamilies:           s.parent.ICEAddressFamilies,
+		ICECandidateTypes:            s.parent.ICECandidateTypes,
+		ICEUDPMux:                    s.iceUDPMux,
+		ICETCPMux:                    s.iceTCPMux,
+		ICEUDPPortRange:              s.parent.UDPPortRange,
+		DTLSCertificate:              s.parent.dtlsCertificate,
+		Publish:                      true,
+		Log:                          s,
+	}
+
+	err = webrtc.FromStream(stream, writer, pc, path.SafeConf().WebRTCPassthrough, s)
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
 
+	s.mutex.Lock()
+	s.stream = stream
+	s.writer = writer
+	s.mutex.Unlock()
+
 	err = pc.Start()
 	if err != nil {
 		return http.StatusBadRequest, err
@@ -415,11 +447,64 @@ func (s *session) APIReaderDescribe() defs.APIPathSourceOrReader {
 	}
 }
 
+// Drain implements defs.DrainableReader.
+func (s *session) Drain() {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.pc != nil {
+		err := s.pc.SendGoodbye()
+		if err != nil {
+			s.Log(logger.Warn, "unable to send goodbye: %v", err)
+		}
+	}
+}
+
 // APISourceDescribe implements source.
 func (s *session) APISourceDescribe() defs.APIPathSourceOrReader {
 	return s.APIReaderDescribe()
 }
 
+// BytesReceived implements bandwidth.Counter.
+func (s *session) BytesReceived() uint64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.pc == nil {
+		return 0
+	}
+	return s.pc.BytesReceived()
+}
+
+// BytesSent implements bandwidth.Counter.
+func (s *session) BytesSent() uint64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.pc == nil {
+		return 0
+	}
+	return s.pc.BytesSent()
+}
+
+func (s *session) setPaused(paused bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.writer == nil {
+		return ErrSessionNotReading
+	}
+
+	if paused {
+		s.stream.PauseReader(s.writer)
+	} else {
+		s.stream.ResumeReader(s.writer)
+	}
+
+	s.paused = paused
+	return nil
+}
+
 func (s *session) apiItem() *defs.APIWebRTCSession {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -453,6 +538,7 @@ func (s *session) apiItem() *defs.APIWebRTCSession {
 		}(),
 		Path:          s.req.pathName,
 		Query:         s.req.query,
+		Paused:        s.paused,
 		BytesReceived: bytesReceived,
 		BytesSent:     bytesSent,
 	}
@@ -0,0 +1,299 @@
+// Package sourcecheck contains a tool that checks whether a source can be read.
+package sourcecheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	hlssource "github.com/bluenviron/mediamtx/internal/staticsources/hls"
+	rtmpsource "github.com/bluenviron/mediamtx/internal/staticsources/rtmp"
+	rtspsource "github.com/bluenviron/mediamtx/internal/staticsources/rtsp"
+	srtsource "github.com/bluenviron/mediamtx/internal/staticsources/srt"
+	udpsource "github.com/bluenviron/mediamtx/internal/staticsources/udp"
+	webrtcsource "github.com/bluenviron/mediamtx/internal/staticsources/webrtc"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type checkerReadyReq struct {
+	desc               *description.Session
+	generateRTPPackets bool
+	res                chan *stream.Stream
+}
+
+// Checker connects to a source, prints discovered tracks,
+// reads the source for a while and prints statistics.
+type Checker struct {
+	Conf              *conf.Conf
+	Source            string
+	Duration          time.Duration
+	Out               io.Writer
+	Parent            logger.Writer
+	UDPMaxPayloadSize int
+
+	tracks  []*trackStats
+	writer  *asyncwriter.Writer
+	chReady chan checkerReadyReq
+}
+
+// Log implements logger.Writer.
+func (c *Checker) Log(level logger.Level, format string, args ...interface{}) {
+	c.Parent.Log(level, "[source] "+format, args...)
+}
+
+func (c *Checker) newSource() (defs.StaticSource, error) {
+	switch {
+	case strings.HasPrefix(c.Source, "rtsp://") ||
+		strings.HasPrefix(c.Source, "rtsps://"):
+		return &rtspsource.Source{
+			ReadTimeout:    c.Conf.ReadTimeout,
+			WriteTimeout:   c.Conf.WriteTimeout,
+			WriteQueueSize: c.Conf.WriteQueueSize,
+			Parent:         c,
+		}, nil
+
+	case strings.HasPrefix(c.Source, "rtmp://") ||
+		strings.HasPrefix(c.Source, "rtmps://"):
+		return &rtmpsource.Source{
+			ReadTimeout:  c.Conf.ReadTimeout,
+			WriteTimeout: c.Conf.WriteTimeout,
+			Parent:       c,
+		}, nil
+
+	case strings.HasPrefix(c.Source, "http://") ||
+		strings.HasPrefix(c.Source, "https://"):
+		return &hlssource.Source{
+			ReadTimeout: c.Conf.ReadTimeout,
+			Parent:      c,
+		}, nil
+
+	case strings.HasPrefix(c.Source, "udp://"):
+		return &udpsource.Source{
+			ReadTimeout: c.Conf.ReadTimeout,
+			Parent:      c,
+		}, nil
+
+	case strings.HasPrefix(c.Source, "srt://"):
+		return &srtsource.Source{
+			ReadTimeout: c.Conf.ReadTimeout,
+			Parent:      c,
+		}, nil
+
+	case strings.HasPrefix(c.Source, "whep://") ||
+		strings.HasPrefix(c.Source, "wheps://"):
+		return &webrtcsource.Source{
+			ReadTimeout: c.Conf.ReadTimeout,
+			Parent:      c,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported source: '%s'", c.Source)
+	}
+}
+
+// Run runs the check.
+func (c *Checker) Run() error {
+	c.chReady = make(chan checkerReadyReq)
+
+	source, err := c.newSource()
+	if err != nil {
+		return err
+	}
+
+	pconf := c.Conf.PathDefaults.Clone()
+	pconf.Source = c.Source
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	runErr := make(chan error)
+
+	go func() {
+		runErr <- source.Run(defs.StaticSourceRunParams{
+			Context:        ctx,
+			ResolvedSource: c.Source,
+			Conf:           pconf,
+		})
+	}()
+
+	var strm *stream.Stream
+
+	select {
+	case req := <-c.chReady:
+		strm, err = c.onReady(req.desc, req.generateRTPPackets)
+		req.res <- strm
+		if err != nil {
+			ctxCancel()
+			<-runErr
+			return err
+		}
+
+	case err = <-runErr:
+		return err
+	}
+
+	c.writer.Start()
+	start := time.Now()
+
+	select {
+	case <-time.After(c.Duration):
+		ctxCancel()
+		<-runErr
+
+	case err = <-runErr:
+	}
+
+	elapsed := time.Since(start)
+	c.writer.Stop()
+	strm.Close()
+
+	c.printStats(strm, elapsed)
+
+	return err
+}
+
+func (c *Checker) onReady(desc *description.Session, generateRTPPackets bool) (*stream.Stream, error) {
+	strm, err := stream.New(
+		c.UDPMaxPayloadSize,
+		desc,
+		generateRTPPackets,
+		formatprocessor.Options{},
+		c,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.writer = asyncwriter.New(c.Conf.WriteQueueSize, c)
+
+	fmt.Fprintf(c.Out, "source is ready, %d %s found:\n",
+		len(desc.Medias),
+		func() string {
+			if len(desc.Medias) == 1 {
+				return "media"
+			}
+			return "medias"
+		}())
+
+	n := 0
+	for i, media := range desc.Medias {
+		for _, forma := range media.Formats {
+			fmt.Fprintf(c.Out, "  track %d: media %d, %s, %s, clock rate %d\n",
+				n, i, media.Type, forma.Codec(), forma.ClockRate())
+
+			ts := &trackStats{
+				n:     n,
+				forma: forma,
+			}
+			c.tracks = append(c.tracks, ts)
+
+			strm.AddReader(c.writer, media, forma, func(u unit.Unit) error {
+				ts.add(u, time.Now())
+				return nil
+			})
+
+			n++
+		}
+	}
+
+	return strm, nil
+}
+
+func (c *Checker) printStats(strm *stream.Stream, elapsed time.Duration) {
+	fmt.Fprintf(c.Out, "statistics over %v:\n", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(c.Out, "  received: %d bytes, %.1f kbit/s\n",
+		strm.BytesReceived(), float64(strm.BytesReceived())*8/1000/elapsed.Seconds())
+
+	for _, ts := range c.tracks {
+		ts.print(c.Out, elapsed)
+	}
+}
+
+// SetReady implements defs.StaticSourceParent.
+func (c *Checker) SetReady(req defs.PathSourceStaticSetReadyReq) defs.PathSourceStaticSetReadyRes {
+	res := make(chan *stream.Stream)
+	c.chReady <- checkerReadyReq{
+		desc:               req.Desc,
+		generateRTPPackets: req.GenerateRTPPackets,
+		res:                res,
+	}
+
+	strm := <-res
+	if strm == nil {
+		return defs.PathSourceStaticSetReadyRes{Err: fmt.Errorf("terminated")}
+	}
+
+	return defs.PathSourceStaticSetReadyRes{Stream: strm}
+}
+
+// SetNotReady implements defs.StaticSourceParent.
+func (c *Checker) SetNotReady(_ defs.PathSourceStaticSetNotReadyReq) {
+}
+
+type trackStats struct {
+	n     int
+	forma format.Format
+
+	units       uint64
+	bytes       uint64
+	firstRecv   time.Time
+	lastRecv    time.Time
+	firstPTS    time.Duration
+	maxInterval time.Duration
+	maxJitter   time.Duration
+}
+
+func (s *trackStats) add(u unit.Unit, now time.Time) {
+	for _, pkt := range u.GetRTPPackets() {
+		s.bytes += uint64(len(pkt.Payload))
+	}
+
+	if s.units == 0 {
+		s.firstRecv = now
+		s.firstPTS = u.GetPTS()
+	} else {
+		if interval := now.Sub(s.lastRecv); interval > s.maxInterval {
+			s.maxInterval = interval
+		}
+
+		// difference between the time elapsed according to the source
+		// and the time elapsed according to the local clock
+		jitter := now.Sub(s.firstRecv) - (u.GetPTS() - s.firstPTS)
+		if jitter < 0 {
+			jitter = -jitter
+		}
+		if jitter > s.maxJitter {
+			s.maxJitter = jitter
+		}
+	}
+
+	s.lastRecv = now
+	s.units++
+}
+
+func (s *trackStats) print(w io.Writer, elapsed time.Duration) {
+	if s.units == 0 {
+		fmt.Fprintf(w, "  track %d (%s): no data received\n", s.n, s.forma.Codec())
+		return
+	}
+
+	fmt.Fprintf(w, "  track %d (%s): %d units, %.2f units/s, %.1f kbit/s, max interval %v, max jitter %v\n",
+		s.n,
+		s.forma.Codec(),
+		s.units,
+		float64(s.units)/elapsed.Seconds(),
+		float64(s.bytes)*8/1000/elapsed.Seconds(),
+		s.maxInterval.Round(time.Millisecond),
+		s.maxJitter.Round(time.Millisecond))
+}
@@ -0,0 +1,117 @@
+package sourcecheck
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/test"
+)
+
+type testServer struct {
+	onDescribe func(*gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error)
+	onSetup    func(*gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error)
+	onPlay     func(*gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error)
+}
+
+func (sh *testServer) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx,
+) (*base.Response, *gortsplib.ServerStream, error) {
+	return sh.onDescribe(ctx)
+}
+
+func (sh *testServer) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return sh.onSetup(ctx)
+}
+
+func (sh *testServer) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return sh.onPlay(ctx)
+}
+
+func TestChecker(t *testing.T) {
+	var stream *gortsplib.ServerStream
+
+	media0 := test.UniqueMediaH264()
+
+	s := gortsplib.Server{
+		Handler: &testServer{
+			onDescribe: func(_ *gortsplib.ServerHandlerOnDescribeCtx,
+			) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(_ *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+				go func() {
+					for i := 0; i < 5; i++ {
+						time.Sleep(100 * time.Millisecond)
+						err2 := stream.WritePacketRTP(media0, &rtp.Packet{
+							Header: rtp.Header{
+								Version:        0x02,
+								PayloadType:    96,
+								SequenceNumber: 57899 + uint16(i),
+								Timestamp:      345234345 + uint32(i)*9000,
+								SSRC:           978651231,
+								Marker:         true,
+							},
+							Payload: []byte{5, 1, 2, 3, 4},
+						})
+						require.NoError(t, err2)
+					}
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress: "127.0.0.1:8555",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = gortsplib.NewServerStream(&s, &description.Session{Medias: []*description.Media{media0}})
+	defer stream.Close()
+
+	cnf, _, err := conf.Load("", nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	c := &Checker{
+		Conf:              cnf,
+		Source:            "rtsp://127.0.0.1:8555/teststream",
+		Duration:          1 * time.Second,
+		Out:               &buf,
+		Parent:            test.NilLogger,
+		UDPMaxPayloadSize: cnf.UDPMaxPayloadSize,
+	}
+	err = c.Run()
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "source is ready, 1 media found:\n"+
+		"  track 0: media 0, video, H264, clock rate 90000\n")
+	require.Contains(t, out, "  track 0 (H264): 5 units, ")
+}
+
+func TestCheckerUnsupportedSource(t *testing.T) {
+	c := &Checker{
+		Source: "unsupported://localhost",
+	}
+	err := c.Run()
+	require.EqualError(t, err, "unsupported source: 'unsupported://localhost'")
+}
@@ -0,0 +1,54 @@
+// Package cluster contains the cluster static source.
+package cluster
+
+import (
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	rtspsource "github.com/bluenviron/mediamtx/internal/staticsources/rtsp"
+)
+
+type sourceLocator interface {
+	Locate(name string) (string, error)
+}
+
+// Source is a static source that reads a stream from the instance that is publishing it.
+type Source struct {
+	ReadTimeout    conf.StringDuration
+	WriteTimeout   conf.StringDuration
+	WriteQueueSize int
+	PathName       string
+	Locator        sourceLocator
+	Parent         defs.StaticSourceParent
+}
+
+// Log implements logger.Writer.
+func (s *Source) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[cluster source] "+format, args...)
+}
+
+// Run implements StaticSource.
+func (s *Source) Run(params defs.StaticSourceRunParams) error {
+	u, err := s.Locator.Locate(s.PathName)
+	if err != nil {
+		return err
+	}
+
+	params.ResolvedSource = u
+
+	rs := &rtspsource.Source{
+		ReadTimeout:    s.ReadTimeout,
+		WriteTimeout:   s.WriteTimeout,
+		WriteQueueSize: s.WriteQueueSize,
+		Parent:         s.Parent,
+	}
+	return rs.Run(params)
+}
+
+// APISourceDescribe implements StaticSource.
+func (*Source) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "clusterSource",
+		ID:   "",
+	}
+}
@@ -0,0 +1,82 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// scale draws src into a region of dst, using nearest-neighbor interpolation.
+func scale(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	dw := rect.Dx()
+	dh := rect.Dy()
+
+	if dw <= 0 || dh <= 0 || sb.Empty() {
+		return
+	}
+
+	ycbcr, isYCbCr := src.(*image.YCbCr)
+
+	for y := 0; y < dh; y++ {
+		sy := sb.Min.Y + y*sb.Dy()/dh
+
+		for x := 0; x < dw; x++ {
+			sx := sb.Min.X + x*sb.Dx()/dw
+
+			var r, g, b uint8
+
+			// JPEG frames are decoded into YCbCr images. Avoid the generic At(),
+			// that allocates a color for every pixel.
+			if isYCbCr {
+				c := ycbcr.YCbCrAt(sx, sy)
+				r, g, b = color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+			} else {
+				r16, g16, b16, _ := src.At(sx, sy).RGBA()
+				r, g, b = uint8(r16>>8), uint8(g16>>8), uint8(b16>>8)
+			}
+
+			off := dst.PixOffset(rect.Min.X+x, rect.Min.Y+y)
+			dst.Pix[off] = r
+			dst.Pix[off+1] = g
+			dst.Pix[off+2] = b
+			dst.Pix[off+3] = 255
+		}
+	}
+}
+
+// compose combines frames into a single image.
+// The first frame is mandatory and defines the size of the output.
+// Other frames can be nil, when they are not available yet.
+func compose(layout string, frames []image.Image) *image.RGBA {
+	size := frames[0].Bounds().Size()
+	dst := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+
+	switch layout {
+	case "pip":
+		draw.Draw(dst, dst.Bounds(), frames[0], frames[0].Bounds().Min, draw.Src)
+
+		if frames[1] != nil {
+			margin := size.Y / 40
+			w := size.X / 4
+			h := size.Y / 4
+			scale(dst, image.Rect(size.X-margin-w, size.Y-margin-h, size.X-margin, size.Y-margin), frames[1])
+		}
+
+	default: // grid
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+		w := size.X / 2
+		h := size.Y / 2
+
+		for i, frame := range frames {
+			if frame != nil {
+				x := (i % 2) * w
+				y := (i / 2) * h
+				scale(dst, image.Rect(x, y, x+w, y+h), frame)
+			}
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,55 @@
+package composite
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func uniformImage(w int, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestComposePIP(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	out := compose("pip", []image.Image{
+		uniformImage(320, 240, red),
+		uniformImage(640, 480, blue),
+	})
+
+	require.Equal(t, image.Rect(0, 0, 320, 240), out.Bounds())
+	require.Equal(t, red, out.At(10, 10))
+	require.Equal(t, blue, out.At(320-6-10, 240-6-10))
+	require.Equal(t, red, out.At(319, 239))
+
+	out = compose("pip", []image.Image{
+		uniformImage(320, 240, red),
+		nil,
+	})
+	require.Equal(t, red, out.At(320-6-10, 240-6-10))
+}
+
+func TestComposeGrid(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	green := color.RGBA{0, 255, 0, 255}
+
+	out := compose("grid", []image.Image{
+		uniformImage(320, 240, red),
+		uniformImage(100, 100, blue),
+		uniformImage(50, 50, green),
+	})
+
+	require.Equal(t, image.Rect(0, 0, 320, 240), out.Bounds())
+	require.Equal(t, red, out.At(10, 10))
+	require.Equal(t, blue, out.At(170, 10))
+	require.Equal(t, green, out.At(10, 130))
+	require.Equal(t, color.RGBA{0, 0, 0, 255}, out.At(170, 130))
+}
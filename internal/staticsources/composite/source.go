@@ -0,0 +1,177 @@
+// Package composite contains the composite static source.
+package composite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	jpegQuality = 90
+)
+
+type sourcePathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+// inputReader is the reader of the input paths.
+type inputReader struct {
+	ctxCancel func()
+}
+
+// Close implements defs.Reader.
+func (r *inputReader) Close() {
+	r.ctxCancel()
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (*inputReader) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "compositeSource",
+		ID:   "",
+	}
+}
+
+// Source is a static source that combines M-JPEG tracks of other paths
+// into a single M-JPEG track.
+type Source struct {
+	WriteQueueSize int
+	PathManager    sourcePathManager
+	Parent         defs.StaticSourceParent
+}
+
+// Log implements logger.Writer.
+func (s *Source) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[composite source] "+format, args...)
+}
+
+// Run implements StaticSource.
+func (s *Source) Run(params defs.StaticSourceRunParams) error {
+	ctx, ctxCancel := context.WithCancel(params.Context)
+	defer ctxCancel()
+
+	r := &inputReader{ctxCancel: ctxCancel}
+
+	layout := params.Conf.SourceCompositeLayout
+	frames := make([]image.Image, len(params.Conf.SourceComposite))
+
+	medi := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{&format.MJPEG{}},
+	}
+
+	var outStream *stream.Stream
+
+	// a single writer is shared between inputs, therefore callbacks are never called concurrently.
+	writer := asyncwriter.New(s.WriteQueueSize, s)
+
+	for i, pathName := range params.Conf.SourceComposite {
+		path, inStream, err := s.PathManager.AddReader(defs.PathAddReaderReq{
+			Author: r,
+			AccessRequest: defs.PathAccessRequest{
+				Name:     pathName,
+				SkipAuth: true,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to read '%s': %w", pathName, err)
+		}
+
+		defer path.RemoveReader(defs.PathRemoveReaderReq{Author: r})
+		defer inStream.RemoveReader(writer)
+
+		var forma *format.MJPEG
+		inMedia := inStream.Desc().FindFormat(&forma)
+		if inMedia == nil {
+			return fmt.Errorf("path '%s' doesn't have a M-JPEG track", pathName)
+		}
+
+		ci := i
+		cPathName := pathName
+
+		inStream.AddReader(writer, inMedia, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.MJPEG)
+			if tunit.Frame == nil {
+				return nil
+			}
+
+			img, err2 := jpeg.Decode(bytes.NewReader(tunit.Frame))
+			if err2 != nil {
+				return fmt.Errorf("unable to decode frame of '%s': %w", cPathName, err2)
+			}
+
+			frames[ci] = img
+
+			// the first input drives the frame rate of the output
+			if ci != 0 {
+				return nil
+			}
+
+			var buf bytes.Buffer
+			err2 = jpeg.Encode(&buf, compose(layout, frames), &jpeg.Options{Quality: jpegQuality})
+			if err2 != nil {
+				return err2
+			}
+
+			outStream.WriteUnit(medi, medi.Formats[0], &unit.MJPEG{
+				Base: unit.Base{
+					PTS: tunit.PTS,
+					NTP: tunit.NTP,
+				},
+				Frame: buf.Bytes(),
+			})
+
+			return nil
+		})
+	}
+
+	res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
+		Desc:               &description.Session{Medias: []*description.Media{medi}},
+		GenerateRTPPackets: true,
+	})
+	if res.Err != nil {
+		return res.Err
+	}
+
+	defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+
+	outStream = res.Stream
+
+	writer.Start()
+	defer writer.Stop()
+
+	for {
+		select {
+		case err := <-writer.Error():
+			return err
+
+		case <-params.ReloadConf:
+
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("an input path is not available anymore")
+		}
+	}
+}
+
+// APISourceDescribe implements StaticSource.
+func (*Source) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "compositeSource",
+		ID:   "",
+	}
+}
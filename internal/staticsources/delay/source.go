@@ -0,0 +1,296 @@
+// Package delay contains the delay static source.
+package delay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// cloneDescription clones a description in order to avoid sharing
+// formats between the original stream and the delayed stream.
+func cloneDescription(desc *description.Session) (*description.Session, error) {
+	byts, err := desc.Marshal(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var sd sdp.SessionDescription
+	err = sd.Unmarshal(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out description.Session
+	err = out.Unmarshal(&sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+type sourcePathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+type entry struct {
+	media    *description.Media
+	format   format.Format
+	pkts     []*rtp.Packet
+	ntp      time.Time
+	pts      time.Duration
+	deadline time.Time
+	size     uint64
+}
+
+// buffer is a FIFO of entries with a maximum size.
+type buffer struct {
+	maxSize uint64
+
+	mutex   sync.Mutex
+	entries []*entry
+	size    uint64
+	notify  chan struct{}
+}
+
+func (b *buffer) initialize() {
+	b.notify = make(chan struct{}, 1)
+}
+
+func (b *buffer) push(e *entry) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if (b.size + e.size) > b.maxSize {
+		return fmt.Errorf("delay buffer is full, increase 'sourceDelayBufferSize'")
+	}
+
+	b.entries = append(b.entries, e)
+	b.size += e.size
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// popExpired removes and returns entries whose deadline is expired.
+func (b *buffer) popExpired(now time.Time) []*entry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	n := 0
+	for n < len(b.entries) && !b.entries[n].deadline.After(now) {
+		b.size -= b.entries[n].size
+		n++
+	}
+
+	ret := b.entries[:n]
+	b.entries = b.entries[n:]
+	return ret
+}
+
+// nextDeadline returns the deadline of the oldest entry, or zero if the buffer is empty.
+func (b *buffer) nextDeadline() time.Time {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.entries) == 0 {
+		return time.Time{}
+	}
+	return b.entries[0].deadline
+}
+
+// inputReader is the reader of the input path.
+type inputReader struct {
+	ctxCancel func()
+}
+
+// Close implements defs.Reader.
+func (r *inputReader) Close() {
+	r.ctxCancel()
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (*inputReader) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "delaySource",
+		ID:   "",
+	}
+}
+
+// Source is a static source that mirrors another path with a fixed delay.
+type Source struct {
+	WriteQueueSize int
+	PathManager    sourcePathManager
+	Parent         defs.StaticSourceParent
+}
+
+// Log implements logger.Writer.
+func (s *Source) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[delay source] "+format, args...)
+}
+
+// Run implements StaticSource.
+func (s *Source) Run(params defs.StaticSourceRunParams) error {
+	ctx, ctxCancel := context.WithCancel(params.Context)
+	defer ctxCancel()
+
+	r := &inputReader{ctxCancel: ctxCancel}
+
+	delay := time.Duration(params.Conf.SourceDelay)
+
+	path, inStream, err := s.PathManager.AddReader(defs.PathAddReaderReq{
+		Author: r,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     params.Conf.SourceDelayPath,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to read '%s': %w", params.Conf.SourceDelayPath, err)
+	}
+
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: r})
+
+	desc, err := cloneDescription(inStream.Desc())
+	if err != nil {
+		return err
+	}
+
+	buf := &buffer{
+		maxSize: uint64(params.Conf.SourceDelayBufferSize),
+	}
+	buf.initialize()
+
+	writer := asyncwriter.New(s.WriteQueueSize, s)
+	defer inStream.RemoveReader(writer)
+
+	for i, inMedia := range inStream.Desc().Medias {
+		for j, inFormat := range inMedia.Formats {
+			outMedia := desc.Medias[i]
+			outFormat := outMedia.Formats[j]
+
+			inStream.AddReader(writer, inMedia, inFormat, func(u unit.Unit) error {
+				pkts := u.GetRTPPackets()
+				if len(pkts) == 0 {
+					return nil
+				}
+
+				e := &entry{
+					media:    outMedia,
+					format:   outFormat,
+					pkts:     make([]*rtp.Packet, len(pkts)),
+					ntp:      u.GetNTP(),
+					pts:      u.GetPTS(),
+					deadline: time.Now().Add(delay),
+				}
+
+				for k, pkt := range pkts {
+					// shallow copy, in order not to share the packet with the original stream
+					e.pkts[k] = &rtp.Packet{
+						Header:  pkt.Header,
+						Payload: pkt.Payload,
+					}
+					e.size += uint64(pkt.MarshalSize())
+				}
+
+				return buf.push(e)
+			})
+		}
+	}
+
+	res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
+		Desc:               desc,
+		GenerateRTPPackets: false,
+	})
+	if res.Err != nil {
+		return res.Err
+	}
+
+	defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+
+	s.Log(logger.Info, "mirroring '%s' with a delay of %v", params.Conf.SourceDelayPath, delay)
+
+	writer.Start()
+	defer writer.Stop()
+
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	timerArmed := false
+
+	armTimer := func() {
+		if next := buf.nextDeadline(); !next.IsZero() {
+			timer.Reset(time.Until(next))
+			timerArmed = true
+		}
+	}
+
+	// when the input path is closed, the buffer is emptied before exiting
+	done := ctx.Done()
+	inputClosed := false
+
+	for {
+		select {
+		case err = <-writer.Error():
+			return err
+
+		case <-buf.notify:
+			if !timerArmed {
+				armTimer()
+			}
+
+		case <-timer.C:
+			timerArmed = false
+
+			for _, e := range buf.popExpired(time.Now()) {
+				for _, pkt := range e.pkts {
+					res.Stream.WriteRTPPacket(e.media, e.format, pkt, e.ntp.Add(delay), e.pts)
+				}
+			}
+
+			armTimer()
+
+			if !timerArmed && inputClosed {
+				return fmt.Errorf("input path is not available anymore")
+			}
+
+		case <-params.ReloadConf:
+
+		case <-done:
+			if params.Context.Err() != nil {
+				return nil
+			}
+
+			done = nil
+			inputClosed = true
+
+			if !timerArmed {
+				return fmt.Errorf("input path is not available anymore")
+			}
+		}
+	}
+}
+
+// APISourceDescribe implements StaticSource.
+func (*Source) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "delaySource",
+		ID:   "",
+	}
+}
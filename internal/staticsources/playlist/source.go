@@ -0,0 +1,386 @@
+// Package playlist contains the playlist static source.
+package playlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/sdp"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/pion/rtp"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// gap between the last frame of an item and the first frame of the next one
+	itemGap = 40 * time.Millisecond
+
+	// pause after all items failed
+	retryPause = 2 * time.Second
+)
+
+func multiplyAndDivide(v, m, d time.Duration) time.Duration {
+	secs := v / d
+	dec := v % d
+	return (secs*m + dec*m/d)
+}
+
+// cloneDescription clones a description in order to avoid sharing
+// formats between the original stream and the playlist stream.
+func cloneDescription(desc *description.Session) (*description.Session, error) {
+	byts, err := desc.Marshal(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var sd sdp.SessionDescription
+	err = sd.Unmarshal(byts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out description.Session
+	err = out.Unmarshal(&sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func isRandomAccess(u unit.Unit) bool {
+	switch tunit := u.(type) {
+	case *unit.H264:
+		return tunit.AU != nil && h264.IDRPresent(tunit.AU)
+
+	case *unit.H265:
+		return tunit.AU != nil && h265.IsRandomAccess(tunit.AU)
+
+	default:
+		return true
+	}
+}
+
+type sourcePathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+type outputFormat struct {
+	media  *description.Media
+	format format.Format
+
+	sequenceNumber uint16
+
+	// state of the current item
+	started bool
+	tsDelta uint32
+}
+
+// itemReader is the reader of a playlist item.
+type itemReader struct {
+	ctx       context.Context
+	ctxCancel func()
+}
+
+// Close implements defs.Reader.
+func (r *itemReader) Close() {
+	r.ctxCancel()
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (*itemReader) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "playlistSource",
+		ID:   "",
+	}
+}
+
+// Source is a static source that reads other paths in sequence,
+// rewriting timestamps in order to generate a continuous stream.
+type Source struct {
+	WriteQueueSize int
+	PathManager    sourcePathManager
+	Parent         defs.StaticSourceParent
+
+	stream  *stream.Stream
+	formats []*outputFormat
+
+	// state of the current item
+	itemStarted  bool
+	itemStartPTS time.Duration
+	ptsOffset    time.Duration
+	lastPTS      time.Duration
+}
+
+// Log implements logger.Writer.
+func (s *Source) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[playlist source] "+format, args...)
+}
+
+// Run implements StaticSource.
+func (s *Source) Run(params defs.StaticSourceRunParams) error {
+	s.stream = nil
+	s.formats = nil
+	s.ptsOffset = 0
+	s.lastPTS = 0
+
+	defer func() {
+		if s.stream != nil {
+			s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+		}
+	}()
+
+	items := params.Conf.SourcePlaylist
+	failures := 0
+
+	for i := 0; ; i++ {
+		item := items[i%len(items)]
+
+		err := s.runItem(params, item)
+
+		select {
+		case <-params.Context.Done():
+			return nil
+		default:
+		}
+
+		if err != nil {
+			s.Log(logger.Warn, "unable to read '%s': %v", item.Path, err)
+			failures++
+		} else {
+			failures = 0
+		}
+
+		if failures >= len(items) {
+			failures = 0
+
+			select {
+			case <-time.After(retryPause):
+			case newConf := <-params.ReloadConf:
+				items = newConf.SourcePlaylist
+			case <-params.Context.Done():
+				return nil
+			}
+		}
+
+		// apply configuration changes between items
+		select {
+		case newConf := <-params.ReloadConf:
+			items = newConf.SourcePlaylist
+		default:
+		}
+	}
+}
+
+func (s *Source) runItem(params defs.StaticSourceRunParams, item conf.PlaylistItem) error {
+	ctx, ctxCancel := context.WithCancel(params.Context)
+	defer ctxCancel()
+
+	r := &itemReader{
+		ctx:       ctx,
+		ctxCancel: ctxCancel,
+	}
+
+	path, strm, err := s.PathManager.AddReader(defs.PathAddReaderReq{
+		Author: r,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     item.Path,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: r})
+
+	if s.stream == nil {
+		err = s.initializeStream(strm.Desc())
+		if err != nil {
+			return err
+		}
+	}
+
+	writer := asyncwriter.New(s.WriteQueueSize, s)
+	defer strm.RemoveReader(writer)
+
+	s.itemStarted = false
+	for _, of := range s.formats {
+		of.started = false
+	}
+
+	n := 0
+
+	for _, of := range s.formats {
+		medi, forma := findFormat(strm.Desc(), of, s.formats)
+		if forma == nil {
+			continue
+		}
+
+		cof := of
+		strm.AddReader(writer, medi, forma, func(u unit.Unit) error {
+			s.writeUnit(cof, u)
+			return nil
+		})
+		n++
+	}
+
+	if n == 0 {
+		return fmt.Errorf("path doesn't have any track compatible with the playlist")
+	}
+
+	s.Log(logger.Info, "reading '%s'", item.Path)
+
+	writer.Start()
+
+	var durationC <-chan time.Time
+	if item.Duration != 0 {
+		t := time.NewTimer(time.Duration(item.Duration))
+		defer t.Stop()
+		durationC = t.C
+	}
+
+	select {
+	case <-durationC:
+	case <-ctx.Done():
+	case err = <-writer.Error():
+	}
+
+	writer.Stop()
+
+	if s.itemStarted {
+		s.ptsOffset = s.lastPTS + itemGap
+	}
+
+	return err
+}
+
+func (s *Source) initializeStream(desc *description.Session) error {
+	desc, err := cloneDescription(desc)
+	if err != nil {
+		return err
+	}
+
+	res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
+		Desc:               desc,
+		GenerateRTPPackets: false,
+	})
+	if res.Err != nil {
+		return res.Err
+	}
+
+	s.stream = res.Stream
+
+	for _, medi := range desc.Medias {
+		for _, forma := range medi.Formats {
+			s.formats = append(s.formats, &outputFormat{
+				media:  medi,
+				format: forma,
+			})
+		}
+	}
+
+	return nil
+}
+
+// findFormat finds the format of an item that corresponds to an output format.
+func findFormat(
+	desc *description.Session,
+	of *outputFormat,
+	formats []*outputFormat,
+) (*description.Media, format.Format) {
+	// number of preceding output formats with the same codec
+	skip := 0
+	for _, of2 := range formats {
+		if of2 == of {
+			break
+		}
+		if of2.media.Type == of.media.Type && of2.format.Codec() == of.format.Codec() {
+			skip++
+		}
+	}
+
+	for _, medi := range desc.Medias {
+		if medi.Type != of.media.Type {
+			continue
+		}
+
+		for _, forma := range medi.Formats {
+			if forma.Codec() == of.format.Codec() &&
+				forma.ClockRate() == of.format.ClockRate() {
+				if skip == 0 {
+					return medi, forma
+				}
+				skip--
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *Source) writeUnit(of *outputFormat, u unit.Unit) {
+	pkts := u.GetRTPPackets()
+	if len(pkts) == 0 {
+		return
+	}
+
+	if !of.started {
+		// start video from a random access point
+		if !isRandomAccess(u) {
+			return
+		}
+
+		if !s.itemStarted {
+			s.itemStarted = true
+			s.itemStartPTS = u.GetPTS()
+		}
+	}
+
+	if u.GetPTS() < s.itemStartPTS {
+		return
+	}
+
+	pts := s.ptsOffset + u.GetPTS() - s.itemStartPTS
+	if pts > s.lastPTS {
+		s.lastPTS = pts
+	}
+
+	if !of.started {
+		of.started = true
+		ts := uint32(multiplyAndDivide(pts, time.Duration(of.format.ClockRate()), time.Second))
+		of.tsDelta = ts - pkts[0].Timestamp
+	}
+
+	for _, pkt := range pkts {
+		// shallow copy, in order not to modify the packet of the original stream
+		pkt2 := &rtp.Packet{
+			Header:  pkt.Header,
+			Payload: pkt.Payload,
+		}
+		pkt2.PayloadType = of.format.PayloadType()
+		pkt2.SequenceNumber = of.sequenceNumber
+		pkt2.Timestamp = pkt.Timestamp + of.tsDelta
+		of.sequenceNumber++
+
+		s.stream.WriteRTPPacket(of.media, of.format, pkt2, u.GetNTP(), pts)
+	}
+}
+
+// APISourceDescribe implements StaticSource.
+func (*Source) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "playlistSource",
+		ID:   "",
+	}
+}
@@ -0,0 +1,227 @@
+// Package processor contains the processor static source.
+package processor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	mcmpegts "github.com/bluenviron/mediacommon/pkg/formats/mpegts"
+	"github.com/kballard/go-shellquote"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/defs"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/protocols/mpegts"
+	"github.com/bluenviron/mediamtx/internal/stream"
+)
+
+const (
+	// same size as MPEG-TS over SRT and UDP
+	writeBufferSize = 1316
+)
+
+type sourcePathManager interface {
+	AddReader(req defs.PathAddReaderReq) (defs.Path, *stream.Stream, error)
+}
+
+// inputReader is the reader of the input path.
+type inputReader struct {
+	ctxCancel func()
+}
+
+// Close implements defs.Reader.
+func (r *inputReader) Close() {
+	r.ctxCancel()
+}
+
+// APIReaderDescribe implements defs.Reader.
+func (*inputReader) APIReaderDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "processorSource",
+		ID:   "",
+	}
+}
+
+// Source is a static source that sends another path to an external processor
+// and publishes the processed stream.
+// The input path is written to the standard input of the processor in MPEG-TS format,
+// while the processed stream is read from its standard output in MPEG-TS format.
+type Source struct {
+	ReadTimeout    conf.StringDuration
+	WriteTimeout   conf.StringDuration
+	WriteQueueSize int
+	PathManager    sourcePathManager
+	Parent         defs.StaticSourceParent
+}
+
+// Log implements logger.Writer.
+func (s *Source) Log(level logger.Level, format string, args ...interface{}) {
+	s.Parent.Log(level, "[processor source] "+format, args...)
+}
+
+// Run implements StaticSource.
+func (s *Source) Run(params defs.StaticSourceRunParams) error {
+	ctx, ctxCancel := context.WithCancel(params.Context)
+	defer ctxCancel()
+
+	r := &inputReader{ctxCancel: ctxCancel}
+
+	path, inStream, err := s.PathManager.AddReader(defs.PathAddReaderReq{
+		Author: r,
+		AccessRequest: defs.PathAccessRequest{
+			Name:     params.Conf.SourceProcessorPath,
+			SkipAuth: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to read '%s': %w", params.Conf.SourceProcessorPath, err)
+	}
+
+	defer path.RemoveReader(defs.PathRemoveReaderReq{Author: r})
+
+	cmdParts, err := shellquote.Split(os.ExpandEnv(params.Conf.SourceProcessorCommand))
+	if err != nil {
+		return err
+	}
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer stdinW.Close()
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		return err
+	}
+	defer stdoutR.Close()
+
+	cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Start()
+
+	// pipe ends used by the processor are not needed anymore
+	stdinR.Close()
+	stdoutW.Close()
+
+	if err != nil {
+		return err
+	}
+
+	var cmdErr error
+	cmdDone := make(chan struct{})
+	go func() {
+		cmdErr = cmd.Wait()
+		close(cmdDone)
+	}()
+
+	defer func() {
+		cmd.Process.Kill() //nolint:errcheck
+		<-cmdDone
+	}()
+
+	writer := asyncwriter.New(s.WriteQueueSize, s)
+	defer inStream.RemoveReader(writer)
+
+	bw := bufio.NewWriterSize(stdinW, writeBufferSize)
+
+	err = mpegts.FromStream(inStream, writer, bw, stdinW, time.Duration(s.WriteTimeout), s)
+	if err != nil {
+		return err
+	}
+
+	s.Log(logger.Info, "processing '%s'", params.Conf.SourceProcessorPath)
+
+	writer.Start()
+	defer writer.Stop()
+
+	readerErr := make(chan error)
+	go func() {
+		readerErr <- s.runReader(stdoutR)
+	}()
+
+	select {
+	case err = <-writer.Error():
+		stdoutR.Close()
+		<-readerErr
+		return err
+
+	case err = <-readerErr:
+		return err
+
+	case <-cmdDone:
+		stdoutR.Close()
+		<-readerErr
+		if cmdErr != nil {
+			return fmt.Errorf("processor exited: %w", cmdErr)
+		}
+		return fmt.Errorf("processor exited")
+
+	case <-ctx.Done():
+		stdoutR.Close()
+		<-readerErr
+		if params.Context.Err() != nil {
+			return fmt.Errorf("terminated")
+		}
+		return fmt.Errorf("input path is not available anymore")
+	}
+}
+
+func (s *Source) runReader(stdout *os.File) error {
+	stdout.SetReadDeadline(time.Now().Add(time.Duration(s.ReadTimeout)))
+	r, err := mcmpegts.NewReader(bufio.NewReader(stdout))
+	if err != nil {
+		return err
+	}
+
+	decodeErrLogger := logger.NewLimitedLogger(s)
+
+	r.OnDecodeError(func(err error) {
+		decodeErrLogger.Log(logger.Warn, err.Error())
+	})
+
+	var stream *stream.Stream
+
+	medias, err := mpegts.ToStream(r, &stream, s)
+	if err != nil {
+		return err
+	}
+
+	res := s.Parent.SetReady(defs.PathSourceStaticSetReadyReq{
+		Desc:               &description.Session{Medias: medias},
+		GenerateRTPPackets: true,
+	})
+	if res.Err != nil {
+		return res.Err
+	}
+
+	defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
+
+	stream = res.Stream
+
+	for {
+		stdout.SetReadDeadline(time.Now().Add(time.Duration(s.ReadTimeout)))
+		err := r.Read()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// APISourceDescribe implements StaticSource.
+func (*Source) APISourceDescribe() defs.APIPathSourceOrReader {
+	return defs.APIPathSourceOrReader{
+		Type: "processorSource",
+		ID:   "",
+	}
+}
@@ -0,0 +1,92 @@
+package rtsp
+
+import (
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/bluenviron/mediamtx/internal/conf"
+	"github.com/bluenviron/mediamtx/internal/logger"
+)
+
+// portRangeListener allocates RTP/RTCP port pairs inside a range.
+// The client asks for a random RTP port, immediately followed by the RTCP port;
+// the RTP request is replaced with a port pair inside the range,
+// and the RTCP listener is kept until it is requested.
+type portRangeListener struct {
+	portRange conf.UDPPortRange
+	log       logger.Writer
+
+	mutex       sync.Mutex
+	pendingRTCP net.PacketConn
+}
+
+func (l *portRangeListener) listenPacket(network string, address string) (net.PacketConn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil || host != "" {
+		return net.ListenPacket(network, address)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return net.ListenPacket(network, address)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// RTCP port
+	if port%2 != 0 {
+		if l.pendingRTCP != nil {
+			pc := l.pendingRTCP
+			l.pendingRTCP = nil
+			return pc, nil
+		}
+		return net.ListenPacket(network, address)
+	}
+
+	// RTP port
+	if l.pendingRTCP != nil {
+		l.pendingRTCP.Close()
+		l.pendingRTCP = nil
+	}
+
+	pairCount := l.portRange.Size() / 2
+	start := rand.Intn(pairCount) //nolint:gosec
+
+	for i := 0; i < pairCount; i++ {
+		rtpPort := l.portRange.Min + ((start+i)%pairCount)*2
+
+		rtpConn, err := net.ListenPacket(network, net.JoinHostPort("", strconv.FormatInt(int64(rtpPort), 10)))
+		if err != nil {
+			continue
+		}
+
+		rtcpConn, err := net.ListenPacket(network, net.JoinHostPort("", strconv.FormatInt(int64(rtpPort+1), 10)))
+		if err != nil {
+			rtpConn.Close()
+			continue
+		}
+
+		l.pendingRTCP = rtcpConn
+		return rtpConn, nil
+	}
+
+	// the client retries indefinitely in case of errors, therefore
+	// fall back to the port chosen by the client.
+	l.log.Log(logger.Warn, "all ports in range %d-%d are in use, using port %d",
+		l.portRange.Min, l.portRange.Max, port)
+
+	return net.ListenPacket(network, address)
+}
+
+func (l *portRangeListener) close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.pendingRTCP != nil {
+		l.pendingRTCP.Close()
+		l.pendingRTCP = nil
+	}
+}
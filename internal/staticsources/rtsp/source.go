@@ -80,12 +80,14 @@ func (s *Source) Run(params defs.StaticSourceRunParams) error {
 	decodeErrLogger := logger.NewLimitedLogger(s)
 
 	c := &gortsplib.Client{
-		Transport:      params.Conf.RTSPTransport.Transport,
-		TLSConfig:      tls.ConfigForFingerprint(params.Conf.SourceFingerprint),
-		ReadTimeout:    time.Duration(s.ReadTimeout),
-		WriteTimeout:   time.Duration(s.WriteTimeout),
-		WriteQueueSize: s.WriteQueueSize,
-		AnyPortEnable:  params.Conf.RTSPAnyPort,
+		Transport:             params.Conf.RTSPTransport.Transport,
+		TLSConfig:             tls.ConfigForFingerprint(params.Conf.SourceFingerprint),
+		ReadTimeout:           time.Duration(s.ReadTimeout),
+		WriteTimeout:          time.Duration(s.WriteTimeout),
+		WriteQueueSize:        s.WriteQueueSize,
+		AnyPortEnable:         params.Conf.RTSPAnyPort,
+		UserAgent:             params.Conf.RTSPUserAgent,
+		InitialUDPReadTimeout: time.Duration(params.Conf.RTSPUDPReadTimeout),
 		OnRequest: func(req *base.Request) {
 			s.Log(logger.Debug, "[c->s] %v", req)
 		},
@@ -103,6 +105,15 @@ func (s *Source) Run(params defs.StaticSourceRunParams) error {
 		},
 	}
 
+	if params.Conf.RTSPUDPPortRange.Size() != 0 {
+		l := &portRangeListener{
+			portRange: params.Conf.RTSPUDPPortRange,
+			log:       s,
+		}
+		defer l.close()
+		c.ListenPacket = l.listenPacket
+	}
+
 	u, err := base.ParseURL(params.ResolvedSource)
 	if err != nil {
 		return err
@@ -137,6 +148,8 @@ func (s *Source) Run(params defs.StaticSourceRunParams) error {
 
 			defer s.Parent.SetNotReady(defs.PathSourceStaticSetNotReadyReq{})
 
+			useRTCP := params.Conf.ClockSource == conf.ClockSourceRTCP
+
 			for _, medi := range desc.Medias {
 				for _, forma := range medi.Formats {
 					cmedi := medi
@@ -148,7 +161,14 @@ func (s *Source) Run(params defs.StaticSourceRunParams) error {
 							return
 						}
 
-						res.Stream.WriteRTPPacket(cmedi, cforma, pkt, time.Now(), pts)
+						ntp := time.Now()
+						if useRTCP {
+							if v, ok := c.PacketNTP(cmedi, pkt); ok {
+								ntp = v
+							}
+						}
+
+						res.Stream.WriteRTPPacket(cmedi, cforma, pkt, ntp, pts)
 					})
 				}
 			}
@@ -353,3 +353,79 @@ func TestRTSPSourceRange(t *testing.T) {
 		})
 	}
 }
+
+func TestRTSPSourceUDPPortRange(t *testing.T) {
+	var stream *gortsplib.ServerStream
+
+	media0 := test.UniqueMediaH264()
+
+	s := gortsplib.Server{
+		Handler: &testServer{
+			onDescribe: func(_ *gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onSetup: func(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+				require.Contains(t, ctx.Request.Header["Transport"][0], "client_port=30000-30001")
+
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					err2 := stream.WritePacketRTP(media0, &rtp.Packet{
+						Header: rtp.Header{
+							Version:        0x02,
+							PayloadType:    96,
+							SequenceNumber: 57899,
+							Timestamp:      345234345,
+							SSRC:           978651231,
+							Marker:         true,
+						},
+						Payload: []byte{5, 1, 2, 3, 4},
+					})
+					require.NoError(t, err2)
+				}()
+
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, stream, nil
+			},
+			onPlay: func(_ *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+				return &base.Response{
+					StatusCode: base.StatusOK,
+				}, nil
+			},
+		},
+		RTSPAddress:    "127.0.0.1:8555",
+		UDPRTPAddress:  "127.0.0.1:8002",
+		UDPRTCPAddress: "127.0.0.1:8003",
+	}
+
+	err := s.Start()
+	require.NoError(t, err)
+	defer s.Close()
+
+	stream = gortsplib.NewServerStream(&s, &description.Session{Medias: []*description.Media{media0}})
+	defer stream.Close()
+
+	var sp conf.RTSPTransport
+	sp.UnmarshalJSON([]byte(`"udp"`)) //nolint:errcheck
+
+	te := test.NewSourceTester(
+		func(p defs.StaticSourceParent) defs.StaticSource {
+			return &Source{
+				ReadTimeout:    conf.StringDuration(10 * time.Second),
+				WriteTimeout:   conf.StringDuration(10 * time.Second),
+				WriteQueueSize: 2048,
+				Parent:         p,
+			}
+		},
+		"rtsp://127.0.0.1:8555/teststream",
+		&conf.Path{
+			RTSPTransport:    sp,
+			RTSPUDPPortRange: conf.UDPPortRange{Min: 30000, Max: 30001},
+		},
+	)
+	defer te.Close()
+
+	<-te.Unit
+}
@@ -50,8 +50,9 @@ func (s *Source) Run(params defs.StaticSourceRunParams) error {
 			Timeout:   time.Duration(s.ReadTimeout),
 			Transport: tr,
 		},
-		URL: u,
-		Log: s,
+		URL:             u,
+		ICEUDPPortRange: params.Conf.WHEPUDPPortRange,
+		Log:             s,
 	}
 
 	_, err = client.Read(params.Context)
@@ -12,6 +12,7 @@ import (
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 	"github.com/bluenviron/mediamtx/internal/unit"
 )
@@ -19,6 +20,17 @@ import (
 // ReadFunc is the callback passed to AddReader().
 type ReadFunc func(unit.Unit) error
 
+// Packetizer converts units into RTP packets.
+// It is shared between all readers that use the same key,
+// in order to packetize every unit once, regardless of the number of readers.
+type Packetizer interface {
+	Packetize(unit.Unit) []*rtp.Packet
+}
+
+// PacketizedReadFunc is the callback passed to AddPacketizedReader().
+// Packets are shared between readers and must not be modified.
+type PacketizedReadFunc func(unit.Unit, []*rtp.Packet) error
+
 // Stream is a media stream.
 // It stores tracks, readers and allows to write data to readers.
 type Stream struct {
@@ -30,6 +42,20 @@ type Stream struct {
 	mutex         sync.RWMutex
 	rtspStream    *gortsplib.ServerStream
 	rtspsStream   *gortsplib.ServerStream
+
+	generateRTPPackets bool
+	maxAudioGap        time.Duration
+	audioGapFillers    []*audioGapFiller
+
+	mediaAliases     map[*description.Media]*description.Media
+	formatAliases    map[format.Format]format.Format
+	ptsMutex         sync.Mutex
+	ptsOffset        time.Duration
+	ptsOffsetPending bool
+	lastPTS          time.Duration
+	serverClock      bool
+	clockStartNTP    time.Time
+	clockStartPTS    time.Duration
 }
 
 // New allocates a Stream.
@@ -37,22 +63,48 @@ func New(
 	udpMaxPayloadSize int,
 	desc *description.Session,
 	generateRTPPackets bool,
+	processingOptions formatprocessor.Options,
 	decodeErrLogger logger.Writer,
 ) (*Stream, error) {
 	s := &Stream{
-		desc:          desc,
-		bytesReceived: new(uint64),
-		bytesSent:     new(uint64),
+		desc:               desc,
+		bytesReceived:      new(uint64),
+		bytesSent:          new(uint64),
+		generateRTPPackets: generateRTPPackets,
 	}
 
 	s.smedias = make(map[*description.Media]*streamMedia)
 
-	for _, media := range desc.Medias {
-		var err error
-		s.smedias[media], err = newStreamMedia(udpMaxPayloadSize, media, generateRTPPackets, decodeErrLogger)
+	outMedias := make([]*description.Media, len(desc.Medias))
+	converted := false
+
+	for i, media := range desc.Medias {
+		sm, outMedia, err := newStreamMedia(
+			udpMaxPayloadSize,
+			media,
+			generateRTPPackets,
+			processingOptions,
+			decodeErrLogger,
+		)
 		if err != nil {
 			return nil, err
 		}
+
+		s.smedias[outMedia] = sm
+		outMedias[i] = outMedia
+
+		if outMedia != media {
+			converted = true
+		}
+	}
+
+	// readers use a description that contains converted formats,
+	// while the publisher keeps writing with its own.
+	if converted {
+		outDesc := *desc
+		outDesc.Medias = outMedias
+		s.desc = &outDesc
+		s.setAliases(desc)
 	}
 
 	return s, nil
@@ -125,6 +177,49 @@ func (s *Stream) AddReader(r *asyncwriter.Writer, medi *description.Media, forma
 	sf.addReader(r, cb)
 }
 
+// AddInternalReader adds a reader that is part of the server itself.
+// Data read by internal readers is not counted as sent.
+func (s *Stream) AddInternalReader(r *asyncwriter.Writer, medi *description.Media, forma format.Format, cb ReadFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sm := s.smedias[medi]
+	sf := sm.formats[forma]
+	sf.addInternalReader(r, cb)
+}
+
+// AddPacketizedReader adds a reader that receives units together with RTP packets
+// generated by a Packetizer. The Packetizer is created with newPacketizer when no other reader
+// of the same format is using the same key, otherwise the existing one is reused.
+func (s *Stream) AddPacketizedReader(
+	r *asyncwriter.Writer,
+	medi *description.Media,
+	forma format.Format,
+	key string,
+	newPacketizer func() (Packetizer, error),
+	cb PacketizedReadFunc,
+) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sm := s.smedias[medi]
+	sf := sm.formats[forma]
+	return sf.addPacketizedReader(r, key, newPacketizer, cb)
+}
+
+// SSRCs returns the SSRCs of RTP packets of a media, that are sent as-is to RTSP readers.
+func (s *Stream) SSRCs(medi *description.Media) []uint32 {
+	var ret []uint32
+
+	for _, sf := range s.smedias[medi].formats {
+		if v := atomic.LoadUint64(&sf.ssrcPlusOne); v != 0 {
+			ret = append(ret, uint32(v-1))
+		}
+	}
+
+	return ret
+}
+
 // RemoveReader removes a reader.
 func (s *Stream) RemoveReader(r *asyncwriter.Writer) {
 	s.mutex.Lock()
@@ -137,6 +232,31 @@ func (s *Stream) RemoveReader(r *asyncwriter.Writer) {
 	}
 }
 
+// PauseReader stops delivering data to a reader, without removing it.
+func (s *Stream) PauseReader(r *asyncwriter.Writer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sm := range s.smedias {
+		for _, sf := range sm.formats {
+			sf.pauseReader(r)
+		}
+	}
+}
+
+// ResumeReader restarts delivering data to a paused reader.
+// H264 and H265 tracks are resumed from the next random access unit.
+func (s *Stream) ResumeReader(r *asyncwriter.Writer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sm := range s.smedias {
+		for forma, sf := range sm.formats {
+			sf.resumeReader(r, forma)
+		}
+	}
+}
+
 // FormatsForReader returns all formats that a reader is reading.
 func (s *Stream) FormatsForReader(r *asyncwriter.Writer) []format.Format {
 	s.mutex.Lock()
@@ -146,7 +266,7 @@ func (s *Stream) FormatsForReader(r *asyncwriter.Writer) []format.Format {
 
 	for _, sm := range s.smedias {
 		for forma, sf := range sm.formats {
-			if _, ok := sf.readers[r]; ok {
+			if sf.hasReader(r) {
 				formats = append(formats, forma)
 			}
 		}
@@ -157,13 +277,36 @@ func (s *Stream) FormatsForReader(r *asyncwriter.Writer) []format.Format {
 
 // WriteUnit writes a Unit.
 func (s *Stream) WriteUnit(medi *description.Media, forma format.Format, u unit.Unit) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	medi, forma = s.resolve(medi, forma)
 	sm := s.smedias[medi]
 	sf := sm.formats[forma]
 
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+	if b, ok := u.(interface {
+		SetPTS(time.Duration)
+		SetNTP(time.Time)
+	}); ok {
+		pts := s.shiftPTS(u.GetPTS())
+		b.SetPTS(pts)
+		b.SetNTP(s.clockNTP(u.GetNTP(), pts))
+	}
+
+	if g := sf.audioGapFiller; g != nil {
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+
+		if g.discard(u.GetPTS()) {
+			return
+		}
+	}
 
 	sf.writeUnit(s, medi, u)
+
+	if medi.Type == description.MediaTypeVideo && len(s.audioGapFillers) != 0 {
+		s.fillAudioGaps(u.GetPTS(), u.GetNTP())
+	}
 }
 
 // WriteRTPPacket writes a RTP packet.
@@ -174,11 +317,28 @@ func (s *Stream) WriteRTPPacket(
 	ntp time.Time,
 	pts time.Duration,
 ) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	medi, forma = s.resolve(medi, forma)
 	sm := s.smedias[medi]
 	sf := sm.formats[forma]
 
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+	pts = s.shiftPTS(pts)
+	ntp = s.clockNTP(ntp, pts)
+
+	if g := sf.audioGapFiller; g != nil {
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+
+		if g.discard(pts) {
+			return
+		}
+	}
 
 	sf.writeRTPPacket(s, medi, pkt, ntp, pts)
+
+	if medi.Type == description.MediaTypeVideo && len(s.audioGapFillers) != 0 {
+		s.fillAudioGaps(pts, ntp)
+	}
 }
@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/mpeg4audio"
+	"github.com/bluenviron/mediacommon/pkg/codecs/opus"
+
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+// silent AAC-LC frames, that do not depend on the sample rate.
+var (
+	mpeg4AudioSilenceMono   = []byte{0x00, 0xc8, 0x00, 0x80, 0x23, 0x80}
+	mpeg4AudioSilenceStereo = []byte{0x21, 0x00, 0x49, 0x90, 0x02, 0x19, 0x00, 0x23, 0x80}
+)
+
+// silent Opus frames (CELT, fullband, 20ms).
+var (
+	opusSilenceMono   = []byte{0xf8, 0xff, 0xfe}
+	opusSilenceStereo = []byte{0xfc, 0xff, 0xfe}
+)
+
+const opusSilenceDuration = 20 * time.Millisecond
+
+type audioGapFiller struct {
+	medi          *description.Media
+	sf            *streamFormat
+	frameDuration time.Duration
+	newUnit       func(pts time.Duration, ntp time.Time) unit.Unit
+
+	mutex   sync.Mutex
+	started bool
+	filling bool
+	end     time.Duration // PTS at which the last written audio unit ends
+}
+
+func newAudioGapFiller(medi *description.Media, forma format.Format, sf *streamFormat) *audioGapFiller {
+	switch forma := forma.(type) {
+	case *format.MPEG4Audio:
+		if forma.LATM || forma.Config == nil || forma.Config.Type != mpeg4audio.ObjectTypeAACLC {
+			return nil
+		}
+
+		var frame []byte
+		switch forma.Config.ChannelCount {
+		case 1:
+			frame = mpeg4AudioSilenceMono
+		case 2:
+			frame = mpeg4AudioSilenceStereo
+		default:
+			return nil
+		}
+
+		return &audioGapFiller{
+			medi: medi,
+			sf:   sf,
+			frameDuration: time.Duration(mpeg4audio.SamplesPerAccessUnit) * time.Second /
+				time.Duration(forma.Config.SampleRate),
+			newUnit: func(pts time.Duration, ntp time.Time) unit.Unit {
+				return &unit.MPEG4Audio{
+					Base: unit.Base{PTS: pts, NTP: ntp},
+					AUs:  [][]byte{frame},
+				}
+			},
+		}
+
+	case *format.Opus:
+		var frame []byte
+		switch forma.ChannelCount {
+		case 1:
+			frame = opusSilenceMono
+		case 2:
+			frame = opusSilenceStereo
+		default:
+			return nil
+		}
+
+		return &audioGapFiller{
+			medi:          medi,
+			sf:            sf,
+			frameDuration: opusSilenceDuration,
+			newUnit: func(pts time.Duration, ntp time.Time) unit.Unit {
+				return &unit.Opus{
+					Base:    unit.Base{PTS: pts, NTP: ntp},
+					Packets: [][]byte{frame},
+				}
+			},
+		}
+	}
+
+	return nil
+}
+
+// discard returns whether a unit of the publisher must be discarded,
+// since it overlaps with silence that has already been written.
+// It must be called with g.mutex locked.
+func (g *audioGapFiller) discard(pts time.Duration) bool {
+	if g.filling {
+		if pts < g.end {
+			return true
+		}
+		g.filling = false
+	}
+	return false
+}
+
+// onUnit is called when a unit of the publisher has been written.
+// It must be called with g.mutex locked.
+func (g *audioGapFiller) onUnit(u unit.Unit) {
+	var duration time.Duration
+
+	switch tu := u.(type) {
+	case *unit.MPEG4Audio:
+		duration = time.Duration(len(tu.AUs)) * g.frameDuration
+
+	case *unit.Opus:
+		for _, pkt := range tu.Packets {
+			duration += opus.PacketDuration(pkt)
+		}
+	}
+
+	if end := u.GetPTS() + duration; !g.started || end > g.end {
+		g.end = end
+	}
+	g.started = true
+}
+
+// FillAudioGaps makes the stream insert silence into AAC-LC and Opus tracks
+// when audio stops for more than maxGap while video continues,
+// in order to keep muxers that interleave audio and video working.
+// Audio received after a gap is discarded until it reaches the end of inserted silence.
+func (s *Stream) FillAudioGaps(maxGap time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.maxAudioGap = maxGap
+
+	for _, medi := range s.desc.Medias {
+		if medi.Type != description.MediaTypeAudio {
+			continue
+		}
+
+		for _, forma := range medi.Formats {
+			sf := s.smedias[medi].formats[forma]
+
+			if g := newAudioGapFiller(medi, forma, sf); g != nil {
+				sf.audioGapFiller = g
+				s.audioGapFillers = append(s.audioGapFillers, g)
+			}
+		}
+	}
+}
+
+// fillAudioGaps is called when a video unit is written.
+// It must be called with s.mutex locked.
+func (s *Stream) fillAudioGaps(pts time.Duration, ntp time.Time) {
+	for _, g := range s.audioGapFillers {
+		s.fillAudioGap(g, pts, ntp)
+	}
+}
+
+func (s *Stream) fillAudioGap(g *audioGapFiller, pts time.Duration, ntp time.Time) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if !g.started || (!g.filling && (pts-g.end) <= s.maxAudioGap) {
+		return
+	}
+
+	g.filling = true
+
+	for (g.end + g.frameDuration) <= pts {
+		u := g.newUnit(g.end, ntp.Add(g.end-pts))
+		g.end += g.frameDuration
+
+		// when the stream doesn't generate RTP packets,
+		// silence is sent only to readers that don't need them.
+		if s.generateRTPPackets {
+			g.sf.writeUnit(s, g.medi, u)
+		} else {
+			g.sf.writeUnitInner(s, g.medi, u)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+type nilLogger struct{}
+
+func (nilLogger) Log(logger.Level, string, ...interface{}) {
+}
+
+func TestFillAudioGaps(t *testing.T) {
+	audioMedia := &description.Media{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{&format.Opus{
+			PayloadTyp:   111,
+			ChannelCount: 1,
+		}},
+	}
+
+	desc := &description.Session{Medias: []*description.Media{
+		{
+			Type: description.MediaTypeVideo,
+			Formats: []format.Format{&format.H264{
+				PayloadTyp:        96,
+				PacketizationMode: 1,
+			}},
+		},
+		audioMedia,
+	}}
+
+	strm, err := New(1460, desc, true, formatprocessor.Options{}, nilLogger{})
+	require.NoError(t, err)
+	defer strm.Close()
+
+	strm.FillAudioGaps(500 * time.Millisecond)
+
+	received := make(chan time.Duration, 64)
+
+	w := asyncwriter.New(512, nilLogger{})
+	strm.AddReader(w, audioMedia, audioMedia.Formats[0], func(u unit.Unit) error {
+		received <- u.GetPTS()
+		return nil
+	})
+
+	writeAudio := func(pts time.Duration) {
+		strm.WriteUnit(audioMedia, audioMedia.Formats[0], &unit.Opus{
+			Base:    unit.Base{PTS: pts},
+			Packets: [][]byte{{0xf8, 1, 2}},
+		})
+	}
+
+	writeVideo := func(pts time.Duration) {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{PTS: pts},
+			AU:   [][]byte{{5, 1}},
+		})
+	}
+
+	writeAudio(0)
+	writeVideo(0)
+
+	// gap is below threshold
+	writeVideo(400 * time.Millisecond)
+
+	// gap is above threshold, silence is inserted until 600ms
+	writeVideo(600 * time.Millisecond)
+
+	// audio that overlaps with silence is discarded
+	writeAudio(500 * time.Millisecond)
+
+	// audio resumes
+	writeAudio(600 * time.Millisecond)
+
+	w.Start()
+	defer w.Stop()
+
+	// the first unit is the one written by the publisher
+	for i := 0; i < 30; i++ {
+		require.Equal(t, time.Duration(i)*20*time.Millisecond, <-received)
+	}
+	require.Equal(t, 600*time.Millisecond, <-received)
+
+	select {
+	case <-received:
+		t.Errorf("unexpected unit")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,32 @@
+package stream
+
+import (
+	"time"
+)
+
+// UseServerClock makes the stream compute NTP timestamps from the server clock,
+// sampled when the first timestamp is written, and from the elapsed PTS,
+// ignoring NTP timestamps provided by the publisher.
+func (s *Stream) UseServerClock() {
+	s.ptsMutex.Lock()
+	defer s.ptsMutex.Unlock()
+
+	s.serverClock = true
+}
+
+// clockNTP returns the NTP timestamp of a unit with the given (shifted) PTS.
+func (s *Stream) clockNTP(ntp time.Time, pts time.Duration) time.Time {
+	s.ptsMutex.Lock()
+	defer s.ptsMutex.Unlock()
+
+	if !s.serverClock {
+		return ntp
+	}
+
+	if s.clockStartNTP.IsZero() {
+		s.clockStartNTP = time.Now()
+		s.clockStartPTS = pts
+	}
+
+	return s.clockStartNTP.Add(pts - s.clockStartPTS)
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
 	"github.com/pion/rtp"
 
 	"github.com/bluenviron/mediamtx/internal/asyncwriter"
@@ -22,19 +24,41 @@ func unitSize(u unit.Unit) uint64 {
 	return n
 }
 
+type sharedPacketizer struct {
+	packetizer Packetizer
+	readers    map[*asyncwriter.Writer]PacketizedReadFunc
+}
+
 type streamFormat struct {
 	decodeErrLogger logger.Writer
 	proc            formatprocessor.Processor
 	readers         map[*asyncwriter.Writer]ReadFunc
+	internalReaders map[*asyncwriter.Writer]struct{}
+	packetizers     map[string]*sharedPacketizer
+	audioGapFiller  *audioGapFiller
+
+	// paused readers, mapped to whether they're still paused (true)
+	// or they're waiting for a random access unit in order to resume (false).
+	pausedReaders map[*asyncwriter.Writer]bool
+
+	// SSRC of the last RTP packet, plus one. Zero means that no packet has been written yet.
+	ssrcPlusOne uint64
 }
 
 func newStreamFormat(
 	udpMaxPayloadSize int,
 	forma format.Format,
 	generateRTPPackets bool,
+	processingOptions formatprocessor.Options,
 	decodeErrLogger logger.Writer,
 ) (*streamFormat, error) {
-	proc, err := formatprocessor.New(udpMaxPayloadSize, forma, generateRTPPackets)
+	proc, err := formatprocessor.New(
+		udpMaxPayloadSize,
+		forma,
+		generateRTPPackets,
+		processingOptions,
+		decodeErrLogger,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +67,9 @@ func newStreamFormat(
 		decodeErrLogger: decodeErrLogger,
 		proc:            proc,
 		readers:         make(map[*asyncwriter.Writer]ReadFunc),
+		internalReaders: make(map[*asyncwriter.Writer]struct{}),
+		packetizers:     make(map[string]*sharedPacketizer),
+		pausedReaders:   make(map[*asyncwriter.Writer]bool),
 	}
 
 	return sf, nil
@@ -52,8 +79,111 @@ func (sf *streamFormat) addReader(r *asyncwriter.Writer, cb ReadFunc) {
 	sf.readers[r] = cb
 }
 
+func (sf *streamFormat) addInternalReader(r *asyncwriter.Writer, cb ReadFunc) {
+	sf.readers[r] = cb
+	sf.internalReaders[r] = struct{}{}
+}
+
+func (sf *streamFormat) addPacketizedReader(
+	r *asyncwriter.Writer,
+	key string,
+	newPacketizer func() (Packetizer, error),
+	cb PacketizedReadFunc,
+) error {
+	sp, ok := sf.packetizers[key]
+	if !ok {
+		packetizer, err := newPacketizer()
+		if err != nil {
+			return err
+		}
+
+		sp = &sharedPacketizer{
+			packetizer: packetizer,
+			readers:    make(map[*asyncwriter.Writer]PacketizedReadFunc),
+		}
+		sf.packetizers[key] = sp
+	}
+
+	sp.readers[r] = cb
+	return nil
+}
+
 func (sf *streamFormat) removeReader(r *asyncwriter.Writer) {
 	delete(sf.readers, r)
+	delete(sf.internalReaders, r)
+	delete(sf.pausedReaders, r)
+
+	for key, sp := range sf.packetizers {
+		delete(sp.readers, r)
+
+		// packetizers are not needed anymore when all their readers are gone
+		if len(sp.readers) == 0 {
+			delete(sf.packetizers, key)
+		}
+	}
+}
+
+func (sf *streamFormat) hasReader(r *asyncwriter.Writer) bool {
+	if _, ok := sf.readers[r]; ok {
+		return true
+	}
+
+	for _, sp := range sf.packetizers {
+		if _, ok := sp.readers[r]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sf *streamFormat) pauseReader(r *asyncwriter.Writer) {
+	if sf.hasReader(r) {
+		sf.pausedReaders[r] = true
+	}
+}
+
+func (sf *streamFormat) resumeReader(r *asyncwriter.Writer, forma format.Format) {
+	if _, ok := sf.pausedReaders[r]; !ok {
+		return
+	}
+
+	switch forma.(type) {
+	case *format.H264, *format.H265:
+		// decoding can restart from a random access unit only
+		sf.pausedReaders[r] = false
+
+	default:
+		delete(sf.pausedReaders, r)
+	}
+}
+
+// isReaderPaused returns whether a unit must not be sent to a reader.
+// It must be called by the routine that writes to the format.
+func (sf *streamFormat) isReaderPaused(r *asyncwriter.Writer, u unit.Unit) bool {
+	paused, ok := sf.pausedReaders[r]
+	if !ok {
+		return false
+	}
+
+	if paused || !isRandomAccess(u) {
+		return true
+	}
+
+	delete(sf.pausedReaders, r)
+	return false
+}
+
+func isRandomAccess(u unit.Unit) bool {
+	switch tu := u.(type) {
+	case *unit.H264:
+		return tu.AU != nil && h264.IDRPresent(tu.AU)
+
+	case *unit.H265:
+		return tu.AU != nil && h265.IsRandomAccess(tu.AU)
+	}
+
+	return true
 }
 
 func (sf *streamFormat) writeUnit(s *Stream, medi *description.Media, u unit.Unit) {
@@ -73,7 +203,7 @@ func (sf *streamFormat) writeRTPPacket(
 	ntp time.Time,
 	pts time.Duration,
 ) {
-	hasNonRTSPReaders := len(sf.readers) > 0
+	hasNonRTSPReaders := len(sf.readers) > 0 || len(sf.packetizers) > 0
 
 	u, err := sf.proc.ProcessRTPPacket(pkt, ntp, pts, hasNonRTSPReaders)
 	if err != nil {
@@ -85,10 +215,18 @@ func (sf *streamFormat) writeRTPPacket(
 }
 
 func (sf *streamFormat) writeUnitInner(s *Stream, medi *description.Media, u unit.Unit) {
+	if sf.audioGapFiller != nil && !sf.audioGapFiller.filling {
+		sf.audioGapFiller.onUnit(u)
+	}
+
 	size := unitSize(u)
 
 	atomic.AddUint64(s.bytesReceived, size)
 
+	if pkts := u.GetRTPPackets(); len(pkts) != 0 {
+		atomic.StoreUint64(&sf.ssrcPlusOne, uint64(pkts[0].SSRC)+1)
+	}
+
 	if s.rtspStream != nil {
 		for _, pkt := range u.GetRTPPackets() {
 			s.rtspStream.WritePacketRTPWithNTP(medi, pkt, u.GetNTP()) //nolint:errcheck
@@ -102,10 +240,40 @@ func (sf *streamFormat) writeUnitInner(s *Stream, medi *description.Media, u uni
 	}
 
 	for writer, cb := range sf.readers {
+		if sf.isReaderPaused(writer, u) {
+			continue
+		}
+
 		ccb := cb
+
+		if _, ok := sf.internalReaders[writer]; ok {
+			writer.Push(func() error {
+				return ccb(u)
+			})
+			continue
+		}
+
 		writer.Push(func() error {
 			atomic.AddUint64(s.bytesSent, size)
 			return ccb(u)
 		})
 	}
+
+	// packetize the unit once and share packets with all readers.
+	// this is done here in order to process units in order.
+	for _, sp := range sf.packetizers {
+		pkts := sp.packetizer.Packetize(u)
+
+		for writer, cb := range sp.readers {
+			if sf.isReaderPaused(writer, u) {
+				continue
+			}
+
+			ccb := cb
+			writer.Push(func() error {
+				atomic.AddUint64(s.bytesSent, size)
+				return ccb(u, pkts)
+			})
+		}
+	}
 }
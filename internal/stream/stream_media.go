@@ -4,6 +4,7 @@ import (
 	"github.com/bluenviron/gortsplib/v4/pkg/description"
 	"github.com/bluenviron/gortsplib/v4/pkg/format"
 
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
 	"github.com/bluenviron/mediamtx/internal/logger"
 )
 
@@ -11,22 +12,45 @@ type streamMedia struct {
 	formats map[format.Format]*streamFormat
 }
 
+// newStreamMedia allocates a streamMedia.
+// It returns the media as seen by readers, that differs from the input one
+// when some formats are converted by their processors.
 func newStreamMedia(udpMaxPayloadSize int,
 	medi *description.Media,
 	generateRTPPackets bool,
+	processingOptions formatprocessor.Options,
 	decodeErrLogger logger.Writer,
-) (*streamMedia, error) {
+) (*streamMedia, *description.Media, error) {
 	sm := &streamMedia{
 		formats: make(map[format.Format]*streamFormat),
 	}
 
-	for _, forma := range medi.Formats {
-		var err error
-		sm.formats[forma], err = newStreamFormat(udpMaxPayloadSize, forma, generateRTPPackets, decodeErrLogger)
+	outMedi := medi
+
+	for i, forma := range medi.Formats {
+		sf, err := newStreamFormat(
+			udpMaxPayloadSize,
+			forma,
+			generateRTPPackets,
+			processingOptions,
+			decodeErrLogger,
+		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		if c, ok := sf.proc.(formatprocessor.FormatConverter); ok && c.OutputFormat() != forma {
+			if outMedi == medi {
+				m := *medi
+				m.Formats = append([]format.Format(nil), medi.Formats...)
+				outMedi = &m
+			}
+			forma = c.OutputFormat()
+			outMedi.Formats[i] = forma
 		}
+
+		sm.formats[forma] = sf
 	}
 
-	return sm, nil
+	return sm, outMedi, nil
 }
@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+)
+
+// gap between the last PTS written by the previous publisher
+// and the first PTS written by the resumed publisher.
+const resumePTSGap = 100 * time.Millisecond
+
+func checkResumeCompatibility(cur *description.Session, next *description.Session) error {
+	if len(cur.Medias) != len(next.Medias) {
+		return fmt.Errorf("media count changed from %d to %d", len(cur.Medias), len(next.Medias))
+	}
+
+	for i, medi := range cur.Medias {
+		nextMedi := next.Medias[i]
+
+		if medi.Type != nextMedi.Type || len(medi.Formats) != len(nextMedi.Formats) {
+			return fmt.Errorf("media %d changed", i+1)
+		}
+
+		for j, forma := range medi.Formats {
+			nextForma := nextMedi.Formats[j]
+
+			if forma.Codec() != nextForma.Codec() ||
+				forma.PayloadType() != nextForma.PayloadType() ||
+				forma.ClockRate() != nextForma.ClockRate() {
+				return fmt.Errorf("format of media %d changed from %s to %s", i+1, forma.Codec(), nextForma.Codec())
+			}
+		}
+	}
+
+	return nil
+}
+
+// Resume allows a new publisher, with a compatible description,
+// to continue writing to the stream in place of the previous one.
+// Readers are kept and timestamps are shifted after the last written ones.
+func (s *Stream) Resume(desc *description.Session) error {
+	err := checkResumeCompatibility(s.desc, desc)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.setAliases(desc)
+
+	s.ptsMutex.Lock()
+	s.ptsOffsetPending = true
+	s.ptsMutex.Unlock()
+
+	return nil
+}
+
+// setAliases maps medias and formats of a publisher to the ones of the stream.
+func (s *Stream) setAliases(desc *description.Session) {
+	s.mediaAliases = make(map[*description.Media]*description.Media)
+	s.formatAliases = make(map[format.Format]format.Format)
+
+	for i, medi := range desc.Medias {
+		s.mediaAliases[medi] = s.desc.Medias[i]
+
+		for j, forma := range medi.Formats {
+			s.formatAliases[forma] = s.desc.Medias[i].Formats[j]
+		}
+	}
+}
+
+// resolve returns the media and format of the stream that correspond
+// to the media and format of the current publisher.
+// It must be called with s.mutex locked.
+func (s *Stream) resolve(medi *description.Media, forma format.Format) (*description.Media, format.Format) {
+	if s.mediaAliases != nil {
+		if v, ok := s.mediaAliases[medi]; ok {
+			return v, s.formatAliases[forma]
+		}
+	}
+	return medi, forma
+}
+
+// shiftPTS returns the PTS shifted after the last PTS written by previous publishers.
+func (s *Stream) shiftPTS(pts time.Duration) time.Duration {
+	s.ptsMutex.Lock()
+	defer s.ptsMutex.Unlock()
+
+	if s.ptsOffsetPending {
+		s.ptsOffsetPending = false
+		s.ptsOffset = s.lastPTS + resumePTSGap - pts
+	}
+
+	pts += s.ptsOffset
+
+	if pts > s.lastPTS {
+		s.lastPTS = pts
+	}
+
+	return pts
+}
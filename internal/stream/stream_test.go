@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestPauseReader(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			PacketizationMode: 1,
+		}},
+	}}}
+
+	strm, err := New(1460, desc, true, formatprocessor.Options{}, nilLogger{})
+	require.NoError(t, err)
+	defer strm.Close()
+
+	received := make(chan time.Duration, 64)
+
+	w := asyncwriter.New(512, nilLogger{})
+	strm.AddReader(w, desc.Medias[0], desc.Medias[0].Formats[0], func(u unit.Unit) error {
+		received <- u.GetPTS()
+		return nil
+	})
+
+	w.Start()
+	defer w.Stop()
+
+	writeVideo := func(pts time.Duration, idr bool) {
+		typ := byte(1)
+		if idr {
+			typ = 5
+		}
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{PTS: pts},
+			AU:   [][]byte{{typ, 1}},
+		})
+	}
+
+	writeVideo(0, true)
+	require.Equal(t, time.Duration(0), <-received)
+
+	strm.PauseReader(w)
+
+	writeVideo(1*time.Second, true)
+	writeVideo(2*time.Second, false)
+
+	strm.ResumeReader(w)
+
+	// delivery restarts from the next random access unit
+	writeVideo(3*time.Second, false)
+	writeVideo(4*time.Second, true)
+	writeVideo(5*time.Second, false)
+
+	require.Equal(t, 4*time.Second, <-received)
+	require.Equal(t, 5*time.Second, <-received)
+
+	select {
+	case <-received:
+		t.Errorf("unexpected unit")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestConvertedFormat(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeAudio,
+		Formats: []format.Format{&format.LPCM{
+			PayloadTyp:   96,
+			BitDepth:     24,
+			SampleRate:   48000,
+			ChannelCount: 1,
+		}},
+	}}}
+
+	strm, err := New(1460, desc, false, formatprocessor.Options{LPCMBitDepth: 16}, nilLogger{})
+	require.NoError(t, err)
+	defer strm.Close()
+
+	// the description of the publisher is left untouched.
+	require.Equal(t, 24, desc.Medias[0].Formats[0].(*format.LPCM).BitDepth)
+
+	readerMedia := strm.Desc().Medias[0]
+	require.NotSame(t, desc.Medias[0], readerMedia)
+	require.Equal(t, 16, readerMedia.Formats[0].(*format.LPCM).BitDepth)
+
+	received := make(chan []byte, 1)
+
+	w := asyncwriter.New(512, nilLogger{})
+	strm.AddReader(w, readerMedia, readerMedia.Formats[0], func(u unit.Unit) error {
+		received <- u.(*unit.LPCM).Samples
+		return nil
+	})
+
+	w.Start()
+	defer w.Stop()
+
+	strm.WriteRTPPacket(desc.Medias[0], desc.Medias[0].Formats[0], &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 123,
+			Timestamp:      45343,
+			SSRC:           563423,
+		},
+		Payload: []byte{1, 2, 3, 4, 5, 6},
+	}, time.Time{}, 0)
+
+	require.Equal(t, []byte{1, 2, 4, 5}, <-received)
+}
@@ -0,0 +1,122 @@
+// Package subtitles contains utilities to handle timed text.
+package subtitles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cue is a timed text cue.
+type Cue struct {
+	Start time.Time
+	End   time.Time
+	Text  string
+}
+
+func parseTimestamp(v string) (time.Duration, error) {
+	v = strings.ReplaceAll(strings.TrimSpace(v), ",", ".")
+
+	// remove cue settings
+	if i := strings.IndexByte(v, ' '); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, fmt.Errorf("invalid timestamp '%s'", v)
+	}
+
+	var hours int64
+	if len(parts) == 3 {
+		var err error
+		hours, err = strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp '%s'", v)
+		}
+		parts = parts[1:]
+	}
+
+	minutes, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp '%s'", v)
+	}
+
+	seconds, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp '%s'", v)
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), nil
+}
+
+func formatTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		ms/3600000,
+		(ms/60000)%60,
+		(ms/1000)%60,
+		ms%1000)
+}
+
+// Parse parses cues in the SubRip or WebVTT format.
+// Timestamps are relative to base.
+func Parse(buf []byte, base time.Time) ([]Cue, error) {
+	text := strings.ReplaceAll(string(buf), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	var cues []Cue
+
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		i := 0
+		for i < len(lines) && !strings.Contains(lines[i], "-->") {
+			i++
+		}
+		if i == len(lines) {
+			// header, identifier-only block, NOTE, STYLE
+			continue
+		}
+
+		timing := strings.SplitN(lines[i], "-->", 2)
+
+		start, err := parseTimestamp(timing[0])
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseTimestamp(timing[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if end <= start {
+			return nil, fmt.Errorf("cue ends before it starts")
+		}
+
+		cues = append(cues, Cue{
+			Start: base.Add(start),
+			End:   base.Add(end),
+			Text:  strings.Join(lines[i+1:], "\n"),
+		})
+	}
+
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("no cues found")
+	}
+
+	return cues, nil
+}
+
+func marshalCue(c Cue, base time.Time) string {
+	return formatTimestamp(c.Start.Sub(base)) + " --> " + formatTimestamp(c.End.Sub(base)) + "\n" +
+		c.Text + "\n\n"
+}
@@ -0,0 +1,103 @@
+package subtitles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	base := time.Date(2010, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, ca := range []struct {
+		name string
+		buf  string
+	}{
+		{
+			"srt",
+			"1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\nworld\r\n\r\n" +
+				"2\r\n00:00:03,000 --> 00:00:04,000\r\nSecond\r\n",
+		},
+		{
+			"webvtt",
+			"WEBVTT\n\nNOTE a comment\n\n00:01.000 --> 00:02.500 align:start\nHello\nworld\n\n" +
+				"cue2\n00:00:03.000 --> 00:00:04.000\nSecond\n",
+		},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			cues, err := Parse([]byte(ca.buf), base)
+			require.NoError(t, err)
+			require.Equal(t, []Cue{
+				{
+					Start: base.Add(1 * time.Second),
+					End:   base.Add(2500 * time.Millisecond),
+					Text:  "Hello\nworld",
+				},
+				{
+					Start: base.Add(3 * time.Second),
+					End:   base.Add(4 * time.Second),
+					Text:  "Second",
+				},
+			}, cues)
+		})
+	}
+
+	_, err := Parse([]byte("WEBVTT\n\n"), base)
+	require.Error(t, err)
+}
+
+func TestTrack(t *testing.T) {
+	now := time.Date(2010, 1, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	dir, err := os.MkdirTemp("", "mediamtx-subtitles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tr := &Track{
+		SegmentDuration: 2 * time.Second,
+		SegmentCount:    3,
+	}
+	tr.Initialize()
+
+	tr.SetRecordingFile(filepath.Join(dir, "rec.vtt"), now)
+
+	err = tr.Add([]Cue{{
+		Start: now.Add(1 * time.Second),
+		End:   now.Add(3 * time.Second),
+		Text:  "Hello",
+	}})
+	require.NoError(t, err)
+
+	now = now.Add(5 * time.Second)
+
+	buf, err := tr.Playlist()
+	require.NoError(t, err)
+	require.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:3\n"+
+		"#EXT-X-TARGETDURATION:2\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-PROGRAM-DATE-TIME:2010-01-01T10:00:00Z\n"+
+		"#EXTINF:2.00000,\n"+
+		"subtitles_0.vtt\n"+
+		"#EXT-X-PROGRAM-DATE-TIME:2010-01-01T10:00:02Z\n"+
+		"#EXTINF:2.00000,\n"+
+		"subtitles_1.vtt\n", string(buf))
+
+	for _, n := range []int{0, 1} {
+		buf, err = tr.Segment(n)
+		require.NoError(t, err)
+		require.Equal(t, "WEBVTT\n\n00:00:01.000 --> 00:00:03.000\nHello\n\n", string(buf))
+	}
+
+	_, err = tr.Segment(2)
+	require.Error(t, err)
+
+	buf, err = os.ReadFile(filepath.Join(dir, "rec.vtt"))
+	require.NoError(t, err)
+	require.Equal(t, "WEBVTT\n\n00:00:01.000 --> 00:00:03.000\nHello\n\n", string(buf))
+}
@@ -0,0 +1,161 @@
+package subtitles
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gohlslib/pkg/playlist"
+)
+
+// ErrDisabled is returned when subtitles are disabled.
+var ErrDisabled = errors.New("subtitles are disabled on this path")
+
+var timeNow = time.Now
+
+// Track is a live timed text track.
+// It generates a HLS WebVTT rendition and writes cues next to recordings.
+type Track struct {
+	SegmentDuration time.Duration
+	SegmentCount    int
+
+	mutex sync.Mutex
+	start time.Time
+	cues  []Cue
+
+	recordingFile  string
+	recordingStart time.Time
+}
+
+// Initialize initializes Track.
+func (t *Track) Initialize() {
+	t.start = timeNow()
+}
+
+func (t *Track) currentSegment(now time.Time) int {
+	return int(now.Sub(t.start) / t.SegmentDuration)
+}
+
+func (t *Track) segmentStart(n int) time.Time {
+	return t.start.Add(time.Duration(n) * t.SegmentDuration)
+}
+
+// Add adds cues.
+func (t *Track) Add(cues []Cue) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// remove cues that are not part of the playlist anymore
+	oldest := t.segmentStart(t.currentSegment(timeNow()) - t.SegmentCount)
+	n := 0
+	for _, c := range t.cues {
+		if c.End.After(oldest) {
+			t.cues[n] = c
+			n++
+		}
+	}
+	t.cues = t.cues[:n]
+
+	t.cues = append(t.cues, cues...)
+
+	if t.recordingFile != "" {
+		return t.writeRecordingCues(cues)
+	}
+
+	return nil
+}
+
+func (t *Track) writeRecordingCues(cues []Cue) error {
+	f, err := os.OpenFile(t.recordingFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+
+	if fi.Size() == 0 {
+		buf.WriteString("WEBVTT\n\n")
+	}
+
+	for _, c := range cues {
+		buf.WriteString(marshalCue(c, t.recordingStart))
+	}
+
+	_, err = f.WriteString(buf.String())
+	return err
+}
+
+// SetRecordingFile sets the file where cues are written.
+// Timestamps are relative to start. An empty path stops writing.
+func (t *Track) SetRecordingFile(fpath string, start time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.recordingFile = fpath
+	t.recordingStart = start
+}
+
+// Playlist returns the media playlist.
+func (t *Track) Playlist() ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cur := t.currentSegment(timeNow())
+	first := cur - t.SegmentCount
+	if first < 0 {
+		first = 0
+	}
+
+	pl := &playlist.Media{
+		Version:        3,
+		TargetDuration: int(math.Ceil(t.SegmentDuration.Seconds())),
+		MediaSequence:  first,
+	}
+
+	for n := first; n < cur; n++ {
+		start := t.segmentStart(n)
+		pl.Segments = append(pl.Segments, &playlist.MediaSegment{
+			DateTime: &start,
+			Duration: t.SegmentDuration,
+			URI:      "subtitles_" + strconv.FormatInt(int64(n), 10) + ".vtt",
+		})
+	}
+
+	return pl.Marshal()
+}
+
+// Segment returns a WebVTT segment.
+func (t *Track) Segment(n int) ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cur := t.currentSegment(timeNow())
+	if n < 0 || n >= cur || n < (cur-t.SegmentCount) {
+		return nil, fmt.Errorf("segment not found")
+	}
+
+	segStart := t.segmentStart(n)
+	segEnd := t.segmentStart(n + 1)
+
+	var buf strings.Builder
+	buf.WriteString("WEBVTT\n\n")
+
+	for _, c := range t.cues {
+		if c.Start.Before(segEnd) && c.End.After(segStart) {
+			buf.WriteString(marshalCue(c, t.start))
+		}
+	}
+
+	return []byte(buf.String()), nil
+}
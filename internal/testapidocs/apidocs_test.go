@@ -36,6 +36,22 @@ func TestAPIDocs(t *testing.T) {
 			"AuthInternalUserPermission",
 			conf.AuthInternalUserPermission{},
 		},
+		{
+			"ConfigChange",
+			defs.APIConfigChange{},
+		},
+		{
+			"ConfigDryRun",
+			defs.APIConfigDryRun{},
+		},
+		{
+			"HTTPResponseHeader",
+			conf.HTTPResponseHeader{},
+		},
+		{
+			"TLSOptions",
+			conf.TLSOptions{},
+		},
 		{
 			"GlobalConf",
 			conf.Conf{},
@@ -60,6 +76,50 @@ func TestAPIDocs(t *testing.T) {
 			"PathSource",
 			defs.APIPathSourceOrReader{},
 		},
+		{
+			"PathSourceRetry",
+			defs.APIPathSourceRetry{},
+		},
+		{
+			"PathAudioLevel",
+			defs.APIPathAudioLevel{},
+		},
+		{
+			"PathVideoStatus",
+			defs.APIPathVideoStatus{},
+		},
+		{
+			"PathGOPStatus",
+			defs.APIPathGOPStatus{},
+		},
+		{
+			"PathTrack",
+			defs.APIPathTrack{},
+		},
+		{
+			"PathTrackList",
+			defs.APIPathTrackList{},
+		},
+		{
+			"SubtitleCue",
+			defs.APISubtitleCue{},
+		},
+		{
+			"SubtitleCueList",
+			defs.APISubtitleCueList{},
+		},
+		{
+			"PathDrainRequest",
+			defs.APIPathDrainReq{},
+		},
+		{
+			"PathPTZ",
+			defs.APIPathPTZ{},
+		},
+		{
+			"PathPTZGotoPresetRequest",
+			defs.APIPathPTZGotoPresetReq{},
+		},
 		{
 			"PathReader",
 			defs.APIPathSourceOrReader{},
@@ -72,6 +132,22 @@ func TestAPIDocs(t *testing.T) {
 			"HLSMuxerList",
 			defs.APIHLSMuxerList{},
 		},
+		{
+			"ClusterPath",
+			defs.APIClusterPath{},
+		},
+		{
+			"ClusterPathList",
+			defs.APIClusterPathList{},
+		},
+		{
+			"BandwidthUser",
+			defs.APIBandwidthUser{},
+		},
+		{
+			"BandwidthUserList",
+			defs.APIBandwidthUserList{},
+		},
 		{
 			"Recording",
 			defs.APIRecording{},
@@ -84,6 +160,10 @@ func TestAPIDocs(t *testing.T) {
 			"RecordingSegment",
 			defs.APIRecordingSegment{},
 		},
+		{
+			"SignedURL",
+			defs.APISignedURL{},
+		},
 		{
 			"RTMPConn",
 			defs.APIRTMPConn{},
@@ -104,10 +184,18 @@ func TestAPIDocs(t *testing.T) {
 			"RTSPSession",
 			defs.APIRTSPSession{},
 		},
+		{
+			"RTSPSessionMedia",
+			defs.APIRTSPSessionMedia{},
+		},
 		{
 			"RTSPSessionList",
 			defs.APIRTSPSessionList{},
 		},
+		{
+			"RTSPTransportStats",
+			defs.APIRTSPTransportStats{},
+		},
 		{
 			"SRTConn",
 			defs.APISRTConn{},
@@ -27,3 +27,13 @@ func (u *Base) GetNTP() time.Time {
 func (u *Base) GetPTS() time.Duration {
 	return u.PTS
 }
+
+// SetPTS sets the PTS of the unit.
+func (u *Base) SetPTS(pts time.Duration) {
+	u.PTS = pts
+}
+
+// SetNTP sets the NTP timestamp of the unit.
+func (u *Base) SetNTP(ntp time.Time) {
+	u.NTP = ntp
+}
@@ -0,0 +1,432 @@
+// Package videomonitor contains a detector of frozen and black video.
+package videomonitor
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+
+	"github.com/bluenviron/mediamtx/internal/asyncwriter"
+	"github.com/bluenviron/mediamtx/internal/logger"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+const (
+	// a H264 or H265 keyframe whose size is below this amount of bytes per pixel
+	// is considered uniform, therefore black.
+	blackBytesPerPixel = 0.002
+
+	// a M-JPEG frame whose average luma is below this value is considered black.
+	blackLuma = 24
+
+	// M-JPEG frames are decoded at most once in this period.
+	mjpegDecodePeriod = 1 * time.Second
+
+	// slices are compared without this amount of leading bytes,
+	// that contain the NALU header and the slice header. Slice headers
+	// of identical pictures differ, since they contain fields like idr_pic_id
+	// and pic_order_cnt_lsb.
+	maxSliceHeaderSize = 64
+)
+
+// alignSlice returns the RBSP of a slice NALU without trailing bits,
+// right-aligned in order to allow to compare the slice data of two slices
+// regardless of the bit length of their headers.
+func alignSlice(nalu []byte) []byte {
+	buf := h264.EmulationPreventionRemove(nalu)
+
+	// remove cabac_zero_words
+	for len(buf) != 0 && buf[len(buf)-1] == 0 {
+		buf = buf[:len(buf)-1]
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+
+	// remove rbsp_stop_one_bit and rbsp_alignment_zero_bits
+	shift := bits.TrailingZeros8(buf[len(buf)-1]) + 1
+
+	out := make([]byte, len(buf))
+	prev := byte(0)
+	for i, b := range buf {
+		out[i] = byte((uint16(prev)<<8 | uint16(b)) >> shift)
+		prev = b
+	}
+
+	return out
+}
+
+// sameSlice checks whether two aligned slices contain the same slice data.
+func sameSlice(a []byte, b []byte) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	if (len(b) - len(a)) > maxSliceHeaderSize {
+		return false
+	}
+
+	n := len(a) - maxSliceHeaderSize
+	if n <= 0 {
+		return bytes.Equal(a, b)
+	}
+
+	return bytes.Equal(a[len(a)-n:], b[len(b)-n:])
+}
+
+func samePicture(a [][]byte, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !sameSlice(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func h264Picture(au [][]byte) [][]byte {
+	var ret [][]byte
+	for _, nalu := range au {
+		typ := h264.NALUType(nalu[0] & 0x1F)
+		if typ >= h264.NALUTypeNonIDR && typ <= h264.NALUTypeIDR {
+			ret = append(ret, alignSlice(nalu))
+		}
+	}
+	return ret
+}
+
+func h265Picture(au [][]byte) [][]byte {
+	var ret [][]byte
+	for _, nalu := range au {
+		typ := h265.NALUType((nalu[0] >> 1) & 0b111111)
+		if typ < h265.NALUType_VPS_NUT {
+			ret = append(ret, alignSlice(nalu))
+		}
+	}
+	return ret
+}
+
+func auSize(au [][]byte) int {
+	n := 0
+	for _, nalu := range au {
+		n += len(nalu)
+	}
+	return n
+}
+
+func averageLuma(img image.Image) float64 {
+	if ycbcr, ok := img.(*image.YCbCr); ok {
+		var sum uint64
+		b := ycbcr.Rect
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := ycbcr.Y[(y-b.Min.Y)*ycbcr.YStride : (y-b.Min.Y)*ycbcr.YStride+b.Dx()]
+			for _, v := range row {
+				sum += uint64(v)
+			}
+		}
+		return float64(sum) / float64(b.Dx()*b.Dy())
+	}
+
+	if gray, ok := img.(*image.Gray); ok {
+		var sum uint64
+		for _, v := range gray.Pix {
+			sum += uint64(v)
+		}
+		return float64(sum) / float64(len(gray.Pix))
+	}
+
+	return 255
+}
+
+func sameFrame(a [][]byte, b [][]byte) bool {
+	return bytes.Equal(a[0], b[0])
+}
+
+type track struct {
+	index   int
+	pixels  int
+	compare func([][]byte, [][]byte) bool
+
+	lastPicture  [][]byte
+	pictureSince time.Duration
+
+	blackActive bool
+	blackSince  time.Duration
+
+	lastDecode *time.Duration
+
+	mutex  sync.RWMutex
+	frozen bool
+	black  bool
+}
+
+func (t *track) setPixelsFromH264SPS(buf []byte) {
+	var sps h264.SPS
+	if err := sps.Unmarshal(buf); err == nil {
+		t.pixels = sps.Width() * sps.Height()
+	}
+}
+
+func (t *track) setPixelsFromH265SPS(buf []byte) {
+	var sps h265.SPS
+	if err := sps.Unmarshal(buf); err == nil {
+		t.pixels = sps.Width() * sps.Height()
+	}
+}
+
+// Status is the status of the video of a stream.
+type Status struct {
+	Frozen bool
+	Black  bool
+}
+
+// Monitor detects whether the video of a stream is frozen or black,
+// without decoding it.
+// Video is considered frozen when keyframes contain the same slice data
+// (slice headers are ignored), and black when keyframes are uniform.
+type Monitor struct {
+	WriteQueueSize int
+	Stream         *stream.Stream
+	Duration       time.Duration
+	OnFrozen       func()
+	OnBlack        func()
+	Parent         logger.Writer
+
+	writer *asyncwriter.Writer
+	tracks []*track
+
+	terminate chan struct{}
+	done      chan struct{}
+}
+
+// Initialize initializes Monitor.
+func (m *Monitor) Initialize() {
+	if m.OnFrozen == nil {
+		m.OnFrozen = func() {}
+	}
+	if m.OnBlack == nil {
+		m.OnBlack = func() {}
+	}
+
+	m.terminate = make(chan struct{})
+	m.done = make(chan struct{})
+
+	m.writer = asyncwriter.New(m.WriteQueueSize, m)
+
+	index := 0
+
+	for _, media := range m.Stream.Desc().Medias {
+		for _, forma := range media.Formats {
+			m.setupFormat(media, forma, index)
+			index++
+		}
+	}
+
+	if len(m.tracks) == 0 {
+		m.Log(logger.Warn, "stream doesn't contain any track that can be monitored (supported are H264, H265, M-JPEG)")
+	} else {
+		m.Log(logger.Info, "monitoring %d %s",
+			len(m.tracks),
+			func() string {
+				if len(m.tracks) == 1 {
+					return "track"
+				}
+				return "tracks"
+			}())
+	}
+
+	go m.run()
+}
+
+func (m *Monitor) setupFormat(media *description.Media, forma format.Format, index int) {
+	switch forma := forma.(type) {
+	case *format.H264:
+		t := &track{index: index, compare: samePicture}
+		m.tracks = append(m.tracks, t)
+
+		if sps, _ := forma.SafeParams(); sps != nil {
+			t.setPixelsFromH264SPS(sps)
+		}
+
+		m.Stream.AddReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.H264)
+
+			if tunit.AU == nil || !h264.IDRPresent(tunit.AU) {
+				return nil
+			}
+
+			for _, nalu := range tunit.AU {
+				if h264.NALUType(nalu[0]&0x1F) == h264.NALUTypeSPS {
+					t.setPixelsFromH264SPS(nalu)
+				}
+			}
+
+			black := t.pixels != 0 && float64(auSize(tunit.AU))/float64(t.pixels) < blackBytesPerPixel
+			m.processKeyframe(t, tunit.PTS, h264Picture(tunit.AU), black)
+			return nil
+		})
+
+	case *format.H265:
+		t := &track{index: index, compare: samePicture}
+		m.tracks = append(m.tracks, t)
+
+		if _, sps, _ := forma.SafeParams(); sps != nil {
+			t.setPixelsFromH265SPS(sps)
+		}
+
+		m.Stream.AddReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.H265)
+
+			if tunit.AU == nil || !h265.IsRandomAccess(tunit.AU) {
+				return nil
+			}
+
+			for _, nalu := range tunit.AU {
+				if h265.NALUType((nalu[0]>>1)&0b111111) == h265.NALUType_SPS_NUT {
+					t.setPixelsFromH265SPS(nalu)
+				}
+			}
+
+			black := t.pixels != 0 && float64(auSize(tunit.AU))/float64(t.pixels) < blackBytesPerPixel
+			m.processKeyframe(t, tunit.PTS, h265Picture(tunit.AU), black)
+			return nil
+		})
+
+	case *format.MJPEG:
+		t := &track{index: index, compare: sameFrame}
+		m.tracks = append(m.tracks, t)
+
+		m.Stream.AddReader(m.writer, media, forma, func(u unit.Unit) error {
+			tunit := u.(*unit.MJPEG)
+
+			if tunit.Frame == nil {
+				return nil
+			}
+
+			black := t.blackActive
+
+			if t.lastDecode == nil || (tunit.PTS-*t.lastDecode) >= mjpegDecodePeriod {
+				pts := tunit.PTS
+				t.lastDecode = &pts
+
+				img, err := jpeg.Decode(bytes.NewReader(tunit.Frame))
+				if err == nil {
+					black = averageLuma(img) < blackLuma
+				}
+			}
+
+			m.processKeyframe(t, tunit.PTS, [][]byte{tunit.Frame}, black)
+			return nil
+		})
+	}
+}
+
+// Close closes Monitor.
+func (m *Monitor) Close() {
+	close(m.terminate)
+	<-m.done
+}
+
+// Log implements logger.Writer.
+func (m *Monitor) Log(level logger.Level, format string, args ...interface{}) {
+	m.Parent.Log(level, "[video monitor] "+format, args...)
+}
+
+func (m *Monitor) run() {
+	defer close(m.done)
+
+	m.writer.Start()
+
+	select {
+	case err := <-m.writer.Error():
+		m.Log(logger.Error, err.Error())
+		m.Stream.RemoveReader(m.writer)
+
+	case <-m.terminate:
+		m.Stream.RemoveReader(m.writer)
+		m.writer.Stop()
+	}
+}
+
+func (m *Monitor) processKeyframe(t *track, pts time.Duration, picture [][]byte, black bool) {
+	t.mutex.RLock()
+	frozen := t.frozen
+	isBlack := t.black
+	t.mutex.RUnlock()
+
+	if t.lastPicture != nil && t.compare(picture, t.lastPicture) {
+		if !frozen && (pts-t.pictureSince) >= m.Duration {
+			m.setFrozen(t, true)
+			m.Log(logger.Warn, "video of track %d is frozen", t.index)
+			m.OnFrozen()
+		}
+	} else {
+		t.lastPicture = picture
+		t.pictureSince = pts
+
+		if frozen {
+			m.setFrozen(t, false)
+			m.Log(logger.Info, "video of track %d is not frozen anymore", t.index)
+		}
+	}
+
+	if black {
+		if !t.blackActive {
+			t.blackActive = true
+			t.blackSince = pts
+		}
+
+		if !isBlack && (pts-t.blackSince) >= m.Duration {
+			m.setBlack(t, true)
+			m.Log(logger.Warn, "video of track %d is black", t.index)
+			m.OnBlack()
+		}
+	} else {
+		t.blackActive = false
+
+		if isBlack {
+			m.setBlack(t, false)
+			m.Log(logger.Info, "video of track %d is not black anymore", t.index)
+		}
+	}
+}
+
+func (m *Monitor) setFrozen(t *track, v bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.frozen = v
+}
+
+func (m *Monitor) setBlack(t *track, v bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.black = v
+}
+
+// Status returns the status of the video.
+// Video is frozen or black when at least one track is.
+func (m *Monitor) Status() Status {
+	var s Status
+
+	for _, t := range m.tracks {
+		t.mutex.RLock()
+		s.Frozen = s.Frozen || t.frozen
+		s.Black = s.Black || t.black
+		t.mutex.RUnlock()
+	}
+
+	return s
+}
@@ -0,0 +1,272 @@
+package videomonitor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/bits"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	mbits "github.com/bluenviron/mediacommon/pkg/bits"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bluenviron/mediamtx/internal/formatprocessor"
+	"github.com/bluenviron/mediamtx/internal/stream"
+	"github.com/bluenviron/mediamtx/internal/test"
+	"github.com/bluenviron/mediamtx/internal/unit"
+)
+
+func TestMonitorH264(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			SPS:               test.FormatH264.SPS,
+			PPS:               test.FormatH264.PPS,
+			PacketizationMode: 1,
+		}},
+	}}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	frozen := make(chan struct{}, 1)
+	black := make(chan struct{}, 1)
+
+	m := &Monitor{
+		WriteQueueSize: 512,
+		Stream:         strm,
+		Duration:       2 * time.Second,
+		OnFrozen: func() {
+			frozen <- struct{}{}
+		},
+		OnBlack: func() {
+			black <- struct{}{}
+		},
+		Parent: test.NilLogger,
+	}
+	m.Initialize()
+	defer m.Close()
+
+	// large and different keyframes
+	for i := 0; i < 5; i++ {
+		idr := make([]byte, 100000)
+		idr[0] = 0x65
+		rand.Read(idr[1:])
+
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * time.Second,
+			},
+			AU: [][]byte{idr},
+		})
+	}
+
+	// small and identical keyframes
+	for i := 5; i < 10; i++ {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * time.Second,
+			},
+			AU: [][]byte{{0x65, 0x01, 0x02, 0x03}},
+		})
+	}
+
+	for _, ch := range []chan struct{}{frozen, black} {
+		select {
+		case <-ch:
+		case <-time.After(2 * time.Second):
+			t.Error("event not received")
+		}
+	}
+
+	require.Equal(t, Status{Frozen: true, Black: true}, m.Status())
+}
+
+func writeGolombUnsigned(buf []byte, pos *int, v uint32) {
+	n := bits.Len32(v + 1)
+	mbits.WriteBitsUnsafe(buf, pos, 0, n-1)
+	mbits.WriteBitsUnsafe(buf, pos, uint64(v+1), n)
+}
+
+// idrSlice generates a H264 IDR slice with the given idr_pic_id and slice data.
+func idrSlice(idrPicID uint32, data []byte) []byte {
+	buf := make([]byte, 16+len(data))
+	buf[0] = 0x65
+	pos := 8
+
+	writeGolombUnsigned(buf, &pos, 0)        // first_mb_in_slice
+	writeGolombUnsigned(buf, &pos, 7)        // slice_type
+	writeGolombUnsigned(buf, &pos, 0)        // pic_parameter_set_id
+	mbits.WriteBitsUnsafe(buf, &pos, 0, 4)   // frame_num
+	writeGolombUnsigned(buf, &pos, idrPicID) // idr_pic_id
+	mbits.WriteBitsUnsafe(buf, &pos, 0, 4)   // pic_order_cnt_lsb
+
+	for _, b := range data {
+		mbits.WriteBitsUnsafe(buf, &pos, uint64(b), 8)
+	}
+
+	// rbsp_stop_one_bit
+	mbits.WriteBitsUnsafe(buf, &pos, 1, 1)
+
+	return buf[:(pos+7)/8]
+}
+
+func TestMonitorH264IDRPicID(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type: description.MediaTypeVideo,
+		Formats: []format.Format{&format.H264{
+			PayloadTyp:        96,
+			SPS:               test.FormatH264.SPS,
+			PPS:               test.FormatH264.PPS,
+			PacketizationMode: 1,
+		}},
+	}}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	frozen := make(chan struct{}, 1)
+
+	m := &Monitor{
+		WriteQueueSize: 512,
+		Stream:         strm,
+		Duration:       2 * time.Second,
+		OnFrozen: func() {
+			frozen <- struct{}{}
+		},
+		Parent: test.NilLogger,
+	}
+	m.Initialize()
+	defer m.Close()
+
+	// bytes with less than 8 consecutive zero bits, in order not to require emulation prevention.
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = 0x10 + byte(rand.Intn(0xF0))
+	}
+
+	// identical pictures, whose slice headers contain different idr_pic_id values.
+	for i := 0; i < 5; i++ {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+			Base: unit.Base{
+				PTS: time.Duration(i) * time.Second,
+			},
+			AU: [][]byte{idrSlice(uint32(i%2), data)},
+		})
+	}
+
+	select {
+	case <-frozen:
+	case <-time.After(2 * time.Second):
+		t.Error("event not received")
+	}
+
+	require.Equal(t, Status{Frozen: true}, m.Status())
+
+	// a different picture
+	data[len(data)/2]++
+
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.H264{
+		Base: unit.Base{
+			PTS: 5 * time.Second,
+		},
+		AU: [][]byte{idrSlice(0, data)},
+	})
+
+	require.Eventually(t, func() bool {
+		return m.Status() == Status{}
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestMonitorMJPEG(t *testing.T) {
+	desc := &description.Session{Medias: []*description.Media{{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{&format.MJPEG{}},
+	}}}
+
+	strm, err := stream.New(
+		1460,
+		desc,
+		true,
+		formatprocessor.Options{},
+		test.NilLogger,
+	)
+	require.NoError(t, err)
+	defer strm.Close()
+
+	black := make(chan struct{}, 1)
+
+	m := &Monitor{
+		WriteQueueSize: 512,
+		Stream:         strm,
+		Duration:       2 * time.Second,
+		OnBlack: func() {
+			black <- struct{}{}
+		},
+		Parent: test.NilLogger,
+	}
+	m.Initialize()
+	defer m.Close()
+
+	encode := func(c color.Color) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				img.Set(x, y, c)
+			}
+		}
+
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, img, nil)
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+
+	for i := 0; i < 5; i++ {
+		strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.MJPEG{
+			Base: unit.Base{
+				PTS: time.Duration(i) * time.Second,
+			},
+			Frame: encode(color.RGBA{0, 0, 0, 255}),
+		})
+	}
+
+	select {
+	case <-black:
+	case <-time.After(2 * time.Second):
+		t.Error("event not received")
+	}
+
+	require.Equal(t, Status{Frozen: true, Black: true}, m.Status())
+
+	strm.WriteUnit(desc.Medias[0], desc.Medias[0].Formats[0], &unit.MJPEG{
+		Base: unit.Base{
+			PTS: 5 * time.Second,
+		},
+		Frame: encode(color.RGBA{255, 255, 255, 255}),
+	})
+
+	require.Eventually(t, func() bool {
+		return m.Status() == Status{}
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,188 @@
+// Package watermark contains a watermark that can be burned into video frames.
+package watermark
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bluenviron/mediamtx/internal/recordstore"
+)
+
+const (
+	jpegQuality = 90
+)
+
+var (
+	textColor       = color.RGBA{255, 255, 255, 255}
+	backgroundColor = color.RGBA{0, 0, 0, 128}
+)
+
+// Watermark is a static image and / or a text that is burned into video frames.
+type Watermark struct {
+	// image to overlay. It can be nil.
+	Image image.Image
+
+	// text to overlay. It can contain %Y %m %d %H %M %S %f %s (time in strftime format)
+	// and %path (path name).
+	Text string
+
+	// one of topLeft, topRight, bottomLeft, bottomRight.
+	Position string
+
+	// path name.
+	PathName string
+}
+
+// Load loads a watermark.
+func Load(imagePath string, text string, position string, pathName string) (*Watermark, error) {
+	w := &Watermark{
+		Text:     text,
+		Position: position,
+		PathName: pathName,
+	}
+
+	if imagePath != "" {
+		f, err := os.Open(imagePath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		w.Image, err = png.Decode(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode watermark image: %w", err)
+		}
+	}
+
+	return w, nil
+}
+
+func textSize(text string, scale int) (int, int) {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0, 0
+	}
+	return (n*(glyphWidth+1) - 1) * scale, glyphHeight * scale
+}
+
+func drawText(dst draw.Image, text string, x int, y int, scale int) {
+	src := image.NewUniform(textColor)
+
+	for _, r := range text {
+		g, ok := glyphs[unicode.ToUpper(r)]
+		if ok {
+			for row := 0; row < glyphHeight; row++ {
+				for col := 0; col < glyphWidth; col++ {
+					if (g[row]>>(glyphWidth-1-col))&1 != 0 {
+						rect := image.Rect(
+							x+col*scale,
+							y+row*scale,
+							x+(col+1)*scale,
+							y+(row+1)*scale)
+						draw.Draw(dst, rect, src, image.Point{}, draw.Src)
+					}
+				}
+			}
+		}
+
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+// Apply burns the watermark into an image.
+func (w *Watermark) Apply(dst draw.Image, t time.Time) {
+	bounds := dst.Bounds()
+
+	scale := bounds.Dy() / 180
+	if scale < 1 {
+		scale = 1
+	}
+	margin := 4 * scale
+
+	text := ""
+	if w.Text != "" {
+		text = recordstore.Path{
+			Start: t,
+			Path:  w.PathName,
+		}.Encode(w.Text)
+	}
+
+	textW, textH := textSize(text, scale)
+
+	var imgW, imgH int
+	if w.Image != nil {
+		imgW = w.Image.Bounds().Dx()
+		imgH = w.Image.Bounds().Dy()
+	}
+
+	blockW := max(imgW, textW+2*scale)
+	blockH := imgH
+	if textH != 0 {
+		if blockH != 0 {
+			blockH += margin
+		}
+		blockH += textH + 2*scale
+	}
+
+	right := strings.HasSuffix(w.Position, "Right")
+	bottom := strings.HasPrefix(w.Position, "bottom")
+
+	x := bounds.Min.X + margin
+	if right {
+		x = bounds.Max.X - margin - blockW
+	}
+	y := bounds.Min.Y + margin
+	if bottom {
+		y = bounds.Max.Y - margin - blockH
+	}
+
+	alignX := func(width int) int {
+		if right {
+			return x + blockW - width
+		}
+		return x
+	}
+
+	if w.Image != nil {
+		rect := image.Rect(alignX(imgW), y, alignX(imgW)+imgW, y+imgH)
+		draw.Draw(dst, rect, w.Image, w.Image.Bounds().Min, draw.Over)
+		y += imgH + margin
+	}
+
+	if textH != 0 {
+		bx := alignX(textW + 2*scale)
+		rect := image.Rect(bx, y, bx+textW+2*scale, y+textH+2*scale)
+		draw.Draw(dst, rect, image.NewUniform(backgroundColor), image.Point{}, draw.Over)
+		drawText(dst, text, bx+scale, y+scale, scale)
+	}
+}
+
+// ApplyJPEG burns the watermark into a JPEG image.
+func (w *Watermark) ApplyJPEG(frame []byte, t time.Time) ([]byte, error) {
+	src, err := jpeg.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	w.Apply(dst, t)
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package watermark
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 320, 180))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(logo, logo.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+	w := &Watermark{
+		Image:    logo,
+		Text:     "%Y",
+		Position: "topLeft",
+	}
+	w.Apply(dst, time.Date(2008, 1, 1, 0, 0, 0, 0, time.Local))
+
+	// image
+	require.Equal(t, color.RGBA{255, 0, 0, 255}, dst.At(4, 4))
+
+	// first row of '2'
+	require.Equal(t, color.RGBA{255, 255, 255, 255}, dst.At(4+1+1, 4+10+4+1))
+	require.Equal(t, color.RGBA{0, 0, 0, 255}, dst.At(4+1+0, 4+10+4+1))
+
+	dst = image.NewRGBA(image.Rect(0, 0, 320, 180))
+	w.Text = ""
+	w.Position = "bottomRight"
+	w.Apply(dst, time.Now())
+
+	require.Equal(t, color.RGBA{255, 0, 0, 255}, dst.At(320-4-1, 180-4-1))
+}
+
+func TestApplyJPEG(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mediamtx-watermark")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logo := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(logo, logo.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	f, err := os.Create(filepath.Join(dir, "logo.png"))
+	require.NoError(t, err)
+	err = png.Encode(f, logo)
+	f.Close()
+	require.NoError(t, err)
+
+	w, err := Load(filepath.Join(dir, "logo.png"), "", "topLeft", "mypath")
+	require.NoError(t, err)
+
+	src := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, src, nil)
+	require.NoError(t, err)
+
+	frame, err := w.ApplyJPEG(buf.Bytes(), time.Now())
+	require.NoError(t, err)
+
+	img, err := jpeg.Decode(bytes.NewReader(frame))
+	require.NoError(t, err)
+
+	r, _, _, _ := img.At(10, 10).RGBA()
+	require.Greater(t, r, uint32(0xE000))
+
+	r, _, _, _ = img.At(40, 40).RGBA()
+	require.Less(t, r, uint32(0x2000))
+}